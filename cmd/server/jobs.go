@@ -0,0 +1,125 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bank-api/internal/config"
+	"github.com/bank-api/internal/jobs"
+	"github.com/bank-api/internal/ledger"
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/repository"
+	"github.com/bank-api/internal/services"
+	"github.com/bank-api/internal/statements"
+)
+
+// holdExpiryBatchSize is how many holds are expired per database transaction
+const holdExpiryBatchSize = 100
+
+// paymentDispatchBatchSize is how many outbound payments are submitted per
+// database transaction
+const paymentDispatchBatchSize = 50
+
+// statementBatchSize is how many accounts' monthly statements are generated
+// per batch
+const statementBatchSize = 20
+
+// standingOrderBatchSize is how many due standing orders are claimed per
+// database transaction
+const standingOrderBatchSize = 20
+
+// pendingBatchSize is how many pending transactions a worker processes before
+// checking for shutdown
+const pendingBatchSize = 10
+
+// interestBatchSize is how many accounts' interest is accrued, capitalized
+// or charged per batch
+const interestBatchSize = 100
+
+// newScheduler registers the server's background jobs
+func newScheduler(db *sql.DB, cfg *config.Config) (*jobs.Scheduler, error) {
+	accountRepo := repository.NewPostgresAccountRepository(db)
+	transactionRepo := repository.NewPostgresTransactionRepository(db)
+	generalLedger := ledger.NewPostgresLedger(db)
+	txRunner := repository.NewPostgresTxRunner(db)
+	holdService := services.NewHoldService(
+		repository.NewPostgresHoldRepository(db), accountRepo, transactionRepo,
+		generalLedger, txRunner, cfg.Holds.DefaultTTL, cfg.Holds.MaxTTL,
+	)
+
+	gateway, err := services.NewPaymentGateway(cfg.Clearing.Gateway, cfg.Clearing.OutboxDir)
+	if err != nil {
+		return nil, err
+	}
+	paymentRepo := repository.NewPostgresOutboundPaymentRepository(db)
+	clearingService := services.NewClearingService(
+		paymentRepo, transactionRepo, accountRepo,
+		generalLedger, txRunner, gateway,
+	)
+
+	bank, ok := models.LookupBankByCode(cfg.Bank.Code)
+	if !ok {
+		return nil, fmt.Errorf("unknown bank code: %s", cfg.Bank.Code)
+	}
+	var statementFont *statements.Font
+	if cfg.Statements.FontFile != "" {
+		if statementFont, err = statements.LoadFont(cfg.Statements.FontFile); err != nil {
+			return nil, err
+		}
+	}
+	statementService := services.NewStatementService(
+		accountRepo, repository.NewPostgresCustomerRepository(db), transactionRepo,
+		repository.NewPostgresStatementRepository(db), generalLedger, bank, statementFont,
+	)
+
+	rateProvider, err := services.NewFXRateProvider(cfg.FX.Provider, cfg.FX.RatesFile)
+	if err != nil {
+		return nil, err
+	}
+	fxQuoteRepo := repository.NewPostgresFXQuoteRepository(db)
+	fxService := services.NewFXService(rateProvider, fxQuoteRepo, cfg.FX.QuoteTTL, cfg.FX.BuySpreadBps, cfg.FX.SellSpreadBps)
+	transactionService := services.NewTransactionService(
+		transactionRepo, accountRepo, generalLedger, txRunner, fxService, fxQuoteRepo, paymentRepo,
+		repository.NewPostgresFeeScheduleRepository(db), repository.NewPostgresLimitRepository(db), bank,
+	)
+	standingOrderService := services.NewStandingOrderService(
+		repository.NewPostgresStandingOrderRepository(db), accountRepo, transactionRepo, transactionService,
+		txRunner, cfg.StandingOrders.MaxRetries, cfg.StandingOrders.RetryBackoff,
+	)
+
+	if !models.IsValidInterestCapitalization(cfg.Interest.Capitalization) {
+		return nil, fmt.Errorf("interest capitalization must be MONTHLY or QUARTERLY, got %s", cfg.Interest.Capitalization)
+	}
+	interestService := services.NewInterestService(
+		repository.NewPostgresInterestRepository(db), accountRepo, transactionRepo, generalLedger, txRunner,
+		cfg.Interest.Capitalization, cfg.Interest.WithholdingBps, cfg.Interest.DayCount,
+	)
+
+	overdraftService := services.NewOverdraftService(
+		repository.NewPostgresOverdraftRepository(db), accountRepo, transactionRepo, generalLedger, txRunner,
+		cfg.Overdraft.DefaultRateBps, cfg.Interest.DayCount,
+	)
+
+	scheduler := jobs.NewScheduler()
+	scheduler.Register("expire-holds", cfg.Holds.ExpiryInterval, jobs.ExpireHolds(holdService, holdExpiryBatchSize))
+	scheduler.Register("purge-idempotency-keys", time.Hour, jobs.PurgeIdempotencyKeys(repository.NewPostgresIdempotencyRepository(db)))
+	scheduler.Register("purge-auth-sessions", time.Hour, jobs.PurgeAuthSessions(
+		repository.NewPostgresSessionRepository(db), repository.NewPostgresMFARepository(db),
+		repository.NewPostgresPasswordRepository(db),
+	))
+	scheduler.Register("purge-login-history", 24*time.Hour, jobs.PurgeLoginHistory(
+		repository.NewPostgresLoginAttemptRepository(db), cfg.Login.HistoryRetention,
+	))
+	scheduler.Register("dispatch-outbound-payments", cfg.Clearing.DispatchInterval, jobs.DispatchOutboundPayments(clearingService, paymentDispatchBatchSize))
+	scheduler.Register("generate-monthly-statements", cfg.Statements.JobInterval, jobs.GenerateMonthlyStatements(statementService, statementBatchSize))
+	scheduler.Register("execute-standing-orders", cfg.StandingOrders.ExecutionInterval, jobs.ExecuteStandingOrders(standingOrderService, standingOrderBatchSize))
+	scheduler.Register("process-pending-transactions", cfg.Pending.Interval, jobs.ProcessPendingTransactions(
+		transactionService, cfg.Pending.Concurrency, pendingBatchSize,
+		services.RetryPolicy{MaxAttempts: cfg.Pending.MaxAttempts, Backoff: cfg.Pending.RetryBackoff},
+	))
+	scheduler.Register("accrue-interest", cfg.Interest.JobInterval, jobs.AccrueInterest(interestService, interestBatchSize))
+	scheduler.Register("charge-overdraft-interest", cfg.Overdraft.JobInterval, jobs.ChargeOverdraftInterest(overdraftService, interestBatchSize))
+
+	return scheduler, nil
+}
@@ -1,19 +1,28 @@
 package main
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/bank-api/internal/api/routes"
 	"github.com/bank-api/internal/config"
 	"github.com/bank-api/internal/repository"
+	"github.com/bank-api/internal/services"
 )
 
 func main() {
 	// Load configuration
 	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 	
 	// Initialize database
 	db, err := repository.NewPostgresDB(&cfg.Database)
@@ -22,13 +31,31 @@ func main() {
 	}
 	defer db.Close()
 	
-	// Initialize database tables
-	if err := repository.InitializeDatabase(db); err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+	// "bank-api migrate ..." manages the schema and exits
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(db, os.Args[2:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+	
+	// Apply pending schema migrations
+	if cfg.Database.AutoMigrate {
+		if err := repository.InitializeDatabase(db); err != nil {
+			log.Fatalf("Failed to initialize database: %v", err)
+		}
+	}
+	
+	// Create the first admin staff user if needed
+	if err := bootstrapAdmin(db, &cfg.Admin); err != nil {
+		log.Fatalf("Failed to bootstrap admin user: %v", err)
 	}
 	
 	// Setup routes
-	router := routes.NewRouter(db, cfg)
+	router, err := routes.NewRouter(db, cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize router: %v", err)
+	}
 	handler := router.SetupRoutes()
 	
 	// Create server
@@ -40,11 +67,62 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 	
+	// Stop on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	
+	// Start background jobs
+	scheduler, err := newScheduler(db, cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize background jobs: %v", err)
+	}
+	scheduler.Start(ctx)
+	
 	log.Printf("🏦 Bank API server starting on %s", server.Addr)
 	log.Printf("📊 Health check available at: http://%s/api/v1/health", server.Addr)
 	
 	// Start server
-	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+	serverErr := make(chan error, 1)
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serverErr <- err
+		}
+	}()
+	
+	select {
+	case err := <-serverErr:
+		scheduler.Stop()
 		log.Fatalf("Failed to start server: %v", err)
+	case <-ctx.Done():
+	}
+	
+	log.Printf("Shutting down...")
+	
+	// Let in-flight requests and job runs finish
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server shutdown failed: %v", err)
+	}
+	scheduler.Stop()
+}
+
+// bootstrapAdmin creates the configured admin staff user when there is no
+// active admin yet, so that the back office can be reached on a fresh install
+func bootstrapAdmin(db *sql.DB, cfg *config.AdminConfig) error {
+	if cfg.Password == "" {
+		return nil
+	}
+	
+	staffService := services.NewStaffService(repository.NewPostgresStaffRepository(db))
+	created, err := staffService.EnsureAdmin(cfg.Username, cfg.Email, cfg.Password)
+	if err != nil {
+		return err
+	}
+	
+	if created {
+		log.Printf("Created admin staff user %s", cfg.Username)
 	}
+	return nil
 }
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/bank-api/internal/repository"
+)
+
+const migrateUsage = "usage: bank-api migrate [status | up | down [N]]"
+
+// runMigrate implements the "migrate" subcommand
+func runMigrate(db *sql.DB, args []string) error {
+	migrator, err := repository.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+
+	command := "status"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.Applied {
+				state = "applied " + status.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Printf("%04d  %-45s %s\n", status.Version, status.Name, state)
+		}
+
+	case "up":
+		applied, err := migrator.Up()
+		if err != nil {
+			return err
+		}
+		log.Printf("Applied %d migration(s)", applied)
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil {
+				return fmt.Errorf("invalid number of migrations %q: %s", args[1], migrateUsage)
+			}
+		}
+		rolledBack, err := migrator.Down(steps)
+		if err != nil {
+			return err
+		}
+		log.Printf("Rolled back %d migration(s)", rolledBack)
+
+	default:
+		return fmt.Errorf("unknown migrate command %q: %s", command, migrateUsage)
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/bank-api/internal/api/middleware"
 	"github.com/bank-api/internal/models"
 	"github.com/bank-api/internal/services"
 	"github.com/bank-api/internal/utils"
@@ -20,7 +21,8 @@ func NewAccountHandler(accountService services.AccountService) *AccountHandler {
 	}
 }
 
-// CreateAccount handles POST /accounts
+// CreateAccount handles POST /accounts, registering a new customer with their
+// first account
 func (h *AccountHandler) CreateAccount(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateAccountRequest
 	if err := utils.ParseJSON(r, &req); err != nil {
@@ -58,11 +60,35 @@ func (h *AccountHandler) GetAccount(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	if !middleware.CanAccessCustomer(r.Context(), account.CustomerID) {
+		utils.WriteError(w, http.StatusForbidden, "You are not authorized to view this account")
+		return
+	}
+	
 	utils.WriteSuccess(w, http.StatusOK, "Account retrieved successfully", account)
 }
 
-// GetAccounts handles GET /accounts
+// GetAccounts handles GET /accounts. Staff allowed to list accounts see every
+// account; customers see their own.
 func (h *AccountHandler) GetAccounts(w http.ResponseWriter, r *http.Request) {
+	role, _ := middleware.GetRoleFromContext(r.Context())
+	if !models.HasPermission(role, models.PermAccountList) {
+		customerID, ok := middleware.GetCustomerIDFromContext(r.Context())
+		if !ok {
+			utils.WriteError(w, http.StatusUnauthorized, "Customer not found in context")
+			return
+		}
+		
+		accounts, err := h.accountService.GetAccountsByCustomerID(customerID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+		
+		utils.WriteSuccess(w, http.StatusOK, "Accounts retrieved successfully", accounts)
+		return
+	}
+	
 	// Parse query parameters
 	limitStr := r.URL.Query().Get("limit")
 	offsetStr := r.URL.Query().Get("offset")
@@ -90,35 +116,6 @@ func (h *AccountHandler) GetAccounts(w http.ResponseWriter, r *http.Request) {
 	utils.WriteSuccess(w, http.StatusOK, "Accounts retrieved successfully", accounts)
 }
 
-// UpdateAccount handles PUT /accounts/{id}
-func (h *AccountHandler) UpdateAccount(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	idStr, exists := vars["id"]
-	if !exists {
-		utils.WriteError(w, http.StatusBadRequest, "Account ID is required")
-		return
-	}
-	
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, "Invalid account ID")
-		return
-	}
-	
-	var req models.UpdateAccountRequest
-	if err := utils.ParseJSON(r, &req); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
-		return
-	}
-	
-	if err := h.accountService.UpdateAccount(id, &req); err != nil {
-		utils.WriteError(w, http.StatusBadRequest, err.Error())
-		return
-	}
-	
-	utils.WriteSuccess(w, http.StatusOK, "Account updated successfully", nil)
-}
-
 // DeleteAccount handles DELETE /accounts/{id}
 func (h *AccountHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -151,6 +148,17 @@ func (h *AccountHandler) GetAccountBalance(w http.ResponseWriter, r *http.Reques
 		return
 	}
 	
+	account, err := h.accountService.GetAccountByAccountNumber(accountNumber)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	
+	if !middleware.CanAccessAccount(r.Context(), account.AccountNumber) {
+		utils.WriteError(w, http.StatusForbidden, "You are not authorized to view this account")
+		return
+	}
+	
 	balance, err := h.accountService.GetAccountBalance(accountNumber)
 	if err != nil {
 		utils.WriteError(w, http.StatusNotFound, err.Error())
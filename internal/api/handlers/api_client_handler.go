@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bank-api/internal/api/middleware"
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/services"
+	"github.com/bank-api/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+type APIClientHandler struct {
+	apiClientService services.APIClientService
+	tokenService     services.TokenService
+}
+
+func NewAPIClientHandler(apiClientService services.APIClientService, tokenService services.TokenService) *APIClientHandler {
+	return &APIClientHandler{
+		apiClientService: apiClientService,
+		tokenService:     tokenService,
+	}
+}
+
+// Token handles POST /oauth/token, the OAuth2 token endpoint (RFC 6749).
+// Only the client_credentials grant is supported; clients authenticate with
+// HTTP Basic or with client_id and client_secret form fields.
+func (h *APIClientHandler) Token(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "the request body must be form encoded")
+		return
+	}
+
+	switch grantType := r.PostForm.Get("grant_type"); grantType {
+	case models.GrantTypeClientCredentials:
+	case "":
+		writeOAuthError(w, http.StatusBadRequest, "invalid_request", "grant_type is required")
+		return
+	default:
+		writeOAuthError(w, http.StatusBadRequest, "unsupported_grant_type", "only client_credentials is supported")
+		return
+	}
+
+	clientID, secret, ok := basicClientCredentials(r)
+	if !ok {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+
+	info := clientInfo(r)
+	client, scopes, err := h.apiClientService.Authenticate(clientID, secret, info.IPAddress, strings.Fields(r.PostForm.Get("scope")))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidClient):
+			w.Header().Set("WWW-Authenticate", `Basic realm="bank-api"`)
+			writeOAuthError(w, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		case errors.Is(err, services.ErrInvalidScope):
+			writeOAuthError(w, http.StatusBadRequest, "invalid_scope", err.Error())
+		default:
+			writeOAuthError(w, http.StatusInternalServerError, "server_error", "failed to authenticate client")
+		}
+		return
+	}
+
+	tokens, err := h.tokenService.IssueClientToken(client, scopes, info)
+	if err != nil {
+		writeOAuthError(w, http.StatusInternalServerError, "server_error", "failed to issue token")
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, models.OAuthTokenResponse{
+		AccessToken: tokens.AccessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(time.Until(tokens.AccessExpiresAt).Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	})
+}
+
+// basicClientCredentials reads HTTP Basic client credentials, which RFC 6749
+// form-encodes before they are joined
+func basicClientCredentials(r *http.Request) (string, string, bool) {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return "", "", false
+	}
+
+	clientID, err := url.QueryUnescape(username)
+	if err != nil {
+		return "", "", false
+	}
+	secret, err := url.QueryUnescape(password)
+	if err != nil {
+		return "", "", false
+	}
+	return clientID, secret, true
+}
+
+func writeOAuthError(w http.ResponseWriter, status int, code, description string) {
+	utils.WriteJSON(w, status, models.OAuthErrorResponse{Error: code, ErrorDescription: description})
+}
+
+// CreateClient handles POST /api-clients. The secret is only in this response.
+func (h *APIClientHandler) CreateClient(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateAPIClientRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	staffID, _ := middleware.GetStaffIDFromContext(r.Context())
+	credentials, err := h.apiClientService.CreateClient(&req, staffID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, "API client created successfully; store the secret now, it is not shown again", credentials)
+}
+
+// GetClients handles GET /api-clients
+func (h *APIClientHandler) GetClients(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	clients, err := h.apiClientService.GetClients(limit, offset)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "API clients retrieved successfully", clients)
+}
+
+// GetClient handles GET /api-clients/{clientId}
+func (h *APIClientHandler) GetClient(w http.ResponseWriter, r *http.Request) {
+	client, err := h.apiClientService.GetClient(mux.Vars(r)["clientId"])
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "API client retrieved successfully", client)
+}
+
+// UpdateClient handles PATCH /api-clients/{clientId}
+func (h *APIClientHandler) UpdateClient(w http.ResponseWriter, r *http.Request) {
+	var req models.UpdateAPIClientRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	client, err := h.apiClientService.UpdateClient(mux.Vars(r)["clientId"], &req)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "API client updated successfully", client)
+}
+
+// UpdateClientStatus handles PATCH /api-clients/{clientId}/status
+func (h *APIClientHandler) UpdateClientStatus(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Status string `json:"status"`
+	}
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	if err := h.apiClientService.UpdateClientStatus(mux.Vars(r)["clientId"], req.Status); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "API client status updated successfully", nil)
+}
+
+// RotateSecret handles POST /api-clients/{clientId}/secret: the old secret
+// and every token obtained with it stop working
+func (h *APIClientHandler) RotateSecret(w http.ResponseWriter, r *http.Request) {
+	credentials, err := h.apiClientService.RotateSecret(mux.Vars(r)["clientId"])
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "API client secret rotated; store the new secret now, it is not shown again", credentials)
+}
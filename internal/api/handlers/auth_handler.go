@@ -1,29 +1,37 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/bank-api/internal/api/middleware"
 	"github.com/bank-api/internal/models"
 	"github.com/bank-api/internal/services"
 	"github.com/bank-api/internal/utils"
 )
 
 type AuthHandler struct {
-	accountService services.AccountService
-	jwtSecret      string
-	jwtExpiresIn   time.Duration
+	customerService services.CustomerService
+	staffService    services.StaffService
+	tokenService    services.TokenService
+	mfaService      services.MFAService
+	loginGuard      services.LoginGuard
 }
 
-func NewAuthHandler(accountService services.AccountService, jwtSecret string, jwtExpiresIn time.Duration) *AuthHandler {
+func NewAuthHandler(customerService services.CustomerService, staffService services.StaffService, tokenService services.TokenService, mfaService services.MFAService, loginGuard services.LoginGuard) *AuthHandler {
 	return &AuthHandler{
-		accountService: accountService,
-		jwtSecret:      jwtSecret,
-		jwtExpiresIn:   jwtExpiresIn,
+		customerService: customerService,
+		staffService:    staffService,
+		tokenService:    tokenService,
+		mfaService:      mfaService,
+		loginGuard:      loginGuard,
 	}
 }
 
-// Login handles POST /auth/login
+// Login handles POST /auth/login. Customers with MFA enabled get a challenge
+// token instead of the session's tokens, see LoginMFA.
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req models.LoginRequest
 	if err := utils.ParseJSON(r, &req); err != nil {
@@ -32,89 +40,240 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 	
 	// Validate request
-	if req.AccountNumber == "" || req.Password == "" {
-		utils.WriteError(w, http.StatusBadRequest, "Account number and password are required")
+	if (req.CustomerID == "" && req.AccountNumber == "") || req.Password == "" {
+		utils.WriteError(w, http.StatusBadRequest, "Customer ID and password are required")
 		return
 	}
 	
-	// Authenticate account
-	account, err := h.accountService.AuthenticateAccount(req.AccountNumber, req.Password)
+	// Authenticate customer, by account number for older clients
+	client := clientInfo(r)
+	var customer *models.Customer
+	var err error
+	identifier := req.CustomerID
+	if identifier != "" {
+		customer, err = h.customerService.AuthenticateCustomer(identifier, req.Password, client)
+	} else {
+		identifier = req.AccountNumber
+		customer, err = h.customerService.AuthenticateByAccountNumber(identifier, req.Password, client)
+	}
 	if err != nil {
-		utils.WriteError(w, http.StatusUnauthorized, "Invalid credentials")
+		if !writeLoginThrottled(w, err) {
+			utils.WriteError(w, http.StatusUnauthorized, "Invalid credentials")
+		}
+		return
+	}
+	
+	mfaEnabled, err := h.mfaService.IsEnabled(customer.CustomerID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to check MFA")
+		return
+	}
+	if mfaEnabled {
+		token, expiresAt, err := h.mfaService.CreateChallenge(customer.CustomerID)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, "Failed to create MFA challenge")
+			return
+		}
+		
+		utils.WriteSuccess(w, http.StatusOK, "MFA verification required", models.MFAChallengeResponse{
+			MFARequired: true,
+			MFAToken:    token,
+			CustomerID:  customer.CustomerID,
+			ExpiresAt:   expiresAt,
+		})
+		return
+	}
+	
+	if err := h.loginGuard.Succeeded(customer, identifier, client); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to record login")
 		return
 	}
 	
-	// Generate JWT token
-	token, err := utils.GenerateJWT(account, h.jwtSecret, h.jwtExpiresIn)
+	// Open a session and issue its first token pair
+	tokens, err := h.tokenService.IssueCustomerTokens(customer, []string{models.AuthMethodPassword}, client)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, "Failed to generate token")
 		return
 	}
 	
-	// Create response
-	response := models.LoginResponse{
-		Token:         token,
-		AccountNumber: account.AccountNumber,
-		CustomerID:    account.CustomerID,
-		ExpiresAt:     time.Now().Add(h.jwtExpiresIn),
+	utils.WriteSuccess(w, http.StatusOK, "Login successful", customerLoginResponse(tokens))
+}
+
+// LoginMFA handles POST /auth/login/mfa: the challenge token of a login and a
+// TOTP or recovery code open the session
+func (h *AuthHandler) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	var req models.MFALoginRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
 	}
 	
-	utils.WriteSuccess(w, http.StatusOK, "Login successful", response)
+	if req.MFAToken == "" || req.Code == "" {
+		utils.WriteError(w, http.StatusBadRequest, "MFA token and code are required")
+		return
+	}
+	
+	client := clientInfo(r)
+	customer, err := h.mfaService.CompleteChallenge(req.MFAToken, req.Code, client)
+	if err != nil {
+		if !writeLoginThrottled(w, err) {
+			utils.WriteError(w, http.StatusUnauthorized, err.Error())
+		}
+		return
+	}
+	
+	if err := h.loginGuard.Succeeded(customer, customer.CustomerID, client); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to record login")
+		return
+	}
+	
+	methods := []string{models.AuthMethodPassword, models.AuthMethodOTP, models.AuthMethodMFA}
+	tokens, err := h.tokenService.IssueCustomerTokens(customer, methods, client)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+	
+	utils.WriteSuccess(w, http.StatusOK, "Login successful", customerLoginResponse(tokens))
 }
 
-// Logout handles POST /auth/logout
+// StaffLogin handles POST /auth/staff/login
+func (h *AuthHandler) StaffLogin(w http.ResponseWriter, r *http.Request) {
+	var req models.StaffLoginRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+	
+	if req.Username == "" || req.Password == "" {
+		utils.WriteError(w, http.StatusBadRequest, "Username and password are required")
+		return
+	}
+	
+	user, err := h.staffService.AuthenticateStaff(req.Username, req.Password)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, "Invalid credentials")
+		return
+	}
+	
+	tokens, err := h.tokenService.IssueStaffTokens(user, clientInfo(r))
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+	
+	utils.WriteSuccess(w, http.StatusOK, "Login successful", staffLoginResponse(tokens))
+}
+
+// Logout handles POST /auth/logout by revoking the caller's session
 func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
-	// In a real implementation, you might want to blacklist the token
-	// For now, we'll just return a success response
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "Authentication required")
+		return
+	}
+	
+	if err := h.tokenService.Logout(claims); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	
 	utils.WriteSuccess(w, http.StatusOK, "Logout successful", nil)
 }
 
-// RefreshToken handles POST /auth/refresh
-func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
-	// Get token from Authorization header
-	authHeader := r.Header.Get("Authorization")
-	if authHeader == "" {
-		utils.WriteError(w, http.StatusUnauthorized, "Authorization header required")
+// LogoutAll handles POST /auth/logout-all by revoking every session of the caller
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	claims, ok := middleware.GetClaimsFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusUnauthorized, "Authentication required")
 		return
 	}
 	
-	// Extract token
-	tokenString := ""
-	if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
-		tokenString = authHeader[7:]
-	} else {
-		utils.WriteError(w, http.StatusUnauthorized, "Invalid authorization header format")
+	revoked, err := h.tokenService.LogoutAll(claims)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 	
-	// Verify current token
-	claims, err := utils.VerifyJWT(tokenString, h.jwtSecret)
-	if err != nil {
-		utils.WriteError(w, http.StatusUnauthorized, "Invalid token")
+	utils.WriteSuccess(w, http.StatusOK, "Logged out of all devices", map[string]int64{
+		"revoked_sessions": revoked,
+	})
+}
+
+// RefreshToken handles POST /auth/refresh. The refresh token is single use:
+// the response carries its replacement.
+func (h *AuthHandler) RefreshToken(w http.ResponseWriter, r *http.Request) {
+	var req models.RefreshTokenRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
 		return
 	}
 	
-	// Get account
-	account, err := h.accountService.GetAccountByAccountNumber(claims.AccountNumber)
-	if err != nil {
-		utils.WriteError(w, http.StatusUnauthorized, "Account not found")
+	if req.RefreshToken == "" {
+		utils.WriteError(w, http.StatusBadRequest, "Refresh token is required")
 		return
 	}
 	
-	// Generate new token
-	newToken, err := utils.GenerateJWT(account, h.jwtSecret, h.jwtExpiresIn)
+	tokens, err := h.tokenService.Refresh(req.RefreshToken)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, "Failed to generate token")
+		utils.WriteError(w, http.StatusUnauthorized, err.Error())
 		return
 	}
 	
-	// Create response
-	response := models.LoginResponse{
-		Token:         newToken,
-		AccountNumber: account.AccountNumber,
-		CustomerID:    account.CustomerID,
-		ExpiresAt:     time.Now().Add(h.jwtExpiresIn),
+	if tokens.Staff != nil {
+		utils.WriteSuccess(w, http.StatusOK, "Token refreshed successfully", staffLoginResponse(tokens))
+		return
 	}
 	
-	utils.WriteSuccess(w, http.StatusOK, "Token refreshed successfully", response)
+	utils.WriteSuccess(w, http.StatusOK, "Token refreshed successfully", customerLoginResponse(tokens))
+}
+
+func customerLoginResponse(tokens *services.IssuedTokens) models.LoginResponse {
+	return models.LoginResponse{
+		Token:            tokens.AccessToken,
+		RefreshToken:     tokens.RefreshToken,
+		CustomerID:       tokens.Customer.CustomerID,
+		ExpiresAt:        tokens.AccessExpiresAt,
+		RefreshExpiresAt: tokens.RefreshExpiresAt,
+	}
+}
+
+func staffLoginResponse(tokens *services.IssuedTokens) models.StaffLoginResponse {
+	return models.StaffLoginResponse{
+		Token:            tokens.AccessToken,
+		RefreshToken:     tokens.RefreshToken,
+		StaffID:          tokens.Staff.StaffID,
+		Username:         tokens.Staff.Username,
+		Role:             tokens.Staff.Role,
+		ExpiresAt:        tokens.AccessExpiresAt,
+		RefreshExpiresAt: tokens.RefreshExpiresAt,
+	}
+}
+
+// clientInfo records the device a session is opened from
+func clientInfo(r *http.Request) services.ClientInfo {
+	return services.ClientInfo{
+		UserAgent: r.UserAgent(),
+		IPAddress: utils.RemoteIP(r),
+	}
+}
+
+// writeLoginThrottled answers a login refused by the login guard: 423 while
+// the customer is locked out, 429 while it must wait. It reports whether err
+// was such a refusal.
+func writeLoginThrottled(w http.ResponseWriter, err error) bool {
+	var throttled *models.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		return false
+	}
+	
+	retryAfter := int(time.Until(throttled.RetryAt).Seconds()) + 1
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	
+	if throttled.Locked {
+		utils.WriteErrorCode(w, http.StatusLocked, models.ErrCodeAccountLocked, "Too many failed logins, try again later")
+		return true
+	}
+	utils.WriteErrorCode(w, http.StatusTooManyRequests, models.ErrCodeLoginThrottled, "Too many login attempts, try again later")
+	return true
 }
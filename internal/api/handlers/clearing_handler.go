@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/bank-api/internal/api/middleware"
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/services"
+	"github.com/bank-api/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+// ClearingSignatureHeader carries "sha256=" followed by the hex HMAC-SHA256
+// of the callback body, keyed with the shared callback secret
+const ClearingSignatureHeader = "X-Clearing-Signature"
+
+// maxCallbackBytes caps the size of a gateway status callback
+const maxCallbackBytes = 64 << 10
+
+type ClearingHandler struct {
+	clearingService services.ClearingService
+	callbackSecret  []byte
+}
+
+func NewClearingHandler(clearingService services.ClearingService, callbackSecret string) *ClearingHandler {
+	return &ClearingHandler{
+		clearingService: clearingService,
+		callbackSecret:  []byte(callbackSecret),
+	}
+}
+
+// Callback handles POST /clearing/callbacks, the interbank gateway's signed
+// notification that a payment was settled or rejected
+func (h *ClearingHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxCallbackBytes))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	if !h.validSignature(body, r.Header.Get(ClearingSignatureHeader)) {
+		utils.WriteError(w, http.StatusUnauthorized, "Invalid callback signature")
+		return
+	}
+
+	var callback models.ClearingCallback
+	if err := json.Unmarshal(body, &callback); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	payment, err := h.clearingService.HandleCallback(&callback)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Callback processed successfully", payment)
+}
+
+// GetPayment handles GET /clearing/payments/{paymentId}
+func (h *ClearingHandler) GetPayment(w http.ResponseWriter, r *http.Request) {
+	payment, err := h.clearingService.GetPayment(mux.Vars(r)["paymentId"])
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	if !middleware.CanAccessAccount(r.Context(), payment.DebtorAccountNumber) {
+		utils.WriteError(w, http.StatusForbidden, "You are not authorized to view this payment")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Payment retrieved successfully", payment)
+}
+
+// validSignature checks the callback's HMAC. Without a configured secret no
+// callback is accepted.
+func (h *ClearingHandler) validSignature(body []byte, header string) bool {
+	if len(h.callbackSecret) == 0 {
+		return false
+	}
+
+	signature, err := hex.DecodeString(strings.TrimPrefix(header, "sha256="))
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, h.callbackSecret)
+	mac.Write(body)
+	return hmac.Equal(signature, mac.Sum(nil))
+}
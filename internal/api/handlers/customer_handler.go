@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bank-api/internal/api/middleware"
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/services"
+	"github.com/bank-api/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+type CustomerHandler struct {
+	customerService services.CustomerService
+	accountService  services.AccountService
+	loginGuard      services.LoginGuard
+}
+
+func NewCustomerHandler(customerService services.CustomerService, accountService services.AccountService, loginGuard services.LoginGuard) *CustomerHandler {
+	return &CustomerHandler{
+		customerService: customerService,
+		accountService:  accountService,
+		loginGuard:      loginGuard,
+	}
+}
+
+// GetCustomer handles GET /customers/{customerId}
+func (h *CustomerHandler) GetCustomer(w http.ResponseWriter, r *http.Request) {
+	customerID := mux.Vars(r)["customerId"]
+	
+	if !middleware.CanAccessCustomer(r.Context(), customerID) {
+		utils.WriteError(w, http.StatusForbidden, "You are not authorized to view this customer")
+		return
+	}
+	
+	customer, err := h.customerService.GetCustomer(customerID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	
+	utils.WriteSuccess(w, http.StatusOK, "Customer retrieved successfully", customer)
+}
+
+// UpdateCustomer handles PUT /customers/{customerId}
+func (h *CustomerHandler) UpdateCustomer(w http.ResponseWriter, r *http.Request) {
+	customerID := mux.Vars(r)["customerId"]
+	
+	var req models.UpdateCustomerRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+	
+	if !middleware.CanAccessCustomer(r.Context(), customerID) {
+		utils.WriteError(w, http.StatusForbidden, "You can only update your own details")
+		return
+	}
+	
+	if err := h.customerService.UpdateCustomer(customerID, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	
+	utils.WriteSuccess(w, http.StatusOK, "Customer updated successfully", nil)
+}
+
+// GetCustomerAccounts handles GET /customers/{customerId}/accounts
+func (h *CustomerHandler) GetCustomerAccounts(w http.ResponseWriter, r *http.Request) {
+	customerID := mux.Vars(r)["customerId"]
+	
+	if !middleware.CanAccessCustomer(r.Context(), customerID) {
+		utils.WriteError(w, http.StatusForbidden, "You are not authorized to view this customer's accounts")
+		return
+	}
+	
+	accounts, err := h.accountService.GetAccountsByCustomerID(customerID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	
+	utils.WriteSuccess(w, http.StatusOK, "Accounts retrieved successfully", accounts)
+}
+
+// OpenAccount handles POST /customers/{customerId}/accounts
+func (h *CustomerHandler) OpenAccount(w http.ResponseWriter, r *http.Request) {
+	customerID := mux.Vars(r)["customerId"]
+	
+	var req models.OpenAccountRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+	
+	if !middleware.CanAccessCustomer(r.Context(), customerID) {
+		utils.WriteError(w, http.StatusForbidden, "You can only open accounts for yourself")
+		return
+	}
+	
+	account, err := h.accountService.OpenAccount(customerID, &req)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	
+	utils.WriteSuccess(w, http.StatusCreated, "Account opened successfully", account)
+}
+
+// GetLoginHistory handles GET /customers/{customerId}/logins, newest first
+func (h *CustomerHandler) GetLoginHistory(w http.ResponseWriter, r *http.Request) {
+	customerID := mux.Vars(r)["customerId"]
+	
+	if !middleware.CanAccessCustomer(r.Context(), customerID) {
+		utils.WriteError(w, http.StatusForbidden, "You are not authorized to view this customer's logins")
+		return
+	}
+	
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	
+	attempts, err := h.loginGuard.GetHistory(customerID, limit, offset)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to retrieve login history")
+		return
+	}
+	
+	utils.WriteSuccess(w, http.StatusOK, "Login history retrieved successfully", attempts)
+}
+
+// UnlockLogin handles POST /customers/{customerId}/unlock, lifting a login
+// lockout before it expires
+func (h *CustomerHandler) UnlockLogin(w http.ResponseWriter, r *http.Request) {
+	customerID := mux.Vars(r)["customerId"]
+	
+	if err := h.loginGuard.Unlock(customerID); err != nil {
+		utils.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	
+	utils.WriteSuccess(w, http.StatusOK, "Customer unlocked successfully", nil)
+}
@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bank-api/internal/api/middleware"
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/services"
+	"github.com/bank-api/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+type FeeHandler struct {
+	feeService services.FeeService
+}
+
+func NewFeeHandler(feeService services.FeeService) *FeeHandler {
+	return &FeeHandler{
+		feeService: feeService,
+	}
+}
+
+// GetQuote handles GET /fees/quote?transaction_type=TRANSFER&amount=100000&account_number=...
+// It previews the fee the account would pay for the transaction now. The
+// channel defaults to the caller's; account_number may be omitted by
+// customers with a single account.
+func (h *FeeHandler) GetQuote(w http.ResponseWriter, r *http.Request) {
+	accountNumber, ok := requestAccountNumber(r)
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, "Account number is required")
+		return
+	}
+
+	if !middleware.CanAccessAccount(r.Context(), accountNumber) {
+		utils.WriteError(w, http.StatusForbidden, "You can only request fee quotes for your own accounts")
+		return
+	}
+
+	amount, err := strconv.ParseInt(r.URL.Query().Get("amount"), 10, 64)
+	if err != nil || amount <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid amount")
+		return
+	}
+
+	req := &models.FeeQuoteRequest{
+		AccountNumber:   accountNumber,
+		TransactionType: strings.ToUpper(r.URL.Query().Get("transaction_type")),
+		Channel:         strings.ToUpper(r.URL.Query().Get("channel")),
+		Amount:          amount,
+	}
+	if req.TransactionType == "" {
+		req.TransactionType = models.TransactionTypeTransfer
+	}
+	if req.Channel == "" {
+		req.Channel = requestChannel(r)
+	}
+
+	quote, err := h.feeService.Quote(req)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Fee quote retrieved successfully", quote)
+}
+
+// GetSchedules handles GET /fees/schedules
+func (h *FeeHandler) GetSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules, err := h.feeService.GetSchedules()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to retrieve fee schedules")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Fee schedules retrieved successfully", schedules)
+}
+
+// CreateSchedule handles POST /fees/schedules
+func (h *FeeHandler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	var req models.FeeScheduleRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	schedule, err := h.feeService.CreateSchedule(&req)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, "Fee schedule created successfully", schedule)
+}
+
+// GetSchedule handles GET /fees/schedules/{id}
+func (h *FeeHandler) GetSchedule(w http.ResponseWriter, r *http.Request) {
+	id, ok := scheduleID(w, r)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.feeService.GetSchedule(id)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Fee schedule retrieved successfully", schedule)
+}
+
+// UpdateSchedule handles PUT /fees/schedules/{id}
+func (h *FeeHandler) UpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	id, ok := scheduleID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.FeeScheduleRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	schedule, err := h.feeService.UpdateSchedule(id, &req)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Fee schedule updated successfully", schedule)
+}
+
+// DeactivateSchedule handles DELETE /fees/schedules/{id}
+func (h *FeeHandler) DeactivateSchedule(w http.ResponseWriter, r *http.Request) {
+	id, ok := scheduleID(w, r)
+	if !ok {
+		return
+	}
+
+	schedule, err := h.feeService.DeactivateSchedule(id)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Fee schedule deactivated successfully", schedule)
+}
+
+// scheduleID parses the fee schedule ID in the URL, writing the error
+// response if it is invalid
+func scheduleID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid fee schedule ID")
+		return 0, false
+	}
+	return id, true
+}
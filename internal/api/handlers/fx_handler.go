@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bank-api/internal/api/middleware"
+	"github.com/bank-api/internal/services"
+	"github.com/bank-api/internal/utils"
+)
+
+type FXHandler struct {
+	fxService services.FXService
+}
+
+func NewFXHandler(fxService services.FXService) *FXHandler {
+	return &FXHandler{
+		fxService: fxService,
+	}
+}
+
+// GetQuote handles GET /fx/quote?from=TND&to=EUR&amount=100000&account_number=...
+// The returned quote locks its rate until expires_at and can be referenced
+// once by a transfer from that account via quote_id. account_number may be
+// omitted by customers with a single account.
+func (h *FXHandler) GetQuote(w http.ResponseWriter, r *http.Request) {
+	accountNumber, ok := requestAccountNumber(r)
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, "Account number is required")
+		return
+	}
+
+	if !middleware.CanAccessAccount(r.Context(), accountNumber) {
+		utils.WriteError(w, http.StatusForbidden, "You can only request quotes for your own accounts")
+		return
+	}
+
+	from := strings.ToUpper(r.URL.Query().Get("from"))
+	to := strings.ToUpper(r.URL.Query().Get("to"))
+	if from == "" || to == "" {
+		utils.WriteError(w, http.StatusBadRequest, "Both from and to currencies are required")
+		return
+	}
+
+	var amount int64
+	if amountStr := r.URL.Query().Get("amount"); amountStr != "" {
+		parsed, err := strconv.ParseInt(amountStr, 10, 64)
+		if err != nil || parsed <= 0 {
+			utils.WriteError(w, http.StatusBadRequest, "Invalid amount")
+			return
+		}
+		amount = parsed
+	}
+
+	quote, err := h.fxService.Quote(accountNumber, from, to, amount)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Quote created successfully", quote)
+}
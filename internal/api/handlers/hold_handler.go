@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/bank-api/internal/api/middleware"
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/services"
+	"github.com/bank-api/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+type HoldHandler struct {
+	holdService services.HoldService
+}
+
+func NewHoldHandler(holdService services.HoldService) *HoldHandler {
+	return &HoldHandler{
+		holdService: holdService,
+	}
+}
+
+// PlaceHold handles POST /holds
+func (h *HoldHandler) PlaceHold(w http.ResponseWriter, r *http.Request) {
+	var req models.PlaceHoldRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	if !middleware.CanAccessAccount(r.Context(), req.AccountNumber) {
+		utils.WriteError(w, http.StatusForbidden, "You can only place holds on your own account")
+		return
+	}
+
+	hold, err := h.holdService.PlaceHold(&req)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, "Hold placed successfully", hold)
+}
+
+// GetHold handles GET /holds/{holdId}
+func (h *HoldHandler) GetHold(w http.ResponseWriter, r *http.Request) {
+	hold, ok := h.ownHold(w, r)
+	if !ok {
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Hold retrieved successfully", hold)
+}
+
+// CaptureHold handles POST /holds/{holdId}/capture
+func (h *HoldHandler) CaptureHold(w http.ResponseWriter, r *http.Request) {
+	// An empty body captures the full held amount
+	var req models.CaptureHoldRequest
+	if err := utils.ParseJSON(r, &req); err != nil && err != io.EOF {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	hold, ok := h.ownHold(w, r)
+	if !ok {
+		return
+	}
+	req.HoldID = hold.HoldID
+
+	captured, err := h.holdService.CaptureHold(&req)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Hold captured successfully", captured)
+}
+
+// ReleaseHold handles POST /holds/{holdId}/release
+func (h *HoldHandler) ReleaseHold(w http.ResponseWriter, r *http.Request) {
+	hold, ok := h.ownHold(w, r)
+	if !ok {
+		return
+	}
+
+	released, err := h.holdService.ReleaseHold(hold.HoldID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Hold released successfully", released)
+}
+
+// GetAccountHolds handles GET /accounts/{accountNumber}/holds?status=ACTIVE
+func (h *HoldHandler) GetAccountHolds(w http.ResponseWriter, r *http.Request) {
+	accountNumber := mux.Vars(r)["accountNumber"]
+
+	if !middleware.CanAccessAccount(r.Context(), accountNumber) {
+		utils.WriteError(w, http.StatusForbidden, "You can only view holds on your own account")
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	holds, err := h.holdService.GetAccountHolds(accountNumber, r.URL.Query().Get("status"), limit, offset)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to retrieve holds")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Holds retrieved successfully", holds)
+}
+
+// ownHold loads the hold named in the URL and checks the caller may access
+// its account, writing the error response otherwise
+func (h *HoldHandler) ownHold(w http.ResponseWriter, r *http.Request) (*models.Hold, bool) {
+	holdID, exists := mux.Vars(r)["holdId"]
+	if !exists {
+		utils.WriteError(w, http.StatusBadRequest, "Hold ID is required")
+		return nil, false
+	}
+
+	hold, err := h.holdService.GetHold(holdID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err.Error())
+		return nil, false
+	}
+
+	if !middleware.CanAccessAccount(r.Context(), hold.AccountNumber) {
+		utils.WriteError(w, http.StatusForbidden, "You are not authorized to access this hold")
+		return nil, false
+	}
+
+	return hold, true
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/bank-api/internal/api/middleware"
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/services"
+	"github.com/bank-api/internal/utils"
+)
+
+type InterestHandler struct {
+	interestService services.InterestService
+}
+
+func NewInterestHandler(interestService services.InterestService) *InterestHandler {
+	return &InterestHandler{
+		interestService: interestService,
+	}
+}
+
+// GetAccrualReport handles GET /interest/accruals?from=2025-01-01&to=2025-03-31&account_number=...
+// Staff may omit account_number to report on every account; customers get
+// their own account's accruals only.
+func (h *InterestHandler) GetAccrualReport(w http.ResponseWriter, r *http.Request) {
+	first, last, err := parsePeriod(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	accountNumber := r.URL.Query().Get("account_number")
+	if !middleware.IsStaff(r.Context()) {
+		var ok bool
+		if accountNumber, ok = requestAccountNumber(r); !ok {
+			utils.WriteError(w, http.StatusBadRequest, "Account number is required")
+			return
+		}
+	}
+	if accountNumber != "" && !middleware.CanAccessAccount(r.Context(), accountNumber) {
+		utils.WriteError(w, http.StatusForbidden, "You can only view the interest of your own accounts")
+		return
+	}
+
+	report, err := h.interestService.GetAccrualReport(accountNumber, first, last)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Interest accrual report retrieved successfully", report)
+}
+
+// RunAccrual handles POST /interest/accruals/run. It accrues every account
+// for a past business date; accounts already accrued for it are skipped.
+func (h *InterestHandler) RunAccrual(w http.ResponseWriter, r *http.Request) {
+	var req models.InterestAccrualRunRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	businessDate, err := time.Parse("2006-01-02", req.BusinessDate)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "business_date must be a date in YYYY-MM-DD format")
+		return
+	}
+
+	accrued, err := h.interestService.RunAccrual(businessDate)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Interest accrued successfully", map[string]interface{}{
+		"business_date":    req.BusinessDate,
+		"accounts_accrued": accrued,
+	})
+}
+
+// GetRateTables handles GET /interest/rate-tables
+func (h *InterestHandler) GetRateTables(w http.ResponseWriter, r *http.Request) {
+	tables, err := h.interestService.GetRateTables()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to retrieve interest rate tables")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Interest rate tables retrieved successfully", tables)
+}
+
+// CreateRateTable handles POST /interest/rate-tables
+func (h *InterestHandler) CreateRateTable(w http.ResponseWriter, r *http.Request) {
+	var req models.InterestRateTableRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	table, err := h.interestService.CreateRateTable(&req)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, "Interest rate table created successfully", table)
+}
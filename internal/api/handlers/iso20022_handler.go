@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/bank-api/internal/api/middleware"
+	"github.com/bank-api/internal/services"
+	"github.com/bank-api/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+// maxPaymentFileBytes caps the size of an uploaded pain.001 file
+const maxPaymentFileBytes = 5 << 20
+
+type ISO20022Handler struct {
+	iso20022Service services.ISO20022Service
+}
+
+func NewISO20022Handler(iso20022Service services.ISO20022Service) *ISO20022Handler {
+	return &ISO20022Handler{iso20022Service: iso20022Service}
+}
+
+// UploadPaymentFile handles POST /accounts/{accountNumber}/payment-files. The
+// body is a pain.001 XML document; with ?dry_run=true the file is only
+// validated.
+func (h *ISO20022Handler) UploadPaymentFile(w http.ResponseWriter, r *http.Request) {
+	accountNumber := mux.Vars(r)["accountNumber"]
+	if !middleware.CanAccessAccount(r.Context(), accountNumber) {
+		utils.WriteError(w, http.StatusForbidden, "You are not authorized to debit this account")
+		return
+	}
+
+	dryRun := false
+	if dryRunStr := r.URL.Query().Get("dry_run"); dryRunStr != "" {
+		parsed, err := strconv.ParseBool(dryRunStr)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, "dry_run must be true or false")
+			return
+		}
+		dryRun = parsed
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxPaymentFileBytes)
+	claims, _ := middleware.GetClaimsFromContext(r.Context())
+	result, err := h.iso20022Service.ImportPain001(accountNumber, body, dryRun, claims)
+	if errors.Is(err, services.ErrStepUpRequired) {
+		writeStepUpRequired(w, "This payment file requires a second factor: verify a code with POST /auth/mfa/step-up")
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	message := "Payment file processed successfully"
+	if dryRun {
+		message = "Payment file validated successfully"
+	}
+	utils.WriteSuccess(w, http.StatusOK, message, result)
+}
+
+// DownloadStatement handles GET /accounts/{accountNumber}/statements/camt053
+// with from and to dates (YYYY-MM-DD, both inclusive)
+func (h *ISO20022Handler) DownloadStatement(w http.ResponseWriter, r *http.Request) {
+	accountNumber := mux.Vars(r)["accountNumber"]
+	if !middleware.CanAccessAccount(r.Context(), accountNumber) {
+		utils.WriteError(w, http.StatusForbidden, "You are not authorized to view this account")
+		return
+	}
+
+	from, to, err := parsePeriod(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	document, err := h.iso20022Service.ExportCamt053(accountNumber, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filename := fmt.Sprintf("camt053_%s_%s_%s.xml", accountNumber, from.Format("20060102"), to.Format("20060102"))
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.WriteHeader(http.StatusOK)
+	w.Write(document)
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/bank-api/internal/utils"
+)
+
+// jwksMaxAge is how long verifiers may cache the key set; new signing keys
+// should be configured at least this long before they activate
+const jwksMaxAge = "max-age=300"
+
+type JWKSHandler struct {
+	keys *utils.JWTKeySet
+}
+
+func NewJWKSHandler(keys *utils.JWTKeySet) *JWKSHandler {
+	return &JWKSHandler{keys: keys}
+}
+
+// GetJWKS handles GET /.well-known/jwks.json: the public keys other services
+// verify our access tokens with
+func (h *JWKSHandler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, "+jwksMaxAge)
+	utils.WriteJSON(w, http.StatusOK, h.keys.JWKS())
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/bank-api/internal/api/middleware"
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/services"
+	"github.com/bank-api/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+type LimitHandler struct {
+	limitService services.LimitService
+}
+
+func NewLimitHandler(limitService services.LimitService) *LimitHandler {
+	return &LimitHandler{
+		limitService: limitService,
+	}
+}
+
+// GetAccountLimits handles GET /accounts/{accountNumber}/limits: the
+// account's limits and the headroom left under them
+func (h *LimitHandler) GetAccountLimits(w http.ResponseWriter, r *http.Request) {
+	accountNumber := mux.Vars(r)["accountNumber"]
+
+	if !middleware.CanAccessAccount(r.Context(), accountNumber) {
+		utils.WriteError(w, http.StatusForbidden, "You can only view the limits of your own account")
+		return
+	}
+
+	status, err := h.limitService.GetStatus(accountNumber)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Limits retrieved successfully", status)
+}
+
+// UpdateAccountLimits handles PUT /accounts/{accountNumber}/limits. The
+// limits apply to all the owner's accounts of the same type and currency. Customers can
+// only lower them; raising or lifting a limit requires limit:manage.
+func (h *LimitHandler) UpdateAccountLimits(w http.ResponseWriter, r *http.Request) {
+	accountNumber := mux.Vars(r)["accountNumber"]
+
+	if !middleware.CanAccessAccount(r.Context(), accountNumber) {
+		utils.WriteError(w, http.StatusForbidden, "You can only change the limits of your own account")
+		return
+	}
+
+	var req models.LimitsRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	allowRaise := middleware.HasPermission(r.Context(), models.PermLimitManage)
+
+	status, err := h.limitService.UpdateLimits(accountNumber, &req, allowRaise, requestActor(r))
+	if errors.Is(err, services.ErrLimitRaiseNotAllowed) {
+		utils.WriteError(w, http.StatusForbidden, err.Error())
+		return
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Limits updated successfully", status)
+}
+
+// ResetAccountLimits handles DELETE /accounts/{accountNumber}/limits: the
+// owner's accounts of its type and currency go back to the account type's
+// limits
+func (h *LimitHandler) ResetAccountLimits(w http.ResponseWriter, r *http.Request) {
+	status, err := h.limitService.ResetLimits(mux.Vars(r)["accountNumber"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Limits reset successfully", status)
+}
+
+// GetDefaults handles GET /limits/defaults
+func (h *LimitHandler) GetDefaults(w http.ResponseWriter, r *http.Request) {
+	defaults, err := h.limitService.GetDefaults()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to retrieve limits")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Limits retrieved successfully", defaults)
+}
+
+// UpdateDefaults handles PUT /limits/defaults/{accountType}/{currency}
+func (h *LimitHandler) UpdateDefaults(w http.ResponseWriter, r *http.Request) {
+	var req models.LimitsRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	vars := mux.Vars(r)
+	defaults, err := h.limitService.UpdateDefaults(vars["accountType"], strings.ToUpper(vars["currency"]), &req)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Limits updated successfully", defaults)
+}
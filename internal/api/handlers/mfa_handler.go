@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bank-api/internal/api/middleware"
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/services"
+	"github.com/bank-api/internal/utils"
+)
+
+type MFAHandler struct {
+	mfaService   services.MFAService
+	tokenService services.TokenService
+}
+
+func NewMFAHandler(mfaService services.MFAService, tokenService services.TokenService) *MFAHandler {
+	return &MFAHandler{
+		mfaService:   mfaService,
+		tokenService: tokenService,
+	}
+}
+
+// GetStatus handles GET /auth/mfa
+func (h *MFAHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := mfaCustomer(w, r)
+	if !ok {
+		return
+	}
+
+	status, err := h.mfaService.GetStatus(customerID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to retrieve MFA status")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "MFA status retrieved successfully", status)
+}
+
+// BeginEnrollment handles POST /auth/mfa/enroll: the secret is shown once and
+// only protects logins after POST /auth/mfa/enroll/verify
+func (h *MFAHandler) BeginEnrollment(w http.ResponseWriter, r *http.Request) {
+	customerID, ok := mfaCustomer(w, r)
+	if !ok {
+		return
+	}
+
+	enrollment, err := h.mfaService.BeginEnrollment(customerID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, "Add the secret to your authenticator app, then verify a code", enrollment)
+}
+
+// ConfirmEnrollment handles POST /auth/mfa/enroll/verify. The response holds
+// the recovery codes, which are not shown again.
+func (h *MFAHandler) ConfirmEnrollment(w http.ResponseWriter, r *http.Request) {
+	customerID, req, ok := mfaCodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := h.mfaService.ConfirmEnrollment(customerID, req.Code)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "MFA enabled successfully", models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// RegenerateRecoveryCodes handles POST /auth/mfa/recovery-codes
+func (h *MFAHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	customerID, req, ok := mfaCodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	codes, err := h.mfaService.RegenerateRecoveryCodes(customerID, req.Code)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Recovery codes regenerated successfully", models.RecoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// Disable handles POST /auth/mfa/disable
+func (h *MFAHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	customerID, req, ok := mfaCodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.mfaService.Disable(customerID, req.Code); err != nil {
+		writeMFAError(w, err)
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "MFA disabled successfully", nil)
+}
+
+// StepUp handles POST /auth/mfa/step-up: a valid code earns an access token
+// for the same session that satisfies operations requiring a recent second
+// factor
+func (h *MFAHandler) StepUp(w http.ResponseWriter, r *http.Request) {
+	customerID, req, ok := mfaCodeRequest(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.mfaService.Verify(customerID, req.Code); err != nil {
+		writeMFAError(w, err)
+		return
+	}
+
+	claims, _ := middleware.GetClaimsFromContext(r.Context())
+	tokens, err := h.tokenService.StepUp(claims, []string{models.AuthMethodOTP, models.AuthMethodMFA})
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Second factor verified", models.StepUpResponse{
+		Token:     tokens.AccessToken,
+		ACR:       models.ACRMultiFactor,
+		ExpiresAt: tokens.AccessExpiresAt,
+	})
+}
+
+// mfaCustomer returns the calling customer; staff users have no second factor
+// here
+func mfaCustomer(w http.ResponseWriter, r *http.Request) (string, bool) {
+	customerID, ok := middleware.GetCustomerIDFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusForbidden, "MFA is only available to customers")
+		return "", false
+	}
+	return customerID, true
+}
+
+func mfaCodeRequest(w http.ResponseWriter, r *http.Request) (string, *models.MFACodeRequest, bool) {
+	customerID, ok := mfaCustomer(w, r)
+	if !ok {
+		return "", nil, false
+	}
+
+	var req models.MFACodeRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return "", nil, false
+	}
+	if req.Code == "" {
+		utils.WriteError(w, http.StatusBadRequest, "Code is required")
+		return "", nil, false
+	}
+
+	return customerID, &req, true
+}
+
+func writeMFAError(w http.ResponseWriter, err error) {
+	if errors.Is(err, services.ErrInvalidMFACode) {
+		utils.WriteError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+	utils.WriteError(w, http.StatusBadRequest, err.Error())
+}
+
+// writeStepUpRequired refuses an operation until the caller verifies a second
+// factor, signalling it as in RFC 9470
+func writeStepUpRequired(w http.ResponseWriter, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_user_authentication", acr_values="`+models.ACRMultiFactor+`"`)
+	utils.WriteErrorCode(w, http.StatusUnauthorized, models.ErrCodeMFARequired, message)
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/bank-api/internal/api/middleware"
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/services"
+	"github.com/bank-api/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+type OverdraftHandler struct {
+	overdraftService services.OverdraftService
+}
+
+func NewOverdraftHandler(overdraftService services.OverdraftService) *OverdraftHandler {
+	return &OverdraftHandler{
+		overdraftService: overdraftService,
+	}
+}
+
+// RequestOverdraft handles POST /accounts/{accountNumber}/overdraft
+func (h *OverdraftHandler) RequestOverdraft(w http.ResponseWriter, r *http.Request) {
+	accountNumber := mux.Vars(r)["accountNumber"]
+
+	if !middleware.CanAccessAccount(r.Context(), accountNumber) {
+		utils.WriteError(w, http.StatusForbidden, "You can only request an overdraft on your own account")
+		return
+	}
+
+	var req models.OverdraftRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	facility, err := h.overdraftService.RequestOverdraft(accountNumber, &req, requestActor(r))
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, "Overdraft requested successfully", facility)
+}
+
+// GetAccountOverdrafts handles GET /accounts/{accountNumber}/overdraft: the
+// account's overdraft requests and facilities, newest first
+func (h *OverdraftHandler) GetAccountOverdrafts(w http.ResponseWriter, r *http.Request) {
+	accountNumber := mux.Vars(r)["accountNumber"]
+
+	if !middleware.CanAccessAccount(r.Context(), accountNumber) {
+		utils.WriteError(w, http.StatusForbidden, "You can only view the overdraft of your own account")
+		return
+	}
+
+	facilities, err := h.overdraftService.GetAccountFacilities(accountNumber)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Overdrafts retrieved successfully", facilities)
+}
+
+// GetOverdrafts handles GET /overdrafts?status=PENDING&limit=50&offset=0
+func (h *OverdraftHandler) GetOverdrafts(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	facilities, err := h.overdraftService.GetFacilities(r.URL.Query().Get("status"), limit, offset)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to retrieve overdrafts")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Overdrafts retrieved successfully", facilities)
+}
+
+// GetOverdraft handles GET /overdrafts/{id}
+func (h *OverdraftHandler) GetOverdraft(w http.ResponseWriter, r *http.Request) {
+	id, ok := overdraftID(w, r)
+	if !ok {
+		return
+	}
+
+	facility, err := h.overdraftService.GetFacility(id)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Overdraft retrieved successfully", facility)
+}
+
+// ApproveOverdraft handles POST /overdrafts/{id}/approve. The body may grant
+// another limit or rate than requested.
+func (h *OverdraftHandler) ApproveOverdraft(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, h.overdraftService.ApproveOverdraft, "Overdraft approved successfully")
+}
+
+// RejectOverdraft handles POST /overdrafts/{id}/reject
+func (h *OverdraftHandler) RejectOverdraft(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, h.overdraftService.RejectOverdraft, "Overdraft rejected successfully")
+}
+
+// RevokeOverdraft handles POST /overdrafts/{id}/revoke
+func (h *OverdraftHandler) RevokeOverdraft(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, h.overdraftService.RevokeOverdraft, "Overdraft revoked successfully")
+}
+
+type overdraftDecision func(id int, req *models.OverdraftDecisionRequest, decidedBy string) (*models.OverdraftFacility, error)
+
+// decide applies an admin's decision on the facility named in the URL; an
+// empty body decides without a note
+func (h *OverdraftHandler) decide(w http.ResponseWriter, r *http.Request, decision overdraftDecision, message string) {
+	id, ok := overdraftID(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.OverdraftDecisionRequest
+	if err := utils.ParseJSON(r, &req); err != nil && err != io.EOF {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	staffID, _ := middleware.GetStaffIDFromContext(r.Context())
+	facility, err := decision(id, &req, staffID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, message, facility)
+}
+
+func overdraftID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid overdraft ID")
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/bank-api/internal/api/middleware"
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/services"
+	"github.com/bank-api/internal/utils"
+)
+
+type PasswordHandler struct {
+	passwordService services.PasswordService
+}
+
+func NewPasswordHandler(passwordService services.PasswordService) *PasswordHandler {
+	return &PasswordHandler{passwordService: passwordService}
+}
+
+// ChangePassword handles POST /auth/password/change. The caller's other
+// sessions are logged out; the one making the change stays valid.
+func (h *PasswordHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	if _, ok := middleware.GetCustomerIDFromContext(r.Context()); !ok {
+		utils.WriteError(w, http.StatusForbidden, "Only customers can change their password here")
+		return
+	}
+
+	var req models.ChangePasswordRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		utils.WriteError(w, http.StatusBadRequest, "Current and new password are required")
+		return
+	}
+
+	claims, _ := middleware.GetClaimsFromContext(r.Context())
+	err := h.passwordService.ChangePassword(claims, req.CurrentPassword, req.NewPassword, clientInfo(r))
+	if err != nil {
+		if writeLoginThrottled(w, err) {
+			return
+		}
+		if errors.Is(err, services.ErrInvalidCredentials) {
+			utils.WriteError(w, http.StatusUnauthorized, "Current password is incorrect")
+			return
+		}
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Password changed successfully; other sessions have been logged out", nil)
+}
+
+// RequestReset handles POST /auth/password/reset/request. The response is the
+// same whether or not the email belongs to a customer.
+func (h *PasswordHandler) RequestReset(w http.ResponseWriter, r *http.Request) {
+	var req models.PasswordResetRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+	if req.Email == "" {
+		utils.WriteError(w, http.StatusBadRequest, "Email is required")
+		return
+	}
+
+	if err := h.passwordService.RequestReset(req.Email); err != nil {
+		if errors.Is(err, services.ErrPasswordResetDisabled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to send password reset token")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusAccepted, "If the email belongs to a customer, a password reset token has been sent to it", nil)
+}
+
+// ResetPassword handles POST /auth/password/reset: a reset token sets a new
+// password and logs out every session
+func (h *PasswordHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req models.ResetPasswordRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+	if req.Token == "" || req.NewPassword == "" {
+		utils.WriteError(w, http.StatusBadRequest, "Token and new password are required")
+		return
+	}
+
+	if err := h.passwordService.ResetPassword(req.Token, req.NewPassword); err != nil {
+		if errors.Is(err, services.ErrPasswordResetDisabled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Password reset successfully; log in with the new password", nil)
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bank-api/internal/api/middleware"
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/services"
+	"github.com/bank-api/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+type StaffHandler struct {
+	staffService services.StaffService
+}
+
+func NewStaffHandler(staffService services.StaffService) *StaffHandler {
+	return &StaffHandler{
+		staffService: staffService,
+	}
+}
+
+// CreateStaffUser handles POST /staff
+func (h *StaffHandler) CreateStaffUser(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateStaffUserRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	user, err := h.staffService.CreateStaffUser(&req)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, "Staff user created successfully", user)
+}
+
+// GetStaffUsers handles GET /staff
+func (h *StaffHandler) GetStaffUsers(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	users, err := h.staffService.GetAllStaff(limit, offset)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Staff users retrieved successfully", users)
+}
+
+// UpdateStaffStatus handles PATCH /staff/{staffId}/status
+func (h *StaffHandler) UpdateStaffStatus(w http.ResponseWriter, r *http.Request) {
+	staffID := mux.Vars(r)["staffId"]
+
+	var req struct {
+		Status string `json:"status"`
+	}
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	if ownID, _ := middleware.GetStaffIDFromContext(r.Context()); ownID == staffID {
+		utils.WriteError(w, http.StatusBadRequest, "You cannot change your own status")
+		return
+	}
+
+	if err := h.staffService.UpdateStaffStatus(staffID, req.Status); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Staff user status updated successfully", nil)
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/bank-api/internal/api/middleware"
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/services"
+	"github.com/bank-api/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+type StandingOrderHandler struct {
+	standingOrderService services.StandingOrderService
+	mfaService           services.MFAService
+}
+
+func NewStandingOrderHandler(standingOrderService services.StandingOrderService, mfaService services.MFAService) *StandingOrderHandler {
+	return &StandingOrderHandler{
+		standingOrderService: standingOrderService,
+		mfaService:           mfaService,
+	}
+}
+
+// CreateOrder handles POST /standing-orders
+func (h *StandingOrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateStandingOrderRequest
+	if err := utils.ParseJSON(r, &req); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+
+	if !middleware.CanAccessAccount(r.Context(), req.FromAccountNumber) {
+		utils.WriteError(w, http.StatusForbidden, "You can only set up standing orders from your own account")
+		return
+	}
+
+	// The order's transfers run later without the customer, so the step-up
+	// rules of a transfer apply when it is set up
+	claims, _ := middleware.GetClaimsFromContext(r.Context())
+	stepUp, err := h.mfaService.RequiresStepUp(claims, &models.TransferRequest{
+		FromAccountNumber: req.FromAccountNumber,
+		ToAccountNumber:   req.ToAccountNumber,
+		ToIBAN:            req.ToIBAN,
+		Amount:            req.Amount,
+	})
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to check authentication")
+		return
+	}
+	if stepUp {
+		writeStepUpRequired(w, "This standing order requires a second factor: verify a code with POST /auth/mfa/step-up")
+		return
+	}
+
+	order, err := h.standingOrderService.CreateOrder(&req)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusCreated, "Standing order created successfully", order)
+}
+
+// GetOrder handles GET /standing-orders/{orderId}
+func (h *StandingOrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	order, ok := h.ownOrder(w, r)
+	if !ok {
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Standing order retrieved successfully", order)
+}
+
+// PauseOrder handles POST /standing-orders/{orderId}/pause
+func (h *StandingOrderHandler) PauseOrder(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.standingOrderService.PauseOrder, "Standing order paused successfully")
+}
+
+// ResumeOrder handles POST /standing-orders/{orderId}/resume
+func (h *StandingOrderHandler) ResumeOrder(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.standingOrderService.ResumeOrder, "Standing order resumed successfully")
+}
+
+// CancelOrder handles POST /standing-orders/{orderId}/cancel
+func (h *StandingOrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, h.standingOrderService.CancelOrder, "Standing order cancelled successfully")
+}
+
+// GetExecutions handles GET /standing-orders/{orderId}/executions
+func (h *StandingOrderHandler) GetExecutions(w http.ResponseWriter, r *http.Request) {
+	order, ok := h.ownOrder(w, r)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	executions, err := h.standingOrderService.GetExecutions(order.OrderID, limit, offset)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to retrieve standing order executions")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Standing order executions retrieved successfully", executions)
+}
+
+// GetAccountOrders handles GET /accounts/{accountNumber}/standing-orders
+func (h *StandingOrderHandler) GetAccountOrders(w http.ResponseWriter, r *http.Request) {
+	accountNumber := mux.Vars(r)["accountNumber"]
+
+	if !middleware.CanAccessAccount(r.Context(), accountNumber) {
+		utils.WriteError(w, http.StatusForbidden, "You can only view standing orders of your own account")
+		return
+	}
+
+	orders, err := h.standingOrderService.GetAccountOrders(accountNumber)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to retrieve standing orders")
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, "Standing orders retrieved successfully", orders)
+}
+
+// changeStatus applies a status change to the order named in the URL
+func (h *StandingOrderHandler) changeStatus(w http.ResponseWriter, r *http.Request, change func(orderID string) (*models.StandingOrder, error), message string) {
+	order, ok := h.ownOrder(w, r)
+	if !ok {
+		return
+	}
+
+	changed, err := change(order.OrderID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	utils.WriteSuccess(w, http.StatusOK, message, changed)
+}
+
+// ownOrder loads the standing order named in the URL and checks the caller
+// may access its source account, writing the error response otherwise
+func (h *StandingOrderHandler) ownOrder(w http.ResponseWriter, r *http.Request) (*models.StandingOrder, bool) {
+	orderID, exists := mux.Vars(r)["orderId"]
+	if !exists {
+		utils.WriteError(w, http.StatusBadRequest, "Standing order ID is required")
+		return nil, false
+	}
+
+	order, err := h.standingOrderService.GetOrder(orderID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err.Error())
+		return nil, false
+	}
+
+	if !middleware.CanAccessAccount(r.Context(), order.FromAccountNumber) {
+		utils.WriteError(w, http.StatusForbidden, "You are not authorized to access this standing order")
+		return nil, false
+	}
+
+	return order, true
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/bank-api/internal/api/middleware"
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/services"
+	"github.com/bank-api/internal/utils"
+	"github.com/gorilla/mux"
+)
+
+type StatementHandler struct {
+	statementService services.StatementService
+}
+
+func NewStatementHandler(statementService services.StatementService) *StatementHandler {
+	return &StatementHandler{statementService: statementService}
+}
+
+// GetStatement handles GET /accounts/{accountNumber}/statements?from=&to=&format=.
+// format is json (default), csv or pdf; the documents are sent as attachments.
+func (h *StatementHandler) GetStatement(w http.ResponseWriter, r *http.Request) {
+	accountNumber := mux.Vars(r)["accountNumber"]
+	if !middleware.CanAccessAccount(r.Context(), accountNumber) {
+		utils.WriteError(w, http.StatusForbidden, "You are not authorized to view this account")
+		return
+	}
+
+	first, last, err := parsePeriod(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" || format == models.StatementFormatJSON {
+		statement, err := h.statementService.GetStatement(accountNumber, first, last)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		utils.WriteSuccess(w, http.StatusOK, "Statement retrieved successfully", statement)
+		return
+	}
+
+	contentTypes := map[string]string{
+		models.StatementFormatCSV: "text/csv; charset=utf-8",
+		models.StatementFormatPDF: "application/pdf",
+	}
+	contentType, ok := contentTypes[format]
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, "format must be json, csv or pdf")
+		return
+	}
+
+	document, err := h.statementService.RenderStatement(accountNumber, first, last, format)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filename := fmt.Sprintf("releve_%s_%s_%s.%s", accountNumber, first.Format("20060102"), last.Format("20060102"), format)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	w.WriteHeader(http.StatusOK)
+	w.Write(document)
+}
+
+// parsePeriod reads the from and to query parameters: dates in YYYY-MM-DD
+// format, both included in the period
+func parsePeriod(r *http.Request) (time.Time, time.Time, error) {
+	first, err := time.Parse("2006-01-02", r.URL.Query().Get("from"))
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("from must be a date in YYYY-MM-DD format")
+	}
+	last, err := time.Parse("2006-01-02", r.URL.Query().Get("to"))
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("to must be a date in YYYY-MM-DD format")
+	}
+	return first, last, nil
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
@@ -14,11 +16,13 @@ import (
 
 type TransactionHandler struct {
 	transactionService services.TransactionService
+	mfaService         services.MFAService
 }
 
-func NewTransactionHandler(transactionService services.TransactionService) *TransactionHandler {
+func NewTransactionHandler(transactionService services.TransactionService, mfaService services.MFAService) *TransactionHandler {
 	return &TransactionHandler{
 		transactionService: transactionService,
+		mfaService:         mfaService,
 	}
 }
 
@@ -30,22 +34,28 @@ func (h *TransactionHandler) Transfer(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	// Get account number from context (set by auth middleware)
-	accountNumber, ok := middleware.GetAccountNumberFromContext(r.Context())
-	if !ok {
-		utils.WriteError(w, http.StatusUnauthorized, "Account not found in context")
+	// Customers can only transfer from their own account
+	if !middleware.CanAccessAccount(r.Context(), req.FromAccountNumber) {
+		utils.WriteError(w, http.StatusForbidden, "You can only transfer from your own account")
 		return
 	}
+	req.Channel = requestChannel(r)
 	
-	// Ensure the user can only transfer from their own account
-	if req.FromAccountNumber != accountNumber {
-		utils.WriteError(w, http.StatusForbidden, "You can only transfer from your own account")
+	// Large transfers and new beneficiaries need a recent second factor
+	claims, _ := middleware.GetClaimsFromContext(r.Context())
+	stepUp, err := h.mfaService.RequiresStepUp(claims, &req)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to check authentication")
+		return
+	}
+	if stepUp {
+		writeStepUpRequired(w, "This transfer requires a second factor: verify a code with POST /auth/mfa/step-up")
 		return
 	}
 	
 	transaction, err := h.transactionService.Transfer(&req)
 	if err != nil {
-		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		writeTransactionError(w, err)
 		return
 	}
 	
@@ -60,15 +70,8 @@ func (h *TransactionHandler) Deposit(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	// Get account number from context (set by auth middleware)
-	accountNumber, ok := middleware.GetAccountNumberFromContext(r.Context())
-	if !ok {
-		utils.WriteError(w, http.StatusUnauthorized, "Account not found in context")
-		return
-	}
-	
-	// Ensure the user can only deposit to their own account
-	if req.AccountNumber != accountNumber {
+	// Customers can only deposit to their own account
+	if !middleware.CanAccessAccount(r.Context(), req.AccountNumber) {
 		utils.WriteError(w, http.StatusForbidden, "You can only deposit to your own account")
 		return
 	}
@@ -90,30 +93,69 @@ func (h *TransactionHandler) Withdraw(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	// Get account number from context (set by auth middleware)
-	accountNumber, ok := middleware.GetAccountNumberFromContext(r.Context())
-	if !ok {
-		utils.WriteError(w, http.StatusUnauthorized, "Account not found in context")
+	// Customers can only withdraw from their own account
+	if !middleware.CanAccessAccount(r.Context(), req.AccountNumber) {
+		utils.WriteError(w, http.StatusForbidden, "You can only withdraw from your own account")
 		return
 	}
+	req.Channel = requestChannel(r)
 	
-	// Ensure the user can only withdraw from their own account
-	if req.AccountNumber != accountNumber {
-		utils.WriteError(w, http.StatusForbidden, "You can only withdraw from your own account")
+	transaction, err := h.transactionService.Withdraw(&req)
+	if err != nil {
+		writeTransactionError(w, err)
 		return
 	}
 	
-	transaction, err := h.transactionService.Withdraw(&req)
+	utils.WriteSuccess(w, http.StatusCreated, "Withdrawal completed successfully", transaction)
+}
+
+// ReverseTransaction handles POST /transactions/{transactionId}/reverse
+func (h *TransactionHandler) ReverseTransaction(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	transactionID, exists := vars["transactionId"]
+	if !exists {
+		utils.WriteError(w, http.StatusBadRequest, "Transaction ID is required")
+		return
+	}
+	
+	// An empty body reverses the full remaining amount
+	var req models.ReversalRequest
+	if err := utils.ParseJSON(r, &req); err != nil && err != io.EOF {
+		utils.WriteError(w, http.StatusBadRequest, "Invalid JSON payload")
+		return
+	}
+	req.TransactionID = transactionID
+	
+	original, err := h.transactionService.GetTransaction(transactionID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	
+	// Only the recipient of the original transfer (or staff) can send the money back
+	if !middleware.CanAccessAccount(r.Context(), original.ToAccountNumber) {
+		utils.WriteError(w, http.StatusForbidden, "You can only reverse transactions received by your own account")
+		return
+	}
+	
+	// The fee is refunded out of the bank's income, so only compliance staff
+	// and admins may grant it
+	if req.RefundFee && !middleware.IsStaff(r.Context()) {
+		utils.WriteError(w, http.StatusForbidden, "Only bank staff can refund the fee of a transaction")
+		return
+	}
+	
+	reversal, err := h.transactionService.Reverse(&req)
 	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 	
-	utils.WriteSuccess(w, http.StatusCreated, "Withdrawal completed successfully", transaction)
+	utils.WriteSuccess(w, http.StatusCreated, "Transaction reversed successfully", reversal)
 }
 
-// GetTransaction handles GET /transactions/{transactionId}
-func (h *TransactionHandler) GetTransaction(w http.ResponseWriter, r *http.Request) {
+// CancelTransaction handles POST /transactions/{transactionId}/cancel
+func (h *TransactionHandler) CancelTransaction(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	transactionID, exists := vars["transactionId"]
 	if !exists {
@@ -127,15 +169,42 @@ func (h *TransactionHandler) GetTransaction(w http.ResponseWriter, r *http.Reque
 		return
 	}
 	
-	// Get account number from context to ensure user can only see their own transactions
-	accountNumber, ok := middleware.GetAccountNumberFromContext(r.Context())
-	if !ok {
-		utils.WriteError(w, http.StatusUnauthorized, "Account not found in context")
+	// Only the account that initiated the transaction (or staff) can cancel it
+	initiator := transaction.FromAccountNumber
+	if initiator == "" {
+		initiator = transaction.ToAccountNumber
+	}
+	if !middleware.CanAccessAccount(r.Context(), initiator) {
+		utils.WriteError(w, http.StatusForbidden, "You can only cancel your own transactions")
+		return
+	}
+	
+	cancelled, err := h.transactionService.CancelTransaction(transactionID)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	
+	utils.WriteSuccess(w, http.StatusOK, "Transaction cancelled successfully", cancelled)
+}
+
+// GetTransaction handles GET /transactions/{transactionId}
+func (h *TransactionHandler) GetTransaction(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	transactionID, exists := vars["transactionId"]
+	if !exists {
+		utils.WriteError(w, http.StatusBadRequest, "Transaction ID is required")
+		return
+	}
+	
+	transaction, err := h.transactionService.GetTransaction(transactionID)
+	if err != nil {
+		utils.WriteError(w, http.StatusNotFound, err.Error())
 		return
 	}
 	
 	// Check if the user is authorized to see this transaction
-	if transaction.FromAccountNumber != accountNumber && transaction.ToAccountNumber != accountNumber {
+	if !middleware.CanAccessAccount(r.Context(), transaction.FromAccountNumber) && !middleware.CanAccessAccount(r.Context(), transaction.ToAccountNumber) {
 		utils.WriteError(w, http.StatusForbidden, "You are not authorized to view this transaction")
 		return
 	}
@@ -145,10 +214,15 @@ func (h *TransactionHandler) GetTransaction(w http.ResponseWriter, r *http.Reque
 
 // GetTransactionHistory handles GET /transactions/history
 func (h *TransactionHandler) GetTransactionHistory(w http.ResponseWriter, r *http.Request) {
-	// Get account number from context
-	accountNumber, ok := middleware.GetAccountNumberFromContext(r.Context())
+	// Callers name the account to inspect; single-account customers may omit it
+	accountNumber, ok := requestAccountNumber(r)
 	if !ok {
-		utils.WriteError(w, http.StatusUnauthorized, "Account not found in context")
+		utils.WriteError(w, http.StatusBadRequest, "Account number is required")
+		return
+	}
+	
+	if !middleware.CanAccessAccount(r.Context(), accountNumber) {
+		utils.WriteError(w, http.StatusForbidden, "You are not authorized to view this account's history")
 		return
 	}
 	
@@ -200,3 +274,41 @@ func (h *TransactionHandler) GetTransactionHistory(w http.ResponseWriter, r *htt
 	
 	utils.WriteSuccess(w, http.StatusOK, "Transaction history retrieved successfully", transactions)
 }
+
+// requestAccountNumber returns the account named by the account_number query
+// parameter, defaulting to the caller's only account
+func requestAccountNumber(r *http.Request) (string, bool) {
+	if accountNumber := r.URL.Query().Get("account_number"); accountNumber != "" {
+		return accountNumber, true
+	}
+	return middleware.DefaultAccountNumber(r.Context())
+}
+
+// requestChannel returns the channel a request comes through: staff act at
+// the branch, customers online
+func requestChannel(r *http.Request) string {
+	if middleware.IsStaff(r.Context()) {
+		return models.ChannelBranch
+	}
+	return models.ChannelOnline
+}
+
+// requestActor identifies the caller for audit: their staff ID or customer ID
+func requestActor(r *http.Request) string {
+	if staffID, ok := middleware.GetStaffIDFromContext(r.Context()); ok && staffID != "" {
+		return staffID
+	}
+	customerID, _ := middleware.GetCustomerIDFromContext(r.Context())
+	return customerID
+}
+
+// writeTransactionError reports why a transaction was refused; one exceeding
+// the account's limits gets the LIMIT_EXCEEDED code
+func writeTransactionError(w http.ResponseWriter, err error) {
+	var limitErr *models.LimitExceededError
+	if errors.As(err, &limitErr) {
+		utils.WriteErrorCode(w, http.StatusUnprocessableEntity, models.ErrCodeLimitExceeded, err.Error())
+		return
+	}
+	utils.WriteError(w, http.StatusBadRequest, err.Error())
+}
@@ -3,8 +3,10 @@ package middleware
 import (
 	"context"
 	"net/http"
+	"slices"
 	"strings"
 
+	"github.com/bank-api/internal/models"
 	"github.com/bank-api/internal/repository"
 	"github.com/bank-api/internal/utils"
 )
@@ -12,12 +14,19 @@ import (
 type contextKey string
 
 const (
-	AccountNumberKey contextKey = "account_number"
-	CustomerIDKey    contextKey = "customer_id"
+	AccountNumbersKey contextKey = "account_numbers"
+	CustomerIDKey     contextKey = "customer_id"
+	StaffIDKey        contextKey = "staff_id"
+	ClientIDKey       contextKey = "client_id"
+	ScopesKey         contextKey = "scopes"
+	RoleKey           contextKey = "role"
+	ClaimsKey         contextKey = "claims"
 )
 
-// JWTAuthMiddleware validates JWT tokens
-func JWTAuthMiddleware(accountRepo repository.AccountRepository, secret string) func(http.Handler) http.Handler {
+// JWTAuthMiddleware validates JWT tokens, rejects tokens whose jti or session
+// has been revoked, and checks that the customer, staff user or API client
+// they were issued to is still active
+func JWTAuthMiddleware(customerRepo repository.CustomerRepository, accountRepo repository.AccountRepository, staffRepo repository.StaffRepository, clientRepo repository.APIClientRepository, sessionRepo repository.SessionRepository, keys *utils.JWTKeySet) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Get token from header
@@ -37,37 +46,133 @@ func JWTAuthMiddleware(accountRepo repository.AccountRepository, secret string)
 			tokenString := tokenParts[1]
 			
 			// Verify token
-			claims, err := utils.VerifyJWT(tokenString, secret)
+			claims, err := keys.Verify(tokenString)
 			if err != nil {
 				utils.WriteError(w, http.StatusUnauthorized, "Invalid token")
 				return
 			}
 			
-			// Verify account exists and is active
-			account, err := accountRepo.GetByAccountNumber(claims.AccountNumber)
+			role := claims.EffectiveRole()
+			if !models.IsValidRole(role) {
+				utils.WriteError(w, http.StatusUnauthorized, "Invalid token")
+				return
+			}
+			
+			// Tokens without a jti and session predate revocation support and
+			// cannot be revoked, so they are no longer accepted
+			if claims.ID == "" || claims.SessionID == "" {
+				utils.WriteError(w, http.StatusUnauthorized, "Invalid token")
+				return
+			}
+			
+			revoked, err := sessionRepo.IsRevoked(claims.ID, claims.SessionID)
+			if err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, "Failed to verify token")
+				return
+			}
+			if revoked {
+				utils.WriteError(w, http.StatusUnauthorized, "Token has been revoked")
+				return
+			}
+			
+			if role == models.RoleAPIClient {
+				// The client is reloaded on every request so that narrowing its
+				// accounts, scopes or addresses takes effect at once
+				client, err := clientRepo.GetByClientID(claims.ClientID)
+				if err != nil || !client.IsActive() {
+					utils.WriteError(w, http.StatusUnauthorized, "API client is not active")
+					return
+				}
+				
+				if !client.AllowsIP(utils.RemoteIP(r)) {
+					utils.WriteError(w, http.StatusForbidden, "API client may not call from this address")
+					return
+				}
+				
+				// A token keeps only the scopes the client still holds
+				var scopes []string
+				for _, scope := range claims.Scopes() {
+					if client.HasScope(scope) {
+						scopes = append(scopes, scope)
+					}
+				}
+				
+				ctx := context.WithValue(r.Context(), ClientIDKey, client.ClientID)
+				ctx = context.WithValue(ctx, AccountNumbersKey, client.AccountNumbers)
+				ctx = context.WithValue(ctx, ScopesKey, scopes)
+				ctx = context.WithValue(ctx, RoleKey, role)
+				ctx = context.WithValue(ctx, ClaimsKey, claims)
+				
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+			
+			if models.IsStaffRole(role) {
+				// Verify staff user exists, is active and still has this role
+				user, err := staffRepo.GetByStaffID(claims.StaffID)
+				if err != nil || !user.IsActive() || user.Role != role {
+					utils.WriteError(w, http.StatusUnauthorized, "Staff user is not active")
+					return
+				}
+				
+				ctx := context.WithValue(r.Context(), StaffIDKey, user.StaffID)
+				ctx = context.WithValue(ctx, RoleKey, role)
+				ctx = context.WithValue(ctx, ClaimsKey, claims)
+				
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+			
+			// Verify customer exists and is active
+			customer, err := customerRepo.GetByCustomerID(claims.CustomerID)
 			if err != nil {
-				utils.WriteError(w, http.StatusUnauthorized, "Account not found")
+				utils.WriteError(w, http.StatusUnauthorized, "Customer not found")
 				return
 			}
 			
-			if !account.IsActive() {
-				utils.WriteError(w, http.StatusUnauthorized, "Account is not active")
+			if !customer.IsActive() {
+				utils.WriteError(w, http.StatusUnauthorized, "Customer is not active")
+				return
+			}
+			
+			// Ownership checks compare against the customer's accounts
+			accounts, err := accountRepo.GetByCustomerID(customer.CustomerID)
+			if err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, "Failed to load customer accounts")
 				return
 			}
 			
-			// Add account info to context
-			ctx := context.WithValue(r.Context(), AccountNumberKey, claims.AccountNumber)
-			ctx = context.WithValue(ctx, CustomerIDKey, claims.CustomerID)
+			accountNumbers := make([]string, 0, len(accounts))
+			for _, account := range accounts {
+				accountNumbers = append(accountNumbers, account.AccountNumber)
+			}
+			
+			// Add customer info to context
+			ctx := context.WithValue(r.Context(), CustomerIDKey, customer.CustomerID)
+			ctx = context.WithValue(ctx, AccountNumbersKey, accountNumbers)
+			ctx = context.WithValue(ctx, RoleKey, role)
+			ctx = context.WithValue(ctx, ClaimsKey, claims)
 			
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
-// GetAccountNumberFromContext retrieves account number from request context
-func GetAccountNumberFromContext(ctx context.Context) (string, bool) {
-	accountNumber, ok := ctx.Value(AccountNumberKey).(string)
-	return accountNumber, ok
+// GetAccountNumbersFromContext retrieves the numbers of the customer's
+// accounts from request context
+func GetAccountNumbersFromContext(ctx context.Context) ([]string, bool) {
+	accountNumbers, ok := ctx.Value(AccountNumbersKey).([]string)
+	return accountNumbers, ok
+}
+
+// DefaultAccountNumber returns the customer's account when they own exactly
+// one, so single-account customers may omit the account number
+func DefaultAccountNumber(ctx context.Context) (string, bool) {
+	accountNumbers, ok := GetAccountNumbersFromContext(ctx)
+	if !ok || len(accountNumbers) != 1 {
+		return "", false
+	}
+	return accountNumbers[0], true
 }
 
 // GetCustomerIDFromContext retrieves customer ID from request context
@@ -75,3 +180,101 @@ func GetCustomerIDFromContext(ctx context.Context) (string, bool) {
 	customerID, ok := ctx.Value(CustomerIDKey).(string)
 	return customerID, ok
 }
+
+// GetStaffIDFromContext retrieves the staff user ID from request context
+func GetStaffIDFromContext(ctx context.Context) (string, bool) {
+	staffID, ok := ctx.Value(StaffIDKey).(string)
+	return staffID, ok
+}
+
+// GetClientIDFromContext retrieves the API client ID from request context
+func GetClientIDFromContext(ctx context.Context) (string, bool) {
+	clientID, ok := ctx.Value(ClientIDKey).(string)
+	return clientID, ok
+}
+
+// HasScope reports whether the caller is an API client whose token carries
+// one of scopes
+func HasScope(ctx context.Context, scopes ...string) bool {
+	granted, _ := ctx.Value(ScopesKey).([]string)
+	for _, scope := range scopes {
+		if slices.Contains(granted, scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetRoleFromContext retrieves the caller's role from request context
+func GetRoleFromContext(ctx context.Context) (string, bool) {
+	role, ok := ctx.Value(RoleKey).(string)
+	return role, ok
+}
+
+// GetClaimsFromContext retrieves the verified access token claims from request context
+func GetClaimsFromContext(ctx context.Context) (*utils.JWTClaims, bool) {
+	claims, ok := ctx.Value(ClaimsKey).(*utils.JWTClaims)
+	return claims, ok
+}
+
+// IsStaff reports whether the caller is a back-office staff user
+func IsStaff(ctx context.Context) bool {
+	role, _ := GetRoleFromContext(ctx)
+	return models.IsStaffRole(role)
+}
+
+// HasPermission reports whether the caller's role grants permission
+func HasPermission(ctx context.Context, permission string) bool {
+	role, ok := GetRoleFromContext(ctx)
+	return ok && models.HasPermission(role, permission)
+}
+
+// CanAccessAccount reports whether the caller may act on the account with the
+// given number: staff reach any account (route permissions still apply),
+// customers only their own
+func CanAccessAccount(ctx context.Context, accountNumber string) bool {
+	if IsStaff(ctx) {
+		return true
+	}
+	
+	accountNumbers, _ := GetAccountNumbersFromContext(ctx)
+	for _, own := range accountNumbers {
+		if accountNumber != "" && own == accountNumber {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccessCustomer reports whether the caller may act on accounts owned by
+// the given customer
+func CanAccessCustomer(ctx context.Context, customerID string) bool {
+	if IsStaff(ctx) {
+		return true
+	}
+	
+	own, ok := GetCustomerIDFromContext(ctx)
+	return ok && customerID != "" && own == customerID
+}
+
+// RequirePermission rejects callers whose role does not grant permission.
+// API clients are further limited to routes naming one of scopes that their
+// token carries. It must run after JWTAuthMiddleware.
+func RequirePermission(permission string, scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			role, ok := GetRoleFromContext(r.Context())
+			if !ok || !models.HasPermission(role, permission) {
+				utils.WriteError(w, http.StatusForbidden, "You do not have permission to perform this action")
+				return
+			}
+			
+			if role == models.RoleAPIClient && !HasScope(r.Context(), scopes...) {
+				utils.WriteErrorCode(w, http.StatusForbidden, models.ErrCodeInsufficientScope, "The token does not carry the scope this action needs")
+				return
+			}
+			
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/repository"
+	"github.com/bank-api/internal/utils"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// IdempotencyMiddleware makes money-moving endpoints safe to retry. When a
+// request carries an Idempotency-Key header, its response is stored and any
+// retry with the same key and body replays it instead of executing again.
+// Reusing a key with a different body is rejected with 422. Must run after
+// JWTAuthMiddleware since keys are scoped to the authenticated customer, staff
+// user or API client.
+func IdempotencyMiddleware(store repository.IdempotencyRepository, ttl time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > maxIdempotencyKeyLength {
+				utils.WriteError(w, http.StatusBadRequest, "Idempotency-Key must be at most 255 characters")
+				return
+			}
+
+			scope, ok := idempotencyScope(r)
+			if !ok {
+				utils.WriteError(w, http.StatusUnauthorized, "Account not found in context")
+				return
+			}
+
+			// Read the body so it can be fingerprinted, then restore it for the handler
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				utils.WriteError(w, http.StatusBadRequest, "Failed to read request body")
+				return
+			}
+			r.Body.Close()
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			now := time.Now().UTC()
+			record := &models.IdempotencyRecord{
+				Scope:       scope,
+				Key:         key,
+				Method:      r.Method,
+				Path:        r.URL.Path,
+				RequestHash: fingerprint(r.Method, r.URL.Path, body),
+				CreatedAt:   now,
+				ExpiresAt:   now.Add(ttl),
+			}
+
+			reserved, err := reserveIdempotencyKey(store, record)
+			if err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, "Failed to process Idempotency-Key")
+				return
+			}
+
+			if !reserved {
+				replayIdempotentResponse(w, store, record)
+				return
+			}
+
+			// A panicking handler must not leave the key reserved, or every retry
+			// would get 409 until the record expires
+			defer func() {
+				if p := recover(); p != nil {
+					store.Delete(scope, key)
+					panic(p)
+				}
+			}()
+
+			recorder := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
+			next.ServeHTTP(recorder, r)
+
+			// Server errors are not stored so the client can retry with the same
+			// key, nor are refusals the caller can fix, such as a missing step-up
+			if recorder.statusCode >= http.StatusInternalServerError || recorder.statusCode == http.StatusUnauthorized || recorder.statusCode == http.StatusForbidden {
+				store.Delete(scope, key)
+				return
+			}
+
+			store.Complete(scope, key, recorder.statusCode, recorder.body.Bytes())
+		})
+	}
+}
+
+// reserveIdempotencyKey claims the key, recycling it first if the previous
+// record for it has expired
+func reserveIdempotencyKey(store repository.IdempotencyRepository, record *models.IdempotencyRecord) (bool, error) {
+	reserved, err := store.Reserve(record)
+	if err != nil || reserved {
+		return reserved, err
+	}
+
+	existing, err := store.Get(record.Scope, record.Key)
+	if err != nil {
+		// Deleted between Reserve and Get; try once more
+		return store.Reserve(record)
+	}
+
+	if existing.IsExpired() {
+		if err := store.Delete(record.Scope, record.Key); err != nil {
+			return false, err
+		}
+		return store.Reserve(record)
+	}
+
+	return false, nil
+}
+
+func replayIdempotentResponse(w http.ResponseWriter, store repository.IdempotencyRepository, record *models.IdempotencyRecord) {
+	existing, err := store.Get(record.Scope, record.Key)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, "Failed to process Idempotency-Key")
+		return
+	}
+
+	if existing.RequestHash != record.RequestHash {
+		utils.WriteError(w, http.StatusUnprocessableEntity, "Idempotency-Key has already been used with a different request")
+		return
+	}
+
+	if !existing.Completed {
+		utils.WriteError(w, http.StatusConflict, "A request with this Idempotency-Key is still being processed")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(existing.StatusCode)
+	w.Write(existing.ResponseBody)
+}
+
+func fingerprint(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method + "\n" + path + "\n"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// responseRecorder passes the response through while keeping a copy of the
+// status code and body for storage
+type responseRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rr *responseRecorder) WriteHeader(code int) {
+	rr.statusCode = code
+	rr.ResponseWriter.WriteHeader(code)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
+
+// idempotencyScope namespaces keys by caller so that two callers can never
+// replay each other's responses
+func idempotencyScope(r *http.Request) (string, bool) {
+	if staffID, ok := GetStaffIDFromContext(r.Context()); ok {
+		return "staff:" + staffID, true
+	}
+	if customerID, ok := GetCustomerIDFromContext(r.Context()); ok {
+		return "customer:" + customerID, true
+	}
+	if clientID, ok := GetClientIDFromContext(r.Context()); ok {
+		return "client:" + clientID, true
+	}
+	return "", false
+}
@@ -2,46 +2,198 @@ package routes
 
 import (
 	"database/sql"
+	"expvar"
+	"fmt"
 	"net/http"
 
 	"github.com/bank-api/internal/api/handlers"
 	"github.com/bank-api/internal/api/middleware"
 	"github.com/bank-api/internal/config"
+	"github.com/bank-api/internal/ledger"
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/notify"
 	"github.com/bank-api/internal/repository"
 	"github.com/bank-api/internal/services"
+	"github.com/bank-api/internal/statements"
+	"github.com/bank-api/internal/utils"
 	"github.com/gorilla/mux"
 )
 
 type Router struct {
-	accountHandler     *handlers.AccountHandler
-	authHandler        *handlers.AuthHandler
-	transactionHandler *handlers.TransactionHandler
-	authMiddleware     func(http.Handler) http.Handler
+	accountHandler       *handlers.AccountHandler
+	customerHandler      *handlers.CustomerHandler
+	authHandler          *handlers.AuthHandler
+	transactionHandler   *handlers.TransactionHandler
+	fxHandler            *handlers.FXHandler
+	holdHandler          *handlers.HoldHandler
+	staffHandler         *handlers.StaffHandler
+	clearingHandler      *handlers.ClearingHandler
+	iso20022Handler      *handlers.ISO20022Handler
+	statementHandler     *handlers.StatementHandler
+	standingOrderHandler *handlers.StandingOrderHandler
+	feeHandler           *handlers.FeeHandler
+	interestHandler      *handlers.InterestHandler
+	limitHandler         *handlers.LimitHandler
+	overdraftHandler     *handlers.OverdraftHandler
+	mfaHandler           *handlers.MFAHandler
+	passwordHandler      *handlers.PasswordHandler
+	jwksHandler          *handlers.JWKSHandler
+	apiClientHandler     *handlers.APIClientHandler
+	authMiddleware       func(http.Handler) http.Handler
+	idempotency          func(http.Handler) http.Handler
 }
 
-func NewRouter(db *sql.DB, cfg *config.Config) *Router {
+func NewRouter(db *sql.DB, cfg *config.Config) (*Router, error) {
 	// Initialize repositories
 	accountRepo := repository.NewPostgresAccountRepository(db)
+	customerRepo := repository.NewPostgresCustomerRepository(db)
 	transactionRepo := repository.NewPostgresTransactionRepository(db)
+	txRunner := repository.NewPostgresTxRunner(db)
+	generalLedger := ledger.NewPostgresLedger(db)
+	idempotencyRepo := repository.NewPostgresIdempotencyRepository(db)
+	fxQuoteRepo := repository.NewPostgresFXQuoteRepository(db)
+	holdRepo := repository.NewPostgresHoldRepository(db)
+	staffRepo := repository.NewPostgresStaffRepository(db)
+	sessionRepo := repository.NewPostgresSessionRepository(db)
+	paymentRepo := repository.NewPostgresOutboundPaymentRepository(db)
+	statementRepo := repository.NewPostgresStatementRepository(db)
+	standingOrderRepo := repository.NewPostgresStandingOrderRepository(db)
+	feeScheduleRepo := repository.NewPostgresFeeScheduleRepository(db)
+	interestRepo := repository.NewPostgresInterestRepository(db)
+	limitRepo := repository.NewPostgresLimitRepository(db)
+	overdraftRepo := repository.NewPostgresOverdraftRepository(db)
+	mfaRepo := repository.NewPostgresMFARepository(db)
+	loginAttemptRepo := repository.NewPostgresLoginAttemptRepository(db)
+	passwordRepo := repository.NewPostgresPasswordRepository(db)
+	apiClientRepo := repository.NewPostgresAPIClientRepository(db)
+	
+	rateProvider, err := services.NewFXRateProvider(cfg.FX.Provider, cfg.FX.RatesFile)
+	if err != nil {
+		return nil, err
+	}
+	
+	bank, ok := models.LookupBankByCode(cfg.Bank.Code)
+	if !ok {
+		return nil, fmt.Errorf("unknown bank code: %s", cfg.Bank.Code)
+	}
+	if _, err := models.NewRIB(bank.Code, cfg.Bank.BranchCode, "0000000000000"); err != nil {
+		return nil, fmt.Errorf("invalid bank branch code %s: %w", cfg.Bank.BranchCode, err)
+	}
+	
+	gateway, err := services.NewPaymentGateway(cfg.Clearing.Gateway, cfg.Clearing.OutboxDir)
+	if err != nil {
+		return nil, err
+	}
+	
+	passwordPolicy, err := services.NewPasswordPolicy(cfg.Password.MinLength, cfg.Password.MinCharClasses, cfg.Password.HistoryDepth, cfg.Password.BreachedListFile)
+	if err != nil {
+		return nil, err
+	}
+	
+	sender, err := notify.NewSender(notify.Options{
+		Kind:         cfg.Notify.Sender,
+		OutboxDir:    cfg.Notify.OutboxDir,
+		SMTPAddr:     cfg.Notify.SMTPAddr,
+		SMTPUsername: cfg.Notify.SMTPUsername,
+		SMTPPassword: cfg.Notify.SMTPPassword,
+		SMTPFrom:     cfg.Notify.SMTPFrom,
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	// Access tokens are signed with the configured key pairs, or the shared
+	// secret when there are none
+	jwtKeys := utils.NewHMACKeySet(cfg.JWT.Secret, cfg.JWT.Issuer, cfg.JWT.Audience)
+	if cfg.JWT.SigningKeys != "" {
+		if jwtKeys, err = utils.LoadJWTKeySet(cfg.JWT.SigningKeys, cfg.JWT.KeyOverlap, cfg.JWT.Issuer, cfg.JWT.Audience); err != nil {
+			return nil, err
+		}
+	}
+	
+	var statementFont *statements.Font
+	if cfg.Statements.FontFile != "" {
+		if statementFont, err = statements.LoadFont(cfg.Statements.FontFile); err != nil {
+			return nil, err
+		}
+	}
 	
 	// Initialize services
-	accountService := services.NewAccountService(accountRepo)
-	transactionService := services.NewTransactionService(transactionRepo, accountRepo)
+	accountService := services.NewAccountService(accountRepo, customerRepo, txRunner, bank, cfg.Bank.BranchCode, passwordPolicy)
+	loginGuard := services.NewLoginGuard(customerRepo, loginAttemptRepo, models.LoginPolicy{
+		MaxFailures:     cfg.Login.MaxFailures,
+		LockoutDuration: cfg.Login.LockoutDuration,
+		DelayBase:       cfg.Login.DelayBase,
+		MaxDelay:        cfg.Login.MaxDelay,
+		IPMaxFailures:   cfg.Login.IPMaxFailures,
+		IPWindow:        cfg.Login.IPWindow,
+	})
+	customerService := services.NewCustomerService(customerRepo, accountRepo, loginGuard)
+	staffService := services.NewStaffService(staffRepo)
+	tokenService := services.NewTokenService(sessionRepo, customerRepo, staffRepo, txRunner, jwtKeys, cfg.JWT.ExpiresIn, cfg.JWT.RefreshExpiresIn, cfg.JWT.SessionLifetime)
+	fxService := services.NewFXService(rateProvider, fxQuoteRepo, cfg.FX.QuoteTTL, cfg.FX.BuySpreadBps, cfg.FX.SellSpreadBps)
+	transactionService := services.NewTransactionService(transactionRepo, accountRepo, generalLedger, txRunner, fxService, fxQuoteRepo, paymentRepo, feeScheduleRepo, limitRepo, bank)
+	holdService := services.NewHoldService(holdRepo, accountRepo, transactionRepo, generalLedger, txRunner, cfg.Holds.DefaultTTL, cfg.Holds.MaxTTL)
+	clearingService := services.NewClearingService(paymentRepo, transactionRepo, accountRepo, generalLedger, txRunner, gateway)
+	mfaService := services.NewMFAService(mfaRepo, customerRepo, accountRepo, transactionRepo, txRunner, loginGuard, cfg.MFA.Issuer, cfg.MFA.ChallengeTTL, cfg.MFA.StepUpThreshold, cfg.MFA.StepUpMaxAge)
+	iso20022Service := services.NewISO20022Service(accountRepo, customerRepo, transactionRepo, generalLedger, transactionService, mfaService)
+	statementService := services.NewStatementService(accountRepo, customerRepo, transactionRepo, statementRepo, generalLedger, bank, statementFont)
+	feeService := services.NewFeeService(feeScheduleRepo, accountRepo, transactionRepo)
+	interestService := services.NewInterestService(interestRepo, accountRepo, transactionRepo, generalLedger, txRunner, cfg.Interest.Capitalization, cfg.Interest.WithholdingBps, cfg.Interest.DayCount)
+	limitService := services.NewLimitService(limitRepo, accountRepo, transactionRepo)
+	overdraftService := services.NewOverdraftService(overdraftRepo, accountRepo, transactionRepo, generalLedger, txRunner, cfg.Overdraft.DefaultRateBps, cfg.Interest.DayCount)
+	passwordService := services.NewPasswordService(customerService, customerRepo, passwordRepo, sessionRepo, txRunner, passwordPolicy, sender, cfg.Password.ResetTTL)
+	standingOrderService := services.NewStandingOrderService(standingOrderRepo, accountRepo, transactionRepo, transactionService, txRunner, cfg.StandingOrders.MaxRetries, cfg.StandingOrders.RetryBackoff)
+	apiClientService := services.NewAPIClientService(apiClientRepo, accountRepo, sessionRepo, txRunner)
 	
 	// Initialize handlers
 	accountHandler := handlers.NewAccountHandler(accountService)
-	authHandler := handlers.NewAuthHandler(accountService, cfg.JWT.Secret, cfg.JWT.ExpiresIn)
-	transactionHandler := handlers.NewTransactionHandler(transactionService)
+	customerHandler := handlers.NewCustomerHandler(customerService, accountService, loginGuard)
+	authHandler := handlers.NewAuthHandler(customerService, staffService, tokenService, mfaService, loginGuard)
+	transactionHandler := handlers.NewTransactionHandler(transactionService, mfaService)
+	fxHandler := handlers.NewFXHandler(fxService)
+	holdHandler := handlers.NewHoldHandler(holdService)
+	staffHandler := handlers.NewStaffHandler(staffService)
+	clearingHandler := handlers.NewClearingHandler(clearingService, cfg.Clearing.CallbackSecret)
+	iso20022Handler := handlers.NewISO20022Handler(iso20022Service)
+	statementHandler := handlers.NewStatementHandler(statementService)
+	standingOrderHandler := handlers.NewStandingOrderHandler(standingOrderService, mfaService)
+	feeHandler := handlers.NewFeeHandler(feeService)
+	interestHandler := handlers.NewInterestHandler(interestService)
+	limitHandler := handlers.NewLimitHandler(limitService)
+	overdraftHandler := handlers.NewOverdraftHandler(overdraftService)
+	mfaHandler := handlers.NewMFAHandler(mfaService, tokenService)
+	passwordHandler := handlers.NewPasswordHandler(passwordService)
+	jwksHandler := handlers.NewJWKSHandler(jwtKeys)
+	apiClientHandler := handlers.NewAPIClientHandler(apiClientService, tokenService)
 	
 	// Initialize middleware
-	authMiddleware := middleware.JWTAuthMiddleware(accountRepo, cfg.JWT.Secret)
+	authMiddleware := middleware.JWTAuthMiddleware(customerRepo, accountRepo, staffRepo, apiClientRepo, sessionRepo, jwtKeys)
+	idempotency := middleware.IdempotencyMiddleware(idempotencyRepo, cfg.Idempotency.KeyTTL)
 	
 	return &Router{
-		accountHandler:     accountHandler,
-		authHandler:        authHandler,
-		transactionHandler: transactionHandler,
-		authMiddleware:     authMiddleware,
-	}
+		accountHandler:       accountHandler,
+		customerHandler:      customerHandler,
+		authHandler:          authHandler,
+		transactionHandler:   transactionHandler,
+		fxHandler:            fxHandler,
+		holdHandler:          holdHandler,
+		staffHandler:         staffHandler,
+		clearingHandler:      clearingHandler,
+		iso20022Handler:      iso20022Handler,
+		statementHandler:     statementHandler,
+		standingOrderHandler: standingOrderHandler,
+		feeHandler:           feeHandler,
+		interestHandler:      interestHandler,
+		limitHandler:         limitHandler,
+		overdraftHandler:     overdraftHandler,
+		mfaHandler:           mfaHandler,
+		passwordHandler:      passwordHandler,
+		jwksHandler:          jwksHandler,
+		apiClientHandler:     apiClientHandler,
+		authMiddleware:       authMiddleware,
+		idempotency:          idempotency,
+	}, nil
 }
 
 func (r *Router) SetupRoutes() *mux.Router {
@@ -52,6 +204,9 @@ func (r *Router) SetupRoutes() *mux.Router {
 	router.Use(middleware.CORSMiddleware)
 	router.Use(middleware.ContentTypeMiddleware)
 	
+	// Public keys access tokens can be verified with
+	router.HandleFunc("/.well-known/jwks.json", r.jwksHandler.GetJWKS).Methods("GET")
+	
 	// API version prefix
 	api := router.PathPrefix("/api/v1").Subrouter()
 	
@@ -61,37 +216,191 @@ func (r *Router) SetupRoutes() *mux.Router {
 	// Authentication routes (no auth required)
 	auth := api.PathPrefix("/auth").Subrouter()
 	auth.HandleFunc("/login", r.authHandler.Login).Methods("POST")
-	auth.HandleFunc("/logout", r.authHandler.Logout).Methods("POST")
+	auth.HandleFunc("/login/mfa", r.authHandler.LoginMFA).Methods("POST")
+	auth.HandleFunc("/staff/login", r.authHandler.StaffLogin).Methods("POST")
 	auth.HandleFunc("/refresh", r.authHandler.RefreshToken).Methods("POST")
+	auth.HandleFunc("/password/reset/request", r.passwordHandler.RequestReset).Methods("POST")
+	auth.HandleFunc("/password/reset", r.passwordHandler.ResetPassword).Methods("POST")
+	
+	// OAuth2 token endpoint of partner API clients (client credentials grant)
+	api.HandleFunc("/oauth/token", r.apiClientHandler.Token).Methods("POST")
+	
+	// Session routes (auth required)
+	sessions := auth.PathPrefix("").Subrouter()
+	sessions.Use(r.authMiddleware)
+	sessions.HandleFunc("/logout", r.authHandler.Logout).Methods("POST")
+	sessions.HandleFunc("/logout-all", r.authHandler.LogoutAll).Methods("POST")
+	sessions.HandleFunc("/password/change", r.passwordHandler.ChangePassword).Methods("POST")
+	
+	// Second factor of customers (auth required)
+	sessions.HandleFunc("/mfa", r.mfaHandler.GetStatus).Methods("GET")
+	sessions.HandleFunc("/mfa/enroll", r.mfaHandler.BeginEnrollment).Methods("POST")
+	sessions.HandleFunc("/mfa/enroll/verify", r.mfaHandler.ConfirmEnrollment).Methods("POST")
+	sessions.HandleFunc("/mfa/recovery-codes", r.mfaHandler.RegenerateRecoveryCodes).Methods("POST")
+	sessions.HandleFunc("/mfa/disable", r.mfaHandler.Disable).Methods("POST")
+	sessions.HandleFunc("/mfa/step-up", r.mfaHandler.StepUp).Methods("POST")
 	
 	// Account routes
 	accounts := api.PathPrefix("/accounts").Subrouter()
 	
-	// Public account routes (no auth required)
+	// Public account routes (no auth required): registers a new customer
 	accounts.HandleFunc("", r.accountHandler.CreateAccount).Methods("POST")
 	
 	// Protected account routes (auth required)
 	protectedAccounts := accounts.PathPrefix("").Subrouter()
 	protectedAccounts.Use(r.authMiddleware)
-	protectedAccounts.HandleFunc("", r.accountHandler.GetAccounts).Methods("GET")
-	protectedAccounts.HandleFunc("/{id:[0-9]+}", r.accountHandler.GetAccount).Methods("GET")
-	protectedAccounts.HandleFunc("/{id:[0-9]+}", r.accountHandler.UpdateAccount).Methods("PUT")
-	protectedAccounts.HandleFunc("/{id:[0-9]+}", r.accountHandler.DeleteAccount).Methods("DELETE")
-	protectedAccounts.HandleFunc("/{id:[0-9]+}/status", r.accountHandler.UpdateAccountStatus).Methods("PATCH")
-	protectedAccounts.HandleFunc("/{accountNumber}/balance", r.accountHandler.GetAccountBalance).Methods("GET")
+	protectedAccounts.Handle("", r.permit(models.PermAccountRead, r.accountHandler.GetAccounts)).Methods("GET")
+	protectedAccounts.Handle("/{id:[0-9]+}", r.permit(models.PermAccountRead, r.accountHandler.GetAccount)).Methods("GET")
+	protectedAccounts.Handle("/{id:[0-9]+}", r.permit(models.PermAccountDelete, r.accountHandler.DeleteAccount)).Methods("DELETE")
+	protectedAccounts.Handle("/{id:[0-9]+}/status", r.permit(models.PermAccountStatus, r.accountHandler.UpdateAccountStatus)).Methods("PATCH")
+	protectedAccounts.Handle("/{accountNumber}/balance", r.permitScoped(models.PermAccountRead, models.ScopeAccountsRead, r.accountHandler.GetAccountBalance)).Methods("GET")
+	protectedAccounts.Handle("/{accountNumber}/holds", r.permit(models.PermHoldRead, r.holdHandler.GetAccountHolds)).Methods("GET")
+	protectedAccounts.Handle("/{accountNumber}/payment-files", r.permitIdempotent(models.PermTransactionCreate, r.iso20022Handler.UploadPaymentFile)).Methods("POST")
+	protectedAccounts.Handle("/{accountNumber}/statements", r.permitScoped(models.PermTransactionRead, models.ScopeTransactionsRead, r.statementHandler.GetStatement)).Methods("GET")
+	protectedAccounts.Handle("/{accountNumber}/standing-orders", r.permit(models.PermStandingOrderRead, r.standingOrderHandler.GetAccountOrders)).Methods("GET")
+	protectedAccounts.Handle("/{accountNumber}/limits", r.permit(models.PermLimitRead, r.limitHandler.GetAccountLimits)).Methods("GET")
+	protectedAccounts.Handle("/{accountNumber}/limits", r.permit(models.PermLimitUpdate, r.limitHandler.UpdateAccountLimits)).Methods("PUT")
+	protectedAccounts.Handle("/{accountNumber}/limits", r.permit(models.PermLimitManage, r.limitHandler.ResetAccountLimits)).Methods("DELETE")
+	protectedAccounts.Handle("/{accountNumber}/overdraft", r.permit(models.PermOverdraftRead, r.overdraftHandler.GetAccountOverdrafts)).Methods("GET")
+	protectedAccounts.Handle("/{accountNumber}/overdraft", r.permit(models.PermOverdraftRequest, r.overdraftHandler.RequestOverdraft)).Methods("POST")
+	protectedAccounts.Handle("/{accountNumber}/statements/camt053", r.permit(models.PermTransactionRead, r.iso20022Handler.DownloadStatement)).Methods("GET")
+	
+	// Customer routes (all require auth)
+	customers := api.PathPrefix("/customers").Subrouter()
+	customers.Use(r.authMiddleware)
+	customers.Handle("/{customerId}", r.permit(models.PermCustomerRead, r.customerHandler.GetCustomer)).Methods("GET")
+	customers.Handle("/{customerId}", r.permit(models.PermCustomerUpdate, r.customerHandler.UpdateCustomer)).Methods("PUT")
+	customers.Handle("/{customerId}/accounts", r.permit(models.PermAccountRead, r.customerHandler.GetCustomerAccounts)).Methods("GET")
+	customers.Handle("/{customerId}/accounts", r.permit(models.PermAccountOpen, r.customerHandler.OpenAccount)).Methods("POST")
+	customers.Handle("/{customerId}/logins", r.permit(models.PermCustomerRead, r.customerHandler.GetLoginHistory)).Methods("GET")
+	customers.Handle("/{customerId}/unlock", r.permit(models.PermCustomerUnlock, r.customerHandler.UnlockLogin)).Methods("POST")
 	
 	// Transaction routes (all require auth)
 	transactions := api.PathPrefix("/transactions").Subrouter()
 	transactions.Use(r.authMiddleware)
-	transactions.HandleFunc("/transfer", r.transactionHandler.Transfer).Methods("POST")
-	transactions.HandleFunc("/deposit", r.transactionHandler.Deposit).Methods("POST")
-	transactions.HandleFunc("/withdraw", r.transactionHandler.Withdraw).Methods("POST")
-	transactions.HandleFunc("/history", r.transactionHandler.GetTransactionHistory).Methods("GET")
-	transactions.HandleFunc("/{transactionId}", r.transactionHandler.GetTransaction).Methods("GET")
+	transactions.Handle("/transfer", r.permitIdempotentScoped(models.PermTransactionCreate, models.ScopeTransfersWrite, r.transactionHandler.Transfer)).Methods("POST")
+	transactions.Handle("/deposit", r.permitIdempotentScoped(models.PermTransactionCreate, models.ScopeDepositsWrite, r.transactionHandler.Deposit)).Methods("POST")
+	transactions.Handle("/withdraw", r.permitIdempotent(models.PermTransactionCreate, r.transactionHandler.Withdraw)).Methods("POST")
+	transactions.Handle("/history", r.permitScoped(models.PermTransactionRead, models.ScopeTransactionsRead, r.transactionHandler.GetTransactionHistory)).Methods("GET")
+	transactions.Handle("/{transactionId}", r.permitScoped(models.PermTransactionRead, models.ScopeTransactionsRead, r.transactionHandler.GetTransaction)).Methods("GET")
+	transactions.Handle("/{transactionId}/reverse", r.permitIdempotent(models.PermTransactionRevert, r.transactionHandler.ReverseTransaction)).Methods("POST")
+	transactions.Handle("/{transactionId}/cancel", r.permit(models.PermTransactionCancel, r.transactionHandler.CancelTransaction)).Methods("POST")
+	
+	// Hold (authorization) routes (all require auth)
+	holds := api.PathPrefix("/holds").Subrouter()
+	holds.Use(r.authMiddleware)
+	holds.Handle("", r.permitIdempotent(models.PermHoldManage, r.holdHandler.PlaceHold)).Methods("POST")
+	holds.Handle("/{holdId}", r.permit(models.PermHoldRead, r.holdHandler.GetHold)).Methods("GET")
+	holds.Handle("/{holdId}/capture", r.permitIdempotent(models.PermHoldManage, r.holdHandler.CaptureHold)).Methods("POST")
+	holds.Handle("/{holdId}/release", r.permit(models.PermHoldManage, r.holdHandler.ReleaseHold)).Methods("POST")
+	
+	// Standing order routes (all require auth)
+	standingOrders := api.PathPrefix("/standing-orders").Subrouter()
+	standingOrders.Use(r.authMiddleware)
+	standingOrders.Handle("", r.permitIdempotent(models.PermStandingOrderManage, r.standingOrderHandler.CreateOrder)).Methods("POST")
+	standingOrders.Handle("/{orderId}", r.permit(models.PermStandingOrderRead, r.standingOrderHandler.GetOrder)).Methods("GET")
+	standingOrders.Handle("/{orderId}/pause", r.permit(models.PermStandingOrderManage, r.standingOrderHandler.PauseOrder)).Methods("POST")
+	standingOrders.Handle("/{orderId}/resume", r.permit(models.PermStandingOrderManage, r.standingOrderHandler.ResumeOrder)).Methods("POST")
+	standingOrders.Handle("/{orderId}/cancel", r.permit(models.PermStandingOrderManage, r.standingOrderHandler.CancelOrder)).Methods("POST")
+	standingOrders.Handle("/{orderId}/executions", r.permit(models.PermStandingOrderRead, r.standingOrderHandler.GetExecutions)).Methods("GET")
+	
+	// FX routes (auth required)
+	fx := api.PathPrefix("/fx").Subrouter()
+	fx.Use(r.authMiddleware)
+	fx.Handle("/quote", r.permit(models.PermFXQuote, r.fxHandler.GetQuote)).Methods("GET")
+	
+	// Fee routes (auth required); schedules are managed by admins
+	fees := api.PathPrefix("/fees").Subrouter()
+	fees.Use(r.authMiddleware)
+	fees.Handle("/quote", r.permit(models.PermFeeQuote, r.feeHandler.GetQuote)).Methods("GET")
+	fees.Handle("/schedules", r.permit(models.PermFeeManage, r.feeHandler.GetSchedules)).Methods("GET")
+	fees.Handle("/schedules", r.permit(models.PermFeeManage, r.feeHandler.CreateSchedule)).Methods("POST")
+	fees.Handle("/schedules/{id:[0-9]+}", r.permit(models.PermFeeManage, r.feeHandler.GetSchedule)).Methods("GET")
+	fees.Handle("/schedules/{id:[0-9]+}", r.permit(models.PermFeeManage, r.feeHandler.UpdateSchedule)).Methods("PUT")
+	fees.Handle("/schedules/{id:[0-9]+}", r.permit(models.PermFeeManage, r.feeHandler.DeactivateSchedule)).Methods("DELETE")
+	
+	// Interest routes (auth required); rates and accrual runs are managed by admins
+	interest := api.PathPrefix("/interest").Subrouter()
+	interest.Use(r.authMiddleware)
+	interest.Handle("/accruals", r.permit(models.PermInterestRead, r.interestHandler.GetAccrualReport)).Methods("GET")
+	interest.Handle("/accruals/run", r.permit(models.PermInterestManage, r.interestHandler.RunAccrual)).Methods("POST")
+	interest.Handle("/rate-tables", r.permit(models.PermInterestRead, r.interestHandler.GetRateTables)).Methods("GET")
+	interest.Handle("/rate-tables", r.permit(models.PermInterestManage, r.interestHandler.CreateRateTable)).Methods("POST")
+	
+	// Limit routes: the account type defaults are managed by admins
+	limits := api.PathPrefix("/limits").Subrouter()
+	limits.Use(r.authMiddleware)
+	limits.Handle("/defaults", r.permit(models.PermLimitManage, r.limitHandler.GetDefaults)).Methods("GET")
+	limits.Handle("/defaults/{accountType}/{currency}", r.permit(models.PermLimitManage, r.limitHandler.UpdateDefaults)).Methods("PUT")
+	
+	// Overdraft facilities are decided by admins
+	overdrafts := api.PathPrefix("/overdrafts").Subrouter()
+	overdrafts.Use(r.authMiddleware)
+	overdrafts.Use(middleware.RequirePermission(models.PermOverdraftManage))
+	overdrafts.HandleFunc("", r.overdraftHandler.GetOverdrafts).Methods("GET")
+	overdrafts.HandleFunc("/{id:[0-9]+}", r.overdraftHandler.GetOverdraft).Methods("GET")
+	overdrafts.HandleFunc("/{id:[0-9]+}/approve", r.overdraftHandler.ApproveOverdraft).Methods("POST")
+	overdrafts.HandleFunc("/{id:[0-9]+}/reject", r.overdraftHandler.RejectOverdraft).Methods("POST")
+	overdrafts.HandleFunc("/{id:[0-9]+}/revoke", r.overdraftHandler.RevokeOverdraft).Methods("POST")
+	
+	// Interbank clearing: the gateway's status callback is authenticated by
+	// its HMAC signature rather than a token
+	clearing := api.PathPrefix("/clearing").Subrouter()
+	clearing.HandleFunc("/callbacks", r.clearingHandler.Callback).Methods("POST")
+	
+	clearingPayments := clearing.PathPrefix("/payments").Subrouter()
+	clearingPayments.Use(r.authMiddleware)
+	clearingPayments.Handle("/{paymentId}", r.permit(models.PermTransactionRead, r.clearingHandler.GetPayment)).Methods("GET")
+	
+	// Runtime metrics, e.g. the pending transaction queue (admin only)
+	metrics := router.PathPrefix("/debug/vars").Subrouter()
+	metrics.Use(r.authMiddleware)
+	metrics.Handle("", r.permit(models.PermMetricsRead, expvar.Handler().ServeHTTP)).Methods("GET")
+	
+	// Back-office staff management (admin only)
+	staff := api.PathPrefix("/staff").Subrouter()
+	staff.Use(r.authMiddleware)
+	staff.Use(middleware.RequirePermission(models.PermStaffManage))
+	staff.HandleFunc("", r.staffHandler.CreateStaffUser).Methods("POST")
+	staff.HandleFunc("", r.staffHandler.GetStaffUsers).Methods("GET")
+	staff.HandleFunc("/{staffId}/status", r.staffHandler.UpdateStaffStatus).Methods("PATCH")
+	
+	// Partner API client registration (admin only)
+	apiClients := api.PathPrefix("/api-clients").Subrouter()
+	apiClients.Use(r.authMiddleware)
+	apiClients.Use(middleware.RequirePermission(models.PermAPIClientManage))
+	apiClients.HandleFunc("", r.apiClientHandler.CreateClient).Methods("POST")
+	apiClients.HandleFunc("", r.apiClientHandler.GetClients).Methods("GET")
+	apiClients.HandleFunc("/{clientId}", r.apiClientHandler.GetClient).Methods("GET")
+	apiClients.HandleFunc("/{clientId}", r.apiClientHandler.UpdateClient).Methods("PATCH")
+	apiClients.HandleFunc("/{clientId}/status", r.apiClientHandler.UpdateClientStatus).Methods("PATCH")
+	apiClients.HandleFunc("/{clientId}/secret", r.apiClientHandler.RotateSecret).Methods("POST")
 	
 	return router
 }
 
+// permit guards a handler with a role permission check
+func (r *Router) permit(permission string, handler http.HandlerFunc) http.Handler {
+	return middleware.RequirePermission(permission)(handler)
+}
+
+// permitIdempotent guards a money-moving handler with a permission check and
+// Idempotency-Key support
+func (r *Router) permitIdempotent(permission string, handler http.HandlerFunc) http.Handler {
+	return middleware.RequirePermission(permission)(r.idempotency(handler))
+}
+
+// permitScoped guards a handler that API clients may also call with scope
+func (r *Router) permitScoped(permission, scope string, handler http.HandlerFunc) http.Handler {
+	return middleware.RequirePermission(permission, scope)(handler)
+}
+
+// permitIdempotentScoped is permitIdempotent for a route API clients may
+// also call with scope
+func (r *Router) permitIdempotentScoped(permission, scope string, handler http.HandlerFunc) http.Handler {
+	return middleware.RequirePermission(permission, scope)(r.idempotency(handler))
+}
+
 func (r *Router) healthCheck(w http.ResponseWriter, req *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(`{"status": "OK", "message": "Bank API is running"}`))
@@ -1,61 +1,325 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"strconv"
 	"time"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	JWT      JWTConfig
+	Server         ServerConfig
+	Database       DatabaseConfig
+	JWT            JWTConfig
+	Idempotency    IdempotencyConfig
+	FX             FXConfig
+	Holds          HoldConfig
+	Admin          AdminConfig
+	Bank           BankConfig
+	Clearing       ClearingConfig
+	Statements     StatementConfig
+	StandingOrders StandingOrderConfig
+	Pending        PendingConfig
+	Interest       InterestConfig
+	Overdraft      OverdraftConfig
+	MFA            MFAConfig
+	Login          LoginConfig
+	Password       PasswordConfig
+	Notify         NotifyConfig
 }
 
+// DefaultJWTSecret is the built-in JWT secret, only accepted in development
+const DefaultJWTSecret = "votre_cle_jwt_secrete_pour_banque_tunisienne_2024"
+
 type ServerConfig struct {
 	Port         string
 	Host         string
 	ReadTimeout  time.Duration
 	WriteTimeout time.Duration
+	Environment  string // "development" relaxes the checks Validate makes for production
 }
 
 type DatabaseConfig struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
+	Host        string
+	Port        string
+	User        string
+	Password    string
+	DBName      string
+	SSLMode     string
+	AutoMigrate bool // Apply pending migrations when the server starts
 }
 
 type JWTConfig struct {
-	Secret     string
-	ExpiresIn  time.Duration
-	Issuer     string
+	Secret           string        // HS256 secret, used when no signing keys are configured
+	SigningKeys      string        // Comma-separated kid=path[@activation] PEM keys to sign with RS256/ES256
+	KeyOverlap       time.Duration // How long a replaced signing key still verifies tokens
+	ExpiresIn        time.Duration // Access token lifetime
+	RefreshExpiresIn time.Duration // Lifetime of each single-use refresh token
+	SessionLifetime  time.Duration // Hard cap on a login session, however often it is refreshed
+	Issuer           string
+	Audience         string
+}
+
+type IdempotencyConfig struct {
+	KeyTTL time.Duration // How long a stored response can be replayed for a given key
+}
+
+type FXConfig struct {
+	Provider      string        // "static" or "bct-mock"
+	RatesFile     string        // Optional JSON rates file for the static provider
+	QuoteTTL      time.Duration // How long a quoted rate stays locked
+	BuySpreadBps  int           // Spread when the bank buys foreign currency (into TND)
+	SellSpreadBps int           // Spread when the bank sells foreign currency
+}
+
+// AdminConfig describes the admin staff user created on startup when no
+// active admin exists. Bootstrapping is skipped when Password is empty.
+type AdminConfig struct {
+	Username string
+	Email    string
+	Password string
+}
+
+// BankConfig identifies the bank and branch that new accounts' RIBs and IBANs
+// are issued under
+type BankConfig struct {
+	Code       string // 2-digit BCT bank code, see models.LookupBankByCode
+	BranchCode string // 3-digit branch code
+}
+
+// ClearingConfig configures outbound payments to other banks
+type ClearingConfig struct {
+	Gateway          string        // "mock" or "file"
+	OutboxDir        string        // Where the file gateway writes payment files
+	CallbackSecret   string        // HMAC-SHA256 key the gateway signs status callbacks with
+	DispatchInterval time.Duration // How often queued payments are handed to the gateway
+}
+
+// StatementConfig configures account statements
+type StatementConfig struct {
+	FontFile    string        // TrueType font with Arabic glyphs for PDF statements; Courier without Arabic captions if empty
+	JobInterval time.Duration // How often the monthly statement job looks for accounts missing last month's statement
+}
+
+// StandingOrderConfig configures the execution of standing orders
+type StandingOrderConfig struct {
+	ExecutionInterval time.Duration // How often due standing orders are executed
+	MaxRetries        int           // Retries of an occurrence that failed for insufficient funds
+	RetryBackoff      time.Duration // Delay before the first retry; doubles with each one
+}
+
+// PendingConfig configures the worker that processes pending transactions
+type PendingConfig struct {
+	Interval     time.Duration // How often the worker looks for pending transactions
+	Concurrency  int           // Transactions processed in parallel
+	MaxAttempts  int           // Attempts before a pending transaction is moved to FAILED
+	RetryBackoff time.Duration // Delay after the first failed attempt; doubles with each one
+}
+
+// InterestConfig configures interest accrual and capitalization
+type InterestConfig struct {
+	JobInterval    time.Duration // How often the job accrues the business dates ended since its last run
+	Capitalization string        // MONTHLY or QUARTERLY: how often accrued interest is paid
+	WithholdingBps int           // Tax withheld at source from paid interest, in basis points
+	DayCount       int           // Days in the year daily rates are computed over
+}
+
+// OverdraftConfig configures debit interest on overdrawn accounts
+type OverdraftConfig struct {
+	JobInterval    time.Duration // How often the job accrues the business dates ended since its last run
+	DefaultRateBps int           // Yearly debit interest of facilities approved without a rate, in basis points
+}
+
+// MFAConfig configures customers' second factor and when transfers require it
+type MFAConfig struct {
+	Issuer          string        // Name authenticator apps show the TOTP entry under
+	ChallengeTTL    time.Duration // How long a login waits for the second factor
+	StepUpThreshold int64         // Transfers of this amount or more require a recent second factor; 0 disables
+	StepUpMaxAge    time.Duration // How long a verified second factor counts as recent
+}
+
+// LoginConfig limits password and second factor guessing on customer logins;
+// zero values disable the corresponding protection
+type LoginConfig struct {
+	MaxFailures      int           // Failed logins in a row that lock the customer out
+	LockoutDuration  time.Duration // How long a lockout lasts before it lifts by itself
+	DelayBase        time.Duration // Wait imposed after the first failed login, doubling with each one
+	MaxDelay         time.Duration // Longest wait between two attempts
+	IPMaxFailures    int           // Failed logins from one client IP within IPWindow that block it
+	IPWindow         time.Duration
+	HistoryRetention time.Duration // How long login attempts are kept
+}
+
+// PasswordConfig is the policy customer passwords must meet, and how password
+// resets work
+type PasswordConfig struct {
+	MinLength        int
+	MinCharClasses   int           // Of lower case letters, upper case letters, digits and symbols
+	HistoryDepth     int           // Recent passwords, the current one included, that cannot be chosen again; 0 disables
+	BreachedListFile string        // Passwords refused as breached, one per line; empty disables
+	ResetTTL         time.Duration // How long a password reset token can be used
+}
+
+// NotifyConfig selects how notifications such as password reset tokens are
+// delivered
+type NotifyConfig struct {
+	Sender       string // smtp, or log and file in development; empty disables password resets
+	OutboxDir    string // Where the file sender writes notifications
+	SMTPAddr     string // host:port of the mail server
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+}
+
+type HoldConfig struct {
+	DefaultTTL     time.Duration // Lifetime of a hold placed without an explicit expiry
+	MaxTTL         time.Duration // Longest lifetime a hold may be placed for
+	ExpiryInterval time.Duration // How often stale holds are expired
 }
 
 func Load() *Config {
+	environment := getEnv("APP_ENV", "production")
+
+	// Only development logs notifications by default; elsewhere a sender
+	// must be chosen for password resets to be available
+	defaultSender := ""
+	if environment == "development" {
+		defaultSender = "log"
+	}
+
 	return &Config{
 		Server: ServerConfig{
 			Port:         getEnv("PORT", "8080"), // Changed to match Docker
 			Host:         getEnv("SERVER_HOST", "0.0.0.0"), // Changed for Docker
 			ReadTimeout:  getDurationEnv("SERVER_READ_TIMEOUT", 30*time.Second),
 			WriteTimeout: getDurationEnv("SERVER_WRITE_TIMEOUT", 30*time.Second),
+			Environment:  environment,
 		},
 		Database: DatabaseConfig{
-			Host:     getEnv("DB_HOST", "localhost"),
-			Port:     getEnv("DB_PORT", "5433"),
-			User:     getEnv("DB_USER", "bankgo"),
-			Password: getEnv("DB_PASSWORD", "testbank"),
-			DBName:   getEnv("DB_NAME", "bankdb_tunisia"), // Updated database name
-			SSLMode:  getEnv("DB_SSLMODE", "disable"),
+			Host:        getEnv("DB_HOST", "localhost"),
+			Port:        getEnv("DB_PORT", "5433"),
+			User:        getEnv("DB_USER", "bankgo"),
+			Password:    getEnv("DB_PASSWORD", "testbank"),
+			DBName:      getEnv("DB_NAME", "bankdb_tunisia"), // Updated database name
+			SSLMode:     getEnv("DB_SSLMODE", "disable"),
+			AutoMigrate: getBoolEnv("DB_AUTO_MIGRATE", true),
 		},
 		JWT: JWTConfig{
-			Secret:    getEnv("JWT_SECRET", "votre_cle_jwt_secrete_pour_banque_tunisienne_2024"),
-			ExpiresIn: getDurationEnv("JWT_EXPIRES_IN", 24*time.Hour),
-			Issuer:    getEnv("JWT_ISSUER", "banque-tunisia-api"),
+			Secret:           getEnv("JWT_SECRET", DefaultJWTSecret),
+			SigningKeys:      getEnv("JWT_SIGNING_KEYS", ""),
+			KeyOverlap:       getDurationEnv("JWT_KEY_OVERLAP", time.Hour),
+			ExpiresIn:        getDurationEnv("JWT_EXPIRES_IN", 15*time.Minute),
+			RefreshExpiresIn: getDurationEnv("JWT_REFRESH_EXPIRES_IN", 7*24*time.Hour),
+			SessionLifetime:  getDurationEnv("JWT_SESSION_LIFETIME", 30*24*time.Hour),
+			Issuer:           getEnv("JWT_ISSUER", "banque-tunisia-api"),
+			Audience:         getEnv("JWT_AUDIENCE", "banque-tunisia-api"),
+		},
+		Idempotency: IdempotencyConfig{
+			KeyTTL: getDurationEnv("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+		},
+		FX: FXConfig{
+			Provider:      getEnv("FX_PROVIDER", "static"),
+			RatesFile:     getEnv("FX_RATES_FILE", ""),
+			QuoteTTL:      getDurationEnv("FX_QUOTE_TTL", 60*time.Second),
+			BuySpreadBps:  getIntEnv("FX_BUY_SPREAD_BPS", 50),
+			SellSpreadBps: getIntEnv("FX_SELL_SPREAD_BPS", 75),
+		},
+		Holds: HoldConfig{
+			DefaultTTL:     getDurationEnv("HOLD_DEFAULT_TTL", 7*24*time.Hour),
+			MaxTTL:         getDurationEnv("HOLD_MAX_TTL", 30*24*time.Hour),
+			ExpiryInterval: getDurationEnv("HOLD_EXPIRY_INTERVAL", time.Minute),
+		},
+		Admin: AdminConfig{
+			Username: getEnv("ADMIN_USERNAME", "admin"),
+			Email:    getEnv("ADMIN_EMAIL", "admin@bank.local"),
+			Password: getEnv("ADMIN_PASSWORD", ""),
+		},
+		Bank: BankConfig{
+			Code:       getEnv("BANK_CODE", "10"),
+			BranchCode: getEnv("BANK_BRANCH_CODE", "001"),
+		},
+		Clearing: ClearingConfig{
+			Gateway:          getEnv("CLEARING_GATEWAY", "mock"),
+			OutboxDir:        getEnv("CLEARING_OUTBOX_DIR", "./clearing/outbox"),
+			CallbackSecret:   getEnv("CLEARING_CALLBACK_SECRET", ""),
+			DispatchInterval: getDurationEnv("CLEARING_DISPATCH_INTERVAL", 30*time.Second),
+		},
+		Statements: StatementConfig{
+			FontFile:    getEnv("STATEMENT_FONT_FILE", ""),
+			JobInterval: getDurationEnv("STATEMENT_JOB_INTERVAL", time.Hour),
+		},
+		StandingOrders: StandingOrderConfig{
+			ExecutionInterval: getDurationEnv("STANDING_ORDER_INTERVAL", time.Minute),
+			MaxRetries:        getIntEnv("STANDING_ORDER_MAX_RETRIES", 5),
+			RetryBackoff:      getDurationEnv("STANDING_ORDER_RETRY_BACKOFF", time.Hour),
+		},
+		Pending: PendingConfig{
+			Interval:     getDurationEnv("PENDING_WORKER_INTERVAL", 10*time.Second),
+			Concurrency:  getIntEnv("PENDING_WORKER_CONCURRENCY", 4),
+			MaxAttempts:  getIntEnv("PENDING_MAX_ATTEMPTS", 5),
+			RetryBackoff: getDurationEnv("PENDING_RETRY_BACKOFF", 30*time.Second),
+		},
+		Interest: InterestConfig{
+			JobInterval:    getDurationEnv("INTEREST_JOB_INTERVAL", time.Hour),
+			Capitalization: getEnv("INTEREST_CAPITALIZATION", "QUARTERLY"),
+			WithholdingBps: getIntEnv("INTEREST_WITHHOLDING_TAX_BPS", 2000),
+			DayCount:       getIntEnv("INTEREST_DAY_COUNT", 365),
+		},
+		Overdraft: OverdraftConfig{
+			JobInterval:    getDurationEnv("OVERDRAFT_JOB_INTERVAL", time.Hour),
+			DefaultRateBps: getIntEnv("OVERDRAFT_DEFAULT_RATE_BPS", 1200),
+		},
+		MFA: MFAConfig{
+			Issuer:          getEnv("MFA_ISSUER", "Banque Tunisia"),
+			ChallengeTTL:    getDurationEnv("MFA_CHALLENGE_TTL", 5*time.Minute),
+			StepUpThreshold: int64(getIntEnv("MFA_STEP_UP_THRESHOLD", 1000000)),
+			StepUpMaxAge:    getDurationEnv("MFA_STEP_UP_MAX_AGE", 5*time.Minute),
 		},
+		Login: LoginConfig{
+			MaxFailures:      getIntEnv("LOGIN_MAX_FAILURES", 5),
+			LockoutDuration:  getDurationEnv("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+			DelayBase:        getDurationEnv("LOGIN_DELAY_BASE", time.Second),
+			MaxDelay:         getDurationEnv("LOGIN_MAX_DELAY", 30*time.Second),
+			IPMaxFailures:    getIntEnv("LOGIN_IP_MAX_FAILURES", 20),
+			IPWindow:         getDurationEnv("LOGIN_IP_WINDOW", 15*time.Minute),
+			HistoryRetention: getDurationEnv("LOGIN_HISTORY_RETENTION", 90*24*time.Hour),
+		},
+		Password: PasswordConfig{
+			MinLength:        getIntEnv("PASSWORD_MIN_LENGTH", 8),
+			MinCharClasses:   getIntEnv("PASSWORD_MIN_CHAR_CLASSES", 2),
+			HistoryDepth:     getIntEnv("PASSWORD_HISTORY_DEPTH", 5),
+			BreachedListFile: getEnv("PASSWORD_BREACHED_LIST_FILE", ""),
+			ResetTTL:         getDurationEnv("PASSWORD_RESET_TTL", 30*time.Minute),
+		},
+		Notify: NotifyConfig{
+			Sender:       getEnv("NOTIFY_SENDER", defaultSender),
+			OutboxDir:    getEnv("NOTIFY_OUTBOX_DIR", "./notifications/outbox"),
+			SMTPAddr:     getEnv("NOTIFY_SMTP_ADDR", ""),
+			SMTPUsername: getEnv("NOTIFY_SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("NOTIFY_SMTP_PASSWORD", ""),
+			SMTPFrom:     getEnv("NOTIFY_SMTP_FROM", ""),
+		},
+	}
+}
+
+// IsDevelopment reports whether the server runs in development mode
+func (c *Config) IsDevelopment() bool {
+	return c.Server.Environment == "development"
+}
+
+// Validate refuses settings that are unsafe outside development
+func (c *Config) Validate() error {
+	if c.JWT.SigningKeys == "" && c.JWT.Secret == DefaultJWTSecret && !c.IsDevelopment() {
+		return fmt.Errorf("JWT_SECRET is the built-in default: set JWT_SECRET or JWT_SIGNING_KEYS, or APP_ENV=development")
 	}
+	// Both write reset tokens where anyone reading logs or disk could use them
+	if (c.Notify.Sender == "log" || c.Notify.Sender == "file") && !c.IsDevelopment() {
+		return fmt.Errorf("NOTIFY_SENDER=%s exposes password reset tokens and is only allowed with APP_ENV=development", c.Notify.Sender)
+	}
+	if c.JWT.SigningKeys != "" && c.JWT.KeyOverlap < c.JWT.ExpiresIn {
+		return fmt.Errorf("JWT_KEY_OVERLAP must be at least JWT_EXPIRES_IN so tokens stay valid after their key is replaced")
+	}
+	return nil
 }
 
 func getEnv(key, defaultValue string) string {
@@ -82,3 +346,12 @@ func getIntEnv(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getBoolEnv(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,34 @@
+// Package iso20022 reads and writes the ISO 20022 XML messages exchanged with
+// corporate clients: pain.001 credit transfer initiations and camt.053
+// account statements.
+package iso20022
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bank-api/internal/models"
+)
+
+// parseAmount converts a decimal amount such as "125.5" into the currency's
+// minor unit, rejecting more decimals than the currency has
+func parseAmount(value, currency string) (int64, error) {
+	units, ok := models.CurrencyMinorUnits(currency)
+	if !ok {
+		return 0, fmt.Errorf("unsupported currency: %s", currency)
+	}
+
+	value = strings.TrimSpace(value)
+	whole, fraction, _ := strings.Cut(value, ".")
+	if whole == "" || len(fraction) > units || strings.HasPrefix(whole, "-") || strings.HasPrefix(whole, "+") {
+		return 0, fmt.Errorf("invalid amount %q for %s", value, currency)
+	}
+	fraction += strings.Repeat("0", units-len(fraction))
+
+	amount, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q for %s", value, currency)
+	}
+	return amount, nil
+}
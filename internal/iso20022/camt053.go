@@ -0,0 +1,233 @@
+package iso20022
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+
+	"github.com/bank-api/internal/models"
+)
+
+const camt053Namespace = "urn:iso:std:iso:20022:tech:xsd:camt.053.001.02"
+
+// Statement is the content of a camt.053 account statement for one period
+type Statement struct {
+	Account        *models.Account
+	OwnerName      string
+	From           time.Time // Start of the period, inclusive
+	To             time.Time // End of the period, exclusive
+	OpeningBalance int64
+	ClosingBalance int64
+	Transactions   []*models.Transaction // Booked transactions, oldest first
+}
+
+type camt053Document struct {
+	XMLName   xml.Name `xml:"Document"`
+	Namespace string   `xml:"xmlns,attr"`
+	Statement struct {
+		GroupHeader struct {
+			MessageID        string `xml:"MsgId"`
+			CreationDateTime string `xml:"CreDtTm"`
+		} `xml:"GrpHdr"`
+		Statement camt053Statement `xml:"Stmt"`
+	} `xml:"BkToCstmrStmt"`
+}
+
+type camt053Statement struct {
+	ID               string `xml:"Id"`
+	CreationDateTime string `xml:"CreDtTm"`
+	Period           struct {
+		From string `xml:"FrDtTm"`
+		To   string `xml:"ToDtTm"`
+	} `xml:"FrToDt"`
+	Account struct {
+		IBAN     string        `xml:"Id>IBAN"`
+		Currency string        `xml:"Ccy"`
+		Owner    *camt053Party `xml:"Ownr,omitempty"`
+		BIC      string        `xml:"Svcr>FinInstnId>BIC"`
+	} `xml:"Acct"`
+	Balances []camt053Balance `xml:"Bal"`
+	Summary  struct {
+		Entries struct {
+			Count int    `xml:"NbOfNtries"`
+			Sum   string `xml:"Sum"`
+		} `xml:"TtlNtries"`
+		Credits struct {
+			Count int    `xml:"NbOfNtries"`
+			Sum   string `xml:"Sum"`
+		} `xml:"TtlCdtNtries"`
+		Debits struct {
+			Count int    `xml:"NbOfNtries"`
+			Sum   string `xml:"Sum"`
+		} `xml:"TtlDbtNtries"`
+	} `xml:"TxsSummry"`
+	Entries []camt053Entry `xml:"Ntry"`
+}
+
+type camt053Party struct {
+	Name string `xml:"Nm"`
+}
+
+type camt053Amount struct {
+	Currency string `xml:"Ccy,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type camt053Balance struct {
+	Type      string        `xml:"Tp>CdOrPrtry>Cd"`
+	Amount    camt053Amount `xml:"Amt"`
+	Indicator string        `xml:"CdtDbtInd"`
+	Date      string        `xml:"Dt>Dt"`
+}
+
+type camt053Account struct {
+	IBAN  string `xml:"Id>IBAN,omitempty"`
+	Other string `xml:"Id>Othr>Id,omitempty"`
+}
+
+type camt053Parties struct {
+	Debtor          *camt053Party   `xml:"Dbtr,omitempty"`
+	DebtorAccount   *camt053Account `xml:"DbtrAcct,omitempty"`
+	Creditor        *camt053Party   `xml:"Cdtr,omitempty"`
+	CreditorAccount *camt053Account `xml:"CdtrAcct,omitempty"`
+}
+
+type camt053Entry struct {
+	Amount          camt053Amount `xml:"Amt"`
+	Indicator       string        `xml:"CdtDbtInd"`
+	Status          string        `xml:"Sts"`
+	BookingDate     string        `xml:"BookgDt>DtTm"`
+	ValueDate       string        `xml:"ValDt>Dt"`
+	ServicerRef     string        `xml:"AcctSvcrRef"`
+	TransactionCode struct {
+		Code   string `xml:"Cd"`
+		Issuer string `xml:"Issr"`
+	} `xml:"BkTxCd>Prtry"`
+	Details struct {
+		References struct {
+			EndToEndID    string `xml:"EndToEndId"`
+			TransactionID string `xml:"TxId"`
+		} `xml:"Refs"`
+		Charges    *camt053Amount  `xml:"Chrgs>Amt,omitempty"`
+		Parties    *camt053Parties `xml:"RltdPties,omitempty"`
+		Remittance string          `xml:"RmtInf>Ustrd,omitempty"`
+	} `xml:"NtryDtls>TxDtls"`
+}
+
+// BuildCamt053 renders a statement as a camt.053.001.02 document. Entries are
+// the statement's transactions seen from its account: debits include fees.
+func BuildCamt053(stmt *Statement) ([]byte, error) {
+	account := stmt.Account
+	now := time.Now().UTC()
+
+	var doc camt053Document
+	doc.Namespace = camt053Namespace
+	doc.Statement.GroupHeader.MessageID = fmt.Sprintf("CAMT053-%s-%d", account.AccountNumber, now.Unix())
+	doc.Statement.GroupHeader.CreationDateTime = now.Format(time.RFC3339)
+
+	s := &doc.Statement.Statement
+	s.ID = fmt.Sprintf("%s-%s-%s", account.AccountNumber, stmt.From.Format("20060102"), stmt.To.AddDate(0, 0, -1).Format("20060102"))
+	s.CreationDateTime = now.Format(time.RFC3339)
+	s.Period.From = stmt.From.UTC().Format(time.RFC3339)
+	s.Period.To = stmt.To.UTC().Format(time.RFC3339)
+	s.Account.IBAN = account.IBAN
+	s.Account.Currency = account.Currency
+	s.Account.BIC = account.BIC
+	if stmt.OwnerName != "" {
+		s.Account.Owner = &camt053Party{Name: stmt.OwnerName}
+	}
+
+	s.Balances = []camt053Balance{
+		balance("OPBD", stmt.OpeningBalance, account.Currency, stmt.From),
+		balance("CLBD", stmt.ClosingBalance, account.Currency, stmt.To.AddDate(0, 0, -1)),
+	}
+
+	var credits, debits int64
+	for _, transaction := range stmt.Transactions {
+		net := transaction.NetAmountFor(account.AccountNumber)
+		if net == 0 {
+			continue
+		}
+
+		entry := camt053Entry{Status: "BOOK", ServicerRef: transaction.TransactionID}
+		if net > 0 {
+			entry.Indicator = "CRDT"
+			credits += net
+			s.Summary.Credits.Count++
+		} else {
+			entry.Indicator = "DBIT"
+			net = -net
+			debits += net
+			s.Summary.Debits.Count++
+		}
+
+		entry.Amount = camt053Amount{Currency: account.Currency, Value: models.FormatAmount(net, account.Currency)}
+		entry.BookingDate = transaction.CreatedAt.UTC().Format(time.RFC3339)
+		entry.ValueDate = transaction.CreatedAt.UTC().Format("2006-01-02")
+		entry.TransactionCode.Code = transaction.TransactionType
+		entry.TransactionCode.Issuer = account.BIC
+
+		entry.Details.References.EndToEndID = "NOTPROVIDED"
+		if transaction.Reference != "" {
+			entry.Details.References.EndToEndID = transaction.Reference
+		}
+		entry.Details.References.TransactionID = transaction.TransactionID
+		entry.Details.Remittance = transaction.Description
+
+		// Fees are entries of their own, charged in full
+		if transaction.TransactionType == models.TransactionTypeFee {
+			entry.Details.Charges = &entry.Amount
+		}
+
+		entry.Details.Parties = counterparty(transaction, account.AccountNumber, entry.Indicator == "CRDT")
+
+		s.Entries = append(s.Entries, entry)
+	}
+
+	s.Summary.Entries.Count = len(s.Entries)
+	s.Summary.Entries.Sum = models.FormatAmount(credits+debits, account.Currency)
+	s.Summary.Credits.Sum = models.FormatAmount(credits, account.Currency)
+	s.Summary.Debits.Sum = models.FormatAmount(debits, account.Currency)
+
+	content, err := xml.MarshalIndent(&doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to build camt.053 statement: %w", err)
+	}
+
+	return append([]byte(xml.Header), content...), nil
+}
+
+func balance(code string, amount int64, currency string, date time.Time) camt053Balance {
+	indicator := "CRDT"
+	if amount < 0 {
+		indicator = "DBIT"
+		amount = -amount
+	}
+
+	return camt053Balance{
+		Type:      code,
+		Amount:    camt053Amount{Currency: currency, Value: models.FormatAmount(amount, currency)},
+		Indicator: indicator,
+		Date:      date.UTC().Format("2006-01-02"),
+	}
+}
+
+// counterparty describes the other side of an entry: the beneficiary of an
+// external transfer, or the other account of an internal one
+func counterparty(transaction *models.Transaction, accountNumber string, credited bool) *camt053Parties {
+	parties := &camt053Parties{}
+
+	switch {
+	case transaction.CounterpartyIBAN != "":
+		parties.Creditor = &camt053Party{Name: transaction.CounterpartyName}
+		parties.CreditorAccount = &camt053Account{IBAN: transaction.CounterpartyIBAN}
+	case credited && transaction.FromAccountNumber != "" && transaction.FromAccountNumber != accountNumber:
+		parties.DebtorAccount = &camt053Account{Other: transaction.FromAccountNumber}
+	case !credited && transaction.ToAccountNumber != "" && transaction.ToAccountNumber != accountNumber:
+		parties.CreditorAccount = &camt053Account{Other: transaction.ToAccountNumber}
+	default:
+		return nil
+	}
+
+	return parties
+}
@@ -0,0 +1,190 @@
+package iso20022
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/bank-api/internal/models"
+)
+
+// pain001Document is the subset of a pain.001 (Customer Credit Transfer
+// Initiation) message we read. Elements are matched by local name so
+// versions 001.001.03 through 001.001.09 are accepted.
+type pain001Document struct {
+	XMLName    xml.Name `xml:"Document"`
+	Initiation struct {
+		GroupHeader struct {
+			MessageID            string `xml:"MsgId"`
+			CreationDateTime     string `xml:"CreDtTm"`
+			NumberOfTransactions string `xml:"NbOfTxs"`
+			ControlSum           string `xml:"CtrlSum"`
+		} `xml:"GrpHdr"`
+		PaymentInfos []pain001PaymentInfo `xml:"PmtInf"`
+	} `xml:"CstmrCdtTrfInitn"`
+}
+
+type pain001PaymentInfo struct {
+	PaymentInfoID string         `xml:"PmtInfId"`
+	PaymentMethod string         `xml:"PmtMtd"`
+	DebtorAccount pain001Account `xml:"DbtrAcct"`
+	Transfers     []struct {
+		PaymentID struct {
+			InstructionID string `xml:"InstrId"`
+			EndToEndID    string `xml:"EndToEndId"`
+		} `xml:"PmtId"`
+		Amount struct {
+			Instructed struct {
+				Currency string `xml:"Ccy,attr"`
+				Value    string `xml:",chardata"`
+			} `xml:"InstdAmt"`
+		} `xml:"Amt"`
+		CreditorAgent struct {
+			BIC   string `xml:"FinInstnId>BIC"`
+			BICFI string `xml:"FinInstnId>BICFI"`
+		} `xml:"CdtrAgt"`
+		Creditor struct {
+			Name string `xml:"Nm"`
+		} `xml:"Cdtr"`
+		CreditorAccount pain001Account `xml:"CdtrAcct"`
+		Remittance      struct {
+			Unstructured []string `xml:"Ustrd"`
+		} `xml:"RmtInf"`
+	} `xml:"CdtTrfTxInf"`
+}
+
+type pain001Account struct {
+	IBAN string `xml:"Id>IBAN"`
+}
+
+// PaymentBatch is a parsed pain.001 file
+type PaymentBatch struct {
+	MessageID string       `json:"message_id"`
+	CreatedAt time.Time    `json:"created_at"`
+	Items     []*BatchItem `json:"items"`
+}
+
+// BatchItem is one credit transfer of a payment file. Request is set only
+// when the item passed validation; Errors lists why it did not.
+type BatchItem struct {
+	PaymentInfoID string                  `json:"payment_info_id"`
+	EndToEndID    string                  `json:"end_to_end_id"`
+	Request       *models.TransferRequest `json:"request,omitempty"`
+	Errors        []string                `json:"errors,omitempty"`
+}
+
+// Valid checks if the item can be executed
+func (i *BatchItem) Valid() bool {
+	return len(i.Errors) == 0
+}
+
+// ParsePain001 reads a pain.001 file debiting account. File-level problems
+// (malformed XML, a wrong transaction count or control sum) fail the whole
+// file; each transfer is validated on its own and turned into a
+// TransferRequest from account when it is valid.
+func ParsePain001(r io.Reader, account *models.Account) (*PaymentBatch, error) {
+	var doc pain001Document
+	if err := xml.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("invalid pain.001 document: %w", err)
+	}
+
+	header := doc.Initiation.GroupHeader
+	if header.MessageID == "" {
+		return nil, errors.New("pain.001 group header has no MsgId")
+	}
+
+	batch := &PaymentBatch{MessageID: header.MessageID}
+	if createdAt, err := time.Parse("2006-01-02T15:04:05", strings.TrimSuffix(header.CreationDateTime, "Z")); err == nil {
+		batch.CreatedAt = createdAt
+	} else if createdAt, err := time.Parse(time.RFC3339, header.CreationDateTime); err == nil {
+		batch.CreatedAt = createdAt
+	}
+
+	count := 0
+	var controlSum float64
+	for _, info := range doc.Initiation.PaymentInfos {
+		for _, transfer := range info.Transfers {
+			count++
+			item := &BatchItem{
+				PaymentInfoID: info.PaymentInfoID,
+				EndToEndID:    transfer.PaymentID.EndToEndID,
+			}
+
+			var value float64
+			fmt.Sscanf(transfer.Amount.Instructed.Value, "%g", &value)
+			controlSum += value
+
+			req := &models.TransferRequest{
+				FromAccountNumber: account.AccountNumber,
+				ToIBAN:            models.NormalizeIBAN(transfer.CreditorAccount.IBAN),
+				ToBIC:             transfer.CreditorAgent.BIC,
+				BeneficiaryName:   strings.TrimSpace(transfer.Creditor.Name),
+				Currency:          transfer.Amount.Instructed.Currency,
+				Description:       strings.Join(transfer.Remittance.Unstructured, " "),
+				Reference:         transfer.PaymentID.EndToEndID,
+				Channel:           models.ChannelBulk,
+			}
+			if req.ToBIC == "" {
+				req.ToBIC = transfer.CreditorAgent.BICFI
+			}
+			if req.Reference == "NOTPROVIDED" {
+				req.Reference = transfer.PaymentID.InstructionID
+			}
+
+			if info.PaymentMethod != "" && info.PaymentMethod != "TRF" {
+				item.Errors = append(item.Errors, fmt.Sprintf("unsupported payment method %s", info.PaymentMethod))
+			}
+			if debtor := models.NormalizeIBAN(info.DebtorAccount.IBAN); debtor != "" && debtor != account.IBAN {
+				item.Errors = append(item.Errors, fmt.Sprintf("debtor IBAN %s is not the account's IBAN", debtor))
+			}
+			if req.Currency != account.Currency {
+				item.Errors = append(item.Errors, fmt.Sprintf("currency %s does not match account currency %s", req.Currency, account.Currency))
+			} else if amount, err := parseAmount(transfer.Amount.Instructed.Value, req.Currency); err != nil {
+				item.Errors = append(item.Errors, err.Error())
+			} else if amount <= 0 {
+				item.Errors = append(item.Errors, "amount must be positive")
+			} else {
+				req.Amount = amount
+			}
+			if err := models.ValidateIBAN(req.ToIBAN); err != nil {
+				item.Errors = append(item.Errors, fmt.Sprintf("creditor IBAN: %v", err))
+			}
+			if req.ToBIC != "" {
+				if err := models.ValidateBIC(req.ToBIC); err != nil {
+					item.Errors = append(item.Errors, fmt.Sprintf("creditor BIC: %v", err))
+				}
+			}
+			if req.BeneficiaryName == "" {
+				item.Errors = append(item.Errors, "creditor name is required")
+			}
+
+			if item.Valid() {
+				item.Request = req
+			}
+			batch.Items = append(batch.Items, item)
+		}
+	}
+
+	if count == 0 {
+		return nil, errors.New("pain.001 file contains no credit transfers")
+	}
+
+	if header.NumberOfTransactions != "" && header.NumberOfTransactions != fmt.Sprint(count) {
+		return nil, fmt.Errorf("group header announces %s transactions but the file contains %d", header.NumberOfTransactions, count)
+	}
+
+	if header.ControlSum != "" {
+		var announced float64
+		if _, err := fmt.Sscanf(header.ControlSum, "%g", &announced); err != nil {
+			return nil, fmt.Errorf("invalid control sum %q", header.ControlSum)
+		}
+		if diff := announced - controlSum; diff > 0.0005 || diff < -0.0005 {
+			return nil, fmt.Errorf("control sum %s does not match the sum of the transfers", header.ControlSum)
+		}
+	}
+
+	return batch, nil
+}
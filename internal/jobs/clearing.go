@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"context"
+	"log"
+
+	"github.com/bank-api/internal/services"
+)
+
+// DispatchOutboundPayments hands queued outbound payments to the interbank
+// gateway, batchSize at a time, until none are left. Payments the gateway
+// refused stay queued for the next run.
+func DispatchOutboundPayments(clearing services.ClearingService, batchSize int) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		total := 0
+		for ctx.Err() == nil {
+			dispatched, err := clearing.DispatchQueued(batchSize)
+			if err != nil {
+				return err
+			}
+			total += dispatched
+			if dispatched < batchSize {
+				break
+			}
+		}
+
+		if total > 0 {
+			log.Printf("Dispatched %d outbound payments", total)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"context"
+	"log"
+
+	"github.com/bank-api/internal/services"
+)
+
+// ExpireHolds releases active holds past their expiry, batchSize at a time,
+// until none are left. Several replicas can run it concurrently: each batch
+// skips holds locked by another one.
+func ExpireHolds(holds services.HoldService, batchSize int) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		total := 0
+		for ctx.Err() == nil {
+			expired, err := holds.ExpireHolds(batchSize)
+			if err != nil {
+				return err
+			}
+			total += expired
+			if expired < batchSize {
+				break
+			}
+		}
+
+		if total > 0 {
+			log.Printf("Expired %d holds", total)
+		}
+		return nil
+	}
+}
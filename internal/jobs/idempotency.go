@@ -0,0 +1,23 @@
+package jobs
+
+import (
+	"context"
+	"log"
+
+	"github.com/bank-api/internal/repository"
+)
+
+// PurgeIdempotencyKeys deletes stored responses whose replay window has passed
+func PurgeIdempotencyKeys(repo repository.IdempotencyRepository) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		purged, err := repo.PurgeExpired()
+		if err != nil {
+			return err
+		}
+
+		if purged > 0 {
+			log.Printf("Purged %d expired idempotency keys", purged)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,59 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/bank-api/internal/services"
+)
+
+// AccrueInterest accrues every account's interest for each business date up
+// to yesterday not fully accrued yet, then capitalizes the interest accrued
+// in past capitalization periods, batchSize accounts at a time. Accruals and
+// capitalizations already made are skipped, so the job can run often and
+// catches up after downtime.
+func AccrueInterest(interest services.InterestService, batchSize int) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		now := time.Now().UTC()
+
+		dates, err := interest.PendingBusinessDates(now)
+		if err != nil {
+			return err
+		}
+		for _, date := range dates {
+			total := 0
+			for ctx.Err() == nil {
+				accrued, err := interest.AccrueInterest(date, batchSize)
+				if err != nil {
+					return err
+				}
+				total += accrued
+				if accrued < batchSize {
+					break
+				}
+			}
+
+			if total > 0 {
+				log.Printf("Accrued interest of %d accounts for %s", total, date.Format("2006-01-02"))
+			}
+		}
+
+		total := 0
+		for ctx.Err() == nil {
+			capitalized, err := interest.CapitalizeInterest(now, batchSize)
+			if err != nil {
+				return err
+			}
+			total += capitalized
+			if capitalized < batchSize {
+				break
+			}
+		}
+
+		if total > 0 {
+			log.Printf("Capitalized the interest of %d accounts", total)
+		}
+		return nil
+	}
+}
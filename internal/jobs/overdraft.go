@@ -0,0 +1,59 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/bank-api/internal/services"
+)
+
+// ChargeOverdraftInterest accrues the debit interest of overdrawn accounts
+// for each business date up to yesterday not fully accrued yet, then charges
+// the interest accrued in past months, batchSize accounts at a time.
+// Accruals and charges already made are skipped, so the job can run often and
+// catches up after downtime.
+func ChargeOverdraftInterest(overdrafts services.OverdraftService, batchSize int) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		now := time.Now().UTC()
+
+		dates, err := overdrafts.PendingBusinessDates(now)
+		if err != nil {
+			return err
+		}
+		for _, date := range dates {
+			total := 0
+			for ctx.Err() == nil {
+				accrued, err := overdrafts.AccrueInterest(date, batchSize)
+				if err != nil {
+					return err
+				}
+				total += accrued
+				if accrued < batchSize {
+					break
+				}
+			}
+
+			if total > 0 {
+				log.Printf("Accrued debit interest of %d accounts for %s", total, date.Format("2006-01-02"))
+			}
+		}
+
+		total := 0
+		for ctx.Err() == nil {
+			charged, err := overdrafts.ChargeInterest(now, batchSize)
+			if err != nil {
+				return err
+			}
+			total += charged
+			if charged < batchSize {
+				break
+			}
+		}
+
+		if total > 0 {
+			log.Printf("Charged the debit interest of %d accounts", total)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,81 @@
+package jobs
+
+import (
+	"context"
+	"expvar"
+	"log"
+	"sync"
+
+	"github.com/bank-api/internal/services"
+)
+
+// pendingMetrics are published under "pending_transactions" at /debug/vars:
+// the queue depth and the lag, in seconds, of its oldest transaction as seen
+// at the start of the last run, and running totals of attempt outcomes
+var pendingMetrics = expvar.NewMap("pending_transactions")
+
+// ProcessPendingTransactions drains the queue of pending transactions with
+// concurrency workers, each processing batchSize transactions at a time.
+// Workers claim transactions with row locks that skip those taken by others,
+// so replicas can run it concurrently. On shutdown each worker finishes the
+// transaction in hand and stops.
+func ProcessPendingTransactions(transactions services.TransactionService, concurrency, batchSize int, policy services.RetryPolicy) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		queue, err := transactions.PendingQueue()
+		if err != nil {
+			return err
+		}
+		pendingMetrics.Set("depth", intVar(int64(queue.Depth)))
+		pendingMetrics.Set("lag_seconds", intVar(int64(queue.Lag.Seconds())))
+		if queue.Depth == 0 {
+			return nil
+		}
+
+		var (
+			wg       sync.WaitGroup
+			mu       sync.Mutex
+			total    services.PendingRunResult
+			firstErr error
+		)
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				for ctx.Err() == nil {
+					result, err := transactions.ProcessPendingTransactions(batchSize, policy)
+
+					mu.Lock()
+					total.Completed += result.Completed
+					total.Retried += result.Retried
+					total.Failed += result.Failed
+					if err != nil && firstErr == nil {
+						firstErr = err
+					}
+					mu.Unlock()
+
+					if err != nil || result.Processed() < batchSize {
+						return
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		pendingMetrics.Add("completed", int64(total.Completed))
+		pendingMetrics.Add("retried", int64(total.Retried))
+		pendingMetrics.Add("failed", int64(total.Failed))
+
+		if total.Processed() > 0 {
+			log.Printf("Processed %d pending transactions: %d completed, %d to retry, %d failed",
+				total.Processed(), total.Completed, total.Retried, total.Failed)
+		}
+		return firstErr
+	}
+}
+
+func intVar(value int64) *expvar.Int {
+	v := new(expvar.Int)
+	v.Set(value)
+	return v
+}
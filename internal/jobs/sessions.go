@@ -0,0 +1,53 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/bank-api/internal/repository"
+)
+
+// PurgeAuthSessions deletes expired login sessions, their refresh tokens,
+// revocation entries for access tokens that have expired anyway, expired
+// MFA login challenges and expired password reset tokens
+func PurgeAuthSessions(repo repository.SessionRepository, mfaRepo repository.MFARepository, passwordRepo repository.PasswordRepository) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		purged, err := repo.PurgeExpired()
+		if err != nil {
+			return err
+		}
+
+		challenges, err := mfaRepo.PurgeExpiredChallenges()
+		if err != nil {
+			return err
+		}
+		purged += challenges
+
+		resetTokens, err := passwordRepo.PurgeExpiredResetTokens()
+		if err != nil {
+			return err
+		}
+		purged += resetTokens
+
+		if purged > 0 {
+			log.Printf("Purged %d expired sessions, revoked tokens, MFA challenges and password reset tokens", purged)
+		}
+		return nil
+	}
+}
+
+// PurgeLoginHistory deletes customer login attempts older than retention
+func PurgeLoginHistory(repo repository.LoginAttemptRepository, retention time.Duration) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		purged, err := repo.PurgeBefore(time.Now().UTC().Add(-retention))
+		if err != nil {
+			return err
+		}
+
+		if purged > 0 {
+			log.Printf("Purged %d login attempts", purged)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"context"
+	"log"
+
+	"github.com/bank-api/internal/services"
+)
+
+// ExecuteStandingOrders executes due standing orders, batchSize at a time,
+// until none are left. Several replicas can run it concurrently: each order
+// is executed by the replica that claimed it.
+func ExecuteStandingOrders(orders services.StandingOrderService, batchSize int) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		total := 0
+		for ctx.Err() == nil {
+			executed, err := orders.ExecuteDue(batchSize)
+			if err != nil {
+				return err
+			}
+			total += executed
+			if executed < batchSize {
+				break
+			}
+		}
+
+		if total > 0 {
+			log.Printf("Executed %d standing orders", total)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/bank-api/internal/services"
+)
+
+// GenerateMonthlyStatements stores last calendar month's statement of every
+// account, batchSize accounts at a time. Accounts that already have it are
+// skipped, so the job can run often and catches up after downtime.
+func GenerateMonthlyStatements(statements services.StatementService, batchSize int) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		now := time.Now().UTC()
+		lastMonth := time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, time.UTC)
+
+		total := 0
+		for ctx.Err() == nil {
+			generated, err := statements.GenerateMonthlyStatements(lastMonth, batchSize)
+			if err != nil {
+				return err
+			}
+			total += generated
+			if generated < batchSize {
+				break
+			}
+		}
+
+		if total > 0 {
+			log.Printf("Generated %d statements for %s", total, lastMonth.Format("2006-01"))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,140 @@
+package ledger
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Posting directions
+const (
+	Debit  = "DEBIT"
+	Credit = "CREDIT"
+)
+
+// Internal general ledger (GL) account codes. GL accounts exist once per
+// supported currency and hold the bank side of every customer movement.
+const (
+	GLCash               = "CASH"                    // Cash in hand / vault (asset, debit-normal)
+	GLFeeIncome          = "FEE_INCOME"              // Collected fees (income, credit-normal)
+	GLFXPosition         = "FX_POSITION"             // Currency bought/sold in conversions (debit-normal)
+	GLMerchantSettlement = "MERCHANT_SETTLEMENT"     // Captured payments owed to merchants (liability, credit-normal)
+	GLClearingSuspense   = "CLEARING_SUSPENSE"       // Outbound payments awaiting interbank settlement (liability, credit-normal)
+	GLNostro             = "NOSTRO"                  // Bank's settlement account at the central bank (asset, debit-normal)
+	GLInterestExpense    = "INTEREST_EXPENSE"        // Interest credited to customers (expense, debit-normal)
+	GLWithholdingTax     = "WITHHOLDING_TAX_PAYABLE" // Tax withheld from interest, owed to the state (liability, credit-normal)
+	GLInterestIncome     = "INTEREST_INCOME"         // Debit interest charged on overdrawn accounts (income, credit-normal)
+)
+
+// JournalEntry is a balanced set of postings recording one business event.
+// For every currency the sum of debits must equal the sum of credits.
+type JournalEntry struct {
+	ID            int        `json:"id" db:"id"`
+	EntryID       string     `json:"entry_id" db:"entry_id"`
+	TransactionID string     `json:"transaction_id" db:"transaction_id"`
+	Description   string     `json:"description" db:"description"`
+	Postings      []*Posting `json:"postings"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Posting is a single debit or credit leg of a journal entry. It targets
+// either a customer account (AccountID) or an internal GL account (GLCode).
+type Posting struct {
+	ID             int       `json:"id" db:"id"`
+	JournalEntryID int       `json:"journal_entry_id" db:"journal_entry_id"`
+	AccountID      int       `json:"account_id,omitempty" db:"account_id"`
+	GLCode         string    `json:"gl_code,omitempty" db:"gl_code"`
+	Currency       string    `json:"currency" db:"currency"`
+	Direction      string    `json:"direction" db:"direction"`
+	Amount         int64     `json:"amount" db:"amount"` // Always positive, in the currency's minor unit
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// NewJournalEntry creates an empty journal entry for the given transaction
+func NewJournalEntry(transactionID, description string) *JournalEntry {
+	return &JournalEntry{
+		EntryID:       generateEntryID(),
+		TransactionID: transactionID,
+		Description:   description,
+		CreatedAt:     time.Now().UTC(),
+	}
+}
+
+// DebitAccount adds a debit leg on a customer account (decreases its balance)
+func (e *JournalEntry) DebitAccount(accountID int, currency string, amount int64) *JournalEntry {
+	return e.add(&Posting{AccountID: accountID, Currency: currency, Direction: Debit, Amount: amount})
+}
+
+// CreditAccount adds a credit leg on a customer account (increases its balance)
+func (e *JournalEntry) CreditAccount(accountID int, currency string, amount int64) *JournalEntry {
+	return e.add(&Posting{AccountID: accountID, Currency: currency, Direction: Credit, Amount: amount})
+}
+
+// DebitGL adds a debit leg on an internal GL account
+func (e *JournalEntry) DebitGL(code, currency string, amount int64) *JournalEntry {
+	return e.add(&Posting{GLCode: code, Currency: currency, Direction: Debit, Amount: amount})
+}
+
+// CreditGL adds a credit leg on an internal GL account
+func (e *JournalEntry) CreditGL(code, currency string, amount int64) *JournalEntry {
+	return e.add(&Posting{GLCode: code, Currency: currency, Direction: Credit, Amount: amount})
+}
+
+// add appends a posting, skipping zero-amount legs (e.g. a waived fee)
+func (e *JournalEntry) add(posting *Posting) *JournalEntry {
+	if posting.Amount != 0 {
+		e.Postings = append(e.Postings, posting)
+	}
+	return e
+}
+
+// Validate checks that every posting is well formed and that the entry
+// balances (debits equal credits) independently for each currency
+func (e *JournalEntry) Validate() error {
+	if e.TransactionID == "" {
+		return errors.New("journal entry must reference a transaction")
+	}
+
+	if len(e.Postings) < 2 {
+		return errors.New("journal entry must have at least two postings")
+	}
+
+	sums := make(map[string]int64)
+	for _, p := range e.Postings {
+		if p.Amount <= 0 {
+			return errors.New("posting amount must be positive")
+		}
+		if p.Currency == "" {
+			return errors.New("posting currency is required")
+		}
+		if (p.AccountID == 0) == (p.GLCode == "") {
+			return errors.New("posting must target exactly one of a customer account or a GL account")
+		}
+
+		switch p.Direction {
+		case Debit:
+			sums[p.Currency] += p.Amount
+		case Credit:
+			sums[p.Currency] -= p.Amount
+		default:
+			return fmt.Errorf("invalid posting direction: %s", p.Direction)
+		}
+	}
+
+	for currency, sum := range sums {
+		if sum != 0 {
+			return fmt.Errorf("journal entry is unbalanced in %s by %d", currency, sum)
+		}
+	}
+
+	return nil
+}
+
+func generateEntryID() string {
+	timestamp := time.Now().Unix()
+	randomBytes := make([]byte, 8)
+	rand.Read(randomBytes)
+
+	return fmt.Sprintf("JE%d%x", timestamp, randomBytes)
+}
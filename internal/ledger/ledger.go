@@ -0,0 +1,228 @@
+package ledger
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bank-api/internal/repository"
+)
+
+// Ledger is the only writer of account balances. Every balance change is
+// recorded as a balanced journal entry and accounts.balance is maintained as
+// a cached projection of the postings.
+type Ledger interface {
+	// Post validates and records the entry, then updates the cached balances
+	// of every customer and GL account it touches. Must be called on a ledger
+	// bound to a transaction via WithTx so the entry and the balances it moves
+	// commit atomically with the business transaction.
+	Post(entry *JournalEntry) error
+	// AccountBalance recomputes a customer account balance from its postings
+	AccountBalance(accountID int) (int64, error)
+	// AccountBalanceAt recomputes a customer account balance from the postings
+	// recorded strictly before the given instant
+	AccountBalanceAt(accountID int, at time.Time) (int64, error)
+	// GLBalance returns the cached balance of an internal GL account
+	GLBalance(code, currency string) (int64, error)
+	GetEntriesByTransactionID(transactionID string) ([]*JournalEntry, error)
+	// WithTx returns a ledger whose queries run inside tx
+	WithTx(tx *sql.Tx) Ledger
+}
+
+type PostgresLedger struct {
+	db repository.DBTX
+}
+
+func NewPostgresLedger(db *sql.DB) Ledger {
+	return &PostgresLedger{db: db}
+}
+
+func (l *PostgresLedger) WithTx(tx *sql.Tx) Ledger {
+	return &PostgresLedger{db: tx}
+}
+
+func (l *PostgresLedger) Post(entry *JournalEntry) error {
+	if _, ok := l.db.(*sql.Tx); !ok {
+		return errors.New("ledger entries must be posted inside a database transaction")
+	}
+
+	if err := entry.Validate(); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO journal_entries (entry_id, transaction_id, description, created_at)
+		VALUES ($1, $2, $3, $4) RETURNING id`
+
+	err := l.db.QueryRow(query, entry.EntryID, entry.TransactionID, entry.Description, entry.CreatedAt).Scan(&entry.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create journal entry: %w", err)
+	}
+
+	for _, posting := range entry.Postings {
+		posting.JournalEntryID = entry.ID
+		posting.CreatedAt = entry.CreatedAt
+
+		if posting.AccountID != 0 {
+			err = l.postToAccount(posting)
+		} else {
+			err = l.postToGL(posting)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// postToAccount records a customer posting and applies it to the cached
+// balance. Customer deposits are bank liabilities, so credits increase them.
+func (l *PostgresLedger) postToAccount(posting *Posting) error {
+	delta := posting.Amount
+	if posting.Direction == Debit {
+		delta = -delta
+	}
+
+	query := `
+		UPDATE accounts SET
+			balance = balance + $1,
+			available_balance = available_balance + $1,
+			updated_at = $2
+		WHERE id = $3 AND currency = $4`
+
+	result, err := l.db.Exec(query, delta, time.Now().UTC(), posting.AccountID, posting.Currency)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("account with id %d not found in currency %s", posting.AccountID, posting.Currency)
+	}
+
+	insert := `
+		INSERT INTO postings (journal_entry_id, account_id, currency, direction, amount, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+
+	return l.db.QueryRow(
+		insert,
+		posting.JournalEntryID, posting.AccountID, posting.Currency,
+		posting.Direction, posting.Amount, posting.CreatedAt,
+	).Scan(&posting.ID)
+}
+
+// postToGL records a GL posting and applies it to the GL account's cached
+// balance according to its normal side
+func (l *PostgresLedger) postToGL(posting *Posting) error {
+	query := `
+		UPDATE gl_accounts SET
+			balance = balance + CASE WHEN normal_balance = $1 THEN $2::BIGINT ELSE -$2::BIGINT END,
+			updated_at = $3
+		WHERE code = $4 AND currency = $5
+		RETURNING id`
+
+	var glAccountID int
+	err := l.db.QueryRow(
+		query, posting.Direction, posting.Amount, time.Now().UTC(), posting.GLCode, posting.Currency,
+	).Scan(&glAccountID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return fmt.Errorf("GL account %s not found in currency %s", posting.GLCode, posting.Currency)
+		}
+		return err
+	}
+
+	insert := `
+		INSERT INTO postings (journal_entry_id, gl_account_id, currency, direction, amount, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+
+	return l.db.QueryRow(
+		insert,
+		posting.JournalEntryID, glAccountID, posting.Currency,
+		posting.Direction, posting.Amount, posting.CreatedAt,
+	).Scan(&posting.ID)
+}
+
+func (l *PostgresLedger) AccountBalance(accountID int) (int64, error) {
+	query := `
+		SELECT COALESCE(SUM(CASE WHEN direction = 'CREDIT' THEN amount ELSE -amount END), 0)
+		FROM postings WHERE account_id = $1`
+
+	var balance int64
+	err := l.db.QueryRow(query, accountID).Scan(&balance)
+	return balance, err
+}
+
+func (l *PostgresLedger) AccountBalanceAt(accountID int, at time.Time) (int64, error) {
+	query := `
+		SELECT COALESCE(SUM(CASE WHEN direction = 'CREDIT' THEN amount ELSE -amount END), 0)
+		FROM postings WHERE account_id = $1 AND created_at < $2`
+
+	var balance int64
+	err := l.db.QueryRow(query, accountID, at).Scan(&balance)
+	return balance, err
+}
+
+func (l *PostgresLedger) GLBalance(code, currency string) (int64, error) {
+	query := `SELECT balance FROM gl_accounts WHERE code = $1 AND currency = $2`
+
+	var balance int64
+	err := l.db.QueryRow(query, code, currency).Scan(&balance)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, fmt.Errorf("GL account %s not found in currency %s", code, currency)
+		}
+		return 0, err
+	}
+
+	return balance, nil
+}
+
+func (l *PostgresLedger) GetEntriesByTransactionID(transactionID string) ([]*JournalEntry, error) {
+	query := `
+		SELECT je.id, je.entry_id, je.transaction_id, je.description, je.created_at,
+			   p.id, COALESCE(p.account_id, 0), COALESCE(g.code, ''), p.currency,
+			   p.direction, p.amount, p.created_at
+		FROM journal_entries je
+		JOIN postings p ON p.journal_entry_id = je.id
+		LEFT JOIN gl_accounts g ON g.id = p.gl_account_id
+		WHERE je.transaction_id = $1
+		ORDER BY je.id, p.id`
+
+	rows, err := l.db.Query(query, transactionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*JournalEntry
+	var current *JournalEntry
+	for rows.Next() {
+		entry := &JournalEntry{}
+		posting := &Posting{}
+
+		err := rows.Scan(
+			&entry.ID, &entry.EntryID, &entry.TransactionID, &entry.Description, &entry.CreatedAt,
+			&posting.ID, &posting.AccountID, &posting.GLCode, &posting.Currency,
+			&posting.Direction, &posting.Amount, &posting.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if current == nil || current.ID != entry.ID {
+			current = entry
+			entries = append(entries, current)
+		}
+		posting.JournalEntryID = current.ID
+		current.Postings = append(current.Postings, posting)
+	}
+
+	return entries, rows.Err()
+}
@@ -2,11 +2,11 @@ package models
 
 import (
 	"errors"
+	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
-
-	"golang.org/x/crypto/bcrypt"
 )
 
 // Account represents a Tunisian bank account following BCT (Central Bank of Tunisia) standards
@@ -21,26 +21,11 @@ type Account struct {
 	Balance         int64     `json:"balance" db:"balance"`     // Amount in millimes (1 TND = 1000 millimes)
 	AvailableBalance int64    `json:"available_balance" db:"available_balance"`
 	HoldAmount      int64     `json:"hold_amount" db:"hold_amount"`
-	FirstName       string    `json:"first_name" db:"first_name"`
-	LastName        string    `json:"last_name" db:"last_name"`
-	Email           string    `json:"email" db:"email"`
-	Phone           string    `json:"phone" db:"phone"`
-	DateOfBirth     time.Time `json:"date_of_birth" db:"date_of_birth"`
-	Address         Address   `json:"address" db:"address"`
-	HashPassword    string    `json:"-" db:"hash_password"`
+	OverdraftLimit  int64     `json:"overdraft_limit" db:"overdraft_limit"`        // Authorized overdraft; debits may go down to -OverdraftLimit
+	OverdraftRateBps int      `json:"overdraft_rate_bps" db:"overdraft_rate_bps"` // Yearly debit interest on a negative balance
 	Status          string    `json:"status" db:"status"`
 	CreatedAt       time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
-	LastLoginAt     *time.Time `json:"last_login_at" db:"last_login_at"`
-}
-
-// Address represents customer address
-type Address struct {
-	Street     string `json:"street" db:"street"`
-	City       string `json:"city" db:"city"`
-	PostalCode string `json:"postal_code" db:"postal_code"`
-	Country    string `json:"country" db:"country"`
-	State      string `json:"state" db:"state"`
 }
 
 // Account status constants
@@ -66,9 +51,35 @@ const (
 	CurrencyUSD = "USD" // US Dollar (for foreign accounts)
 )
 
-// ValidatePassword checks if the provided password matches the hashed password
-func (a *Account) ValidatePassword(password string) bool {
-	return bcrypt.CompareHashAndPassword([]byte(a.HashPassword), []byte(password)) == nil
+// currencyMinorUnits is the number of decimals of each currency's minor unit
+var currencyMinorUnits = map[string]int{
+	CurrencyTND: 3, // millimes
+	CurrencyEUR: 2, // cents
+	CurrencyUSD: 2, // cents
+}
+
+// CurrencyMinorUnits returns how many decimals the currency's minor unit has
+func CurrencyMinorUnits(currency string) (int, bool) {
+	units, ok := currencyMinorUnits[currency]
+	return units, ok
+}
+
+// FormatAmount renders a minor-unit amount as a decimal in the currency's
+// major unit, e.g. 12500 TND as "12.500"
+func FormatAmount(amount int64, currency string) string {
+	units, ok := CurrencyMinorUnits(currency)
+	if !ok || units == 0 {
+		return strconv.FormatInt(amount, 10)
+	}
+
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := fmt.Sprintf("%0*d", units+1, amount)
+	return sign + digits[:len(digits)-units] + "." + digits[len(digits)-units:]
 }
 
 // IsActive checks if the account is active
@@ -76,9 +87,11 @@ func (a *Account) IsActive() bool {
 	return a.Status == AccountStatusActive
 }
 
-// HasSufficientBalance checks if account has sufficient balance for a transaction
+// HasSufficientBalance checks if account has sufficient balance for a transaction,
+// net of the amount reserved by outstanding holds and including its
+// authorized overdraft
 func (a *Account) HasSufficientBalance(amount int64) bool {
-	return a.AvailableBalance >= amount
+	return a.GetAvailableBalance()+a.OverdraftLimit >= amount
 }
 
 // GetAvailableBalance calculates available balance in millimes
@@ -86,27 +99,38 @@ func (a *Account) GetAvailableBalance() int64 {
 	return a.Balance - a.HoldAmount
 }
 
-// ValidateTunisianIBAN validates Tunisian IBAN format (TN59 + 20 digits)
-func ValidateTunisianIBAN(iban string) error {
-	iban = strings.ReplaceAll(strings.ToUpper(iban), " ", "")
-	
-	// Tunisian IBAN: TN + 2 check digits + 20 digits = 24 characters total
-	if len(iban) != 24 {
-		return errors.New("IBAN tunisien doit contenir exactement 24 caractères")
+// GetOverdraftUsed returns how much of the available balance is drawn on the
+// overdraft
+func (a *Account) GetOverdraftUsed() int64 {
+	if available := a.GetAvailableBalance(); available < 0 {
+		return -available
+	}
+	return 0
+}
+
+// GetOverdraftRemaining returns how much more the account can overdraw
+func (a *Account) GetOverdraftRemaining() int64 {
+	if remaining := a.OverdraftLimit - a.GetOverdraftUsed(); remaining > 0 {
+		return remaining
 	}
+	return 0
+}
+
+// ValidateTunisianIBAN validates a Tunisian IBAN: TN, ISO 13616 check digits
+// and a 20-digit RIB with a valid RIB key
+func ValidateTunisianIBAN(iban string) error {
+	iban = NormalizeIBAN(iban)
 	
-	// Must start with TN followed by 2 check digits
 	if !strings.HasPrefix(iban, "TN") {
 		return errors.New("IBAN tunisien doit commencer par 'TN'")
 	}
 	
-	// Validate format: TN + 2 digits + 20 alphanumeric
-	tunisianIBANRegex := regexp.MustCompile(`^TN[0-9]{2}[0-9]{20}$`)
-	if !tunisianIBANRegex.MatchString(iban) {
-		return errors.New("format IBAN tunisien invalide (TN + 2 chiffres de contrôle + 20 chiffres)")
+	if err := ValidateIBAN(iban); err != nil {
+		return err
 	}
 	
-	return nil
+	_, err := ParseRIB(iban[4:])
+	return err
 }
 
 // ValidateBIC validates BIC format
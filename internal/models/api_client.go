@@ -0,0 +1,136 @@
+package models
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Scopes an API client can be granted. Each route open to API clients names
+// the scope it needs; every other route stays closed to them.
+const (
+	ScopeAccountsRead     = "accounts:read"
+	ScopeTransactionsRead = "transactions:read" // History, single transactions and statements
+	ScopeDepositsWrite    = "deposits:write"
+	ScopeTransfersWrite   = "transfers:write"
+)
+
+var apiClientScopes = []string{ScopeAccountsRead, ScopeTransactionsRead, ScopeDepositsWrite, ScopeTransfersWrite}
+
+// ErrCodeInsufficientScope is the error code of a request refused because
+// the API client's token lacks the route's scope
+const ErrCodeInsufficientScope = "INSUFFICIENT_SCOPE"
+
+// API client status constants
+const (
+	APIClientStatusActive   = "ACTIVE"
+	APIClientStatusDisabled = "DISABLED"
+)
+
+// GrantTypeClientCredentials is the only OAuth2 grant the token endpoint supports
+const GrantTypeClientCredentials = "client_credentials"
+
+// APIClient is a partner system, such as an ERP or accounting package, that
+// obtains access tokens with the OAuth2 client credentials grant. It acts
+// only on its allowed accounts, within its scopes and from its allowed
+// addresses.
+type APIClient struct {
+	ID             int        `json:"-" db:"id"`
+	ClientID       string     `json:"client_id" db:"client_id"`
+	Name           string     `json:"name" db:"name"`
+	SecretHash     string     `json:"-" db:"secret_hash"`
+	Scopes         []string   `json:"scopes" db:"scopes"`
+	AccountNumbers []string   `json:"account_numbers"`
+	AllowedIPs     []string   `json:"allowed_ips" db:"allowed_ips"` // IPs and CIDR ranges; empty allows any address
+	Status         string     `json:"status" db:"status"`
+	CreatedBy      string     `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+	LastUsedAt     *time.Time `json:"last_used_at" db:"last_used_at"`
+}
+
+// APIClientCredentials is returned when a client is registered or its secret
+// rotated; the secret is never shown again
+type APIClientCredentials struct {
+	*APIClient
+	ClientSecret string `json:"client_secret"`
+}
+
+// IsActive checks if the client may obtain and use tokens
+func (c *APIClient) IsActive() bool {
+	return c.Status == APIClientStatusActive
+}
+
+// HasScope checks if the client was granted scope
+func (c *APIClient) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes, scope)
+}
+
+// AllowsIP checks if the client may call from ip
+func (c *APIClient) AllowsIP(ip string) bool {
+	if len(c.AllowedIPs) == 0 {
+		return true
+	}
+
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, allowed := range c.AllowedIPs {
+		if _, network, err := net.ParseCIDR(allowed); err == nil {
+			if network.Contains(addr) {
+				return true
+			}
+		} else if allowedIP := net.ParseIP(allowed); allowedIP != nil && allowedIP.Equal(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidScope checks if scope is one API clients can be granted
+func IsValidScope(scope string) bool {
+	return slices.Contains(apiClientScopes, scope)
+}
+
+// ValidateScopes checks a client's scopes: at least one, all known
+func ValidateScopes(scopes []string) error {
+	if len(scopes) == 0 {
+		return fmt.Errorf("at least one scope is required")
+	}
+	for _, scope := range scopes {
+		if !IsValidScope(scope) {
+			return fmt.Errorf("unknown scope %s; must be one of %s", scope, strings.Join(apiClientScopes, ", "))
+		}
+	}
+	return nil
+}
+
+// ValidateAllowedIPs checks that each entry is an IP address or CIDR range
+func ValidateAllowedIPs(allowedIPs []string) error {
+	for _, allowed := range allowedIPs {
+		if _, _, err := net.ParseCIDR(allowed); err == nil {
+			continue
+		}
+		if net.ParseIP(allowed) == nil {
+			return fmt.Errorf("invalid IP address or CIDR range: %s", allowed)
+		}
+	}
+	return nil
+}
+
+// OAuthTokenResponse is the token endpoint's answer (RFC 6749 section 5.1)
+type OAuthTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"` // Seconds
+	Scope       string `json:"scope"`
+}
+
+// OAuthErrorResponse is the token endpoint's error (RFC 6749 section 5.2)
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "strings"
+
+// Bank is a Tunisian bank as identified in RIBs (by its BCT bank code) and
+// in international payments (by its BIC)
+type Bank struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+	BIC  string `json:"bic"`
+}
+
+// tunisianBanks is the registry of Tunisian banks keyed by BCT bank code
+var tunisianBanks = map[string]Bank{
+	"01": {Code: "01", Name: "Arab Tunisian Bank", BIC: "ATBKTNTT"},
+	"03": {Code: "03", Name: "Banque Nationale Agricole", BIC: "BNTETNTT"},
+	"04": {Code: "04", Name: "Attijari Bank", BIC: "BSTUTNTT"},
+	"05": {Code: "05", Name: "Banque de Tunisie", BIC: "BTBKTNTT"},
+	"07": {Code: "07", Name: "Amen Bank", BIC: "CFCTTNTT"},
+	"08": {Code: "08", Name: "Banque Internationale Arabe de Tunisie", BIC: "BIATTNTT"},
+	"10": {Code: "10", Name: "Société Tunisienne de Banque", BIC: "STBKTNTT"},
+	"11": {Code: "11", Name: "Union Bancaire pour le Commerce et l'Industrie", BIC: "UBCITNTT"},
+	"12": {Code: "12", Name: "Union Internationale de Banques", BIC: "UIBKTNTT"},
+	"14": {Code: "14", Name: "Banque de l'Habitat", BIC: "BHBKTNTT"},
+}
+
+// LookupBankByCode finds a Tunisian bank by its 2-digit bank code
+func LookupBankByCode(code string) (Bank, bool) {
+	bank, ok := tunisianBanks[code]
+	return bank, ok
+}
+
+// LookupBankByBIC finds a Tunisian bank by BIC; 11-character BICs match on
+// their 8-character institution prefix
+func LookupBankByBIC(bic string) (Bank, bool) {
+	bic = strings.ToUpper(bic)
+	if len(bic) == 11 {
+		bic = bic[:8]
+	}
+
+	for _, bank := range tunisianBanks {
+		if bank.BIC == bic {
+			return bank, true
+		}
+	}
+	return Bank{}, false
+}
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Customer is the person behind one or more accounts. It holds identity and
+// login credentials; accounts reference it by CustomerID.
+type Customer struct {
+	ID           int        `json:"id" db:"id"`
+	CustomerID   string     `json:"customer_id" db:"customer_id"`
+	FirstName    string     `json:"first_name" db:"first_name"`
+	LastName     string     `json:"last_name" db:"last_name"`
+	Email        string     `json:"email" db:"email"`
+	Phone        string     `json:"phone" db:"phone"`
+	DateOfBirth  time.Time  `json:"date_of_birth" db:"date_of_birth"`
+	Address      Address    `json:"address" db:"address"`
+	HashPassword string     `json:"-" db:"hash_password"`
+	Status       string     `json:"status" db:"status"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+	LastLoginAt  *time.Time `json:"last_login_at" db:"last_login_at"`
+	// Failed logins in a row, see LoginPolicy
+	FailedLoginAttempts int        `json:"failed_login_attempts" db:"failed_login_attempts"`
+	LastFailedLoginAt   *time.Time `json:"last_failed_login_at,omitempty" db:"last_failed_login_at"`
+	LockedUntil         *time.Time `json:"locked_until,omitempty" db:"locked_until"`
+	PasswordChangedAt   *time.Time `json:"password_changed_at,omitempty" db:"password_changed_at"`
+}
+
+// Address represents customer address
+type Address struct {
+	Street     string `json:"street" db:"street"`
+	City       string `json:"city" db:"city"`
+	PostalCode string `json:"postal_code" db:"postal_code"`
+	Country    string `json:"country" db:"country"`
+	State      string `json:"state" db:"state"`
+}
+
+// Customer status constants
+const (
+	CustomerStatusActive    = "ACTIVE"
+	CustomerStatusSuspended = "SUSPENDED"
+	CustomerStatusClosed    = "CLOSED"
+)
+
+// ValidatePassword checks if the provided password matches the hashed password
+func (c *Customer) ValidatePassword(password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(c.HashPassword), []byte(password)) == nil
+}
+
+// IsActive checks if the customer may log in
+func (c *Customer) IsActive() bool {
+	return c.Status == CustomerStatusActive
+}
@@ -0,0 +1,178 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Channels a transaction can be initiated through. Fee schedules may price
+// them differently.
+const (
+	ChannelOnline        = "ONLINE"         // Customer through the API
+	ChannelBranch        = "BRANCH"         // Staff on behalf of a customer
+	ChannelStandingOrder = "STANDING_ORDER" // Executed by the standing order job
+	ChannelBulk          = "BULK"           // Imported payment file
+)
+
+// IsValidChannel checks if channel is one of the known channels
+func IsValidChannel(channel string) bool {
+	switch channel {
+	case ChannelOnline, ChannelBranch, ChannelStandingOrder, ChannelBulk:
+		return true
+	}
+	return false
+}
+
+// IsFeeableTransactionType checks if fee schedules can price the type
+func IsFeeableTransactionType(transactionType string) bool {
+	switch transactionType {
+	case TransactionTypeTransfer, TransactionTypeWithdrawal, TransactionTypeExternal:
+		return true
+	}
+	return false
+}
+
+// FeeSchedule prices one kind of transaction. An empty account type, channel
+// or currency matches any; of the active schedules matching a transaction the
+// most specific applies. Amounts are in the currency's minor unit.
+type FeeSchedule struct {
+	ID              int       `json:"id" db:"id"`
+	AccountType     string    `json:"account_type,omitempty" db:"account_type"`
+	TransactionType string    `json:"transaction_type" db:"transaction_type"`
+	Channel         string    `json:"channel,omitempty" db:"channel"`
+	Currency        string    `json:"currency,omitempty" db:"currency"`
+	FlatFee         int64     `json:"flat_fee" db:"flat_fee"`
+	PercentageBps   int       `json:"percentage_bps" db:"percentage_bps"` // Of the amount, in basis points
+	Tiers           []FeeTier `json:"tiers,omitempty" db:"tiers"`         // Replace the flat fee and percentage when set
+	MinFee          int64     `json:"min_fee" db:"min_fee"`
+	MaxFee          int64     `json:"max_fee,omitempty" db:"max_fee"`               // 0 for no cap
+	FreePerMonth    int       `json:"free_per_month,omitempty" db:"free_per_month"` // Transactions per calendar month charged nothing
+	Active          bool      `json:"active" db:"active"`
+	CreatedAt       time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// FeeTier prices the amounts up to its bound, from the previous tier's bound
+type FeeTier struct {
+	UpTo          int64 `json:"up_to,omitempty"` // Inclusive; 0 for the last, unbounded tier
+	FlatFee       int64 `json:"flat_fee"`
+	PercentageBps int   `json:"percentage_bps"`
+}
+
+// Validate checks the schedule's key and pricing rules
+func (s *FeeSchedule) Validate() error {
+	if !IsFeeableTransactionType(s.TransactionType) {
+		return errors.New("transaction type must be TRANSFER, WITHDRAWAL or EXTERNAL_TRANSFER")
+	}
+	switch s.AccountType {
+	case "", AccountTypeChecking, AccountTypeSavings, AccountTypeBusiness, AccountTypeForeign:
+	default:
+		return errors.New("invalid account type")
+	}
+	if s.Channel != "" && !IsValidChannel(s.Channel) {
+		return errors.New("channel must be ONLINE, BRANCH, STANDING_ORDER or BULK")
+	}
+	if s.Currency != "" {
+		if _, ok := CurrencyMinorUnits(s.Currency); !ok {
+			return errors.New("invalid currency")
+		}
+	}
+	if s.FlatFee < 0 || s.PercentageBps < 0 || s.MinFee < 0 || s.MaxFee < 0 {
+		return errors.New("fees cannot be negative")
+	}
+	if s.MaxFee > 0 && s.MaxFee < s.MinFee {
+		return errors.New("maximum fee cannot be below the minimum fee")
+	}
+	if s.FreePerMonth < 0 {
+		return errors.New("free transactions per month cannot be negative")
+	}
+
+	if len(s.Tiers) > 0 && (s.FlatFee != 0 || s.PercentageBps != 0) {
+		return errors.New("a tiered schedule takes its flat fee and percentage from its tiers")
+	}
+	var bound int64
+	for i, tier := range s.Tiers {
+		if tier.FlatFee < 0 || tier.PercentageBps < 0 {
+			return errors.New("fees cannot be negative")
+		}
+		last := i == len(s.Tiers)-1
+		if tier.UpTo == 0 && !last {
+			return errors.New("only the last tier can be unbounded")
+		}
+		if tier.UpTo != 0 && tier.UpTo <= bound {
+			return errors.New("tier bounds must be increasing")
+		}
+		bound = tier.UpTo
+	}
+
+	return nil
+}
+
+// Matches checks if the schedule prices the given transaction
+func (s *FeeSchedule) Matches(accountType, transactionType, channel, currency string) bool {
+	return s.Active && s.TransactionType == transactionType &&
+		(s.AccountType == "" || s.AccountType == accountType) &&
+		(s.Channel == "" || s.Channel == channel) &&
+		(s.Currency == "" || s.Currency == currency)
+}
+
+// specificity counts the keys the schedule is restricted to
+func (s *FeeSchedule) specificity() int {
+	n := 0
+	for _, key := range []string{s.AccountType, s.Channel, s.Currency} {
+		if key != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// MatchFeeSchedule returns the most specific schedule pricing the given
+// transaction, the first of equally specific ones, or nil when none does
+func MatchFeeSchedule(schedules []*FeeSchedule, accountType, transactionType, channel, currency string) *FeeSchedule {
+	var best *FeeSchedule
+	for _, schedule := range schedules {
+		if !schedule.Matches(accountType, transactionType, channel, currency) {
+			continue
+		}
+		if best == nil || schedule.specificity() > best.specificity() {
+			best = schedule
+		}
+	}
+	return best
+}
+
+// Calculate prices an amount, before any free monthly quota: the flat fee
+// plus the percentage of the tier the amount falls in (or of the schedule),
+// within the minimum and maximum
+func (s *FeeSchedule) Calculate(amount int64) int64 {
+	flat, bps := s.FlatFee, s.PercentageBps
+	for _, tier := range s.Tiers {
+		flat, bps = tier.FlatFee, tier.PercentageBps
+		if tier.UpTo == 0 || amount <= tier.UpTo {
+			break
+		}
+	}
+
+	fee := flat + amount*int64(bps)/10000
+	if fee < s.MinFee {
+		fee = s.MinFee
+	}
+	if s.MaxFee > 0 && fee > s.MaxFee {
+		fee = s.MaxFee
+	}
+	return fee
+}
+
+// FeeQuote previews the fee an account pays for a transaction
+type FeeQuote struct {
+	AccountNumber   string `json:"account_number"`
+	TransactionType string `json:"transaction_type"`
+	Channel         string `json:"channel"`
+	Currency        string `json:"currency"`
+	Amount          int64  `json:"amount"`
+	Fee             int64  `json:"fee"`
+	Total           int64  `json:"total"`                    // Debited from the account: amount plus fee
+	ScheduleID      int    `json:"schedule_id,omitempty"`    // Schedule applied; none for a transaction without fee
+	FreeRemaining   int    `json:"free_remaining,omitempty"` // Free transactions left this month, this one included
+}
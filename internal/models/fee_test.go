@@ -0,0 +1,132 @@
+package models
+
+import "testing"
+
+func TestFeeScheduleCalculate(t *testing.T) {
+	tiered := &FeeSchedule{
+		Tiers: []FeeTier{
+			{UpTo: 100000, FlatFee: 500},
+			{UpTo: 1000000, FlatFee: 500, PercentageBps: 10},
+			{FlatFee: 2000, PercentageBps: 5},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		schedule *FeeSchedule
+		amount   int64
+		want     int64
+	}{
+		{name: "flat and percentage", schedule: &FeeSchedule{FlatFee: 500, PercentageBps: 10}, amount: 200000, want: 700},
+		{name: "percentage rounds down", schedule: &FeeSchedule{PercentageBps: 10}, amount: 9999, want: 9},
+		{name: "minimum", schedule: &FeeSchedule{PercentageBps: 10, MinFee: 300}, amount: 100000, want: 300},
+		{name: "cap", schedule: &FeeSchedule{PercentageBps: 100, MaxFee: 5000}, amount: 1000000, want: 5000},
+		{name: "under the cap", schedule: &FeeSchedule{PercentageBps: 100, MaxFee: 5000}, amount: 400000, want: 4000},
+		{name: "no cap", schedule: &FeeSchedule{PercentageBps: 100}, amount: 1000000, want: 10000},
+		{name: "first tier", schedule: tiered, amount: 50000, want: 500},
+		{name: "first tier bound is inclusive", schedule: tiered, amount: 100000, want: 500},
+		{name: "just above the first tier", schedule: tiered, amount: 100001, want: 600},
+		{name: "second tier bound is inclusive", schedule: tiered, amount: 1000000, want: 1500},
+		{name: "unbounded last tier", schedule: tiered, amount: 1000001, want: 2500},
+		{
+			name:     "tier within the cap",
+			schedule: &FeeSchedule{Tiers: tiered.Tiers, MaxFee: 2200},
+			amount:   2000000,
+			want:     2200,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.schedule.Calculate(tt.amount); got != tt.want {
+				t.Errorf("Calculate(%d) = %d, want %d", tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFeeScheduleValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule FeeSchedule
+		wantErr  bool
+	}{
+		{name: "flat", schedule: FeeSchedule{TransactionType: TransactionTypeTransfer, FlatFee: 500}},
+		{
+			name: "tiered",
+			schedule: FeeSchedule{TransactionType: TransactionTypeTransfer, Tiers: []FeeTier{
+				{UpTo: 100000, FlatFee: 500}, {FlatFee: 1000},
+			}},
+		},
+		{name: "not feeable", schedule: FeeSchedule{TransactionType: TransactionTypeDeposit}, wantErr: true},
+		{name: "cap below minimum", schedule: FeeSchedule{TransactionType: TransactionTypeTransfer, MinFee: 500, MaxFee: 100}, wantErr: true},
+		{name: "negative free quota", schedule: FeeSchedule{TransactionType: TransactionTypeTransfer, FreePerMonth: -1}, wantErr: true},
+		{
+			name: "tiers and flat fee",
+			schedule: FeeSchedule{TransactionType: TransactionTypeTransfer, FlatFee: 500, Tiers: []FeeTier{
+				{FlatFee: 1000},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "unbounded tier before the last",
+			schedule: FeeSchedule{TransactionType: TransactionTypeTransfer, Tiers: []FeeTier{
+				{FlatFee: 500}, {UpTo: 100000, FlatFee: 1000},
+			}},
+			wantErr: true,
+		},
+		{
+			name: "decreasing bounds",
+			schedule: FeeSchedule{TransactionType: TransactionTypeTransfer, Tiers: []FeeTier{
+				{UpTo: 100000, FlatFee: 500}, {UpTo: 100000, FlatFee: 1000}, {FlatFee: 2000},
+			}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.schedule.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMatchFeeSchedule(t *testing.T) {
+	catchAll := &FeeSchedule{ID: 1, TransactionType: TransactionTypeTransfer, Active: true}
+	online := &FeeSchedule{ID: 2, TransactionType: TransactionTypeTransfer, Channel: ChannelOnline, Active: true}
+	onlineSavings := &FeeSchedule{
+		ID: 3, TransactionType: TransactionTypeTransfer, Channel: ChannelOnline,
+		AccountType: AccountTypeSavings, Active: true,
+	}
+	inactive := &FeeSchedule{
+		ID: 4, TransactionType: TransactionTypeTransfer, Channel: ChannelOnline,
+		AccountType: AccountTypeSavings, Currency: CurrencyTND,
+	}
+	schedules := []*FeeSchedule{catchAll, online, onlineSavings, inactive}
+
+	tests := []struct {
+		name        string
+		accountType string
+		channel     string
+		want        *FeeSchedule
+	}{
+		{name: "most specific", accountType: AccountTypeSavings, channel: ChannelOnline, want: onlineSavings},
+		{name: "channel only", accountType: AccountTypeChecking, channel: ChannelOnline, want: online},
+		{name: "catch-all", accountType: AccountTypeChecking, channel: ChannelBranch, want: catchAll},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MatchFeeSchedule(schedules, tt.accountType, TransactionTypeTransfer, tt.channel, CurrencyTND)
+			if got != tt.want {
+				t.Errorf("MatchFeeSchedule = %+v, want schedule %d", got, tt.want.ID)
+			}
+		})
+	}
+
+	if got := MatchFeeSchedule(schedules, AccountTypeChecking, TransactionTypeWithdrawal, ChannelOnline, CurrencyTND); got != nil {
+		t.Errorf("MatchFeeSchedule for another transaction type = %+v, want none", got)
+	}
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// FXRate is a mid-market exchange rate: 1 unit of Base is worth Mid units of Quote
+type FXRate struct {
+	Base   string    `json:"base"`
+	Quote  string    `json:"quote"`
+	Mid    float64   `json:"mid"`
+	Source string    `json:"source"`
+	AsOf   time.Time `json:"as_of"`
+}
+
+// FXQuote is a snapshot of the customer rate (mid rate minus the bank's
+// spread) for a currency pair. A quote requested through the API is locked
+// for a short time and can be referenced once by a transfer; transfers
+// without a quote get a snapshot at the live rate.
+type FXQuote struct {
+	ID              int        `json:"-" db:"id"`
+	QuoteID         string     `json:"quote_id" db:"quote_id"`
+	AccountNumber   string     `json:"account_number" db:"account_number"`
+	FromCurrency    string     `json:"from_currency" db:"from_currency"`
+	ToCurrency      string     `json:"to_currency" db:"to_currency"`
+	MidRate         float64    `json:"mid_rate" db:"mid_rate"`
+	Rate            float64    `json:"rate" db:"rate"` // Customer rate after spread
+	SpreadBps       int        `json:"spread_bps" db:"spread_bps"`
+	Amount          int64      `json:"amount,omitempty" db:"amount"`                     // Source amount quoted, in the from currency's minor unit
+	ConvertedAmount int64      `json:"converted_amount,omitempty" db:"converted_amount"` // In the to currency's minor unit
+	Source          string     `json:"source" db:"source"`
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt       time.Time  `json:"expires_at" db:"expires_at"`
+	UsedAt          *time.Time `json:"used_at,omitempty" db:"used_at"`
+	TransactionID   string     `json:"transaction_id,omitempty" db:"transaction_id"`
+}
+
+// IsExpired checks if the quote's locked rate can no longer be used
+func (q *FXQuote) IsExpired() bool {
+	return time.Now().UTC().After(q.ExpiresAt)
+}
+
+// IsUsed checks if a transfer has already consumed the quote
+func (q *FXQuote) IsUsed() bool {
+	return q.UsedAt != nil
+}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// Hold reserves funds on an account for a merchant authorization. While
+// ACTIVE its amount counts in the account's hold_amount and is excluded from
+// the available balance; it is then captured into a PAYMENT transaction,
+// released, or expires.
+type Hold struct {
+	ID                int        `json:"id" db:"id"`
+	HoldID            string     `json:"hold_id" db:"hold_id"`
+	AccountID         int        `json:"account_id" db:"account_id"`
+	AccountNumber     string     `json:"account_number" db:"account_number"`
+	Amount            int64      `json:"amount" db:"amount"`
+	CapturedAmount    int64      `json:"captured_amount" db:"captured_amount"`
+	Currency          string     `json:"currency" db:"currency"`
+	MerchantReference string     `json:"merchant_reference" db:"merchant_reference"`
+	Description       string     `json:"description" db:"description"`
+	Status            string     `json:"status" db:"status"`
+	TransactionID     string     `json:"transaction_id,omitempty" db:"transaction_id"` // Capture transaction
+	ExpiresAt         time.Time  `json:"expires_at" db:"expires_at"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`
+	ClosedAt          *time.Time `json:"closed_at,omitempty" db:"closed_at"`
+}
+
+// Hold status constants
+const (
+	HoldStatusActive   = "ACTIVE"
+	HoldStatusCaptured = "CAPTURED"
+	HoldStatusReleased = "RELEASED"
+	HoldStatusExpired  = "EXPIRED"
+)
+
+// IsActive checks if the hold still reserves funds
+func (h *Hold) IsActive() bool {
+	return h.Status == HoldStatusActive
+}
+
+// IsExpired checks if the hold's authorization window has passed
+func (h *Hold) IsExpired() bool {
+	return time.Now().UTC().After(h.ExpiresAt)
+}
@@ -0,0 +1,166 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ibanLengths is the total IBAN length per country (ISO 13616 registry) for
+// the countries our customers send money to most. IBANs from countries not
+// listed are checked for structure and checksum only.
+var ibanLengths = map[string]int{
+	"AE": 23, "AT": 20, "BE": 16, "CH": 21, "DE": 22, "DZ": 26, "ES": 24,
+	"FR": 27, "GB": 22, "IE": 22, "IT": 27, "LU": 20, "LY": 25, "MA": 28,
+	"NL": 18, "PT": 25, "SA": 24, "TN": 24, "TR": 26,
+}
+
+var ibanRegex = regexp.MustCompile(`^[A-Z]{2}[0-9]{2}[A-Z0-9]{11,30}$`)
+
+// NormalizeIBAN removes spaces and upper-cases an IBAN as typed by a user
+func NormalizeIBAN(iban string) string {
+	return strings.ToUpper(strings.ReplaceAll(iban, " ", ""))
+}
+
+// ValidateIBAN checks the structure, country length and ISO 13616 mod-97
+// checksum of an IBAN from any country
+func ValidateIBAN(iban string) error {
+	iban = NormalizeIBAN(iban)
+
+	if !ibanRegex.MatchString(iban) {
+		return errors.New("invalid IBAN format")
+	}
+
+	if length, ok := ibanLengths[iban[:2]]; ok && len(iban) != length {
+		return fmt.Errorf("IBAN for country %s must be %d characters", iban[:2], length)
+	}
+
+	// Move the country code and check digits to the end; a valid IBAN leaves remainder 1
+	remainder, err := mod97(iban[4:] + iban[:4])
+	if err != nil {
+		return err
+	}
+	if remainder != 1 {
+		return errors.New("invalid IBAN check digits")
+	}
+
+	return nil
+}
+
+// IBANCheckDigits computes the two ISO 13616 check digits for a BBAN
+func IBANCheckDigits(countryCode, bban string) (string, error) {
+	remainder, err := mod97(NormalizeIBAN(bban) + strings.ToUpper(countryCode) + "00")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%02d", 98-remainder), nil
+}
+
+// BuildIBAN assembles an IBAN from a country code and BBAN
+func BuildIBAN(countryCode, bban string) (string, error) {
+	checkDigits, err := IBANCheckDigits(countryCode, bban)
+	if err != nil {
+		return "", err
+	}
+	return strings.ToUpper(countryCode) + checkDigits + NormalizeIBAN(bban), nil
+}
+
+// mod97 returns the value of an alphanumeric string modulo 97, with letters
+// counted as two digits (A=10 ... Z=35)
+func mod97(value string) (int, error) {
+	remainder := 0
+	for _, c := range value {
+		switch {
+		case c >= '0' && c <= '9':
+			remainder = (remainder*10 + int(c-'0')) % 97
+		case c >= 'A' && c <= 'Z':
+			remainder = (remainder*100 + int(c-'A') + 10) % 97
+		default:
+			return 0, fmt.Errorf("invalid character %q in IBAN", c)
+		}
+	}
+	return remainder, nil
+}
+
+// RIB is a Tunisian Relevé d'Identité Bancaire: the 20-digit domestic account
+// identifier, which is also the BBAN of a Tunisian IBAN
+type RIB struct {
+	BankCode      string // 2 digits, see LookupBankByCode
+	BranchCode    string // 3 digits
+	AccountNumber string // 13 digits
+	Key           string // 2-digit check key
+}
+
+var (
+	ribRegex       = regexp.MustCompile(`^[0-9]{20}$`)
+	ribDigitsRegex = regexp.MustCompile(`^[0-9]{18}$`)
+)
+
+// NewRIB builds a RIB and computes its key
+func NewRIB(bankCode, branchCode, accountNumber string) (*RIB, error) {
+	if len(bankCode) != 2 || len(branchCode) != 3 || len(accountNumber) != 13 {
+		return nil, errors.New("RIB : code banque (2), code agence (3) et numéro de compte (13) requis")
+	}
+
+	key, err := RIBKey(bankCode, branchCode, accountNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RIB{
+		BankCode:      bankCode,
+		BranchCode:    branchCode,
+		AccountNumber: accountNumber,
+		Key:           key,
+	}, nil
+}
+
+// ParseRIB splits a 20-digit RIB and verifies its key
+func ParseRIB(rib string) (*RIB, error) {
+	rib = strings.ReplaceAll(rib, " ", "")
+	if !ribRegex.MatchString(rib) {
+		return nil, errors.New("RIB tunisien doit contenir exactement 20 chiffres")
+	}
+
+	parsed := &RIB{
+		BankCode:      rib[:2],
+		BranchCode:    rib[2:5],
+		AccountNumber: rib[5:18],
+		Key:           rib[18:],
+	}
+
+	key, err := RIBKey(parsed.BankCode, parsed.BranchCode, parsed.AccountNumber)
+	if err != nil {
+		return nil, err
+	}
+	if key != parsed.Key {
+		return nil, errors.New("clé RIB invalide")
+	}
+
+	return parsed, nil
+}
+
+// RIBKey computes the RIB key: 97 - ((bank, branch and account digits × 100) mod 97)
+func RIBKey(bankCode, branchCode, accountNumber string) (string, error) {
+	digits := bankCode + branchCode + accountNumber
+	if !ribDigitsRegex.MatchString(digits) {
+		return "", errors.New("RIB : les codes doivent être numériques")
+	}
+
+	remainder, err := mod97(digits + "00")
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%02d", 97-remainder), nil
+}
+
+// String returns the 20-digit RIB
+func (r *RIB) String() string {
+	return r.BankCode + r.BranchCode + r.AccountNumber + r.Key
+}
+
+// IBAN returns the Tunisian IBAN of the RIB
+func (r *RIB) IBAN() (string, error) {
+	return BuildIBAN("TN", r.String())
+}
@@ -0,0 +1,111 @@
+package models
+
+import "testing"
+
+// The Tunisian example of the ISO 13616 IBAN registry
+const (
+	registryTNIBAN = "TN59 1000 6035 1835 9847 8831"
+	registryTNRIB  = "10006035183598478831"
+)
+
+func TestValidateIBAN(t *testing.T) {
+	tests := []struct {
+		name    string
+		iban    string
+		wantErr bool
+	}{
+		{name: "registry example", iban: registryTNIBAN},
+		{name: "lower case without spaces", iban: "tn5910006035183598478831"},
+		{name: "foreign IBAN", iban: "FR14 2004 1010 0505 0001 3M02 606"},
+		{name: "wrong check digits", iban: "TN58 1000 6035 1835 9847 8831", wantErr: true},
+		{name: "swapped digits", iban: "TN59 1000 6035 1835 9847 8813", wantErr: true},
+		{name: "wrong length for the country", iban: "TN59 1000 6035 1835 9847 883", wantErr: true},
+		{name: "bad characters", iban: "TN59-1000-6035-1835-9847-8831", wantErr: true},
+		{name: "empty", iban: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateIBAN(tt.iban); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateIBAN(%q) error = %v, want error %v", tt.iban, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRIBKey(t *testing.T) {
+	tests := []struct {
+		name          string
+		bankCode      string
+		branchCode    string
+		accountNumber string
+		want          string
+		wantErr       bool
+	}{
+		{name: "registry example", bankCode: "10", branchCode: "006", accountNumber: "0351835984788", want: "31"},
+		{name: "BIAT", bankCode: "08", branchCode: "001", accountNumber: "0000012345678", want: "10"},
+		{name: "letters", bankCode: "10", branchCode: "00A", accountNumber: "0351835984788", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RIBKey(tt.bankCode, tt.branchCode, tt.accountNumber)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RIBKey error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("RIBKey = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRIB(t *testing.T) {
+	tests := []struct {
+		name    string
+		rib     string
+		want    RIB
+		wantErr bool
+	}{
+		{
+			name: "registry example",
+			rib:  registryTNRIB,
+			want: RIB{BankCode: "10", BranchCode: "006", AccountNumber: "0351835984788", Key: "31"},
+		},
+		{
+			name: "with spaces",
+			rib:  "10 006 0351835984788 31",
+			want: RIB{BankCode: "10", BranchCode: "006", AccountNumber: "0351835984788", Key: "31"},
+		},
+		{name: "wrong key", rib: "10006035183598478832", wantErr: true},
+		{name: "too short", rib: "1000603518359847883", wantErr: true},
+		{name: "letters", rib: "1000603518359847883A", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRIB(tt.rib)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRIB error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && *got != tt.want {
+				t.Errorf("ParseRIB = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRIBIBAN(t *testing.T) {
+	rib, err := ParseRIB(registryTNRIB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	iban, err := rib.IBAN()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := NormalizeIBAN(registryTNIBAN); iban != want {
+		t.Errorf("IBAN = %q, want %q", iban, want)
+	}
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// IdempotencyRecord stores the outcome of a money-moving request so that a
+// retry carrying the same Idempotency-Key replays it instead of re-executing
+type IdempotencyRecord struct {
+	ID           int       `json:"id" db:"id"`
+	Scope        string    `json:"scope" db:"scope"` // Caller: "customer:<id>", "staff:<id>" or "client:<id>"; keys are unique per caller
+	Key          string    `json:"key" db:"idempotency_key"`
+	Method       string    `json:"method" db:"method"`
+	Path         string    `json:"path" db:"path"`
+	RequestHash  string    `json:"request_hash" db:"request_hash"` // SHA-256 fingerprint of method, path and body
+	StatusCode   int       `json:"status_code" db:"status_code"`
+	ResponseBody []byte    `json:"-" db:"response_body"`
+	Completed    bool      `json:"completed" db:"completed"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at" db:"expires_at"`
+}
+
+// IsExpired checks if the record is past its retention window
+func (r *IdempotencyRecord) IsExpired() bool {
+	return time.Now().UTC().After(r.ExpiresAt)
+}
@@ -0,0 +1,200 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Interest capitalization frequencies: accrued interest is paid at the end of
+// every calendar month or quarter
+const (
+	InterestCapitalizationMonthly   = "MONTHLY"
+	InterestCapitalizationQuarterly = "QUARTERLY"
+)
+
+// microsPerMinorUnit is the precision interest accrues in: a millionth of the
+// currency's minor unit
+const microsPerMinorUnit = 1000000
+
+// InterestRateTable sets the rates an account type earns from its effective
+// date until a later table replaces it. A table without currency applies to
+// every currency the account type is held in.
+type InterestRateTable struct {
+	ID            int            `json:"id" db:"id"`
+	AccountType   string         `json:"account_type" db:"account_type"`
+	Currency      string         `json:"currency,omitempty" db:"currency"`
+	EffectiveFrom time.Time      `json:"effective_from" db:"effective_from"`
+	Tiers         []InterestTier `json:"tiers" db:"tiers"`
+	CreatedAt     time.Time      `json:"created_at" db:"created_at"`
+}
+
+// InterestTier pays its annual rate on the part of the balance above the
+// previous tier's bound and up to its own
+type InterestTier struct {
+	UpTo    int64 `json:"up_to,omitempty"` // Inclusive; 0 for the last, unbounded tier
+	RateBps int   `json:"rate_bps"`        // Annual rate in basis points
+}
+
+// Validate checks the table's key and tiers
+func (t *InterestRateTable) Validate() error {
+	switch t.AccountType {
+	case AccountTypeChecking, AccountTypeSavings, AccountTypeBusiness, AccountTypeForeign:
+	default:
+		return errors.New("invalid account type")
+	}
+	if t.Currency != "" {
+		if _, ok := CurrencyMinorUnits(t.Currency); !ok {
+			return errors.New("invalid currency")
+		}
+	}
+	if t.EffectiveFrom.IsZero() {
+		return errors.New("effective date is required")
+	}
+	if len(t.Tiers) == 0 {
+		return errors.New("at least one tier is required")
+	}
+
+	var bound int64
+	for i, tier := range t.Tiers {
+		if tier.RateBps < 0 {
+			return errors.New("interest rates cannot be negative")
+		}
+		last := i == len(t.Tiers)-1
+		if tier.UpTo == 0 && !last {
+			return errors.New("only the last tier can be unbounded")
+		}
+		if tier.UpTo != 0 && tier.UpTo <= bound {
+			return errors.New("tier bounds must be increasing")
+		}
+		bound = tier.UpTo
+	}
+
+	return nil
+}
+
+// DailyAccrual returns the interest a closing balance earns in a day, in
+// millionths of the minor unit, for a year of dayCount days. Negative
+// balances earn nothing; a balance above the last bound earns nothing on the
+// excess.
+func (t *InterestRateTable) DailyAccrual(balance int64, dayCount int) int64 {
+	if balance <= 0 || dayCount <= 0 {
+		return 0
+	}
+
+	var weighted, lower int64
+	for _, tier := range t.Tiers {
+		upper := balance
+		if tier.UpTo != 0 && tier.UpTo < upper {
+			upper = tier.UpTo
+		}
+		if upper > lower {
+			weighted += (upper - lower) * int64(tier.RateBps)
+		}
+		if tier.UpTo == 0 || tier.UpTo >= balance {
+			break
+		}
+		lower = tier.UpTo
+	}
+
+	// weighted / 10000 bps a year, in micros, per day
+	return weighted * (microsPerMinorUnit / 10000) / int64(dayCount)
+}
+
+// MatchInterestRateTable returns the table in effect on date for an account:
+// the latest effective one of its type and currency or of its type for every
+// currency, preferring the currency's own on the same date. It returns nil
+// when none applies.
+func MatchInterestRateTable(tables []*InterestRateTable, accountType, currency string, date time.Time) *InterestRateTable {
+	var best *InterestRateTable
+	for _, table := range tables {
+		if table.AccountType != accountType || (table.Currency != "" && table.Currency != currency) {
+			continue
+		}
+		if table.EffectiveFrom.After(date) {
+			continue
+		}
+		if best == nil || table.EffectiveFrom.After(best.EffectiveFrom) ||
+			(table.EffectiveFrom.Equal(best.EffectiveFrom) && table.Currency != "") {
+			best = table
+		}
+	}
+	return best
+}
+
+// InterestAccrual is the interest an account earned on a business date's
+// closing balance
+type InterestAccrual struct {
+	ID               int64     `json:"id" db:"id"`
+	AccountID        int       `json:"account_id" db:"account_id"`
+	AccountNumber    string    `json:"account_number" db:"account_number"`
+	BusinessDate     time.Time `json:"business_date" db:"business_date"`
+	Balance          int64     `json:"balance" db:"balance"` // Closing balance of the day, in the currency's minor unit
+	RateTableID      int       `json:"rate_table_id" db:"rate_table_id"`
+	AmountMicros     int64     `json:"amount_micros" db:"amount_micros"` // Millionths of the minor unit
+	CapitalizationID int       `json:"capitalization_id,omitempty" db:"capitalization_id"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+}
+
+// InterestCapitalization pays the interest an account accrued over a period.
+// The gross interest is credited by an INTEREST transaction and the tax
+// withheld at source debited by a linked WITHHOLDING_TAX transaction.
+type InterestCapitalization struct {
+	ID             int       `json:"id" db:"id"`
+	AccountID      int       `json:"account_id" db:"account_id"`
+	AccountNumber  string    `json:"account_number" db:"account_number"`
+	PeriodStart    time.Time `json:"period_start" db:"period_start"`
+	PeriodEnd      time.Time `json:"period_end" db:"period_end"`
+	GrossAmount    int64     `json:"gross_amount" db:"gross_amount"`
+	WithholdingTax int64     `json:"withholding_tax" db:"withholding_tax"`
+	NetAmount      int64     `json:"net_amount" db:"net_amount"`
+	TransactionID  string    `json:"transaction_id,omitempty" db:"transaction_id"` // None when the gross rounds to zero
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// RoundInterest converts accrued millionths to the minor unit, rounding half up
+func RoundInterest(micros int64) int64 {
+	return (micros + microsPerMinorUnit/2) / microsPerMinorUnit
+}
+
+// WithholdingTax returns the tax withheld at source on gross interest at the
+// given rate in basis points, rounded half up
+func WithholdingTax(gross int64, rateBps int) int64 {
+	return (gross*int64(rateBps) + 5000) / 10000
+}
+
+// InterestPeriodStart returns the first day of the capitalization period
+// containing date
+func InterestPeriodStart(date time.Time, frequency string) time.Time {
+	month := date.Month()
+	if frequency == InterestCapitalizationQuarterly {
+		month -= (month - 1) % 3
+	}
+	return time.Date(date.Year(), month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// IsValidInterestCapitalization checks if frequency is MONTHLY or QUARTERLY
+func IsValidInterestCapitalization(frequency string) bool {
+	return frequency == InterestCapitalizationMonthly || frequency == InterestCapitalizationQuarterly
+}
+
+// InterestAccrualReport summarizes the interest accrued per account over a
+// period of business dates, both included
+type InterestAccrualReport struct {
+	From     time.Time                 `json:"from"`
+	To       time.Time                 `json:"to"`
+	Accounts []*InterestAccrualSummary `json:"accounts"`
+	Total    map[string]int64          `json:"total"` // Accrued per currency, in its minor unit
+}
+
+// InterestAccrualSummary is one account's line of an accrual report. Amounts
+// are in the currency's minor unit, rounded from the daily accruals' sum.
+type InterestAccrualSummary struct {
+	AccountNumber  string `json:"account_number"`
+	AccountType    string `json:"account_type"`
+	Currency       string `json:"currency"`
+	Days           int    `json:"days"`            // Business dates accrued
+	AverageBalance int64  `json:"average_balance"` // Of the closing balances accrued on
+	Accrued        int64  `json:"accrued"`
+	Capitalized    int64  `json:"capitalized"` // Part of the accrued interest already paid
+	AccruedMicros  int64  `json:"accrued_micros"`
+}
@@ -0,0 +1,206 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// ErrCodeLimitExceeded is the error code of a transaction refused by the
+// account's limits
+const ErrCodeLimitExceeded = "LIMIT_EXCEEDED"
+
+// Limits on the money leaving an account
+const (
+	LimitSingle      = "SINGLE_TRANSACTION" // Amount of one transaction
+	LimitDaily       = "DAILY"              // Total sent since midnight UTC
+	LimitMonthly     = "MONTHLY"            // Total sent since the 1st of the month
+	LimitHourlyCount = "HOURLY_COUNT"       // Transactions sent in the last hour
+)
+
+// IsLimitedTransactionType checks if transactions of the type count towards
+// and are checked against the limits
+func IsLimitedTransactionType(transactionType string) bool {
+	switch transactionType {
+	case TransactionTypeTransfer, TransactionTypeWithdrawal, TransactionTypeExternal:
+		return true
+	}
+	return false
+}
+
+// TransactionLimits caps the transfers and withdrawals from an account.
+// Amounts are in the account currency's minor unit; 0 means no limit.
+type TransactionLimits struct {
+	SingleMax   int64 `json:"single_max"`
+	DailyMax    int64 `json:"daily_max"`
+	MonthlyMax  int64 `json:"monthly_max"`
+	HourlyCount int   `json:"hourly_count"`
+}
+
+// Raised returns the first limit that other lifts or sets higher than l, or
+// an empty string if other only keeps or lowers them
+func (l TransactionLimits) Raised(other TransactionLimits) string {
+	raised := func(current, updated int64) bool {
+		return current != 0 && (updated == 0 || updated > current)
+	}
+	switch {
+	case raised(l.SingleMax, other.SingleMax):
+		return LimitSingle
+	case raised(l.DailyMax, other.DailyMax):
+		return LimitDaily
+	case raised(l.MonthlyMax, other.MonthlyMax):
+		return LimitMonthly
+	case raised(int64(l.HourlyCount), int64(other.HourlyCount)):
+		return LimitHourlyCount
+	}
+	return ""
+}
+
+// Check verifies that sending amount on top of usage stays within the limits
+func (l TransactionLimits) Check(amount int64, usage LimitUsage) error {
+	if l.SingleMax > 0 && amount > l.SingleMax {
+		return &LimitExceededError{Limit: LimitSingle, Max: l.SingleMax, Requested: amount}
+	}
+	if l.DailyMax > 0 && usage.Daily+amount > l.DailyMax {
+		return &LimitExceededError{Limit: LimitDaily, Max: l.DailyMax, Used: usage.Daily, Requested: amount}
+	}
+	if l.MonthlyMax > 0 && usage.Monthly+amount > l.MonthlyMax {
+		return &LimitExceededError{Limit: LimitMonthly, Max: l.MonthlyMax, Used: usage.Monthly, Requested: amount}
+	}
+	if l.HourlyCount > 0 && usage.HourlyCount+1 > l.HourlyCount {
+		return &LimitExceededError{Limit: LimitHourlyCount, Max: int64(l.HourlyCount), Used: int64(usage.HourlyCount), Requested: 1}
+	}
+	return nil
+}
+
+// LimitExceededError refuses a transaction that would exceed a limit
+type LimitExceededError struct {
+	Limit     string
+	Max       int64
+	Used      int64 // Already sent in the limit's period
+	Requested int64
+}
+
+func (e *LimitExceededError) Error() string {
+	switch e.Limit {
+	case LimitSingle:
+		return fmt.Sprintf("amount %d exceeds the single transaction limit of %d", e.Requested, e.Max)
+	case LimitHourlyCount:
+		return fmt.Sprintf("limit of %d transactions per hour reached", e.Max)
+	}
+	remaining := e.Max - e.Used
+	if remaining < 0 {
+		remaining = 0
+	}
+	return fmt.Sprintf("amount %d exceeds the %s limit of %d: %d remaining", e.Requested, e.periodName(), e.Max, remaining)
+}
+
+func (e *LimitExceededError) periodName() string {
+	if e.Limit == LimitMonthly {
+		return "monthly"
+	}
+	return "daily"
+}
+
+// AccountTypeLimits are the default limits of every account of a type in a
+// currency. Amounts are in that currency's minor unit, so each currency has
+// its own.
+type AccountTypeLimits struct {
+	AccountType string `json:"account_type"`
+	Currency    string `json:"currency"`
+	TransactionLimits
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CustomerLimits overrides the account type's limits on a customer's
+// accounts of that type and currency. A nil limit keeps the account type's;
+// 0 lifts it.
+type CustomerLimits struct {
+	ID          int       `json:"id"`
+	CustomerID  string    `json:"customer_id"`
+	AccountType string    `json:"account_type"`
+	Currency    string    `json:"currency"`
+	SingleMax   *int64    `json:"single_max,omitempty"`
+	DailyMax    *int64    `json:"daily_max,omitempty"`
+	MonthlyMax  *int64    `json:"monthly_max,omitempty"`
+	HourlyCount *int      `json:"hourly_count,omitempty"`
+	UpdatedBy   string    `json:"updated_by"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Apply returns the limits in effect: defaults with the overrides set
+func (c *CustomerLimits) Apply(defaults TransactionLimits) TransactionLimits {
+	limits := defaults
+	if c == nil {
+		return limits
+	}
+	if c.SingleMax != nil {
+		limits.SingleMax = *c.SingleMax
+	}
+	if c.DailyMax != nil {
+		limits.DailyMax = *c.DailyMax
+	}
+	if c.MonthlyMax != nil {
+		limits.MonthlyMax = *c.MonthlyMax
+	}
+	if c.HourlyCount != nil {
+		limits.HourlyCount = *c.HourlyCount
+	}
+	return limits
+}
+
+// LimitUsage is how much of its limits an account has used
+type LimitUsage struct {
+	Daily       int64 `json:"daily"`
+	Monthly     int64 `json:"monthly"`
+	HourlyCount int   `json:"hourly_count"`
+}
+
+// LimitStatus describes an account's limits and the headroom left under
+// them. A nil remaining amount has no limit.
+type LimitStatus struct {
+	AccountNumber string            `json:"account_number"`
+	AccountType   string            `json:"account_type"`
+	Currency      string            `json:"currency"`
+	Limits        TransactionLimits `json:"limits"`
+	Customized    bool              `json:"customized"` // The customer's own limits override some of the account type's
+	Used          LimitUsage        `json:"used"`
+	Remaining     LimitHeadroom     `json:"remaining"`
+}
+
+// LimitHeadroom is what an account can still send under its limits
+type LimitHeadroom struct {
+	Single      *int64 `json:"single"` // Largest transaction allowed now
+	Daily       *int64 `json:"daily"`
+	Monthly     *int64 `json:"monthly"`
+	HourlyCount *int   `json:"hourly_count"`
+}
+
+// Headroom returns what can still be sent on top of usage
+func (l TransactionLimits) Headroom(usage LimitUsage) LimitHeadroom {
+	remaining := func(max, used int64) *int64 {
+		if max == 0 {
+			return nil
+		}
+		left := max - used
+		if left < 0 {
+			left = 0
+		}
+		return &left
+	}
+
+	headroom := LimitHeadroom{
+		Daily:   remaining(l.DailyMax, usage.Daily),
+		Monthly: remaining(l.MonthlyMax, usage.Monthly),
+	}
+	// One transaction can send up to the tightest of the amount limits
+	for _, left := range []*int64{remaining(l.SingleMax, 0), headroom.Daily, headroom.Monthly} {
+		if left != nil && (headroom.Single == nil || *left < *headroom.Single) {
+			headroom.Single = left
+		}
+	}
+	if count := remaining(int64(l.HourlyCount), int64(usage.HourlyCount)); count != nil {
+		left := int(*count)
+		headroom.HourlyCount = &left
+	}
+	return headroom
+}
@@ -0,0 +1,190 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTransactionLimitsRaised(t *testing.T) {
+	current := TransactionLimits{SingleMax: 100000, DailyMax: 200000, MonthlyMax: 1000000, HourlyCount: 10}
+
+	tests := []struct {
+		name    string
+		updated TransactionLimits
+		want    string
+	}{
+		{name: "unchanged", updated: current, want: ""},
+		{
+			name:    "lowered",
+			updated: TransactionLimits{SingleMax: 50000, DailyMax: 100000, MonthlyMax: 500000, HourlyCount: 5},
+			want:    "",
+		},
+		{
+			name:    "single raised",
+			updated: TransactionLimits{SingleMax: 100001, DailyMax: 200000, MonthlyMax: 1000000, HourlyCount: 10},
+			want:    LimitSingle,
+		},
+		{
+			name:    "daily lifted",
+			updated: TransactionLimits{SingleMax: 100000, DailyMax: 0, MonthlyMax: 1000000, HourlyCount: 10},
+			want:    LimitDaily,
+		},
+		{
+			name:    "monthly raised",
+			updated: TransactionLimits{SingleMax: 100000, DailyMax: 200000, MonthlyMax: 2000000, HourlyCount: 10},
+			want:    LimitMonthly,
+		},
+		{
+			name:    "hourly count lifted",
+			updated: TransactionLimits{SingleMax: 100000, DailyMax: 200000, MonthlyMax: 1000000},
+			want:    LimitHourlyCount,
+		},
+		{
+			name:    "first raised limit is reported",
+			updated: TransactionLimits{SingleMax: 50000, DailyMax: 300000, MonthlyMax: 2000000, HourlyCount: 10},
+			want:    LimitDaily,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := current.Raised(tt.updated); got != tt.want {
+				t.Errorf("Raised = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	// Setting a limit where there was none restricts the account further
+	if got := (TransactionLimits{}).Raised(current); got != "" {
+		t.Errorf("Raised from no limits = %q, want none", got)
+	}
+}
+
+func TestTransactionLimitsCheck(t *testing.T) {
+	limits := TransactionLimits{SingleMax: 100000, DailyMax: 200000, MonthlyMax: 1000000, HourlyCount: 3}
+
+	tests := []struct {
+		name   string
+		amount int64
+		usage  LimitUsage
+		want   string
+	}{
+		{name: "within every limit", amount: 100000, usage: LimitUsage{Daily: 100000, Monthly: 100000, HourlyCount: 2}},
+		{name: "single", amount: 100001, want: LimitSingle},
+		{name: "daily reached exactly", amount: 50000, usage: LimitUsage{Daily: 150000, Monthly: 150000}},
+		{name: "daily", amount: 50001, usage: LimitUsage{Daily: 150000, Monthly: 150000}, want: LimitDaily},
+		{name: "monthly", amount: 1000, usage: LimitUsage{Monthly: 999500}, want: LimitMonthly},
+		{name: "hourly count", amount: 1000, usage: LimitUsage{HourlyCount: 3}, want: LimitHourlyCount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := limits.Check(tt.amount, tt.usage)
+
+			var exceeded *LimitExceededError
+			switch {
+			case tt.want == "" && err != nil:
+				t.Errorf("Check error = %v, want none", err)
+			case tt.want != "" && !errors.As(err, &exceeded):
+				t.Errorf("Check error = %v, want a %s limit error", err, tt.want)
+			case tt.want != "" && exceeded.Limit != tt.want:
+				t.Errorf("Check exceeded %s, want %s", exceeded.Limit, tt.want)
+			}
+		})
+	}
+
+	if err := (TransactionLimits{}).Check(1<<40, LimitUsage{Daily: 1 << 40, HourlyCount: 1000}); err != nil {
+		t.Errorf("Check without limits error = %v, want none", err)
+	}
+}
+
+func TestTransactionLimitsHeadroom(t *testing.T) {
+	ptr := func(v int64) *int64 { return &v }
+
+	tests := []struct {
+		name       string
+		limits     TransactionLimits
+		usage      LimitUsage
+		wantSingle *int64
+		wantDaily  *int64
+		wantCount  *int64
+	}{
+		{
+			name:       "single limit is the tightest",
+			limits:     TransactionLimits{SingleMax: 50000, DailyMax: 200000, MonthlyMax: 1000000, HourlyCount: 5},
+			usage:      LimitUsage{Daily: 20000, Monthly: 20000, HourlyCount: 1},
+			wantSingle: ptr(50000),
+			wantDaily:  ptr(180000),
+			wantCount:  ptr(4),
+		},
+		{
+			name:       "daily remainder is the tightest",
+			limits:     TransactionLimits{SingleMax: 100000, DailyMax: 200000, MonthlyMax: 1000000},
+			usage:      LimitUsage{Daily: 160000, Monthly: 160000},
+			wantSingle: ptr(40000),
+			wantDaily:  ptr(40000),
+		},
+		{
+			name:       "monthly remainder without a single limit",
+			limits:     TransactionLimits{MonthlyMax: 1000000},
+			usage:      LimitUsage{Daily: 10000, Monthly: 990000},
+			wantSingle: ptr(10000),
+		},
+		{
+			name:       "used beyond a lowered limit",
+			limits:     TransactionLimits{DailyMax: 100000, HourlyCount: 2},
+			usage:      LimitUsage{Daily: 150000, Monthly: 150000, HourlyCount: 4},
+			wantSingle: ptr(0),
+			wantDaily:  ptr(0),
+			wantCount:  ptr(0),
+		},
+		{name: "no limits", usage: LimitUsage{Daily: 10000, Monthly: 10000, HourlyCount: 1}},
+	}
+
+	equal := func(got, want *int64) bool {
+		return (got == nil && want == nil) || (got != nil && want != nil && *got == *want)
+	}
+	show := func(v *int64) interface{} {
+		if v == nil {
+			return "none"
+		}
+		return *v
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headroom := tt.limits.Headroom(tt.usage)
+
+			var count *int64
+			if headroom.HourlyCount != nil {
+				count = ptr(int64(*headroom.HourlyCount))
+			}
+
+			if !equal(headroom.Single, tt.wantSingle) {
+				t.Errorf("single headroom = %v, want %v", show(headroom.Single), show(tt.wantSingle))
+			}
+			if !equal(headroom.Daily, tt.wantDaily) {
+				t.Errorf("daily headroom = %v, want %v", show(headroom.Daily), show(tt.wantDaily))
+			}
+			if !equal(count, tt.wantCount) {
+				t.Errorf("hourly count headroom = %v, want %v", show(count), show(tt.wantCount))
+			}
+		})
+	}
+}
+
+func TestCustomerLimitsApply(t *testing.T) {
+	defaults := TransactionLimits{SingleMax: 100000, DailyMax: 200000, MonthlyMax: 1000000, HourlyCount: 10}
+	lowered, lifted := int64(50000), int64(0)
+
+	var none *CustomerLimits
+	if got := none.Apply(defaults); got != defaults {
+		t.Errorf("Apply without customer limits = %+v, want %+v", got, defaults)
+	}
+
+	own := &CustomerLimits{SingleMax: &lowered, MonthlyMax: &lifted}
+	want := TransactionLimits{SingleMax: 50000, DailyMax: 200000, MonthlyMax: 0, HourlyCount: 10}
+	if got := own.Apply(defaults); got != want {
+		t.Errorf("Apply = %+v, want %+v", got, want)
+	}
+}
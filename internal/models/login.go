@@ -0,0 +1,102 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Error codes of logins refused before the password is checked
+const (
+	ErrCodeAccountLocked  = "ACCOUNT_LOCKED"  // Too many failed logins in a row
+	ErrCodeLoginThrottled = "LOGIN_THROTTLED" // Too soon after the last failure, or too many failures from the client IP
+)
+
+// Login failure reasons recorded in the login history
+const (
+	LoginFailureInvalidPassword  = "INVALID_PASSWORD"
+	LoginFailureInvalidMFACode   = "INVALID_MFA_CODE"
+	LoginFailureUnknownCustomer  = "UNKNOWN_CUSTOMER"
+	LoginFailureCustomerInactive = "CUSTOMER_INACTIVE"
+	LoginFailureLocked           = "LOCKED"    // Refused without checking the password
+	LoginFailureThrottled        = "THROTTLED" // Refused without checking the password
+)
+
+// CountsTowardsLockout checks if a failure is a wrong guess that counts
+// against the customer and the client IP
+func CountsTowardsLockout(reason string) bool {
+	return reason == LoginFailureInvalidPassword || reason == LoginFailureInvalidMFACode || reason == LoginFailureUnknownCustomer
+}
+
+// LoginAttempt is one customer login attempt
+type LoginAttempt struct {
+	ID            int64     `json:"id"`
+	CustomerID    string    `json:"customer_id,omitempty"`
+	Identifier    string    `json:"identifier"` // Customer ID or account number as sent
+	IPAddress     string    `json:"ip_address,omitempty"`
+	UserAgent     string    `json:"user_agent,omitempty"`
+	Success       bool      `json:"success"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// LoginPolicy limits password and second factor guessing. Zero values
+// disable the corresponding protection.
+type LoginPolicy struct {
+	MaxFailures     int           // Failed logins in a row that lock the customer out
+	LockoutDuration time.Duration // How long a lockout lasts; older failures are forgotten too
+	DelayBase       time.Duration // Wait imposed after the first failure, doubling with each one
+	MaxDelay        time.Duration // Longest wait between two attempts
+	IPMaxFailures   int           // Failed logins from one client IP within IPWindow that block it
+	IPWindow        time.Duration
+}
+
+// Failures returns how many failed logins in a row still count against the
+// customer at now
+func (p LoginPolicy) Failures(customer *Customer, now time.Time) int {
+	if customer.LastFailedLoginAt == nil || customer.LastFailedLoginAt.Before(now.Add(-p.LockoutDuration)) {
+		return 0
+	}
+	return customer.FailedLoginAttempts
+}
+
+// Delay returns how long the next attempt must wait after failures failed
+// logins in a row
+func (p LoginPolicy) Delay(failures int) time.Duration {
+	if failures <= 0 || p.DelayBase <= 0 {
+		return 0
+	}
+
+	delay := p.DelayBase
+	for i := 1; i < failures; i++ {
+		delay *= 2
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// LoginThrottledError refuses a login attempt until RetryAt, without
+// checking its credentials
+type LoginThrottledError struct {
+	Locked  bool // The customer is locked out, rather than made to wait
+	RetryAt time.Time
+}
+
+func (e *LoginThrottledError) Error() string {
+	if e.Locked {
+		return fmt.Sprintf("too many failed logins: locked until %s", e.RetryAt.Format(time.RFC3339))
+	}
+	return fmt.Sprintf("too many login attempts: retry after %s", e.RetryAt.Format(time.RFC3339))
+}
+
+// Reason returns the failure reason the refused attempt is recorded with
+func (e *LoginThrottledError) Reason() string {
+	if e.Locked {
+		return LoginFailureLocked
+	}
+	return LoginFailureThrottled
+}
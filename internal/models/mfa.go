@@ -0,0 +1,87 @@
+package models
+
+import (
+	"time"
+)
+
+// Authentication methods, as in the amr claim of access tokens (RFC 8176)
+const (
+	AuthMethodPassword = "pwd"
+	AuthMethodOTP      = "otp" // TOTP code, or single-use recovery code
+	AuthMethodMFA      = "mfa"
+)
+
+// Authentication context classes, as in the acr claim of access tokens
+const (
+	ACRPassword    = "aal1" // Password only
+	ACRMultiFactor = "aal2" // Password and a second factor
+)
+
+// ACRForMethods returns the authentication context class a session that
+// authenticated with the methods has reached
+func ACRForMethods(methods []string) string {
+	for _, method := range methods {
+		if method == AuthMethodOTP || method == AuthMethodMFA {
+			return ACRMultiFactor
+		}
+	}
+	return ACRPassword
+}
+
+// ErrCodeMFARequired is the error code of an operation refused until the
+// caller verifies a second factor, see POST /auth/mfa/step-up
+const ErrCodeMFARequired = "MFA_REQUIRED"
+
+// MFAMaxAttempts is how many wrong codes a login challenge takes before it
+// is no longer accepted
+const MFAMaxAttempts = 5
+
+// MFARecoveryCodeCount is how many recovery codes a customer is given
+const MFARecoveryCodeCount = 10
+
+// CustomerMFA is a customer's TOTP enrolment. It only protects logins once
+// the customer has confirmed it with a valid code.
+type CustomerMFA struct {
+	CustomerID   string     `json:"customer_id" db:"customer_id"`
+	Secret       string     `json:"-" db:"totp_secret"`
+	EnabledAt    *time.Time `json:"enabled_at,omitempty" db:"enabled_at"`
+	LastUsedStep int64      `json:"-" db:"last_used_step"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// IsEnabled checks if the enrolment has been confirmed
+func (m *CustomerMFA) IsEnabled() bool {
+	return m.EnabledAt != nil
+}
+
+// MFAChallenge is a stored (hashed) login challenge: the customer's password
+// checked out and a second factor is awaited
+type MFAChallenge struct {
+	ID         int        `db:"id"`
+	TokenHash  string     `db:"token_hash"`
+	CustomerID string     `db:"customer_id"`
+	Attempts   int        `db:"attempts"`
+	CreatedAt  time.Time  `db:"created_at"`
+	ExpiresAt  time.Time  `db:"expires_at"`
+	UsedAt     *time.Time `db:"used_at"`
+}
+
+// IsValid checks if the challenge can still be answered
+func (c *MFAChallenge) IsValid() bool {
+	return c.UsedAt == nil && c.Attempts < MFAMaxAttempts && time.Now().UTC().Before(c.ExpiresAt)
+}
+
+// MFAStatus describes a customer's second factor
+type MFAStatus struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+}
+
+// MFAEnrollment is returned once when a customer starts enrolling: the secret
+// to add to an authenticator app, also as an otpauth URI for QR codes
+type MFAEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// OutboundPayment is a credit transfer to an account at another bank. The
+// customer is debited into the clearing suspense account when the payment is
+// queued; the interbank gateway's status callback then settles it or returns
+// the funds.
+type OutboundPayment struct {
+	ID                  int        `json:"id" db:"id"`
+	PaymentID           string     `json:"payment_id" db:"payment_id"` // End-to-end identification sent to the gateway
+	TransactionID       string     `json:"transaction_id" db:"transaction_id"`
+	DebtorAccountNumber string     `json:"debtor_account_number" db:"debtor_account_number"`
+	DebtorIBAN          string     `json:"debtor_iban" db:"debtor_iban"`
+	DebtorBIC           string     `json:"debtor_bic" db:"debtor_bic"`
+	CreditorIBAN        string     `json:"creditor_iban" db:"creditor_iban"`
+	CreditorBIC         string     `json:"creditor_bic" db:"creditor_bic"`
+	CreditorName        string     `json:"creditor_name" db:"creditor_name"`
+	Amount              int64      `json:"amount" db:"amount"`
+	Currency            string     `json:"currency" db:"currency"`
+	RemittanceInfo      string     `json:"remittance_info,omitempty" db:"remittance_info"`
+	Status              string     `json:"status" db:"status"`
+	GatewayReference    string     `json:"gateway_reference,omitempty" db:"gateway_reference"`
+	Attempts            int        `json:"attempts" db:"attempts"`
+	LastError           string     `json:"last_error,omitempty" db:"last_error"`
+	FailureReason       string     `json:"failure_reason,omitempty" db:"failure_reason"`
+	CreatedAt           time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at" db:"updated_at"`
+	SubmittedAt         *time.Time `json:"submitted_at,omitempty" db:"submitted_at"`
+	ClosedAt            *time.Time `json:"closed_at,omitempty" db:"closed_at"`
+}
+
+// Outbound payment status constants
+const (
+	OutboundPaymentStatusQueued    = "QUEUED"    // Waiting to be handed to the gateway
+	OutboundPaymentStatusSubmitted = "SUBMITTED" // Accepted by the gateway, awaiting settlement
+	OutboundPaymentStatusSettled   = "SETTLED"
+	OutboundPaymentStatusRejected  = "REJECTED"
+)
+
+// IsClosed checks if the payment has reached a final status
+func (p *OutboundPayment) IsClosed() bool {
+	return p.Status == OutboundPaymentStatusSettled || p.Status == OutboundPaymentStatusRejected
+}
+
+// ClearingCallback is the status notification the interbank gateway posts
+// once a payment is settled or rejected by the receiving bank
+type ClearingCallback struct {
+	PaymentID        string `json:"payment_id"`
+	GatewayReference string `json:"gateway_reference,omitempty"`
+	Status           string `json:"status"` // SETTLED or REJECTED
+	Reason           string `json:"reason,omitempty"`
+}
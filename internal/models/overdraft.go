@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// Overdraft facility status constants
+const (
+	OverdraftStatusPending  = "PENDING"  // Requested, awaiting an admin's decision
+	OverdraftStatusApproved = "APPROVED" // In force on the account
+	OverdraftStatusRejected = "REJECTED"
+	OverdraftStatusRevoked  = "REVOKED" // Withdrawn by an admin or replaced by a later facility
+)
+
+// IsOverdraftAccountType checks if accounts of the type may be granted an
+// overdraft: only current accounts can
+func IsOverdraftAccountType(accountType string) bool {
+	return accountType == AccountTypeChecking
+}
+
+// OverdraftFacility is a request for an authorized overdraft on an account
+// and the admin's decision on it
+type OverdraftFacility struct {
+	ID             int        `json:"id"`
+	AccountID      int        `json:"account_id"`
+	AccountNumber  string     `json:"account_number"`
+	RequestedLimit int64      `json:"requested_limit"`
+	ApprovedLimit  int64      `json:"approved_limit,omitempty"`
+	RateBps        int        `json:"rate_bps,omitempty"` // Yearly debit interest on the negative balance
+	Status         string     `json:"status"`
+	Reason         string     `json:"reason,omitempty"`
+	RequestedBy    string     `json:"requested_by"`
+	DecidedBy      string     `json:"decided_by,omitempty"`
+	DecisionNote   string     `json:"decision_note,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DecidedAt      *time.Time `json:"decided_at,omitempty"`
+	EndedAt        *time.Time `json:"ended_at,omitempty"`
+}
+
+// OverdraftInterest returns the debit interest a negative closing balance
+// owes for a day, in millionths of the minor unit, at rateBps a year over
+// dayCount days. Balances of zero or more owe nothing.
+func OverdraftInterest(balance int64, rateBps, dayCount int) int64 {
+	if balance >= 0 || dayCount <= 0 {
+		return 0
+	}
+	return -balance * int64(rateBps) * (microsPerMinorUnit / 10000) / int64(dayCount)
+}
+
+// OverdraftInterestAccrual is the debit interest owed on an account's
+// negative closing balance on a business date
+type OverdraftInterestAccrual struct {
+	ID            int64      `json:"id"`
+	AccountID     int        `json:"account_id"`
+	AccountNumber string     `json:"account_number"`
+	BusinessDate  time.Time  `json:"business_date"`
+	Balance       int64      `json:"balance"`
+	RateBps       int        `json:"rate_bps"`
+	AmountMicros  int64      `json:"amount_micros"`
+	ChargedAt     *time.Time `json:"charged_at,omitempty"`
+	TransactionID string     `json:"transaction_id,omitempty"` // DEBIT_INTEREST transaction that charged it
+	CreatedAt     time.Time  `json:"created_at"`
+}
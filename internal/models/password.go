@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// PasswordResetToken is a stored (hashed) single-use token letting a
+// customer who forgot their password set a new one
+type PasswordResetToken struct {
+	ID         int        `db:"id"`
+	TokenHash  string     `db:"token_hash"`
+	CustomerID string     `db:"customer_id"`
+	CreatedAt  time.Time  `db:"created_at"`
+	ExpiresAt  time.Time  `db:"expires_at"`
+	UsedAt     *time.Time `db:"used_at"`
+}
+
+// IsValid checks if the token can still be used
+func (t *PasswordResetToken) IsValid() bool {
+	return t.UsedAt == nil && time.Now().UTC().Before(t.ExpiresAt)
+}
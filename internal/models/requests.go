@@ -7,7 +7,8 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
-// CreateAccountRequest represents the request payload for creating a new account
+// CreateAccountRequest represents the request payload for registering a new
+// customer together with their first account
 type CreateAccountRequest struct {
 	FirstName    string    `json:"first_name" validate:"required,min=2,max=50"`
 	LastName     string    `json:"last_name" validate:"required,min=2,max=50"`
@@ -20,8 +21,15 @@ type CreateAccountRequest struct {
 	Address      Address   `json:"address" validate:"required"`
 }
 
-// UpdateAccountRequest represents the request payload for updating an account
-type UpdateAccountRequest struct {
+// OpenAccountRequest represents the request payload for opening an additional
+// account for an existing customer
+type OpenAccountRequest struct {
+	AccountType string `json:"account_type" validate:"required"`
+	Currency    string `json:"currency" validate:"required"`
+}
+
+// UpdateCustomerRequest represents the request payload for updating a customer's details
+type UpdateCustomerRequest struct {
 	FirstName   string  `json:"first_name,omitempty"`
 	LastName    string  `json:"last_name,omitempty"`
 	Email       string  `json:"email,omitempty"`
@@ -29,28 +37,142 @@ type UpdateAccountRequest struct {
 	Address     Address `json:"address,omitempty"`
 }
 
-// LoginRequest represents the login request payload
+// LoginRequest represents the login request payload. Customers identify
+// themselves by customer ID; an account number is accepted as a fallback.
 type LoginRequest struct {
-	AccountNumber string `json:"account_number" validate:"required"`
+	CustomerID    string `json:"customer_id,omitempty"`
+	AccountNumber string `json:"account_number,omitempty"`
 	Password      string `json:"password" validate:"required"`
 }
 
 // LoginResponse represents the login response payload
 type LoginResponse struct {
-	Token         string    `json:"token"`
-	AccountNumber string    `json:"account_number"`
-	CustomerID    string    `json:"customer_id"`
-	ExpiresAt     time.Time `json:"expires_at"`
+	Token            string    `json:"token"`
+	RefreshToken     string    `json:"refresh_token"`
+	CustomerID       string    `json:"customer_id"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// MFAChallengeResponse is returned by a login whose customer has MFA
+// enabled: the challenge token is exchanged for the session's tokens by
+// POST /auth/login/mfa with a code
+type MFAChallengeResponse struct {
+	MFARequired bool      `json:"mfa_required"`
+	MFAToken    string    `json:"mfa_token"`
+	CustomerID  string    `json:"customer_id"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// MFALoginRequest completes a login with the challenge token and a TOTP or
+// recovery code
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+// MFACodeRequest carries a TOTP or recovery code
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// RecoveryCodesResponse lists newly generated recovery codes; they are never
+// shown again
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// StepUpResponse carries an access token for the same session, valid for
+// operations that require a recent second factor
+type StepUpResponse struct {
+	Token     string    `json:"token"`
+	ACR       string    `json:"acr"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// ChangePasswordRequest represents the request payload for changing the
+// caller's password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+// PasswordResetRequest asks for a reset token to be sent to the customer
+// with the email
+type PasswordResetRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest sets a new password with a reset token
+type ResetPasswordRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required"`
+}
+
+// RefreshTokenRequest represents the token refresh request payload
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// CreateStaffUserRequest represents the request payload for creating a staff user
+type CreateStaffUserRequest struct {
+	Username string `json:"username" validate:"required"`
+	Email    string `json:"email" validate:"required,email"`
+	FullName string `json:"full_name" validate:"required"`
+	Role     string `json:"role" validate:"required"`
+	Password string `json:"password" validate:"required,min=12"`
+}
+
+// StaffLoginRequest represents the staff login request payload
+type StaffLoginRequest struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// StaffLoginResponse represents the staff login response payload
+type StaffLoginResponse struct {
+	Token            string    `json:"token"`
+	RefreshToken     string    `json:"refresh_token"`
+	StaffID          string    `json:"staff_id"`
+	Username         string    `json:"username"`
+	Role             string    `json:"role"`
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+// CreateAPIClientRequest represents the request payload for registering an
+// API client
+type CreateAPIClientRequest struct {
+	Name           string   `json:"name" validate:"required"`
+	Scopes         []string `json:"scopes" validate:"required"`
+	AccountNumbers []string `json:"account_numbers" validate:"required"`
+	AllowedIPs     []string `json:"allowed_ips,omitempty"`
+}
+
+// UpdateAPIClientRequest represents the request payload for changing an API
+// client. Omitted fields are left alone; an empty allowed_ips list allows
+// any address.
+type UpdateAPIClientRequest struct {
+	Name           string   `json:"name,omitempty"`
+	Scopes         []string `json:"scopes"`
+	AccountNumbers []string `json:"account_numbers"`
+	AllowedIPs     []string `json:"allowed_ips"`
 }
 
 // TransferRequest represents a transfer request payload
 type TransferRequest struct {
 	FromAccountNumber string `json:"from_account_number" validate:"required"`
-	ToAccountNumber   string `json:"to_account_number" validate:"required"`
+	ToAccountNumber   string `json:"to_account_number,omitempty"`
+	ToIBAN            string `json:"to_iban,omitempty"`          // Alternative to ToAccountNumber
+	ToBIC             string `json:"to_bic,omitempty"`           // Beneficiary bank; another bank's BIC makes this an external transfer unless the IBAN is ours
+	BeneficiaryName   string `json:"beneficiary_name,omitempty"` // Required for external transfers
 	Amount            int64  `json:"amount" validate:"required,min=1"`
-	Currency          string `json:"currency" validate:"required"`
+	Currency          string `json:"currency" validate:"required"` // Must match the source account's currency
 	Description       string `json:"description,omitempty"`
 	Reference         string `json:"reference,omitempty"`
+	QuoteID           string `json:"quote_id,omitempty"` // Locked FX quote for a cross-currency transfer
+	TransactionID     string `json:"-"`                  // Preassigned by callers that must recognise the transfer later, e.g. standing orders
+	Channel           string `json:"-"`                  // Where the transfer was initiated; defaults to ONLINE
 }
 
 // DepositRequest represents a deposit request payload
@@ -69,6 +191,33 @@ type WithdrawalRequest struct {
 	Currency      string `json:"currency" validate:"required"`
 	Description   string `json:"description,omitempty"`
 	Reference     string `json:"reference,omitempty"`
+	Channel       string `json:"-"` // Where the withdrawal was initiated; defaults to ONLINE
+}
+
+// ReversalRequest represents a request to reverse (refund) a completed transaction
+type ReversalRequest struct {
+	TransactionID string `json:"-"`                // Original transaction, taken from the URL
+	Amount        int64  `json:"amount,omitempty"` // Partial refund amount; defaults to the remaining reversible amount
+	RefundFee     bool   `json:"refund_fee,omitempty"` // Staff only
+	Reason        string `json:"reason,omitempty"`
+}
+
+// PlaceHoldRequest represents a request to authorize (reserve) funds
+type PlaceHoldRequest struct {
+	AccountNumber     string     `json:"account_number" validate:"required"`
+	Amount            int64      `json:"amount" validate:"required,min=1"`
+	Currency          string     `json:"currency" validate:"required"`
+	MerchantReference string     `json:"merchant_reference" validate:"required"`
+	Description       string     `json:"description,omitempty"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"` // Defaults to the configured hold lifetime
+}
+
+// CaptureHoldRequest represents a request to capture an active hold. Capturing
+// less than the held amount releases the remainder.
+type CaptureHoldRequest struct {
+	HoldID      string `json:"-"`                // Taken from the URL
+	Amount      int64  `json:"amount,omitempty"` // Defaults to the full held amount
+	Description string `json:"description,omitempty"`
 }
 
 // TransactionHistoryRequest represents request for transaction history
@@ -83,11 +232,115 @@ type TransactionHistoryRequest struct {
 
 // BalanceResponse represents account balance response
 type BalanceResponse struct {
-	AccountNumber    string `json:"account_number"`
-	Balance          int64  `json:"balance"`
-	AvailableBalance int64  `json:"available_balance"`
-	Currency         string `json:"currency"`
-	HoldAmount       int64  `json:"hold_amount"`
+	AccountNumber      string `json:"account_number"`
+	Balance            int64  `json:"balance"`
+	AvailableBalance   int64  `json:"available_balance"`
+	Currency           string `json:"currency"`
+	HoldAmount         int64  `json:"hold_amount"`
+	OverdraftLimit     int64  `json:"overdraft_limit"`
+	OverdraftUsed      int64  `json:"overdraft_used"`      // Part of the available balance drawn below zero
+	OverdraftRemaining int64  `json:"overdraft_remaining"` // How much more can be overdrawn
+}
+
+// PaymentFileResponse reports the outcome of an uploaded pain.001 file
+type PaymentFileResponse struct {
+	MessageID string             `json:"message_id"`
+	DryRun    bool               `json:"dry_run"`
+	Total     int                `json:"total"`
+	Accepted  int                `json:"accepted"`
+	Rejected  int                `json:"rejected"`
+	Items     []*PaymentFileItem `json:"items"`
+}
+
+// PaymentFileItem is the outcome of one credit transfer of a payment file
+type PaymentFileItem struct {
+	EndToEndID    string   `json:"end_to_end_id"`
+	Amount        int64    `json:"amount,omitempty"`
+	ToIBAN        string   `json:"to_iban,omitempty"`
+	TransactionID string   `json:"transaction_id,omitempty"`
+	Status        string   `json:"status"` // Transaction status, VALID on a dry run, or REJECTED
+	Errors        []string `json:"errors,omitempty"`
+}
+
+// CreateStandingOrderRequest represents a standing order payload. Dates are
+// in YYYY-MM-DD format.
+type CreateStandingOrderRequest struct {
+	FromAccountNumber string `json:"from_account_number" validate:"required"`
+	ToAccountNumber   string `json:"to_account_number,omitempty"`
+	ToIBAN            string `json:"to_iban,omitempty"` // Alternative to ToAccountNumber
+	ToBIC             string `json:"to_bic,omitempty"`
+	BeneficiaryName   string `json:"beneficiary_name,omitempty"`
+	Amount            int64  `json:"amount" validate:"required,min=1"`
+	Currency          string `json:"currency" validate:"required"`
+	Description       string `json:"description,omitempty"`
+	Reference         string `json:"reference,omitempty"`
+	Frequency         string `json:"frequency" validate:"required"`  // ONCE, WEEKLY or MONTHLY
+	StartDate         string `json:"start_date" validate:"required"` // Execution date of a ONCE order
+	DayOfMonth        int    `json:"day_of_month,omitempty"`         // MONTHLY orders; defaults to the start date's day
+	EndDate           string `json:"end_date,omitempty"`
+	MaxOccurrences    int    `json:"max_occurrences,omitempty"`
+}
+
+// FeeScheduleRequest represents a fee schedule payload. An update replaces
+// the whole schedule.
+type FeeScheduleRequest struct {
+	AccountType     string    `json:"account_type,omitempty"`
+	TransactionType string    `json:"transaction_type" validate:"required"`
+	Channel         string    `json:"channel,omitempty"`
+	Currency        string    `json:"currency,omitempty"`
+	FlatFee         int64     `json:"flat_fee,omitempty"`
+	PercentageBps   int       `json:"percentage_bps,omitempty"`
+	Tiers           []FeeTier `json:"tiers,omitempty"`
+	MinFee          int64     `json:"min_fee,omitempty"`
+	MaxFee          int64     `json:"max_fee,omitempty"`
+	FreePerMonth    int       `json:"free_per_month,omitempty"`
+	Active          *bool     `json:"active,omitempty"` // Defaults to true
+}
+
+// FeeQuoteRequest asks for the fee of a transaction before making it
+type FeeQuoteRequest struct {
+	AccountNumber   string
+	TransactionType string
+	Channel         string
+	Amount          int64
+}
+
+// InterestRateTableRequest represents an interest rate table payload. The
+// effective date is in YYYY-MM-DD format.
+type InterestRateTableRequest struct {
+	AccountType   string         `json:"account_type" validate:"required"`
+	Currency      string         `json:"currency,omitempty"`
+	EffectiveFrom string         `json:"effective_from" validate:"required"`
+	Tiers         []InterestTier `json:"tiers" validate:"required"`
+}
+
+// LimitsRequest represents a limits update. Omitted limits are left as they
+// are; 0 lifts a limit.
+type LimitsRequest struct {
+	SingleMax   *int64 `json:"single_max,omitempty"`
+	DailyMax    *int64 `json:"daily_max,omitempty"`
+	MonthlyMax  *int64 `json:"monthly_max,omitempty"`
+	HourlyCount *int   `json:"hourly_count,omitempty"`
+}
+
+// OverdraftRequest asks for an overdraft facility on an account
+type OverdraftRequest struct {
+	Limit  int64  `json:"limit"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// OverdraftDecisionRequest records an admin's decision on an overdraft. An
+// approval may grant another limit or rate than requested.
+type OverdraftDecisionRequest struct {
+	Limit   int64  `json:"limit,omitempty"`    // Approved limit; the requested one if omitted
+	RateBps *int   `json:"rate_bps,omitempty"` // Debit interest rate; the configured default if omitted
+	Note    string `json:"note,omitempty"`
+}
+
+// InterestAccrualRunRequest asks to accrue interest for a past business date,
+// in YYYY-MM-DD format
+type InterestAccrualRunRequest struct {
+	BusinessDate string `json:"business_date" validate:"required"`
 }
 
 // ErrorResponse represents an error response
@@ -126,11 +379,21 @@ func (r *CreateAccountRequest) Validate() error {
 		return errors.New("password must be at least 8 characters long")
 	}
 	
-	if r.AccountType == "" {
+	return validateAccountProduct(r.AccountType, r.Currency)
+}
+
+// Validate validates the OpenAccountRequest
+func (r *OpenAccountRequest) Validate() error {
+	return validateAccountProduct(r.AccountType, r.Currency)
+}
+
+// validateAccountProduct checks the account type and currency of a new account
+func validateAccountProduct(accountType, currency string) error {
+	if accountType == "" {
 		return errors.New("account type is required")
 	}
 	
-	if r.Currency == "" {
+	if currency == "" {
 		return errors.New("currency is required")
 	}
 	
@@ -141,51 +404,203 @@ func (r *CreateAccountRequest) Validate() error {
 		AccountTypeBusiness: true,
 	}
 	
-	if !validAccountTypes[r.AccountType] {
+	if !validAccountTypes[accountType] {
 		return errors.New("invalid account type")
 	}
-		// Check if currency is valid for Tunisian banking
+	
+	// Check if currency is valid for Tunisian banking
 	validCurrencies := map[string]bool{
 		CurrencyTND: true,
 		CurrencyEUR: true, 
 		CurrencyUSD: true,
 	}
 	
-	if !validCurrencies[r.Currency] {
+	if !validCurrencies[currency] {
 		return errors.New("invalid currency")
 	}
 	
 	return nil
 }
 
-// NewAccount creates a new account from CreateAccountRequest
-func NewAccount(req *CreateAccountRequest, customerID, accountNumber, iban, bic string) (*Account, error) {
+// NewCustomer creates a new customer from CreateAccountRequest
+func NewCustomer(req *CreateAccountRequest, customerID string) (*Customer, error) {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
 		return nil, err
 	}
 	
-	account := &Account{
+	customer := &Customer{
+		CustomerID:   customerID,
+		FirstName:    req.FirstName,
+		LastName:     req.LastName,
+		Email:        req.Email,
+		Phone:        req.Phone,
+		DateOfBirth:  req.DateOfBirth,
+		Address:      req.Address,
+		HashPassword: string(hashedPassword),
+		Status:       CustomerStatusActive,
+		CreatedAt:    time.Now().UTC(),
+		UpdatedAt:    time.Now().UTC(),
+	}
+	
+	return customer, nil
+}
+
+// NewAccount creates a new empty account owned by customerID
+func NewAccount(customerID, accountType, currency, accountNumber, iban, bic string) *Account {
+	return &Account{
 		CustomerID:       customerID,
 		AccountNumber:    accountNumber,
-		IBAN:            iban,
-		BIC:             bic,
-		AccountType:     req.AccountType,
-		Currency:        req.Currency,
-		Balance:         0,
+		IBAN:             iban,
+		BIC:              bic,
+		AccountType:      accountType,
+		Currency:         currency,
+		Balance:          0,
 		AvailableBalance: 0,
-		HoldAmount:      0,
-		FirstName:       req.FirstName,
-		LastName:        req.LastName,
-		Email:           req.Email,
-		Phone:           req.Phone,
-		DateOfBirth:     req.DateOfBirth,
-		Address:         req.Address,
-		HashPassword:    string(hashedPassword),
-		Status:          AccountStatusActive,
-		CreatedAt:       time.Now().UTC(),
-		UpdatedAt:       time.Now().UTC(),
+		HoldAmount:       0,
+		Status:           AccountStatusActive,
+		CreatedAt:        time.Now().UTC(),
+		UpdatedAt:        time.Now().UTC(),
 	}
-	
-	return account, nil
+}
+
+// Validate validates the place hold request
+func (r *PlaceHoldRequest) Validate() error {
+	if r.AccountNumber == "" {
+		return errors.New("account number is required")
+	}
+	if r.Amount <= 0 {
+		return errors.New("hold amount must be positive")
+	}
+	if r.Currency == "" {
+		return errors.New("currency is required")
+	}
+	if r.MerchantReference == "" {
+		return errors.New("merchant reference is required")
+	}
+	return nil
+}
+
+// Validate validates the create staff user request
+func (r *CreateStaffUserRequest) Validate() error {
+	if len(r.Username) < 3 {
+		return errors.New("username must be at least 3 characters")
+	}
+	if err := ValidateEmail(r.Email); err != nil {
+		return err
+	}
+	if r.FullName == "" {
+		return errors.New("full name is required")
+	}
+	if !IsStaffRole(r.Role) {
+		return errors.New("role must be one of teller, compliance or admin")
+	}
+	if len(r.Password) < 12 {
+		return errors.New("staff passwords must be at least 12 characters")
+	}
+	return nil
+}
+
+// Validate validates the create API client request
+func (r *CreateAPIClientRequest) Validate() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	if err := ValidateScopes(r.Scopes); err != nil {
+		return err
+	}
+	if len(r.AccountNumbers) == 0 {
+		return errors.New("at least one account number is required")
+	}
+	return ValidateAllowedIPs(r.AllowedIPs)
+}
+
+// Validate validates the update API client request
+func (r *UpdateAPIClientRequest) Validate() error {
+	if r.Scopes != nil {
+		if err := ValidateScopes(r.Scopes); err != nil {
+			return err
+		}
+	}
+	if r.AccountNumbers != nil && len(r.AccountNumbers) == 0 {
+		return errors.New("at least one account number is required")
+	}
+	return ValidateAllowedIPs(r.AllowedIPs)
+}
+
+// Validate validates the create standing order request
+func (r *CreateStandingOrderRequest) Validate() error {
+	if r.FromAccountNumber == "" {
+		return errors.New("source account number is required")
+	}
+	if r.ToAccountNumber == "" && r.ToIBAN == "" {
+		return errors.New("destination account number or IBAN is required")
+	}
+	if r.Amount <= 0 {
+		return errors.New("standing order amount must be positive")
+	}
+	if r.Currency == "" {
+		return errors.New("currency is required")
+	}
+	switch r.Frequency {
+	case StandingOrderOnce, StandingOrderWeekly, StandingOrderMonthly:
+	default:
+		return errors.New("frequency must be ONCE, WEEKLY or MONTHLY")
+	}
+	if r.DayOfMonth < 0 || r.DayOfMonth > 31 {
+		return errors.New("day of month must be between 1 and 31")
+	}
+	if r.MaxOccurrences < 0 {
+		return errors.New("max occurrences cannot be negative")
+	}
+	return nil
+}
+
+// Validate checks that no limit is negative
+func (r *LimitsRequest) Validate() error {
+	for _, limit := range []*int64{r.SingleMax, r.DailyMax, r.MonthlyMax} {
+		if limit != nil && *limit < 0 {
+			return errors.New("limits cannot be negative")
+		}
+	}
+	if r.HourlyCount != nil && *r.HourlyCount < 0 {
+		return errors.New("limits cannot be negative")
+	}
+	return nil
+}
+
+// Apply returns limits with the request's limits set
+func (r *LimitsRequest) Apply(limits TransactionLimits) TransactionLimits {
+	if r.SingleMax != nil {
+		limits.SingleMax = *r.SingleMax
+	}
+	if r.DailyMax != nil {
+		limits.DailyMax = *r.DailyMax
+	}
+	if r.MonthlyMax != nil {
+		limits.MonthlyMax = *r.MonthlyMax
+	}
+	if r.HourlyCount != nil {
+		limits.HourlyCount = *r.HourlyCount
+	}
+	return limits
+}
+
+// Validate checks the requested overdraft
+func (r *OverdraftRequest) Validate() error {
+	if r.Limit <= 0 {
+		return errors.New("limit must be positive")
+	}
+	return nil
+}
+
+// Validate checks the decision's amounts
+func (r *OverdraftDecisionRequest) Validate() error {
+	if r.Limit < 0 {
+		return errors.New("limit cannot be negative")
+	}
+	if r.RateBps != nil && *r.RateBps < 0 {
+		return errors.New("rate_bps cannot be negative")
+	}
+	return nil
 }
@@ -0,0 +1,107 @@
+package models
+
+// Roles carried in access tokens. Customers act on their own accounts only;
+// the back-office roles belong to staff users and reach any account within
+// the permissions of their role. API clients reach their allowed accounts,
+// on the routes their scopes open.
+const (
+	RoleCustomer   = "customer"
+	RoleTeller     = "teller"
+	RoleCompliance = "compliance"
+	RoleAdmin      = "admin"
+	RoleAPIClient  = "api_client"
+)
+
+// Permissions checked per route
+const (
+	PermAccountRead         = "account:read"
+	PermAccountList         = "account:list" // List every customer's accounts
+	PermAccountOpen         = "account:open"
+	PermAccountStatus       = "account:status"
+	PermAccountDelete       = "account:delete"
+	PermCustomerRead        = "customer:read"
+	PermCustomerUpdate      = "customer:update"
+	PermCustomerUnlock      = "customer:unlock" // Lift a login lockout
+	PermTransactionCreate   = "transaction:create"
+	PermTransactionRead     = "transaction:read"
+	PermTransactionCancel   = "transaction:cancel"
+	PermTransactionRevert   = "transaction:reverse"
+	PermHoldManage          = "hold:manage"
+	PermHoldRead            = "hold:read"
+	PermStandingOrderManage = "standing_order:manage"
+	PermStandingOrderRead   = "standing_order:read"
+	PermFXQuote             = "fx:quote"
+	PermFeeQuote            = "fee:quote"
+	PermFeeManage           = "fee:manage"
+	PermInterestRead        = "interest:read"
+	PermInterestManage      = "interest:manage"
+	PermLimitRead           = "limit:read"
+	PermLimitUpdate         = "limit:update" // Lower an account's limits
+	PermLimitManage         = "limit:manage" // Raise limits and set the account type defaults
+	PermOverdraftRead       = "overdraft:read"
+	PermOverdraftRequest    = "overdraft:request"
+	PermOverdraftManage     = "overdraft:manage" // Approve, reject and revoke overdrafts
+	PermStaffManage         = "staff:manage"
+	PermAPIClientManage     = "api_client:manage" // Register partner API clients
+	PermMetricsRead         = "metrics:read"
+)
+
+var rolePermissions = map[string][]string{
+	RoleCustomer: {
+		PermAccountRead, PermAccountOpen, PermCustomerRead, PermCustomerUpdate,
+		PermTransactionCreate, PermTransactionRead, PermTransactionCancel, PermTransactionRevert,
+		PermHoldManage, PermHoldRead, PermFXQuote, PermFeeQuote,
+		PermStandingOrderManage, PermStandingOrderRead, PermInterestRead,
+		PermLimitRead, PermLimitUpdate, PermOverdraftRead, PermOverdraftRequest,
+	},
+	RoleTeller: {
+		PermAccountRead, PermAccountList, PermAccountOpen, PermAccountStatus,
+		PermCustomerRead, PermCustomerUpdate,
+		PermTransactionCreate, PermTransactionRead, PermTransactionCancel,
+		PermHoldRead, PermFeeQuote, PermInterestRead, PermLimitRead,
+		PermStandingOrderManage, PermStandingOrderRead,
+		PermOverdraftRead, PermOverdraftRequest,
+	},
+	RoleCompliance: {
+		PermAccountRead, PermAccountList, PermAccountStatus, PermCustomerRead,
+		PermTransactionRead, PermTransactionRevert,
+		PermHoldManage, PermHoldRead,
+		PermStandingOrderRead, PermFeeQuote, PermInterestRead, PermLimitRead,
+		PermOverdraftRead,
+	},
+	RoleAdmin: {
+		PermAccountRead, PermAccountList, PermAccountOpen, PermAccountStatus, PermAccountDelete,
+		PermCustomerRead, PermCustomerUpdate, PermCustomerUnlock,
+		PermTransactionCreate, PermTransactionRead, PermTransactionCancel, PermTransactionRevert,
+		PermHoldManage, PermHoldRead, PermStaffManage, PermAPIClientManage,
+		PermStandingOrderManage, PermStandingOrderRead, PermMetricsRead,
+		PermFeeQuote, PermFeeManage, PermInterestRead, PermInterestManage,
+		PermLimitRead, PermLimitUpdate, PermLimitManage,
+		PermOverdraftRead, PermOverdraftRequest, PermOverdraftManage,
+	},
+	// The most an API client can do; each route also needs its scope
+	RoleAPIClient: {
+		PermAccountRead, PermTransactionCreate, PermTransactionRead,
+	},
+}
+
+// IsValidRole checks if role is one of the known roles
+func IsValidRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// IsStaffRole checks if role belongs to back-office staff
+func IsStaffRole(role string) bool {
+	return role == RoleTeller || role == RoleCompliance || role == RoleAdmin
+}
+
+// HasPermission checks if role grants permission
+func HasPermission(role, permission string) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// Session subject types
+const (
+	SubjectCustomer  = "customer"
+	SubjectStaff     = "staff"
+	SubjectAPIClient = "api_client"
+)
+
+// Session revocation reasons
+const (
+	RevokedLogout     = "LOGOUT"
+	RevokedLogoutAll  = "LOGOUT_ALL"
+	RevokedTokenReuse = "REFRESH_TOKEN_REUSE"
+	// Other sessions end when the customer changes their password, all of
+	// them when it is reset
+	RevokedPasswordChange = "PASSWORD_CHANGE"
+	RevokedPasswordReset  = "PASSWORD_RESET"
+	// Tokens of an API client end when it is disabled or its secret rotated
+	RevokedClientDisabled = "CLIENT_DISABLED"
+	RevokedSecretRotated  = "SECRET_ROTATED"
+)
+
+// AuthSession is one login of a customer (identified by customer ID), staff
+// user (by staff ID) or API client (by client ID). Access tokens carry its ID in the sid claim, so
+// revoking the session kills every token issued for it.
+type AuthSession struct {
+	ID            int        `json:"-" db:"id"`
+	SessionID     string     `json:"session_id" db:"session_id"`
+	SubjectType   string     `json:"subject_type" db:"subject_type"`
+	SubjectID     string     `json:"subject_id" db:"subject_id"`
+	UserAgent     string     `json:"user_agent,omitempty" db:"user_agent"`
+	IPAddress     string     `json:"ip_address,omitempty" db:"ip_address"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	LastUsedAt    time.Time  `json:"last_used_at" db:"last_used_at"`
+	ExpiresAt     time.Time  `json:"expires_at" db:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	RevokedReason string     `json:"revoked_reason,omitempty" db:"revoked_reason"`
+	// How the session authenticated, see AuthMethodPassword. Stepping up
+	// adds methods and moves AuthenticatedAt forward.
+	AuthMethods     []string  `json:"auth_methods" db:"auth_methods"`
+	AuthenticatedAt time.Time `json:"authenticated_at" db:"authenticated_at"`
+}
+
+// IsValid checks if the session can still be refreshed
+func (s *AuthSession) IsValid() bool {
+	return s.RevokedAt == nil && time.Now().UTC().Before(s.ExpiresAt)
+}
+
+// RefreshToken is a stored (hashed) single-use refresh token
+type RefreshToken struct {
+	ID        int        `db:"id"`
+	TokenHash string     `db:"token_hash"`
+	SessionID string     `db:"session_id"`
+	CreatedAt time.Time  `db:"created_at"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+}
+
+// IsExpired checks if the refresh token can no longer be exchanged
+func (t *RefreshToken) IsExpired() bool {
+	return time.Now().UTC().After(t.ExpiresAt)
+}
+
+// IsUsed checks if the refresh token has already been rotated
+func (t *RefreshToken) IsUsed() bool {
+	return t.UsedAt != nil
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// StaffUser is a back-office user (teller, compliance officer or admin).
+// Staff users are kept apart from customer accounts and log in separately.
+type StaffUser struct {
+	ID           int        `json:"id" db:"id"`
+	StaffID      string     `json:"staff_id" db:"staff_id"`
+	Username     string     `json:"username" db:"username"`
+	Email        string     `json:"email" db:"email"`
+	FullName     string     `json:"full_name" db:"full_name"`
+	Role         string     `json:"role" db:"role"`
+	HashPassword string     `json:"-" db:"hash_password"`
+	Status       string     `json:"status" db:"status"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+	LastLoginAt  *time.Time `json:"last_login_at" db:"last_login_at"`
+}
+
+// Staff status constants
+const (
+	StaffStatusActive   = "ACTIVE"
+	StaffStatusDisabled = "DISABLED"
+)
+
+// ValidatePassword checks if the provided password matches the hashed password
+func (u *StaffUser) ValidatePassword(password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(u.HashPassword), []byte(password)) == nil
+}
+
+// IsActive checks if the staff user may log in
+func (u *StaffUser) IsActive() bool {
+	return u.Status == StaffStatusActive
+}
@@ -0,0 +1,174 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// StandingOrder is a transfer the bank executes on a schedule: once on a
+// future date, weekly on the start date's weekday, or monthly on a day of the
+// month. Occurrences are dates; an order is due from midnight UTC of its
+// next run date.
+type StandingOrder struct {
+	ID                int        `json:"id" db:"id"`
+	OrderID           string     `json:"order_id" db:"order_id"`
+	FromAccountNumber string     `json:"from_account_number" db:"from_account_number"`
+	ToAccountNumber   string     `json:"to_account_number,omitempty" db:"to_account_number"`
+	ToIBAN            string     `json:"to_iban,omitempty" db:"to_iban"`
+	ToBIC             string     `json:"to_bic,omitempty" db:"to_bic"`
+	BeneficiaryName   string     `json:"beneficiary_name,omitempty" db:"beneficiary_name"`
+	Amount            int64      `json:"amount" db:"amount"`
+	Currency          string     `json:"currency" db:"currency"`
+	Description       string     `json:"description,omitempty" db:"description"`
+	Reference         string     `json:"reference,omitempty" db:"reference"`
+	Frequency         string     `json:"frequency" db:"frequency"`
+	DayOfMonth        int        `json:"day_of_month,omitempty" db:"day_of_month"` // Monthly orders; the last day of shorter months
+	StartDate         time.Time  `json:"start_date" db:"start_date"`
+	EndDate           *time.Time `json:"end_date,omitempty" db:"end_date"`               // Last day an occurrence may fall on
+	MaxOccurrences    int        `json:"max_occurrences,omitempty" db:"max_occurrences"` // 0 for no limit
+	Occurrences       int        `json:"occurrences" db:"occurrences"`                   // Occurrences executed or given up on
+	NextRunDate       *time.Time `json:"next_run_date,omitempty" db:"next_run_date"`
+	NextAttemptAt     *time.Time `json:"next_attempt_at,omitempty" db:"next_attempt_at"` // Later than the run date while retrying
+	RetryCount        int        `json:"retry_count" db:"retry_count"`                   // Failed attempts at the current occurrence
+	Status            string     `json:"status" db:"status"`
+	LastError         string     `json:"last_error,omitempty" db:"last_error"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// Standing order frequencies
+const (
+	StandingOrderOnce    = "ONCE"
+	StandingOrderWeekly  = "WEEKLY"
+	StandingOrderMonthly = "MONTHLY"
+)
+
+// Standing order status constants
+const (
+	StandingOrderStatusActive    = "ACTIVE"
+	StandingOrderStatusPaused    = "PAUSED"
+	StandingOrderStatusCancelled = "CANCELLED"
+	StandingOrderStatusCompleted = "COMPLETED" // Schedule ended
+)
+
+// StandingOrderExecution is one attempt at executing an occurrence of a
+// standing order. Its transaction ID is assigned before the transfer runs,
+// so an attempt interrupted by a crash can be matched with its transfer.
+type StandingOrderExecution struct {
+	ID            int        `json:"id" db:"id"`
+	OrderID       string     `json:"order_id" db:"order_id"`
+	ScheduledFor  time.Time  `json:"scheduled_for" db:"scheduled_for"`
+	Attempt       int        `json:"attempt" db:"attempt"`
+	TransactionID string     `json:"transaction_id" db:"transaction_id"`
+	Status        string     `json:"status" db:"status"`
+	Error         string     `json:"error,omitempty" db:"error"`
+	StartedAt     time.Time  `json:"started_at" db:"started_at"`
+	FinishedAt    *time.Time `json:"finished_at,omitempty" db:"finished_at"`
+}
+
+// Standing order execution status constants
+const (
+	ExecutionStatusRunning   = "RUNNING"
+	ExecutionStatusCompleted = "COMPLETED"
+	ExecutionStatusFailed    = "FAILED"
+)
+
+// IsClosed checks if the order will not run again
+func (o *StandingOrder) IsClosed() bool {
+	return o.Status == StandingOrderStatusCancelled || o.Status == StandingOrderStatusCompleted
+}
+
+// TransferRequest returns the transfer an occurrence of the order executes
+func (o *StandingOrder) TransferRequest() *TransferRequest {
+	return &TransferRequest{
+		FromAccountNumber: o.FromAccountNumber,
+		ToAccountNumber:   o.ToAccountNumber,
+		ToIBAN:            o.ToIBAN,
+		ToBIC:             o.ToBIC,
+		BeneficiaryName:   o.BeneficiaryName,
+		Amount:            o.Amount,
+		Currency:          o.Currency,
+		Description:       o.Description,
+		Reference:         o.Reference,
+		Channel:           ChannelStandingOrder,
+	}
+}
+
+// Schedule sets the first run date: the first occurrence on or after the
+// start date
+func (o *StandingOrder) Schedule() error {
+	first := o.StartDate
+	if o.Frequency == StandingOrderMonthly {
+		first = monthlyOccurrence(o.StartDate.Year(), o.StartDate.Month(), o.DayOfMonth)
+		if first.Before(o.StartDate) {
+			first = monthlyOccurrence(o.StartDate.Year(), o.StartDate.Month()+1, o.DayOfMonth)
+		}
+	}
+
+	if o.EndDate != nil && first.After(*o.EndDate) {
+		return errors.New("standing order has no occurrence before its end date")
+	}
+
+	o.setNextRun(first)
+	return nil
+}
+
+// Advance moves to the occurrence after the current one, once it was
+// executed or given up on, and completes the order when its schedule ends
+func (o *StandingOrder) Advance() {
+	o.Occurrences++
+	if o.Frequency == StandingOrderOnce || (o.MaxOccurrences > 0 && o.Occurrences >= o.MaxOccurrences) {
+		o.complete()
+		return
+	}
+
+	o.setNextRun(o.occurrenceAfter(*o.NextRunDate))
+	if o.EndDate != nil && o.NextRunDate.After(*o.EndDate) {
+		o.complete()
+	}
+}
+
+// SkipTo moves past occurrences that fell before date without counting them,
+// e.g. those missed while the order was paused
+func (o *StandingOrder) SkipTo(date time.Time) {
+	for o.NextRunDate != nil && o.NextRunDate.Before(date) {
+		if o.Frequency == StandingOrderOnce {
+			o.complete()
+			return
+		}
+		o.setNextRun(o.occurrenceAfter(*o.NextRunDate))
+		if o.EndDate != nil && o.NextRunDate.After(*o.EndDate) {
+			o.complete()
+		}
+	}
+}
+
+func (o *StandingOrder) setNextRun(date time.Time) {
+	o.NextRunDate = &date
+	o.NextAttemptAt = &date
+	o.RetryCount = 0
+}
+
+func (o *StandingOrder) complete() {
+	o.Status = StandingOrderStatusCompleted
+	o.NextRunDate = nil
+	o.NextAttemptAt = nil
+	o.RetryCount = 0
+}
+
+func (o *StandingOrder) occurrenceAfter(date time.Time) time.Time {
+	if o.Frequency == StandingOrderWeekly {
+		return date.AddDate(0, 0, 7)
+	}
+	return monthlyOccurrence(date.Year(), date.Month()+1, o.DayOfMonth)
+}
+
+// monthlyOccurrence returns day of the month, or the month's last day if it
+// is shorter. month may overflow into the next year.
+func monthlyOccurrence(year int, month time.Month, day int) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	if last := first.AddDate(0, 1, -1).Day(); day > last {
+		day = last
+	}
+	return first.AddDate(0, 0, day-1)
+}
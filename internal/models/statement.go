@@ -0,0 +1,88 @@
+package models
+
+import "time"
+
+// Statement formats
+const (
+	StatementFormatJSON = "json"
+	StatementFormatCSV  = "csv"
+	StatementFormatPDF  = "pdf"
+)
+
+// Statement is an account statement for a period of whole days: the opening
+// balance, every booked transaction with the balance after it, and totals
+type Statement struct {
+	AccountNumber  string          `json:"account_number"`
+	IBAN           string          `json:"iban"`
+	BIC            string          `json:"bic"`
+	Currency       string          `json:"currency"`
+	OwnerName      string          `json:"owner_name"`
+	PeriodStart    time.Time       `json:"period_start"`
+	PeriodEnd      time.Time       `json:"period_end"` // Last day included
+	OpeningBalance int64           `json:"opening_balance"`
+	TotalDebits    int64           `json:"total_debits"` // Fees excluded
+	TotalCredits   int64           `json:"total_credits"`
+	TotalFees      int64           `json:"total_fees"`
+	ClosingBalance int64           `json:"closing_balance"`
+	Lines          []StatementLine `json:"lines"`
+	GeneratedAt    time.Time       `json:"generated_at"`
+}
+
+// StatementLine is one booked transaction of a statement: the amount
+// debited or credited, or the fee charged
+type StatementLine struct {
+	Date            time.Time `json:"date"`
+	TransactionID   string    `json:"transaction_id"`
+	TransactionType string    `json:"transaction_type"`
+	Description     string    `json:"description"`
+	Reference       string    `json:"reference,omitempty"`
+	Debit           int64     `json:"debit"`
+	Credit          int64     `json:"credit"`
+	Fee             int64     `json:"fee"`
+	Balance         int64     `json:"balance"` // Running balance after the line
+}
+
+// StoredStatement is a statement rendered ahead of time by the monthly
+// statement job
+type StoredStatement struct {
+	ID             int       `json:"id" db:"id"`
+	AccountNumber  string    `json:"account_number" db:"account_number"`
+	PeriodStart    time.Time `json:"period_start" db:"period_start"`
+	PeriodEnd      time.Time `json:"period_end" db:"period_end"`
+	OpeningBalance int64     `json:"opening_balance" db:"opening_balance"`
+	ClosingBalance int64     `json:"closing_balance" db:"closing_balance"`
+	LineCount      int       `json:"line_count" db:"line_count"`
+	CSV            []byte    `json:"-" db:"csv_document"`
+	PDF            []byte    `json:"-" db:"pdf_document"`
+	CreatedAt      time.Time `json:"created_at" db:"created_at"`
+}
+
+// AddLine appends a transaction to the statement, updating the running
+// balance and the totals
+func (s *Statement) AddLine(t *Transaction) {
+	net := t.NetAmountFor(s.AccountNumber)
+	line := StatementLine{
+		Date:            t.CreatedAt,
+		TransactionID:   t.TransactionID,
+		TransactionType: t.TransactionType,
+		Description:     t.Description,
+		Reference:       t.Reference,
+	}
+
+	// Fees are booked as FEE transactions of their own
+	switch {
+	case t.TransactionType == TransactionTypeFee:
+		line.Fee = -net
+	case net < 0:
+		line.Debit = -net
+	default:
+		line.Credit = net
+	}
+
+	s.ClosingBalance += net
+	line.Balance = s.ClosingBalance
+	s.TotalDebits += line.Debit
+	s.TotalCredits += line.Credit
+	s.TotalFees += line.Fee
+	s.Lines = append(s.Lines, line)
+}
@@ -13,7 +13,7 @@ type Transaction struct {
 	ToAccountID           int       `json:"to_account_id" db:"to_account_id"`
 	FromAccountNumber     string    `json:"from_account_number" db:"from_account_number"`
 	ToAccountNumber       string    `json:"to_account_number" db:"to_account_number"`
-	Amount                int64     `json:"amount" db:"amount"` // Amount in cents
+	Amount                int64     `json:"amount" db:"amount"` // In the currency's minor unit, e.g. millimes
 	Currency              string    `json:"currency" db:"currency"`
 	ExchangeRate          float64   `json:"exchange_rate" db:"exchange_rate"`
 	ConvertedAmount       int64     `json:"converted_amount" db:"converted_amount"`
@@ -21,21 +21,34 @@ type Transaction struct {
 	Status                string    `json:"status" db:"status"`
 	Description           string    `json:"description" db:"description"`
 	Reference             string    `json:"reference" db:"reference"`
-	Fee                   int64     `json:"fee" db:"fee"`
+	Fee                   int64     `json:"fee" db:"fee"` // Charged on the transaction, booked by a FEE transaction linked to it
 	ProcessedAt           *time.Time `json:"processed_at" db:"processed_at"`
 	CreatedAt             time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt             time.Time `json:"updated_at" db:"updated_at"`
 	FailureReason         string    `json:"failure_reason,omitempty" db:"failure_reason"`
+	OriginalTransactionID string    `json:"original_transaction_id,omitempty" db:"original_transaction_id"` // Set on reversals
+	FeeRefund             int64     `json:"fee_refund,omitempty" db:"fee_refund"`                             // Fee of the original refunded by a reversal
+	FXQuoteID             string    `json:"fx_quote_id,omitempty" db:"fx_quote_id"`                           // Rate snapshot of a cross-currency transfer
+	CounterpartyIBAN      string    `json:"counterparty_iban,omitempty" db:"counterparty_iban"`               // Beneficiary at another bank
+	CounterpartyBIC       string    `json:"counterparty_bic,omitempty" db:"counterparty_bic"`
+	CounterpartyName      string    `json:"counterparty_name,omitempty" db:"counterparty_name"`
+	Attempts              int       `json:"attempts,omitempty" db:"attempts"`                                 // Failed attempts of the pending transaction worker
+	Channel               string    `json:"channel,omitempty" db:"channel"`                                   // Where the transaction was initiated
+	ParentTransactionID   string    `json:"parent_transaction_id,omitempty" db:"parent_transaction_id"`       // Set on fees: the transaction charged
 }
 
 // Transaction type constants
 const (
-	TransactionTypeTransfer    = "TRANSFER"
-	TransactionTypeDeposit     = "DEPOSIT"
-	TransactionTypeWithdrawal  = "WITHDRAWAL"
-	TransactionTypePayment     = "PAYMENT"
-	TransactionTypeFee         = "FEE"
-	TransactionTypeInterest    = "INTEREST"
+	TransactionTypeTransfer      = "TRANSFER"
+	TransactionTypeDeposit       = "DEPOSIT"
+	TransactionTypeWithdrawal    = "WITHDRAWAL"
+	TransactionTypePayment       = "PAYMENT"
+	TransactionTypeFee           = "FEE"
+	TransactionTypeInterest      = "INTEREST"
+	TransactionTypeReversal      = "REVERSAL"
+	TransactionTypeExternal      = "EXTERNAL_TRANSFER" // Transfer to another bank through clearing
+	TransactionTypeWithholding   = "WITHHOLDING_TAX"   // Tax withheld at source from credited interest
+	TransactionTypeDebitInterest = "DEBIT_INTEREST"    // Interest charged on an overdrawn balance
 )
 
 // Transaction status constants
@@ -46,6 +59,13 @@ const (
 	TransactionStatusCancelled = "CANCELLED"
 )
 
+// PendingQueueStats describes the pending transactions waiting for the
+// background worker
+type PendingQueueStats struct {
+	Depth int           // Transactions waiting, including those waiting for a retry
+	Lag   time.Duration // Age of the oldest one
+}
+
 // ValidateTransaction validates transaction data
 func (t *Transaction) ValidateTransaction() error {
 	if t.Amount <= 0 {
@@ -82,7 +102,45 @@ func (t *Transaction) IsPending() bool {
 	return t.Status == TransactionStatusPending
 }
 
-// CanBeCancelled checks if transaction can be cancelled
+// CanBeCancelled checks if transaction can be cancelled. External transfers
+// are already debited while pending and can only be failed by the gateway.
 func (t *Transaction) CanBeCancelled() bool {
-	return t.Status == TransactionStatusPending
+	return t.Status == TransactionStatusPending && t.TransactionType != TransactionTypeExternal
+}
+
+// CanBeReversed checks if a completed transaction can be (partially) reversed
+func (t *Transaction) CanBeReversed() bool {
+	return t.Status == TransactionStatusCompleted && t.TransactionType == TransactionTypeTransfer
+}
+
+// IsBooked checks if the transaction's amounts are on its accounts' balances:
+// it completed, or it is an external transfer debited while awaiting settlement
+func (t *Transaction) IsBooked() bool {
+	return t.Status == TransactionStatusCompleted ||
+		(t.Status == TransactionStatusPending && t.TransactionType == TransactionTypeExternal)
+}
+
+// NetAmountFor returns how much the transaction moved the given account's
+// balance: positive when it was credited, negative when it was debited
+func (t *Transaction) NetAmountFor(accountNumber string) int64 {
+	// A reversal debits the original recipient what they were credited and
+	// refunds the original sender the amount plus any refunded fee
+	if t.TransactionType == TransactionTypeReversal {
+		switch accountNumber {
+		case t.FromAccountNumber:
+			return -t.ConvertedAmount
+		case t.ToAccountNumber:
+			return t.Amount + t.FeeRefund
+		}
+		return 0
+	}
+	
+	// The fee charged on a transaction is booked by its FEE transaction
+	switch accountNumber {
+	case t.FromAccountNumber:
+		return -t.Amount
+	case t.ToAccountNumber:
+		return t.ConvertedAmount
+	}
+	return 0
 }
@@ -0,0 +1,163 @@
+// Package notify delivers messages to customers outside the API, such as
+// password reset tokens
+package notify
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Message is a notification to one recipient
+type Message struct {
+	To      string    `json:"to"`
+	Subject string    `json:"subject"`
+	Body    string    `json:"body"`
+	SentAt  time.Time `json:"sent_at"`
+}
+
+// Sender delivers notifications. A production deployment plugs in its mail
+// or SMS provider here.
+type Sender interface {
+	Send(msg *Message) error
+}
+
+// Options configures the sender selected by NOTIFY_SENDER
+type Options struct {
+	Kind         string // smtp, log, file, or empty for none
+	OutboxDir    string // Where the file sender writes notifications
+	SMTPAddr     string // host:port of the mail server
+	SMTPUsername string // Empty sends without authentication
+	SMTPPassword string
+	SMTPFrom     string
+}
+
+// NewSender selects the sender configured by NOTIFY_SENDER. With none it
+// returns a nil Sender: nothing is sent and features that need one, such as
+// password resets, are disabled.
+func NewSender(opts Options) (Sender, error) {
+	switch opts.Kind {
+	case "":
+		return nil, nil
+	case "smtp":
+		return NewSMTPSender(opts.SMTPAddr, opts.SMTPUsername, opts.SMTPPassword, opts.SMTPFrom)
+	case "log":
+		return NewLogSender(), nil
+	case "file":
+		return NewFileSender(opts.OutboxDir)
+	default:
+		return nil, fmt.Errorf("unknown notification sender: %s", opts.Kind)
+	}
+}
+
+// SMTPSender emails notifications through a mail server. Credentials are
+// only sent once the connection has switched to TLS with STARTTLS.
+type SMTPSender struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPSender(addr, username, password, from string) (Sender, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP server address %q: %w", addr, err)
+	}
+	if from == "" {
+		return nil, fmt.Errorf("sender address is required for the SMTP sender")
+	}
+
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTPSender{addr: addr, auth: auth, from: from}, nil
+}
+
+func (s *SMTPSender) Send(msg *Message) error {
+	if msg.SentAt.IsZero() {
+		msg.SentAt = time.Now().UTC()
+	}
+	if strings.ContainsAny(msg.To, "\r\n") {
+		return fmt.Errorf("invalid recipient address")
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "From: %s\r\n", s.from)
+	fmt.Fprintf(&body, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&body, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&body, "Date: %s\r\n", msg.SentAt.Format(time.RFC1123Z))
+	body.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	body.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+
+	if err := smtp.SendMail(s.addr, s.auth, s.from, []string{msg.To}, []byte(body.String())); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// LogSender writes notifications to the server log, for local development
+// only: the log then holds whatever secrets the messages carry
+type LogSender struct{}
+
+func NewLogSender() Sender {
+	return &LogSender{}
+}
+
+func (s *LogSender) Send(msg *Message) error {
+	log.Printf("Notification to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	return nil
+}
+
+// FileSender writes each notification as a JSON file into an outbox
+// directory, for local development and tests only
+type FileSender struct {
+	dir string
+}
+
+func NewFileSender(dir string) (Sender, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("notification outbox directory is required for the file sender")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create notification outbox: %w", err)
+	}
+
+	return &FileSender{dir: dir}, nil
+}
+
+func (s *FileSender) Send(msg *Message) error {
+	if msg.SentAt.IsZero() {
+		msg.SentAt = time.Now().UTC()
+	}
+
+	content, err := json.MarshalIndent(msg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+
+	// Write then rename so readers never see a partial file
+	name := fmt.Sprintf("%s-%s.json", msg.SentAt.Format("20060102T150405.000000000"), hex.EncodeToString(suffix))
+	tmp := filepath.Join(s.dir, "."+name+".tmp")
+	if err := os.WriteFile(tmp, content, 0o640); err != nil {
+		return fmt.Errorf("failed to write notification: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(s.dir, name)); err != nil {
+		return fmt.Errorf("failed to write notification: %w", err)
+	}
+
+	return nil
+}
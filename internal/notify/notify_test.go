@@ -0,0 +1,33 @@
+package notify
+
+import "testing"
+
+func TestNewSender(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "none disables sending", opts: Options{}, wantNil: true},
+		{name: "log", opts: Options{Kind: "log"}},
+		{name: "file", opts: Options{Kind: "file", OutboxDir: t.TempDir()}},
+		{name: "file without outbox", opts: Options{Kind: "file"}, wantErr: true},
+		{name: "smtp", opts: Options{Kind: "smtp", SMTPAddr: "mail.example.tn:587", SMTPFrom: "no-reply@example.tn"}},
+		{name: "smtp without port", opts: Options{Kind: "smtp", SMTPAddr: "mail.example.tn", SMTPFrom: "no-reply@example.tn"}, wantErr: true},
+		{name: "smtp without from", opts: Options{Kind: "smtp", SMTPAddr: "mail.example.tn:587"}, wantErr: true},
+		{name: "unknown", opts: Options{Kind: "pigeon"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender, err := NewSender(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewSender error = %v, want error %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (sender == nil) != tt.wantNil {
+				t.Errorf("NewSender returned %v, want nil %v", sender, tt.wantNil)
+			}
+		})
+	}
+}
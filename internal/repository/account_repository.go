@@ -3,6 +3,7 @@ package repository
 import (
 	"database/sql"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/bank-api/internal/models"
@@ -13,69 +14,103 @@ type AccountRepository interface {
 	Create(account *models.Account) error
 	GetByID(id int) (*models.Account, error)
 	GetByAccountNumber(accountNumber string) (*models.Account, error)
+	GetByIBAN(iban string) (*models.Account, error)
 	GetByCustomerID(customerID string) ([]*models.Account, error)
 	GetAll(limit, offset int) ([]*models.Account, error)
-	Update(id int, account *models.Account) error
-	UpdateBalance(accountNumber string, balance int64) error
 	UpdateStatus(id int, status string) error
 	Delete(id int) error
 	AccountExists(accountNumber string) (bool, error)
+	// LockByIDs loads and row-locks (SELECT ... FOR UPDATE) the given accounts.
+	// Locks are always taken in ascending id order so that concurrent units of
+	// work touching the same accounts cannot deadlock. Must be called on a
+	// repository bound to a transaction via WithTx.
+	LockByIDs(ids ...int) (map[int]*models.Account, error)
+	// AdjustHold adds delta to the account's held amount and removes it from
+	// the available balance. Callers must hold the account's row lock.
+	AdjustHold(id int, delta int64) error
+	// SetOverdraft sets the account's authorized overdraft and debit interest
+	// rate. Callers must hold the account's row lock.
+	SetOverdraft(id int, limit int64, rateBps int) error
+	// WithTx returns a repository whose queries run inside tx
+	WithTx(tx *sql.Tx) AccountRepository
 }
 
 type PostgresAccountRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewPostgresAccountRepository(db *sql.DB) AccountRepository {
 	return &PostgresAccountRepository{db: db}
 }
 
+const accountColumns = `
+	id, customer_id, account_number, iban, bic, account_type, currency,
+	balance, available_balance, hold_amount, overdraft_limit, overdraft_rate_bps,
+	status, created_at, updated_at`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAccount(row rowScanner) (*models.Account, error) {
+	account := &models.Account{}
+
+	err := row.Scan(
+		&account.ID, &account.CustomerID, &account.AccountNumber, &account.IBAN,
+		&account.BIC, &account.AccountType, &account.Currency, &account.Balance,
+		&account.AvailableBalance, &account.HoldAmount, &account.OverdraftLimit,
+		&account.OverdraftRateBps, &account.Status,
+		&account.CreatedAt, &account.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return account, nil
+}
+
+func scanAccounts(rows *sql.Rows) ([]*models.Account, error) {
+	defer rows.Close()
+
+	var accounts []*models.Account
+	for rows.Next() {
+		account, err := scanAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, rows.Err()
+}
+
+func (r *PostgresAccountRepository) WithTx(tx *sql.Tx) AccountRepository {
+	return &PostgresAccountRepository{db: tx}
+}
+
 func (r *PostgresAccountRepository) Create(account *models.Account) error {
 	query := `
 		INSERT INTO accounts (
 			customer_id, account_number, iban, bic, account_type, currency,
-			balance, available_balance, hold_amount, first_name, last_name,
-			email, phone, date_of_birth, street, city, postal_code, country,
-			state, hash_password, status, created_at, updated_at
+			balance, available_balance, hold_amount, status, created_at, updated_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14,
-			$15, $16, $17, $18, $19, $20, $21, $22, $23
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
 		) RETURNING id`
 	
 	err := r.db.QueryRow(
 		query,
 		account.CustomerID, account.AccountNumber, account.IBAN, account.BIC,
 		account.AccountType, account.Currency, account.Balance, account.AvailableBalance,
-		account.HoldAmount, account.FirstName, account.LastName, account.Email,
-		account.Phone, account.DateOfBirth, account.Address.Street, account.Address.City,
-		account.Address.PostalCode, account.Address.Country, account.Address.State,
-		account.HashPassword, account.Status, account.CreatedAt, account.UpdatedAt,
+		account.HoldAmount, account.Status, account.CreatedAt, account.UpdatedAt,
 	).Scan(&account.ID)
 	
 	return err
 }
 
 func (r *PostgresAccountRepository) GetByID(id int) (*models.Account, error) {
-	query := `
-		SELECT id, customer_id, account_number, iban, bic, account_type, currency,
-			   balance, available_balance, hold_amount, first_name, last_name,
-			   email, phone, date_of_birth, street, city, postal_code, country,
-			   state, hash_password, status, created_at, updated_at, last_login_at
-		FROM accounts WHERE id = $1`
-	
-	account := &models.Account{}
-	var lastLoginAt sql.NullTime
-	
-	err := r.db.QueryRow(query, id).Scan(
-		&account.ID, &account.CustomerID, &account.AccountNumber, &account.IBAN,
-		&account.BIC, &account.AccountType, &account.Currency, &account.Balance,
-		&account.AvailableBalance, &account.HoldAmount, &account.FirstName,
-		&account.LastName, &account.Email, &account.Phone, &account.DateOfBirth,
-		&account.Address.Street, &account.Address.City, &account.Address.PostalCode,
-		&account.Address.Country, &account.Address.State, &account.HashPassword,
-		&account.Status, &account.CreatedAt, &account.UpdatedAt, &lastLoginAt,
-	)
+	query := `SELECT ` + accountColumns + ` FROM accounts WHERE id = $1`
 	
+	account, err := scanAccount(r.db.QueryRow(query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("account with id %d not found", id)
@@ -83,34 +118,13 @@ func (r *PostgresAccountRepository) GetByID(id int) (*models.Account, error) {
 		return nil, err
 	}
 	
-	if lastLoginAt.Valid {
-		account.LastLoginAt = &lastLoginAt.Time
-	}
-	
 	return account, nil
 }
 
 func (r *PostgresAccountRepository) GetByAccountNumber(accountNumber string) (*models.Account, error) {
-	query := `
-		SELECT id, customer_id, account_number, iban, bic, account_type, currency,
-			   balance, available_balance, hold_amount, first_name, last_name,
-			   email, phone, date_of_birth, street, city, postal_code, country,
-			   state, hash_password, status, created_at, updated_at, last_login_at
-		FROM accounts WHERE account_number = $1`
-	
-	account := &models.Account{}
-	var lastLoginAt sql.NullTime
-	
-	err := r.db.QueryRow(query, accountNumber).Scan(
-		&account.ID, &account.CustomerID, &account.AccountNumber, &account.IBAN,
-		&account.BIC, &account.AccountType, &account.Currency, &account.Balance,
-		&account.AvailableBalance, &account.HoldAmount, &account.FirstName,
-		&account.LastName, &account.Email, &account.Phone, &account.DateOfBirth,
-		&account.Address.Street, &account.Address.City, &account.Address.PostalCode,
-		&account.Address.Country, &account.Address.State, &account.HashPassword,
-		&account.Status, &account.CreatedAt, &account.UpdatedAt, &lastLoginAt,
-	)
+	query := `SELECT ` + accountColumns + ` FROM accounts WHERE account_number = $1`
 	
+	account, err := scanAccount(r.db.QueryRow(query, accountNumber))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("account with number %s not found", accountNumber)
@@ -118,127 +132,118 @@ func (r *PostgresAccountRepository) GetByAccountNumber(accountNumber string) (*m
 		return nil, err
 	}
 	
-	if lastLoginAt.Valid {
-		account.LastLoginAt = &lastLoginAt.Time
+	return account, nil
+}
+
+func (r *PostgresAccountRepository) GetByIBAN(iban string) (*models.Account, error) {
+	query := `SELECT ` + accountColumns + ` FROM accounts WHERE iban = $1`
+	
+	account, err := scanAccount(r.db.QueryRow(query, iban))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("account with IBAN %s not found", iban)
+		}
+		return nil, err
 	}
 	
 	return account, nil
 }
 
 func (r *PostgresAccountRepository) GetByCustomerID(customerID string) ([]*models.Account, error) {
-	query := `
-		SELECT id, customer_id, account_number, iban, bic, account_type, currency,
-			   balance, available_balance, hold_amount, first_name, last_name,
-			   email, phone, date_of_birth, street, city, postal_code, country,
-			   state, hash_password, status, created_at, updated_at, last_login_at
-		FROM accounts WHERE customer_id = $1 ORDER BY created_at DESC`
+	query := `SELECT ` + accountColumns + ` FROM accounts WHERE customer_id = $1 ORDER BY created_at DESC`
 	
 	rows, err := r.db.Query(query, customerID)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-	
-	var accounts []*models.Account
-	for rows.Next() {
-		account := &models.Account{}
-		var lastLoginAt sql.NullTime
-		
-		err := rows.Scan(
-			&account.ID, &account.CustomerID, &account.AccountNumber, &account.IBAN,
-			&account.BIC, &account.AccountType, &account.Currency, &account.Balance,
-			&account.AvailableBalance, &account.HoldAmount, &account.FirstName,
-			&account.LastName, &account.Email, &account.Phone, &account.DateOfBirth,
-			&account.Address.Street, &account.Address.City, &account.Address.PostalCode,
-			&account.Address.Country, &account.Address.State, &account.HashPassword,
-			&account.Status, &account.CreatedAt, &account.UpdatedAt, &lastLoginAt,
-		)
-		if err != nil {
-			return nil, err
-		}
-		
-		if lastLoginAt.Valid {
-			account.LastLoginAt = &lastLoginAt.Time
-		}
-		
-		accounts = append(accounts, account)
-	}
 	
-	return accounts, nil
+	return scanAccounts(rows)
 }
 
 func (r *PostgresAccountRepository) GetAll(limit, offset int) ([]*models.Account, error) {
-	query := `
-		SELECT id, customer_id, account_number, iban, bic, account_type, currency,
-			   balance, available_balance, hold_amount, first_name, last_name,
-			   email, phone, date_of_birth, street, city, postal_code, country,
-			   state, hash_password, status, created_at, updated_at, last_login_at
-		FROM accounts ORDER BY created_at DESC LIMIT $1 OFFSET $2`
+	query := `SELECT ` + accountColumns + ` FROM accounts ORDER BY created_at DESC LIMIT $1 OFFSET $2`
 	
 	rows, err := r.db.Query(query, limit, offset)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 	
-	var accounts []*models.Account
-	for rows.Next() {
-		account := &models.Account{}
-		var lastLoginAt sql.NullTime
+	return scanAccounts(rows)
+}
+
+func (r *PostgresAccountRepository) LockByIDs(ids ...int) (map[int]*models.Account, error) {
+	sorted := make([]int, len(ids))
+	copy(sorted, ids)
+	sort.Ints(sorted)
+	
+	query := `SELECT ` + accountColumns + ` FROM accounts WHERE id = $1 FOR UPDATE`
+	
+	accounts := make(map[int]*models.Account, len(sorted))
+	for _, id := range sorted {
+		if _, locked := accounts[id]; locked {
+			continue
+		}
 		
-		err := rows.Scan(
-			&account.ID, &account.CustomerID, &account.AccountNumber, &account.IBAN,
-			&account.BIC, &account.AccountType, &account.Currency, &account.Balance,
-			&account.AvailableBalance, &account.HoldAmount, &account.FirstName,
-			&account.LastName, &account.Email, &account.Phone, &account.DateOfBirth,
-			&account.Address.Street, &account.Address.City, &account.Address.PostalCode,
-			&account.Address.Country, &account.Address.State, &account.HashPassword,
-			&account.Status, &account.CreatedAt, &account.UpdatedAt, &lastLoginAt,
-		)
+		account, err := scanAccount(r.db.QueryRow(query, id))
 		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, fmt.Errorf("account with id %d not found", id)
+			}
 			return nil, err
 		}
-		
-		if lastLoginAt.Valid {
-			account.LastLoginAt = &lastLoginAt.Time
-		}
-		
-		accounts = append(accounts, account)
+		accounts[id] = account
 	}
 	
 	return accounts, nil
 }
 
-func (r *PostgresAccountRepository) Update(id int, account *models.Account) error {
+func (r *PostgresAccountRepository) UpdateStatus(id int, status string) error {
+	query := `UPDATE accounts SET status = $1, updated_at = $2 WHERE id = $3`
+	_, err := r.db.Exec(query, status, time.Now().UTC(), id)
+	return err
+}
+
+func (r *PostgresAccountRepository) AdjustHold(id int, delta int64) error {
 	query := `
-		UPDATE accounts SET
-			first_name = $1, last_name = $2, email = $3, phone = $4,
-			street = $5, city = $6, postal_code = $7, country = $8, state = $9,
-			updated_at = $10
-		WHERE id = $11`
+		UPDATE accounts
+		SET hold_amount = hold_amount + $1, available_balance = available_balance - $1, updated_at = $2
+		WHERE id = $3`
 	
-	account.UpdatedAt = time.Now().UTC()
+	result, err := r.db.Exec(query, delta, time.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("failed to adjust hold amount: %w", err)
+	}
 	
-	_, err := r.db.Exec(
-		query,
-		account.FirstName, account.LastName, account.Email, account.Phone,
-		account.Address.Street, account.Address.City, account.Address.PostalCode,
-		account.Address.Country, account.Address.State, account.UpdatedAt, id,
-	)
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
 	
-	return err
-}
-
-func (r *PostgresAccountRepository) UpdateBalance(accountNumber string, balance int64) error {
-	query := `UPDATE accounts SET balance = $1, available_balance = $1, updated_at = $2 WHERE account_number = $3`
-	_, err := r.db.Exec(query, balance, time.Now().UTC(), accountNumber)
-	return err
+	if rowsAffected == 0 {
+		return fmt.Errorf("account with id %d not found", id)
+	}
+	
+	return nil
 }
 
-func (r *PostgresAccountRepository) UpdateStatus(id int, status string) error {
-	query := `UPDATE accounts SET status = $1, updated_at = $2 WHERE id = $3`
-	_, err := r.db.Exec(query, status, time.Now().UTC(), id)
-	return err
+func (r *PostgresAccountRepository) SetOverdraft(id int, limit int64, rateBps int) error {
+	query := `UPDATE accounts SET overdraft_limit = $1, overdraft_rate_bps = $2, updated_at = $3 WHERE id = $4`
+	
+	result, err := r.db.Exec(query, limit, rateBps, time.Now().UTC(), id)
+	if err != nil {
+		return fmt.Errorf("failed to set overdraft: %w", err)
+	}
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	
+	if rowsAffected == 0 {
+		return fmt.Errorf("account with id %d not found", id)
+	}
+	
+	return nil
 }
 
 func (r *PostgresAccountRepository) Delete(id int) error {
@@ -0,0 +1,191 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bank-api/internal/models"
+)
+
+type APIClientRepository interface {
+	// Create stores a client together with its allowed accounts
+	Create(client *models.APIClient) error
+	GetByClientID(clientID string) (*models.APIClient, error)
+	GetAll(limit, offset int) ([]*models.APIClient, error)
+	// Update saves a client's name, scopes and allowed IPs and replaces its
+	// allowed accounts
+	Update(client *models.APIClient) error
+	UpdateSecret(clientID, secretHash string) error
+	UpdateStatus(clientID, status string) error
+	UpdateLastUsed(clientID string, at time.Time) error
+	// WithTx returns a repository whose queries run inside tx
+	WithTx(tx *sql.Tx) APIClientRepository
+}
+
+type PostgresAPIClientRepository struct {
+	db DBTX
+}
+
+func NewPostgresAPIClientRepository(db *sql.DB) APIClientRepository {
+	return &PostgresAPIClientRepository{db: db}
+}
+
+func (r *PostgresAPIClientRepository) WithTx(tx *sql.Tx) APIClientRepository {
+	return &PostgresAPIClientRepository{db: tx}
+}
+
+const apiClientColumns = `
+	c.id, c.client_id, c.name, c.secret_hash, c.scopes, c.allowed_ips, c.status,
+	c.created_by, c.created_at, c.updated_at, c.last_used_at,
+	(SELECT COALESCE(string_agg(a.account_number, ' ' ORDER BY a.account_number), '')
+		FROM api_client_accounts a WHERE a.client_id = c.client_id)`
+
+func scanAPIClient(row rowScanner) (*models.APIClient, error) {
+	client := &models.APIClient{}
+	var scopes, allowedIPs, accountNumbers string
+	var createdBy sql.NullString
+	var lastUsedAt sql.NullTime
+
+	err := row.Scan(
+		&client.ID, &client.ClientID, &client.Name, &client.SecretHash, &scopes, &allowedIPs, &client.Status,
+		&createdBy, &client.CreatedAt, &client.UpdatedAt, &lastUsedAt, &accountNumbers,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	client.Scopes = strings.Fields(scopes)
+	client.AllowedIPs = strings.Fields(allowedIPs)
+	client.AccountNumbers = strings.Fields(accountNumbers)
+	client.CreatedBy = createdBy.String
+	if lastUsedAt.Valid {
+		client.LastUsedAt = &lastUsedAt.Time
+	}
+
+	return client, nil
+}
+
+func (r *PostgresAPIClientRepository) Create(client *models.APIClient) error {
+	query := `
+		INSERT INTO api_clients (
+			client_id, name, secret_hash, scopes, allowed_ips, status, created_by, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8, $9
+		) RETURNING id`
+
+	err := r.db.QueryRow(
+		query,
+		client.ClientID, client.Name, client.SecretHash, strings.Join(client.Scopes, " "),
+		strings.Join(client.AllowedIPs, " "), client.Status, client.CreatedBy, client.CreatedAt, client.UpdatedAt,
+	).Scan(&client.ID)
+	if err != nil {
+		return err
+	}
+
+	return r.addAccounts(client.ClientID, client.AccountNumbers)
+}
+
+func (r *PostgresAPIClientRepository) addAccounts(clientID string, accountNumbers []string) error {
+	for _, accountNumber := range accountNumbers {
+		query := `
+			INSERT INTO api_client_accounts (client_id, account_number) VALUES ($1, $2)
+			ON CONFLICT DO NOTHING`
+
+		if _, err := r.db.Exec(query, clientID, accountNumber); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *PostgresAPIClientRepository) GetByClientID(clientID string) (*models.APIClient, error) {
+	query := `SELECT ` + apiClientColumns + ` FROM api_clients c WHERE c.client_id = $1`
+
+	client, err := scanAPIClient(r.db.QueryRow(query, clientID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("API client %s not found", clientID)
+		}
+		return nil, err
+	}
+
+	return client, nil
+}
+
+func (r *PostgresAPIClientRepository) GetAll(limit, offset int) ([]*models.APIClient, error) {
+	query := `SELECT ` + apiClientColumns + ` FROM api_clients c ORDER BY c.created_at DESC LIMIT $1 OFFSET $2`
+
+	rows, err := r.db.Query(query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var clients []*models.APIClient
+	for rows.Next() {
+		client, err := scanAPIClient(rows)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+
+	return clients, rows.Err()
+}
+
+func (r *PostgresAPIClientRepository) Update(client *models.APIClient) error {
+	query := `
+		UPDATE api_clients SET name = $1, scopes = $2, allowed_ips = $3, updated_at = $4
+		WHERE client_id = $5`
+
+	result, err := r.db.Exec(
+		query,
+		client.Name, strings.Join(client.Scopes, " "), strings.Join(client.AllowedIPs, " "), client.UpdatedAt, client.ClientID,
+	)
+	if err := expectOneClient(result, err, client.ClientID); err != nil {
+		return err
+	}
+
+	if _, err := r.db.Exec(`DELETE FROM api_client_accounts WHERE client_id = $1`, client.ClientID); err != nil {
+		return err
+	}
+	return r.addAccounts(client.ClientID, client.AccountNumbers)
+}
+
+func (r *PostgresAPIClientRepository) UpdateSecret(clientID, secretHash string) error {
+	query := `UPDATE api_clients SET secret_hash = $1, updated_at = $2 WHERE client_id = $3`
+
+	result, err := r.db.Exec(query, secretHash, time.Now().UTC(), clientID)
+	return expectOneClient(result, err, clientID)
+}
+
+func (r *PostgresAPIClientRepository) UpdateStatus(clientID, status string) error {
+	query := `UPDATE api_clients SET status = $1, updated_at = $2 WHERE client_id = $3`
+
+	result, err := r.db.Exec(query, status, time.Now().UTC(), clientID)
+	return expectOneClient(result, err, clientID)
+}
+
+func (r *PostgresAPIClientRepository) UpdateLastUsed(clientID string, at time.Time) error {
+	_, err := r.db.Exec(`UPDATE api_clients SET last_used_at = $1 WHERE client_id = $2`, at, clientID)
+	return err
+}
+
+// expectOneClient checks that an update hit the client
+func expectOneClient(result sql.Result, err error, clientID string) error {
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("API client %s not found", clientID)
+	}
+
+	return nil
+}
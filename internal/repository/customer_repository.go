@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bank-api/internal/models"
+)
+
+type CustomerRepository interface {
+	Create(customer *models.Customer) error
+	GetByCustomerID(customerID string) (*models.Customer, error)
+	GetByEmail(email string) (*models.Customer, error)
+	EmailExists(email string) (bool, error)
+	Update(customer *models.Customer) error
+	// RecordLoginSuccess sets the customer's last login and clears their
+	// failed logins and lockout
+	RecordLoginSuccess(customerID string, at time.Time) error
+	// RecordLoginFailure counts a failed login and returns how many there
+	// have been in a row; failures before forgetBefore no longer count
+	RecordLoginFailure(customerID string, at, forgetBefore time.Time) (int, error)
+	LockLogin(customerID string, until time.Time) error
+	// UnlockLogin lifts the customer's lockout and forgets their failed logins
+	UnlockLogin(customerID string) error
+	// UpdatePassword replaces the customer's password hash, which also lifts
+	// their login lockout
+	UpdatePassword(customerID, hashPassword string, changedAt time.Time) error
+	// WithTx returns a repository whose queries run inside tx
+	WithTx(tx *sql.Tx) CustomerRepository
+}
+
+type PostgresCustomerRepository struct {
+	db DBTX
+}
+
+func NewPostgresCustomerRepository(db *sql.DB) CustomerRepository {
+	return &PostgresCustomerRepository{db: db}
+}
+
+const customerColumns = `
+	id, customer_id, first_name, last_name, email, phone, date_of_birth,
+	street, city, postal_code, country, state, hash_password, status,
+	created_at, updated_at, last_login_at,
+	failed_login_attempts, last_failed_login_at, locked_until, password_changed_at`
+
+func scanCustomer(row rowScanner) (*models.Customer, error) {
+	customer := &models.Customer{}
+	var street, city, postalCode, country, state sql.NullString
+	var lastLoginAt, lastFailedLoginAt, lockedUntil, passwordChangedAt sql.NullTime
+
+	err := row.Scan(
+		&customer.ID, &customer.CustomerID, &customer.FirstName, &customer.LastName,
+		&customer.Email, &customer.Phone, &customer.DateOfBirth,
+		&street, &city, &postalCode, &country, &state,
+		&customer.HashPassword, &customer.Status, &customer.CreatedAt, &customer.UpdatedAt, &lastLoginAt,
+		&customer.FailedLoginAttempts, &lastFailedLoginAt, &lockedUntil, &passwordChangedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	customer.Address = models.Address{
+		Street:     street.String,
+		City:       city.String,
+		PostalCode: postalCode.String,
+		Country:    country.String,
+		State:      state.String,
+	}
+	if lastLoginAt.Valid {
+		customer.LastLoginAt = &lastLoginAt.Time
+	}
+	if lastFailedLoginAt.Valid {
+		customer.LastFailedLoginAt = &lastFailedLoginAt.Time
+	}
+	if lockedUntil.Valid {
+		customer.LockedUntil = &lockedUntil.Time
+	}
+	if passwordChangedAt.Valid {
+		customer.PasswordChangedAt = &passwordChangedAt.Time
+	}
+
+	return customer, nil
+}
+
+func (r *PostgresCustomerRepository) WithTx(tx *sql.Tx) CustomerRepository {
+	return &PostgresCustomerRepository{db: tx}
+}
+
+func (r *PostgresCustomerRepository) Create(customer *models.Customer) error {
+	query := `
+		INSERT INTO customers (
+			customer_id, first_name, last_name, email, phone, date_of_birth,
+			street, city, postal_code, country, state, hash_password, status,
+			created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15
+		) RETURNING id`
+
+	return r.db.QueryRow(
+		query,
+		customer.CustomerID, customer.FirstName, customer.LastName, customer.Email,
+		customer.Phone, customer.DateOfBirth, customer.Address.Street, customer.Address.City,
+		customer.Address.PostalCode, customer.Address.Country, customer.Address.State,
+		customer.HashPassword, customer.Status, customer.CreatedAt, customer.UpdatedAt,
+	).Scan(&customer.ID)
+}
+
+func (r *PostgresCustomerRepository) GetByCustomerID(customerID string) (*models.Customer, error) {
+	query := `SELECT ` + customerColumns + ` FROM customers WHERE customer_id = $1`
+
+	customer, err := scanCustomer(r.db.QueryRow(query, customerID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("customer %s not found", customerID)
+		}
+		return nil, err
+	}
+
+	return customer, nil
+}
+
+func (r *PostgresCustomerRepository) GetByEmail(email string) (*models.Customer, error) {
+	query := `SELECT ` + customerColumns + ` FROM customers WHERE email = $1`
+
+	customer, err := scanCustomer(r.db.QueryRow(query, email))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("customer with email %s not found", email)
+		}
+		return nil, err
+	}
+
+	return customer, nil
+}
+
+func (r *PostgresCustomerRepository) EmailExists(email string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM customers WHERE email = $1)`, email).Scan(&exists)
+	return exists, err
+}
+
+func (r *PostgresCustomerRepository) Update(customer *models.Customer) error {
+	query := `
+		UPDATE customers SET
+			first_name = $1, last_name = $2, email = $3, phone = $4,
+			street = $5, city = $6, postal_code = $7, country = $8, state = $9,
+			updated_at = $10
+		WHERE customer_id = $11`
+
+	customer.UpdatedAt = time.Now().UTC()
+
+	result, err := r.db.Exec(
+		query,
+		customer.FirstName, customer.LastName, customer.Email, customer.Phone,
+		customer.Address.Street, customer.Address.City, customer.Address.PostalCode,
+		customer.Address.Country, customer.Address.State, customer.UpdatedAt, customer.CustomerID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("customer %s not found", customer.CustomerID)
+	}
+
+	return nil
+}
+
+func (r *PostgresCustomerRepository) RecordLoginSuccess(customerID string, at time.Time) error {
+	query := `
+		UPDATE customers SET last_login_at = $1, failed_login_attempts = 0, locked_until = NULL
+		WHERE customer_id = $2`
+
+	_, err := r.db.Exec(query, at, customerID)
+	return err
+}
+
+func (r *PostgresCustomerRepository) RecordLoginFailure(customerID string, at, forgetBefore time.Time) (int, error) {
+	query := `
+		UPDATE customers SET
+			failed_login_attempts = CASE
+				WHEN last_failed_login_at IS NULL OR last_failed_login_at < $2 THEN 1
+				ELSE failed_login_attempts + 1
+			END,
+			last_failed_login_at = $1
+		WHERE customer_id = $3
+		RETURNING failed_login_attempts`
+
+	var failures int
+	err := r.db.QueryRow(query, at, forgetBefore, customerID).Scan(&failures)
+	return failures, err
+}
+
+func (r *PostgresCustomerRepository) LockLogin(customerID string, until time.Time) error {
+	_, err := r.db.Exec(`UPDATE customers SET locked_until = $1 WHERE customer_id = $2`, until, customerID)
+	return err
+}
+
+func (r *PostgresCustomerRepository) UnlockLogin(customerID string) error {
+	query := `UPDATE customers SET failed_login_attempts = 0, locked_until = NULL WHERE customer_id = $1`
+
+	result, err := r.db.Exec(query, customerID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("customer %s not found", customerID)
+	}
+
+	return nil
+}
+
+func (r *PostgresCustomerRepository) UpdatePassword(customerID, hashPassword string, changedAt time.Time) error {
+	query := `
+		UPDATE customers SET
+			hash_password = $1, password_changed_at = $2, updated_at = $2,
+			failed_login_attempts = 0, locked_until = NULL
+		WHERE customer_id = $3`
+
+	result, err := r.db.Exec(query, hashPassword, changedAt, customerID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("customer %s not found", customerID)
+	}
+
+	return nil
+}
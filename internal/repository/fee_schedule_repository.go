@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bank-api/internal/models"
+)
+
+type FeeScheduleRepository interface {
+	Create(schedule *models.FeeSchedule) error
+	GetByID(id int) (*models.FeeSchedule, error)
+	GetAll() ([]*models.FeeSchedule, error)
+	// GetActive returns the active schedules of a transaction type, oldest
+	// first
+	GetActive(transactionType string) ([]*models.FeeSchedule, error)
+	// Update saves a schedule's key, pricing rules and active flag
+	Update(schedule *models.FeeSchedule) error
+}
+
+type PostgresFeeScheduleRepository struct {
+	db DBTX
+}
+
+func NewPostgresFeeScheduleRepository(db *sql.DB) FeeScheduleRepository {
+	return &PostgresFeeScheduleRepository{db: db}
+}
+
+const feeScheduleColumns = `
+	id, account_type, transaction_type, channel, currency, flat_fee, percentage_bps,
+	tiers, min_fee, max_fee, free_per_month, active, created_at, updated_at`
+
+func scanFeeSchedule(row rowScanner) (*models.FeeSchedule, error) {
+	schedule := &models.FeeSchedule{}
+	var accountType, channel, currency, tiers sql.NullString
+	var maxFee sql.NullInt64
+
+	err := row.Scan(
+		&schedule.ID, &accountType, &schedule.TransactionType, &channel, &currency,
+		&schedule.FlatFee, &schedule.PercentageBps, &tiers, &schedule.MinFee, &maxFee,
+		&schedule.FreePerMonth, &schedule.Active, &schedule.CreatedAt, &schedule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule.AccountType = accountType.String
+	schedule.Channel = channel.String
+	schedule.Currency = currency.String
+	schedule.MaxFee = maxFee.Int64
+	if tiers.Valid {
+		if err := json.Unmarshal([]byte(tiers.String), &schedule.Tiers); err != nil {
+			return nil, fmt.Errorf("invalid tiers of fee schedule %d: %w", schedule.ID, err)
+		}
+	}
+
+	return schedule, nil
+}
+
+func scanFeeSchedules(rows *sql.Rows) ([]*models.FeeSchedule, error) {
+	defer rows.Close()
+
+	var schedules []*models.FeeSchedule
+	for rows.Next() {
+		schedule, err := scanFeeSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, schedule)
+	}
+
+	return schedules, rows.Err()
+}
+
+// encodeTiers renders tiers as JSON, or an empty string for none
+func encodeTiers(tiers []models.FeeTier) (string, error) {
+	if len(tiers) == 0 {
+		return "", nil
+	}
+	encoded, err := json.Marshal(tiers)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
+
+func (r *PostgresFeeScheduleRepository) Create(schedule *models.FeeSchedule) error {
+	tiers, err := encodeTiers(schedule.Tiers)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO fee_schedules (
+			account_type, transaction_type, channel, currency, flat_fee, percentage_bps,
+			tiers, min_fee, max_fee, free_per_month, active, created_at, updated_at
+		) VALUES (
+			NULLIF($1, ''), $2, NULLIF($3, ''), NULLIF($4, ''), $5, $6,
+			NULLIF($7, '')::jsonb, $8, NULLIF($9, 0), $10, $11, $12, $13
+		) RETURNING id`
+
+	return r.db.QueryRow(
+		query,
+		schedule.AccountType, schedule.TransactionType, schedule.Channel, schedule.Currency,
+		schedule.FlatFee, schedule.PercentageBps, tiers, schedule.MinFee, schedule.MaxFee,
+		schedule.FreePerMonth, schedule.Active, schedule.CreatedAt, schedule.UpdatedAt,
+	).Scan(&schedule.ID)
+}
+
+func (r *PostgresFeeScheduleRepository) GetByID(id int) (*models.FeeSchedule, error) {
+	query := `SELECT ` + feeScheduleColumns + ` FROM fee_schedules WHERE id = $1`
+
+	schedule, err := scanFeeSchedule(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("fee schedule %d not found", id)
+		}
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+func (r *PostgresFeeScheduleRepository) GetAll() ([]*models.FeeSchedule, error) {
+	query := `SELECT ` + feeScheduleColumns + ` FROM fee_schedules ORDER BY transaction_type, id`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanFeeSchedules(rows)
+}
+
+func (r *PostgresFeeScheduleRepository) GetActive(transactionType string) ([]*models.FeeSchedule, error) {
+	query := `
+		SELECT ` + feeScheduleColumns + ` FROM fee_schedules
+		WHERE transaction_type = $1 AND active
+		ORDER BY id`
+
+	rows, err := r.db.Query(query, transactionType)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanFeeSchedules(rows)
+}
+
+func (r *PostgresFeeScheduleRepository) Update(schedule *models.FeeSchedule) error {
+	tiers, err := encodeTiers(schedule.Tiers)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE fee_schedules
+		SET account_type = NULLIF($1, ''), transaction_type = $2, channel = NULLIF($3, ''),
+			currency = NULLIF($4, ''), flat_fee = $5, percentage_bps = $6, tiers = NULLIF($7, '')::jsonb,
+			min_fee = $8, max_fee = NULLIF($9, 0), free_per_month = $10, active = $11, updated_at = $12
+		WHERE id = $13`
+
+	schedule.UpdatedAt = time.Now().UTC()
+	result, err := r.db.Exec(
+		query,
+		schedule.AccountType, schedule.TransactionType, schedule.Channel, schedule.Currency,
+		schedule.FlatFee, schedule.PercentageBps, tiers, schedule.MinFee, schedule.MaxFee,
+		schedule.FreePerMonth, schedule.Active, schedule.UpdatedAt, schedule.ID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("fee schedule %d not found", schedule.ID)
+	}
+
+	return nil
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bank-api/internal/models"
+)
+
+type FXQuoteRepository interface {
+	Create(quote *models.FXQuote) error
+	GetByQuoteID(quoteID string) (*models.FXQuote, error)
+	// GetByQuoteIDForUpdate loads and row-locks a quote. Must be called on a
+	// repository bound to a transaction via WithTx.
+	GetByQuoteIDForUpdate(quoteID string) (*models.FXQuote, error)
+	MarkUsed(quoteID, transactionID string) error
+	// WithTx returns a repository whose queries run inside tx
+	WithTx(tx *sql.Tx) FXQuoteRepository
+}
+
+type PostgresFXQuoteRepository struct {
+	db DBTX
+}
+
+func NewPostgresFXQuoteRepository(db *sql.DB) FXQuoteRepository {
+	return &PostgresFXQuoteRepository{db: db}
+}
+
+func (r *PostgresFXQuoteRepository) WithTx(tx *sql.Tx) FXQuoteRepository {
+	return &PostgresFXQuoteRepository{db: tx}
+}
+
+func (r *PostgresFXQuoteRepository) Create(quote *models.FXQuote) error {
+	query := `
+		INSERT INTO fx_quotes (
+			quote_id, account_number, from_currency, to_currency, mid_rate, rate,
+			spread_bps, amount, converted_amount, source, created_at, expires_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+		) RETURNING id`
+
+	return r.db.QueryRow(
+		query,
+		quote.QuoteID, quote.AccountNumber, quote.FromCurrency, quote.ToCurrency,
+		quote.MidRate, quote.Rate, quote.SpreadBps, quote.Amount, quote.ConvertedAmount,
+		quote.Source, quote.CreatedAt, quote.ExpiresAt,
+	).Scan(&quote.ID)
+}
+
+func (r *PostgresFXQuoteRepository) GetByQuoteID(quoteID string) (*models.FXQuote, error) {
+	return r.getByQuoteID(quoteID, false)
+}
+
+func (r *PostgresFXQuoteRepository) GetByQuoteIDForUpdate(quoteID string) (*models.FXQuote, error) {
+	return r.getByQuoteID(quoteID, true)
+}
+
+func (r *PostgresFXQuoteRepository) getByQuoteID(quoteID string, forUpdate bool) (*models.FXQuote, error) {
+	query := `
+		SELECT id, quote_id, account_number, from_currency, to_currency, mid_rate, rate,
+			   spread_bps, amount, converted_amount, source, created_at, expires_at,
+			   used_at, transaction_id
+		FROM fx_quotes WHERE quote_id = $1`
+	if forUpdate {
+		query += ` FOR UPDATE`
+	}
+
+	quote := &models.FXQuote{}
+	var usedAt sql.NullTime
+	var transactionID sql.NullString
+
+	err := r.db.QueryRow(query, quoteID).Scan(
+		&quote.ID, &quote.QuoteID, &quote.AccountNumber, &quote.FromCurrency, &quote.ToCurrency,
+		&quote.MidRate, &quote.Rate, &quote.SpreadBps, &quote.Amount, &quote.ConvertedAmount,
+		&quote.Source, &quote.CreatedAt, &quote.ExpiresAt, &usedAt, &transactionID,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("FX quote %s not found", quoteID)
+		}
+		return nil, err
+	}
+
+	if usedAt.Valid {
+		quote.UsedAt = &usedAt.Time
+	}
+	quote.TransactionID = transactionID.String
+
+	return quote, nil
+}
+
+func (r *PostgresFXQuoteRepository) MarkUsed(quoteID, transactionID string) error {
+	query := `UPDATE fx_quotes SET used_at = $1, transaction_id = $2 WHERE quote_id = $3 AND used_at IS NULL`
+
+	result, err := r.db.Exec(query, time.Now().UTC(), transactionID, quoteID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("FX quote %s has already been used", quoteID)
+	}
+
+	return nil
+}
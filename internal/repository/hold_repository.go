@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bank-api/internal/models"
+)
+
+type HoldRepository interface {
+	Create(hold *models.Hold) error
+	GetByHoldID(holdID string) (*models.Hold, error)
+	// GetByHoldIDForUpdate loads and row-locks a hold. Must be called on a
+	// repository bound to a transaction via WithTx.
+	GetByHoldIDForUpdate(holdID string) (*models.Hold, error)
+	GetByAccountNumber(accountNumber, status string, limit, offset int) ([]*models.Hold, error)
+	// LockExpired row-locks up to limit ACTIVE holds past their expiry,
+	// skipping holds already locked by another worker. Must be called on a
+	// repository bound to a transaction via WithTx.
+	LockExpired(now time.Time, limit int) ([]*models.Hold, error)
+	// Close moves an ACTIVE hold to a final status
+	Close(holdID, status string, capturedAmount int64, transactionID string) error
+	// WithTx returns a repository whose queries run inside tx
+	WithTx(tx *sql.Tx) HoldRepository
+}
+
+type PostgresHoldRepository struct {
+	db DBTX
+}
+
+func NewPostgresHoldRepository(db *sql.DB) HoldRepository {
+	return &PostgresHoldRepository{db: db}
+}
+
+const holdColumns = `
+	id, hold_id, account_id, account_number, amount, captured_amount, currency,
+	merchant_reference, description, status, transaction_id, expires_at,
+	created_at, updated_at, closed_at`
+
+func scanHold(row rowScanner) (*models.Hold, error) {
+	hold := &models.Hold{}
+	var description, transactionID sql.NullString
+	var closedAt sql.NullTime
+
+	err := row.Scan(
+		&hold.ID, &hold.HoldID, &hold.AccountID, &hold.AccountNumber, &hold.Amount,
+		&hold.CapturedAmount, &hold.Currency, &hold.MerchantReference, &description,
+		&hold.Status, &transactionID, &hold.ExpiresAt, &hold.CreatedAt, &hold.UpdatedAt,
+		&closedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	hold.Description = description.String
+	hold.TransactionID = transactionID.String
+	if closedAt.Valid {
+		hold.ClosedAt = &closedAt.Time
+	}
+
+	return hold, nil
+}
+
+func scanHolds(rows *sql.Rows) ([]*models.Hold, error) {
+	defer rows.Close()
+
+	var holds []*models.Hold
+	for rows.Next() {
+		hold, err := scanHold(rows)
+		if err != nil {
+			return nil, err
+		}
+		holds = append(holds, hold)
+	}
+
+	return holds, rows.Err()
+}
+
+func (r *PostgresHoldRepository) WithTx(tx *sql.Tx) HoldRepository {
+	return &PostgresHoldRepository{db: tx}
+}
+
+func (r *PostgresHoldRepository) Create(hold *models.Hold) error {
+	query := `
+		INSERT INTO holds (
+			hold_id, account_id, account_number, amount, currency, merchant_reference,
+			description, status, expires_at, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+		) RETURNING id`
+
+	return r.db.QueryRow(
+		query,
+		hold.HoldID, hold.AccountID, hold.AccountNumber, hold.Amount, hold.Currency,
+		hold.MerchantReference, hold.Description, hold.Status, hold.ExpiresAt,
+		hold.CreatedAt, hold.UpdatedAt,
+	).Scan(&hold.ID)
+}
+
+func (r *PostgresHoldRepository) GetByHoldID(holdID string) (*models.Hold, error) {
+	return r.getByHoldID(holdID, false)
+}
+
+func (r *PostgresHoldRepository) GetByHoldIDForUpdate(holdID string) (*models.Hold, error) {
+	return r.getByHoldID(holdID, true)
+}
+
+func (r *PostgresHoldRepository) getByHoldID(holdID string, forUpdate bool) (*models.Hold, error) {
+	query := `SELECT ` + holdColumns + ` FROM holds WHERE hold_id = $1`
+	if forUpdate {
+		query += ` FOR UPDATE`
+	}
+
+	hold, err := scanHold(r.db.QueryRow(query, holdID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("hold %s not found", holdID)
+		}
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+func (r *PostgresHoldRepository) GetByAccountNumber(accountNumber, status string, limit, offset int) ([]*models.Hold, error) {
+	query := `
+		SELECT ` + holdColumns + ` FROM holds
+		WHERE account_number = $1 AND ($2 = '' OR status = $2)
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4`
+
+	rows, err := r.db.Query(query, accountNumber, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanHolds(rows)
+}
+
+func (r *PostgresHoldRepository) LockExpired(now time.Time, limit int) ([]*models.Hold, error) {
+	query := `
+		SELECT ` + holdColumns + ` FROM holds
+		WHERE status = $1 AND expires_at <= $2
+		ORDER BY expires_at
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED`
+
+	rows, err := r.db.Query(query, models.HoldStatusActive, now, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanHolds(rows)
+}
+
+func (r *PostgresHoldRepository) Close(holdID, status string, capturedAmount int64, transactionID string) error {
+	query := `
+		UPDATE holds
+		SET status = $1, captured_amount = $2, transaction_id = $3, closed_at = $4, updated_at = $4
+		WHERE hold_id = $5 AND status = $6`
+
+	var txID interface{}
+	if transactionID != "" {
+		txID = transactionID
+	}
+
+	result, err := r.db.Exec(query, status, capturedAmount, txID, time.Now().UTC(), holdID, models.HoldStatusActive)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("hold %s is no longer active", holdID)
+	}
+
+	return nil
+}
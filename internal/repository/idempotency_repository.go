@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bank-api/internal/models"
+)
+
+type IdempotencyRepository interface {
+	// Reserve inserts an in-progress record and reports whether it was
+	// created. It returns false when a record already exists for the key.
+	Reserve(record *models.IdempotencyRecord) (bool, error)
+	Get(scope, key string) (*models.IdempotencyRecord, error)
+	Complete(scope, key string, statusCode int, responseBody []byte) error
+	Delete(scope, key string) error
+	PurgeExpired() (int64, error)
+}
+
+type PostgresIdempotencyRepository struct {
+	db DBTX
+}
+
+func NewPostgresIdempotencyRepository(db *sql.DB) IdempotencyRepository {
+	return &PostgresIdempotencyRepository{db: db}
+}
+
+func (r *PostgresIdempotencyRepository) Reserve(record *models.IdempotencyRecord) (bool, error) {
+	query := `
+		INSERT INTO idempotency_keys (
+			scope, idempotency_key, method, path, request_hash, completed, created_at, expires_at
+		) VALUES ($1, $2, $3, $4, $5, FALSE, $6, $7)
+		ON CONFLICT (scope, idempotency_key) DO NOTHING
+		RETURNING id`
+
+	err := r.db.QueryRow(
+		query,
+		record.Scope, record.Key, record.Method, record.Path,
+		record.RequestHash, record.CreatedAt, record.ExpiresAt,
+	).Scan(&record.ID)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *PostgresIdempotencyRepository) Get(scope, key string) (*models.IdempotencyRecord, error) {
+	query := `
+		SELECT id, scope, idempotency_key, method, path, request_hash,
+			   status_code, response_body, completed, created_at, expires_at
+		FROM idempotency_keys WHERE scope = $1 AND idempotency_key = $2`
+
+	record := &models.IdempotencyRecord{}
+	var statusCode sql.NullInt32
+
+	err := r.db.QueryRow(query, scope, key).Scan(
+		&record.ID, &record.Scope, &record.Key, &record.Method, &record.Path,
+		&record.RequestHash, &statusCode, &record.ResponseBody, &record.Completed,
+		&record.CreatedAt, &record.ExpiresAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("idempotency key %s not found", key)
+		}
+		return nil, err
+	}
+
+	if statusCode.Valid {
+		record.StatusCode = int(statusCode.Int32)
+	}
+
+	return record, nil
+}
+
+func (r *PostgresIdempotencyRepository) Complete(scope, key string, statusCode int, responseBody []byte) error {
+	query := `
+		UPDATE idempotency_keys SET status_code = $1, response_body = $2, completed = TRUE
+		WHERE scope = $3 AND idempotency_key = $4`
+
+	_, err := r.db.Exec(query, statusCode, responseBody, scope, key)
+	return err
+}
+
+func (r *PostgresIdempotencyRepository) Delete(scope, key string) error {
+	query := `DELETE FROM idempotency_keys WHERE scope = $1 AND idempotency_key = $2`
+	_, err := r.db.Exec(query, scope, key)
+	return err
+}
+
+func (r *PostgresIdempotencyRepository) PurgeExpired() (int64, error) {
+	query := `DELETE FROM idempotency_keys WHERE expires_at < $1`
+	result, err := r.db.Exec(query, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
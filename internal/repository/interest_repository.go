@@ -0,0 +1,276 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bank-api/internal/models"
+)
+
+type InterestRepository interface {
+	CreateRateTable(table *models.InterestRateTable) error
+	// GetRateTables returns every rate table, by account type then effective
+	// date
+	GetRateTables() ([]*models.InterestRateTable, error)
+	// AccountsToAccrue lists up to limit accounts opened by the end of the
+	// business date, not closed, that a rate table applies to on that date and
+	// that have no accrual for it
+	AccountsToAccrue(businessDate time.Time, limit int) ([]*models.Account, error)
+	// CreateAccrual stores an accrual and reports whether it did; it does
+	// nothing if the account already has one for the business date
+	CreateAccrual(accrual *models.InterestAccrual) (bool, error)
+	// LastAccrualDate returns the latest business date accrued, or the zero
+	// time if none was
+	LastAccrualDate() (time.Time, error)
+	// AccountsToCapitalize lists the IDs of up to limit accounts with
+	// uncapitalized accruals dated before the given date
+	AccountsToCapitalize(before time.Time, limit int) ([]int, error)
+	// LockUncapitalized loads and row-locks an account's uncapitalized
+	// accruals dated before the given date, oldest first
+	LockUncapitalized(accountID int, before time.Time) ([]*models.InterestAccrual, error)
+	// CreateCapitalization stores a capitalization and links the given
+	// accruals to it
+	CreateCapitalization(capitalization *models.InterestCapitalization, accrualIDs []int64) error
+	// AccrualReport summarizes the accruals of every account, or of one, over
+	// the business dates from first to last included
+	AccrualReport(accountNumber string, first, last time.Time) ([]*models.InterestAccrualSummary, error)
+	// WithTx returns a repository whose queries run inside tx
+	WithTx(tx *sql.Tx) InterestRepository
+}
+
+type PostgresInterestRepository struct {
+	db DBTX
+}
+
+func NewPostgresInterestRepository(db *sql.DB) InterestRepository {
+	return &PostgresInterestRepository{db: db}
+}
+
+func (r *PostgresInterestRepository) WithTx(tx *sql.Tx) InterestRepository {
+	return &PostgresInterestRepository{db: tx}
+}
+
+func (r *PostgresInterestRepository) CreateRateTable(table *models.InterestRateTable) error {
+	tiers, err := json.Marshal(table.Tiers)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO interest_rate_tables (account_type, currency, effective_from, tiers, created_at)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5)
+		RETURNING id`
+
+	return r.db.QueryRow(
+		query, table.AccountType, table.Currency, table.EffectiveFrom, string(tiers), table.CreatedAt,
+	).Scan(&table.ID)
+}
+
+func (r *PostgresInterestRepository) GetRateTables() ([]*models.InterestRateTable, error) {
+	query := `
+		SELECT id, account_type, currency, effective_from, tiers, created_at
+		FROM interest_rate_tables
+		ORDER BY account_type, effective_from, id`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []*models.InterestRateTable
+	for rows.Next() {
+		table := &models.InterestRateTable{}
+		var currency sql.NullString
+		var tiers string
+		if err := rows.Scan(&table.ID, &table.AccountType, &currency, &table.EffectiveFrom, &tiers, &table.CreatedAt); err != nil {
+			return nil, err
+		}
+		table.Currency = currency.String
+		table.EffectiveFrom = table.EffectiveFrom.UTC()
+		if err := json.Unmarshal([]byte(tiers), &table.Tiers); err != nil {
+			return nil, fmt.Errorf("invalid tiers of interest rate table %d: %w", table.ID, err)
+		}
+		tables = append(tables, table)
+	}
+
+	return tables, rows.Err()
+}
+
+func (r *PostgresInterestRepository) AccountsToAccrue(businessDate time.Time, limit int) ([]*models.Account, error) {
+	query := `
+		SELECT ` + accountColumns + ` FROM accounts a
+		WHERE a.created_at < $1::date + 1 AND a.status <> 'CLOSED'
+		AND EXISTS (
+			SELECT 1 FROM interest_rate_tables t
+			WHERE t.account_type = a.account_type AND (t.currency IS NULL OR t.currency = a.currency)
+			AND t.effective_from <= $1
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM interest_accruals i
+			WHERE i.account_id = a.id AND i.business_date = $1
+		)
+		ORDER BY a.id
+		LIMIT $2`
+
+	rows, err := r.db.Query(query, businessDate, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanAccounts(rows)
+}
+
+func (r *PostgresInterestRepository) CreateAccrual(accrual *models.InterestAccrual) (bool, error) {
+	query := `
+		INSERT INTO interest_accruals (
+			account_id, account_number, business_date, balance, rate_table_id, amount_micros, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (account_id, business_date) DO NOTHING
+		RETURNING id`
+
+	err := r.db.QueryRow(
+		query, accrual.AccountID, accrual.AccountNumber, accrual.BusinessDate, accrual.Balance,
+		accrual.RateTableID, accrual.AmountMicros, accrual.CreatedAt,
+	).Scan(&accrual.ID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *PostgresInterestRepository) LastAccrualDate() (time.Time, error) {
+	var last sql.NullTime
+	if err := r.db.QueryRow(`SELECT MAX(business_date) FROM interest_accruals`).Scan(&last); err != nil {
+		return time.Time{}, err
+	}
+	if !last.Valid {
+		return time.Time{}, nil
+	}
+	return last.Time.UTC(), nil
+}
+
+func (r *PostgresInterestRepository) AccountsToCapitalize(before time.Time, limit int) ([]int, error) {
+	query := `
+		SELECT DISTINCT account_id FROM interest_accruals
+		WHERE capitalization_id IS NULL AND business_date < $1
+		ORDER BY account_id
+		LIMIT $2`
+
+	rows, err := r.db.Query(query, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accountIDs []int
+	for rows.Next() {
+		var accountID int
+		if err := rows.Scan(&accountID); err != nil {
+			return nil, err
+		}
+		accountIDs = append(accountIDs, accountID)
+	}
+
+	return accountIDs, rows.Err()
+}
+
+func (r *PostgresInterestRepository) LockUncapitalized(accountID int, before time.Time) ([]*models.InterestAccrual, error) {
+	query := `
+		SELECT id, account_id, account_number, business_date, balance, rate_table_id, amount_micros, created_at
+		FROM interest_accruals
+		WHERE account_id = $1 AND capitalization_id IS NULL AND business_date < $2
+		ORDER BY business_date
+		FOR UPDATE`
+
+	rows, err := r.db.Query(query, accountID, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accruals []*models.InterestAccrual
+	for rows.Next() {
+		accrual := &models.InterestAccrual{}
+		err := rows.Scan(
+			&accrual.ID, &accrual.AccountID, &accrual.AccountNumber, &accrual.BusinessDate,
+			&accrual.Balance, &accrual.RateTableID, &accrual.AmountMicros, &accrual.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		accrual.BusinessDate = accrual.BusinessDate.UTC()
+		accruals = append(accruals, accrual)
+	}
+
+	return accruals, rows.Err()
+}
+
+func (r *PostgresInterestRepository) CreateCapitalization(capitalization *models.InterestCapitalization, accrualIDs []int64) error {
+	query := `
+		INSERT INTO interest_capitalizations (
+			account_id, account_number, period_start, period_end, gross_amount,
+			withholding_tax, net_amount, transaction_id, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9)
+		RETURNING id`
+
+	err := r.db.QueryRow(
+		query, capitalization.AccountID, capitalization.AccountNumber, capitalization.PeriodStart,
+		capitalization.PeriodEnd, capitalization.GrossAmount, capitalization.WithholdingTax,
+		capitalization.NetAmount, capitalization.TransactionID, capitalization.CreatedAt,
+	).Scan(&capitalization.ID)
+	if err != nil {
+		return err
+	}
+
+	for _, accrualID := range accrualIDs {
+		_, err := r.db.Exec(`UPDATE interest_accruals SET capitalization_id = $1 WHERE id = $2`, capitalization.ID, accrualID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *PostgresInterestRepository) AccrualReport(accountNumber string, first, last time.Time) ([]*models.InterestAccrualSummary, error) {
+	query := `
+		SELECT i.account_number, a.account_type, a.currency, COUNT(*),
+			AVG(i.balance)::BIGINT, SUM(i.amount_micros),
+			COALESCE(SUM(i.amount_micros) FILTER (WHERE i.capitalization_id IS NOT NULL), 0)
+		FROM interest_accruals i
+		JOIN accounts a ON a.id = i.account_id
+		WHERE i.business_date BETWEEN $1 AND $2 AND ($3 = '' OR i.account_number = $3)
+		GROUP BY i.account_number, a.account_type, a.currency
+		ORDER BY i.account_number`
+
+	rows, err := r.db.Query(query, first, last, accountNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var summaries []*models.InterestAccrualSummary
+	for rows.Next() {
+		summary := &models.InterestAccrualSummary{}
+		var capitalizedMicros int64
+		err := rows.Scan(
+			&summary.AccountNumber, &summary.AccountType, &summary.Currency, &summary.Days,
+			&summary.AverageBalance, &summary.AccruedMicros, &capitalizedMicros,
+		)
+		if err != nil {
+			return nil, err
+		}
+		summary.Accrued = models.RoundInterest(summary.AccruedMicros)
+		summary.Capitalized = models.RoundInterest(capitalizedMicros)
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, rows.Err()
+}
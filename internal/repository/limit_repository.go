@@ -0,0 +1,172 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bank-api/internal/models"
+)
+
+type LimitRepository interface {
+	// GetDefaults returns the limits of every account type and currency
+	GetDefaults() ([]*models.AccountTypeLimits, error)
+	// GetDefault returns an account type's limits in a currency, none if it
+	// has no row
+	GetDefault(accountType, currency string) (*models.AccountTypeLimits, error)
+	// SaveDefault creates or replaces an account type's limits in a currency
+	SaveDefault(limits *models.AccountTypeLimits) error
+	// GetCustomerLimits returns a customer's own limits on their accounts of
+	// a type and currency, or nil if they have none
+	GetCustomerLimits(customerID, accountType, currency string) (*models.CustomerLimits, error)
+	// SaveCustomerLimits creates or replaces a customer's own limits
+	SaveCustomerLimits(limits *models.CustomerLimits) error
+	DeleteCustomerLimits(customerID, accountType, currency string) error
+}
+
+type PostgresLimitRepository struct {
+	db DBTX
+}
+
+func NewPostgresLimitRepository(db *sql.DB) LimitRepository {
+	return &PostgresLimitRepository{db: db}
+}
+
+const accountTypeLimitColumns = `account_type, currency, single_max, daily_max, monthly_max, hourly_count, updated_at`
+
+func scanAccountTypeLimits(row rowScanner) (*models.AccountTypeLimits, error) {
+	limits := &models.AccountTypeLimits{}
+	var singleMax, dailyMax, monthlyMax, hourlyCount sql.NullInt64
+
+	err := row.Scan(&limits.AccountType, &limits.Currency, &singleMax, &dailyMax, &monthlyMax, &hourlyCount, &limits.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	limits.SingleMax = singleMax.Int64
+	limits.DailyMax = dailyMax.Int64
+	limits.MonthlyMax = monthlyMax.Int64
+	limits.HourlyCount = int(hourlyCount.Int64)
+	return limits, nil
+}
+
+func (r *PostgresLimitRepository) GetDefaults() ([]*models.AccountTypeLimits, error) {
+	query := `SELECT ` + accountTypeLimitColumns + ` FROM account_type_limits ORDER BY account_type, currency`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var defaults []*models.AccountTypeLimits
+	for rows.Next() {
+		limits, err := scanAccountTypeLimits(rows)
+		if err != nil {
+			return nil, err
+		}
+		defaults = append(defaults, limits)
+	}
+
+	return defaults, rows.Err()
+}
+
+func (r *PostgresLimitRepository) GetDefault(accountType, currency string) (*models.AccountTypeLimits, error) {
+	query := `SELECT ` + accountTypeLimitColumns + ` FROM account_type_limits WHERE account_type = $1 AND currency = $2`
+
+	limits, err := scanAccountTypeLimits(r.db.QueryRow(query, accountType, currency))
+	if err == sql.ErrNoRows {
+		return &models.AccountTypeLimits{AccountType: accountType, Currency: currency}, nil
+	}
+	return limits, err
+}
+
+func (r *PostgresLimitRepository) SaveDefault(limits *models.AccountTypeLimits) error {
+	query := `
+		INSERT INTO account_type_limits (account_type, currency, single_max, daily_max, monthly_max, hourly_count, updated_at)
+		VALUES ($1, $2, NULLIF($3::BIGINT, 0), NULLIF($4::BIGINT, 0), NULLIF($5::BIGINT, 0), NULLIF($6::INTEGER, 0), $7)
+		ON CONFLICT (account_type, currency) DO UPDATE
+		SET single_max = EXCLUDED.single_max, daily_max = EXCLUDED.daily_max,
+			monthly_max = EXCLUDED.monthly_max, hourly_count = EXCLUDED.hourly_count,
+			updated_at = EXCLUDED.updated_at`
+
+	limits.UpdatedAt = time.Now().UTC()
+	_, err := r.db.Exec(
+		query, limits.AccountType, limits.Currency, limits.SingleMax, limits.DailyMax, limits.MonthlyMax,
+		limits.HourlyCount, limits.UpdatedAt,
+	)
+	return err
+}
+
+func (r *PostgresLimitRepository) GetCustomerLimits(customerID, accountType, currency string) (*models.CustomerLimits, error) {
+	query := `
+		SELECT id, customer_id, account_type, currency, single_max, daily_max, monthly_max, hourly_count, updated_by, updated_at
+		FROM customer_limits
+		WHERE customer_id = $1 AND account_type = $2 AND currency = $3`
+
+	limits := &models.CustomerLimits{}
+	var singleMax, dailyMax, monthlyMax, hourlyCount sql.NullInt64
+	err := r.db.QueryRow(query, customerID, accountType, currency).Scan(
+		&limits.ID, &limits.CustomerID, &limits.AccountType, &limits.Currency, &singleMax, &dailyMax,
+		&monthlyMax, &hourlyCount, &limits.UpdatedBy, &limits.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if singleMax.Valid {
+		limits.SingleMax = &singleMax.Int64
+	}
+	if dailyMax.Valid {
+		limits.DailyMax = &dailyMax.Int64
+	}
+	if monthlyMax.Valid {
+		limits.MonthlyMax = &monthlyMax.Int64
+	}
+	if hourlyCount.Valid {
+		count := int(hourlyCount.Int64)
+		limits.HourlyCount = &count
+	}
+	return limits, nil
+}
+
+func (r *PostgresLimitRepository) SaveCustomerLimits(limits *models.CustomerLimits) error {
+	query := `
+		INSERT INTO customer_limits (
+			customer_id, account_type, currency, single_max, daily_max, monthly_max, hourly_count, updated_by, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (customer_id, account_type, currency) DO UPDATE
+		SET single_max = EXCLUDED.single_max, daily_max = EXCLUDED.daily_max,
+			monthly_max = EXCLUDED.monthly_max, hourly_count = EXCLUDED.hourly_count,
+			updated_by = EXCLUDED.updated_by, updated_at = EXCLUDED.updated_at
+		RETURNING id`
+
+	limits.UpdatedAt = time.Now().UTC()
+	return r.db.QueryRow(
+		query, limits.CustomerID, limits.AccountType, limits.Currency, limits.SingleMax, limits.DailyMax,
+		limits.MonthlyMax, limits.HourlyCount, limits.UpdatedBy, limits.UpdatedAt,
+	).Scan(&limits.ID)
+}
+
+func (r *PostgresLimitRepository) DeleteCustomerLimits(customerID, accountType, currency string) error {
+	result, err := r.db.Exec(
+		`DELETE FROM customer_limits WHERE customer_id = $1 AND account_type = $2 AND currency = $3`,
+		customerID, accountType, currency,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("customer has no limits of their own on %s accounts in %s", accountType, currency)
+	}
+
+	return nil
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/bank-api/internal/models"
+)
+
+type LoginAttemptRepository interface {
+	Create(attempt *models.LoginAttempt) error
+	// CountIPFailures counts the failed logins from ip since a time that
+	// count towards a lockout, and returns when the oldest of them happened
+	CountIPFailures(ip string, since time.Time) (int, time.Time, error)
+	GetByCustomerID(customerID string, limit, offset int) ([]*models.LoginAttempt, error)
+	// PurgeBefore deletes login attempts older than a time
+	PurgeBefore(before time.Time) (int64, error)
+}
+
+type PostgresLoginAttemptRepository struct {
+	db DBTX
+}
+
+func NewPostgresLoginAttemptRepository(db *sql.DB) LoginAttemptRepository {
+	return &PostgresLoginAttemptRepository{db: db}
+}
+
+const loginAttemptColumns = `id, customer_id, identifier, ip_address, user_agent, success, failure_reason, created_at`
+
+func scanLoginAttempt(row rowScanner) (*models.LoginAttempt, error) {
+	attempt := &models.LoginAttempt{}
+	var customerID, ipAddress, userAgent, failureReason sql.NullString
+
+	err := row.Scan(
+		&attempt.ID, &customerID, &attempt.Identifier, &ipAddress, &userAgent,
+		&attempt.Success, &failureReason, &attempt.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	attempt.CustomerID = customerID.String
+	attempt.IPAddress = ipAddress.String
+	attempt.UserAgent = userAgent.String
+	attempt.FailureReason = failureReason.String
+
+	return attempt, nil
+}
+
+func (r *PostgresLoginAttemptRepository) Create(attempt *models.LoginAttempt) error {
+	query := `
+		INSERT INTO login_attempts (
+			customer_id, identifier, ip_address, user_agent, success, failure_reason, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`
+
+	// Unknown identifiers are recorded too, without a customer
+	var customerID, failureReason interface{}
+	if attempt.CustomerID != "" {
+		customerID = attempt.CustomerID
+	}
+	if attempt.FailureReason != "" {
+		failureReason = attempt.FailureReason
+	}
+
+	return r.db.QueryRow(
+		query,
+		customerID, attempt.Identifier, attempt.IPAddress, attempt.UserAgent,
+		attempt.Success, failureReason, attempt.CreatedAt,
+	).Scan(&attempt.ID)
+}
+
+func (r *PostgresLoginAttemptRepository) CountIPFailures(ip string, since time.Time) (int, time.Time, error) {
+	query := `
+		SELECT COUNT(*), COALESCE(MIN(created_at), $2) FROM login_attempts
+		WHERE ip_address = $1 AND NOT success AND created_at >= $2
+		AND failure_reason IN ($3, $4, $5)`
+
+	var count int
+	var oldest time.Time
+	err := r.db.QueryRow(
+		query, ip, since,
+		models.LoginFailureInvalidPassword, models.LoginFailureInvalidMFACode, models.LoginFailureUnknownCustomer,
+	).Scan(&count, &oldest)
+	return count, oldest, err
+}
+
+func (r *PostgresLoginAttemptRepository) GetByCustomerID(customerID string, limit, offset int) ([]*models.LoginAttempt, error) {
+	query := `
+		SELECT ` + loginAttemptColumns + ` FROM login_attempts
+		WHERE customer_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.Query(query, customerID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var attempts []*models.LoginAttempt
+	for rows.Next() {
+		attempt, err := scanLoginAttempt(rows)
+		if err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, attempt)
+	}
+
+	return attempts, rows.Err()
+}
+
+func (r *PostgresLoginAttemptRepository) PurgeBefore(before time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM login_attempts WHERE created_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
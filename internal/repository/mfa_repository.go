@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bank-api/internal/models"
+)
+
+type MFARepository interface {
+	// GetByCustomerID returns the customer's enrolment, or nil if they have none
+	GetByCustomerID(customerID string) (*models.CustomerMFA, error)
+	// LockByCustomerID loads and row-locks the customer's enrolment, or
+	// returns nil. Must be called on a repository bound to a transaction via
+	// WithTx.
+	LockByCustomerID(customerID string) (*models.CustomerMFA, error)
+	// Save stores a new, unconfirmed enrolment in place of the customer's
+	// current one
+	Save(mfa *models.CustomerMFA) error
+	Enable(customerID string, enabledAt time.Time) error
+	// UpdateLastUsedStep records the TOTP time step of a code just accepted
+	UpdateLastUsedStep(customerID string, step int64) error
+	// Delete removes the customer's enrolment and recovery codes
+	Delete(customerID string) error
+	// ReplaceRecoveryCodes stores the hashes of new recovery codes in place
+	// of the customer's current ones
+	ReplaceRecoveryCodes(customerID string, codeHashes []string, createdAt time.Time) error
+	// UseRecoveryCode marks an unused recovery code as used and reports
+	// whether there was one
+	UseRecoveryCode(customerID, codeHash string) (bool, error)
+	CountRecoveryCodes(customerID string) (int, error)
+	CreateChallenge(challenge *models.MFAChallenge) error
+	// GetChallengeForUpdate loads and row-locks a login challenge by its
+	// hash. Must be called on a repository bound to a transaction via WithTx.
+	GetChallengeForUpdate(tokenHash string) (*models.MFAChallenge, error)
+	RecordChallengeAttempt(id int) error
+	MarkChallengeUsed(id int) error
+	// PurgeExpiredChallenges deletes login challenges that have expired
+	PurgeExpiredChallenges() (int64, error)
+	// WithTx returns a repository whose queries run inside tx
+	WithTx(tx *sql.Tx) MFARepository
+}
+
+type PostgresMFARepository struct {
+	db DBTX
+}
+
+func NewPostgresMFARepository(db *sql.DB) MFARepository {
+	return &PostgresMFARepository{db: db}
+}
+
+func (r *PostgresMFARepository) WithTx(tx *sql.Tx) MFARepository {
+	return &PostgresMFARepository{db: tx}
+}
+
+const customerMFAColumns = `customer_id, totp_secret, enabled_at, last_used_step, created_at, updated_at`
+
+func scanCustomerMFA(row rowScanner) (*models.CustomerMFA, error) {
+	mfa := &models.CustomerMFA{}
+	var enabledAt sql.NullTime
+
+	err := row.Scan(&mfa.CustomerID, &mfa.Secret, &enabledAt, &mfa.LastUsedStep, &mfa.CreatedAt, &mfa.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if enabledAt.Valid {
+		mfa.EnabledAt = &enabledAt.Time
+	}
+	return mfa, nil
+}
+
+func (r *PostgresMFARepository) GetByCustomerID(customerID string) (*models.CustomerMFA, error) {
+	query := `SELECT ` + customerMFAColumns + ` FROM customer_mfa WHERE customer_id = $1`
+	return scanCustomerMFA(r.db.QueryRow(query, customerID))
+}
+
+func (r *PostgresMFARepository) LockByCustomerID(customerID string) (*models.CustomerMFA, error) {
+	query := `SELECT ` + customerMFAColumns + ` FROM customer_mfa WHERE customer_id = $1 FOR UPDATE`
+	return scanCustomerMFA(r.db.QueryRow(query, customerID))
+}
+
+func (r *PostgresMFARepository) Save(mfa *models.CustomerMFA) error {
+	query := `
+		INSERT INTO customer_mfa (customer_id, totp_secret, enabled_at, last_used_step, created_at, updated_at)
+		VALUES ($1, $2, NULL, 0, $3, $3)
+		ON CONFLICT (customer_id) DO UPDATE
+		SET totp_secret = EXCLUDED.totp_secret, enabled_at = NULL, last_used_step = 0,
+			created_at = EXCLUDED.created_at, updated_at = EXCLUDED.updated_at`
+
+	_, err := r.db.Exec(query, mfa.CustomerID, mfa.Secret, mfa.CreatedAt)
+	return err
+}
+
+func (r *PostgresMFARepository) Enable(customerID string, enabledAt time.Time) error {
+	query := `UPDATE customer_mfa SET enabled_at = $1, updated_at = $1 WHERE customer_id = $2`
+	_, err := r.db.Exec(query, enabledAt, customerID)
+	return err
+}
+
+func (r *PostgresMFARepository) UpdateLastUsedStep(customerID string, step int64) error {
+	query := `UPDATE customer_mfa SET last_used_step = $1, updated_at = $2 WHERE customer_id = $3`
+	_, err := r.db.Exec(query, step, time.Now().UTC(), customerID)
+	return err
+}
+
+func (r *PostgresMFARepository) Delete(customerID string) error {
+	if _, err := r.db.Exec(`DELETE FROM mfa_recovery_codes WHERE customer_id = $1`, customerID); err != nil {
+		return err
+	}
+	_, err := r.db.Exec(`DELETE FROM customer_mfa WHERE customer_id = $1`, customerID)
+	return err
+}
+
+func (r *PostgresMFARepository) ReplaceRecoveryCodes(customerID string, codeHashes []string, createdAt time.Time) error {
+	if _, err := r.db.Exec(`DELETE FROM mfa_recovery_codes WHERE customer_id = $1`, customerID); err != nil {
+		return err
+	}
+
+	query := `INSERT INTO mfa_recovery_codes (customer_id, code_hash, created_at) VALUES ($1, $2, $3)`
+	for _, codeHash := range codeHashes {
+		if _, err := r.db.Exec(query, customerID, codeHash, createdAt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *PostgresMFARepository) UseRecoveryCode(customerID, codeHash string) (bool, error) {
+	query := `
+		UPDATE mfa_recovery_codes SET used_at = $1
+		WHERE customer_id = $2 AND code_hash = $3 AND used_at IS NULL`
+
+	result, err := r.db.Exec(query, time.Now().UTC(), customerID, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (r *PostgresMFARepository) CountRecoveryCodes(customerID string) (int, error) {
+	var count int
+	err := r.db.QueryRow(
+		`SELECT COUNT(*) FROM mfa_recovery_codes WHERE customer_id = $1 AND used_at IS NULL`, customerID,
+	).Scan(&count)
+	return count, err
+}
+
+func (r *PostgresMFARepository) CreateChallenge(challenge *models.MFAChallenge) error {
+	query := `
+		INSERT INTO mfa_challenges (token_hash, customer_id, created_at, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`
+
+	return r.db.QueryRow(
+		query, challenge.TokenHash, challenge.CustomerID, challenge.CreatedAt, challenge.ExpiresAt,
+	).Scan(&challenge.ID)
+}
+
+func (r *PostgresMFARepository) GetChallengeForUpdate(tokenHash string) (*models.MFAChallenge, error) {
+	query := `
+		SELECT id, token_hash, customer_id, attempts, created_at, expires_at, used_at
+		FROM mfa_challenges WHERE token_hash = $1
+		FOR UPDATE`
+
+	challenge := &models.MFAChallenge{}
+	var usedAt sql.NullTime
+
+	err := r.db.QueryRow(query, tokenHash).Scan(
+		&challenge.ID, &challenge.TokenHash, &challenge.CustomerID, &challenge.Attempts,
+		&challenge.CreatedAt, &challenge.ExpiresAt, &usedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("MFA challenge not found")
+		}
+		return nil, err
+	}
+
+	if usedAt.Valid {
+		challenge.UsedAt = &usedAt.Time
+	}
+
+	return challenge, nil
+}
+
+func (r *PostgresMFARepository) RecordChallengeAttempt(id int) error {
+	_, err := r.db.Exec(`UPDATE mfa_challenges SET attempts = attempts + 1 WHERE id = $1`, id)
+	return err
+}
+
+func (r *PostgresMFARepository) MarkChallengeUsed(id int) error {
+	_, err := r.db.Exec(`UPDATE mfa_challenges SET used_at = $1 WHERE id = $2`, time.Now().UTC(), id)
+	return err
+}
+
+func (r *PostgresMFARepository) PurgeExpiredChallenges() (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM mfa_challenges WHERE expires_at < $1`, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,51 @@
+CREATE TABLE IF NOT EXISTS gl_accounts (
+	id SERIAL PRIMARY KEY,
+	code VARCHAR(30) NOT NULL,
+	currency VARCHAR(3) NOT NULL,
+	name VARCHAR(100) NOT NULL,
+	normal_balance VARCHAR(6) NOT NULL,
+	balance BIGINT NOT NULL DEFAULT 0,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+	CONSTRAINT uq_gl_accounts_code_currency UNIQUE (code, currency),
+	CONSTRAINT chk_gl_normal_balance CHECK (normal_balance IN ('DEBIT', 'CREDIT'))
+);
+
+CREATE TABLE IF NOT EXISTS journal_entries (
+	id SERIAL PRIMARY KEY,
+	entry_id VARCHAR(50) UNIQUE NOT NULL,
+	transaction_id VARCHAR(50) NOT NULL REFERENCES transactions(transaction_id),
+	description TEXT,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS postings (
+	id SERIAL PRIMARY KEY,
+	journal_entry_id INTEGER NOT NULL REFERENCES journal_entries(id),
+	account_id INTEGER REFERENCES accounts(id),
+	gl_account_id INTEGER REFERENCES gl_accounts(id),
+	currency VARCHAR(3) NOT NULL,
+	direction VARCHAR(6) NOT NULL,
+	amount BIGINT NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+
+	CONSTRAINT chk_posting_amount_positive CHECK (amount > 0),
+	CONSTRAINT chk_posting_direction CHECK (direction IN ('DEBIT', 'CREDIT')),
+	CONSTRAINT chk_posting_single_target CHECK ((account_id IS NULL) <> (gl_account_id IS NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_journal_entries_transaction_id ON journal_entries(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_postings_journal_entry_id ON postings(journal_entry_id);
+CREATE INDEX IF NOT EXISTS idx_postings_account_id_created_at ON postings(account_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_postings_gl_account_id ON postings(gl_account_id);
+
+-- Internal bank-side accounts, one per supported currency
+INSERT INTO gl_accounts (code, currency, name, normal_balance) VALUES
+	('CASH', 'TND', 'Caisse TND', 'DEBIT'),
+	('CASH', 'EUR', 'Caisse EUR', 'DEBIT'),
+	('CASH', 'USD', 'Caisse USD', 'DEBIT'),
+	('FEE_INCOME', 'TND', 'Commissions perçues TND', 'CREDIT'),
+	('FEE_INCOME', 'EUR', 'Commissions perçues EUR', 'CREDIT'),
+	('FEE_INCOME', 'USD', 'Commissions perçues USD', 'CREDIT')
+ON CONFLICT (code, currency) DO NOTHING;
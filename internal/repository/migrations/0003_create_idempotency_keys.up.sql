@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+	id SERIAL PRIMARY KEY,
+	scope VARCHAR(50) NOT NULL,
+	idempotency_key VARCHAR(255) NOT NULL,
+	method VARCHAR(10) NOT NULL,
+	path VARCHAR(255) NOT NULL,
+	request_hash CHAR(64) NOT NULL,
+	status_code INTEGER,
+	response_body BYTEA,
+	completed BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+
+	CONSTRAINT uq_idempotency_keys_scope_key UNIQUE (scope, idempotency_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
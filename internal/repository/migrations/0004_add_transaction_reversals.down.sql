@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_transactions_original_transaction_id;
+
+ALTER TABLE transactions DROP CONSTRAINT chk_valid_transaction_type;
+ALTER TABLE transactions ADD CONSTRAINT chk_valid_transaction_type CHECK (
+	transaction_type IN ('TRANSFER', 'DEPOSIT', 'WITHDRAWAL', 'PAYMENT', 'FEE', 'INTEREST')
+);
+
+ALTER TABLE transactions
+	DROP COLUMN fee_refund,
+	DROP COLUMN original_transaction_id;
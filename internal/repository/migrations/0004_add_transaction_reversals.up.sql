@@ -0,0 +1,10 @@
+ALTER TABLE transactions
+	ADD COLUMN original_transaction_id VARCHAR(50) REFERENCES transactions(transaction_id),
+	ADD COLUMN fee_refund BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE transactions DROP CONSTRAINT chk_valid_transaction_type;
+ALTER TABLE transactions ADD CONSTRAINT chk_valid_transaction_type CHECK (
+	transaction_type IN ('TRANSFER', 'DEPOSIT', 'WITHDRAWAL', 'PAYMENT', 'FEE', 'INTEREST', 'REVERSAL')
+);
+
+CREATE INDEX idx_transactions_original_transaction_id ON transactions(original_transaction_id);
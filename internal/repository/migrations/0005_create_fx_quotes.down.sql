@@ -0,0 +1,5 @@
+DELETE FROM gl_accounts WHERE code = 'FX_POSITION' AND balance = 0;
+
+ALTER TABLE transactions DROP COLUMN fx_quote_id;
+
+DROP TABLE IF EXISTS fx_quotes;
@@ -0,0 +1,32 @@
+CREATE TABLE fx_quotes (
+	id SERIAL PRIMARY KEY,
+	quote_id VARCHAR(50) UNIQUE NOT NULL,
+	account_number VARCHAR(20) NOT NULL,
+	from_currency VARCHAR(3) NOT NULL,
+	to_currency VARCHAR(3) NOT NULL,
+	mid_rate DECIMAL(18,8) NOT NULL,
+	rate DECIMAL(18,8) NOT NULL,
+	spread_bps INTEGER NOT NULL DEFAULT 0,
+	amount BIGINT NOT NULL DEFAULT 0,
+	converted_amount BIGINT NOT NULL DEFAULT 0,
+	source VARCHAR(50) NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	used_at TIMESTAMP WITH TIME ZONE,
+	transaction_id VARCHAR(50),
+
+	CONSTRAINT chk_fx_quote_rate_positive CHECK (rate > 0 AND mid_rate > 0)
+);
+
+CREATE INDEX idx_fx_quotes_account_number ON fx_quotes(account_number);
+
+-- Rate snapshot applied to cross-currency transfers
+ALTER TABLE transactions ADD COLUMN fx_quote_id VARCHAR(50) REFERENCES fx_quotes(quote_id);
+
+-- Currency position accounts: the two legs of a conversion each balance in
+-- their own currency against the bank's position in that currency
+INSERT INTO gl_accounts (code, currency, name, normal_balance) VALUES
+	('FX_POSITION', 'TND', 'Position de change TND', 'DEBIT'),
+	('FX_POSITION', 'EUR', 'Position de change EUR', 'DEBIT'),
+	('FX_POSITION', 'USD', 'Position de change USD', 'DEBIT')
+ON CONFLICT (code, currency) DO NOTHING;
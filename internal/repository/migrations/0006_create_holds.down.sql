@@ -0,0 +1,8 @@
+DELETE FROM gl_accounts WHERE code = 'MERCHANT_SETTLEMENT' AND balance = 0;
+
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS chk_hold_amount_non_negative;
+
+-- Outstanding holds disappear with the table
+UPDATE accounts SET hold_amount = 0, available_balance = balance;
+
+DROP TABLE IF EXISTS holds;
@@ -0,0 +1,37 @@
+CREATE TABLE holds (
+	id SERIAL PRIMARY KEY,
+	hold_id VARCHAR(50) UNIQUE NOT NULL,
+	account_id INTEGER NOT NULL REFERENCES accounts(id),
+	account_number VARCHAR(20) NOT NULL,
+	amount BIGINT NOT NULL,
+	captured_amount BIGINT NOT NULL DEFAULT 0,
+	currency VARCHAR(3) NOT NULL,
+	merchant_reference VARCHAR(100) NOT NULL,
+	description TEXT,
+	status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE',
+	transaction_id VARCHAR(50) REFERENCES transactions(transaction_id),
+	expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	closed_at TIMESTAMP WITH TIME ZONE,
+
+	CONSTRAINT chk_hold_amount_positive CHECK (amount > 0),
+	CONSTRAINT chk_hold_captured_amount CHECK (captured_amount >= 0 AND captured_amount <= amount),
+	CONSTRAINT chk_valid_hold_status CHECK (status IN ('ACTIVE', 'CAPTURED', 'RELEASED', 'EXPIRED'))
+);
+
+CREATE INDEX idx_holds_account_number ON holds(account_number);
+CREATE INDEX idx_holds_active_expires_at ON holds(expires_at) WHERE status = 'ACTIVE';
+
+-- hold_amount is the sum of the account's ACTIVE holds and available_balance
+-- is always balance - hold_amount
+UPDATE accounts SET available_balance = balance - hold_amount WHERE available_balance <> balance - hold_amount;
+
+ALTER TABLE accounts ADD CONSTRAINT chk_hold_amount_non_negative CHECK (hold_amount >= 0);
+
+-- Card/merchant payments captured from holds await settlement here
+INSERT INTO gl_accounts (code, currency, name, normal_balance) VALUES
+	('MERCHANT_SETTLEMENT', 'TND', 'Règlements commerçants TND', 'CREDIT'),
+	('MERCHANT_SETTLEMENT', 'EUR', 'Règlements commerçants EUR', 'CREDIT'),
+	('MERCHANT_SETTLEMENT', 'USD', 'Règlements commerçants USD', 'CREDIT')
+ON CONFLICT (code, currency) DO NOTHING;
@@ -0,0 +1,16 @@
+CREATE TABLE staff_users (
+	id SERIAL PRIMARY KEY,
+	staff_id VARCHAR(50) UNIQUE NOT NULL,
+	username VARCHAR(50) UNIQUE NOT NULL,
+	email VARCHAR(255) UNIQUE NOT NULL,
+	full_name VARCHAR(100) NOT NULL,
+	role VARCHAR(20) NOT NULL,
+	hash_password VARCHAR(255) NOT NULL,
+	status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE',
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	last_login_at TIMESTAMP WITH TIME ZONE,
+
+	CONSTRAINT chk_valid_staff_role CHECK (role IN ('teller', 'compliance', 'admin')),
+	CONSTRAINT chk_valid_staff_status CHECK (status IN ('ACTIVE', 'DISABLED'))
+);
@@ -0,0 +1,40 @@
+-- A session is one login on one device. Its refresh tokens form a family:
+-- each refresh consumes the current token and issues the next one, so a
+-- consumed token being presented again means it was stolen.
+CREATE TABLE auth_sessions (
+	id SERIAL PRIMARY KEY,
+	session_id VARCHAR(64) UNIQUE NOT NULL,
+	subject_type VARCHAR(20) NOT NULL,
+	subject_id VARCHAR(50) NOT NULL,
+	user_agent TEXT,
+	ip_address VARCHAR(64),
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	last_used_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	revoked_at TIMESTAMP WITH TIME ZONE,
+	revoked_reason VARCHAR(50),
+
+	CONSTRAINT chk_valid_session_subject_type CHECK (subject_type IN ('customer', 'staff'))
+);
+
+CREATE INDEX idx_auth_sessions_subject ON auth_sessions(subject_type, subject_id);
+
+CREATE TABLE refresh_tokens (
+	id SERIAL PRIMARY KEY,
+	token_hash CHAR(64) UNIQUE NOT NULL,
+	session_id VARCHAR(64) NOT NULL REFERENCES auth_sessions(session_id) ON DELETE CASCADE,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	used_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_refresh_tokens_session_id ON refresh_tokens(session_id);
+
+-- Access tokens revoked before their expiry (by jti)
+CREATE TABLE revoked_tokens (
+	jti VARCHAR(64) PRIMARY KEY,
+	revoked_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	expires_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_revoked_tokens_expires_at ON revoked_tokens(expires_at);
@@ -0,0 +1,41 @@
+-- Copies customer details back onto each of their accounts. Fails if a
+-- customer owns more than one account, since customer_id and email were
+-- unique per account before this migration.
+ALTER TABLE accounts
+	DROP CONSTRAINT IF EXISTS fk_accounts_customer,
+	ADD COLUMN first_name VARCHAR(100),
+	ADD COLUMN last_name VARCHAR(100),
+	ADD COLUMN email VARCHAR(255),
+	ADD COLUMN phone VARCHAR(20),
+	ADD COLUMN date_of_birth DATE,
+	ADD COLUMN street VARCHAR(255),
+	ADD COLUMN city VARCHAR(100),
+	ADD COLUMN postal_code VARCHAR(20),
+	ADD COLUMN country VARCHAR(100),
+	ADD COLUMN state VARCHAR(100),
+	ADD COLUMN hash_password VARCHAR(255),
+	ADD COLUMN last_login_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE accounts a SET
+	first_name = c.first_name, last_name = c.last_name, email = c.email,
+	phone = c.phone, date_of_birth = c.date_of_birth, street = c.street,
+	city = c.city, postal_code = c.postal_code, country = c.country,
+	state = c.state, hash_password = c.hash_password, last_login_at = c.last_login_at
+FROM customers c
+WHERE c.customer_id = a.customer_id;
+
+ALTER TABLE accounts
+	ALTER COLUMN first_name SET NOT NULL,
+	ALTER COLUMN last_name SET NOT NULL,
+	ALTER COLUMN email SET NOT NULL,
+	ALTER COLUMN phone SET NOT NULL,
+	ALTER COLUMN date_of_birth SET NOT NULL,
+	ALTER COLUMN hash_password SET NOT NULL,
+	ADD CONSTRAINT accounts_customer_id_key UNIQUE (customer_id),
+	ADD CONSTRAINT accounts_email_key UNIQUE (email);
+
+CREATE INDEX IF NOT EXISTS idx_accounts_email ON accounts(email);
+
+DELETE FROM auth_sessions WHERE subject_type = 'customer';
+
+DROP TABLE IF EXISTS customers;
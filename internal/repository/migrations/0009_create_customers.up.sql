@@ -0,0 +1,58 @@
+-- Identity and credentials move from accounts to customers so one customer
+-- can own several accounts under a single login.
+CREATE TABLE customers (
+	id SERIAL PRIMARY KEY,
+	customer_id VARCHAR(50) UNIQUE NOT NULL,
+	first_name VARCHAR(100) NOT NULL,
+	last_name VARCHAR(100) NOT NULL,
+	email VARCHAR(255) UNIQUE NOT NULL,
+	phone VARCHAR(20) NOT NULL,
+	date_of_birth DATE NOT NULL,
+	street VARCHAR(255),
+	city VARCHAR(100),
+	postal_code VARCHAR(20),
+	country VARCHAR(100),
+	state VARCHAR(100),
+	hash_password VARCHAR(255) NOT NULL,
+	status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE',
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	last_login_at TIMESTAMP WITH TIME ZONE,
+
+	CONSTRAINT chk_valid_customer_status CHECK (status IN ('ACTIVE', 'SUSPENDED', 'CLOSED'))
+);
+
+-- Every existing account had its own customer_id, so each becomes a customer
+INSERT INTO customers (
+	customer_id, first_name, last_name, email, phone, date_of_birth,
+	street, city, postal_code, country, state, hash_password, status,
+	created_at, updated_at, last_login_at
+)
+SELECT
+	customer_id, first_name, last_name, email, phone, date_of_birth,
+	street, city, postal_code, country, state, hash_password, 'ACTIVE',
+	created_at, updated_at, last_login_at
+FROM accounts;
+
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_customer_id_key;
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS accounts_email_key;
+DROP INDEX IF EXISTS idx_accounts_email;
+
+ALTER TABLE accounts
+	ADD CONSTRAINT fk_accounts_customer FOREIGN KEY (customer_id) REFERENCES customers(customer_id),
+	DROP COLUMN first_name,
+	DROP COLUMN last_name,
+	DROP COLUMN email,
+	DROP COLUMN phone,
+	DROP COLUMN date_of_birth,
+	DROP COLUMN street,
+	DROP COLUMN city,
+	DROP COLUMN postal_code,
+	DROP COLUMN country,
+	DROP COLUMN state,
+	DROP COLUMN hash_password,
+	DROP COLUMN last_login_at;
+
+-- Customer sessions were keyed by account number; they are now keyed by
+-- customer ID, so existing customer sessions must log in again
+DELETE FROM auth_sessions WHERE subject_type = 'customer';
@@ -0,0 +1,3 @@
+UPDATE accounts
+SET iban = 'TN59' || account_number
+WHERE account_number ~ '^[0-9]{20}$';
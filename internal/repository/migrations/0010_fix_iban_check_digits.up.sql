@@ -0,0 +1,6 @@
+-- Accounts were issued with hardcoded IBAN check digits ('59'). Recompute
+-- the ISO 13616 check digits from the 20-digit account number: move "TN00"
+-- to the end of the BBAN (T=29, N=23) and take 98 - (value mod 97).
+UPDATE accounts
+SET iban = 'TN' || lpad((98 - mod((account_number || '292300')::numeric, 97))::text, 2, '0') || account_number
+WHERE account_number ~ '^[0-9]{20}$';
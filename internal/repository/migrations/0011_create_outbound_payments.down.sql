@@ -0,0 +1,13 @@
+DELETE FROM gl_accounts WHERE code IN ('CLEARING_SUSPENSE', 'NOSTRO') AND balance = 0;
+
+ALTER TABLE transactions DROP CONSTRAINT chk_valid_transaction_type;
+ALTER TABLE transactions ADD CONSTRAINT chk_valid_transaction_type CHECK (
+	transaction_type IN ('TRANSFER', 'DEPOSIT', 'WITHDRAWAL', 'PAYMENT', 'FEE', 'INTEREST', 'REVERSAL')
+);
+
+ALTER TABLE transactions
+	DROP COLUMN counterparty_name,
+	DROP COLUMN counterparty_bic,
+	DROP COLUMN counterparty_iban;
+
+DROP TABLE IF EXISTS outbound_payments;
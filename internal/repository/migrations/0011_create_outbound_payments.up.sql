@@ -0,0 +1,50 @@
+CREATE TABLE outbound_payments (
+	id SERIAL PRIMARY KEY,
+	payment_id VARCHAR(50) UNIQUE NOT NULL,
+	transaction_id VARCHAR(50) UNIQUE NOT NULL REFERENCES transactions(transaction_id),
+	debtor_account_number VARCHAR(20) NOT NULL,
+	debtor_iban VARCHAR(34) NOT NULL,
+	debtor_bic VARCHAR(11) NOT NULL,
+	creditor_iban VARCHAR(34) NOT NULL,
+	creditor_bic VARCHAR(11) NOT NULL,
+	creditor_name VARCHAR(140) NOT NULL,
+	amount BIGINT NOT NULL,
+	currency VARCHAR(3) NOT NULL,
+	remittance_info VARCHAR(140),
+	status VARCHAR(20) NOT NULL DEFAULT 'QUEUED',
+	gateway_reference VARCHAR(100),
+	attempts INTEGER NOT NULL DEFAULT 0,
+	last_error TEXT,
+	failure_reason TEXT,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	submitted_at TIMESTAMP WITH TIME ZONE,
+	closed_at TIMESTAMP WITH TIME ZONE,
+
+	CONSTRAINT chk_outbound_amount_positive CHECK (amount > 0),
+	CONSTRAINT chk_valid_outbound_status CHECK (status IN ('QUEUED', 'SUBMITTED', 'SETTLED', 'REJECTED'))
+);
+
+CREATE INDEX idx_outbound_payments_queued ON outbound_payments(created_at) WHERE status = 'QUEUED';
+
+-- Beneficiary of a transfer to another bank, which has no local account
+ALTER TABLE transactions
+	ADD COLUMN counterparty_iban VARCHAR(34),
+	ADD COLUMN counterparty_bic VARCHAR(11),
+	ADD COLUMN counterparty_name VARCHAR(140);
+
+ALTER TABLE transactions DROP CONSTRAINT chk_valid_transaction_type;
+ALTER TABLE transactions ADD CONSTRAINT chk_valid_transaction_type CHECK (
+	transaction_type IN ('TRANSFER', 'DEPOSIT', 'WITHDRAWAL', 'PAYMENT', 'FEE', 'INTEREST', 'REVERSAL', 'EXTERNAL_TRANSFER')
+);
+
+-- Outbound payments sit in clearing suspense from the customer debit until the
+-- gateway settles them against the bank's settlement (nostro) account
+INSERT INTO gl_accounts (code, currency, name, normal_balance) VALUES
+	('CLEARING_SUSPENSE', 'TND', 'Compte d''attente compensation TND', 'CREDIT'),
+	('CLEARING_SUSPENSE', 'EUR', 'Compte d''attente compensation EUR', 'CREDIT'),
+	('CLEARING_SUSPENSE', 'USD', 'Compte d''attente compensation USD', 'CREDIT'),
+	('NOSTRO', 'TND', 'Compte de règlement BCT TND', 'DEBIT'),
+	('NOSTRO', 'EUR', 'Comptes correspondants EUR', 'DEBIT'),
+	('NOSTRO', 'USD', 'Comptes correspondants USD', 'DEBIT')
+ON CONFLICT (code, currency) DO NOTHING;
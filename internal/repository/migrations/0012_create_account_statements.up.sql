@@ -0,0 +1,17 @@
+-- Statements rendered ahead of time by the monthly statement job. Periods are
+-- whole days: period_end is the last day included.
+CREATE TABLE account_statements (
+	id SERIAL PRIMARY KEY,
+	account_number VARCHAR(20) NOT NULL REFERENCES accounts(account_number) ON DELETE CASCADE,
+	period_start DATE NOT NULL,
+	period_end DATE NOT NULL,
+	opening_balance BIGINT NOT NULL,
+	closing_balance BIGINT NOT NULL,
+	line_count INTEGER NOT NULL,
+	csv_document BYTEA NOT NULL,
+	pdf_document BYTEA NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+
+	CONSTRAINT uq_account_statement_period UNIQUE (account_number, period_start, period_end),
+	CONSTRAINT chk_statement_period CHECK (period_end >= period_start)
+);
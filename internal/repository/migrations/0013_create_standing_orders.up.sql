@@ -0,0 +1,56 @@
+CREATE TABLE standing_orders (
+	id SERIAL PRIMARY KEY,
+	order_id VARCHAR(50) UNIQUE NOT NULL,
+	from_account_number VARCHAR(20) NOT NULL REFERENCES accounts(account_number) ON DELETE CASCADE,
+	to_account_number VARCHAR(20),
+	to_iban VARCHAR(34),
+	to_bic VARCHAR(11),
+	beneficiary_name VARCHAR(140),
+	amount BIGINT NOT NULL,
+	currency VARCHAR(3) NOT NULL,
+	description TEXT,
+	reference VARCHAR(100),
+	frequency VARCHAR(10) NOT NULL,
+	day_of_month INTEGER,
+	start_date DATE NOT NULL,
+	end_date DATE,
+	max_occurrences INTEGER,
+	occurrences INTEGER NOT NULL DEFAULT 0,
+	next_run_date DATE,
+	next_attempt_at TIMESTAMP WITH TIME ZONE,
+	retry_count INTEGER NOT NULL DEFAULT 0,
+	status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE',
+	last_error TEXT,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+
+	CONSTRAINT chk_standing_order_amount_positive CHECK (amount > 0),
+	CONSTRAINT chk_standing_order_destination CHECK (to_account_number IS NOT NULL OR to_iban IS NOT NULL),
+	CONSTRAINT chk_valid_standing_order_frequency CHECK (frequency IN ('ONCE', 'WEEKLY', 'MONTHLY')),
+	CONSTRAINT chk_standing_order_day_of_month CHECK (day_of_month BETWEEN 1 AND 31),
+	CONSTRAINT chk_valid_standing_order_status CHECK (status IN ('ACTIVE', 'PAUSED', 'CANCELLED', 'COMPLETED'))
+);
+
+CREATE INDEX idx_standing_orders_from_account ON standing_orders(from_account_number);
+CREATE INDEX idx_standing_orders_due ON standing_orders(next_attempt_at) WHERE status = 'ACTIVE';
+
+-- transaction_id is assigned before the transfer runs and has no foreign key:
+-- an attempt interrupted before its transfer committed has no transaction
+CREATE TABLE standing_order_executions (
+	id SERIAL PRIMARY KEY,
+	order_id VARCHAR(50) NOT NULL REFERENCES standing_orders(order_id) ON DELETE CASCADE,
+	scheduled_for DATE NOT NULL,
+	attempt INTEGER NOT NULL,
+	transaction_id VARCHAR(50) UNIQUE NOT NULL,
+	status VARCHAR(20) NOT NULL,
+	error TEXT,
+	started_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	finished_at TIMESTAMP WITH TIME ZONE,
+
+	CONSTRAINT chk_valid_execution_status CHECK (status IN ('RUNNING', 'COMPLETED', 'FAILED'))
+);
+
+CREATE INDEX idx_standing_order_executions_order ON standing_order_executions(order_id, started_at);
+
+-- At most one attempt of an order runs at a time, whichever replica runs it
+CREATE UNIQUE INDEX uq_standing_order_running_execution ON standing_order_executions(order_id) WHERE status = 'RUNNING';
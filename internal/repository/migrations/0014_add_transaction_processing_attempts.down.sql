@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_transactions_pending;
+
+ALTER TABLE transactions
+	DROP COLUMN next_attempt_at,
+	DROP COLUMN attempts;
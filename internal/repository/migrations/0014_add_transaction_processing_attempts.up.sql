@@ -0,0 +1,7 @@
+-- Pending transactions are processed by a background worker: failed attempts
+-- are counted and retried from next_attempt_at until the limit is reached
+ALTER TABLE transactions
+	ADD COLUMN attempts INTEGER NOT NULL DEFAULT 0,
+	ADD COLUMN next_attempt_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX idx_transactions_pending ON transactions(created_at) WHERE status = 'PENDING';
@@ -0,0 +1,17 @@
+-- Fees go back to being part of their transaction: move their journal
+-- entries onto the parent before dropping the FEE transactions
+UPDATE journal_entries j
+SET transaction_id = f.parent_transaction_id
+FROM transactions f
+WHERE j.transaction_id = f.transaction_id
+  AND f.transaction_type = 'FEE' AND f.parent_transaction_id IS NOT NULL;
+
+DELETE FROM transactions WHERE transaction_type = 'FEE' AND parent_transaction_id IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_transactions_parent;
+
+ALTER TABLE transactions
+	DROP COLUMN channel,
+	DROP COLUMN parent_transaction_id;
+
+DROP TABLE IF EXISTS fee_schedules;
@@ -0,0 +1,61 @@
+-- Fees are priced from schedules editable by admins. An empty account type,
+-- channel or currency matches any; the most specific active schedule applies.
+CREATE TABLE fee_schedules (
+	id SERIAL PRIMARY KEY,
+	account_type VARCHAR(20),
+	transaction_type VARCHAR(20) NOT NULL,
+	channel VARCHAR(20),
+	currency VARCHAR(3),
+	flat_fee BIGINT NOT NULL DEFAULT 0,
+	percentage_bps INTEGER NOT NULL DEFAULT 0,
+	tiers JSONB,
+	min_fee BIGINT NOT NULL DEFAULT 0,
+	max_fee BIGINT,
+	free_per_month INTEGER NOT NULL DEFAULT 0,
+	active BOOLEAN NOT NULL DEFAULT TRUE,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+	CONSTRAINT chk_fee_schedule_amounts CHECK (flat_fee >= 0 AND percentage_bps >= 0 AND min_fee >= 0),
+	CONSTRAINT chk_fee_schedule_max_fee CHECK (max_fee IS NULL OR max_fee >= min_fee),
+	CONSTRAINT chk_fee_schedule_free_per_month CHECK (free_per_month >= 0),
+	CONSTRAINT chk_valid_fee_transaction_type CHECK (transaction_type IN ('TRANSFER', 'WITHDRAWAL', 'EXTERNAL_TRANSFER'))
+);
+
+-- Two active schedules with the same key would make the applicable one ambiguous
+CREATE UNIQUE INDEX uq_fee_schedules_active_key ON fee_schedules(
+	COALESCE(account_type, ''), transaction_type, COALESCE(channel, ''), COALESCE(currency, '')
+) WHERE active;
+
+-- The fees charged until now, in the currency's minor unit: 0.1% of a
+-- transfer with a minimum of 100, a flat 200 per withdrawal
+INSERT INTO fee_schedules (transaction_type, percentage_bps, min_fee) VALUES
+	('TRANSFER', 10, 100),
+	('EXTERNAL_TRANSFER', 10, 100);
+INSERT INTO fee_schedules (transaction_type, flat_fee) VALUES
+	('WITHDRAWAL', 200);
+
+-- A charged fee is booked as a FEE transaction of its own, linked to the
+-- transaction it was charged on. The channel a transaction came through
+-- selects its fee schedule and counts towards free monthly quotas.
+ALTER TABLE transactions
+	ADD COLUMN parent_transaction_id VARCHAR(50) REFERENCES transactions(transaction_id),
+	ADD COLUMN channel VARCHAR(20);
+
+CREATE INDEX idx_transactions_parent ON transactions(parent_transaction_id) WHERE parent_transaction_id IS NOT NULL;
+
+-- Fees charged so far were part of their transaction's amounts: give each
+-- booked one its FEE transaction. Their journal entries stay with the parent.
+INSERT INTO transactions (
+	transaction_id, from_account_id, from_account_number, amount, currency,
+	exchange_rate, converted_amount, transaction_type, status, description,
+	reference, fee, processed_at, created_at, updated_at, parent_transaction_id
+)
+SELECT
+	LEFT('FEE' || t.transaction_id, 50), t.from_account_id, t.from_account_number, t.fee, t.currency,
+	1.0, t.fee, 'FEE', 'COMPLETED', 'Fee for ' || t.transaction_id,
+	t.reference, 0, COALESCE(t.processed_at, t.created_at), t.created_at, t.updated_at, t.transaction_id
+FROM transactions t
+WHERE t.fee > 0
+  AND t.transaction_type IN ('TRANSFER', 'WITHDRAWAL', 'EXTERNAL_TRANSFER')
+  AND (t.status = 'COMPLETED' OR (t.status = 'PENDING' AND t.transaction_type = 'EXTERNAL_TRANSFER'));
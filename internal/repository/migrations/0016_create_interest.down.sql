@@ -0,0 +1,10 @@
+DELETE FROM gl_accounts WHERE code IN ('INTEREST_EXPENSE', 'WITHHOLDING_TAX_PAYABLE') AND balance = 0;
+
+ALTER TABLE transactions DROP CONSTRAINT chk_valid_transaction_type;
+ALTER TABLE transactions ADD CONSTRAINT chk_valid_transaction_type CHECK (
+	transaction_type IN ('TRANSFER', 'DEPOSIT', 'WITHDRAWAL', 'PAYMENT', 'FEE', 'INTEREST', 'REVERSAL', 'EXTERNAL_TRANSFER')
+);
+
+DROP TABLE IF EXISTS interest_accruals;
+DROP TABLE IF EXISTS interest_capitalizations;
+DROP TABLE IF EXISTS interest_rate_tables;
@@ -0,0 +1,80 @@
+-- Interest rates per account type, effective from a business date. Each tier
+-- pays its rate on the part of the balance between the previous tier's bound
+-- and its own; a table without currency applies to every currency.
+CREATE TABLE interest_rate_tables (
+	id SERIAL PRIMARY KEY,
+	account_type VARCHAR(20) NOT NULL,
+	currency VARCHAR(3),
+	effective_from DATE NOT NULL,
+	tiers JSONB NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX uq_interest_rate_tables_key ON interest_rate_tables(
+	account_type, COALESCE(currency, ''), effective_from
+);
+
+-- Interest earned by an account on a business date's closing balance, in
+-- millionths of the currency's minor unit so that daily amounts add up
+-- without rounding. One accrual per account and date makes runs idempotent.
+CREATE TABLE interest_accruals (
+	id BIGSERIAL PRIMARY KEY,
+	account_id INTEGER NOT NULL REFERENCES accounts(id),
+	account_number VARCHAR(20) NOT NULL,
+	business_date DATE NOT NULL,
+	balance BIGINT NOT NULL,
+	rate_table_id INTEGER NOT NULL REFERENCES interest_rate_tables(id),
+	amount_micros BIGINT NOT NULL,
+	capitalization_id INTEGER,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+	CONSTRAINT uq_interest_accruals_account_date UNIQUE (account_id, business_date),
+	CONSTRAINT chk_interest_accrual_amount CHECK (amount_micros >= 0)
+);
+
+CREATE INDEX idx_interest_accruals_date ON interest_accruals(business_date);
+CREATE INDEX idx_interest_accruals_uncapitalized ON interest_accruals(account_id, business_date) WHERE capitalization_id IS NULL;
+
+-- Accrued interest paid to an account at the end of a capitalization period,
+-- less the tax withheld at source
+CREATE TABLE interest_capitalizations (
+	id SERIAL PRIMARY KEY,
+	account_id INTEGER NOT NULL REFERENCES accounts(id),
+	account_number VARCHAR(20) NOT NULL,
+	period_start DATE NOT NULL,
+	period_end DATE NOT NULL,
+	gross_amount BIGINT NOT NULL,
+	withholding_tax BIGINT NOT NULL,
+	net_amount BIGINT NOT NULL,
+	transaction_id VARCHAR(50) REFERENCES transactions(transaction_id),
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+	CONSTRAINT uq_interest_capitalizations_account_period UNIQUE (account_id, period_end),
+	CONSTRAINT chk_interest_capitalization_amounts CHECK (
+		gross_amount >= 0 AND withholding_tax >= 0 AND net_amount = gross_amount - withholding_tax
+	)
+);
+
+ALTER TABLE interest_accruals ADD CONSTRAINT fk_interest_accruals_capitalization
+	FOREIGN KEY (capitalization_id) REFERENCES interest_capitalizations(id);
+
+-- Savings accounts earn 5% a year until admins publish other rates
+INSERT INTO interest_rate_tables (account_type, effective_from, tiers) VALUES
+	('COMPTE_EPARGNE', '2025-01-01', '[{"rate_bps": 500}]');
+
+-- Tax withheld from interest is booked as a transaction of its own, linked to
+-- the INTEREST transaction it was withheld from
+ALTER TABLE transactions DROP CONSTRAINT chk_valid_transaction_type;
+ALTER TABLE transactions ADD CONSTRAINT chk_valid_transaction_type CHECK (
+	transaction_type IN ('TRANSFER', 'DEPOSIT', 'WITHDRAWAL', 'PAYMENT', 'FEE', 'INTEREST', 'REVERSAL', 'EXTERNAL_TRANSFER', 'WITHHOLDING_TAX')
+);
+
+-- Interest is an expense of the bank; the tax withheld is owed to the state
+INSERT INTO gl_accounts (code, currency, name, normal_balance) VALUES
+	('INTEREST_EXPENSE', 'TND', 'Intérêts servis TND', 'DEBIT'),
+	('INTEREST_EXPENSE', 'EUR', 'Intérêts servis EUR', 'DEBIT'),
+	('INTEREST_EXPENSE', 'USD', 'Intérêts servis USD', 'DEBIT'),
+	('WITHHOLDING_TAX_PAYABLE', 'TND', 'Retenue à la source à reverser TND', 'CREDIT'),
+	('WITHHOLDING_TAX_PAYABLE', 'EUR', 'Retenue à la source à reverser EUR', 'CREDIT'),
+	('WITHHOLDING_TAX_PAYABLE', 'USD', 'Retenue à la source à reverser USD', 'CREDIT')
+ON CONFLICT (code, currency) DO NOTHING;
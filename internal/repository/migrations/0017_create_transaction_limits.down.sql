@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_transactions_outgoing;
+DROP TABLE IF EXISTS customer_limits;
+DROP TABLE IF EXISTS account_type_limits;
@@ -0,0 +1,43 @@
+-- Outgoing limits per account type, in the account currency's minor unit.
+-- A NULL limit does not apply.
+CREATE TABLE account_type_limits (
+	account_type VARCHAR(20) PRIMARY KEY,
+	single_max BIGINT,
+	daily_max BIGINT,
+	monthly_max BIGINT,
+	hourly_count INTEGER,
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+	CONSTRAINT chk_account_type_limits_positive CHECK (
+		single_max > 0 AND daily_max > 0 AND monthly_max > 0 AND hourly_count > 0
+	)
+);
+
+INSERT INTO account_type_limits (account_type, single_max, daily_max, monthly_max, hourly_count) VALUES
+	('COMPTE_COURANT', 10000000, 20000000, 100000000, 20),
+	('COMPTE_EPARGNE', 5000000, 5000000, 20000000, 10),
+	('COMPTE_ENTREPRISE', 100000000, 200000000, 1000000000, 100),
+	('COMPTE_DEVISES', 1000000, 2000000, 10000000, 20);
+
+-- A customer's limits on their accounts of a type. A NULL limit keeps the
+-- account type's; a limit of 0 lifts it.
+CREATE TABLE customer_limits (
+	id SERIAL PRIMARY KEY,
+	customer_id VARCHAR(50) NOT NULL REFERENCES customers(customer_id),
+	account_type VARCHAR(20) NOT NULL,
+	single_max BIGINT,
+	daily_max BIGINT,
+	monthly_max BIGINT,
+	hourly_count INTEGER,
+	updated_by VARCHAR(50) NOT NULL,
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+	CONSTRAINT uq_customer_limits_customer_type UNIQUE (customer_id, account_type),
+	CONSTRAINT chk_customer_limits_not_negative CHECK (
+		single_max >= 0 AND daily_max >= 0 AND monthly_max >= 0 AND hourly_count >= 0
+	)
+);
+
+-- Outgoing totals are summed per account over the current day and month
+CREATE INDEX idx_transactions_outgoing ON transactions(from_account_number, created_at)
+	WHERE transaction_type IN ('TRANSFER', 'WITHDRAWAL', 'EXTERNAL_TRANSFER');
@@ -0,0 +1,15 @@
+DELETE FROM gl_accounts WHERE code = 'INTEREST_INCOME' AND balance = 0;
+
+ALTER TABLE transactions DROP CONSTRAINT chk_valid_transaction_type;
+ALTER TABLE transactions ADD CONSTRAINT chk_valid_transaction_type CHECK (
+	transaction_type IN ('TRANSFER', 'DEPOSIT', 'WITHDRAWAL', 'PAYMENT', 'FEE', 'INTEREST', 'REVERSAL', 'EXTERNAL_TRANSFER', 'WITHHOLDING_TAX')
+);
+
+DROP TABLE IF EXISTS overdraft_interest_accruals;
+DROP TABLE IF EXISTS overdraft_facilities;
+
+-- Accounts still overdrawn keep their balance; only new rows are checked
+ALTER TABLE accounts DROP CONSTRAINT IF EXISTS chk_overdraft_non_negative;
+ALTER TABLE accounts ADD CONSTRAINT chk_balance_non_negative CHECK (balance >= 0) NOT VALID;
+ALTER TABLE accounts DROP COLUMN IF EXISTS overdraft_rate_bps;
+ALTER TABLE accounts DROP COLUMN IF EXISTS overdraft_limit;
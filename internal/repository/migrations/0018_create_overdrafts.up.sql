@@ -0,0 +1,72 @@
+-- Authorized overdraft: debits may take an account's available balance down
+-- to -overdraft_limit, and its negative closing balances accrue debit interest
+-- at overdraft_rate_bps a year. Both are set when an admin approves a
+-- facility. Debits are checked against the limit under the account's lock;
+-- debit interest, or a facility lowered or revoked while in use, can leave the
+-- balance below it, so the balance itself is no longer constrained.
+ALTER TABLE accounts ADD COLUMN overdraft_limit BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE accounts ADD COLUMN overdraft_rate_bps INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE accounts DROP CONSTRAINT chk_balance_non_negative;
+ALTER TABLE accounts ADD CONSTRAINT chk_overdraft_non_negative CHECK (overdraft_limit >= 0 AND overdraft_rate_bps >= 0);
+
+-- Overdraft requests and the decisions on them. An account has at most one
+-- request pending and one facility approved at a time.
+CREATE TABLE overdraft_facilities (
+	id SERIAL PRIMARY KEY,
+	account_id INTEGER NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+	account_number VARCHAR(20) NOT NULL,
+	requested_limit BIGINT NOT NULL,
+	approved_limit BIGINT,
+	rate_bps INTEGER,
+	status VARCHAR(20) NOT NULL DEFAULT 'PENDING',
+	reason TEXT NOT NULL DEFAULT '',
+	requested_by VARCHAR(50) NOT NULL,
+	decided_by VARCHAR(50),
+	decision_note TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+	decided_at TIMESTAMP WITH TIME ZONE,
+	ended_at TIMESTAMP WITH TIME ZONE,
+
+	CONSTRAINT chk_overdraft_facility_status CHECK (status IN ('PENDING', 'APPROVED', 'REJECTED', 'REVOKED')),
+	CONSTRAINT chk_overdraft_facility_amounts CHECK (
+		requested_limit > 0 AND (approved_limit IS NULL OR approved_limit > 0) AND (rate_bps IS NULL OR rate_bps >= 0)
+	)
+);
+
+CREATE UNIQUE INDEX uq_overdraft_facilities_pending ON overdraft_facilities(account_id) WHERE status = 'PENDING';
+CREATE UNIQUE INDEX uq_overdraft_facilities_approved ON overdraft_facilities(account_id) WHERE status = 'APPROVED';
+CREATE INDEX idx_overdraft_facilities_status ON overdraft_facilities(status, created_at);
+
+-- Debit interest owed on a business date's negative closing balance, in
+-- millionths of the currency's minor unit, until it is charged by a
+-- DEBIT_INTEREST transaction. One accrual per account and date makes runs
+-- idempotent.
+CREATE TABLE overdraft_interest_accruals (
+	id BIGSERIAL PRIMARY KEY,
+	account_id INTEGER NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+	account_number VARCHAR(20) NOT NULL,
+	business_date DATE NOT NULL,
+	balance BIGINT NOT NULL,
+	rate_bps INTEGER NOT NULL,
+	amount_micros BIGINT NOT NULL,
+	charged_at TIMESTAMP WITH TIME ZONE,
+	transaction_id VARCHAR(50) REFERENCES transactions(transaction_id),
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+	CONSTRAINT uq_overdraft_interest_accruals_account_date UNIQUE (account_id, business_date),
+	CONSTRAINT chk_overdraft_interest_amount CHECK (amount_micros >= 0)
+);
+
+CREATE INDEX idx_overdraft_interest_accruals_uncharged ON overdraft_interest_accruals(account_id, business_date) WHERE charged_at IS NULL;
+
+ALTER TABLE transactions DROP CONSTRAINT chk_valid_transaction_type;
+ALTER TABLE transactions ADD CONSTRAINT chk_valid_transaction_type CHECK (
+	transaction_type IN ('TRANSFER', 'DEPOSIT', 'WITHDRAWAL', 'PAYMENT', 'FEE', 'INTEREST', 'REVERSAL', 'EXTERNAL_TRANSFER', 'WITHHOLDING_TAX', 'DEBIT_INTEREST')
+);
+
+-- Debit interest is income of the bank
+INSERT INTO gl_accounts (code, currency, name, normal_balance) VALUES
+	('INTEREST_INCOME', 'TND', 'Intérêts débiteurs perçus TND', 'CREDIT'),
+	('INTEREST_INCOME', 'EUR', 'Intérêts débiteurs perçus EUR', 'CREDIT'),
+	('INTEREST_INCOME', 'USD', 'Intérêts débiteurs perçus USD', 'CREDIT')
+ON CONFLICT (code, currency) DO NOTHING;
@@ -0,0 +1,7 @@
+ALTER TABLE auth_sessions
+	DROP COLUMN IF EXISTS authenticated_at,
+	DROP COLUMN IF EXISTS auth_methods;
+
+DROP TABLE IF EXISTS mfa_challenges;
+DROP TABLE IF EXISTS mfa_recovery_codes;
+DROP TABLE IF EXISTS customer_mfa;
@@ -0,0 +1,45 @@
+-- TOTP second factor of customers. An enrolment only protects logins once
+-- the customer has confirmed it with a valid code (enabled_at is set).
+CREATE TABLE customer_mfa (
+	customer_id VARCHAR(50) PRIMARY KEY REFERENCES customers(customer_id) ON DELETE CASCADE,
+	totp_secret VARCHAR(64) NOT NULL,
+	enabled_at TIMESTAMP WITH TIME ZONE,
+	last_used_step BIGINT NOT NULL DEFAULT 0, -- TOTP time step of the last code accepted, which cannot be replayed
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+-- Single-use codes standing in for the authenticator app; only hashes are kept
+CREATE TABLE mfa_recovery_codes (
+	id SERIAL PRIMARY KEY,
+	customer_id VARCHAR(50) NOT NULL REFERENCES customers(customer_id) ON DELETE CASCADE,
+	code_hash CHAR(64) NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	used_at TIMESTAMP WITH TIME ZONE,
+
+	CONSTRAINT uq_mfa_recovery_code UNIQUE (customer_id, code_hash)
+);
+
+-- Issued when the password of a customer with MFA checks out, and exchanged
+-- with a valid code for the session's tokens
+CREATE TABLE mfa_challenges (
+	id SERIAL PRIMARY KEY,
+	token_hash CHAR(64) UNIQUE NOT NULL,
+	customer_id VARCHAR(50) NOT NULL REFERENCES customers(customer_id) ON DELETE CASCADE,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	used_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_mfa_challenges_expires_at ON mfa_challenges(expires_at);
+
+-- How the session authenticated, carried into its tokens' amr/acr claims.
+-- authenticated_at moves forward when the customer steps up.
+ALTER TABLE auth_sessions
+	ADD COLUMN auth_methods VARCHAR(50) NOT NULL DEFAULT 'pwd',
+	ADD COLUMN authenticated_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE auth_sessions SET authenticated_at = created_at;
+
+ALTER TABLE auth_sessions ALTER COLUMN authenticated_at SET NOT NULL;
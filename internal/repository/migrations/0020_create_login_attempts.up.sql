@@ -0,0 +1,23 @@
+-- Failed logins in a row lock the customer out for a while; last_login_at is
+-- only set once a login completes, second factor included
+ALTER TABLE customers
+	ADD COLUMN failed_login_attempts INTEGER NOT NULL DEFAULT 0,
+	ADD COLUMN last_failed_login_at TIMESTAMP WITH TIME ZONE,
+	ADD COLUMN locked_until TIMESTAMP WITH TIME ZONE;
+
+-- Every customer login attempt, successful or not. Serves the customer's
+-- login history and counts the failures coming from a client IP.
+CREATE TABLE login_attempts (
+	id BIGSERIAL PRIMARY KEY,
+	customer_id VARCHAR(50) REFERENCES customers(customer_id) ON DELETE CASCADE, -- NULL when the identifier matched no customer
+	identifier VARCHAR(50) NOT NULL, -- Customer ID or account number as sent
+	ip_address VARCHAR(64),
+	user_agent TEXT,
+	success BOOLEAN NOT NULL,
+	failure_reason VARCHAR(30),
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_login_attempts_customer ON login_attempts(customer_id, created_at DESC);
+CREATE INDEX idx_login_attempts_ip_failures ON login_attempts(ip_address, created_at) WHERE NOT success;
+CREATE INDEX idx_login_attempts_created_at ON login_attempts(created_at);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS password_reset_tokens;
+DROP TABLE IF EXISTS password_history;
+
+ALTER TABLE customers DROP COLUMN IF EXISTS password_changed_at;
@@ -0,0 +1,25 @@
+ALTER TABLE customers ADD COLUMN password_changed_at TIMESTAMP WITH TIME ZONE;
+
+-- A customer's previous password hashes, newest first, so recent passwords
+-- cannot be chosen again
+CREATE TABLE password_history (
+	id BIGSERIAL PRIMARY KEY,
+	customer_id VARCHAR(50) NOT NULL REFERENCES customers(customer_id) ON DELETE CASCADE,
+	hash_password VARCHAR(255) NOT NULL,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX idx_password_history_customer ON password_history(customer_id, created_at DESC);
+
+-- Single-use password reset tokens; only their SHA-256 hash is stored
+CREATE TABLE password_reset_tokens (
+	id SERIAL PRIMARY KEY,
+	token_hash CHAR(64) NOT NULL UNIQUE,
+	customer_id VARCHAR(50) NOT NULL REFERENCES customers(customer_id) ON DELETE CASCADE,
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	used_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX idx_password_reset_tokens_customer ON password_reset_tokens(customer_id) WHERE used_at IS NULL;
+CREATE INDEX idx_password_reset_tokens_expires_at ON password_reset_tokens(expires_at);
@@ -0,0 +1,8 @@
+DELETE FROM auth_sessions WHERE subject_type = 'api_client';
+
+ALTER TABLE auth_sessions DROP CONSTRAINT chk_valid_session_subject_type;
+ALTER TABLE auth_sessions ADD CONSTRAINT chk_valid_session_subject_type
+	CHECK (subject_type IN ('customer', 'staff'));
+
+DROP TABLE IF EXISTS api_client_accounts;
+DROP TABLE IF EXISTS api_clients;
@@ -0,0 +1,31 @@
+-- Partner systems (ERPs, accounting packages) that obtain access tokens with
+-- the OAuth2 client credentials grant. Only a hash of the secret is kept.
+CREATE TABLE api_clients (
+	id SERIAL PRIMARY KEY,
+	client_id VARCHAR(50) UNIQUE NOT NULL,
+	name VARCHAR(100) NOT NULL,
+	secret_hash CHAR(64) NOT NULL,
+	scopes VARCHAR(200) NOT NULL, -- Space-separated, e.g. "transactions:read deposits:write"
+	allowed_ips TEXT NOT NULL DEFAULT '', -- Space-separated IPs and CIDR ranges; empty allows any address
+	status VARCHAR(20) NOT NULL DEFAULT 'ACTIVE',
+	created_by VARCHAR(50),
+	created_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	updated_at TIMESTAMP WITH TIME ZONE NOT NULL,
+	last_used_at TIMESTAMP WITH TIME ZONE,
+
+	CONSTRAINT chk_valid_api_client_status CHECK (status IN ('ACTIVE', 'DISABLED'))
+);
+
+-- The accounts a client may act on
+CREATE TABLE api_client_accounts (
+	client_id VARCHAR(50) NOT NULL REFERENCES api_clients(client_id) ON DELETE CASCADE,
+	account_number VARCHAR(20) NOT NULL REFERENCES accounts(account_number) ON DELETE CASCADE,
+
+	PRIMARY KEY (client_id, account_number)
+);
+
+-- Each token a client obtains opens a session, so disabling the client or
+-- rotating its secret revokes its tokens
+ALTER TABLE auth_sessions DROP CONSTRAINT chk_valid_session_subject_type;
+ALTER TABLE auth_sessions ADD CONSTRAINT chk_valid_session_subject_type
+	CHECK (subject_type IN ('customer', 'staff', 'api_client'));
@@ -0,0 +1,9 @@
+DELETE FROM customer_limits WHERE currency <> 'TND';
+ALTER TABLE customer_limits DROP CONSTRAINT uq_customer_limits_customer_type_currency;
+ALTER TABLE customer_limits ADD CONSTRAINT uq_customer_limits_customer_type UNIQUE (customer_id, account_type);
+ALTER TABLE customer_limits DROP COLUMN currency;
+
+DELETE FROM account_type_limits WHERE currency <> 'TND';
+ALTER TABLE account_type_limits DROP CONSTRAINT account_type_limits_pkey;
+ALTER TABLE account_type_limits ADD PRIMARY KEY (account_type);
+ALTER TABLE account_type_limits DROP COLUMN currency;
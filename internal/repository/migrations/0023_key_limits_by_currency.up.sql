@@ -0,0 +1,29 @@
+-- Limits are amounts in the account currency's minor unit, so the same
+-- figure means a thousandth of a dinar on a TND account and a cent on an
+-- EUR one. Defaults and customer limits are kept per currency instead.
+ALTER TABLE account_type_limits ADD COLUMN currency VARCHAR(3) NOT NULL DEFAULT 'TND';
+ALTER TABLE account_type_limits ALTER COLUMN currency DROP DEFAULT;
+ALTER TABLE account_type_limits DROP CONSTRAINT account_type_limits_pkey;
+ALTER TABLE account_type_limits ADD PRIMARY KEY (account_type, currency);
+
+-- Euro and dollar defaults, in cents
+INSERT INTO account_type_limits (account_type, currency, single_max, daily_max, monthly_max, hourly_count)
+SELECT l.account_type, c.currency, l.single_max, l.daily_max, l.monthly_max, l.hourly_count
+FROM (VALUES
+	('COMPTE_COURANT', 300000, 600000, 3000000, 20),
+	('COMPTE_EPARGNE', 150000, 150000, 600000, 10),
+	('COMPTE_ENTREPRISE', 3000000, 6000000, 30000000, 100),
+	('COMPTE_DEVISES', 1000000, 2000000, 10000000, 20)
+) AS l(account_type, single_max, daily_max, monthly_max, hourly_count)
+CROSS JOIN (VALUES ('EUR'), ('USD')) AS c(currency);
+
+-- A customer's limits apply in the currency of the accounts they were set on
+ALTER TABLE customer_limits ADD COLUMN currency VARCHAR(3);
+UPDATE customer_limits cl SET currency = COALESCE((
+	SELECT MIN(a.currency) FROM accounts a
+	WHERE a.customer_id = cl.customer_id AND a.account_type = cl.account_type
+), 'TND');
+ALTER TABLE customer_limits ALTER COLUMN currency SET NOT NULL;
+ALTER TABLE customer_limits DROP CONSTRAINT uq_customer_limits_customer_type;
+ALTER TABLE customer_limits ADD CONSTRAINT uq_customer_limits_customer_type_currency
+	UNIQUE (customer_id, account_type, currency);
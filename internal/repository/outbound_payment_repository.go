@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bank-api/internal/models"
+)
+
+type OutboundPaymentRepository interface {
+	Create(payment *models.OutboundPayment) error
+	GetByPaymentID(paymentID string) (*models.OutboundPayment, error)
+	// GetByPaymentIDForUpdate loads and row-locks a payment. Must be called on
+	// a repository bound to a transaction via WithTx.
+	GetByPaymentIDForUpdate(paymentID string) (*models.OutboundPayment, error)
+	GetByTransactionID(transactionID string) (*models.OutboundPayment, error)
+	// LockQueued row-locks up to limit QUEUED payments, oldest first, skipping
+	// payments already locked by another dispatcher. Must be called on a
+	// repository bound to a transaction via WithTx.
+	LockQueued(limit int) ([]*models.OutboundPayment, error)
+	// MarkSubmitted records that the gateway accepted a QUEUED payment
+	MarkSubmitted(paymentID, gatewayReference string) error
+	// RecordAttempt records a failed hand-off to the gateway; the payment
+	// stays QUEUED and is retried on the next dispatch
+	RecordAttempt(paymentID, lastError string) error
+	// Close moves an open payment to SETTLED or REJECTED
+	Close(paymentID, status, failureReason string) error
+	// WithTx returns a repository whose queries run inside tx
+	WithTx(tx *sql.Tx) OutboundPaymentRepository
+}
+
+type PostgresOutboundPaymentRepository struct {
+	db DBTX
+}
+
+func NewPostgresOutboundPaymentRepository(db *sql.DB) OutboundPaymentRepository {
+	return &PostgresOutboundPaymentRepository{db: db}
+}
+
+const outboundPaymentColumns = `
+	id, payment_id, transaction_id, debtor_account_number, debtor_iban, debtor_bic,
+	creditor_iban, creditor_bic, creditor_name, amount, currency, remittance_info,
+	status, gateway_reference, attempts, last_error, failure_reason, created_at,
+	updated_at, submitted_at, closed_at`
+
+func scanOutboundPayment(row rowScanner) (*models.OutboundPayment, error) {
+	payment := &models.OutboundPayment{}
+	var remittanceInfo, gatewayReference, lastError, failureReason sql.NullString
+	var submittedAt, closedAt sql.NullTime
+
+	err := row.Scan(
+		&payment.ID, &payment.PaymentID, &payment.TransactionID, &payment.DebtorAccountNumber,
+		&payment.DebtorIBAN, &payment.DebtorBIC, &payment.CreditorIBAN, &payment.CreditorBIC,
+		&payment.CreditorName, &payment.Amount, &payment.Currency, &remittanceInfo,
+		&payment.Status, &gatewayReference, &payment.Attempts, &lastError, &failureReason,
+		&payment.CreatedAt, &payment.UpdatedAt, &submittedAt, &closedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	payment.RemittanceInfo = remittanceInfo.String
+	payment.GatewayReference = gatewayReference.String
+	payment.LastError = lastError.String
+	payment.FailureReason = failureReason.String
+	if submittedAt.Valid {
+		payment.SubmittedAt = &submittedAt.Time
+	}
+	if closedAt.Valid {
+		payment.ClosedAt = &closedAt.Time
+	}
+
+	return payment, nil
+}
+
+func (r *PostgresOutboundPaymentRepository) WithTx(tx *sql.Tx) OutboundPaymentRepository {
+	return &PostgresOutboundPaymentRepository{db: tx}
+}
+
+func (r *PostgresOutboundPaymentRepository) Create(payment *models.OutboundPayment) error {
+	query := `
+		INSERT INTO outbound_payments (
+			payment_id, transaction_id, debtor_account_number, debtor_iban, debtor_bic,
+			creditor_iban, creditor_bic, creditor_name, amount, currency, remittance_info,
+			status, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''), $12, $13, $14
+		) RETURNING id`
+
+	return r.db.QueryRow(
+		query,
+		payment.PaymentID, payment.TransactionID, payment.DebtorAccountNumber, payment.DebtorIBAN,
+		payment.DebtorBIC, payment.CreditorIBAN, payment.CreditorBIC, payment.CreditorName,
+		payment.Amount, payment.Currency, payment.RemittanceInfo, payment.Status,
+		payment.CreatedAt, payment.UpdatedAt,
+	).Scan(&payment.ID)
+}
+
+func (r *PostgresOutboundPaymentRepository) GetByPaymentID(paymentID string) (*models.OutboundPayment, error) {
+	return r.getOne(`payment_id = $1`, paymentID, false)
+}
+
+func (r *PostgresOutboundPaymentRepository) GetByPaymentIDForUpdate(paymentID string) (*models.OutboundPayment, error) {
+	return r.getOne(`payment_id = $1`, paymentID, true)
+}
+
+func (r *PostgresOutboundPaymentRepository) GetByTransactionID(transactionID string) (*models.OutboundPayment, error) {
+	return r.getOne(`transaction_id = $1`, transactionID, false)
+}
+
+func (r *PostgresOutboundPaymentRepository) getOne(where, id string, forUpdate bool) (*models.OutboundPayment, error) {
+	query := `SELECT ` + outboundPaymentColumns + ` FROM outbound_payments WHERE ` + where
+	if forUpdate {
+		query += ` FOR UPDATE`
+	}
+
+	payment, err := scanOutboundPayment(r.db.QueryRow(query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("outbound payment %s not found", id)
+		}
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+func (r *PostgresOutboundPaymentRepository) LockQueued(limit int) ([]*models.OutboundPayment, error) {
+	query := `
+		SELECT ` + outboundPaymentColumns + ` FROM outbound_payments
+		WHERE status = $1
+		ORDER BY created_at
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED`
+
+	rows, err := r.db.Query(query, models.OutboundPaymentStatusQueued, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var payments []*models.OutboundPayment
+	for rows.Next() {
+		payment, err := scanOutboundPayment(rows)
+		if err != nil {
+			return nil, err
+		}
+		payments = append(payments, payment)
+	}
+
+	return payments, rows.Err()
+}
+
+func (r *PostgresOutboundPaymentRepository) MarkSubmitted(paymentID, gatewayReference string) error {
+	query := `
+		UPDATE outbound_payments
+		SET status = $1, gateway_reference = $2, attempts = attempts + 1, last_error = NULL,
+			submitted_at = $3, updated_at = $3
+		WHERE payment_id = $4 AND status = $5`
+
+	result, err := r.db.Exec(query, models.OutboundPaymentStatusSubmitted, gatewayReference,
+		time.Now().UTC(), paymentID, models.OutboundPaymentStatusQueued)
+	return expectOnePayment(result, err, paymentID)
+}
+
+func (r *PostgresOutboundPaymentRepository) RecordAttempt(paymentID, lastError string) error {
+	query := `
+		UPDATE outbound_payments
+		SET attempts = attempts + 1, last_error = $1, updated_at = $2
+		WHERE payment_id = $3`
+
+	_, err := r.db.Exec(query, lastError, time.Now().UTC(), paymentID)
+	return err
+}
+
+func (r *PostgresOutboundPaymentRepository) Close(paymentID, status, failureReason string) error {
+	query := `
+		UPDATE outbound_payments
+		SET status = $1, failure_reason = NULLIF($2, ''), closed_at = $3, updated_at = $3
+		WHERE payment_id = $4 AND status IN ($5, $6)`
+
+	result, err := r.db.Exec(query, status, failureReason, time.Now().UTC(), paymentID,
+		models.OutboundPaymentStatusQueued, models.OutboundPaymentStatusSubmitted)
+	return expectOnePayment(result, err, paymentID)
+}
+
+// expectOnePayment checks that an update hit the payment in the expected status
+func expectOnePayment(result sql.Result, err error, paymentID string) error {
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("outbound payment %s is not in the expected status", paymentID)
+	}
+
+	return nil
+}
@@ -0,0 +1,315 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bank-api/internal/models"
+)
+
+type OverdraftRepository interface {
+	CreateFacility(facility *models.OverdraftFacility) error
+	GetFacility(id int) (*models.OverdraftFacility, error)
+	// LockFacility loads and row-locks a facility. Must be called on a
+	// repository bound to a transaction via WithTx.
+	LockFacility(id int) (*models.OverdraftFacility, error)
+	// GetAccountFacilities returns an account's facilities, newest first
+	GetAccountFacilities(accountID int) ([]*models.OverdraftFacility, error)
+	// GetFacilities returns the facilities with a status, or all of them if
+	// status is empty, oldest first
+	GetFacilities(status string, limit, offset int) ([]*models.OverdraftFacility, error)
+	// GetApproved returns the facility in force on an account, or nil
+	GetApproved(accountID int) (*models.OverdraftFacility, error)
+	// UpdateDecision stores a facility's status, approved terms and decision
+	UpdateDecision(facility *models.OverdraftFacility) error
+	// AccountsToAccrue lists up to limit accounts opened by the end of the
+	// business date, with a debit interest rate, that have an overdraft or a
+	// negative balance and no accrual for the date
+	AccountsToAccrue(businessDate time.Time, limit int) ([]*models.Account, error)
+	// CreateAccrual stores an accrual and reports whether it did; it does
+	// nothing if the account already has one for the business date
+	CreateAccrual(accrual *models.OverdraftInterestAccrual) (bool, error)
+	// LastAccrualDate returns the latest business date accrued, or the zero
+	// time if none was
+	LastAccrualDate() (time.Time, error)
+	// AccountsToCharge lists the IDs of up to limit accounts with uncharged
+	// accruals dated before the given date
+	AccountsToCharge(before time.Time, limit int) ([]int, error)
+	// LockUncharged loads and row-locks an account's uncharged accruals dated
+	// before the given date, oldest first
+	LockUncharged(accountID int, before time.Time) ([]*models.OverdraftInterestAccrual, error)
+	// MarkCharged records that accruals were charged, by transactionID if any
+	// interest was due
+	MarkCharged(accrualIDs []int64, transactionID string, chargedAt time.Time) error
+	// WithTx returns a repository whose queries run inside tx
+	WithTx(tx *sql.Tx) OverdraftRepository
+}
+
+type PostgresOverdraftRepository struct {
+	db DBTX
+}
+
+func NewPostgresOverdraftRepository(db *sql.DB) OverdraftRepository {
+	return &PostgresOverdraftRepository{db: db}
+}
+
+func (r *PostgresOverdraftRepository) WithTx(tx *sql.Tx) OverdraftRepository {
+	return &PostgresOverdraftRepository{db: tx}
+}
+
+const overdraftFacilityColumns = `
+	id, account_id, account_number, requested_limit, approved_limit, rate_bps, status, reason,
+	requested_by, decided_by, decision_note, created_at, decided_at, ended_at`
+
+func scanOverdraftFacility(row rowScanner) (*models.OverdraftFacility, error) {
+	facility := &models.OverdraftFacility{}
+	var approvedLimit, rateBps sql.NullInt64
+	var decidedBy sql.NullString
+	var decidedAt, endedAt sql.NullTime
+
+	err := row.Scan(
+		&facility.ID, &facility.AccountID, &facility.AccountNumber, &facility.RequestedLimit,
+		&approvedLimit, &rateBps, &facility.Status, &facility.Reason, &facility.RequestedBy,
+		&decidedBy, &facility.DecisionNote, &facility.CreatedAt, &decidedAt, &endedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	facility.ApprovedLimit = approvedLimit.Int64
+	facility.RateBps = int(rateBps.Int64)
+	facility.DecidedBy = decidedBy.String
+	if decidedAt.Valid {
+		facility.DecidedAt = &decidedAt.Time
+	}
+	if endedAt.Valid {
+		facility.EndedAt = &endedAt.Time
+	}
+	return facility, nil
+}
+
+func scanOverdraftFacilities(rows *sql.Rows) ([]*models.OverdraftFacility, error) {
+	defer rows.Close()
+
+	var facilities []*models.OverdraftFacility
+	for rows.Next() {
+		facility, err := scanOverdraftFacility(rows)
+		if err != nil {
+			return nil, err
+		}
+		facilities = append(facilities, facility)
+	}
+
+	return facilities, rows.Err()
+}
+
+func (r *PostgresOverdraftRepository) CreateFacility(facility *models.OverdraftFacility) error {
+	query := `
+		INSERT INTO overdraft_facilities (
+			account_id, account_number, requested_limit, status, reason, requested_by, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id`
+
+	return r.db.QueryRow(
+		query, facility.AccountID, facility.AccountNumber, facility.RequestedLimit, facility.Status,
+		facility.Reason, facility.RequestedBy, facility.CreatedAt,
+	).Scan(&facility.ID)
+}
+
+func (r *PostgresOverdraftRepository) GetFacility(id int) (*models.OverdraftFacility, error) {
+	query := `SELECT ` + overdraftFacilityColumns + ` FROM overdraft_facilities WHERE id = $1`
+
+	facility, err := scanOverdraftFacility(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("overdraft facility %d not found", id)
+	}
+	return facility, err
+}
+
+func (r *PostgresOverdraftRepository) LockFacility(id int) (*models.OverdraftFacility, error) {
+	query := `SELECT ` + overdraftFacilityColumns + ` FROM overdraft_facilities WHERE id = $1 FOR UPDATE`
+
+	facility, err := scanOverdraftFacility(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("overdraft facility %d not found", id)
+	}
+	return facility, err
+}
+
+func (r *PostgresOverdraftRepository) GetAccountFacilities(accountID int) ([]*models.OverdraftFacility, error) {
+	query := `SELECT ` + overdraftFacilityColumns + ` FROM overdraft_facilities WHERE account_id = $1 ORDER BY created_at DESC, id DESC`
+
+	rows, err := r.db.Query(query, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanOverdraftFacilities(rows)
+}
+
+func (r *PostgresOverdraftRepository) GetFacilities(status string, limit, offset int) ([]*models.OverdraftFacility, error) {
+	query := `
+		SELECT ` + overdraftFacilityColumns + ` FROM overdraft_facilities
+		WHERE ($1 = '' OR status = $1)
+		ORDER BY created_at, id
+		LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.Query(query, status, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanOverdraftFacilities(rows)
+}
+
+func (r *PostgresOverdraftRepository) GetApproved(accountID int) (*models.OverdraftFacility, error) {
+	query := `SELECT ` + overdraftFacilityColumns + ` FROM overdraft_facilities WHERE account_id = $1 AND status = 'APPROVED'`
+
+	facility, err := scanOverdraftFacility(r.db.QueryRow(query, accountID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return facility, err
+}
+
+func (r *PostgresOverdraftRepository) UpdateDecision(facility *models.OverdraftFacility) error {
+	query := `
+		UPDATE overdraft_facilities
+		SET status = $1, approved_limit = NULLIF($2::BIGINT, 0), rate_bps = $3, decided_by = NULLIF($4, ''),
+			decision_note = $5, decided_at = $6, ended_at = $7
+		WHERE id = $8`
+
+	// Only approved facilities, in force or revoked since, have terms
+	var rateBps sql.NullInt64
+	if facility.ApprovedLimit > 0 {
+		rateBps = sql.NullInt64{Int64: int64(facility.RateBps), Valid: true}
+	}
+
+	_, err := r.db.Exec(
+		query, facility.Status, facility.ApprovedLimit, rateBps, facility.DecidedBy,
+		facility.DecisionNote, facility.DecidedAt, facility.EndedAt, facility.ID,
+	)
+	return err
+}
+
+func (r *PostgresOverdraftRepository) AccountsToAccrue(businessDate time.Time, limit int) ([]*models.Account, error) {
+	query := `
+		SELECT ` + accountColumns + ` FROM accounts a
+		WHERE a.created_at < $1::date + 1 AND a.status <> 'CLOSED'
+		AND a.overdraft_rate_bps > 0 AND (a.overdraft_limit > 0 OR a.balance < 0)
+		AND NOT EXISTS (
+			SELECT 1 FROM overdraft_interest_accruals i
+			WHERE i.account_id = a.id AND i.business_date = $1
+		)
+		ORDER BY a.id
+		LIMIT $2`
+
+	rows, err := r.db.Query(query, businessDate, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanAccounts(rows)
+}
+
+func (r *PostgresOverdraftRepository) CreateAccrual(accrual *models.OverdraftInterestAccrual) (bool, error) {
+	query := `
+		INSERT INTO overdraft_interest_accruals (
+			account_id, account_number, business_date, balance, rate_bps, amount_micros, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (account_id, business_date) DO NOTHING
+		RETURNING id`
+
+	err := r.db.QueryRow(
+		query, accrual.AccountID, accrual.AccountNumber, accrual.BusinessDate, accrual.Balance,
+		accrual.RateBps, accrual.AmountMicros, accrual.CreatedAt,
+	).Scan(&accrual.ID)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *PostgresOverdraftRepository) LastAccrualDate() (time.Time, error) {
+	var last sql.NullTime
+	if err := r.db.QueryRow(`SELECT MAX(business_date) FROM overdraft_interest_accruals`).Scan(&last); err != nil {
+		return time.Time{}, err
+	}
+	if !last.Valid {
+		return time.Time{}, nil
+	}
+	return last.Time.UTC(), nil
+}
+
+func (r *PostgresOverdraftRepository) AccountsToCharge(before time.Time, limit int) ([]int, error) {
+	query := `
+		SELECT DISTINCT account_id FROM overdraft_interest_accruals
+		WHERE charged_at IS NULL AND business_date < $1
+		ORDER BY account_id
+		LIMIT $2`
+
+	rows, err := r.db.Query(query, before, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accountIDs []int
+	for rows.Next() {
+		var accountID int
+		if err := rows.Scan(&accountID); err != nil {
+			return nil, err
+		}
+		accountIDs = append(accountIDs, accountID)
+	}
+
+	return accountIDs, rows.Err()
+}
+
+func (r *PostgresOverdraftRepository) LockUncharged(accountID int, before time.Time) ([]*models.OverdraftInterestAccrual, error) {
+	query := `
+		SELECT id, account_id, account_number, business_date, balance, rate_bps, amount_micros, created_at
+		FROM overdraft_interest_accruals
+		WHERE account_id = $1 AND charged_at IS NULL AND business_date < $2
+		ORDER BY business_date
+		FOR UPDATE`
+
+	rows, err := r.db.Query(query, accountID, before)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accruals []*models.OverdraftInterestAccrual
+	for rows.Next() {
+		accrual := &models.OverdraftInterestAccrual{}
+		err := rows.Scan(
+			&accrual.ID, &accrual.AccountID, &accrual.AccountNumber, &accrual.BusinessDate,
+			&accrual.Balance, &accrual.RateBps, &accrual.AmountMicros, &accrual.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		accrual.BusinessDate = accrual.BusinessDate.UTC()
+		accruals = append(accruals, accrual)
+	}
+
+	return accruals, rows.Err()
+}
+
+func (r *PostgresOverdraftRepository) MarkCharged(accrualIDs []int64, transactionID string, chargedAt time.Time) error {
+	query := `UPDATE overdraft_interest_accruals SET charged_at = $1, transaction_id = NULLIF($2, '') WHERE id = $3`
+
+	for _, accrualID := range accrualIDs {
+		if _, err := r.db.Exec(query, chargedAt, transactionID, accrualID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bank-api/internal/models"
+)
+
+type PasswordRepository interface {
+	// AddHistory records a password hash the customer no longer uses, keeping
+	// only their keep most recent ones
+	AddHistory(customerID, hashPassword string, createdAt time.Time, keep int) error
+	// RecentHashes returns up to limit of the customer's previous password
+	// hashes, newest first
+	RecentHashes(customerID string, limit int) ([]string, error)
+	CreateResetToken(token *models.PasswordResetToken) error
+	// GetResetTokenForUpdate loads and row-locks a reset token by its hash.
+	// Must be called on a repository bound to a transaction via WithTx.
+	GetResetTokenForUpdate(tokenHash string) (*models.PasswordResetToken, error)
+	MarkResetTokenUsed(id int, usedAt time.Time) error
+	// InvalidateResetTokens uses up the customer's outstanding reset tokens
+	InvalidateResetTokens(customerID string, at time.Time) error
+	// PurgeExpiredResetTokens deletes reset tokens that have expired
+	PurgeExpiredResetTokens() (int64, error)
+	// WithTx returns a repository whose queries run inside tx
+	WithTx(tx *sql.Tx) PasswordRepository
+}
+
+type PostgresPasswordRepository struct {
+	db DBTX
+}
+
+func NewPostgresPasswordRepository(db *sql.DB) PasswordRepository {
+	return &PostgresPasswordRepository{db: db}
+}
+
+func (r *PostgresPasswordRepository) WithTx(tx *sql.Tx) PasswordRepository {
+	return &PostgresPasswordRepository{db: tx}
+}
+
+func (r *PostgresPasswordRepository) AddHistory(customerID, hashPassword string, createdAt time.Time, keep int) error {
+	_, err := r.db.Exec(
+		`INSERT INTO password_history (customer_id, hash_password, created_at) VALUES ($1, $2, $3)`,
+		customerID, hashPassword, createdAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		DELETE FROM password_history
+		WHERE customer_id = $1 AND id NOT IN (
+			SELECT id FROM password_history WHERE customer_id = $1
+			ORDER BY created_at DESC, id DESC
+			LIMIT $2
+		)`
+
+	_, err = r.db.Exec(query, customerID, keep)
+	return err
+}
+
+func (r *PostgresPasswordRepository) RecentHashes(customerID string, limit int) ([]string, error) {
+	query := `
+		SELECT hash_password FROM password_history
+		WHERE customer_id = $1
+		ORDER BY created_at DESC, id DESC
+		LIMIT $2`
+
+	rows, err := r.db.Query(query, customerID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+
+	return hashes, rows.Err()
+}
+
+func (r *PostgresPasswordRepository) CreateResetToken(token *models.PasswordResetToken) error {
+	query := `
+		INSERT INTO password_reset_tokens (token_hash, customer_id, created_at, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`
+
+	return r.db.QueryRow(
+		query, token.TokenHash, token.CustomerID, token.CreatedAt, token.ExpiresAt,
+	).Scan(&token.ID)
+}
+
+func (r *PostgresPasswordRepository) GetResetTokenForUpdate(tokenHash string) (*models.PasswordResetToken, error) {
+	query := `
+		SELECT id, token_hash, customer_id, created_at, expires_at, used_at
+		FROM password_reset_tokens WHERE token_hash = $1
+		FOR UPDATE`
+
+	token := &models.PasswordResetToken{}
+	var usedAt sql.NullTime
+
+	err := r.db.QueryRow(query, tokenHash).Scan(
+		&token.ID, &token.TokenHash, &token.CustomerID, &token.CreatedAt, &token.ExpiresAt, &usedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("password reset token not found")
+		}
+		return nil, err
+	}
+
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+
+	return token, nil
+}
+
+func (r *PostgresPasswordRepository) MarkResetTokenUsed(id int, usedAt time.Time) error {
+	_, err := r.db.Exec(`UPDATE password_reset_tokens SET used_at = $1 WHERE id = $2`, usedAt, id)
+	return err
+}
+
+func (r *PostgresPasswordRepository) InvalidateResetTokens(customerID string, at time.Time) error {
+	query := `UPDATE password_reset_tokens SET used_at = $1 WHERE customer_id = $2 AND used_at IS NULL`
+	_, err := r.db.Exec(query, at, customerID)
+	return err
+}
+
+func (r *PostgresPasswordRepository) PurgeExpiredResetTokens() (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM password_reset_tokens WHERE expires_at < $1`, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,240 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bank-api/internal/models"
+)
+
+type SessionRepository interface {
+	CreateSession(session *models.AuthSession) error
+	// GetSessionForUpdate loads and row-locks a session. Must be called on a
+	// repository bound to a transaction via WithTx.
+	GetSessionForUpdate(sessionID string) (*models.AuthSession, error)
+	TouchSession(sessionID string) error
+	// UpdateAuthentication records that a session authenticated again, e.g.
+	// stepped up with a second factor
+	UpdateAuthentication(sessionID string, methods []string, authenticatedAt time.Time) error
+	RevokeSession(sessionID, reason string) error
+	// RevokeSubjectSessions revokes every live session of a customer or staff
+	// user but exceptSessionID, if set
+	RevokeSubjectSessions(subjectType, subjectID, exceptSessionID, reason string) (int64, error)
+	CreateRefreshToken(token *models.RefreshToken) error
+	// GetRefreshTokenForUpdate loads and row-locks a refresh token by its hash.
+	// Must be called on a repository bound to a transaction via WithTx.
+	GetRefreshTokenForUpdate(tokenHash string) (*models.RefreshToken, error)
+	MarkRefreshTokenUsed(tokenHash string) error
+	// RevokeAccessToken puts an access token on the revocation list until it expires
+	RevokeAccessToken(jti string, expiresAt time.Time) error
+	// IsRevoked reports whether the access token or its session has been revoked
+	IsRevoked(jti, sessionID string) (bool, error)
+	// PurgeExpired deletes expired sessions, refresh tokens and revocation entries
+	PurgeExpired() (int64, error)
+	// WithTx returns a repository whose queries run inside tx
+	WithTx(tx *sql.Tx) SessionRepository
+}
+
+type PostgresSessionRepository struct {
+	db DBTX
+}
+
+func NewPostgresSessionRepository(db *sql.DB) SessionRepository {
+	return &PostgresSessionRepository{db: db}
+}
+
+func (r *PostgresSessionRepository) WithTx(tx *sql.Tx) SessionRepository {
+	return &PostgresSessionRepository{db: tx}
+}
+
+func (r *PostgresSessionRepository) CreateSession(session *models.AuthSession) error {
+	query := `
+		INSERT INTO auth_sessions (
+			session_id, subject_type, subject_id, user_agent, ip_address,
+			created_at, last_used_at, expires_at, auth_methods, authenticated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id`
+
+	return r.db.QueryRow(
+		query,
+		session.SessionID, session.SubjectType, session.SubjectID, session.UserAgent, session.IPAddress,
+		session.CreatedAt, session.LastUsedAt, session.ExpiresAt,
+		strings.Join(session.AuthMethods, " "), session.AuthenticatedAt,
+	).Scan(&session.ID)
+}
+
+func (r *PostgresSessionRepository) GetSessionForUpdate(sessionID string) (*models.AuthSession, error) {
+	query := `
+		SELECT id, session_id, subject_type, subject_id, user_agent, ip_address,
+			created_at, last_used_at, expires_at, revoked_at, revoked_reason,
+			auth_methods, authenticated_at
+		FROM auth_sessions WHERE session_id = $1
+		FOR UPDATE`
+
+	session := &models.AuthSession{}
+	var userAgent, ipAddress, revokedReason sql.NullString
+	var revokedAt sql.NullTime
+	var authMethods string
+
+	err := r.db.QueryRow(query, sessionID).Scan(
+		&session.ID, &session.SessionID, &session.SubjectType, &session.SubjectID, &userAgent, &ipAddress,
+		&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt, &revokedAt, &revokedReason,
+		&authMethods, &session.AuthenticatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("session %s not found", sessionID)
+		}
+		return nil, err
+	}
+
+	session.UserAgent = userAgent.String
+	session.IPAddress = ipAddress.String
+	session.RevokedReason = revokedReason.String
+	session.AuthMethods = strings.Fields(authMethods)
+	if revokedAt.Valid {
+		session.RevokedAt = &revokedAt.Time
+	}
+
+	return session, nil
+}
+
+func (r *PostgresSessionRepository) TouchSession(sessionID string) error {
+	query := `UPDATE auth_sessions SET last_used_at = $1 WHERE session_id = $2`
+	_, err := r.db.Exec(query, time.Now().UTC(), sessionID)
+	return err
+}
+
+func (r *PostgresSessionRepository) UpdateAuthentication(sessionID string, methods []string, authenticatedAt time.Time) error {
+	query := `UPDATE auth_sessions SET auth_methods = $1, authenticated_at = $2 WHERE session_id = $3`
+	_, err := r.db.Exec(query, strings.Join(methods, " "), authenticatedAt, sessionID)
+	return err
+}
+
+func (r *PostgresSessionRepository) RevokeSession(sessionID, reason string) error {
+	query := `
+		UPDATE auth_sessions SET revoked_at = $1, revoked_reason = $2
+		WHERE session_id = $3 AND revoked_at IS NULL`
+
+	_, err := r.db.Exec(query, time.Now().UTC(), reason, sessionID)
+	return err
+}
+
+func (r *PostgresSessionRepository) RevokeSubjectSessions(subjectType, subjectID, exceptSessionID, reason string) (int64, error) {
+	query := `
+		UPDATE auth_sessions SET revoked_at = $1, revoked_reason = $2
+		WHERE subject_type = $3 AND subject_id = $4 AND revoked_at IS NULL
+		AND session_id <> $5`
+
+	result, err := r.db.Exec(query, time.Now().UTC(), reason, subjectType, subjectID, exceptSessionID)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *PostgresSessionRepository) CreateRefreshToken(token *models.RefreshToken) error {
+	query := `
+		INSERT INTO refresh_tokens (token_hash, session_id, created_at, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id`
+
+	return r.db.QueryRow(query, token.TokenHash, token.SessionID, token.CreatedAt, token.ExpiresAt).Scan(&token.ID)
+}
+
+func (r *PostgresSessionRepository) GetRefreshTokenForUpdate(tokenHash string) (*models.RefreshToken, error) {
+	query := `
+		SELECT id, token_hash, session_id, created_at, expires_at, used_at
+		FROM refresh_tokens WHERE token_hash = $1
+		FOR UPDATE`
+
+	token := &models.RefreshToken{}
+	var usedAt sql.NullTime
+
+	err := r.db.QueryRow(query, tokenHash).Scan(
+		&token.ID, &token.TokenHash, &token.SessionID, &token.CreatedAt, &token.ExpiresAt, &usedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("refresh token not found")
+		}
+		return nil, err
+	}
+
+	if usedAt.Valid {
+		token.UsedAt = &usedAt.Time
+	}
+
+	return token, nil
+}
+
+func (r *PostgresSessionRepository) MarkRefreshTokenUsed(tokenHash string) error {
+	query := `UPDATE refresh_tokens SET used_at = $1 WHERE token_hash = $2 AND used_at IS NULL`
+
+	result, err := r.db.Exec(query, time.Now().UTC(), tokenHash)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("refresh token already used")
+	}
+
+	return nil
+}
+
+func (r *PostgresSessionRepository) RevokeAccessToken(jti string, expiresAt time.Time) error {
+	query := `
+		INSERT INTO revoked_tokens (jti, revoked_at, expires_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (jti) DO NOTHING`
+
+	_, err := r.db.Exec(query, jti, time.Now().UTC(), expiresAt)
+	return err
+}
+
+func (r *PostgresSessionRepository) IsRevoked(jti, sessionID string) (bool, error) {
+	query := `
+		SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1)
+			OR NOT EXISTS (
+				SELECT 1 FROM auth_sessions
+				WHERE session_id = $2 AND revoked_at IS NULL AND expires_at > $3
+			)`
+
+	var revoked bool
+	err := r.db.QueryRow(query, jti, sessionID, time.Now().UTC()).Scan(&revoked)
+	return revoked, err
+}
+
+func (r *PostgresSessionRepository) PurgeExpired() (int64, error) {
+	now := time.Now().UTC()
+
+	result, err := r.db.Exec(`DELETE FROM revoked_tokens WHERE expires_at < $1`, now)
+	if err != nil {
+		return 0, err
+	}
+	purged, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	// Refresh tokens go with their session (ON DELETE CASCADE)
+	result, err = r.db.Exec(`DELETE FROM auth_sessions WHERE expires_at < $1`, now)
+	if err != nil {
+		return 0, err
+	}
+	sessions, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return purged + sessions, nil
+}
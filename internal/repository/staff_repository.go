@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bank-api/internal/models"
+)
+
+type StaffRepository interface {
+	Create(user *models.StaffUser) error
+	GetByStaffID(staffID string) (*models.StaffUser, error)
+	GetByUsername(username string) (*models.StaffUser, error)
+	GetAll(limit, offset int) ([]*models.StaffUser, error)
+	UpdateStatus(staffID, status string) error
+	UpdateLastLogin(staffID string) error
+	CountActiveByRole(role string) (int, error)
+}
+
+type PostgresStaffRepository struct {
+	db DBTX
+}
+
+func NewPostgresStaffRepository(db *sql.DB) StaffRepository {
+	return &PostgresStaffRepository{db: db}
+}
+
+const staffColumns = `
+	id, staff_id, username, email, full_name, role, hash_password, status,
+	created_at, updated_at, last_login_at`
+
+func scanStaffUser(row rowScanner) (*models.StaffUser, error) {
+	user := &models.StaffUser{}
+	var lastLoginAt sql.NullTime
+
+	err := row.Scan(
+		&user.ID, &user.StaffID, &user.Username, &user.Email, &user.FullName, &user.Role,
+		&user.HashPassword, &user.Status, &user.CreatedAt, &user.UpdatedAt, &lastLoginAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if lastLoginAt.Valid {
+		user.LastLoginAt = &lastLoginAt.Time
+	}
+
+	return user, nil
+}
+
+func (r *PostgresStaffRepository) Create(user *models.StaffUser) error {
+	query := `
+		INSERT INTO staff_users (
+			staff_id, username, email, full_name, role, hash_password, status, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9
+		) RETURNING id`
+
+	return r.db.QueryRow(
+		query,
+		user.StaffID, user.Username, user.Email, user.FullName, user.Role,
+		user.HashPassword, user.Status, user.CreatedAt, user.UpdatedAt,
+	).Scan(&user.ID)
+}
+
+func (r *PostgresStaffRepository) GetByStaffID(staffID string) (*models.StaffUser, error) {
+	query := `SELECT ` + staffColumns + ` FROM staff_users WHERE staff_id = $1`
+
+	user, err := scanStaffUser(r.db.QueryRow(query, staffID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("staff user %s not found", staffID)
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *PostgresStaffRepository) GetByUsername(username string) (*models.StaffUser, error) {
+	query := `SELECT ` + staffColumns + ` FROM staff_users WHERE username = $1`
+
+	user, err := scanStaffUser(r.db.QueryRow(query, username))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("staff user %s not found", username)
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (r *PostgresStaffRepository) GetAll(limit, offset int) ([]*models.StaffUser, error) {
+	query := `SELECT ` + staffColumns + ` FROM staff_users ORDER BY created_at DESC LIMIT $1 OFFSET $2`
+
+	rows, err := r.db.Query(query, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*models.StaffUser
+	for rows.Next() {
+		user, err := scanStaffUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+func (r *PostgresStaffRepository) UpdateStatus(staffID, status string) error {
+	query := `UPDATE staff_users SET status = $1, updated_at = $2 WHERE staff_id = $3`
+
+	result, err := r.db.Exec(query, status, time.Now().UTC(), staffID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("staff user %s not found", staffID)
+	}
+
+	return nil
+}
+
+func (r *PostgresStaffRepository) UpdateLastLogin(staffID string) error {
+	query := `UPDATE staff_users SET last_login_at = $1 WHERE staff_id = $2`
+	_, err := r.db.Exec(query, time.Now().UTC(), staffID)
+	return err
+}
+
+func (r *PostgresStaffRepository) CountActiveByRole(role string) (int, error) {
+	var count int
+	err := r.db.QueryRow(
+		`SELECT COUNT(*) FROM staff_users WHERE role = $1 AND status = $2`,
+		role, models.StaffStatusActive,
+	).Scan(&count)
+	return count, err
+}
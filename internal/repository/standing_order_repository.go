@@ -0,0 +1,293 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bank-api/internal/models"
+)
+
+type StandingOrderRepository interface {
+	Create(order *models.StandingOrder) error
+	GetByOrderID(orderID string) (*models.StandingOrder, error)
+	// GetByOrderIDForUpdate loads and row-locks an order. Must be called on a
+	// repository bound to a transaction via WithTx.
+	GetByOrderIDForUpdate(orderID string) (*models.StandingOrder, error)
+	GetByAccountNumber(accountNumber string) ([]*models.StandingOrder, error)
+	// LockDue row-locks up to limit active orders whose next attempt is due,
+	// skipping orders locked by another replica. Must be called on a
+	// repository bound to a transaction via WithTx.
+	LockDue(now time.Time, limit int) ([]*models.StandingOrder, error)
+	// UpdateSchedule saves an order's status and schedule
+	UpdateSchedule(order *models.StandingOrder) error
+	CreateExecution(execution *models.StandingOrderExecution) error
+	// GetRunningExecution returns the order's unfinished execution, if any
+	GetRunningExecution(orderID string) (*models.StandingOrderExecution, error)
+	// FinishExecution records the outcome of a RUNNING execution
+	FinishExecution(id int, status, errorMessage string) error
+	GetExecutions(orderID string, limit, offset int) ([]*models.StandingOrderExecution, error)
+	// WithTx returns a repository whose queries run inside tx
+	WithTx(tx *sql.Tx) StandingOrderRepository
+}
+
+type PostgresStandingOrderRepository struct {
+	db DBTX
+}
+
+func NewPostgresStandingOrderRepository(db *sql.DB) StandingOrderRepository {
+	return &PostgresStandingOrderRepository{db: db}
+}
+
+const standingOrderColumns = `
+	id, order_id, from_account_number, to_account_number, to_iban, to_bic,
+	beneficiary_name, amount, currency, description, reference, frequency,
+	day_of_month, start_date, end_date, max_occurrences, occurrences,
+	next_run_date, next_attempt_at, retry_count, status, last_error,
+	created_at, updated_at`
+
+func scanStandingOrder(row rowScanner) (*models.StandingOrder, error) {
+	order := &models.StandingOrder{}
+	var toAccountNumber, toIBAN, toBIC, beneficiaryName, description, reference, lastError sql.NullString
+	var dayOfMonth, maxOccurrences sql.NullInt64
+	var endDate, nextRunDate, nextAttemptAt sql.NullTime
+
+	err := row.Scan(
+		&order.ID, &order.OrderID, &order.FromAccountNumber, &toAccountNumber, &toIBAN, &toBIC,
+		&beneficiaryName, &order.Amount, &order.Currency, &description, &reference, &order.Frequency,
+		&dayOfMonth, &order.StartDate, &endDate, &maxOccurrences, &order.Occurrences,
+		&nextRunDate, &nextAttemptAt, &order.RetryCount, &order.Status, &lastError,
+		&order.CreatedAt, &order.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	order.ToAccountNumber = toAccountNumber.String
+	order.ToIBAN = toIBAN.String
+	order.ToBIC = toBIC.String
+	order.BeneficiaryName = beneficiaryName.String
+	order.Description = description.String
+	order.Reference = reference.String
+	order.LastError = lastError.String
+	order.DayOfMonth = int(dayOfMonth.Int64)
+	order.MaxOccurrences = int(maxOccurrences.Int64)
+	if endDate.Valid {
+		order.EndDate = &endDate.Time
+	}
+	if nextRunDate.Valid {
+		order.NextRunDate = &nextRunDate.Time
+	}
+	if nextAttemptAt.Valid {
+		order.NextAttemptAt = &nextAttemptAt.Time
+	}
+
+	return order, nil
+}
+
+func scanStandingOrders(rows *sql.Rows) ([]*models.StandingOrder, error) {
+	defer rows.Close()
+
+	var orders []*models.StandingOrder
+	for rows.Next() {
+		order, err := scanStandingOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+
+	return orders, rows.Err()
+}
+
+func (r *PostgresStandingOrderRepository) WithTx(tx *sql.Tx) StandingOrderRepository {
+	return &PostgresStandingOrderRepository{db: tx}
+}
+
+func (r *PostgresStandingOrderRepository) Create(order *models.StandingOrder) error {
+	query := `
+		INSERT INTO standing_orders (
+			order_id, from_account_number, to_account_number, to_iban, to_bic, beneficiary_name,
+			amount, currency, description, reference, frequency, day_of_month, start_date,
+			end_date, max_occurrences, next_run_date, next_attempt_at, status, created_at, updated_at
+		) VALUES (
+			$1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), $7, $8,
+			NULLIF($9, ''), NULLIF($10, ''), $11, NULLIF($12, 0), $13, $14, NULLIF($15, 0),
+			$16, $17, $18, $19, $20
+		) RETURNING id`
+
+	return r.db.QueryRow(
+		query,
+		order.OrderID, order.FromAccountNumber, order.ToAccountNumber, order.ToIBAN, order.ToBIC,
+		order.BeneficiaryName, order.Amount, order.Currency, order.Description, order.Reference,
+		order.Frequency, order.DayOfMonth, order.StartDate, order.EndDate, order.MaxOccurrences,
+		order.NextRunDate, order.NextAttemptAt, order.Status, order.CreatedAt, order.UpdatedAt,
+	).Scan(&order.ID)
+}
+
+func (r *PostgresStandingOrderRepository) GetByOrderID(orderID string) (*models.StandingOrder, error) {
+	return r.getByOrderID(orderID, false)
+}
+
+func (r *PostgresStandingOrderRepository) GetByOrderIDForUpdate(orderID string) (*models.StandingOrder, error) {
+	return r.getByOrderID(orderID, true)
+}
+
+func (r *PostgresStandingOrderRepository) getByOrderID(orderID string, forUpdate bool) (*models.StandingOrder, error) {
+	query := `SELECT ` + standingOrderColumns + ` FROM standing_orders WHERE order_id = $1`
+	if forUpdate {
+		query += ` FOR UPDATE`
+	}
+
+	order, err := scanStandingOrder(r.db.QueryRow(query, orderID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("standing order %s not found", orderID)
+		}
+		return nil, err
+	}
+
+	return order, nil
+}
+
+func (r *PostgresStandingOrderRepository) GetByAccountNumber(accountNumber string) ([]*models.StandingOrder, error) {
+	query := `
+		SELECT ` + standingOrderColumns + ` FROM standing_orders
+		WHERE from_account_number = $1
+		ORDER BY created_at DESC`
+
+	rows, err := r.db.Query(query, accountNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanStandingOrders(rows)
+}
+
+func (r *PostgresStandingOrderRepository) LockDue(now time.Time, limit int) ([]*models.StandingOrder, error) {
+	query := `
+		SELECT ` + standingOrderColumns + ` FROM standing_orders
+		WHERE status = $1 AND next_attempt_at <= $2
+		ORDER BY next_attempt_at
+		LIMIT $3
+		FOR UPDATE SKIP LOCKED`
+
+	rows, err := r.db.Query(query, models.StandingOrderStatusActive, now, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanStandingOrders(rows)
+}
+
+func (r *PostgresStandingOrderRepository) UpdateSchedule(order *models.StandingOrder) error {
+	query := `
+		UPDATE standing_orders
+		SET status = $1, occurrences = $2, next_run_date = $3, next_attempt_at = $4,
+			retry_count = $5, last_error = NULLIF($6, ''), updated_at = $7
+		WHERE order_id = $8`
+
+	order.UpdatedAt = time.Now().UTC()
+	_, err := r.db.Exec(query, order.Status, order.Occurrences, order.NextRunDate, order.NextAttemptAt,
+		order.RetryCount, order.LastError, order.UpdatedAt, order.OrderID)
+	return err
+}
+
+const executionColumns = `
+	id, order_id, scheduled_for, attempt, transaction_id, status, error, started_at, finished_at`
+
+func scanExecution(row rowScanner) (*models.StandingOrderExecution, error) {
+	execution := &models.StandingOrderExecution{}
+	var errorMessage sql.NullString
+	var finishedAt sql.NullTime
+
+	err := row.Scan(
+		&execution.ID, &execution.OrderID, &execution.ScheduledFor, &execution.Attempt,
+		&execution.TransactionID, &execution.Status, &errorMessage, &execution.StartedAt, &finishedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	execution.Error = errorMessage.String
+	if finishedAt.Valid {
+		execution.FinishedAt = &finishedAt.Time
+	}
+
+	return execution, nil
+}
+
+func (r *PostgresStandingOrderRepository) CreateExecution(execution *models.StandingOrderExecution) error {
+	query := `
+		INSERT INTO standing_order_executions (
+			order_id, scheduled_for, attempt, transaction_id, status, started_at
+		) VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`
+
+	return r.db.QueryRow(
+		query,
+		execution.OrderID, execution.ScheduledFor, execution.Attempt, execution.TransactionID,
+		execution.Status, execution.StartedAt,
+	).Scan(&execution.ID)
+}
+
+func (r *PostgresStandingOrderRepository) GetRunningExecution(orderID string) (*models.StandingOrderExecution, error) {
+	query := `SELECT ` + executionColumns + ` FROM standing_order_executions WHERE order_id = $1 AND status = $2`
+
+	execution, err := scanExecution(r.db.QueryRow(query, orderID, models.ExecutionStatusRunning))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("standing order %s has no running execution", orderID)
+		}
+		return nil, err
+	}
+
+	return execution, nil
+}
+
+func (r *PostgresStandingOrderRepository) FinishExecution(id int, status, errorMessage string) error {
+	query := `
+		UPDATE standing_order_executions
+		SET status = $1, error = NULLIF($2, ''), finished_at = $3
+		WHERE id = $4 AND status = $5`
+
+	result, err := r.db.Exec(query, status, errorMessage, time.Now().UTC(), id, models.ExecutionStatusRunning)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("standing order execution %d is not running", id)
+	}
+
+	return nil
+}
+
+func (r *PostgresStandingOrderRepository) GetExecutions(orderID string, limit, offset int) ([]*models.StandingOrderExecution, error) {
+	query := `
+		SELECT ` + executionColumns + ` FROM standing_order_executions
+		WHERE order_id = $1
+		ORDER BY started_at DESC
+		LIMIT $2 OFFSET $3`
+
+	rows, err := r.db.Query(query, orderID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var executions []*models.StandingOrderExecution
+	for rows.Next() {
+		execution, err := scanExecution(rows)
+		if err != nil {
+			return nil, err
+		}
+		executions = append(executions, execution)
+	}
+
+	return executions, rows.Err()
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bank-api/internal/models"
+)
+
+type StatementRepository interface {
+	// Create stores a statement; it does nothing if the account already has
+	// one for the period
+	Create(statement *models.StoredStatement) error
+	GetByPeriod(accountNumber string, start, end time.Time) (*models.StoredStatement, error)
+	// AccountsWithoutStatement lists up to limit accounts opened before the
+	// period ended that have no statement for it
+	AccountsWithoutStatement(start, end time.Time, limit int) ([]string, error)
+}
+
+type PostgresStatementRepository struct {
+	db DBTX
+}
+
+func NewPostgresStatementRepository(db *sql.DB) StatementRepository {
+	return &PostgresStatementRepository{db: db}
+}
+
+func (r *PostgresStatementRepository) Create(statement *models.StoredStatement) error {
+	query := `
+		INSERT INTO account_statements (
+			account_number, period_start, period_end, opening_balance, closing_balance,
+			line_count, csv_document, pdf_document, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (account_number, period_start, period_end) DO NOTHING`
+
+	_, err := r.db.Exec(
+		query,
+		statement.AccountNumber, statement.PeriodStart, statement.PeriodEnd, statement.OpeningBalance,
+		statement.ClosingBalance, statement.LineCount, statement.CSV, statement.PDF, statement.CreatedAt,
+	)
+	return err
+}
+
+func (r *PostgresStatementRepository) GetByPeriod(accountNumber string, start, end time.Time) (*models.StoredStatement, error) {
+	query := `
+		SELECT id, account_number, period_start, period_end, opening_balance, closing_balance,
+			line_count, csv_document, pdf_document, created_at
+		FROM account_statements
+		WHERE account_number = $1 AND period_start = $2 AND period_end = $3`
+
+	statement := &models.StoredStatement{}
+	err := r.db.QueryRow(query, accountNumber, start, end).Scan(
+		&statement.ID, &statement.AccountNumber, &statement.PeriodStart, &statement.PeriodEnd,
+		&statement.OpeningBalance, &statement.ClosingBalance, &statement.LineCount,
+		&statement.CSV, &statement.PDF, &statement.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("statement not found")
+		}
+		return nil, err
+	}
+
+	return statement, nil
+}
+
+func (r *PostgresStatementRepository) AccountsWithoutStatement(start, end time.Time, limit int) ([]string, error) {
+	query := `
+		SELECT a.account_number FROM accounts a
+		WHERE a.created_at < $2::date + 1
+		AND NOT EXISTS (
+			SELECT 1 FROM account_statements s
+			WHERE s.account_number = a.account_number AND s.period_start = $1 AND s.period_end = $2
+		)
+		ORDER BY a.id
+		LIMIT $3`
+
+	rows, err := r.db.Query(query, start, end, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accountNumbers []string
+	for rows.Next() {
+		var accountNumber string
+		if err := rows.Scan(&accountNumber); err != nil {
+			return nil, err
+		}
+		accountNumbers = append(accountNumbers, accountNumber)
+	}
+
+	return accountNumbers, rows.Err()
+}
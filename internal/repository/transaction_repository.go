@@ -12,38 +12,160 @@ type TransactionRepository interface {
 	Create(transaction *models.Transaction) error
 	GetByID(id int) (*models.Transaction, error)
 	GetByTransactionID(transactionID string) (*models.Transaction, error)
+	// GetByTransactionIDForUpdate loads and row-locks a transaction. Must be
+	// called on a repository bound to a transaction via WithTx.
+	GetByTransactionIDForUpdate(transactionID string) (*models.Transaction, error)
 	GetByAccountNumber(accountNumber string, limit, offset int) ([]*models.Transaction, error)
 	GetByDateRange(accountNumber string, startDate, endDate time.Time, limit, offset int) ([]*models.Transaction, error)
 	UpdateStatus(transactionID string, status string) error
-	GetPendingTransactions() ([]*models.Transaction, error)
+	// MarkFailed moves a transaction to FAILED and records why
+	MarkFailed(transactionID, reason string) error
+	// LockPending row-locks up to limit pending transactions due for
+	// processing, oldest first, skipping those locked by another worker.
+	// Transfers to other banks, which wait for the clearing gateway, are left
+	// out. Must be called on a repository bound to a transaction via WithTx.
+	LockPending(now time.Time, limit int) ([]*models.Transaction, error)
+	// RecordAttempt counts a failed processing attempt and schedules the next
+	RecordAttempt(transactionID, reason string, nextAttemptAt time.Time) error
+	// PendingQueue returns how many transactions wait for processing and when
+	// the oldest was created
+	PendingQueue() (depth int, oldest *time.Time, err error)
+	// UpdateFee records the fee charged on a transaction
+	UpdateFee(transactionID string, fee int64) error
+	// GetFees returns the FEE transactions charged on a transaction
+	GetFees(parentTransactionID string) ([]*models.Transaction, error)
+	// CountBooked counts the booked transactions of a type sent from an
+	// account since the given time, through channel unless it is empty,
+	// leaving out excludeTransactionID
+	CountBooked(accountNumber, transactionType, channel string, since time.Time, excludeTransactionID string) (int, error)
+	// GetLimitUsage sums the booked transfers and withdrawals sent from an
+	// account since dayStart and monthStart and counts those since hourStart,
+	// leaving out excludeTransactionID
+	GetLimitUsage(accountNumber string, dayStart, monthStart, hourStart time.Time, excludeTransactionID string) (models.LimitUsage, error)
+	// HasPaid reports whether an account has completed a transfer to the
+	// account number or, for other banks, the IBAN given
+	HasPaid(fromAccountNumber, toAccountNumber, toIBAN string) (bool, error)
+	// GetReversedTotals returns how much of a transaction's amount and fee has
+	// already been refunded by completed reversals
+	GetReversedTotals(originalTransactionID string) (amount int64, feeRefund int64, err error)
+	// WithTx returns a repository whose queries run inside tx
+	WithTx(tx *sql.Tx) TransactionRepository
 }
 
 type PostgresTransactionRepository struct {
-	db *sql.DB
+	db DBTX
 }
 
 func NewPostgresTransactionRepository(db *sql.DB) TransactionRepository {
 	return &PostgresTransactionRepository{db: db}
 }
 
+const transactionColumns = `
+	id, transaction_id, from_account_id, to_account_id, from_account_number,
+	to_account_number, amount, currency, exchange_rate, converted_amount,
+	transaction_type, status, description, reference, fee, processed_at,
+	created_at, updated_at, failure_reason, original_transaction_id, fee_refund,
+	fx_quote_id, counterparty_iban, counterparty_bic, counterparty_name, attempts,
+	channel, parent_transaction_id`
+
+func scanTransaction(row rowScanner) (*models.Transaction, error) {
+	transaction := &models.Transaction{}
+	var fromAccountID, toAccountID sql.NullInt32
+	var fromAccountNumber, toAccountNumber sql.NullString
+	var processedAt sql.NullTime
+	var failureReason, originalTransactionID, fxQuoteID sql.NullString
+	var counterpartyIBAN, counterpartyBIC, counterpartyName sql.NullString
+	var channel, parentTransactionID sql.NullString
+
+	err := row.Scan(
+		&transaction.ID, &transaction.TransactionID, &fromAccountID,
+		&toAccountID, &fromAccountNumber, &toAccountNumber,
+		&transaction.Amount, &transaction.Currency, &transaction.ExchangeRate,
+		&transaction.ConvertedAmount, &transaction.TransactionType, &transaction.Status,
+		&transaction.Description, &transaction.Reference, &transaction.Fee,
+		&processedAt, &transaction.CreatedAt, &transaction.UpdatedAt, &failureReason,
+		&originalTransactionID, &transaction.FeeRefund, &fxQuoteID,
+		&counterpartyIBAN, &counterpartyBIC, &counterpartyName, &transaction.Attempts,
+		&channel, &parentTransactionID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Handle nullable fields
+	if fromAccountID.Valid {
+		transaction.FromAccountID = int(fromAccountID.Int32)
+	}
+	if toAccountID.Valid {
+		transaction.ToAccountID = int(toAccountID.Int32)
+	}
+	transaction.FromAccountNumber = fromAccountNumber.String
+	transaction.ToAccountNumber = toAccountNumber.String
+	if processedAt.Valid {
+		transaction.ProcessedAt = &processedAt.Time
+	}
+	if failureReason.Valid {
+		transaction.FailureReason = failureReason.String
+	}
+	transaction.OriginalTransactionID = originalTransactionID.String
+	transaction.FXQuoteID = fxQuoteID.String
+	transaction.CounterpartyIBAN = counterpartyIBAN.String
+	transaction.CounterpartyBIC = counterpartyBIC.String
+	transaction.CounterpartyName = counterpartyName.String
+	transaction.Channel = channel.String
+	transaction.ParentTransactionID = parentTransactionID.String
+
+	return transaction, nil
+}
+
+func scanTransactions(rows *sql.Rows) ([]*models.Transaction, error) {
+	defer rows.Close()
+
+	var transactions []*models.Transaction
+	for rows.Next() {
+		transaction, err := scanTransaction(rows)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, rows.Err()
+}
+
+func (r *PostgresTransactionRepository) WithTx(tx *sql.Tx) TransactionRepository {
+	return &PostgresTransactionRepository{db: tx}
+}
+
 func (r *PostgresTransactionRepository) Create(transaction *models.Transaction) error {
 	query := `
 		INSERT INTO transactions (
 			transaction_id, from_account_id, to_account_id, from_account_number,
 			to_account_number, amount, currency, exchange_rate, converted_amount,
-			transaction_type, status, description, reference, fee, created_at, updated_at
+			transaction_type, status, description, reference, fee, created_at, updated_at,
+			original_transaction_id, fee_refund, fx_quote_id, counterparty_iban,
+			counterparty_bic, counterparty_name, failure_reason, channel,
+			parent_transaction_id, processed_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19,
+			NULLIF($20, ''), NULLIF($21, ''), NULLIF($22, ''), NULLIF($23, ''), NULLIF($24, ''),
+			NULLIF($25, ''), $26
 		) RETURNING id`
 	
 	// Handle nullable foreign key references
-	var fromAccountID, toAccountID interface{}
+	var fromAccountID, toAccountID, originalTransactionID, fxQuoteID interface{}
 	if transaction.FromAccountID != 0 {
 		fromAccountID = transaction.FromAccountID
 	}
 	if transaction.ToAccountID != 0 {
 		toAccountID = transaction.ToAccountID
 	}
+	if transaction.OriginalTransactionID != "" {
+		originalTransactionID = transaction.OriginalTransactionID
+	}
+	if transaction.FXQuoteID != "" {
+		fxQuoteID = transaction.FXQuoteID
+	}
 	
 	err := r.db.QueryRow(
 		query,
@@ -52,33 +174,18 @@ func (r *PostgresTransactionRepository) Create(transaction *models.Transaction)
 		transaction.Currency, transaction.ExchangeRate, transaction.ConvertedAmount,
 		transaction.TransactionType, transaction.Status, transaction.Description,
 		transaction.Reference, transaction.Fee, transaction.CreatedAt, transaction.UpdatedAt,
+		originalTransactionID, transaction.FeeRefund, fxQuoteID, transaction.CounterpartyIBAN,
+		transaction.CounterpartyBIC, transaction.CounterpartyName, transaction.FailureReason,
+		transaction.Channel, transaction.ParentTransactionID, transaction.ProcessedAt,
 	).Scan(&transaction.ID)
 	
 	return err
 }
 
 func (r *PostgresTransactionRepository) GetByID(id int) (*models.Transaction, error) {
-	query := `
-		SELECT id, transaction_id, from_account_id, to_account_id, from_account_number,
-			   to_account_number, amount, currency, exchange_rate, converted_amount,
-			   transaction_type, status, description, reference, fee, processed_at,
-			   created_at, updated_at, failure_reason
-		FROM transactions WHERE id = $1`
-	
-	transaction := &models.Transaction{}
-	var fromAccountID, toAccountID sql.NullInt32
-	var processedAt sql.NullTime
-	var failureReason sql.NullString
-	
-	err := r.db.QueryRow(query, id).Scan(
-		&transaction.ID, &transaction.TransactionID, &fromAccountID,
-		&toAccountID, &transaction.FromAccountNumber, &transaction.ToAccountNumber,
-		&transaction.Amount, &transaction.Currency, &transaction.ExchangeRate,
-		&transaction.ConvertedAmount, &transaction.TransactionType, &transaction.Status,
-		&transaction.Description, &transaction.Reference, &transaction.Fee,
-		&processedAt, &transaction.CreatedAt, &transaction.UpdatedAt, &failureReason,
-	)
+	query := `SELECT ` + transactionColumns + ` FROM transactions WHERE id = $1`
 	
+	transaction, err := scanTransaction(r.db.QueryRow(query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("transaction with id %d not found", id)
@@ -86,45 +193,24 @@ func (r *PostgresTransactionRepository) GetByID(id int) (*models.Transaction, er
 		return nil, err
 	}
 	
-	// Handle nullable fields
-	if fromAccountID.Valid {
-		transaction.FromAccountID = int(fromAccountID.Int32)
-	}
-	if toAccountID.Valid {
-		transaction.ToAccountID = int(toAccountID.Int32)
-	}
-	if processedAt.Valid {
-		transaction.ProcessedAt = &processedAt.Time
-	}
-	if failureReason.Valid {
-		transaction.FailureReason = failureReason.String
-	}
-	
 	return transaction, nil
 }
 
 func (r *PostgresTransactionRepository) GetByTransactionID(transactionID string) (*models.Transaction, error) {
-	query := `
-		SELECT id, transaction_id, from_account_id, to_account_id, from_account_number,
-			   to_account_number, amount, currency, exchange_rate, converted_amount,
-			   transaction_type, status, description, reference, fee, processed_at,
-			   created_at, updated_at, failure_reason
-		FROM transactions WHERE transaction_id = $1`
-	
-	transaction := &models.Transaction{}
-	var fromAccountID, toAccountID sql.NullInt32
-	var processedAt sql.NullTime
-	var failureReason sql.NullString
-	
-	err := r.db.QueryRow(query, transactionID).Scan(
-		&transaction.ID, &transaction.TransactionID, &fromAccountID,
-		&toAccountID, &transaction.FromAccountNumber, &transaction.ToAccountNumber,
-		&transaction.Amount, &transaction.Currency, &transaction.ExchangeRate,
-		&transaction.ConvertedAmount, &transaction.TransactionType, &transaction.Status,
-		&transaction.Description, &transaction.Reference, &transaction.Fee,
-		&processedAt, &transaction.CreatedAt, &transaction.UpdatedAt, &failureReason,
-	)
+	return r.getByTransactionID(transactionID, false)
+}
+
+func (r *PostgresTransactionRepository) GetByTransactionIDForUpdate(transactionID string) (*models.Transaction, error) {
+	return r.getByTransactionID(transactionID, true)
+}
+
+func (r *PostgresTransactionRepository) getByTransactionID(transactionID string, forUpdate bool) (*models.Transaction, error) {
+	query := `SELECT ` + transactionColumns + ` FROM transactions WHERE transaction_id = $1`
+	if forUpdate {
+		query += ` FOR UPDATE`
+	}
 	
+	transaction, err := scanTransaction(r.db.QueryRow(query, transactionID))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("transaction with ID %s not found", transactionID)
@@ -132,29 +218,12 @@ func (r *PostgresTransactionRepository) GetByTransactionID(transactionID string)
 		return nil, err
 	}
 	
-	// Handle nullable fields
-	if fromAccountID.Valid {
-		transaction.FromAccountID = int(fromAccountID.Int32)
-	}
-	if toAccountID.Valid {
-		transaction.ToAccountID = int(toAccountID.Int32)
-	}
-	if processedAt.Valid {
-		transaction.ProcessedAt = &processedAt.Time
-	}
-	if failureReason.Valid {
-		transaction.FailureReason = failureReason.String
-	}
-	
 	return transaction, nil
 }
 
 func (r *PostgresTransactionRepository) GetByAccountNumber(accountNumber string, limit, offset int) ([]*models.Transaction, error) {
 	query := `
-		SELECT id, transaction_id, from_account_id, to_account_id, from_account_number,
-			   to_account_number, amount, currency, exchange_rate, converted_amount,
-			   transaction_type, status, description, reference, fee, processed_at,
-			   created_at, updated_at, failure_reason
+		SELECT ` + transactionColumns + `
 		FROM transactions 
 		WHERE from_account_number = $1 OR to_account_number = $1
 		ORDER BY created_at DESC 
@@ -164,53 +233,13 @@ func (r *PostgresTransactionRepository) GetByAccountNumber(accountNumber string,
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-	
-	var transactions []*models.Transaction
-	for rows.Next() {
-		transaction := &models.Transaction{}
-		var fromAccountID, toAccountID sql.NullInt32
-		var processedAt sql.NullTime
-		var failureReason sql.NullString
-		
-		err := rows.Scan(
-			&transaction.ID, &transaction.TransactionID, &fromAccountID,
-			&toAccountID, &transaction.FromAccountNumber, &transaction.ToAccountNumber,
-			&transaction.Amount, &transaction.Currency, &transaction.ExchangeRate,
-			&transaction.ConvertedAmount, &transaction.TransactionType, &transaction.Status,
-			&transaction.Description, &transaction.Reference, &transaction.Fee,
-			&processedAt, &transaction.CreatedAt, &transaction.UpdatedAt, &failureReason,
-		)
-		if err != nil {
-			return nil, err
-		}
-		
-		// Handle nullable fields
-		if fromAccountID.Valid {
-			transaction.FromAccountID = int(fromAccountID.Int32)
-		}
-		if toAccountID.Valid {
-			transaction.ToAccountID = int(toAccountID.Int32)
-		}
-		if processedAt.Valid {
-			transaction.ProcessedAt = &processedAt.Time
-		}
-		if failureReason.Valid {
-			transaction.FailureReason = failureReason.String
-		}
-		
-		transactions = append(transactions, transaction)
-	}
 	
-	return transactions, nil
+	return scanTransactions(rows)
 }
 
 func (r *PostgresTransactionRepository) GetByDateRange(accountNumber string, startDate, endDate time.Time, limit, offset int) ([]*models.Transaction, error) {
 	query := `
-		SELECT id, transaction_id, from_account_id, to_account_id, from_account_number,
-			   to_account_number, amount, currency, exchange_rate, converted_amount,
-			   transaction_type, status, description, reference, fee, processed_at,
-			   created_at, updated_at, failure_reason
+		SELECT ` + transactionColumns + `
 		FROM transactions 
 		WHERE (from_account_number = $1 OR to_account_number = $1)
 		  AND created_at >= $2 AND created_at <= $3
@@ -221,112 +250,154 @@ func (r *PostgresTransactionRepository) GetByDateRange(accountNumber string, sta
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 	
-	var transactions []*models.Transaction
-	for rows.Next() {
-		transaction := &models.Transaction{}
-		var fromAccountID, toAccountID sql.NullInt32
-		var processedAt sql.NullTime
-		var failureReason sql.NullString
-		
-		err := rows.Scan(
-			&transaction.ID, &transaction.TransactionID, &fromAccountID,
-			&toAccountID, &transaction.FromAccountNumber, &transaction.ToAccountNumber,
-			&transaction.Amount, &transaction.Currency, &transaction.ExchangeRate,
-			&transaction.ConvertedAmount, &transaction.TransactionType, &transaction.Status,
-			&transaction.Description, &transaction.Reference, &transaction.Fee,
-			&processedAt, &transaction.CreatedAt, &transaction.UpdatedAt, &failureReason,
-		)
-		if err != nil {
-			return nil, err
-		}
-		
-		// Handle nullable fields
-		if fromAccountID.Valid {
-			transaction.FromAccountID = int(fromAccountID.Int32)
-		}
-		if toAccountID.Valid {
-			transaction.ToAccountID = int(toAccountID.Int32)
-		}
-		if processedAt.Valid {
-			transaction.ProcessedAt = &processedAt.Time
-		}
-		if failureReason.Valid {
-			transaction.FailureReason = failureReason.String
-		}
-		
-		transactions = append(transactions, transaction)
+	return scanTransactions(rows)
+}
+
+func (r *PostgresTransactionRepository) UpdateStatus(transactionID string, status string) error {
+	query := `
+		UPDATE transactions SET
+			status = $1,
+			updated_at = $2,
+			processed_at = CASE WHEN $1 = '` + models.TransactionStatusCompleted + `' THEN $2 ELSE processed_at END
+		WHERE transaction_id = $3`
+	
+	_, err := r.db.Exec(query, status, time.Now().UTC(), transactionID)
+	return err
+}
+
+func (r *PostgresTransactionRepository) MarkFailed(transactionID, reason string) error {
+	query := `UPDATE transactions SET status = $1, failure_reason = $2, updated_at = $3 WHERE transaction_id = $4`
+	
+	_, err := r.db.Exec(query, models.TransactionStatusFailed, reason, time.Now().UTC(), transactionID)
+	return err
+}
+
+// pendingQueueCondition selects the transactions the pending worker processes
+const pendingQueueCondition = `
+	t.status = '` + models.TransactionStatusPending + `'
+	AND NOT EXISTS (SELECT 1 FROM outbound_payments p WHERE p.transaction_id = t.transaction_id)`
+
+func (r *PostgresTransactionRepository) LockPending(now time.Time, limit int) ([]*models.Transaction, error) {
+	query := `
+		SELECT ` + transactionColumns + `
+		FROM transactions t
+		WHERE ` + pendingQueueCondition + `
+		AND (t.next_attempt_at IS NULL OR t.next_attempt_at <= $1)
+		ORDER BY t.created_at ASC
+		LIMIT $2
+		FOR UPDATE OF t SKIP LOCKED`
+	
+	rows, err := r.db.Query(query, now, limit)
+	if err != nil {
+		return nil, err
 	}
 	
-	return transactions, nil
+	return scanTransactions(rows)
 }
 
-func (r *PostgresTransactionRepository) UpdateStatus(transactionID string, status string) error {
-	query := `UPDATE transactions SET status = $1, updated_at = $2 WHERE transaction_id = $3`
+func (r *PostgresTransactionRepository) RecordAttempt(transactionID, reason string, nextAttemptAt time.Time) error {
+	query := `
+		UPDATE transactions
+		SET attempts = attempts + 1, failure_reason = $1, next_attempt_at = $2, updated_at = $3
+		WHERE transaction_id = $4`
 	
-	now := time.Now().UTC()
-	_, err := r.db.Exec(query, status, now, transactionID)
+	_, err := r.db.Exec(query, reason, nextAttemptAt, time.Now().UTC(), transactionID)
+	return err
+}
+
+func (r *PostgresTransactionRepository) PendingQueue() (int, *time.Time, error) {
+	query := `SELECT COUNT(*), MIN(t.created_at) FROM transactions t WHERE ` + pendingQueueCondition
+	
+	var depth int
+	var oldest sql.NullTime
+	if err := r.db.QueryRow(query).Scan(&depth, &oldest); err != nil {
+		return 0, nil, err
+	}
 	
-	if status == models.TransactionStatusCompleted {
-		processedQuery := `UPDATE transactions SET processed_at = $1 WHERE transaction_id = $2`
-		_, err = r.db.Exec(processedQuery, now, transactionID)
+	if !oldest.Valid {
+		return depth, nil, nil
 	}
+	return depth, &oldest.Time, nil
+}
+
+func (r *PostgresTransactionRepository) UpdateFee(transactionID string, fee int64) error {
+	query := `UPDATE transactions SET fee = $1, updated_at = $2 WHERE transaction_id = $3`
 	
+	_, err := r.db.Exec(query, fee, time.Now().UTC(), transactionID)
 	return err
 }
 
-func (r *PostgresTransactionRepository) GetPendingTransactions() ([]*models.Transaction, error) {
+func (r *PostgresTransactionRepository) GetFees(parentTransactionID string) ([]*models.Transaction, error) {
 	query := `
-		SELECT id, transaction_id, from_account_id, to_account_id, from_account_number,
-			   to_account_number, amount, currency, exchange_rate, converted_amount,
-			   transaction_type, status, description, reference, fee, processed_at,
-			   created_at, updated_at, failure_reason
-		FROM transactions 
-		WHERE status = $1
+		SELECT ` + transactionColumns + `
+		FROM transactions
+		WHERE parent_transaction_id = $1 AND transaction_type = $2
 		ORDER BY created_at ASC`
 	
-	rows, err := r.db.Query(query, models.TransactionStatusPending)
+	rows, err := r.db.Query(query, parentTransactionID, models.TransactionTypeFee)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 	
-	var transactions []*models.Transaction
-	for rows.Next() {
-		transaction := &models.Transaction{}
-		var fromAccountID, toAccountID sql.NullInt32
-		var processedAt sql.NullTime
-		var failureReason sql.NullString
-		
-		err := rows.Scan(
-			&transaction.ID, &transaction.TransactionID, &fromAccountID,
-			&toAccountID, &transaction.FromAccountNumber, &transaction.ToAccountNumber,
-			&transaction.Amount, &transaction.Currency, &transaction.ExchangeRate,
-			&transaction.ConvertedAmount, &transaction.TransactionType, &transaction.Status,
-			&transaction.Description, &transaction.Reference, &transaction.Fee,
-			&processedAt, &transaction.CreatedAt, &transaction.UpdatedAt, &failureReason,
-		)
-		if err != nil {
-			return nil, err
-		}
-		
-		// Handle nullable fields
-		if fromAccountID.Valid {
-			transaction.FromAccountID = int(fromAccountID.Int32)
-		}
-		if toAccountID.Valid {
-			transaction.ToAccountID = int(toAccountID.Int32)
-		}
-		if processedAt.Valid {
-			transaction.ProcessedAt = &processedAt.Time
-		}
-		if failureReason.Valid {
-			transaction.FailureReason = failureReason.String
-		}
-		
-		transactions = append(transactions, transaction)
-	}
+	return scanTransactions(rows)
+}
+
+func (r *PostgresTransactionRepository) CountBooked(accountNumber, transactionType, channel string, since time.Time, excludeTransactionID string) (int, error) {
+	// External transfers are booked while they wait for settlement
+	query := `
+		SELECT COUNT(*)
+		FROM transactions
+		WHERE from_account_number = $1 AND transaction_type = $2
+		  AND ($3 = '' OR COALESCE(channel, '` + models.ChannelOnline + `') = $3)
+		  AND created_at >= $4 AND transaction_id <> $5
+		  AND (status = '` + models.TransactionStatusCompleted + `'
+		       OR (status = '` + models.TransactionStatusPending + `' AND transaction_type = '` + models.TransactionTypeExternal + `'))`
+	
+	var count int
+	err := r.db.QueryRow(query, accountNumber, transactionType, channel, since, excludeTransactionID).Scan(&count)
+	return count, err
+}
+
+func (r *PostgresTransactionRepository) HasPaid(fromAccountNumber, toAccountNumber, toIBAN string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM transactions
+			WHERE from_account_number = $1 AND status = '` + models.TransactionStatusCompleted + `'
+			  AND transaction_type IN ('` + models.TransactionTypeTransfer + `', '` + models.TransactionTypeExternal + `')
+			  AND (($2 <> '' AND to_account_number = $2) OR ($3 <> '' AND counterparty_iban = $3))
+		)`
+	
+	var paid bool
+	err := r.db.QueryRow(query, fromAccountNumber, toAccountNumber, toIBAN).Scan(&paid)
+	return paid, err
+}
+
+func (r *PostgresTransactionRepository) GetLimitUsage(accountNumber string, dayStart, monthStart, hourStart time.Time, excludeTransactionID string) (models.LimitUsage, error) {
+	query := `
+		SELECT
+			COALESCE(SUM(amount) FILTER (WHERE created_at >= $2), 0),
+			COALESCE(SUM(amount) FILTER (WHERE created_at >= $3), 0),
+			COUNT(*) FILTER (WHERE created_at >= $4)
+		FROM transactions
+		WHERE from_account_number = $1 AND transaction_id <> $5
+		  AND transaction_type IN ('` + models.TransactionTypeTransfer + `', '` + models.TransactionTypeWithdrawal + `', '` + models.TransactionTypeExternal + `')
+		  AND created_at >= LEAST($2, $3, $4)
+		  AND (status = '` + models.TransactionStatusCompleted + `'
+		       OR (status = '` + models.TransactionStatusPending + `' AND transaction_type = '` + models.TransactionTypeExternal + `'))`
+	
+	var usage models.LimitUsage
+	err := r.db.QueryRow(query, accountNumber, dayStart, monthStart, hourStart, excludeTransactionID).Scan(&usage.Daily, &usage.Monthly, &usage.HourlyCount)
+	return usage, err
+}
+
+func (r *PostgresTransactionRepository) GetReversedTotals(originalTransactionID string) (int64, int64, error) {
+	query := `
+		SELECT COALESCE(SUM(amount), 0), COALESCE(SUM(fee_refund), 0)
+		FROM transactions
+		WHERE original_transaction_id = $1 AND status = $2`
 	
-	return transactions, nil
+	var amount, feeRefund int64
+	err := r.db.QueryRow(query, originalTransactionID, models.TransactionStatusCompleted).Scan(&amount, &feeRefund)
+	return amount, feeRefund, err
 }
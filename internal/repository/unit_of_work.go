@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// DBTX is the subset of *sql.DB and *sql.Tx used by the Postgres repositories,
+// so the same repository code can run standalone or inside a unit of work
+type DBTX interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// TxRunner executes a function inside a single database transaction
+type TxRunner interface {
+	RunInTx(fn func(tx *sql.Tx) error) error
+}
+
+type PostgresTxRunner struct {
+	db *sql.DB
+}
+
+func NewPostgresTxRunner(db *sql.DB) TxRunner {
+	return &PostgresTxRunner{db: db}
+}
+
+// RunInTx begins a transaction, runs fn and commits if fn succeeds.
+// Any error (or panic) from fn rolls the whole transaction back.
+func (r *PostgresTxRunner) RunInTx(fn func(tx *sql.Tx) error) (err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// RunInSavepoint runs fn inside a savepoint of tx. If fn fails, only its
+// changes are rolled back: tx, and the row locks it holds, can carry on.
+func RunInSavepoint(tx *sql.Tx, fn func() error) error {
+	if _, err := tx.Exec("SAVEPOINT unit_of_work"); err != nil {
+		return fmt.Errorf("failed to create savepoint: %w", err)
+	}
+
+	if err := fn(); err != nil {
+		if _, rollbackErr := tx.Exec("ROLLBACK TO SAVEPOINT unit_of_work"); rollbackErr != nil {
+			return fmt.Errorf("failed to roll back to savepoint: %w", rollbackErr)
+		}
+		return err
+	}
+
+	_, err := tx.Exec("RELEASE SAVEPOINT unit_of_work")
+	return err
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"crypto/rand"
+	"database/sql"
 	"fmt"
 	"strings"
 	"time"
@@ -11,25 +12,36 @@ import (
 )
 
 type AccountService interface {
+	// CreateAccount registers a new customer together with their first account
 	CreateAccount(req *models.CreateAccountRequest) (*models.Account, error)
+	// OpenAccount opens an additional account for an existing customer
+	OpenAccount(customerID string, req *models.OpenAccountRequest) (*models.Account, error)
 	GetAccountByID(id int) (*models.Account, error)
 	GetAccountByAccountNumber(accountNumber string) (*models.Account, error)
 	GetAccountsByCustomerID(customerID string) ([]*models.Account, error)
 	GetAllAccounts(limit, offset int) ([]*models.Account, error)
-	UpdateAccount(id int, req *models.UpdateAccountRequest) error
 	DeleteAccount(id int) error
-	AuthenticateAccount(accountNumber, password string) (*models.Account, error)
 	UpdateAccountStatus(id int, status string) error
 	GetAccountBalance(accountNumber string) (*models.BalanceResponse, error)
 }
 
 type accountService struct {
-	accountRepo repository.AccountRepository
+	accountRepo  repository.AccountRepository
+	customerRepo repository.CustomerRepository
+	txRunner     repository.TxRunner
+	bank         models.Bank // Bank our accounts are held at
+	branchCode   string      // 3-digit branch code used in new RIBs
+	passwords    *PasswordPolicy
 }
 
-func NewAccountService(accountRepo repository.AccountRepository) AccountService {
+func NewAccountService(accountRepo repository.AccountRepository, customerRepo repository.CustomerRepository, txRunner repository.TxRunner, bank models.Bank, branchCode string, passwords *PasswordPolicy) AccountService {
 	return &accountService{
-		accountRepo: accountRepo,
+		accountRepo:  accountRepo,
+		customerRepo: customerRepo,
+		txRunner:     txRunner,
+		bank:         bank,
+		branchCode:   branchCode,
+		passwords:    passwords,
 	}
 }
 
@@ -38,37 +50,93 @@ func (s *accountService) CreateAccount(req *models.CreateAccountRequest) (*model
 	if err := req.Validate(); err != nil {
 		return nil, err
 	}
-		// Generate unique identifiers
-	customerID := s.generateCustomerID()
-	accountNumber := s.generateAccountNumber()
-	iban := s.generateTunisianIBAN(accountNumber)
-	bic := s.generateTunisianBIC()
+	if err := s.passwords.Validate(req.Password); err != nil {
+		return nil, err
+	}
+	
+	// One login per person: further accounts are opened under the same customer
+	exists, err := s.customerRepo.EmailExists(req.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check email: %w", err)
+	}
+	if exists {
+		return nil, fmt.Errorf("a customer with this email already exists; log in to open another account")
+	}
 	
-	// Create account
-	account, err := models.NewAccount(req, customerID, accountNumber, iban, bic)
+	customer, err := models.NewCustomer(req, s.generateCustomerID())
 	if err != nil {
-		return nil, fmt.Errorf("failed to create account: %w", err)
+		return nil, fmt.Errorf("failed to create customer: %w", err)
 	}
 	
-	// Save to database
+	account, err := s.newAccount(customer.CustomerID, req.AccountType, req.Currency)
+	if err != nil {
+		return nil, err
+	}
+	
+	// Save both or neither
+	err = s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		if err := s.customerRepo.WithTx(tx).Create(customer); err != nil {
+			return fmt.Errorf("failed to save customer: %w", err)
+		}
+		if err := s.accountRepo.WithTx(tx).Create(account); err != nil {
+			return fmt.Errorf("failed to save account: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	return account, nil
+}
+
+func (s *accountService) OpenAccount(customerID string, req *models.OpenAccountRequest) (*models.Account, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	
+	customer, err := s.customerRepo.GetByCustomerID(customerID)
+	if err != nil {
+		return nil, err
+	}
+	
+	if !customer.IsActive() {
+		return nil, fmt.Errorf("customer is not active")
+	}
+	
+	account, err := s.newAccount(customer.CustomerID, req.AccountType, req.Currency)
+	if err != nil {
+		return nil, err
+	}
 	if err := s.accountRepo.Create(account); err != nil {
 		return nil, fmt.Errorf("failed to save account: %w", err)
 	}
 	
-	// Clear password from response
-	account.HashPassword = ""
-	
 	return account, nil
 }
 
+// newAccount builds an account with freshly generated identifiers. The
+// account number is the account's 20-digit RIB, which is also its IBAN's BBAN.
+func (s *accountService) newAccount(customerID, accountType, currency string) (*models.Account, error) {
+	rib, err := s.generateRIB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate account number: %w", err)
+	}
+	
+	iban, err := rib.IBAN()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate IBAN: %w", err)
+	}
+	
+	return models.NewAccount(customerID, accountType, currency, rib.String(), iban, s.bank.BIC), nil
+}
+
 func (s *accountService) GetAccountByID(id int) (*models.Account, error) {
 	account, err := s.accountRepo.GetByID(id)
 	if err != nil {
 		return nil, err
 	}
 	
-	// Clear password from response
-	account.HashPassword = ""
 	return account, nil
 }
 
@@ -78,8 +146,6 @@ func (s *accountService) GetAccountByAccountNumber(accountNumber string) (*model
 		return nil, err
 	}
 	
-	// Clear password from response
-	account.HashPassword = ""
 	return account, nil
 }
 
@@ -89,11 +155,6 @@ func (s *accountService) GetAccountsByCustomerID(customerID string) ([]*models.A
 		return nil, err
 	}
 	
-	// Clear passwords from response
-	for _, account := range accounts {
-		account.HashPassword = ""
-	}
-	
 	return accounts, nil
 }
 
@@ -110,49 +171,9 @@ func (s *accountService) GetAllAccounts(limit, offset int) ([]*models.Account, e
 		return nil, err
 	}
 	
-	// Clear passwords from response
-	for _, account := range accounts {
-		account.HashPassword = ""
-	}
-	
 	return accounts, nil
 }
 
-func (s *accountService) UpdateAccount(id int, req *models.UpdateAccountRequest) error {
-	// Get existing account
-	existingAccount, err := s.accountRepo.GetByID(id)
-	if err != nil {
-		return fmt.Errorf("account not found: %w", err)
-	}
-	
-	// Update fields if provided
-	if req.FirstName != "" {
-		existingAccount.FirstName = req.FirstName
-	}
-	if req.LastName != "" {
-		existingAccount.LastName = req.LastName
-	}
-	if req.Email != "" {
-		if err := models.ValidateEmail(req.Email); err != nil {
-			return err
-		}
-		existingAccount.Email = req.Email
-	}
-	if req.Phone != "" {
-		if err := models.ValidatePhone(req.Phone); err != nil {
-			return err
-		}
-		existingAccount.Phone = req.Phone
-	}
-	if req.Address.Street != "" || req.Address.City != "" || req.Address.PostalCode != "" || req.Address.Country != "" {
-		existingAccount.Address = req.Address
-	}
-	
-	existingAccount.UpdatedAt = time.Now().UTC()
-	
-	return s.accountRepo.Update(id, existingAccount)
-}
-
 func (s *accountService) DeleteAccount(id int) error {
 	// Check if account exists
 	account, err := s.accountRepo.GetByID(id)
@@ -168,26 +189,6 @@ func (s *accountService) DeleteAccount(id int) error {
 	return s.accountRepo.Delete(id)
 }
 
-func (s *accountService) AuthenticateAccount(accountNumber, password string) (*models.Account, error) {
-	account, err := s.accountRepo.GetByAccountNumber(accountNumber)
-	if err != nil {
-		return nil, fmt.Errorf("invalid credentials")
-	}
-	
-	if !account.IsActive() {
-		return nil, fmt.Errorf("account is not active")
-	}
-
-	if !account.ValidatePassword(password) {
-		return nil, fmt.Errorf("invalid credentials")
-	}
-	
-	// Clear password from response
-	account.HashPassword = ""
-	
-	return account, nil
-}
-
 func (s *accountService) UpdateAccountStatus(id int, status string) error {
 	// Validate status
 	validStatuses := map[string]bool{
@@ -211,11 +212,14 @@ func (s *accountService) GetAccountBalance(accountNumber string) (*models.Balanc
 	}
 	
 	return &models.BalanceResponse{
-		AccountNumber:    account.AccountNumber,
-		Balance:          account.Balance,
-		AvailableBalance: account.GetAvailableBalance(),
-		Currency:         account.Currency,
-		HoldAmount:       account.HoldAmount,
+		AccountNumber:      account.AccountNumber,
+		Balance:            account.Balance,
+		AvailableBalance:   account.GetAvailableBalance(),
+		Currency:           account.Currency,
+		HoldAmount:         account.HoldAmount,
+		OverdraftLimit:     account.OverdraftLimit,
+		OverdraftUsed:      account.GetOverdraftUsed(),
+		OverdraftRemaining: account.GetOverdraftRemaining(),
 	}, nil
 }
 
@@ -229,45 +233,15 @@ func (s *accountService) generateCustomerID() string {
 	return fmt.Sprintf("CUST%d%x", timestamp, randomBytes)
 }
 
-func (s *accountService) generateAccountNumber() string {
-	// Generate Tunisian account number format: 20 digits
-	// Format: Bank code (3) + Branch code (3) + Account sequence (14)
-	randomBytes := make([]byte, 10)
+func (s *accountService) generateRIB() (*models.RIB, error) {
+	// RIB: bank code (2) + branch code (3) + account sequence (13) + RIB key (2)
+	randomBytes := make([]byte, 13)
 	rand.Read(randomBytes)
 	
-	var accountNumber strings.Builder
+	var sequence strings.Builder
 	for _, b := range randomBytes {
-		accountNumber.WriteString(fmt.Sprintf("%02d", int(b)%100))
-	}
-	
-	return accountNumber.String()
-}
-
-func (s *accountService) generateTunisianIBAN(accountNumber string) string {
-	// Tunisian IBAN format: TN + 2 check digits + 20-digit account number
-	countryCode := "TN"
-	checkDigits := "59" // Simplified check digits for demo (should be calculated)
-	
-	// Ensure account number is exactly 20 digits
-	if len(accountNumber) > 20 {
-		accountNumber = accountNumber[:20]
-	}
-	for len(accountNumber) < 20 {
-		accountNumber = "0" + accountNumber
-	}
-	
-	return countryCode + checkDigits + accountNumber
-}
-
-func (s *accountService) generateTunisianBIC() string {
-	// Common Tunisian bank BIC codes (for demo purposes)
-	tunisianBICs := []string{
-		"STBKTNTT", // STB (Société Tunisienne de Banque)
-		"BIATTNTT", // BIAT (Banque Internationale Arabe de Tunisie)
-		"BNTUTNTT", // BNA (Banque Nationale Agricole)
-		"ABTNTNTT", // ATB (Arab Tunisian Bank)
-		"UBCITNTT", // UBCI (Union Bancaire pour le Commerce et l'Industrie)
+		sequence.WriteByte('0' + b%10)
 	}
 	
-	return tunisianBICs[0] // Using STB as default for demo
+	return models.NewRIB(s.bank.Code, s.branchCode, sequence.String())
 }
@@ -0,0 +1,261 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/repository"
+)
+
+var (
+	// ErrInvalidClient is returned for any client credentials that do not
+	// authenticate; callers should not learn why
+	ErrInvalidClient = errors.New("invalid client credentials")
+	// ErrInvalidScope is returned when a client asks for a scope it was not granted
+	ErrInvalidScope = errors.New("requested scope was not granted to the client")
+)
+
+type APIClientService interface {
+	// CreateClient registers a partner API client; the returned secret is
+	// shown once
+	CreateClient(req *models.CreateAPIClientRequest, createdBy string) (*models.APIClientCredentials, error)
+	GetClient(clientID string) (*models.APIClient, error)
+	GetClients(limit, offset int) ([]*models.APIClient, error)
+	// UpdateClient changes a client's name, scopes, accounts or allowed IPs;
+	// its tokens pick up the change on their next request
+	UpdateClient(clientID string, req *models.UpdateAPIClientRequest) (*models.APIClient, error)
+	// UpdateClientStatus enables or disables a client; disabling revokes its tokens
+	UpdateClientStatus(clientID, status string) error
+	// RotateSecret replaces a client's secret and revokes its tokens
+	RotateSecret(clientID string) (*models.APIClientCredentials, error)
+	// Authenticate checks a client's credentials and address and returns the
+	// scopes to grant: those requested, or all of the client's if none are
+	Authenticate(clientID, secret, ipAddress string, requestedScopes []string) (*models.APIClient, []string, error)
+}
+
+type apiClientService struct {
+	clientRepo  repository.APIClientRepository
+	accountRepo repository.AccountRepository
+	sessionRepo repository.SessionRepository
+	txRunner    repository.TxRunner
+}
+
+func NewAPIClientService(
+	clientRepo repository.APIClientRepository,
+	accountRepo repository.AccountRepository,
+	sessionRepo repository.SessionRepository,
+	txRunner repository.TxRunner,
+) APIClientService {
+	return &apiClientService{
+		clientRepo:  clientRepo,
+		accountRepo: accountRepo,
+		sessionRepo: sessionRepo,
+		txRunner:    txRunner,
+	}
+}
+
+func (s *apiClientService) CreateClient(req *models.CreateAPIClientRequest, createdBy string) (*models.APIClientCredentials, error) {
+	req.Name = strings.TrimSpace(req.Name)
+
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.checkAccounts(req.AccountNumbers); err != nil {
+		return nil, err
+	}
+
+	secret := generateClientSecret()
+	now := time.Now().UTC()
+	client := &models.APIClient{
+		ClientID:       s.generateClientID(),
+		Name:           req.Name,
+		SecretHash:     hashClientSecret(secret),
+		Scopes:         req.Scopes,
+		AccountNumbers: req.AccountNumbers,
+		AllowedIPs:     req.AllowedIPs,
+		Status:         models.APIClientStatusActive,
+		CreatedBy:      createdBy,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+
+	err := s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		return s.clientRepo.WithTx(tx).Create(client)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create API client: %w", err)
+	}
+
+	return &models.APIClientCredentials{APIClient: client, ClientSecret: secret}, nil
+}
+
+// checkAccounts refuses account numbers that do not name an active account
+func (s *apiClientService) checkAccounts(accountNumbers []string) error {
+	for _, accountNumber := range accountNumbers {
+		account, err := s.accountRepo.GetByAccountNumber(accountNumber)
+		if err != nil {
+			return fmt.Errorf("account %s not found", accountNumber)
+		}
+		if !account.IsActive() {
+			return fmt.Errorf("account %s is not active", accountNumber)
+		}
+	}
+	return nil
+}
+
+func (s *apiClientService) GetClient(clientID string) (*models.APIClient, error) {
+	return s.clientRepo.GetByClientID(clientID)
+}
+
+func (s *apiClientService) GetClients(limit, offset int) ([]*models.APIClient, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	return s.clientRepo.GetAll(limit, offset)
+}
+
+func (s *apiClientService) UpdateClient(clientID string, req *models.UpdateAPIClientRequest) (*models.APIClient, error) {
+	req.Name = strings.TrimSpace(req.Name)
+
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	var client *models.APIClient
+	err := s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		clientRepo := s.clientRepo.WithTx(tx)
+
+		var err error
+		client, err = clientRepo.GetByClientID(clientID)
+		if err != nil {
+			return err
+		}
+
+		if req.Name != "" {
+			client.Name = req.Name
+		}
+		if req.Scopes != nil {
+			client.Scopes = req.Scopes
+		}
+		if req.AccountNumbers != nil {
+			if err := s.checkAccounts(req.AccountNumbers); err != nil {
+				return err
+			}
+			client.AccountNumbers = req.AccountNumbers
+		}
+		if req.AllowedIPs != nil {
+			client.AllowedIPs = req.AllowedIPs
+		}
+		client.UpdatedAt = time.Now().UTC()
+
+		return clientRepo.Update(client)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.clientRepo.GetByClientID(clientID)
+}
+
+func (s *apiClientService) UpdateClientStatus(clientID, status string) error {
+	if status != models.APIClientStatusActive && status != models.APIClientStatusDisabled {
+		return fmt.Errorf("invalid API client status: %s", status)
+	}
+
+	return s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		if err := s.clientRepo.WithTx(tx).UpdateStatus(clientID, status); err != nil {
+			return err
+		}
+		if status != models.APIClientStatusDisabled {
+			return nil
+		}
+
+		_, err := s.sessionRepo.WithTx(tx).RevokeSubjectSessions(models.SubjectAPIClient, clientID, "", models.RevokedClientDisabled)
+		return err
+	})
+}
+
+func (s *apiClientService) RotateSecret(clientID string) (*models.APIClientCredentials, error) {
+	secret := generateClientSecret()
+
+	err := s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		if err := s.clientRepo.WithTx(tx).UpdateSecret(clientID, hashClientSecret(secret)); err != nil {
+			return err
+		}
+
+		_, err := s.sessionRepo.WithTx(tx).RevokeSubjectSessions(models.SubjectAPIClient, clientID, "", models.RevokedSecretRotated)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := s.clientRepo.GetByClientID(clientID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.APIClientCredentials{APIClient: client, ClientSecret: secret}, nil
+}
+
+func (s *apiClientService) Authenticate(clientID, secret, ipAddress string, requestedScopes []string) (*models.APIClient, []string, error) {
+	if clientID == "" || secret == "" {
+		return nil, nil, ErrInvalidClient
+	}
+
+	client, err := s.clientRepo.GetByClientID(clientID)
+	if err != nil {
+		return nil, nil, ErrInvalidClient
+	}
+
+	if subtle.ConstantTimeCompare([]byte(hashClientSecret(secret)), []byte(client.SecretHash)) != 1 {
+		return nil, nil, ErrInvalidClient
+	}
+	if !client.IsActive() || !client.AllowsIP(ipAddress) {
+		return nil, nil, ErrInvalidClient
+	}
+
+	scopes := client.Scopes
+	if len(requestedScopes) > 0 {
+		for _, scope := range requestedScopes {
+			if !client.HasScope(scope) {
+				return nil, nil, ErrInvalidScope
+			}
+		}
+		scopes = requestedScopes
+	}
+
+	s.clientRepo.UpdateLastUsed(client.ClientID, time.Now().UTC())
+
+	return client, scopes, nil
+}
+
+func (s *apiClientService) generateClientID() string {
+	randomBytes := make([]byte, 8)
+	rand.Read(randomBytes)
+
+	return fmt.Sprintf("CLI%x", randomBytes)
+}
+
+// generateClientSecret returns an opaque 256-bit secret; only its hash is stored
+func generateClientSecret() string {
+	randomBytes := make([]byte, 32)
+	rand.Read(randomBytes)
+
+	return base64.RawURLEncoding.EncodeToString(randomBytes)
+}
+
+func hashClientSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,206 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+
+	"github.com/bank-api/internal/ledger"
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/repository"
+)
+
+// ClearingService moves outbound payments to other banks through the
+// interbank gateway and applies the gateway's settlement outcome
+type ClearingService interface {
+	GetPayment(paymentID string) (*models.OutboundPayment, error)
+	// DispatchQueued submits up to batchSize queued payments to the gateway and
+	// returns how many it accepted
+	DispatchQueued(batchSize int) (int, error)
+	// HandleCallback settles or rejects a payment as reported by the gateway.
+	// Repeating a callback that has already been applied is a no-op.
+	HandleCallback(callback *models.ClearingCallback) (*models.OutboundPayment, error)
+}
+
+type clearingService struct {
+	paymentRepo     repository.OutboundPaymentRepository
+	transactionRepo repository.TransactionRepository
+	accountRepo     repository.AccountRepository
+	ledger          ledger.Ledger
+	txRunner        repository.TxRunner
+	gateway         PaymentGateway
+}
+
+func NewClearingService(paymentRepo repository.OutboundPaymentRepository, transactionRepo repository.TransactionRepository, accountRepo repository.AccountRepository, ledger ledger.Ledger, txRunner repository.TxRunner, gateway PaymentGateway) ClearingService {
+	return &clearingService{
+		paymentRepo:     paymentRepo,
+		transactionRepo: transactionRepo,
+		accountRepo:     accountRepo,
+		ledger:          ledger,
+		txRunner:        txRunner,
+		gateway:         gateway,
+	}
+}
+
+func (s *clearingService) GetPayment(paymentID string) (*models.OutboundPayment, error) {
+	return s.paymentRepo.GetByPaymentID(paymentID)
+}
+
+func (s *clearingService) DispatchQueued(batchSize int) (int, error) {
+	dispatched := 0
+
+	err := s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		dispatched = 0
+
+		payments := s.paymentRepo.WithTx(tx)
+
+		queued, err := payments.LockQueued(batchSize)
+		if err != nil {
+			return err
+		}
+
+		// The row locks are held while the gateway is called so a payment is
+		// never submitted by two dispatchers at once
+		for _, payment := range queued {
+			reference, err := s.gateway.Submit(payment)
+			if err != nil {
+				log.Printf("Failed to submit outbound payment %s: %v", payment.PaymentID, err)
+				if err := payments.RecordAttempt(payment.PaymentID, err.Error()); err != nil {
+					return err
+				}
+				continue
+			}
+
+			if err := payments.MarkSubmitted(payment.PaymentID, reference); err != nil {
+				return err
+			}
+			dispatched++
+		}
+
+		return nil
+	})
+
+	return dispatched, err
+}
+
+func (s *clearingService) HandleCallback(callback *models.ClearingCallback) (*models.OutboundPayment, error) {
+	if callback.PaymentID == "" {
+		return nil, fmt.Errorf("payment_id is required")
+	}
+
+	var status string
+	switch callback.Status {
+	case models.OutboundPaymentStatusSettled:
+		status = models.OutboundPaymentStatusSettled
+	case models.OutboundPaymentStatusRejected:
+		if callback.Reason == "" {
+			return nil, fmt.Errorf("reason is required when a payment is rejected")
+		}
+		status = models.OutboundPaymentStatusRejected
+	default:
+		return nil, fmt.Errorf("invalid payment status: %s", callback.Status)
+	}
+
+	var payment *models.OutboundPayment
+	err := s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		payments := s.paymentRepo.WithTx(tx)
+
+		current, err := payments.GetByPaymentIDForUpdate(callback.PaymentID)
+		if err != nil {
+			return err
+		}
+
+		if current.IsClosed() {
+			if current.Status != status {
+				return fmt.Errorf("outbound payment %s is already %s", current.PaymentID, current.Status)
+			}
+			payment = current
+			return nil
+		}
+
+		if callback.GatewayReference != "" && current.GatewayReference != "" && callback.GatewayReference != current.GatewayReference {
+			return fmt.Errorf("gateway reference does not match outbound payment %s", current.PaymentID)
+		}
+
+		if status == models.OutboundPaymentStatusSettled {
+			err = s.settle(tx, current)
+		} else {
+			err = s.reject(tx, current, callback.Reason)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := payments.Close(current.PaymentID, status, callback.Reason); err != nil {
+			return err
+		}
+
+		payment, err = payments.GetByPaymentID(current.PaymentID)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+// settle clears the suspense against the bank's settlement account and
+// completes the transfer
+func (s *clearingService) settle(tx *sql.Tx, payment *models.OutboundPayment) error {
+	transactions := s.transactionRepo.WithTx(tx)
+
+	if _, err := transactions.GetByTransactionIDForUpdate(payment.TransactionID); err != nil {
+		return err
+	}
+
+	entry := ledger.NewJournalEntry(payment.TransactionID, "Settlement of outbound payment "+payment.PaymentID).
+		DebitGL(ledger.GLClearingSuspense, payment.Currency, payment.Amount).
+		CreditGL(ledger.GLNostro, payment.Currency, payment.Amount)
+
+	if err := s.ledger.WithTx(tx).Post(entry); err != nil {
+		return err
+	}
+
+	return transactions.UpdateStatus(payment.TransactionID, models.TransactionStatusCompleted)
+}
+
+// reject returns the amount and the fee from suspense to the customer and
+// fails the transfer with the receiving bank's reason
+func (s *clearingService) reject(tx *sql.Tx, payment *models.OutboundPayment, reason string) error {
+	transactions := s.transactionRepo.WithTx(tx)
+
+	transaction, err := transactions.GetByTransactionIDForUpdate(payment.TransactionID)
+	if err != nil {
+		return err
+	}
+
+	locked, err := s.accountRepo.WithTx(tx).LockByIDs(transaction.FromAccountID)
+	if err != nil {
+		return err
+	}
+	account := locked[transaction.FromAccountID]
+
+	entry := ledger.NewJournalEntry(payment.TransactionID, "Return of rejected outbound payment "+payment.PaymentID).
+		DebitGL(ledger.GLClearingSuspense, payment.Currency, payment.Amount).
+		DebitGL(ledger.GLFeeIncome, account.Currency, transaction.Fee).
+		CreditAccount(account.ID, account.Currency, payment.Amount+transaction.Fee)
+
+	if err := s.ledger.WithTx(tx).Post(entry); err != nil {
+		return err
+	}
+
+	// The refunded fee's FEE transaction fails along with the transfer
+	fees, err := transactions.GetFees(payment.TransactionID)
+	if err != nil {
+		return err
+	}
+	for _, fee := range fees {
+		if err := transactions.MarkFailed(fee.TransactionID, reason); err != nil {
+			return err
+		}
+	}
+
+	return transactions.MarkFailed(payment.TransactionID, reason)
+}
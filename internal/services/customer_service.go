@@ -0,0 +1,147 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/repository"
+)
+
+// ErrInvalidCredentials is returned for a wrong password or unknown
+// customer; callers should not learn which
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+type CustomerService interface {
+	GetCustomer(customerID string) (*models.Customer, error)
+	UpdateCustomer(customerID string, req *models.UpdateCustomerRequest) error
+	// AuthenticateCustomer checks a customer's credentials. Attempts the
+	// login guard refuses fail with a *models.LoginThrottledError; the
+	// caller records the login once it completes.
+	AuthenticateCustomer(customerID, password string, client ClientInfo) (*models.Customer, error)
+	// AuthenticateByAccountNumber checks the credentials of the customer
+	// owning the account, for clients that still log in by account number
+	AuthenticateByAccountNumber(accountNumber, password string, client ClientInfo) (*models.Customer, error)
+}
+
+type customerService struct {
+	customerRepo repository.CustomerRepository
+	accountRepo  repository.AccountRepository
+	loginGuard   LoginGuard
+}
+
+func NewCustomerService(customerRepo repository.CustomerRepository, accountRepo repository.AccountRepository, loginGuard LoginGuard) CustomerService {
+	return &customerService{
+		customerRepo: customerRepo,
+		accountRepo:  accountRepo,
+		loginGuard:   loginGuard,
+	}
+}
+
+func (s *customerService) GetCustomer(customerID string) (*models.Customer, error) {
+	customer, err := s.customerRepo.GetByCustomerID(customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Clear password from response
+	customer.HashPassword = ""
+	return customer, nil
+}
+
+func (s *customerService) UpdateCustomer(customerID string, req *models.UpdateCustomerRequest) error {
+	customer, err := s.customerRepo.GetByCustomerID(customerID)
+	if err != nil {
+		return fmt.Errorf("customer not found: %w", err)
+	}
+
+	// Update fields if provided
+	if req.FirstName != "" {
+		customer.FirstName = req.FirstName
+	}
+	if req.LastName != "" {
+		customer.LastName = req.LastName
+	}
+	if req.Email != "" && req.Email != customer.Email {
+		if err := models.ValidateEmail(req.Email); err != nil {
+			return err
+		}
+		exists, err := s.customerRepo.EmailExists(req.Email)
+		if err != nil {
+			return fmt.Errorf("failed to check email: %w", err)
+		}
+		if exists {
+			return fmt.Errorf("email is already in use")
+		}
+		customer.Email = req.Email
+	}
+	if req.Phone != "" {
+		if err := models.ValidatePhone(req.Phone); err != nil {
+			return err
+		}
+		customer.Phone = req.Phone
+	}
+	if req.Address.Street != "" || req.Address.City != "" || req.Address.PostalCode != "" || req.Address.Country != "" {
+		customer.Address = req.Address
+	}
+
+	return s.customerRepo.Update(customer)
+}
+
+func (s *customerService) AuthenticateCustomer(customerID, password string, client ClientInfo) (*models.Customer, error) {
+	customer, err := s.customerRepo.GetByCustomerID(customerID)
+	if err != nil {
+		customer = nil
+	}
+
+	return s.checkCredentials(customerID, customer, password, client)
+}
+
+func (s *customerService) AuthenticateByAccountNumber(accountNumber, password string, client ClientInfo) (*models.Customer, error) {
+	var customer *models.Customer
+	if account, err := s.accountRepo.GetByAccountNumber(accountNumber); err == nil {
+		if customer, err = s.customerRepo.GetByCustomerID(account.CustomerID); err != nil {
+			customer = nil
+		}
+	}
+
+	return s.checkCredentials(accountNumber, customer, password, client)
+}
+
+// checkCredentials checks a password against the customer an identifier
+// resolved to, or nil, recording any failure with the login guard
+func (s *customerService) checkCredentials(identifier string, customer *models.Customer, password string, client ClientInfo) (*models.Customer, error) {
+	if err := s.loginGuard.Allow(customer, client.IPAddress); err != nil {
+		var throttled *models.LoginThrottledError
+		if errors.As(err, &throttled) {
+			if err := s.loginGuard.Failed(customer, identifier, throttled.Reason(), client); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+
+	reason := ""
+	switch {
+	case customer == nil:
+		reason = models.LoginFailureUnknownCustomer
+	case !customer.IsActive():
+		reason = models.LoginFailureCustomerInactive
+	case !customer.ValidatePassword(password):
+		reason = models.LoginFailureInvalidPassword
+	}
+	if reason != "" {
+		if err := s.loginGuard.Failed(customer, identifier, reason, client); err != nil {
+			return nil, err
+		}
+		if reason == models.LoginFailureCustomerInactive {
+			return nil, fmt.Errorf("customer is not active")
+		}
+		return nil, ErrInvalidCredentials
+	}
+
+	// Clear password from response
+	customer.HashPassword = ""
+
+	return customer, nil
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/repository"
+)
+
+type FeeService interface {
+	CreateSchedule(req *models.FeeScheduleRequest) (*models.FeeSchedule, error)
+	GetSchedule(id int) (*models.FeeSchedule, error)
+	GetSchedules() ([]*models.FeeSchedule, error)
+	UpdateSchedule(id int, req *models.FeeScheduleRequest) (*models.FeeSchedule, error)
+	// DeactivateSchedule stops a schedule from pricing transactions; it is
+	// kept for reference
+	DeactivateSchedule(id int) (*models.FeeSchedule, error)
+	// Quote previews the fee the account would pay for a transaction now
+	Quote(req *models.FeeQuoteRequest) (*models.FeeQuote, error)
+}
+
+type feeService struct {
+	scheduleRepo repository.FeeScheduleRepository
+	accountRepo  repository.AccountRepository
+	fees         feeEngine
+}
+
+func NewFeeService(scheduleRepo repository.FeeScheduleRepository, accountRepo repository.AccountRepository, transactionRepo repository.TransactionRepository) FeeService {
+	return &feeService{
+		scheduleRepo: scheduleRepo,
+		accountRepo:  accountRepo,
+		fees:         feeEngine{scheduleRepo: scheduleRepo, transactionRepo: transactionRepo},
+	}
+}
+
+func (s *feeService) CreateSchedule(req *models.FeeScheduleRequest) (*models.FeeSchedule, error) {
+	now := time.Now().UTC()
+	schedule := &models.FeeSchedule{CreatedAt: now, UpdatedAt: now}
+	applyFeeScheduleRequest(schedule, req)
+
+	if err := s.checkSchedule(schedule); err != nil {
+		return nil, err
+	}
+
+	if err := s.scheduleRepo.Create(schedule); err != nil {
+		return nil, fmt.Errorf("failed to create fee schedule: %w", err)
+	}
+
+	return schedule, nil
+}
+
+func (s *feeService) GetSchedule(id int) (*models.FeeSchedule, error) {
+	return s.scheduleRepo.GetByID(id)
+}
+
+func (s *feeService) GetSchedules() ([]*models.FeeSchedule, error) {
+	return s.scheduleRepo.GetAll()
+}
+
+func (s *feeService) UpdateSchedule(id int, req *models.FeeScheduleRequest) (*models.FeeSchedule, error) {
+	schedule, err := s.scheduleRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	applyFeeScheduleRequest(schedule, req)
+
+	if err := s.checkSchedule(schedule); err != nil {
+		return nil, err
+	}
+
+	if err := s.scheduleRepo.Update(schedule); err != nil {
+		return nil, fmt.Errorf("failed to update fee schedule: %w", err)
+	}
+
+	return schedule, nil
+}
+
+func (s *feeService) DeactivateSchedule(id int) (*models.FeeSchedule, error) {
+	schedule, err := s.scheduleRepo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !schedule.Active {
+		return nil, fmt.Errorf("fee schedule is already inactive")
+	}
+
+	schedule.Active = false
+	if err := s.scheduleRepo.Update(schedule); err != nil {
+		return nil, fmt.Errorf("failed to update fee schedule: %w", err)
+	}
+
+	return schedule, nil
+}
+
+// checkSchedule validates a schedule and makes sure no other active schedule
+// has the same key
+func (s *feeService) checkSchedule(schedule *models.FeeSchedule) error {
+	if err := schedule.Validate(); err != nil {
+		return err
+	}
+	if !schedule.Active {
+		return nil
+	}
+
+	active, err := s.scheduleRepo.GetActive(schedule.TransactionType)
+	if err != nil {
+		return err
+	}
+	for _, other := range active {
+		if other.ID != schedule.ID && other.AccountType == schedule.AccountType &&
+			other.Channel == schedule.Channel && other.Currency == schedule.Currency {
+			return fmt.Errorf("fee schedule %d already prices these transactions", other.ID)
+		}
+	}
+
+	return nil
+}
+
+func applyFeeScheduleRequest(schedule *models.FeeSchedule, req *models.FeeScheduleRequest) {
+	schedule.AccountType = req.AccountType
+	schedule.TransactionType = req.TransactionType
+	schedule.Channel = req.Channel
+	schedule.Currency = req.Currency
+	schedule.FlatFee = req.FlatFee
+	schedule.PercentageBps = req.PercentageBps
+	schedule.Tiers = req.Tiers
+	schedule.MinFee = req.MinFee
+	schedule.MaxFee = req.MaxFee
+	schedule.FreePerMonth = req.FreePerMonth
+	schedule.Active = req.Active == nil || *req.Active
+}
+
+func (s *feeService) Quote(req *models.FeeQuoteRequest) (*models.FeeQuote, error) {
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+	if !models.IsFeeableTransactionType(req.TransactionType) {
+		return nil, fmt.Errorf("transaction type must be TRANSFER, WITHDRAWAL or EXTERNAL_TRANSFER")
+	}
+	if req.Channel == "" {
+		req.Channel = models.ChannelOnline
+	}
+	if !models.IsValidChannel(req.Channel) {
+		return nil, fmt.Errorf("channel must be ONLINE, BRANCH, STANDING_ORDER or BULK")
+	}
+
+	account, err := s.accountRepo.GetByAccountNumber(req.AccountNumber)
+	if err != nil {
+		return nil, fmt.Errorf("account not found")
+	}
+
+	return s.fees.assess(account, req.TransactionType, req.Channel, req.Amount, "")
+}
+
+// feeEngine prices transactions against the active fee schedules
+type feeEngine struct {
+	scheduleRepo    repository.FeeScheduleRepository
+	transactionRepo repository.TransactionRepository
+}
+
+// withTx returns an engine counting the free quota inside tx
+func (e feeEngine) withTx(tx *sql.Tx) feeEngine {
+	return feeEngine{scheduleRepo: e.scheduleRepo, transactionRepo: e.transactionRepo.WithTx(tx)}
+}
+
+// assess prices a transaction from account. Transactions within the
+// schedule's free monthly quota are charged nothing; the transaction being
+// priced, excludeTransactionID, does not count towards it.
+func (e feeEngine) assess(account *models.Account, transactionType, channel string, amount int64, excludeTransactionID string) (*models.FeeQuote, error) {
+	quote := &models.FeeQuote{
+		AccountNumber:   account.AccountNumber,
+		TransactionType: transactionType,
+		Channel:         channel,
+		Currency:        account.Currency,
+		Amount:          amount,
+		Total:           amount,
+	}
+
+	schedules, err := e.scheduleRepo.GetActive(transactionType)
+	if err != nil {
+		return nil, err
+	}
+	schedule := models.MatchFeeSchedule(schedules, account.AccountType, transactionType, channel, account.Currency)
+	if schedule == nil {
+		return quote, nil
+	}
+	quote.ScheduleID = schedule.ID
+
+	if schedule.FreePerMonth > 0 {
+		now := time.Now().UTC()
+		monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		used, err := e.transactionRepo.CountBooked(account.AccountNumber, transactionType, schedule.Channel, monthStart, excludeTransactionID)
+		if err != nil {
+			return nil, err
+		}
+		if used < schedule.FreePerMonth {
+			quote.FreeRemaining = schedule.FreePerMonth - used
+			return quote, nil
+		}
+	}
+
+	quote.Fee = schedule.Calculate(amount)
+	quote.Total = amount + quote.Fee
+	return quote, nil
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/repository"
+)
+
+// stubFeeScheduleRepository serves a fixed list of active schedules
+type stubFeeScheduleRepository struct {
+	repository.FeeScheduleRepository
+	schedules []*models.FeeSchedule
+}
+
+func (r *stubFeeScheduleRepository) GetActive(transactionType string) ([]*models.FeeSchedule, error) {
+	return r.schedules, nil
+}
+
+// stubBookedTransactionRepository reports a fixed number of transactions
+// already booked this month
+type stubBookedTransactionRepository struct {
+	repository.TransactionRepository
+	booked int
+}
+
+func (r *stubBookedTransactionRepository) CountBooked(accountNumber, transactionType, channel string, since time.Time, excludeTransactionID string) (int, error) {
+	return r.booked, nil
+}
+
+func TestFeeEngineAssessFreeQuota(t *testing.T) {
+	schedule := &models.FeeSchedule{
+		ID:              7,
+		TransactionType: models.TransactionTypeTransfer,
+		FlatFee:         500,
+		FreePerMonth:    3,
+		Active:          true,
+	}
+	account := &models.Account{
+		AccountNumber: "TN0000000001",
+		AccountType:   models.AccountTypeChecking,
+		Currency:      models.CurrencyTND,
+	}
+
+	tests := []struct {
+		name              string
+		booked            int
+		wantFee           int64
+		wantFreeRemaining int
+	}{
+		{name: "first of the month", booked: 0, wantFee: 0, wantFreeRemaining: 3},
+		{name: "last free one", booked: 2, wantFee: 0, wantFreeRemaining: 1},
+		{name: "quota used up", booked: 3, wantFee: 500},
+		{name: "well past the quota", booked: 10, wantFee: 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := feeEngine{
+				scheduleRepo:    &stubFeeScheduleRepository{schedules: []*models.FeeSchedule{schedule}},
+				transactionRepo: &stubBookedTransactionRepository{booked: tt.booked},
+			}
+
+			quote, err := engine.assess(account, models.TransactionTypeTransfer, models.ChannelOnline, 100000, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if quote.Fee != tt.wantFee || quote.FreeRemaining != tt.wantFreeRemaining {
+				t.Errorf("fee %d, free remaining %d, want %d and %d", quote.Fee, quote.FreeRemaining, tt.wantFee, tt.wantFreeRemaining)
+			}
+			if quote.Total != 100000+tt.wantFee || quote.ScheduleID != schedule.ID {
+				t.Errorf("total %d, schedule %d, want %d and %d", quote.Total, quote.ScheduleID, 100000+tt.wantFee, schedule.ID)
+			}
+		})
+	}
+}
+
+func TestFeeEngineAssessWithoutSchedule(t *testing.T) {
+	engine := feeEngine{
+		scheduleRepo:    &stubFeeScheduleRepository{},
+		transactionRepo: &stubBookedTransactionRepository{},
+	}
+	account := &models.Account{AccountNumber: "TN0000000001", AccountType: models.AccountTypeChecking, Currency: models.CurrencyTND}
+
+	quote, err := engine.assess(account, models.TransactionTypeTransfer, models.ChannelOnline, 100000, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quote.Fee != 0 || quote.Total != 100000 || quote.ScheduleID != 0 {
+		t.Errorf("quote without a schedule: %+v", quote)
+	}
+}
@@ -0,0 +1,140 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/bank-api/internal/models"
+)
+
+// FXRateProvider supplies mid-market exchange rates
+type FXRateProvider interface {
+	GetRate(base, quote string) (*models.FXRate, error)
+}
+
+// NewFXRateProvider selects the exchange rate source configured by
+// FX_PROVIDER. The static provider reads ratesFile when it is set.
+func NewFXRateProvider(kind, ratesFile string) (FXRateProvider, error) {
+	switch kind {
+	case "static":
+		if ratesFile != "" {
+			return NewFileRateProvider(ratesFile)
+		}
+		return NewStaticRateProvider(nil), nil
+	case "bct-mock":
+		return NewBCTMockRateProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown FX provider: %s", kind)
+	}
+}
+
+// Reference mid rates in TND per unit of currency, used by the BCT stand-in
+// and as the default static table
+var referenceTNDRates = map[string]float64{
+	models.CurrencyTND: 1.0,
+	models.CurrencyEUR: 3.3850,
+	models.CurrencyUSD: 3.1240,
+}
+
+// StaticRateProvider serves a fixed table of rates expressed in TND per unit
+// of each currency; cross rates are derived through TND
+type StaticRateProvider struct {
+	tndRates map[string]float64
+	source   string
+	asOf     time.Time
+}
+
+func NewStaticRateProvider(tndRates map[string]float64) FXRateProvider {
+	rates := map[string]float64{models.CurrencyTND: 1.0}
+	for currency, rate := range tndRates {
+		rates[currency] = rate
+	}
+
+	return &StaticRateProvider{tndRates: rates, source: "STATIC", asOf: time.Now().UTC()}
+}
+
+// NewFileRateProvider loads a static rate table from a JSON file mapping
+// currency codes to their value in TND, e.g. {"EUR": 3.385, "USD": 3.124}
+func NewFileRateProvider(path string) (FXRateProvider, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read FX rates file: %w", err)
+	}
+
+	var tndRates map[string]float64
+	if err := json.Unmarshal(content, &tndRates); err != nil {
+		return nil, fmt.Errorf("invalid FX rates file: %w", err)
+	}
+
+	for currency, rate := range tndRates {
+		if rate <= 0 {
+			return nil, fmt.Errorf("invalid FX rate for %s: %v", currency, rate)
+		}
+	}
+
+	provider := NewStaticRateProvider(tndRates).(*StaticRateProvider)
+	provider.source = "FILE"
+	return provider, nil
+}
+
+func (p *StaticRateProvider) GetRate(base, quote string) (*models.FXRate, error) {
+	mid, err := crossRate(p.tndRates, base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.FXRate{Base: base, Quote: quote, Mid: mid, Source: p.source, AsOf: p.asOf}, nil
+}
+
+// BCTMockRateProvider stands in for the Central Bank of Tunisia's published
+// interbank rates. Rates drift deterministically (within ±0.5%) around the
+// reference table over the day so that quotes visibly change over time.
+type BCTMockRateProvider struct {
+	now func() time.Time
+}
+
+func NewBCTMockRateProvider() FXRateProvider {
+	return &BCTMockRateProvider{now: time.Now}
+}
+
+func (p *BCTMockRateProvider) GetRate(base, quote string) (*models.FXRate, error) {
+	now := p.now().UTC()
+	dayFraction := float64(now.Hour()*60+now.Minute()) / (24 * 60)
+
+	rates := make(map[string]float64, len(referenceTNDRates))
+	phase := 0.0
+	for _, currency := range []string{models.CurrencyTND, models.CurrencyEUR, models.CurrencyUSD} {
+		rate := referenceTNDRates[currency]
+		if currency != models.CurrencyTND {
+			rate *= 1 + 0.005*math.Sin(2*math.Pi*dayFraction+phase)
+			phase += math.Pi / 3
+		}
+		rates[currency] = rate
+	}
+
+	mid, err := crossRate(rates, base, quote)
+	if err != nil {
+		return nil, err
+	}
+
+	// BCT publishes rates by the minute in this stand-in
+	return &models.FXRate{Base: base, Quote: quote, Mid: mid, Source: "BCT-MOCK", AsOf: now.Truncate(time.Minute)}, nil
+}
+
+// crossRate derives the base/quote rate from rates expressed in TND
+func crossRate(tndRates map[string]float64, base, quote string) (float64, error) {
+	baseRate, ok := tndRates[base]
+	if !ok {
+		return 0, fmt.Errorf("no exchange rate available for %s", base)
+	}
+
+	quoteRate, ok := tndRates[quote]
+	if !ok {
+		return 0, fmt.Errorf("no exchange rate available for %s", quote)
+	}
+
+	return baseRate / quoteRate, nil
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/repository"
+)
+
+type FXService interface {
+	// Quote prices a conversion at the current rate minus the bank's spread
+	// and stores it, locking the rate for the configured quote lifetime
+	Quote(accountNumber, fromCurrency, toCurrency string, amount int64) (*models.FXQuote, error)
+	GetQuote(quoteID string) (*models.FXQuote, error)
+}
+
+type fxService struct {
+	provider      FXRateProvider
+	quoteRepo     repository.FXQuoteRepository
+	quoteTTL      time.Duration
+	buySpreadBps  int
+	sellSpreadBps int
+}
+
+// NewFXService creates the FX service. The buy spread applies when the bank
+// buys foreign currency from the customer (conversions into TND); the sell
+// spread applies when it sells foreign currency (conversions out of TND and
+// between two foreign currencies).
+func NewFXService(provider FXRateProvider, quoteRepo repository.FXQuoteRepository, quoteTTL time.Duration, buySpreadBps, sellSpreadBps int) FXService {
+	return &fxService{
+		provider:      provider,
+		quoteRepo:     quoteRepo,
+		quoteTTL:      quoteTTL,
+		buySpreadBps:  buySpreadBps,
+		sellSpreadBps: sellSpreadBps,
+	}
+}
+
+func (s *fxService) Quote(accountNumber, fromCurrency, toCurrency string, amount int64) (*models.FXQuote, error) {
+	if fromCurrency == toCurrency {
+		return nil, fmt.Errorf("cannot quote a conversion between identical currencies")
+	}
+
+	if amount < 0 {
+		return nil, fmt.Errorf("quote amount must be positive")
+	}
+
+	rate, err := s.provider.GetRate(fromCurrency, toCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	spreadBps := s.sellSpreadBps
+	if toCurrency == models.CurrencyTND {
+		spreadBps = s.buySpreadBps
+	}
+
+	now := time.Now().UTC()
+	quote := &models.FXQuote{
+		QuoteID:       s.generateQuoteID(),
+		AccountNumber: accountNumber,
+		FromCurrency:  fromCurrency,
+		ToCurrency:    toCurrency,
+		MidRate:       rate.Mid,
+		Rate:          rate.Mid * (1 - float64(spreadBps)/10000),
+		SpreadBps:     spreadBps,
+		Amount:        amount,
+		Source:        rate.Source,
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(s.quoteTTL),
+	}
+
+	if amount > 0 {
+		if quote.ConvertedAmount, err = convertAmount(amount, fromCurrency, toCurrency, quote.Rate); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.quoteRepo.Create(quote); err != nil {
+		return nil, fmt.Errorf("failed to save FX quote: %w", err)
+	}
+
+	return quote, nil
+}
+
+func (s *fxService) GetQuote(quoteID string) (*models.FXQuote, error) {
+	return s.quoteRepo.GetByQuoteID(quoteID)
+}
+
+func (s *fxService) generateQuoteID() string {
+	timestamp := time.Now().Unix()
+	randomBytes := make([]byte, 6)
+	rand.Read(randomBytes)
+
+	return fmt.Sprintf("FXQ%d%x", timestamp, randomBytes)
+}
+
+// convertAmount converts an amount between currencies' minor units at the
+// given rate (units of toCurrency per unit of fromCurrency), rounding to the
+// nearest minor unit
+func convertAmount(amount int64, fromCurrency, toCurrency string, rate float64) (int64, error) {
+	fromUnits, ok := models.CurrencyMinorUnits(fromCurrency)
+	if !ok {
+		return 0, fmt.Errorf("unsupported currency: %s", fromCurrency)
+	}
+
+	toUnits, ok := models.CurrencyMinorUnits(toCurrency)
+	if !ok {
+		return 0, fmt.Errorf("unsupported currency: %s", toCurrency)
+	}
+
+	scale := math.Pow10(toUnits - fromUnits)
+	return int64(math.Round(float64(amount) * rate * scale)), nil
+}
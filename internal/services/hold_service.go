@@ -0,0 +1,332 @@
+package services
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bank-api/internal/ledger"
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/repository"
+)
+
+type HoldService interface {
+	PlaceHold(req *models.PlaceHoldRequest) (*models.Hold, error)
+	// CaptureHold turns an active hold into a completed PAYMENT transaction
+	// for the captured amount and releases whatever is left of the hold
+	CaptureHold(req *models.CaptureHoldRequest) (*models.Hold, error)
+	ReleaseHold(holdID string) (*models.Hold, error)
+	GetHold(holdID string) (*models.Hold, error)
+	GetAccountHolds(accountNumber, status string, limit, offset int) ([]*models.Hold, error)
+	// ExpireHolds releases up to batchSize active holds past their expiry and
+	// returns how many were expired
+	ExpireHolds(batchSize int) (int, error)
+}
+
+type holdService struct {
+	holdRepo        repository.HoldRepository
+	accountRepo     repository.AccountRepository
+	transactionRepo repository.TransactionRepository
+	ledger          ledger.Ledger
+	txRunner        repository.TxRunner
+	defaultTTL      time.Duration
+	maxTTL          time.Duration
+}
+
+func NewHoldService(holdRepo repository.HoldRepository, accountRepo repository.AccountRepository, transactionRepo repository.TransactionRepository, ledger ledger.Ledger, txRunner repository.TxRunner, defaultTTL, maxTTL time.Duration) HoldService {
+	return &holdService{
+		holdRepo:        holdRepo,
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+		ledger:          ledger,
+		txRunner:        txRunner,
+		defaultTTL:      defaultTTL,
+		maxTTL:          maxTTL,
+	}
+}
+
+func (s *holdService) PlaceHold(req *models.PlaceHoldRequest) (*models.Hold, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	expiresAt := now.Add(s.defaultTTL)
+	if req.ExpiresAt != nil {
+		expiresAt = req.ExpiresAt.UTC()
+		if !expiresAt.After(now) {
+			return nil, fmt.Errorf("hold expiry must be in the future")
+		}
+		if expiresAt.After(now.Add(s.maxTTL)) {
+			return nil, fmt.Errorf("hold expiry cannot be more than %s ahead", s.maxTTL)
+		}
+	}
+
+	account, err := s.accountRepo.GetByAccountNumber(req.AccountNumber)
+	if err != nil {
+		return nil, fmt.Errorf("account not found")
+	}
+
+	if req.Currency != account.Currency {
+		return nil, fmt.Errorf("hold currency %s does not match account currency %s", req.Currency, account.Currency)
+	}
+
+	hold := &models.Hold{
+		HoldID:            s.generateHoldID(),
+		AccountID:         account.ID,
+		AccountNumber:     account.AccountNumber,
+		Amount:            req.Amount,
+		Currency:          req.Currency,
+		MerchantReference: req.MerchantReference,
+		Description:       req.Description,
+		Status:            models.HoldStatusActive,
+		ExpiresAt:         expiresAt,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+
+	err = s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		accounts := s.accountRepo.WithTx(tx)
+
+		locked, err := accounts.LockByIDs(account.ID)
+		if err != nil {
+			return err
+		}
+		account := locked[hold.AccountID]
+
+		if !account.IsActive() {
+			return fmt.Errorf("account is not active")
+		}
+		if !account.HasSufficientBalance(hold.Amount) {
+			return fmt.Errorf("insufficient balance")
+		}
+
+		if err := s.holdRepo.WithTx(tx).Create(hold); err != nil {
+			return fmt.Errorf("failed to create hold: %w", err)
+		}
+
+		return accounts.AdjustHold(account.ID, hold.Amount)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return hold, nil
+}
+
+func (s *holdService) CaptureHold(req *models.CaptureHoldRequest) (*models.Hold, error) {
+	if req.Amount < 0 {
+		return nil, fmt.Errorf("capture amount must be positive")
+	}
+
+	var captured *models.Hold
+
+	err := s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		holds := s.holdRepo.WithTx(tx)
+		accounts := s.accountRepo.WithTx(tx)
+		transactions := s.transactionRepo.WithTx(tx)
+
+		hold, err := holds.GetByHoldIDForUpdate(req.HoldID)
+		if err != nil {
+			return fmt.Errorf("hold not found")
+		}
+
+		if !hold.IsActive() {
+			return fmt.Errorf("hold is %s and can no longer be captured", hold.Status)
+		}
+		if hold.IsExpired() {
+			return fmt.Errorf("hold has expired")
+		}
+
+		amount := req.Amount
+		if amount == 0 {
+			amount = hold.Amount
+		}
+		if amount > hold.Amount {
+			return fmt.Errorf("capture amount exceeds the held amount of %d", hold.Amount)
+		}
+
+		locked, err := accounts.LockByIDs(hold.AccountID)
+		if err != nil {
+			return err
+		}
+		account := locked[hold.AccountID]
+
+		// A hold on a frozen or closed account is released, not captured
+		if !account.IsActive() {
+			return fmt.Errorf("account is not active")
+		}
+
+		// The whole hold is released; only the captured part leaves the account
+		if err := accounts.AdjustHold(account.ID, -hold.Amount); err != nil {
+			return err
+		}
+
+		description := req.Description
+		if description == "" {
+			description = hold.Description
+		}
+
+		now := time.Now().UTC()
+		transaction := &models.Transaction{
+			TransactionID:     generateTransactionID(),
+			FromAccountID:     account.ID,
+			FromAccountNumber: account.AccountNumber,
+			Amount:            amount,
+			Currency:          hold.Currency,
+			ExchangeRate:      1.0,
+			ConvertedAmount:   amount,
+			TransactionType:   models.TransactionTypePayment,
+			Status:            models.TransactionStatusPending,
+			Description:       description,
+			Reference:         hold.MerchantReference,
+			CreatedAt:         now,
+			UpdatedAt:         now,
+		}
+
+		if err := transactions.Create(transaction); err != nil {
+			return fmt.Errorf("failed to create transaction: %w", err)
+		}
+
+		entry := ledger.NewJournalEntry(transaction.TransactionID, "Capture of hold "+hold.HoldID).
+			DebitAccount(account.ID, account.Currency, amount).
+			CreditGL(ledger.GLMerchantSettlement, account.Currency, amount)
+
+		if err := s.ledger.WithTx(tx).Post(entry); err != nil {
+			return err
+		}
+
+		if err := transactions.UpdateStatus(transaction.TransactionID, models.TransactionStatusCompleted); err != nil {
+			return err
+		}
+
+		if err := holds.Close(hold.HoldID, models.HoldStatusCaptured, amount, transaction.TransactionID); err != nil {
+			return err
+		}
+
+		hold.Status = models.HoldStatusCaptured
+		hold.CapturedAmount = amount
+		hold.TransactionID = transaction.TransactionID
+		hold.ClosedAt = &now
+		hold.UpdatedAt = now
+		captured = hold
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return captured, nil
+}
+
+func (s *holdService) ReleaseHold(holdID string) (*models.Hold, error) {
+	var released *models.Hold
+
+	err := s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		holds := s.holdRepo.WithTx(tx)
+
+		hold, err := holds.GetByHoldIDForUpdate(holdID)
+		if err != nil {
+			return fmt.Errorf("hold not found")
+		}
+
+		if !hold.IsActive() {
+			return fmt.Errorf("hold is %s and can no longer be released", hold.Status)
+		}
+
+		if err := s.closeHolds(tx, []*models.Hold{hold}, models.HoldStatusReleased); err != nil {
+			return err
+		}
+
+		released = hold
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return released, nil
+}
+
+func (s *holdService) GetHold(holdID string) (*models.Hold, error) {
+	return s.holdRepo.GetByHoldID(holdID)
+}
+
+func (s *holdService) GetAccountHolds(accountNumber, status string, limit, offset int) ([]*models.Hold, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	return s.holdRepo.GetByAccountNumber(accountNumber, status, limit, offset)
+}
+
+func (s *holdService) ExpireHolds(batchSize int) (int, error) {
+	expired := 0
+
+	err := s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		holds, err := s.holdRepo.WithTx(tx).LockExpired(time.Now().UTC(), batchSize)
+		if err != nil {
+			return err
+		}
+
+		if len(holds) == 0 {
+			return nil
+		}
+
+		if err := s.closeHolds(tx, holds, models.HoldStatusExpired); err != nil {
+			return err
+		}
+
+		expired = len(holds)
+		return nil
+	})
+
+	return expired, err
+}
+
+// closeHolds returns the amounts of locked, active holds to their accounts'
+// available balance and moves the holds to status
+func (s *holdService) closeHolds(tx *sql.Tx, holds []*models.Hold, status string) error {
+	accounts := s.accountRepo.WithTx(tx)
+
+	// Lock every affected account at once so the locks are taken in id order
+	accountIDs := make([]int, 0, len(holds))
+	for _, hold := range holds {
+		accountIDs = append(accountIDs, hold.AccountID)
+	}
+	if _, err := accounts.LockByIDs(accountIDs...); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for _, hold := range holds {
+		if err := accounts.AdjustHold(hold.AccountID, -hold.Amount); err != nil {
+			return err
+		}
+
+		if err := s.holdRepo.WithTx(tx).Close(hold.HoldID, status, 0, ""); err != nil {
+			return err
+		}
+
+		hold.Status = status
+		hold.ClosedAt = &now
+		hold.UpdatedAt = now
+	}
+
+	return nil
+}
+
+func (s *holdService) generateHoldID() string {
+	timestamp := time.Now().Unix()
+	randomBytes := make([]byte, 6)
+	rand.Read(randomBytes)
+
+	return fmt.Sprintf("HLD%d%x", timestamp, randomBytes)
+}
@@ -0,0 +1,335 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bank-api/internal/ledger"
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/repository"
+)
+
+// interestAccrualBatchSize is how many accounts a manual accrual run accrues
+// per query
+const interestAccrualBatchSize = 100
+
+type InterestService interface {
+	// CreateRateTable publishes rates for an account type from a business
+	// date on; earlier accruals keep the rates they were made at
+	CreateRateTable(req *models.InterestRateTableRequest) (*models.InterestRateTable, error)
+	GetRateTables() ([]*models.InterestRateTable, error)
+	// AccrueInterest accrues the interest of up to limit accounts not yet
+	// accrued for a past business date and returns how many it accrued
+	AccrueInterest(businessDate time.Time, limit int) (int, error)
+	// RunAccrual accrues every account for a past business date. Accounts
+	// already accrued for it are skipped, so running it again changes nothing.
+	RunAccrual(businessDate time.Time) (int, error)
+	// PendingBusinessDates returns the business dates up to yesterday that
+	// may have accounts left to accrue
+	PendingBusinessDates(now time.Time) ([]time.Time, error)
+	// CapitalizeInterest pays up to limit accounts the interest they accrued
+	// before the capitalization period containing now, and returns how many
+	// accounts it handled
+	CapitalizeInterest(now time.Time, limit int) (int, error)
+	GetAccrualReport(accountNumber string, first, last time.Time) (*models.InterestAccrualReport, error)
+}
+
+type interestService struct {
+	interestRepo    repository.InterestRepository
+	accountRepo     repository.AccountRepository
+	transactionRepo repository.TransactionRepository
+	ledger          ledger.Ledger
+	txRunner        repository.TxRunner
+	capitalization  string
+	withholdingBps  int
+	dayCount        int
+}
+
+// NewInterestService creates the interest service. Accrued interest is
+// capitalized every MONTHLY or QUARTERLY period, less withholdingBps of tax
+// withheld at source; daily rates are annual rates over dayCount days.
+func NewInterestService(interestRepo repository.InterestRepository, accountRepo repository.AccountRepository, transactionRepo repository.TransactionRepository, ledger ledger.Ledger, txRunner repository.TxRunner, capitalization string, withholdingBps, dayCount int) InterestService {
+	return &interestService{
+		interestRepo:    interestRepo,
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+		ledger:          ledger,
+		txRunner:        txRunner,
+		capitalization:  capitalization,
+		withholdingBps:  withholdingBps,
+		dayCount:        dayCount,
+	}
+}
+
+func (s *interestService) CreateRateTable(req *models.InterestRateTableRequest) (*models.InterestRateTable, error) {
+	effectiveFrom, err := time.Parse("2006-01-02", req.EffectiveFrom)
+	if err != nil {
+		return nil, fmt.Errorf("invalid effective date, expected YYYY-MM-DD")
+	}
+	if effectiveFrom.Before(truncateDay(time.Now().UTC())) {
+		return nil, fmt.Errorf("effective date cannot be in the past")
+	}
+
+	table := &models.InterestRateTable{
+		AccountType:   req.AccountType,
+		Currency:      req.Currency,
+		EffectiveFrom: effectiveFrom,
+		Tiers:         req.Tiers,
+		CreatedAt:     time.Now().UTC(),
+	}
+	if err := table.Validate(); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.interestRepo.GetRateTables()
+	if err != nil {
+		return nil, err
+	}
+	for _, other := range existing {
+		if other.AccountType == table.AccountType && other.Currency == table.Currency && other.EffectiveFrom.Equal(table.EffectiveFrom) {
+			return nil, fmt.Errorf("rate table %d already takes effect on that date", other.ID)
+		}
+	}
+
+	if err := s.interestRepo.CreateRateTable(table); err != nil {
+		return nil, fmt.Errorf("failed to create interest rate table: %w", err)
+	}
+
+	return table, nil
+}
+
+func (s *interestService) GetRateTables() ([]*models.InterestRateTable, error) {
+	return s.interestRepo.GetRateTables()
+}
+
+func (s *interestService) AccrueInterest(businessDate time.Time, limit int) (int, error) {
+	businessDate = truncateDay(businessDate)
+	if !businessDate.Before(truncateDay(time.Now().UTC())) {
+		return 0, fmt.Errorf("interest can only be accrued once the business date has ended")
+	}
+
+	accounts, err := s.interestRepo.AccountsToAccrue(businessDate, limit)
+	if err != nil {
+		return 0, err
+	}
+	if len(accounts) == 0 {
+		return 0, nil
+	}
+
+	tables, err := s.interestRepo.GetRateTables()
+	if err != nil {
+		return 0, err
+	}
+
+	endOfDay := businessDate.AddDate(0, 0, 1)
+	for _, account := range accounts {
+		table := models.MatchInterestRateTable(tables, account.AccountType, account.Currency, businessDate)
+		if table == nil {
+			return 0, fmt.Errorf("no interest rate table applies to account %s on %s", account.AccountNumber, businessDate.Format("2006-01-02"))
+		}
+
+		balance, err := s.ledger.AccountBalanceAt(account.ID, endOfDay)
+		if err != nil {
+			return 0, fmt.Errorf("closing balance of account %s: %w", account.AccountNumber, err)
+		}
+
+		// Another replica may have accrued it meanwhile; its accrual is kept
+		_, err = s.interestRepo.CreateAccrual(&models.InterestAccrual{
+			AccountID:     account.ID,
+			AccountNumber: account.AccountNumber,
+			BusinessDate:  businessDate,
+			Balance:       balance,
+			RateTableID:   table.ID,
+			AmountMicros:  table.DailyAccrual(balance, s.dayCount),
+			CreatedAt:     time.Now().UTC(),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to accrue interest of account %s: %w", account.AccountNumber, err)
+		}
+	}
+
+	return len(accounts), nil
+}
+
+func (s *interestService) RunAccrual(businessDate time.Time) (int, error) {
+	total := 0
+	for {
+		accrued, err := s.AccrueInterest(businessDate, interestAccrualBatchSize)
+		if err != nil {
+			return total, err
+		}
+		total += accrued
+		if accrued < interestAccrualBatchSize {
+			return total, nil
+		}
+	}
+}
+
+func (s *interestService) PendingBusinessDates(now time.Time) ([]time.Time, error) {
+	yesterday := truncateDay(now).AddDate(0, 0, -1)
+
+	// The last date accrued may have been interrupted part way, so it is
+	// checked again; without any accrual yet, accruals start yesterday
+	start, err := s.interestRepo.LastAccrualDate()
+	if err != nil {
+		return nil, err
+	}
+	if start.IsZero() {
+		start = yesterday
+	}
+
+	var dates []time.Time
+	for date := start; !date.After(yesterday); date = date.AddDate(0, 0, 1) {
+		dates = append(dates, date)
+	}
+	return dates, nil
+}
+
+func (s *interestService) CapitalizeInterest(now time.Time, limit int) (int, error) {
+	periodStart := models.InterestPeriodStart(now, s.capitalization)
+
+	accountIDs, err := s.interestRepo.AccountsToCapitalize(periodStart, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, accountID := range accountIDs {
+		err := s.txRunner.RunInTx(func(tx *sql.Tx) error {
+			return s.capitalize(tx, accountID, periodStart)
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to capitalize interest of account %d: %w", accountID, err)
+		}
+	}
+
+	return len(accountIDs), nil
+}
+
+// capitalize pays an account the interest it accrued before the given date:
+// an INTEREST transaction credits the gross amount and a linked
+// WITHHOLDING_TAX transaction debits the tax withheld at source
+func (s *interestService) capitalize(tx *sql.Tx, accountID int, before time.Time) error {
+	locked, err := s.accountRepo.WithTx(tx).LockByIDs(accountID)
+	if err != nil {
+		return err
+	}
+	account := locked[accountID]
+
+	interest := s.interestRepo.WithTx(tx)
+	accruals, err := interest.LockUncapitalized(accountID, before)
+	if err != nil {
+		return err
+	}
+	// Another replica capitalized them meanwhile
+	if len(accruals) == 0 {
+		return nil
+	}
+
+	var micros int64
+	accrualIDs := make([]int64, len(accruals))
+	for i, accrual := range accruals {
+		micros += accrual.AmountMicros
+		accrualIDs[i] = accrual.ID
+	}
+
+	gross := models.RoundInterest(micros)
+	tax := models.WithholdingTax(gross, s.withholdingBps)
+	capitalization := &models.InterestCapitalization{
+		AccountID:      account.ID,
+		AccountNumber:  account.AccountNumber,
+		PeriodStart:    accruals[0].BusinessDate,
+		PeriodEnd:      accruals[len(accruals)-1].BusinessDate,
+		GrossAmount:    gross,
+		WithholdingTax: tax,
+		NetAmount:      gross - tax,
+		CreatedAt:      time.Now().UTC(),
+	}
+
+	if gross > 0 {
+		description := fmt.Sprintf("Interest %s to %s",
+			capitalization.PeriodStart.Format("2006-01-02"), capitalization.PeriodEnd.Format("2006-01-02"))
+		credit, err := s.bookInterest(tx, account, models.TransactionTypeInterest, gross, description, "")
+		if err != nil {
+			return err
+		}
+		capitalization.TransactionID = credit.TransactionID
+
+		if tax > 0 {
+			if _, err := s.bookInterest(tx, account, models.TransactionTypeWithholding, tax, "Withholding tax on "+description, credit.TransactionID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return interest.CreateCapitalization(capitalization, accrualIDs)
+}
+
+// bookInterest records a completed INTEREST credit to the account, or a
+// WITHHOLDING_TAX debit of it linked to its INTEREST transaction, and posts
+// it against the matching GL account
+func (s *interestService) bookInterest(tx *sql.Tx, account *models.Account, transactionType string, amount int64, description, parentTransactionID string) (*models.Transaction, error) {
+	now := time.Now().UTC()
+	transaction := &models.Transaction{
+		TransactionID:       generateTransactionID(),
+		Amount:              amount,
+		Currency:            account.Currency,
+		ExchangeRate:        1.0,
+		ConvertedAmount:     amount,
+		TransactionType:     transactionType,
+		Status:              models.TransactionStatusCompleted,
+		Description:         description,
+		ParentTransactionID: parentTransactionID,
+		ProcessedAt:         &now,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+
+	entry := ledger.NewJournalEntry(transaction.TransactionID, description)
+	if transactionType == models.TransactionTypeInterest {
+		transaction.ToAccountID = account.ID
+		transaction.ToAccountNumber = account.AccountNumber
+		entry.DebitGL(ledger.GLInterestExpense, account.Currency, amount).
+			CreditAccount(account.ID, account.Currency, amount)
+	} else {
+		transaction.FromAccountID = account.ID
+		transaction.FromAccountNumber = account.AccountNumber
+		entry.DebitAccount(account.ID, account.Currency, amount).
+			CreditGL(ledger.GLWithholdingTax, account.Currency, amount)
+	}
+
+	if err := s.transactionRepo.WithTx(tx).Create(transaction); err != nil {
+		return nil, fmt.Errorf("failed to create %s transaction: %w", transactionType, err)
+	}
+	if err := s.ledger.WithTx(tx).Post(entry); err != nil {
+		return nil, err
+	}
+
+	return transaction, nil
+}
+
+func (s *interestService) GetAccrualReport(accountNumber string, first, last time.Time) (*models.InterestAccrualReport, error) {
+	if last.Before(first) {
+		return nil, fmt.Errorf("period end cannot be before its start")
+	}
+
+	accounts, err := s.interestRepo.AccrualReport(accountNumber, first, last)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build accrual report: %w", err)
+	}
+
+	report := &models.InterestAccrualReport{
+		From:     first,
+		To:       last,
+		Accounts: accounts,
+		Total:    make(map[string]int64),
+	}
+	if report.Accounts == nil {
+		report.Accounts = []*models.InterestAccrualSummary{}
+	}
+	for _, account := range accounts {
+		report.Total[account.Currency] += account.Accrued
+	}
+
+	return report, nil
+}
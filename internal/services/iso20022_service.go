@@ -0,0 +1,171 @@
+package services
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/bank-api/internal/iso20022"
+	"github.com/bank-api/internal/ledger"
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/repository"
+	"github.com/bank-api/internal/utils"
+)
+
+// Payment file item status when the file is only validated
+const PaymentFileItemValid = "VALID"
+
+// Payment file item status when the item failed validation or execution
+const PaymentFileItemRejected = "REJECTED"
+
+// ISO20022Service imports corporate payment files and exports account
+// statements in ISO 20022 formats
+type ISO20022Service interface {
+	// ImportPain001 validates a pain.001 file debiting the account and, unless
+	// dryRun is set, executes each valid transfer on its own. Nothing is
+	// executed if any item, or the file's total, needs a step-up the caller's
+	// claims lack.
+	ImportPain001(accountNumber string, file io.Reader, dryRun bool, claims *utils.JWTClaims) (*models.PaymentFileResponse, error)
+	// ExportCamt053 builds a camt.053 statement of the account's booked
+	// transactions in [from, to)
+	ExportCamt053(accountNumber string, from, to time.Time) ([]byte, error)
+}
+
+type iso20022Service struct {
+	accountRepo        repository.AccountRepository
+	customerRepo       repository.CustomerRepository
+	transactionRepo    repository.TransactionRepository
+	ledger             ledger.Ledger
+	transactionService TransactionService
+	mfaService         MFAService
+}
+
+func NewISO20022Service(accountRepo repository.AccountRepository, customerRepo repository.CustomerRepository, transactionRepo repository.TransactionRepository, ledger ledger.Ledger, transactionService TransactionService, mfaService MFAService) ISO20022Service {
+	return &iso20022Service{
+		accountRepo:        accountRepo,
+		customerRepo:       customerRepo,
+		transactionRepo:    transactionRepo,
+		ledger:             ledger,
+		transactionService: transactionService,
+		mfaService:         mfaService,
+	}
+}
+
+func (s *iso20022Service) ImportPain001(accountNumber string, file io.Reader, dryRun bool, claims *utils.JWTClaims) (*models.PaymentFileResponse, error) {
+	account, err := s.accountRepo.GetByAccountNumber(accountNumber)
+	if err != nil {
+		return nil, fmt.Errorf("account not found")
+	}
+
+	batch, err := iso20022.ParsePain001(file, account)
+	if err != nil {
+		return nil, err
+	}
+
+	if !dryRun {
+		if err := s.checkStepUp(claims, accountNumber, batch.Items); err != nil {
+			return nil, err
+		}
+	}
+
+	response := &models.PaymentFileResponse{
+		MessageID: batch.MessageID,
+		DryRun:    dryRun,
+		Total:     len(batch.Items),
+	}
+
+	// Items are executed one by one: a transfer failing (e.g. on balance)
+	// does not undo the ones before it
+	for _, item := range batch.Items {
+		result := &models.PaymentFileItem{EndToEndID: item.EndToEndID, Errors: item.Errors}
+		response.Items = append(response.Items, result)
+
+		if !item.Valid() {
+			result.Status = PaymentFileItemRejected
+			response.Rejected++
+			continue
+		}
+
+		result.Amount = item.Request.Amount
+		result.ToIBAN = item.Request.ToIBAN
+
+		if dryRun {
+			result.Status = PaymentFileItemValid
+			response.Accepted++
+			continue
+		}
+
+		transaction, err := s.transactionService.Transfer(item.Request)
+		if err != nil {
+			result.Status = PaymentFileItemRejected
+			result.Errors = append(result.Errors, err.Error())
+			response.Rejected++
+			continue
+		}
+
+		result.TransactionID = transaction.TransactionID
+		result.Status = transaction.Status
+		response.Accepted++
+	}
+
+	return response, nil
+}
+
+// checkStepUp applies the transfer step-up rules to each valid item and to the
+// file's total, so that splitting a large payment does not avoid the threshold
+func (s *iso20022Service) checkStepUp(claims *utils.JWTClaims, accountNumber string, items []*iso20022.BatchItem) error {
+	// Without a destination only the threshold applies
+	total := &models.TransferRequest{FromAccountNumber: accountNumber}
+	requests := []*models.TransferRequest{total}
+
+	for _, item := range items {
+		if item.Valid() {
+			requests = append(requests, item.Request)
+			total.Amount += item.Request.Amount
+		}
+	}
+
+	for _, req := range requests {
+		stepUp, err := s.mfaService.RequiresStepUp(claims, req)
+		if err != nil {
+			return err
+		}
+		if stepUp {
+			return ErrStepUpRequired
+		}
+	}
+	return nil
+}
+
+func (s *iso20022Service) ExportCamt053(accountNumber string, from, to time.Time) ([]byte, error) {
+	if !to.After(from) {
+		return nil, fmt.Errorf("statement period must end after it starts")
+	}
+	if to.Sub(from) > maxStatementPeriod {
+		return nil, fmt.Errorf("statement period cannot exceed one year")
+	}
+
+	account, err := s.accountRepo.GetByAccountNumber(accountNumber)
+	if err != nil {
+		return nil, fmt.Errorf("account not found")
+	}
+
+	statement := &iso20022.Statement{Account: account, From: from, To: to}
+
+	if customer, err := s.customerRepo.GetByCustomerID(account.CustomerID); err == nil {
+		statement.OwnerName = customer.FirstName + " " + customer.LastName
+	}
+
+	if statement.OpeningBalance, err = s.ledger.AccountBalanceAt(account.ID, from); err != nil {
+		return nil, err
+	}
+	if statement.ClosingBalance, err = s.ledger.AccountBalanceAt(account.ID, to); err != nil {
+		return nil, err
+	}
+
+	if statement.Transactions, err = bookedTransactions(s.transactionRepo, accountNumber, from, to); err != nil {
+		return nil, err
+	}
+
+	return iso20022.BuildCamt053(statement)
+}
@@ -0,0 +1,217 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/repository"
+)
+
+// ErrLimitRaiseNotAllowed is returned when a caller who may only lower limits
+// tries to lift or raise one
+var ErrLimitRaiseNotAllowed = errors.New("raising a limit requires an admin")
+
+type LimitService interface {
+	GetDefaults() ([]*models.AccountTypeLimits, error)
+	// UpdateDefaults changes the limits of every account of a type in a
+	// currency that its customer has not set themselves
+	UpdateDefaults(accountType, currency string, req *models.LimitsRequest) (*models.AccountTypeLimits, error)
+	// GetStatus returns an account's limits and the headroom left under them
+	GetStatus(accountNumber string) (*models.LimitStatus, error)
+	// UpdateLimits sets the limits of the account owner's accounts of its
+	// type and currency. Unless allowRaise, limits can only be lowered.
+	UpdateLimits(accountNumber string, req *models.LimitsRequest, allowRaise bool, updatedBy string) (*models.LimitStatus, error)
+	// ResetLimits puts the account owner's accounts of its type and currency
+	// back on the account type's limits
+	ResetLimits(accountNumber string) (*models.LimitStatus, error)
+}
+
+type limitService struct {
+	limitRepo   repository.LimitRepository
+	accountRepo repository.AccountRepository
+	limits      limitEngine
+}
+
+func NewLimitService(limitRepo repository.LimitRepository, accountRepo repository.AccountRepository, transactionRepo repository.TransactionRepository) LimitService {
+	return &limitService{
+		limitRepo:   limitRepo,
+		accountRepo: accountRepo,
+		limits:      limitEngine{limitRepo: limitRepo, transactionRepo: transactionRepo},
+	}
+}
+
+func (s *limitService) GetDefaults() ([]*models.AccountTypeLimits, error) {
+	return s.limitRepo.GetDefaults()
+}
+
+func (s *limitService) UpdateDefaults(accountType, currency string, req *models.LimitsRequest) (*models.AccountTypeLimits, error) {
+	switch accountType {
+	case models.AccountTypeChecking, models.AccountTypeSavings, models.AccountTypeBusiness, models.AccountTypeForeign:
+	default:
+		return nil, fmt.Errorf("invalid account type")
+	}
+	if _, ok := models.CurrencyMinorUnits(currency); !ok {
+		return nil, fmt.Errorf("invalid currency")
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	defaults, err := s.limitRepo.GetDefault(accountType, currency)
+	if err != nil {
+		return nil, err
+	}
+	defaults.TransactionLimits = req.Apply(defaults.TransactionLimits)
+
+	if err := s.limitRepo.SaveDefault(defaults); err != nil {
+		return nil, fmt.Errorf("failed to update limits: %w", err)
+	}
+
+	return defaults, nil
+}
+
+func (s *limitService) GetStatus(accountNumber string) (*models.LimitStatus, error) {
+	account, err := s.accountRepo.GetByAccountNumber(accountNumber)
+	if err != nil {
+		return nil, fmt.Errorf("account not found")
+	}
+
+	return s.limits.status(account)
+}
+
+func (s *limitService) UpdateLimits(accountNumber string, req *models.LimitsRequest, allowRaise bool, updatedBy string) (*models.LimitStatus, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	account, err := s.accountRepo.GetByAccountNumber(accountNumber)
+	if err != nil {
+		return nil, fmt.Errorf("account not found")
+	}
+
+	defaults, err := s.limitRepo.GetDefault(account.AccountType, account.Currency)
+	if err != nil {
+		return nil, err
+	}
+	own, err := s.limitRepo.GetCustomerLimits(account.CustomerID, account.AccountType, account.Currency)
+	if err != nil {
+		return nil, err
+	}
+	current := own.Apply(defaults.TransactionLimits)
+
+	if own == nil {
+		own = &models.CustomerLimits{CustomerID: account.CustomerID, AccountType: account.AccountType, Currency: account.Currency}
+	}
+	if req.SingleMax != nil {
+		own.SingleMax = req.SingleMax
+	}
+	if req.DailyMax != nil {
+		own.DailyMax = req.DailyMax
+	}
+	if req.MonthlyMax != nil {
+		own.MonthlyMax = req.MonthlyMax
+	}
+	if req.HourlyCount != nil {
+		own.HourlyCount = req.HourlyCount
+	}
+	own.UpdatedBy = updatedBy
+
+	if !allowRaise && current.Raised(own.Apply(defaults.TransactionLimits)) != "" {
+		return nil, ErrLimitRaiseNotAllowed
+	}
+
+	if err := s.limitRepo.SaveCustomerLimits(own); err != nil {
+		return nil, fmt.Errorf("failed to update limits: %w", err)
+	}
+
+	return s.limits.status(account)
+}
+
+func (s *limitService) ResetLimits(accountNumber string) (*models.LimitStatus, error) {
+	account, err := s.accountRepo.GetByAccountNumber(accountNumber)
+	if err != nil {
+		return nil, fmt.Errorf("account not found")
+	}
+
+	if err := s.limitRepo.DeleteCustomerLimits(account.CustomerID, account.AccountType, account.Currency); err != nil {
+		return nil, err
+	}
+
+	return s.limits.status(account)
+}
+
+// limitEngine checks transactions against their account's limits
+type limitEngine struct {
+	limitRepo       repository.LimitRepository
+	transactionRepo repository.TransactionRepository
+}
+
+// withTx returns an engine summing the account's usage inside tx
+func (e limitEngine) withTx(tx *sql.Tx) limitEngine {
+	return limitEngine{limitRepo: e.limitRepo, transactionRepo: e.transactionRepo.WithTx(tx)}
+}
+
+// limits returns the limits in effect on an account and whether its
+// customer set some of them
+func (e limitEngine) limits(account *models.Account) (models.TransactionLimits, bool, error) {
+	defaults, err := e.limitRepo.GetDefault(account.AccountType, account.Currency)
+	if err != nil {
+		return models.TransactionLimits{}, false, err
+	}
+	own, err := e.limitRepo.GetCustomerLimits(account.CustomerID, account.AccountType, account.Currency)
+	if err != nil {
+		return models.TransactionLimits{}, false, err
+	}
+
+	return own.Apply(defaults.TransactionLimits), own != nil, nil
+}
+
+// usage sums what the account has sent in the limits' periods, leaving out
+// excludeTransactionID
+func (e limitEngine) usage(account *models.Account, excludeTransactionID string) (models.LimitUsage, error) {
+	now := time.Now().UTC()
+	dayStart := truncateDay(now)
+	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	return e.transactionRepo.GetLimitUsage(account.AccountNumber, dayStart, monthStart, now.Add(-time.Hour), excludeTransactionID)
+}
+
+// check refuses sending amount from account, with a
+// *models.LimitExceededError, if it would exceed one of its limits; the
+// transaction being checked, excludeTransactionID, is not counted as sent
+func (e limitEngine) check(account *models.Account, amount int64, excludeTransactionID string) error {
+	limits, _, err := e.limits(account)
+	if err != nil {
+		return err
+	}
+	usage, err := e.usage(account, excludeTransactionID)
+	if err != nil {
+		return err
+	}
+
+	return limits.Check(amount, usage)
+}
+
+func (e limitEngine) status(account *models.Account) (*models.LimitStatus, error) {
+	limits, customized, err := e.limits(account)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := e.usage(account, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.LimitStatus{
+		AccountNumber: account.AccountNumber,
+		AccountType:   account.AccountType,
+		Currency:      account.Currency,
+		Limits:        limits,
+		Customized:    customized,
+		Used:          usage,
+		Remaining:     limits.Headroom(usage),
+	}, nil
+}
@@ -0,0 +1,149 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/repository"
+)
+
+// LoginGuard slows down and locks out credential guessing on customer logins,
+// and keeps their login history
+type LoginGuard interface {
+	// Allow refuses with a *models.LoginThrottledError an attempt that must
+	// wait or is locked out. customer is nil when the identifier matched no
+	// customer; an empty ip is not tracked.
+	Allow(customer *models.Customer, ip string) error
+	// Failed records a failed attempt; wrong guesses count towards a lockout
+	Failed(customer *models.Customer, identifier, reason string, client ClientInfo) error
+	// Succeeded records a completed login, second factor included, and
+	// clears the customer's failed logins
+	Succeeded(customer *models.Customer, identifier string, client ClientInfo) error
+	// Unlock lifts a customer's lockout before it expires
+	Unlock(customerID string) error
+	GetHistory(customerID string, limit, offset int) ([]*models.LoginAttempt, error)
+}
+
+type loginGuard struct {
+	customerRepo repository.CustomerRepository
+	attemptRepo  repository.LoginAttemptRepository
+	policy       models.LoginPolicy
+}
+
+func NewLoginGuard(customerRepo repository.CustomerRepository, attemptRepo repository.LoginAttemptRepository, policy models.LoginPolicy) LoginGuard {
+	return &loginGuard{
+		customerRepo: customerRepo,
+		attemptRepo:  attemptRepo,
+		policy:       policy,
+	}
+}
+
+func (g *loginGuard) Allow(customer *models.Customer, ip string) error {
+	now := time.Now().UTC()
+
+	if ip != "" && g.policy.IPMaxFailures > 0 {
+		failures, oldest, err := g.attemptRepo.CountIPFailures(ip, now.Add(-g.policy.IPWindow))
+		if err != nil {
+			return fmt.Errorf("failed to check login attempts: %w", err)
+		}
+		// The IP is let through again once its oldest failure leaves the window
+		if failures >= g.policy.IPMaxFailures {
+			return &models.LoginThrottledError{RetryAt: oldest.Add(g.policy.IPWindow)}
+		}
+	}
+
+	if customer == nil {
+		return nil
+	}
+
+	if customer.LockedUntil != nil && now.Before(*customer.LockedUntil) {
+		return &models.LoginThrottledError{Locked: true, RetryAt: *customer.LockedUntil}
+	}
+
+	failures := g.policy.Failures(customer, now)
+	if failures > 0 {
+		retryAt := customer.LastFailedLoginAt.Add(g.policy.Delay(failures))
+		if now.Before(retryAt) {
+			return &models.LoginThrottledError{RetryAt: retryAt}
+		}
+	}
+
+	return nil
+}
+
+func (g *loginGuard) Failed(customer *models.Customer, identifier, reason string, client ClientInfo) error {
+	now := time.Now().UTC()
+
+	attempt := &models.LoginAttempt{
+		Identifier:    identifier,
+		IPAddress:     client.IPAddress,
+		UserAgent:     client.UserAgent,
+		FailureReason: reason,
+		CreatedAt:     now,
+	}
+	if customer != nil {
+		attempt.CustomerID = customer.CustomerID
+	}
+	if err := g.attemptRepo.Create(attempt); err != nil {
+		return fmt.Errorf("failed to record login attempt: %w", err)
+	}
+
+	if customer == nil || !models.CountsTowardsLockout(reason) {
+		return nil
+	}
+
+	failures, err := g.customerRepo.RecordLoginFailure(customer.CustomerID, now, now.Add(-g.policy.LockoutDuration))
+	if err != nil {
+		return fmt.Errorf("failed to record failed login: %w", err)
+	}
+
+	if g.policy.MaxFailures > 0 && failures >= g.policy.MaxFailures {
+		if err := g.customerRepo.LockLogin(customer.CustomerID, now.Add(g.policy.LockoutDuration)); err != nil {
+			return fmt.Errorf("failed to lock customer: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (g *loginGuard) Succeeded(customer *models.Customer, identifier string, client ClientInfo) error {
+	now := time.Now().UTC()
+
+	err := g.attemptRepo.Create(&models.LoginAttempt{
+		CustomerID: customer.CustomerID,
+		Identifier: identifier,
+		IPAddress:  client.IPAddress,
+		UserAgent:  client.UserAgent,
+		Success:    true,
+		CreatedAt:  now,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record login attempt: %w", err)
+	}
+
+	if err := g.customerRepo.RecordLoginSuccess(customer.CustomerID, now); err != nil {
+		return fmt.Errorf("failed to record login: %w", err)
+	}
+
+	customer.LastLoginAt = &now
+	customer.FailedLoginAttempts = 0
+	customer.LockedUntil = nil
+
+	return nil
+}
+
+func (g *loginGuard) Unlock(customerID string) error {
+	return g.customerRepo.UnlockLogin(customerID)
+}
+
+func (g *loginGuard) GetHistory(customerID string, limit, offset int) ([]*models.LoginAttempt, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	return g.attemptRepo.GetByCustomerID(customerID, limit, offset)
+}
@@ -0,0 +1,462 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/repository"
+	"github.com/bank-api/internal/utils"
+)
+
+var (
+	// ErrInvalidMFACode is returned for a wrong, expired or already used code
+	ErrInvalidMFACode = errors.New("invalid MFA code")
+	// ErrInvalidMFAChallenge is returned for any login challenge that cannot
+	// be answered; callers should not learn why
+	ErrInvalidMFAChallenge = errors.New("invalid or expired MFA challenge")
+	ErrMFANotEnabled       = errors.New("MFA is not enabled")
+	// ErrStepUpRequired is returned when a payment needs a second factor the
+	// caller's token has not verified recently
+	ErrStepUpRequired = errors.New("a recent second factor is required")
+)
+
+type MFAService interface {
+	GetStatus(customerID string) (*models.MFAStatus, error)
+	IsEnabled(customerID string) (bool, error)
+	// BeginEnrollment generates a new TOTP secret for a customer without MFA.
+	// It protects nothing until confirmed.
+	BeginEnrollment(customerID string) (*models.MFAEnrollment, error)
+	// ConfirmEnrollment enables MFA once a code shows the customer's app holds
+	// the secret, and returns their recovery codes
+	ConfirmEnrollment(customerID, code string) ([]string, error)
+	// RegenerateRecoveryCodes replaces the customer's recovery codes
+	RegenerateRecoveryCodes(customerID, code string) ([]string, error)
+	// Disable removes the customer's enrolment and recovery codes
+	Disable(customerID, code string) error
+	// Verify checks a TOTP or recovery code of a customer with MFA enabled.
+	// Neither can be used twice.
+	Verify(customerID, code string) error
+	// CreateChallenge starts the second step of a customer's login
+	CreateChallenge(customerID string) (token string, expiresAt time.Time, err error)
+	// CompleteChallenge checks the code answering a login challenge and
+	// returns the customer it was issued to. Wrong codes count towards the
+	// customer's lockout like wrong passwords.
+	CompleteChallenge(token, code string, client ClientInfo) (*models.Customer, error)
+	// RequiresStepUp reports whether the caller must verify a second factor
+	// before the transfer: customers with MFA enabled must for amounts from
+	// the threshold and for beneficiaries they have not paid before, unless
+	// their token verified one recently
+	RequiresStepUp(claims *utils.JWTClaims, req *models.TransferRequest) (bool, error)
+}
+
+type mfaService struct {
+	mfaRepo         repository.MFARepository
+	customerRepo    repository.CustomerRepository
+	accountRepo     repository.AccountRepository
+	transactionRepo repository.TransactionRepository
+	txRunner        repository.TxRunner
+	loginGuard      LoginGuard
+	issuer          string
+	challengeTTL    time.Duration
+	stepUpThreshold int64
+	stepUpMaxAge    time.Duration
+}
+
+func NewMFAService(
+	mfaRepo repository.MFARepository,
+	customerRepo repository.CustomerRepository,
+	accountRepo repository.AccountRepository,
+	transactionRepo repository.TransactionRepository,
+	txRunner repository.TxRunner,
+	loginGuard LoginGuard,
+	issuer string,
+	challengeTTL time.Duration,
+	stepUpThreshold int64,
+	stepUpMaxAge time.Duration,
+) MFAService {
+	return &mfaService{
+		mfaRepo:         mfaRepo,
+		customerRepo:    customerRepo,
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+		txRunner:        txRunner,
+		loginGuard:      loginGuard,
+		issuer:          issuer,
+		challengeTTL:    challengeTTL,
+		stepUpThreshold: stepUpThreshold,
+		stepUpMaxAge:    stepUpMaxAge,
+	}
+}
+
+func (s *mfaService) GetStatus(customerID string) (*models.MFAStatus, error) {
+	mfa, err := s.mfaRepo.GetByCustomerID(customerID)
+	if err != nil {
+		return nil, err
+	}
+	if mfa == nil || !mfa.IsEnabled() {
+		return &models.MFAStatus{}, nil
+	}
+
+	remaining, err := s.mfaRepo.CountRecoveryCodes(customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MFAStatus{
+		Enabled:                true,
+		EnabledAt:              mfa.EnabledAt,
+		RecoveryCodesRemaining: remaining,
+	}, nil
+}
+
+func (s *mfaService) IsEnabled(customerID string) (bool, error) {
+	mfa, err := s.mfaRepo.GetByCustomerID(customerID)
+	if err != nil {
+		return false, err
+	}
+	return mfa != nil && mfa.IsEnabled(), nil
+}
+
+func (s *mfaService) BeginEnrollment(customerID string) (*models.MFAEnrollment, error) {
+	secret := utils.GenerateTOTPSecret()
+
+	err := s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		mfaRepo := s.mfaRepo.WithTx(tx)
+
+		mfa, err := mfaRepo.LockByCustomerID(customerID)
+		if err != nil {
+			return err
+		}
+		if mfa != nil && mfa.IsEnabled() {
+			return fmt.Errorf("MFA is already enabled")
+		}
+
+		// Starting over replaces an enrolment that was never confirmed
+		return mfaRepo.Save(&models.CustomerMFA{
+			CustomerID: customerID,
+			Secret:     secret,
+			CreatedAt:  time.Now().UTC(),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MFAEnrollment{
+		Secret:     secret,
+		OTPAuthURI: utils.TOTPURI(s.issuer, customerID, secret),
+	}, nil
+}
+
+func (s *mfaService) ConfirmEnrollment(customerID, code string) ([]string, error) {
+	var recoveryCodes []string
+
+	err := s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		mfaRepo := s.mfaRepo.WithTx(tx)
+
+		mfa, err := mfaRepo.LockByCustomerID(customerID)
+		if err != nil {
+			return err
+		}
+		if mfa == nil {
+			return fmt.Errorf("no MFA enrolment in progress")
+		}
+		if mfa.IsEnabled() {
+			return fmt.Errorf("MFA is already enabled")
+		}
+
+		ok, err := s.verify(mfaRepo, mfa, code)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidMFACode
+		}
+
+		now := time.Now().UTC()
+		if err := mfaRepo.Enable(customerID, now); err != nil {
+			return fmt.Errorf("failed to enable MFA: %w", err)
+		}
+
+		recoveryCodes, err = s.replaceRecoveryCodes(mfaRepo, customerID, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+func (s *mfaService) RegenerateRecoveryCodes(customerID, code string) ([]string, error) {
+	var recoveryCodes []string
+
+	err := s.withVerifiedCode(customerID, code, func(mfaRepo repository.MFARepository) error {
+		var err error
+		recoveryCodes, err = s.replaceRecoveryCodes(mfaRepo, customerID, time.Now().UTC())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return recoveryCodes, nil
+}
+
+func (s *mfaService) Disable(customerID, code string) error {
+	return s.withVerifiedCode(customerID, code, func(mfaRepo repository.MFARepository) error {
+		if err := mfaRepo.Delete(customerID); err != nil {
+			return fmt.Errorf("failed to disable MFA: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *mfaService) Verify(customerID, code string) error {
+	return s.withVerifiedCode(customerID, code, func(repository.MFARepository) error {
+		return nil
+	})
+}
+
+// withVerifiedCode runs fn in the transaction that checks and uses up the
+// code of a customer with MFA enabled
+func (s *mfaService) withVerifiedCode(customerID, code string, fn func(mfaRepo repository.MFARepository) error) error {
+	return s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		mfaRepo := s.mfaRepo.WithTx(tx)
+
+		mfa, err := mfaRepo.LockByCustomerID(customerID)
+		if err != nil {
+			return err
+		}
+		if mfa == nil || !mfa.IsEnabled() {
+			return ErrMFANotEnabled
+		}
+
+		ok, err := s.verify(mfaRepo, mfa, code)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvalidMFACode
+		}
+
+		return fn(mfaRepo)
+	})
+}
+
+// verify checks a code against a locked enrolment and uses it up: a TOTP
+// code's time step cannot be accepted again, a recovery code is marked used.
+// Recovery codes only exist once the enrolment is confirmed.
+func (s *mfaService) verify(mfaRepo repository.MFARepository, mfa *models.CustomerMFA, code string) (bool, error) {
+	code = strings.TrimSpace(code)
+
+	if step, ok := utils.ValidateTOTP(mfa.Secret, code, time.Now()); ok {
+		if step <= mfa.LastUsedStep {
+			return false, nil
+		}
+		if err := mfaRepo.UpdateLastUsedStep(mfa.CustomerID, step); err != nil {
+			return false, fmt.Errorf("failed to record MFA code: %w", err)
+		}
+		return true, nil
+	}
+
+	if !mfa.IsEnabled() || code == "" {
+		return false, nil
+	}
+
+	used, err := mfaRepo.UseRecoveryCode(mfa.CustomerID, hashMFAToken(normalizeRecoveryCode(code)))
+	if err != nil {
+		return false, fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	return used, nil
+}
+
+func (s *mfaService) replaceRecoveryCodes(mfaRepo repository.MFARepository, customerID string, now time.Time) ([]string, error) {
+	codes := make([]string, models.MFARecoveryCodeCount)
+	hashes := make([]string, models.MFARecoveryCodeCount)
+	for i := range codes {
+		codes[i] = generateRecoveryCode()
+		hashes[i] = hashMFAToken(normalizeRecoveryCode(codes[i]))
+	}
+
+	if err := mfaRepo.ReplaceRecoveryCodes(customerID, hashes, now); err != nil {
+		return nil, fmt.Errorf("failed to store recovery codes: %w", err)
+	}
+	return codes, nil
+}
+
+func (s *mfaService) CreateChallenge(customerID string) (string, time.Time, error) {
+	now := time.Now().UTC()
+	token := generateMFAChallengeToken()
+
+	challenge := &models.MFAChallenge{
+		TokenHash:  hashMFAToken(token),
+		CustomerID: customerID,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(s.challengeTTL),
+	}
+	if err := s.mfaRepo.CreateChallenge(challenge); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to create MFA challenge: %w", err)
+	}
+
+	return token, challenge.ExpiresAt, nil
+}
+
+func (s *mfaService) CompleteChallenge(token, code string, client ClientInfo) (*models.Customer, error) {
+	if token == "" {
+		return nil, ErrInvalidMFAChallenge
+	}
+
+	var customer *models.Customer
+	failed := false
+
+	err := s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		mfaRepo := s.mfaRepo.WithTx(tx)
+
+		challenge, err := mfaRepo.GetChallengeForUpdate(hashMFAToken(token))
+		if err != nil || !challenge.IsValid() {
+			return ErrInvalidMFAChallenge
+		}
+
+		customer, err = s.customerRepo.GetByCustomerID(challenge.CustomerID)
+		if err != nil {
+			return ErrInvalidMFAChallenge
+		}
+		if err := s.loginGuard.Allow(customer, client.IPAddress); err != nil {
+			return err
+		}
+
+		mfa, err := mfaRepo.LockByCustomerID(challenge.CustomerID)
+		if err != nil {
+			return err
+		}
+		if mfa == nil || !mfa.IsEnabled() {
+			return ErrInvalidMFAChallenge
+		}
+
+		ok, err := s.verify(mfaRepo, mfa, code)
+		if err != nil {
+			return err
+		}
+
+		// A wrong code is counted, so the transaction must commit
+		if !ok {
+			failed = true
+			return mfaRepo.RecordChallengeAttempt(challenge.ID)
+		}
+
+		return mfaRepo.MarkChallengeUsed(challenge.ID)
+	})
+	if err != nil {
+		var throttled *models.LoginThrottledError
+		if errors.As(err, &throttled) {
+			if err := s.loginGuard.Failed(customer, customer.CustomerID, throttled.Reason(), client); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+
+	if failed {
+		if err := s.loginGuard.Failed(customer, customer.CustomerID, models.LoginFailureInvalidMFACode, client); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidMFACode
+	}
+
+	if !customer.IsActive() {
+		return nil, fmt.Errorf("customer is not active")
+	}
+
+	customer.HashPassword = ""
+	return customer, nil
+}
+
+func (s *mfaService) RequiresStepUp(claims *utils.JWTClaims, req *models.TransferRequest) (bool, error) {
+	if claims == nil || claims.CustomerID == "" || models.IsStaffRole(claims.EffectiveRole()) {
+		return false, nil
+	}
+	if claims.HasRecentMFA(s.stepUpMaxAge) {
+		return false, nil
+	}
+
+	enabled, err := s.IsEnabled(claims.CustomerID)
+	if err != nil || !enabled {
+		return false, err
+	}
+
+	if s.stepUpThreshold > 0 && req.Amount >= s.stepUpThreshold {
+		return true, nil
+	}
+
+	known, err := s.isKnownBeneficiary(claims.CustomerID, req)
+	if err != nil {
+		return false, err
+	}
+	return !known, nil
+}
+
+// isKnownBeneficiary reports whether a transfer goes to one of the customer's
+// own accounts or to a beneficiary the source account has paid before.
+// Destinations that do not resolve are let through: the transfer fails anyway.
+func (s *mfaService) isKnownBeneficiary(customerID string, req *models.TransferRequest) (bool, error) {
+	toAccountNumber, toIBAN := req.ToAccountNumber, models.NormalizeIBAN(req.ToIBAN)
+
+	if toIBAN != "" {
+		if account, err := s.accountRepo.GetByIBAN(toIBAN); err == nil {
+			toAccountNumber, toIBAN = account.AccountNumber, ""
+		}
+	}
+
+	if toAccountNumber != "" {
+		account, err := s.accountRepo.GetByAccountNumber(toAccountNumber)
+		if err != nil || account.CustomerID == customerID {
+			return true, nil
+		}
+	}
+
+	if toAccountNumber == "" && toIBAN == "" {
+		return true, nil
+	}
+
+	return s.transactionRepo.HasPaid(req.FromAccountNumber, toAccountNumber, toIBAN)
+}
+
+// generateRecoveryCode returns a 50-bit code formatted as xxxxx-xxxxx
+func generateRecoveryCode() string {
+	randomBytes := make([]byte, 10)
+	rand.Read(randomBytes)
+
+	code := strings.ToLower(base32.StdEncoding.EncodeToString(randomBytes))[:10]
+	return code[:5] + "-" + code[5:]
+}
+
+// normalizeRecoveryCode accepts recovery codes typed in any case, with or
+// without the dash
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(code)
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+// generateMFAChallengeToken returns an opaque 256-bit token; only its hash is stored
+func generateMFAChallengeToken() string {
+	randomBytes := make([]byte, 32)
+	rand.Read(randomBytes)
+
+	return base64.RawURLEncoding.EncodeToString(randomBytes)
+}
+
+func hashMFAToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
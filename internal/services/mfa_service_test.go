@@ -0,0 +1,105 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/repository"
+	"github.com/bank-api/internal/utils"
+)
+
+// stubMFARepository records the TOTP step and recovery codes used
+type stubMFARepository struct {
+	repository.MFARepository
+	lastUsedStep  int64
+	recoveryCodes map[string]bool // Hash to unused
+}
+
+func (r *stubMFARepository) UpdateLastUsedStep(customerID string, step int64) error {
+	r.lastUsedStep = step
+	return nil
+}
+
+func (r *stubMFARepository) UseRecoveryCode(customerID, codeHash string) (bool, error) {
+	if !r.recoveryCodes[codeHash] {
+		return false, nil
+	}
+	r.recoveryCodes[codeHash] = false
+	return true, nil
+}
+
+func TestMFAServiceVerify(t *testing.T) {
+	secret := utils.GenerateTOTPSecret()
+	enabledAt := time.Now().UTC()
+	step := utils.TOTPStep(time.Now())
+
+	codeAt := func(step int64) string {
+		code, err := utils.TOTPCode(secret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name         string
+		enabled      bool
+		lastUsedStep int64
+		code         string
+		want         bool
+	}{
+		{name: "current code", enabled: true, code: codeAt(step), want: true},
+		{name: "with surrounding spaces", enabled: true, code: " " + codeAt(step) + " ", want: true},
+		{name: "enrolment being confirmed", code: codeAt(step), want: true},
+		{name: "code of a step already used", enabled: true, lastUsedStep: step + 1, code: codeAt(step)},
+		{name: "expired code", enabled: true, code: codeAt(step - 3)},
+		{name: "recovery code", enabled: true, code: "abcde-fghij", want: true},
+		{name: "recovery code before confirmation", code: "abcde-fghij"},
+		{name: "unknown recovery code", enabled: true, code: "zzzzz-zzzzz"},
+		{name: "empty", enabled: true, code: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mfa := &models.CustomerMFA{CustomerID: "CUST1", Secret: secret, LastUsedStep: tt.lastUsedStep}
+			if tt.enabled {
+				mfa.EnabledAt = &enabledAt
+			}
+			repo := &stubMFARepository{
+				lastUsedStep:  tt.lastUsedStep,
+				recoveryCodes: map[string]bool{hashMFAToken(normalizeRecoveryCode("ABCDEFGHIJ")): true},
+			}
+
+			got, err := (&mfaService{}).verify(repo, mfa, tt.code)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("verify(%q) = %v, want %v", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMFAServiceVerifyRefusesReplay(t *testing.T) {
+	secret := utils.GenerateTOTPSecret()
+	enabledAt := time.Now().UTC()
+	code, err := utils.TOTPCode(secret, utils.TOTPStep(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := &stubMFARepository{}
+	mfa := &models.CustomerMFA{CustomerID: "CUST1", Secret: secret, EnabledAt: &enabledAt}
+	service := &mfaService{}
+
+	if ok, err := service.verify(repo, mfa, code); err != nil || !ok {
+		t.Fatalf("first use of the code = %v, %v, want accepted", ok, err)
+	}
+	mfa.LastUsedStep = repo.lastUsedStep
+
+	if ok, err := service.verify(repo, mfa, code); err != nil || ok {
+		t.Errorf("second use of the code = %v, %v, want refused", ok, err)
+	}
+}
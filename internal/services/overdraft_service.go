@@ -0,0 +1,400 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/bank-api/internal/ledger"
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/repository"
+)
+
+type OverdraftService interface {
+	// RequestOverdraft asks for an overdraft facility on a current account;
+	// it takes effect once an admin approves it
+	RequestOverdraft(accountNumber string, req *models.OverdraftRequest, requestedBy string) (*models.OverdraftFacility, error)
+	GetAccountFacilities(accountNumber string) ([]*models.OverdraftFacility, error)
+	GetFacilities(status string, limit, offset int) ([]*models.OverdraftFacility, error)
+	GetFacility(id int) (*models.OverdraftFacility, error)
+	// ApproveOverdraft grants a pending request, replacing the facility in
+	// force on the account if any
+	ApproveOverdraft(id int, req *models.OverdraftDecisionRequest, decidedBy string) (*models.OverdraftFacility, error)
+	RejectOverdraft(id int, req *models.OverdraftDecisionRequest, decidedBy string) (*models.OverdraftFacility, error)
+	// RevokeOverdraft withdraws an approved facility. A balance already
+	// overdrawn keeps accruing debit interest at its rate until repaid.
+	RevokeOverdraft(id int, req *models.OverdraftDecisionRequest, decidedBy string) (*models.OverdraftFacility, error)
+	// AccrueInterest accrues the debit interest of up to limit accounts not
+	// yet accrued for a past business date and returns how many it accrued
+	AccrueInterest(businessDate time.Time, limit int) (int, error)
+	// PendingBusinessDates returns the business dates up to yesterday that
+	// may have accounts left to accrue
+	PendingBusinessDates(now time.Time) ([]time.Time, error)
+	// ChargeInterest charges up to limit accounts the debit interest they
+	// accrued before the month containing now, and returns how many accounts
+	// it handled
+	ChargeInterest(now time.Time, limit int) (int, error)
+}
+
+type overdraftService struct {
+	overdraftRepo   repository.OverdraftRepository
+	accountRepo     repository.AccountRepository
+	transactionRepo repository.TransactionRepository
+	ledger          ledger.Ledger
+	txRunner        repository.TxRunner
+	defaultRateBps  int
+	dayCount        int
+}
+
+// NewOverdraftService creates the overdraft service. Facilities approved
+// without a rate charge defaultRateBps a year; daily rates are annual rates
+// over dayCount days.
+func NewOverdraftService(overdraftRepo repository.OverdraftRepository, accountRepo repository.AccountRepository, transactionRepo repository.TransactionRepository, ledger ledger.Ledger, txRunner repository.TxRunner, defaultRateBps, dayCount int) OverdraftService {
+	return &overdraftService{
+		overdraftRepo:   overdraftRepo,
+		accountRepo:     accountRepo,
+		transactionRepo: transactionRepo,
+		ledger:          ledger,
+		txRunner:        txRunner,
+		defaultRateBps:  defaultRateBps,
+		dayCount:        dayCount,
+	}
+}
+
+func (s *overdraftService) RequestOverdraft(accountNumber string, req *models.OverdraftRequest, requestedBy string) (*models.OverdraftFacility, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	account, err := s.accountRepo.GetByAccountNumber(accountNumber)
+	if err != nil {
+		return nil, fmt.Errorf("account not found")
+	}
+	if !models.IsOverdraftAccountType(account.AccountType) {
+		return nil, fmt.Errorf("overdrafts are only available on current accounts")
+	}
+	if !account.IsActive() {
+		return nil, fmt.Errorf("account is not active")
+	}
+
+	facilities, err := s.overdraftRepo.GetAccountFacilities(account.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, facility := range facilities {
+		if facility.Status == models.OverdraftStatusPending {
+			return nil, fmt.Errorf("overdraft request %d is already pending for this account", facility.ID)
+		}
+	}
+
+	facility := &models.OverdraftFacility{
+		AccountID:      account.ID,
+		AccountNumber:  account.AccountNumber,
+		RequestedLimit: req.Limit,
+		Status:         models.OverdraftStatusPending,
+		Reason:         req.Reason,
+		RequestedBy:    requestedBy,
+		CreatedAt:      time.Now().UTC(),
+	}
+	if err := s.overdraftRepo.CreateFacility(facility); err != nil {
+		return nil, fmt.Errorf("failed to request overdraft: %w", err)
+	}
+
+	return facility, nil
+}
+
+func (s *overdraftService) GetAccountFacilities(accountNumber string) ([]*models.OverdraftFacility, error) {
+	account, err := s.accountRepo.GetByAccountNumber(accountNumber)
+	if err != nil {
+		return nil, fmt.Errorf("account not found")
+	}
+
+	return s.overdraftRepo.GetAccountFacilities(account.ID)
+}
+
+func (s *overdraftService) GetFacilities(status string, limit, offset int) ([]*models.OverdraftFacility, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	return s.overdraftRepo.GetFacilities(status, limit, offset)
+}
+
+func (s *overdraftService) GetFacility(id int) (*models.OverdraftFacility, error) {
+	return s.overdraftRepo.GetFacility(id)
+}
+
+func (s *overdraftService) ApproveOverdraft(id int, req *models.OverdraftDecisionRequest, decidedBy string) (*models.OverdraftFacility, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	var facility *models.OverdraftFacility
+	err := s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		overdrafts := s.overdraftRepo.WithTx(tx)
+
+		var err error
+		if facility, err = overdrafts.LockFacility(id); err != nil {
+			return err
+		}
+		if facility.Status != models.OverdraftStatusPending {
+			return fmt.Errorf("overdraft facility is %s, only pending requests can be approved", facility.Status)
+		}
+
+		locked, err := s.accountRepo.WithTx(tx).LockByIDs(facility.AccountID)
+		if err != nil {
+			return err
+		}
+		account := locked[facility.AccountID]
+		if !account.IsActive() {
+			return fmt.Errorf("account is not active")
+		}
+
+		now := time.Now().UTC()
+		current, err := overdrafts.GetApproved(account.ID)
+		if err != nil {
+			return err
+		}
+		if current != nil {
+			current.Status = models.OverdraftStatusRevoked
+			current.EndedAt = &now
+			if err := overdrafts.UpdateDecision(current); err != nil {
+				return err
+			}
+		}
+
+		facility.Status = models.OverdraftStatusApproved
+		facility.ApprovedLimit = facility.RequestedLimit
+		if req.Limit > 0 {
+			facility.ApprovedLimit = req.Limit
+		}
+		facility.RateBps = s.defaultRateBps
+		if req.RateBps != nil {
+			facility.RateBps = *req.RateBps
+		}
+		s.decide(facility, req, decidedBy, now)
+		if err := overdrafts.UpdateDecision(facility); err != nil {
+			return err
+		}
+
+		return s.accountRepo.WithTx(tx).SetOverdraft(account.ID, facility.ApprovedLimit, facility.RateBps)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return facility, nil
+}
+
+func (s *overdraftService) RejectOverdraft(id int, req *models.OverdraftDecisionRequest, decidedBy string) (*models.OverdraftFacility, error) {
+	var facility *models.OverdraftFacility
+	err := s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		overdrafts := s.overdraftRepo.WithTx(tx)
+
+		var err error
+		if facility, err = overdrafts.LockFacility(id); err != nil {
+			return err
+		}
+		if facility.Status != models.OverdraftStatusPending {
+			return fmt.Errorf("overdraft facility is %s, only pending requests can be rejected", facility.Status)
+		}
+
+		facility.Status = models.OverdraftStatusRejected
+		s.decide(facility, req, decidedBy, time.Now().UTC())
+		return overdrafts.UpdateDecision(facility)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return facility, nil
+}
+
+func (s *overdraftService) RevokeOverdraft(id int, req *models.OverdraftDecisionRequest, decidedBy string) (*models.OverdraftFacility, error) {
+	var facility *models.OverdraftFacility
+	err := s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		overdrafts := s.overdraftRepo.WithTx(tx)
+
+		var err error
+		if facility, err = overdrafts.LockFacility(id); err != nil {
+			return err
+		}
+		if facility.Status != models.OverdraftStatusApproved {
+			return fmt.Errorf("overdraft facility is %s, only approved facilities can be revoked", facility.Status)
+		}
+
+		if _, err := s.accountRepo.WithTx(tx).LockByIDs(facility.AccountID); err != nil {
+			return err
+		}
+
+		now := time.Now().UTC()
+		facility.Status = models.OverdraftStatusRevoked
+		facility.EndedAt = &now
+		s.decide(facility, req, decidedBy, now)
+		if err := overdrafts.UpdateDecision(facility); err != nil {
+			return err
+		}
+
+		return s.accountRepo.WithTx(tx).SetOverdraft(facility.AccountID, 0, facility.RateBps)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return facility, nil
+}
+
+// decide records who decided on a facility, when and why
+func (s *overdraftService) decide(facility *models.OverdraftFacility, req *models.OverdraftDecisionRequest, decidedBy string, now time.Time) {
+	facility.DecidedBy = decidedBy
+	facility.DecidedAt = &now
+	if req != nil {
+		facility.DecisionNote = req.Note
+	}
+}
+
+func (s *overdraftService) AccrueInterest(businessDate time.Time, limit int) (int, error) {
+	businessDate = truncateDay(businessDate)
+	if !businessDate.Before(truncateDay(time.Now().UTC())) {
+		return 0, fmt.Errorf("interest can only be accrued once the business date has ended")
+	}
+
+	accounts, err := s.overdraftRepo.AccountsToAccrue(businessDate, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	endOfDay := businessDate.AddDate(0, 0, 1)
+	for _, account := range accounts {
+		balance, err := s.ledger.AccountBalanceAt(account.ID, endOfDay)
+		if err != nil {
+			return 0, fmt.Errorf("closing balance of account %s: %w", account.AccountNumber, err)
+		}
+
+		// Days in credit are recorded too, owing nothing, so that the date
+		// counts as accrued. Another replica may have accrued it meanwhile;
+		// its accrual is kept.
+		_, err = s.overdraftRepo.CreateAccrual(&models.OverdraftInterestAccrual{
+			AccountID:     account.ID,
+			AccountNumber: account.AccountNumber,
+			BusinessDate:  businessDate,
+			Balance:       balance,
+			RateBps:       account.OverdraftRateBps,
+			AmountMicros:  models.OverdraftInterest(balance, account.OverdraftRateBps, s.dayCount),
+			CreatedAt:     time.Now().UTC(),
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to accrue debit interest of account %s: %w", account.AccountNumber, err)
+		}
+	}
+
+	return len(accounts), nil
+}
+
+func (s *overdraftService) PendingBusinessDates(now time.Time) ([]time.Time, error) {
+	yesterday := truncateDay(now).AddDate(0, 0, -1)
+
+	// The last date accrued may have been interrupted part way, so it is
+	// checked again; without any accrual yet, accruals start yesterday
+	start, err := s.overdraftRepo.LastAccrualDate()
+	if err != nil {
+		return nil, err
+	}
+	if start.IsZero() {
+		start = yesterday
+	}
+
+	var dates []time.Time
+	for date := start; !date.After(yesterday); date = date.AddDate(0, 0, 1) {
+		dates = append(dates, date)
+	}
+	return dates, nil
+}
+
+func (s *overdraftService) ChargeInterest(now time.Time, limit int) (int, error) {
+	monthStart := models.InterestPeriodStart(now, models.InterestCapitalizationMonthly)
+
+	accountIDs, err := s.overdraftRepo.AccountsToCharge(monthStart, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, accountID := range accountIDs {
+		err := s.txRunner.RunInTx(func(tx *sql.Tx) error {
+			return s.charge(tx, accountID, monthStart)
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to charge debit interest of account %d: %w", accountID, err)
+		}
+	}
+
+	return len(accountIDs), nil
+}
+
+// charge debits an account the interest it accrued before the given date by
+// a DEBIT_INTEREST transaction. Debit interest is owed whatever the overdraft
+// left, so it may take the balance below the limit.
+func (s *overdraftService) charge(tx *sql.Tx, accountID int, before time.Time) error {
+	locked, err := s.accountRepo.WithTx(tx).LockByIDs(accountID)
+	if err != nil {
+		return err
+	}
+	account := locked[accountID]
+
+	overdrafts := s.overdraftRepo.WithTx(tx)
+	accruals, err := overdrafts.LockUncharged(accountID, before)
+	if err != nil {
+		return err
+	}
+	// Another replica charged them meanwhile
+	if len(accruals) == 0 {
+		return nil
+	}
+
+	var micros int64
+	accrualIDs := make([]int64, len(accruals))
+	for i, accrual := range accruals {
+		micros += accrual.AmountMicros
+		accrualIDs[i] = accrual.ID
+	}
+
+	now := time.Now().UTC()
+	amount := models.RoundInterest(micros)
+	if amount == 0 {
+		return overdrafts.MarkCharged(accrualIDs, "", now)
+	}
+
+	description := fmt.Sprintf("Debit interest %s to %s",
+		accruals[0].BusinessDate.Format("2006-01-02"), accruals[len(accruals)-1].BusinessDate.Format("2006-01-02"))
+	transaction := &models.Transaction{
+		TransactionID:     generateTransactionID(),
+		FromAccountID:     account.ID,
+		FromAccountNumber: account.AccountNumber,
+		Amount:            amount,
+		Currency:          account.Currency,
+		ExchangeRate:      1.0,
+		ConvertedAmount:   amount,
+		TransactionType:   models.TransactionTypeDebitInterest,
+		Status:            models.TransactionStatusCompleted,
+		Description:       description,
+		ProcessedAt:       &now,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+
+	entry := ledger.NewJournalEntry(transaction.TransactionID, description).
+		DebitAccount(account.ID, account.Currency, amount).
+		CreditGL(ledger.GLInterestIncome, account.Currency, amount)
+
+	if err := s.transactionRepo.WithTx(tx).Create(transaction); err != nil {
+		return fmt.Errorf("failed to create debit interest transaction: %w", err)
+	}
+	if err := s.ledger.WithTx(tx).Post(entry); err != nil {
+		return err
+	}
+
+	return overdrafts.MarkCharged(accrualIDs, transaction.TransactionID, now)
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// maxPasswordBytes is the longest password bcrypt hashes in full
+const maxPasswordBytes = 72
+
+// PasswordPolicy decides which passwords customers may choose
+type PasswordPolicy struct {
+	MinLength      int
+	MinCharClasses int // Of lower case letters, upper case letters, digits and symbols
+	HistoryDepth   int // Recent passwords, the current one included, that cannot be chosen again
+	breached       map[string]struct{}
+}
+
+// NewPasswordPolicy loads the breached password list, one password per line;
+// an empty path disables the check
+func NewPasswordPolicy(minLength, minCharClasses, historyDepth int, breachedListFile string) (*PasswordPolicy, error) {
+	policy := &PasswordPolicy{
+		MinLength:      minLength,
+		MinCharClasses: minCharClasses,
+		HistoryDepth:   historyDepth,
+		breached:       map[string]struct{}{},
+	}
+	if breachedListFile == "" {
+		return policy, nil
+	}
+
+	file, err := os.Open(breachedListFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password list: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		policy.breached[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read breached password list: %w", err)
+	}
+
+	return policy, nil
+}
+
+// Validate checks a new password against the length, character class and
+// breached password rules
+func (p *PasswordPolicy) Validate(password string) error {
+	if len([]rune(password)) < p.MinLength {
+		return fmt.Errorf("password must be at least %d characters long", p.MinLength)
+	}
+	if len(password) > maxPasswordBytes {
+		return fmt.Errorf("password must be at most %d bytes long", maxPasswordBytes)
+	}
+
+	if classes := passwordCharClasses(password); classes < p.MinCharClasses {
+		return fmt.Errorf("password must mix at least %d of lower case letters, upper case letters, digits and symbols", p.MinCharClasses)
+	}
+
+	if _, found := p.breached[strings.ToLower(password)]; found {
+		return fmt.Errorf("password appears in a list of breached passwords; choose another one")
+	}
+
+	return nil
+}
+
+// CheckReuse refuses a password matching one of the previous password hashes,
+// newest first; only the policy's history depth is considered
+func (p *PasswordPolicy) CheckReuse(password string, previousHashes []string) error {
+	for i, hash := range previousHashes {
+		if i >= p.HistoryDepth {
+			break
+		}
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil {
+			return fmt.Errorf("password was used recently; choose another one")
+		}
+	}
+	return nil
+}
+
+func passwordCharClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	classes := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			classes++
+		}
+	}
+	return classes
+}
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/notify"
+	"github.com/bank-api/internal/repository"
+	"github.com/bank-api/internal/utils"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrInvalidResetToken is returned for any reset token that cannot be used;
+	// callers should not learn why
+	ErrInvalidResetToken = errors.New("invalid or expired password reset token")
+	// ErrPasswordResetDisabled is returned when no notification sender is
+	// configured to deliver reset tokens
+	ErrPasswordResetDisabled = errors.New("password reset is not available")
+)
+
+type PasswordService interface {
+	// ChangePassword replaces the password of the customer behind claims once
+	// their current one checks out, and revokes their other sessions. Wrong
+	// current passwords count towards a lockout like failed logins.
+	ChangePassword(claims *utils.JWTClaims, currentPassword, newPassword string, client ClientInfo) error
+	// RequestReset sends a reset token to the active customer with the email,
+	// if there is one, replacing any token sent before. Failures are logged
+	// rather than returned so that the outcome is the same for every email.
+	// Without a notification sender it returns ErrPasswordResetDisabled.
+	RequestReset(email string) error
+	// ResetPassword sets a new password with a reset token and revokes every
+	// session of the customer
+	ResetPassword(token, newPassword string) error
+}
+
+type passwordService struct {
+	customerService CustomerService
+	customerRepo    repository.CustomerRepository
+	passwordRepo    repository.PasswordRepository
+	sessionRepo     repository.SessionRepository
+	txRunner        repository.TxRunner
+	policy          *PasswordPolicy
+	sender          notify.Sender
+	resetTTL        time.Duration
+}
+
+func NewPasswordService(
+	customerService CustomerService,
+	customerRepo repository.CustomerRepository,
+	passwordRepo repository.PasswordRepository,
+	sessionRepo repository.SessionRepository,
+	txRunner repository.TxRunner,
+	policy *PasswordPolicy,
+	sender notify.Sender,
+	resetTTL time.Duration,
+) PasswordService {
+	return &passwordService{
+		customerService: customerService,
+		customerRepo:    customerRepo,
+		passwordRepo:    passwordRepo,
+		sessionRepo:     sessionRepo,
+		txRunner:        txRunner,
+		policy:          policy,
+		sender:          sender,
+		resetTTL:        resetTTL,
+	}
+}
+
+func (s *passwordService) ChangePassword(claims *utils.JWTClaims, currentPassword, newPassword string, client ClientInfo) error {
+	if _, err := s.customerService.AuthenticateCustomer(claims.CustomerID, currentPassword, client); err != nil {
+		return err
+	}
+
+	if err := s.policy.Validate(newPassword); err != nil {
+		return err
+	}
+
+	return s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		return s.replacePassword(tx, claims.CustomerID, newPassword, claims.SessionID, models.RevokedPasswordChange)
+	})
+}
+
+func (s *passwordService) RequestReset(email string) error {
+	if s.sender == nil {
+		return ErrPasswordResetDisabled
+	}
+
+	customer, err := s.customerRepo.GetByEmail(strings.TrimSpace(email))
+	if err != nil || !customer.IsActive() {
+		return nil
+	}
+
+	now := time.Now().UTC()
+	token := generatePasswordResetToken()
+	resetToken := &models.PasswordResetToken{
+		TokenHash:  hashPasswordResetToken(token),
+		CustomerID: customer.CustomerID,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(s.resetTTL),
+	}
+
+	err = s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		passwordRepo := s.passwordRepo.WithTx(tx)
+
+		if err := passwordRepo.InvalidateResetTokens(customer.CustomerID, now); err != nil {
+			return fmt.Errorf("failed to invalidate reset tokens: %w", err)
+		}
+		if err := passwordRepo.CreateResetToken(resetToken); err != nil {
+			return fmt.Errorf("failed to create reset token: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to create password reset token for customer %s: %v", customer.CustomerID, err)
+		return nil
+	}
+
+	err = s.sender.Send(&notify.Message{
+		To:      customer.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nUse this token to choose a new password: %s\n\nIt expires at %s. If you did not ask to reset your password, ignore this message.",
+			customer.FirstName, token, resetToken.ExpiresAt.Format(time.RFC1123),
+		),
+		SentAt: now,
+	})
+	if err != nil {
+		// Failing only for registered emails would tell callers which ones are
+		log.Printf("Failed to send password reset token to customer %s: %v", customer.CustomerID, err)
+	}
+
+	return nil
+}
+
+func (s *passwordService) ResetPassword(token, newPassword string) error {
+	if s.sender == nil {
+		return ErrPasswordResetDisabled
+	}
+	if token == "" {
+		return ErrInvalidResetToken
+	}
+
+	// A password the policy refuses does not use up the token
+	if err := s.policy.Validate(newPassword); err != nil {
+		return err
+	}
+
+	return s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		passwordRepo := s.passwordRepo.WithTx(tx)
+
+		resetToken, err := passwordRepo.GetResetTokenForUpdate(hashPasswordResetToken(token))
+		if err != nil || !resetToken.IsValid() {
+			return ErrInvalidResetToken
+		}
+
+		if err := passwordRepo.MarkResetTokenUsed(resetToken.ID, time.Now().UTC()); err != nil {
+			return fmt.Errorf("failed to use reset token: %w", err)
+		}
+
+		return s.replacePassword(tx, resetToken.CustomerID, newPassword, "", models.RevokedPasswordReset)
+	})
+}
+
+// replacePassword sets a customer's new password unless it is one of their
+// recent ones, keeps the old hash in their history and revokes their
+// sessions but keepSessionID
+func (s *passwordService) replacePassword(tx *sql.Tx, customerID, newPassword, keepSessionID, reason string) error {
+	customerRepo := s.customerRepo.WithTx(tx)
+	passwordRepo := s.passwordRepo.WithTx(tx)
+
+	customer, err := customerRepo.GetByCustomerID(customerID)
+	if err != nil || !customer.IsActive() {
+		return fmt.Errorf("customer is not active")
+	}
+
+	if s.policy.HistoryDepth > 0 {
+		previous, err := passwordRepo.RecentHashes(customerID, s.policy.HistoryDepth-1)
+		if err != nil {
+			return fmt.Errorf("failed to load password history: %w", err)
+		}
+		if err := s.policy.CheckReuse(newPassword, append([]string{customer.HashPassword}, previous...)); err != nil {
+			return err
+		}
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	now := time.Now().UTC()
+	if err := customerRepo.UpdatePassword(customerID, string(hashedPassword), now); err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+
+	if s.policy.HistoryDepth > 1 {
+		if err := passwordRepo.AddHistory(customerID, customer.HashPassword, now, s.policy.HistoryDepth-1); err != nil {
+			return fmt.Errorf("failed to record password history: %w", err)
+		}
+	}
+
+	if _, err := s.sessionRepo.WithTx(tx).RevokeSubjectSessions(models.SubjectCustomer, customerID, keepSessionID, reason); err != nil {
+		return fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+
+	return nil
+}
+
+// generatePasswordResetToken returns an opaque 256-bit token; only its hash is stored
+func generatePasswordResetToken() string {
+	randomBytes := make([]byte, 32)
+	rand.Read(randomBytes)
+
+	return base64.RawURLEncoding.EncodeToString(randomBytes)
+}
+
+func hashPasswordResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/bank-api/internal/models"
+)
+
+// PaymentGateway hands outbound payments to the interbank clearing system.
+// Submit must be idempotent on the payment ID: a payment may be submitted
+// again if recording the first submission failed. Settlement or rejection is
+// reported later through the clearing status callback.
+type PaymentGateway interface {
+	Submit(payment *models.OutboundPayment) (reference string, err error)
+}
+
+// NewPaymentGateway selects the gateway configured by CLEARING_GATEWAY
+func NewPaymentGateway(kind, outboxDir string) (PaymentGateway, error) {
+	switch kind {
+	case "mock":
+		return NewMockGateway(), nil
+	case "file":
+		return NewFileGateway(outboxDir)
+	default:
+		return nil, fmt.Errorf("unknown clearing gateway: %s", kind)
+	}
+}
+
+// MockGateway accepts every payment without sending it anywhere; status
+// callbacks have to be posted by hand
+type MockGateway struct{}
+
+func NewMockGateway() PaymentGateway {
+	return &MockGateway{}
+}
+
+func (g *MockGateway) Submit(payment *models.OutboundPayment) (string, error) {
+	return "MOCK-" + payment.PaymentID, nil
+}
+
+// FileGateway writes each payment as a JSON file into an outbox directory,
+// standing in for the file exchange with the clearing house
+type FileGateway struct {
+	dir string
+}
+
+func NewFileGateway(dir string) (PaymentGateway, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("clearing outbox directory is required for the file gateway")
+	}
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create clearing outbox: %w", err)
+	}
+
+	return &FileGateway{dir: dir}, nil
+}
+
+func (g *FileGateway) Submit(payment *models.OutboundPayment) (string, error) {
+	content, err := json.MarshalIndent(payment, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	// Write then rename so the clearing side never picks up a partial file;
+	// resubmitting a payment overwrites its file
+	name := payment.PaymentID + ".json"
+	tmp := filepath.Join(g.dir, "."+name+".tmp")
+	if err := os.WriteFile(tmp, content, 0o640); err != nil {
+		return "", fmt.Errorf("failed to write payment file: %w", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(g.dir, name)); err != nil {
+		return "", fmt.Errorf("failed to write payment file: %w", err)
+	}
+
+	return name, nil
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"crypto/rand"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/repository"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type StaffService interface {
+	CreateStaffUser(req *models.CreateStaffUserRequest) (*models.StaffUser, error)
+	AuthenticateStaff(username, password string) (*models.StaffUser, error)
+	GetStaffUser(staffID string) (*models.StaffUser, error)
+	GetAllStaff(limit, offset int) ([]*models.StaffUser, error)
+	UpdateStaffStatus(staffID, status string) error
+	// EnsureAdmin creates the bootstrap admin user when there is no active admin
+	EnsureAdmin(username, email, password string) (bool, error)
+}
+
+type staffService struct {
+	staffRepo repository.StaffRepository
+}
+
+func NewStaffService(staffRepo repository.StaffRepository) StaffService {
+	return &staffService{
+		staffRepo: staffRepo,
+	}
+}
+
+func (s *staffService) CreateStaffUser(req *models.CreateStaffUserRequest) (*models.StaffUser, error) {
+	req.Username = strings.ToLower(strings.TrimSpace(req.Username))
+
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.staffRepo.GetByUsername(req.Username); err == nil {
+		return nil, fmt.Errorf("username %s is already taken", req.Username)
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	now := time.Now().UTC()
+	user := &models.StaffUser{
+		StaffID:      s.generateStaffID(),
+		Username:     req.Username,
+		Email:        req.Email,
+		FullName:     req.FullName,
+		Role:         req.Role,
+		HashPassword: string(hashedPassword),
+		Status:       models.StaffStatusActive,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	if err := s.staffRepo.Create(user); err != nil {
+		return nil, fmt.Errorf("failed to create staff user: %w", err)
+	}
+
+	user.HashPassword = ""
+	return user, nil
+}
+
+func (s *staffService) AuthenticateStaff(username, password string) (*models.StaffUser, error) {
+	user, err := s.staffRepo.GetByUsername(strings.ToLower(strings.TrimSpace(username)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid credentials")
+	}
+
+	if !user.ValidatePassword(password) {
+		return nil, fmt.Errorf("invalid credentials")
+	}
+
+	if !user.IsActive() {
+		return nil, fmt.Errorf("staff user is disabled")
+	}
+
+	s.staffRepo.UpdateLastLogin(user.StaffID)
+
+	user.HashPassword = ""
+	return user, nil
+}
+
+func (s *staffService) GetStaffUser(staffID string) (*models.StaffUser, error) {
+	return s.staffRepo.GetByStaffID(staffID)
+}
+
+func (s *staffService) GetAllStaff(limit, offset int) ([]*models.StaffUser, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	return s.staffRepo.GetAll(limit, offset)
+}
+
+func (s *staffService) UpdateStaffStatus(staffID, status string) error {
+	if status != models.StaffStatusActive && status != models.StaffStatusDisabled {
+		return fmt.Errorf("invalid staff status: %s", status)
+	}
+
+	user, err := s.staffRepo.GetByStaffID(staffID)
+	if err != nil {
+		return err
+	}
+
+	// Never lock the back office out entirely
+	if user.Role == models.RoleAdmin && status == models.StaffStatusDisabled && user.IsActive() {
+		admins, err := s.staffRepo.CountActiveByRole(models.RoleAdmin)
+		if err != nil {
+			return err
+		}
+		if admins <= 1 {
+			return fmt.Errorf("cannot disable the last active admin")
+		}
+	}
+
+	return s.staffRepo.UpdateStatus(staffID, status)
+}
+
+func (s *staffService) EnsureAdmin(username, email, password string) (bool, error) {
+	admins, err := s.staffRepo.CountActiveByRole(models.RoleAdmin)
+	if err != nil {
+		return false, err
+	}
+
+	if admins > 0 {
+		return false, nil
+	}
+
+	_, err = s.CreateStaffUser(&models.CreateStaffUserRequest{
+		Username: username,
+		Email:    email,
+		FullName: "Administrator",
+		Role:     models.RoleAdmin,
+		Password: password,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (s *staffService) generateStaffID() string {
+	timestamp := time.Now().Unix()
+	randomBytes := make([]byte, 4)
+	rand.Read(randomBytes)
+
+	return fmt.Sprintf("STF%d%x", timestamp, randomBytes)
+}
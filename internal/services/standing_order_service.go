@@ -0,0 +1,412 @@
+package services
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/repository"
+)
+
+// standingOrderLease is how long a claimed order is left to its replica
+// before another one may resume the execution
+const standingOrderLease = 10 * time.Minute
+
+type StandingOrderService interface {
+	CreateOrder(req *models.CreateStandingOrderRequest) (*models.StandingOrder, error)
+	GetOrder(orderID string) (*models.StandingOrder, error)
+	GetAccountOrders(accountNumber string) ([]*models.StandingOrder, error)
+	PauseOrder(orderID string) (*models.StandingOrder, error)
+	// ResumeOrder reactivates a paused order, skipping the occurrences that
+	// fell while it was paused
+	ResumeOrder(orderID string) (*models.StandingOrder, error)
+	CancelOrder(orderID string) (*models.StandingOrder, error)
+	GetExecutions(orderID string, limit, offset int) ([]*models.StandingOrderExecution, error)
+	// ExecuteDue executes up to limit due orders and returns how many it
+	// claimed. Several replicas can run it concurrently: each order is
+	// claimed by one of them only.
+	ExecuteDue(limit int) (int, error)
+}
+
+type standingOrderService struct {
+	orderRepo          repository.StandingOrderRepository
+	accountRepo        repository.AccountRepository
+	transactionRepo    repository.TransactionRepository
+	transactionService TransactionService
+	txRunner           repository.TxRunner
+	maxRetries         int
+	retryBackoff       time.Duration
+}
+
+// NewStandingOrderService creates the standing order service. An occurrence
+// that fails for insufficient funds is retried up to maxRetries times, after
+// retryBackoff, then twice as long after each further failure.
+func NewStandingOrderService(orderRepo repository.StandingOrderRepository, accountRepo repository.AccountRepository, transactionRepo repository.TransactionRepository, transactionService TransactionService, txRunner repository.TxRunner, maxRetries int, retryBackoff time.Duration) StandingOrderService {
+	return &standingOrderService{
+		orderRepo:          orderRepo,
+		accountRepo:        accountRepo,
+		transactionRepo:    transactionRepo,
+		transactionService: transactionService,
+		txRunner:           txRunner,
+		maxRetries:         maxRetries,
+		retryBackoff:       retryBackoff,
+	}
+}
+
+func (s *standingOrderService) CreateOrder(req *models.CreateStandingOrderRequest) (*models.StandingOrder, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start date, expected YYYY-MM-DD")
+	}
+	if startDate.Before(truncateDay(time.Now().UTC())) {
+		return nil, fmt.Errorf("start date cannot be in the past")
+	}
+
+	var endDate *time.Time
+	if req.EndDate != "" {
+		parsed, err := time.Parse("2006-01-02", req.EndDate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid end date, expected YYYY-MM-DD")
+		}
+		if parsed.Before(startDate) {
+			return nil, fmt.Errorf("end date cannot be before the start date")
+		}
+		endDate = &parsed
+	}
+
+	if req.Frequency == models.StandingOrderOnce && (endDate != nil || req.MaxOccurrences > 0) {
+		return nil, fmt.Errorf("a one-off order takes neither an end date nor an occurrence count")
+	}
+
+	dayOfMonth := req.DayOfMonth
+	if req.Frequency != models.StandingOrderMonthly {
+		if dayOfMonth != 0 {
+			return nil, fmt.Errorf("day of month only applies to monthly orders")
+		}
+	} else if dayOfMonth == 0 {
+		dayOfMonth = startDate.Day()
+	}
+
+	fromAccount, err := s.accountRepo.GetByAccountNumber(req.FromAccountNumber)
+	if err != nil {
+		return nil, fmt.Errorf("source account not found")
+	}
+	if !fromAccount.IsActive() {
+		return nil, fmt.Errorf("source account is not active")
+	}
+	if req.Currency != fromAccount.Currency {
+		return nil, fmt.Errorf("standing order currency %s does not match account currency %s", req.Currency, fromAccount.Currency)
+	}
+
+	// The destination is checked again by each transfer; catch typos now
+	if req.ToIBAN != "" {
+		if err := models.ValidateIBAN(models.NormalizeIBAN(req.ToIBAN)); err != nil {
+			return nil, fmt.Errorf("invalid destination IBAN: %w", err)
+		}
+		req.ToIBAN = models.NormalizeIBAN(req.ToIBAN)
+	}
+	if req.ToAccountNumber != "" {
+		if req.ToAccountNumber == req.FromAccountNumber {
+			return nil, fmt.Errorf("cannot transfer to the same account")
+		}
+		if _, err := s.accountRepo.GetByAccountNumber(req.ToAccountNumber); err != nil {
+			return nil, fmt.Errorf("destination account not found")
+		}
+	}
+
+	now := time.Now().UTC()
+	order := &models.StandingOrder{
+		OrderID:           s.generateOrderID(),
+		FromAccountNumber: fromAccount.AccountNumber,
+		ToAccountNumber:   req.ToAccountNumber,
+		ToIBAN:            req.ToIBAN,
+		ToBIC:             strings.ToUpper(req.ToBIC),
+		BeneficiaryName:   req.BeneficiaryName,
+		Amount:            req.Amount,
+		Currency:          req.Currency,
+		Description:       req.Description,
+		Reference:         req.Reference,
+		Frequency:         req.Frequency,
+		DayOfMonth:        dayOfMonth,
+		StartDate:         startDate,
+		EndDate:           endDate,
+		MaxOccurrences:    req.MaxOccurrences,
+		Status:            models.StandingOrderStatusActive,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+
+	if err := order.Schedule(); err != nil {
+		return nil, err
+	}
+
+	if err := s.orderRepo.Create(order); err != nil {
+		return nil, fmt.Errorf("failed to create standing order: %w", err)
+	}
+
+	return order, nil
+}
+
+func (s *standingOrderService) GetOrder(orderID string) (*models.StandingOrder, error) {
+	return s.orderRepo.GetByOrderID(orderID)
+}
+
+func (s *standingOrderService) GetAccountOrders(accountNumber string) ([]*models.StandingOrder, error) {
+	return s.orderRepo.GetByAccountNumber(accountNumber)
+}
+
+func (s *standingOrderService) PauseOrder(orderID string) (*models.StandingOrder, error) {
+	return s.changeStatus(orderID, func(order *models.StandingOrder) error {
+		if order.Status != models.StandingOrderStatusActive {
+			return fmt.Errorf("standing order is %s and cannot be paused", order.Status)
+		}
+		order.Status = models.StandingOrderStatusPaused
+		return nil
+	})
+}
+
+func (s *standingOrderService) ResumeOrder(orderID string) (*models.StandingOrder, error) {
+	return s.changeStatus(orderID, func(order *models.StandingOrder) error {
+		if order.Status != models.StandingOrderStatusPaused {
+			return fmt.Errorf("standing order is %s and cannot be resumed", order.Status)
+		}
+		order.Status = models.StandingOrderStatusActive
+		order.SkipTo(truncateDay(time.Now().UTC()))
+		return nil
+	})
+}
+
+func (s *standingOrderService) CancelOrder(orderID string) (*models.StandingOrder, error) {
+	return s.changeStatus(orderID, func(order *models.StandingOrder) error {
+		if order.IsClosed() {
+			return fmt.Errorf("standing order is already %s", order.Status)
+		}
+		order.Status = models.StandingOrderStatusCancelled
+		order.NextRunDate = nil
+		order.NextAttemptAt = nil
+		return nil
+	})
+}
+
+// changeStatus applies change to the locked order and saves it
+func (s *standingOrderService) changeStatus(orderID string, change func(order *models.StandingOrder) error) (*models.StandingOrder, error) {
+	var changed *models.StandingOrder
+
+	err := s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		orders := s.orderRepo.WithTx(tx)
+
+		order, err := orders.GetByOrderIDForUpdate(orderID)
+		if err != nil {
+			return fmt.Errorf("standing order not found")
+		}
+
+		if err := change(order); err != nil {
+			return err
+		}
+
+		if err := orders.UpdateSchedule(order); err != nil {
+			return err
+		}
+
+		changed = order
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return changed, nil
+}
+
+func (s *standingOrderService) GetExecutions(orderID string, limit, offset int) ([]*models.StandingOrderExecution, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	return s.orderRepo.GetExecutions(orderID, limit, offset)
+}
+
+// claim is a due order and the execution a replica claimed it for
+type claim struct {
+	order     *models.StandingOrder
+	execution *models.StandingOrderExecution
+}
+
+func (s *standingOrderService) ExecuteDue(limit int) (int, error) {
+	claims, err := s.claimDue(limit)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, c := range claims {
+		if err := s.execute(c); err != nil {
+			return 0, fmt.Errorf("standing order %s: %w", c.order.OrderID, err)
+		}
+	}
+
+	return len(claims), nil
+}
+
+// claimDue locks due orders and records a RUNNING execution for each, then
+// pushes their next attempt past the lease so that no other replica picks
+// them up while their transfers run. An execution left RUNNING by a replica
+// that stopped is resumed with its transaction ID, which the transfer cannot
+// reuse if it went through.
+func (s *standingOrderService) claimDue(limit int) ([]claim, error) {
+	var claims []claim
+
+	err := s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		orders := s.orderRepo.WithTx(tx)
+
+		now := time.Now().UTC()
+		due, err := orders.LockDue(now, limit)
+		if err != nil {
+			return err
+		}
+
+		for _, order := range due {
+			execution, err := orders.GetRunningExecution(order.OrderID)
+			if err != nil {
+				execution = &models.StandingOrderExecution{
+					OrderID:       order.OrderID,
+					ScheduledFor:  *order.NextRunDate,
+					Attempt:       order.RetryCount + 1,
+					TransactionID: generateTransactionID(),
+					Status:        models.ExecutionStatusRunning,
+					StartedAt:     now,
+				}
+				if err := orders.CreateExecution(execution); err != nil {
+					return fmt.Errorf("failed to record standing order execution: %w", err)
+				}
+			}
+
+			leaseEnd := now.Add(standingOrderLease)
+			order.NextAttemptAt = &leaseEnd
+			if err := orders.UpdateSchedule(order); err != nil {
+				return err
+			}
+
+			claims = append(claims, claim{order: order, execution: execution})
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// execute runs the transfer of a claimed execution, unless it already went
+// through, and records the outcome
+func (s *standingOrderService) execute(c claim) error {
+	cause := s.transferOutcome(c.execution.TransactionID)
+	if cause == errNoTransaction {
+		req := c.order.TransferRequest()
+		req.TransactionID = c.execution.TransactionID
+		_, err := s.transactionService.Transfer(req)
+		if cause = err; err != nil {
+			// A replica resuming the execution may have lost a race with
+			// the one it thought stopped
+			if outcome := s.transferOutcome(c.execution.TransactionID); outcome == nil {
+				cause = nil
+			}
+		}
+	}
+
+	return s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		orders := s.orderRepo.WithTx(tx)
+
+		order, err := orders.GetByOrderIDForUpdate(c.order.OrderID)
+		if err != nil {
+			return err
+		}
+
+		status, message := models.ExecutionStatusCompleted, ""
+		if cause != nil {
+			status, message = models.ExecutionStatusFailed, cause.Error()
+		}
+		if err := orders.FinishExecution(c.execution.ID, status, message); err != nil {
+			return err
+		}
+
+		// A cancelled order keeps no schedule
+		if order.IsClosed() {
+			return nil
+		}
+
+		switch {
+		case cause == nil:
+			order.LastError = ""
+			order.Advance()
+		case isInsufficientFunds(cause) && order.RetryCount < s.maxRetries:
+			order.LastError = message
+			order.RetryCount++
+			retryAt := time.Now().UTC().Add(s.retryDelay(order.RetryCount))
+			order.NextAttemptAt = &retryAt
+		default:
+			// The occurrence is given up on; the order carries on
+			order.LastError = message
+			order.Advance()
+		}
+
+		return orders.UpdateSchedule(order)
+	})
+}
+
+// retryDelay returns how long to wait after the nth failed attempt at an
+// occurrence, doubling each time up to a week
+func (s *standingOrderService) retryDelay(n int) time.Duration {
+	delay := s.retryBackoff
+	for i := 1; i < n && delay < 7*24*time.Hour; i++ {
+		delay *= 2
+	}
+	if delay > 7*24*time.Hour {
+		delay = 7 * 24 * time.Hour
+	}
+	return delay
+}
+
+// errNoTransaction means an execution's transfer has not been recorded
+var errNoTransaction = errors.New("transfer not recorded")
+
+// transferOutcome returns nil if the transaction went through, its failure
+// reason if it failed, and errNoTransaction if there is none
+func (s *standingOrderService) transferOutcome(transactionID string) error {
+	transaction, err := s.transactionRepo.GetByTransactionID(transactionID)
+	if err != nil {
+		return errNoTransaction
+	}
+	if transaction.Status == models.TransactionStatusFailed {
+		return errors.New(transaction.FailureReason)
+	}
+	return nil
+}
+
+// isInsufficientFunds checks if a transfer failed for lack of funds, which
+// may be resolved by the time it is retried
+func isInsufficientFunds(err error) bool {
+	return strings.Contains(err.Error(), "insufficient balance")
+}
+
+func (s *standingOrderService) generateOrderID() string {
+	timestamp := time.Now().Unix()
+	randomBytes := make([]byte, 6)
+	rand.Read(randomBytes)
+
+	return fmt.Sprintf("SO%d%x", timestamp, randomBytes)
+}
@@ -0,0 +1,209 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/bank-api/internal/ledger"
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/repository"
+	"github.com/bank-api/internal/statements"
+)
+
+// maxStatementPeriod is the longest period a single statement covers
+const maxStatementPeriod = 366 * 24 * time.Hour
+
+// statementPageSize is how many transactions are read per query when
+// building a statement
+const statementPageSize = 100
+
+type StatementService interface {
+	// GetStatement builds the statement of the account for the days from
+	// first to last, both included
+	GetStatement(accountNumber string, first, last time.Time) (*models.Statement, error)
+	// RenderStatement returns the statement as a CSV or PDF document, from
+	// the copy stored by the monthly job when there is one
+	RenderStatement(accountNumber string, first, last time.Time, format string) ([]byte, error)
+	// GenerateMonthlyStatements renders and stores the statement of the
+	// month starting at month for up to limit accounts that lack one, and
+	// returns how many accounts it went through
+	GenerateMonthlyStatements(month time.Time, limit int) (int, error)
+}
+
+type statementService struct {
+	accountRepo     repository.AccountRepository
+	customerRepo    repository.CustomerRepository
+	transactionRepo repository.TransactionRepository
+	statementRepo   repository.StatementRepository
+	ledger          ledger.Ledger
+	bank            models.Bank
+	font            *statements.Font
+}
+
+// NewStatementService creates the statement service. font may be nil, in
+// which case PDF statements are rendered without their Arabic captions.
+func NewStatementService(accountRepo repository.AccountRepository, customerRepo repository.CustomerRepository, transactionRepo repository.TransactionRepository, statementRepo repository.StatementRepository, ledger ledger.Ledger, bank models.Bank, font *statements.Font) StatementService {
+	return &statementService{
+		accountRepo:     accountRepo,
+		customerRepo:    customerRepo,
+		transactionRepo: transactionRepo,
+		statementRepo:   statementRepo,
+		ledger:          ledger,
+		bank:            bank,
+		font:            font,
+	}
+}
+
+func (s *statementService) GetStatement(accountNumber string, first, last time.Time) (*models.Statement, error) {
+	first, last = truncateDay(first), truncateDay(last)
+	end := last.AddDate(0, 0, 1)
+	if !end.After(first) {
+		return nil, fmt.Errorf("statement period must end after it starts")
+	}
+	if end.Sub(first) > maxStatementPeriod {
+		return nil, fmt.Errorf("statement period cannot exceed one year")
+	}
+
+	account, err := s.accountRepo.GetByAccountNumber(accountNumber)
+	if err != nil {
+		return nil, fmt.Errorf("account not found")
+	}
+
+	statement := &models.Statement{
+		AccountNumber: account.AccountNumber,
+		IBAN:          account.IBAN,
+		BIC:           account.BIC,
+		Currency:      account.Currency,
+		PeriodStart:   first,
+		PeriodEnd:     last,
+		Lines:         []models.StatementLine{},
+		GeneratedAt:   time.Now().UTC(),
+	}
+
+	if customer, err := s.customerRepo.GetByCustomerID(account.CustomerID); err == nil {
+		statement.OwnerName = customer.FirstName + " " + customer.LastName
+	}
+
+	if statement.OpeningBalance, err = s.ledger.AccountBalanceAt(account.ID, first); err != nil {
+		return nil, err
+	}
+	statement.ClosingBalance = statement.OpeningBalance
+
+	transactions, err := bookedTransactions(s.transactionRepo, accountNumber, first, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, transaction := range transactions {
+		statement.AddLine(transaction)
+	}
+
+	return statement, nil
+}
+
+func (s *statementService) RenderStatement(accountNumber string, first, last time.Time, format string) ([]byte, error) {
+	if format != models.StatementFormatCSV && format != models.StatementFormatPDF {
+		return nil, fmt.Errorf("unsupported statement format: %s", format)
+	}
+
+	first, last = truncateDay(first), truncateDay(last)
+	if stored, err := s.statementRepo.GetByPeriod(accountNumber, first, last); err == nil {
+		if format == models.StatementFormatCSV {
+			return stored.CSV, nil
+		}
+		return stored.PDF, nil
+	}
+
+	statement, err := s.GetStatement(accountNumber, first, last)
+	if err != nil {
+		return nil, err
+	}
+
+	if format == models.StatementFormatCSV {
+		return statements.RenderCSV(statement)
+	}
+	return statements.RenderPDF(statement, s.bank.Name, s.font)
+}
+
+func (s *statementService) GenerateMonthlyStatements(month time.Time, limit int) (int, error) {
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 1, -1)
+
+	accountNumbers, err := s.statementRepo.AccountsWithoutStatement(first, last, limit)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, accountNumber := range accountNumbers {
+		stored, err := s.renderForStorage(accountNumber, first, last)
+		if err != nil {
+			return 0, fmt.Errorf("statement of account %s for %s: %w", accountNumber, first.Format("2006-01"), err)
+		}
+
+		// Another replica may have stored it meanwhile; its copy is kept
+		if err := s.statementRepo.Create(stored); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(accountNumbers), nil
+}
+
+// renderForStorage renders both documents of a statement
+func (s *statementService) renderForStorage(accountNumber string, first, last time.Time) (*models.StoredStatement, error) {
+	statement, err := s.GetStatement(accountNumber, first, last)
+	if err != nil {
+		return nil, err
+	}
+
+	csv, err := statements.RenderCSV(statement)
+	if err != nil {
+		return nil, err
+	}
+	pdf, err := statements.RenderPDF(statement, s.bank.Name, s.font)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.StoredStatement{
+		AccountNumber:  accountNumber,
+		PeriodStart:    first,
+		PeriodEnd:      last,
+		OpeningBalance: statement.OpeningBalance,
+		ClosingBalance: statement.ClosingBalance,
+		LineCount:      len(statement.Lines),
+		CSV:            csv,
+		PDF:            pdf,
+		CreatedAt:      statement.GeneratedAt,
+	}, nil
+}
+
+// bookedTransactions returns the account's booked transactions created in
+// [from, to), oldest first
+func bookedTransactions(transactionRepo repository.TransactionRepository, accountNumber string, from, to time.Time) ([]*models.Transaction, error) {
+	// History is returned newest first, a page at a time
+	end := to.Add(-time.Microsecond)
+	var transactions []*models.Transaction
+	for offset := 0; ; offset += statementPageSize {
+		page, err := transactionRepo.GetByDateRange(accountNumber, from, end, statementPageSize, offset)
+		if err != nil {
+			return nil, err
+		}
+		transactions = append(transactions, page...)
+		if len(page) < statementPageSize {
+			break
+		}
+	}
+
+	var booked []*models.Transaction
+	for i := len(transactions) - 1; i >= 0; i-- {
+		if transactions[i].IsBooked() {
+			booked = append(booked, transactions[i])
+		}
+	}
+	return booked, nil
+}
+
+// truncateDay returns midnight UTC of t's day
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,409 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/bank-api/internal/models"
+	"github.com/bank-api/internal/repository"
+	"github.com/bank-api/internal/utils"
+)
+
+// ErrInvalidRefreshToken is returned for any refresh token that cannot be
+// exchanged; callers should not learn why
+var ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+
+// IssuedTokens is a short-lived access token plus the single-use refresh
+// token that renews it. Exactly one of Customer, Staff or Client is set; API
+// clients get no refresh token.
+type IssuedTokens struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+	SessionID        string
+	Customer         *models.Customer
+	Staff            *models.StaffUser
+	Client           *models.APIClient
+	Scopes           []string // Granted to the client's access token
+}
+
+// ClientInfo identifies the device a session was opened from
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+type TokenService interface {
+	// IssueCustomerTokens opens a new session for a customer authenticated
+	// with the given methods, see models.AuthMethodPassword
+	IssueCustomerTokens(customer *models.Customer, methods []string, client ClientInfo) (*IssuedTokens, error)
+	// IssueStaffTokens opens a new session for an authenticated staff user
+	IssueStaffTokens(user *models.StaffUser, client ClientInfo) (*IssuedTokens, error)
+	// IssueClientToken opens a session for an authenticated API client and
+	// signs an access token limited to scopes. The session ends with the
+	// token; the client asks for a new one instead of refreshing.
+	IssueClientToken(apiClient *models.APIClient, scopes []string, client ClientInfo) (*IssuedTokens, error)
+	// Refresh exchanges a refresh token for a new token pair in the same
+	// session. Presenting an already-used refresh token revokes the session.
+	Refresh(refreshToken string) (*IssuedTokens, error)
+	// Logout revokes the session of the given access token
+	Logout(claims *utils.JWTClaims) error
+	// LogoutAll revokes every session of the access token's owner
+	LogoutAll(claims *utils.JWTClaims) (int64, error)
+	// StepUp records that the access token's session authenticated again
+	// with the given methods and issues it a new access token. The session's
+	// refresh token is unchanged; tokens it renews carry the stepped-up
+	// authentication too.
+	StepUp(claims *utils.JWTClaims, methods []string) (*IssuedTokens, error)
+}
+
+type tokenService struct {
+	sessionRepo     repository.SessionRepository
+	customerRepo    repository.CustomerRepository
+	staffRepo       repository.StaffRepository
+	txRunner        repository.TxRunner
+	keys            *utils.JWTKeySet
+	accessTTL       time.Duration
+	refreshTTL      time.Duration
+	sessionLifetime time.Duration
+}
+
+func NewTokenService(
+	sessionRepo repository.SessionRepository,
+	customerRepo repository.CustomerRepository,
+	staffRepo repository.StaffRepository,
+	txRunner repository.TxRunner,
+	keys *utils.JWTKeySet,
+	accessTTL, refreshTTL, sessionLifetime time.Duration,
+) TokenService {
+	return &tokenService{
+		sessionRepo:     sessionRepo,
+		customerRepo:    customerRepo,
+		staffRepo:       staffRepo,
+		txRunner:        txRunner,
+		keys:            keys,
+		accessTTL:       accessTTL,
+		refreshTTL:      refreshTTL,
+		sessionLifetime: sessionLifetime,
+	}
+}
+
+func (s *tokenService) IssueCustomerTokens(customer *models.Customer, methods []string, client ClientInfo) (*IssuedTokens, error) {
+	tokens := &IssuedTokens{Customer: customer}
+	if err := s.openSession(models.SubjectCustomer, customer.CustomerID, methods, client, tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (s *tokenService) IssueStaffTokens(user *models.StaffUser, client ClientInfo) (*IssuedTokens, error) {
+	tokens := &IssuedTokens{Staff: user}
+	methods := []string{models.AuthMethodPassword}
+	if err := s.openSession(models.SubjectStaff, user.StaffID, methods, client, tokens); err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+func (s *tokenService) IssueClientToken(apiClient *models.APIClient, scopes []string, client ClientInfo) (*IssuedTokens, error) {
+	now := time.Now().UTC()
+	session := &models.AuthSession{
+		SessionID:       generateSessionID(),
+		SubjectType:     models.SubjectAPIClient,
+		SubjectID:       apiClient.ClientID,
+		UserAgent:       client.UserAgent,
+		IPAddress:       client.IPAddress,
+		CreatedAt:       now,
+		LastUsedAt:      now,
+		ExpiresAt:       now.Add(s.accessTTL),
+		AuthenticatedAt: now,
+	}
+
+	tokens := &IssuedTokens{Client: apiClient, Scopes: scopes}
+	err := s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		if err := s.sessionRepo.WithTx(tx).CreateSession(session); err != nil {
+			return fmt.Errorf("failed to create session: %w", err)
+		}
+
+		return s.signAccessToken(session, tokens)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+func (s *tokenService) openSession(subjectType, subjectID string, methods []string, client ClientInfo, tokens *IssuedTokens) error {
+	now := time.Now().UTC()
+	session := &models.AuthSession{
+		SessionID:       generateSessionID(),
+		SubjectType:     subjectType,
+		SubjectID:       subjectID,
+		UserAgent:       client.UserAgent,
+		IPAddress:       client.IPAddress,
+		CreatedAt:       now,
+		LastUsedAt:      now,
+		ExpiresAt:       now.Add(s.sessionLifetime),
+		AuthMethods:     methods,
+		AuthenticatedAt: now,
+	}
+
+	return s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		sessionRepo := s.sessionRepo.WithTx(tx)
+
+		if err := sessionRepo.CreateSession(session); err != nil {
+			return fmt.Errorf("failed to create session: %w", err)
+		}
+
+		return s.issue(sessionRepo, session, tokens)
+	})
+}
+
+func (s *tokenService) Refresh(refreshToken string) (*IssuedTokens, error) {
+	if refreshToken == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	var tokens *IssuedTokens
+	reused := false
+
+	err := s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		sessionRepo := s.sessionRepo.WithTx(tx)
+
+		stored, err := sessionRepo.GetRefreshTokenForUpdate(hashRefreshToken(refreshToken))
+		if err != nil {
+			return ErrInvalidRefreshToken
+		}
+
+		session, err := sessionRepo.GetSessionForUpdate(stored.SessionID)
+		if err != nil {
+			return ErrInvalidRefreshToken
+		}
+
+		// A rotated token coming back means two parties hold the family:
+		// kill the whole session so the thief's copy dies with it
+		if stored.IsUsed() {
+			reused = true
+			return sessionRepo.RevokeSession(session.SessionID, models.RevokedTokenReuse)
+		}
+
+		if stored.IsExpired() || !session.IsValid() {
+			return ErrInvalidRefreshToken
+		}
+
+		if err := sessionRepo.MarkRefreshTokenUsed(stored.TokenHash); err != nil {
+			return ErrInvalidRefreshToken
+		}
+
+		tokens = &IssuedTokens{}
+		if err := s.loadSubject(session, tokens); err != nil {
+			return err
+		}
+
+		if err := sessionRepo.TouchSession(session.SessionID); err != nil {
+			return fmt.Errorf("failed to update session: %w", err)
+		}
+
+		return s.issue(sessionRepo, session, tokens)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if reused {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	return tokens, nil
+}
+
+// loadSubject reloads the session owner so a refresh never outlives a
+// suspended customer, a disabled staff user or a role change
+func (s *tokenService) loadSubject(session *models.AuthSession, tokens *IssuedTokens) error {
+	switch session.SubjectType {
+	case models.SubjectCustomer:
+		customer, err := s.customerRepo.GetByCustomerID(session.SubjectID)
+		if err != nil || !customer.IsActive() {
+			return fmt.Errorf("customer is not active")
+		}
+		tokens.Customer = customer
+	case models.SubjectStaff:
+		user, err := s.staffRepo.GetByStaffID(session.SubjectID)
+		if err != nil || !user.IsActive() {
+			return fmt.Errorf("staff user is not active")
+		}
+		tokens.Staff = user
+	default:
+		return ErrInvalidRefreshToken
+	}
+
+	return nil
+}
+
+func (s *tokenService) StepUp(claims *utils.JWTClaims, methods []string) (*IssuedTokens, error) {
+	var tokens *IssuedTokens
+
+	err := s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		sessionRepo := s.sessionRepo.WithTx(tx)
+
+		session, err := sessionRepo.GetSessionForUpdate(claims.SessionID)
+		if err != nil || !session.IsValid() {
+			return fmt.Errorf("session is no longer valid")
+		}
+
+		for _, method := range methods {
+			if !slices.Contains(session.AuthMethods, method) {
+				session.AuthMethods = append(session.AuthMethods, method)
+			}
+		}
+		session.AuthenticatedAt = time.Now().UTC()
+
+		if err := sessionRepo.UpdateAuthentication(session.SessionID, session.AuthMethods, session.AuthenticatedAt); err != nil {
+			return fmt.Errorf("failed to update session: %w", err)
+		}
+
+		tokens = &IssuedTokens{}
+		if err := s.loadSubject(session, tokens); err != nil {
+			return err
+		}
+
+		return s.signAccessToken(session, tokens)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tokens, nil
+}
+
+// signAccessToken signs a new access token for session
+func (s *tokenService) signAccessToken(session *models.AuthSession, tokens *IssuedTokens) error {
+	var accessToken string
+	var err error
+	if tokens.Client != nil {
+		accessToken, err = utils.GenerateClientJWT(tokens.Client, tokens.Scopes, session, s.keys, s.accessTTL)
+	} else if tokens.Staff != nil {
+		accessToken, err = utils.GenerateStaffJWT(tokens.Staff, session, s.keys, s.accessTTL)
+	} else {
+		accessToken, err = utils.GenerateJWT(tokens.Customer, session, s.keys, s.accessTTL)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	tokens.AccessToken = accessToken
+	tokens.AccessExpiresAt = time.Now().UTC().Add(s.accessTTL)
+	tokens.SessionID = session.SessionID
+	return nil
+}
+
+// issue signs a new access token and stores a new refresh token for session
+func (s *tokenService) issue(sessionRepo repository.SessionRepository, session *models.AuthSession, tokens *IssuedTokens) error {
+	if err := s.signAccessToken(session, tokens); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	refreshToken := generateRefreshToken()
+
+	// A refresh token never outlives its session
+	refreshExpiresAt := now.Add(s.refreshTTL)
+	if refreshExpiresAt.After(session.ExpiresAt) {
+		refreshExpiresAt = session.ExpiresAt
+	}
+
+	err := sessionRepo.CreateRefreshToken(&models.RefreshToken{
+		TokenHash: hashRefreshToken(refreshToken),
+		SessionID: session.SessionID,
+		CreatedAt: now,
+		ExpiresAt: refreshExpiresAt,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	tokens.RefreshToken = refreshToken
+	tokens.RefreshExpiresAt = refreshExpiresAt
+
+	return nil
+}
+
+func (s *tokenService) Logout(claims *utils.JWTClaims) error {
+	return s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		sessionRepo := s.sessionRepo.WithTx(tx)
+
+		if err := sessionRepo.RevokeSession(claims.SessionID, models.RevokedLogout); err != nil {
+			return fmt.Errorf("failed to revoke session: %w", err)
+		}
+
+		return s.revokeAccessToken(sessionRepo, claims)
+	})
+}
+
+func (s *tokenService) LogoutAll(claims *utils.JWTClaims) (int64, error) {
+	subjectType, subjectID := models.SubjectCustomer, claims.CustomerID
+	if models.IsStaffRole(claims.EffectiveRole()) {
+		subjectType, subjectID = models.SubjectStaff, claims.StaffID
+	} else if claims.EffectiveRole() == models.RoleAPIClient {
+		subjectType, subjectID = models.SubjectAPIClient, claims.ClientID
+	}
+
+	var revoked int64
+	err := s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		sessionRepo := s.sessionRepo.WithTx(tx)
+
+		var err error
+		revoked, err = sessionRepo.RevokeSubjectSessions(subjectType, subjectID, "", models.RevokedLogoutAll)
+		if err != nil {
+			return fmt.Errorf("failed to revoke sessions: %w", err)
+		}
+
+		return s.revokeAccessToken(sessionRepo, claims)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return revoked, nil
+}
+
+// revokeAccessToken lists the presented access token until it would have expired
+func (s *tokenService) revokeAccessToken(sessionRepo repository.SessionRepository, claims *utils.JWTClaims) error {
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return nil
+	}
+
+	if err := sessionRepo.RevokeAccessToken(claims.ID, claims.ExpiresAt.Time); err != nil {
+		return fmt.Errorf("failed to revoke token: %w", err)
+	}
+	return nil
+}
+
+func generateSessionID() string {
+	randomBytes := make([]byte, 16)
+	rand.Read(randomBytes)
+
+	return fmt.Sprintf("SES%x", randomBytes)
+}
+
+// generateRefreshToken returns an opaque 256-bit token; only its hash is stored
+func generateRefreshToken() string {
+	randomBytes := make([]byte, 32)
+	rand.Read(randomBytes)
+
+	return base64.RawURLEncoding.EncodeToString(randomBytes)
+}
+
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -2,9 +2,13 @@ package services
 
 import (
 	"crypto/rand"
+	"database/sql"
 	"fmt"
+	"math"
+	"strings"
 	"time"
 
+	"github.com/bank-api/internal/ledger"
 	"github.com/bank-api/internal/models"
 	"github.com/bank-api/internal/repository"
 )
@@ -13,21 +17,274 @@ type TransactionService interface {
 	Transfer(req *models.TransferRequest) (*models.Transaction, error)
 	Deposit(req *models.DepositRequest) (*models.Transaction, error)
 	Withdraw(req *models.WithdrawalRequest) (*models.Transaction, error)
+	Reverse(req *models.ReversalRequest) (*models.Transaction, error)
+	CancelTransaction(transactionID string) (*models.Transaction, error)
 	GetTransaction(transactionID string) (*models.Transaction, error)
 	GetTransactionHistory(req *models.TransactionHistoryRequest) ([]*models.Transaction, error)
-	ProcessPendingTransactions() error
+	// ProcessPendingTransactions processes up to batchSize pending
+	// transactions, each in its own database transaction. Failed attempts are
+	// retried as policy allows, then the transaction is moved to FAILED.
+	ProcessPendingTransactions(batchSize int, policy RetryPolicy) (PendingRunResult, error)
+	// PendingQueue reports the transactions waiting for processing
+	PendingQueue() (*models.PendingQueueStats, error)
+}
+
+// RetryPolicy bounds how often a failing pending transaction is retried
+type RetryPolicy struct {
+	MaxAttempts int           // Attempts before the transaction is moved to FAILED
+	Backoff     time.Duration // Delay after the first failed attempt, doubled after each further one
+}
+
+// maxRetryDelay caps the delay between two attempts
+const maxRetryDelay = 24 * time.Hour
+
+// Delay returns how long to wait after the given failed attempt
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	delay := p.Backoff
+	for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
+
+// PendingRunResult counts the outcomes of processing pending transactions
+type PendingRunResult struct {
+	Completed int
+	Retried   int // Failed attempts that will be retried
+	Failed    int // Transactions moved to FAILED after their last attempt
+}
+
+// Processed returns how many transactions were attempted
+func (r PendingRunResult) Processed() int {
+	return r.Completed + r.Retried + r.Failed
 }
 
 type transactionService struct {
 	transactionRepo repository.TransactionRepository
 	accountRepo     repository.AccountRepository
+	ledger          ledger.Ledger
+	txRunner        repository.TxRunner
+	fxService       FXService
+	fxQuoteRepo     repository.FXQuoteRepository
+	paymentRepo     repository.OutboundPaymentRepository
+	fees            feeEngine
+	limits          limitEngine
+	bank            models.Bank // Our bank; transfers to other BICs leave through clearing
 }
 
-func NewTransactionService(transactionRepo repository.TransactionRepository, accountRepo repository.AccountRepository) TransactionService {
+func NewTransactionService(transactionRepo repository.TransactionRepository, accountRepo repository.AccountRepository, ledger ledger.Ledger, txRunner repository.TxRunner, fxService FXService, fxQuoteRepo repository.FXQuoteRepository, paymentRepo repository.OutboundPaymentRepository, feeScheduleRepo repository.FeeScheduleRepository, limitRepo repository.LimitRepository, bank models.Bank) TransactionService {
 	return &transactionService{
 		transactionRepo: transactionRepo,
 		accountRepo:     accountRepo,
+		ledger:          ledger,
+		txRunner:        txRunner,
+		fxService:       fxService,
+		fxQuoteRepo:     fxQuoteRepo,
+		paymentRepo:     paymentRepo,
+		fees:            feeEngine{scheduleRepo: feeScheduleRepo, transactionRepo: transactionRepo},
+		limits:          limitEngine{limitRepo: limitRepo, transactionRepo: transactionRepo},
+		bank:            bank,
+	}
+}
+
+// resolveDestinationIBAN verifies the checksum of a transfer's destination IBAN
+// and fills in the account number it belongs to
+func (s *transactionService) resolveDestinationIBAN(req *models.TransferRequest) error {
+	iban := models.NormalizeIBAN(req.ToIBAN)
+	if err := models.ValidateIBAN(iban); err != nil {
+		return fmt.Errorf("invalid destination IBAN: %w", err)
+	}
+	
+	toAccount, err := s.accountRepo.GetByIBAN(iban)
+	if err != nil {
+		return fmt.Errorf("no account with IBAN %s", iban)
+	}
+	
+	if req.ToAccountNumber != "" && req.ToAccountNumber != toAccount.AccountNumber {
+		return fmt.Errorf("destination IBAN does not match destination account number")
 	}
+	
+	req.ToIBAN = iban
+	req.ToAccountNumber = toAccount.AccountNumber
+	return nil
+}
+
+// isExternalIBAN decides whether a transfer by IBAN goes to another bank,
+// filling in the BIC of Tunisian beneficiaries from their RIB's bank code.
+// IBANs of our own accounts are always internal, whatever bank code or BIC
+// they come with.
+func (s *transactionService) isExternalIBAN(req *models.TransferRequest) (bool, error) {
+	if _, err := s.accountRepo.GetByIBAN(models.NormalizeIBAN(req.ToIBAN)); err == nil {
+		return false, nil
+	}
+	
+	if req.ToBIC != "" {
+		if err := models.ValidateBIC(req.ToBIC); err != nil {
+			return false, fmt.Errorf("invalid destination BIC: %w", err)
+		}
+		req.ToBIC = strings.ToUpper(req.ToBIC)
+		return req.ToBIC[:8] != s.bank.BIC[:8], nil
+	}
+	
+	iban := models.NormalizeIBAN(req.ToIBAN)
+	if !strings.HasPrefix(iban, "TN") {
+		return false, fmt.Errorf("destination BIC is required for payments to foreign banks")
+	}
+	if len(iban) < 6 || iban[4:6] == s.bank.Code {
+		return false, nil
+	}
+	
+	bank, ok := models.LookupBankByCode(iban[4:6])
+	if !ok {
+		return false, fmt.Errorf("unknown bank code %s in destination IBAN", iban[4:6])
+	}
+	req.ToBIC = bank.BIC
+	return true, nil
+}
+
+// transferExternal debits the customer into clearing suspense and queues an
+// outbound payment for the interbank gateway. The transaction stays PENDING
+// until the gateway reports the payment settled or rejected.
+func (s *transactionService) transferExternal(req *models.TransferRequest) (*models.Transaction, error) {
+	iban := models.NormalizeIBAN(req.ToIBAN)
+	validate := models.ValidateIBAN
+	if strings.HasPrefix(iban, "TN") {
+		validate = models.ValidateTunisianIBAN
+	}
+	if err := validate(iban); err != nil {
+		return nil, fmt.Errorf("invalid destination IBAN: %w", err)
+	}
+	
+	if req.ToAccountNumber != "" {
+		return nil, fmt.Errorf("destination account number cannot be combined with another bank's IBAN")
+	}
+	if req.QuoteID != "" {
+		return nil, fmt.Errorf("transfers to other banks are sent in the source account's currency")
+	}
+	
+	beneficiary := strings.TrimSpace(req.BeneficiaryName)
+	if beneficiary == "" {
+		return nil, fmt.Errorf("beneficiary name is required for transfers to other banks")
+	}
+	if len(beneficiary) > 140 {
+		return nil, fmt.Errorf("beneficiary name must be at most 140 characters")
+	}
+	if len(req.Description) > 140 {
+		return nil, fmt.Errorf("description of a transfer to another bank must be at most 140 characters")
+	}
+	
+	fromAccount, err := s.accountRepo.GetByAccountNumber(req.FromAccountNumber)
+	if err != nil {
+		return nil, fmt.Errorf("source account not found")
+	}
+	
+	if !fromAccount.IsActive() {
+		return nil, fmt.Errorf("source account is not active")
+	}
+	
+	if req.Currency != fromAccount.Currency {
+		return nil, fmt.Errorf("transfer currency %s does not match source account currency %s", req.Currency, fromAccount.Currency)
+	}
+	
+	if err := s.limits.check(fromAccount, req.Amount, ""); err != nil {
+		return nil, err
+	}
+	
+	channel := transactionChannel(req.Channel)
+	quote, err := s.fees.assess(fromAccount, models.TransactionTypeExternal, channel, req.Amount, "")
+	if err != nil {
+		return nil, err
+	}
+	if !fromAccount.HasSufficientBalance(quote.Total) {
+		return nil, fmt.Errorf("insufficient balance including fees")
+	}
+	
+	now := time.Now().UTC()
+	transaction := &models.Transaction{
+		TransactionID:     transferTransactionID(req),
+		FromAccountID:     fromAccount.ID,
+		FromAccountNumber: fromAccount.AccountNumber,
+		Amount:            req.Amount,
+		Currency:          req.Currency,
+		ExchangeRate:      1.0,
+		ConvertedAmount:   req.Amount,
+		TransactionType:   models.TransactionTypeExternal,
+		Status:            models.TransactionStatusPending,
+		Description:       req.Description,
+		Reference:         req.Reference,
+		Fee:               quote.Fee,
+		Channel:           channel,
+		CounterpartyIBAN:  iban,
+		CounterpartyBIC:   req.ToBIC,
+		CounterpartyName:  beneficiary,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	
+	payment := &models.OutboundPayment{
+		PaymentID:           generatePaymentID(),
+		TransactionID:       transaction.TransactionID,
+		DebtorAccountNumber: fromAccount.AccountNumber,
+		DebtorIBAN:          fromAccount.IBAN,
+		DebtorBIC:           fromAccount.BIC,
+		CreditorIBAN:        iban,
+		CreditorBIC:         req.ToBIC,
+		CreditorName:        beneficiary,
+		Amount:              req.Amount,
+		Currency:            req.Currency,
+		RemittanceInfo:      req.Description,
+		Status:              models.OutboundPaymentStatusQueued,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+	
+	err = s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		if err := s.transactionRepo.WithTx(tx).Create(transaction); err != nil {
+			return fmt.Errorf("failed to create transaction: %w", err)
+		}
+		
+		locked, err := s.accountRepo.WithTx(tx).LockByIDs(fromAccount.ID)
+		if err != nil {
+			return err
+		}
+		account := locked[fromAccount.ID]
+		
+		if !account.IsActive() {
+			return fmt.Errorf("source account is not active")
+		}
+		if err := s.limits.withTx(tx).check(account, transaction.Amount, transaction.TransactionID); err != nil {
+			return err
+		}
+		if err := s.assessFee(tx, transaction, account); err != nil {
+			return err
+		}
+		if !account.HasSufficientBalance(transaction.Amount + transaction.Fee) {
+			return fmt.Errorf("insufficient balance including fees")
+		}
+		
+		entry := ledger.NewJournalEntry(transaction.TransactionID, "Transfer to "+iban).
+			DebitAccount(account.ID, account.Currency, transaction.Amount).
+			CreditGL(ledger.GLClearingSuspense, account.Currency, transaction.Amount)
+		
+		if err := s.ledger.WithTx(tx).Post(entry); err != nil {
+			return err
+		}
+		if err := s.bookFee(tx, transaction, account); err != nil {
+			return err
+		}
+		
+		return s.paymentRepo.WithTx(tx).Create(payment)
+	})
+	
+	if err != nil {
+		s.markFailed(transaction, err)
+		return nil, err
+	}
+	
+	return transaction, nil
 }
 
 func (s *transactionService) Transfer(req *models.TransferRequest) (*models.Transaction, error) {
@@ -36,6 +293,24 @@ func (s *transactionService) Transfer(req *models.TransferRequest) (*models.Tran
 		return nil, fmt.Errorf("transfer amount must be positive")
 	}
 	
+	if req.ToIBAN != "" {
+		external, err := s.isExternalIBAN(req)
+		if err != nil {
+			return nil, err
+		}
+		if external {
+			return s.transferExternal(req)
+		}
+		
+		if err := s.resolveDestinationIBAN(req); err != nil {
+			return nil, err
+		}
+	}
+	
+	if req.ToAccountNumber == "" {
+		return nil, fmt.Errorf("destination account number or IBAN is required")
+	}
+	
 	if req.FromAccountNumber == req.ToAccountNumber {
 		return nil, fmt.Errorf("cannot transfer to the same account")
 	}
@@ -50,6 +325,10 @@ func (s *transactionService) Transfer(req *models.TransferRequest) (*models.Tran
 		return nil, fmt.Errorf("source account is not active")
 	}
 	
+	if req.Currency != fromAccount.Currency {
+		return nil, fmt.Errorf("transfer currency %s does not match source account currency %s", req.Currency, fromAccount.Currency)
+	}
+	
 	// Get destination account
 	toAccount, err := s.accountRepo.GetByAccountNumber(req.ToAccountNumber)
 	if err != nil {
@@ -60,35 +339,65 @@ func (s *transactionService) Transfer(req *models.TransferRequest) (*models.Tran
 		return nil, fmt.Errorf("destination account is not active")
 	}
 	
+	if err := s.limits.check(fromAccount, req.Amount, ""); err != nil {
+		return nil, err
+	}
+	
 	// Check sufficient balance
 	if !fromAccount.HasSufficientBalance(req.Amount) {
 		return nil, fmt.Errorf("insufficient balance")
 	}
 	
-	// Calculate fee (simplified - 0.1% of transfer amount, minimum $1)
-	fee := s.calculateTransferFee(req.Amount)
-	totalAmount := req.Amount + fee
+	// Preview the fee from the schedules; it is charged under the account's
+	// lock when the transfer is processed
+	channel := transactionChannel(req.Channel)
+	quote, err := s.fees.assess(fromAccount, models.TransactionTypeTransfer, channel, req.Amount, "")
+	if err != nil {
+		return nil, err
+	}
 	
-	if !fromAccount.HasSufficientBalance(totalAmount) {
+	if !fromAccount.HasSufficientBalance(quote.Total) {
 		return nil, fmt.Errorf("insufficient balance including fees")
 	}
 	
+	// Convert into the destination account's currency at a locked quote or,
+	// without one, at a snapshot of the live rate
+	exchangeRate := 1.0
+	convertedAmount := req.Amount
+	var quoteID string
+	if fromAccount.Currency != toAccount.Currency {
+		quote, err := s.resolveQuote(req, toAccount.Currency)
+		if err != nil {
+			return nil, err
+		}
+		
+		if convertedAmount, err = convertAmount(req.Amount, quote.FromCurrency, quote.ToCurrency, quote.Rate); err != nil {
+			return nil, err
+		}
+		exchangeRate = quote.Rate
+		quoteID = quote.QuoteID
+	} else if req.QuoteID != "" {
+		return nil, fmt.Errorf("FX quote is only applicable to cross-currency transfers")
+	}
+	
 	// Create transaction
 	transaction := &models.Transaction{
-		TransactionID:     s.generateTransactionID(),
+		TransactionID:     transferTransactionID(req),
 		FromAccountID:     fromAccount.ID,
 		ToAccountID:       toAccount.ID,
 		FromAccountNumber: req.FromAccountNumber,
 		ToAccountNumber:   req.ToAccountNumber,
 		Amount:            req.Amount,
 		Currency:          req.Currency,
-		ExchangeRate:      1.0, // Simplified - same currency
-		ConvertedAmount:   req.Amount,
+		ExchangeRate:      exchangeRate,
+		ConvertedAmount:   convertedAmount,
 		TransactionType:   models.TransactionTypeTransfer,
 		Status:            models.TransactionStatusPending,
 		Description:       req.Description,
 		Reference:         req.Reference,
-		Fee:               fee,
+		Fee:               quote.Fee,
+		Channel:           channel,
+		FXQuoteID:         quoteID,
 		CreatedAt:         time.Now().UTC(),
 		UpdatedAt:         time.Now().UTC(),
 	}
@@ -98,15 +407,8 @@ func (s *transactionService) Transfer(req *models.TransferRequest) (*models.Tran
 		return nil, err
 	}
 	
-	// Save transaction
-	if err := s.transactionRepo.Create(transaction); err != nil {
-		return nil, fmt.Errorf("failed to create transaction: %w", err)
-	}
-	
-	// Process transaction immediately (in real system, this might be async)
-	if err := s.processTransfer(transaction); err != nil {
-		// Update transaction status to failed
-		s.transactionRepo.UpdateStatus(transaction.TransactionID, models.TransactionStatusFailed)
+	// Save and process the transaction in a single database transaction
+	if err := s.execute(transaction, s.processTransfer); err != nil {
 		return nil, err
 	}
 	
@@ -129,9 +431,13 @@ func (s *transactionService) Deposit(req *models.DepositRequest) (*models.Transa
 		return nil, fmt.Errorf("account is not active")
 	}
 	
+	if req.Currency != account.Currency {
+		return nil, fmt.Errorf("deposit currency %s does not match account currency %s", req.Currency, account.Currency)
+	}
+	
 	// Create transaction
 	transaction := &models.Transaction{
-		TransactionID:     s.generateTransactionID(),
+		TransactionID:     generateTransactionID(),
 		ToAccountID:       account.ID,
 		ToAccountNumber:   req.AccountNumber,
 		Amount:            req.Amount,
@@ -147,14 +453,8 @@ func (s *transactionService) Deposit(req *models.DepositRequest) (*models.Transa
 		UpdatedAt:         time.Now().UTC(),
 	}
 	
-	// Save transaction
-	if err := s.transactionRepo.Create(transaction); err != nil {
-		return nil, fmt.Errorf("failed to create transaction: %w", err)
-	}
-	
-	// Process deposit
-	if err := s.processDeposit(transaction); err != nil {
-		s.transactionRepo.UpdateStatus(transaction.TransactionID, models.TransactionStatusFailed)
+	// Save and process deposit
+	if err := s.execute(transaction, s.processDeposit); err != nil {
 		return nil, err
 	}
 	
@@ -177,18 +477,30 @@ func (s *transactionService) Withdraw(req *models.WithdrawalRequest) (*models.Tr
 		return nil, fmt.Errorf("account is not active")
 	}
 	
-	// Calculate fee (simplified - $2 per withdrawal)
-	fee := s.calculateWithdrawalFee()
-	totalAmount := req.Amount + fee
+	if req.Currency != account.Currency {
+		return nil, fmt.Errorf("withdrawal currency %s does not match account currency %s", req.Currency, account.Currency)
+	}
+	
+	if err := s.limits.check(account, req.Amount, ""); err != nil {
+		return nil, err
+	}
+	
+	// Preview the fee from the schedules; it is charged under the account's
+	// lock when the withdrawal is processed
+	channel := transactionChannel(req.Channel)
+	quote, err := s.fees.assess(account, models.TransactionTypeWithdrawal, channel, req.Amount, "")
+	if err != nil {
+		return nil, err
+	}
 	
 	// Check sufficient balance
-	if !account.HasSufficientBalance(totalAmount) {
+	if !account.HasSufficientBalance(quote.Total) {
 		return nil, fmt.Errorf("insufficient balance")
 	}
 	
 	// Create transaction
 	transaction := &models.Transaction{
-		TransactionID:     s.generateTransactionID(),
+		TransactionID:     generateTransactionID(),
 		FromAccountID:     account.ID,
 		FromAccountNumber: req.AccountNumber,
 		Amount:            req.Amount,
@@ -199,19 +511,120 @@ func (s *transactionService) Withdraw(req *models.WithdrawalRequest) (*models.Tr
 		Status:            models.TransactionStatusPending,
 		Description:       req.Description,
 		Reference:         req.Reference,
-		Fee:               fee,
+		Fee:               quote.Fee,
+		Channel:           channel,
 		CreatedAt:         time.Now().UTC(),
 		UpdatedAt:         time.Now().UTC(),
 	}
 	
-	// Save transaction
-	if err := s.transactionRepo.Create(transaction); err != nil {
-		return nil, fmt.Errorf("failed to create transaction: %w", err)
+	// Save and process withdrawal
+	if err := s.execute(transaction, s.processWithdrawal); err != nil {
+		return nil, err
 	}
 	
-	// Process withdrawal
-	if err := s.processWithdrawal(transaction); err != nil {
-		s.transactionRepo.UpdateStatus(transaction.TransactionID, models.TransactionStatusFailed)
+	return transaction, nil
+}
+
+func (s *transactionService) Reverse(req *models.ReversalRequest) (*models.Transaction, error) {
+	if req.Amount < 0 {
+		return nil, fmt.Errorf("reversal amount must be positive")
+	}
+	
+	original, err := s.transactionRepo.GetByTransactionID(req.TransactionID)
+	if err != nil {
+		return nil, fmt.Errorf("transaction not found")
+	}
+	
+	if !original.CanBeReversed() {
+		return nil, fmt.Errorf("only completed transfers can be reversed")
+	}
+	
+	// Default to refunding whatever has not been reversed yet; the amount is
+	// checked again under lock when the reversal is processed
+	amount := req.Amount
+	if amount == 0 {
+		reversed, _, err := s.transactionRepo.GetReversedTotals(original.TransactionID)
+		if err != nil {
+			return nil, err
+		}
+		amount = original.Amount - reversed
+		if amount <= 0 {
+			return nil, fmt.Errorf("transaction has already been fully reversed")
+		}
+	}
+	
+	description := "Reversal of " + original.TransactionID
+	if req.Reason != "" {
+		description += ": " + req.Reason
+	}
+	
+	var feeRefund int64
+	if req.RefundFee {
+		feeRefund = original.Fee
+	}
+	
+	// Amount stays in the original transfer's currency so reversals of it can
+	// be summed; the original recipient is debited the proportional share of
+	// what they were credited, at the original rate
+	convertedAmount := amount
+	if original.FXQuoteID != "" {
+		convertedAmount = int64(math.Round(float64(amount) * float64(original.ConvertedAmount) / float64(original.Amount)))
+	}
+	
+	// Money flows back from the original recipient to the original sender
+	reversal := &models.Transaction{
+		TransactionID:         generateTransactionID(),
+		FromAccountID:         original.ToAccountID,
+		ToAccountID:           original.FromAccountID,
+		FromAccountNumber:     original.ToAccountNumber,
+		ToAccountNumber:       original.FromAccountNumber,
+		Amount:                amount,
+		Currency:              original.Currency,
+		ExchangeRate:          original.ExchangeRate,
+		ConvertedAmount:       convertedAmount,
+		TransactionType:       models.TransactionTypeReversal,
+		Status:                models.TransactionStatusPending,
+		Description:           description,
+		Reference:             original.Reference,
+		OriginalTransactionID: original.TransactionID,
+		FeeRefund:             feeRefund,
+		CreatedAt:             time.Now().UTC(),
+		UpdatedAt:             time.Now().UTC(),
+	}
+	
+	if err := s.execute(reversal, s.processReversal); err != nil {
+		return nil, err
+	}
+	
+	return reversal, nil
+}
+
+func (s *transactionService) CancelTransaction(transactionID string) (*models.Transaction, error) {
+	var transaction *models.Transaction
+	
+	err := s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		transactions := s.transactionRepo.WithTx(tx)
+		
+		current, err := transactions.GetByTransactionIDForUpdate(transactionID)
+		if err != nil {
+			return fmt.Errorf("transaction not found")
+		}
+		
+		if !current.CanBeCancelled() {
+			return fmt.Errorf("only pending transactions can be cancelled")
+		}
+		
+		if err := transactions.UpdateStatus(transactionID, models.TransactionStatusCancelled); err != nil {
+			return err
+		}
+		
+		current.Status = models.TransactionStatusCancelled
+		current.UpdatedAt = time.Now().UTC()
+		transaction = current
+		return nil
+	})
+	
+	if err != nil {
 		return nil, err
 	}
 	
@@ -246,113 +659,475 @@ func (s *transactionService) GetTransactionHistory(req *models.TransactionHistor
 	return s.transactionRepo.GetByAccountNumber(req.AccountNumber, req.Limit, req.Offset)
 }
 
-func (s *transactionService) ProcessPendingTransactions() error {
-	transactions, err := s.transactionRepo.GetPendingTransactions()
+func (s *transactionService) ProcessPendingTransactions(batchSize int, policy RetryPolicy) (PendingRunResult, error) {
+	var result PendingRunResult
+	for result.Processed() < batchSize {
+		outcome, err := s.processNextPending(policy)
+		if err != nil {
+			return result, err
+		}
+		
+		switch outcome {
+		case pendingNone:
+			return result, nil
+		case pendingCompleted:
+			result.Completed++
+		case pendingRetried:
+			result.Retried++
+		case pendingFailed:
+			result.Failed++
+		}
+	}
+	
+	return result, nil
+}
+
+func (s *transactionService) PendingQueue() (*models.PendingQueueStats, error) {
+	depth, oldest, err := s.transactionRepo.PendingQueue()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	
+	stats := &models.PendingQueueStats{Depth: depth}
+	if oldest != nil {
+		stats.Lag = time.Since(*oldest)
 	}
+	return stats, nil
+}
+
+// Outcomes of processNextPending
+const (
+	pendingNone = iota
+	pendingCompleted
+	pendingRetried
+	pendingFailed
+)
+
+// processNextPending claims the oldest due pending transaction and processes
+// it. The claim's row lock is held throughout, so no other worker or replica
+// processes the transaction concurrently; a failed attempt is rolled back to a
+// savepoint and recorded under the same lock.
+func (s *transactionService) processNextPending(policy RetryPolicy) (int, error) {
+	outcome := pendingNone
 	
-	for _, transaction := range transactions {
-		switch transaction.TransactionType {
-		case models.TransactionTypeTransfer:
-			s.processTransfer(transaction)
-		case models.TransactionTypeDeposit:
-			s.processDeposit(transaction)
-		case models.TransactionTypeWithdrawal:
-			s.processWithdrawal(transaction)
+	err := s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		transactions := s.transactionRepo.WithTx(tx)
+		
+		now := time.Now().UTC()
+		pending, err := transactions.LockPending(now, 1)
+		if err != nil {
+			return err
+		}
+		if len(pending) == 0 {
+			return nil
+		}
+		transaction := pending[0]
+		
+		cause := repository.RunInSavepoint(tx, func() error {
+			process, ok := s.pendingProcessor(transaction.TransactionType)
+			if !ok {
+				return fmt.Errorf("%s transactions cannot be processed", transaction.TransactionType)
+			}
+			if err := process(tx, transaction); err != nil {
+				return err
+			}
+			return transactions.UpdateStatus(transaction.TransactionID, models.TransactionStatusCompleted)
+		})
+		if cause == nil {
+			outcome = pendingCompleted
+			return nil
 		}
+		
+		attempt := transaction.Attempts + 1
+		if err := transactions.RecordAttempt(transaction.TransactionID, cause.Error(), now.Add(policy.Delay(attempt))); err != nil {
+			return err
+		}
+		if attempt < policy.MaxAttempts {
+			outcome = pendingRetried
+			return nil
+		}
+		
+		outcome = pendingFailed
+		return transactions.MarkFailed(transaction.TransactionID, cause.Error())
+	})
+	
+	if err != nil {
+		return pendingNone, err
 	}
 	
-	return nil
+	return outcome, nil
+}
+
+// pendingProcessor returns the processing step of a transaction type
+func (s *transactionService) pendingProcessor(transactionType string) (func(tx *sql.Tx, transaction *models.Transaction) error, bool) {
+	switch transactionType {
+	case models.TransactionTypeTransfer:
+		return s.processTransfer, true
+	case models.TransactionTypeDeposit:
+		return s.processDeposit, true
+	case models.TransactionTypeWithdrawal:
+		return s.processWithdrawal, true
+	case models.TransactionTypeReversal:
+		return s.processReversal, true
+	default:
+		return nil, false
+	}
 }
 
 // Helper methods
 
-func (s *transactionService) processTransfer(transaction *models.Transaction) error {
-	// Get accounts
-	fromAccount, err := s.accountRepo.GetByID(transaction.FromAccountID)
+// execute runs process for a new transaction inside a single database
+// transaction: the transaction is inserted, balances are moved and the
+// transaction is marked COMPLETED atomically. If anything fails the whole unit
+// of work is rolled back and the transaction is recorded as FAILED.
+func (s *transactionService) execute(transaction *models.Transaction, process func(tx *sql.Tx, transaction *models.Transaction) error) error {
+	err := s.txRunner.RunInTx(func(tx *sql.Tx) error {
+		transactions := s.transactionRepo.WithTx(tx)
+		
+		if err := transactions.Create(transaction); err != nil {
+			return fmt.Errorf("failed to create transaction: %w", err)
+		}
+		
+		if err := process(tx, transaction); err != nil {
+			return err
+		}
+		
+		return transactions.UpdateStatus(transaction.TransactionID, models.TransactionStatusCompleted)
+	})
+	
 	if err != nil {
+		s.markFailed(transaction, err)
 		return err
 	}
 	
-	toAccount, err := s.accountRepo.GetByID(transaction.ToAccountID)
+	now := time.Now().UTC()
+	transaction.Status = models.TransactionStatusCompleted
+	transaction.ProcessedAt = &now
+	transaction.UpdatedAt = now
+	
+	return nil
+}
+
+// markFailed records a new transaction whose unit of work was rolled back
+func (s *transactionService) markFailed(transaction *models.Transaction, cause error) {
+	transaction.Status = models.TransactionStatusFailed
+	transaction.FailureReason = cause.Error()
+	transaction.UpdatedAt = time.Now().UTC()
+	
+	// The row inserted inside the rolled back unit of work no longer exists
+	transaction.ID = 0
+	s.transactionRepo.Create(transaction)
+}
+
+func (s *transactionService) processTransfer(tx *sql.Tx, transaction *models.Transaction) error {
+	accounts := s.accountRepo.WithTx(tx)
+	
+	// Lock both accounts (in id order) before reading their balances
+	locked, err := accounts.LockByIDs(transaction.FromAccountID, transaction.ToAccountID)
 	if err != nil {
 		return err
 	}
+	fromAccount := locked[transaction.FromAccountID]
+	toAccount := locked[transaction.ToAccountID]
 	
-	// Update balances
-	totalAmount := transaction.Amount + transaction.Fee
-	newFromBalance := fromAccount.Balance - totalAmount
-	newToBalance := toAccount.Balance + transaction.Amount
+	if !fromAccount.IsActive() {
+		return fmt.Errorf("source account is not active")
+	}
+	if !toAccount.IsActive() {
+		return fmt.Errorf("destination account is not active")
+	}
 	
-	// Update from account balance
-	if err := s.accountRepo.UpdateBalance(fromAccount.AccountNumber, newFromBalance); err != nil {
+	// Limits are checked again under the lock so that concurrent transfers
+	// cannot each pass on the same headroom
+	if err := s.limits.withTx(tx).check(fromAccount, transaction.Amount, transaction.TransactionID); err != nil {
 		return err
 	}
+	if err := s.assessFee(tx, transaction, fromAccount); err != nil {
+		return err
+	}
+	if !fromAccount.HasSufficientBalance(transaction.Amount + transaction.Fee) {
+		return fmt.Errorf("insufficient balance including fees")
+	}
+	
+	if transaction.FXQuoteID != "" {
+		if err := s.consumeQuote(tx, transaction, fromAccount.Currency, toAccount.Currency); err != nil {
+			return err
+		}
+	} else if fromAccount.Currency != toAccount.Currency {
+		return fmt.Errorf("cross-currency transfer has no FX rate")
+	}
 	
-	// Update to account balance
-	if err := s.accountRepo.UpdateBalance(toAccount.AccountNumber, newToBalance); err != nil {
-		// Rollback from account
-		s.accountRepo.UpdateBalance(fromAccount.AccountNumber, fromAccount.Balance)
+	entry := ledger.NewJournalEntry(transaction.TransactionID, "Transfer").
+		DebitAccount(fromAccount.ID, fromAccount.Currency, transaction.Amount).
+		CreditAccount(toAccount.ID, toAccount.Currency, transaction.ConvertedAmount)
+	
+	// A conversion balances each currency against the bank's FX position:
+	// the bank buys the source amount and sells the converted amount
+	if fromAccount.Currency != toAccount.Currency {
+		entry.CreditGL(ledger.GLFXPosition, fromAccount.Currency, transaction.Amount).
+			DebitGL(ledger.GLFXPosition, toAccount.Currency, transaction.ConvertedAmount)
+	}
+	
+	if err := s.ledger.WithTx(tx).Post(entry); err != nil {
 		return err
 	}
 	
-	// Update transaction status
-	return s.transactionRepo.UpdateStatus(transaction.TransactionID, models.TransactionStatusCompleted)
+	return s.bookFee(tx, transaction, fromAccount)
 }
 
-func (s *transactionService) processDeposit(transaction *models.Transaction) error {
-	// Get account
-	account, err := s.accountRepo.GetByID(transaction.ToAccountID)
+func (s *transactionService) processDeposit(tx *sql.Tx, transaction *models.Transaction) error {
+	accounts := s.accountRepo.WithTx(tx)
+	
+	locked, err := accounts.LockByIDs(transaction.ToAccountID)
+	if err != nil {
+		return err
+	}
+	
+	account := locked[transaction.ToAccountID]
+	
+	if !account.IsActive() {
+		return fmt.Errorf("account is not active")
+	}
+	
+	entry := ledger.NewJournalEntry(transaction.TransactionID, "Deposit").
+		DebitGL(ledger.GLCash, account.Currency, transaction.Amount).
+		CreditAccount(account.ID, account.Currency, transaction.Amount)
+	
+	return s.ledger.WithTx(tx).Post(entry)
+}
+
+func (s *transactionService) processWithdrawal(tx *sql.Tx, transaction *models.Transaction) error {
+	accounts := s.accountRepo.WithTx(tx)
+	
+	locked, err := accounts.LockByIDs(transaction.FromAccountID)
+	if err != nil {
+		return err
+	}
+	account := locked[transaction.FromAccountID]
+	
+	if !account.IsActive() {
+		return fmt.Errorf("account is not active")
+	}
+	
+	if err := s.limits.withTx(tx).check(account, transaction.Amount, transaction.TransactionID); err != nil {
+		return err
+	}
+	if err := s.assessFee(tx, transaction, account); err != nil {
+		return err
+	}
+	if !account.HasSufficientBalance(transaction.Amount + transaction.Fee) {
+		return fmt.Errorf("insufficient balance")
+	}
+	
+	entry := ledger.NewJournalEntry(transaction.TransactionID, "Withdrawal").
+		DebitAccount(account.ID, account.Currency, transaction.Amount).
+		CreditGL(ledger.GLCash, account.Currency, transaction.Amount)
+	
+	if err := s.ledger.WithTx(tx).Post(entry); err != nil {
+		return err
+	}
+	
+	return s.bookFee(tx, transaction, account)
+}
+
+func (s *transactionService) processReversal(tx *sql.Tx, reversal *models.Transaction) error {
+	transactions := s.transactionRepo.WithTx(tx)
+	
+	// Lock the original so concurrent reversals of it are serialized
+	original, err := transactions.GetByTransactionIDForUpdate(reversal.OriginalTransactionID)
+	if err != nil {
+		return err
+	}
+	
+	if !original.CanBeReversed() {
+		return fmt.Errorf("only completed transfers can be reversed")
+	}
+	
+	reversedAmount, refundedFee, err := transactions.GetReversedTotals(original.TransactionID)
 	if err != nil {
 		return err
 	}
 	
-	// Update balance
-	newBalance := account.Balance + transaction.Amount
+	if reversedAmount >= original.Amount {
+		return fmt.Errorf("transaction has already been fully reversed")
+	}
+	if reversal.Amount > original.Amount-reversedAmount {
+		return fmt.Errorf("reversal amount exceeds the remaining reversible amount of %d", original.Amount-reversedAmount)
+	}
+	if reversal.FeeRefund > 0 && refundedFee > 0 {
+		return fmt.Errorf("fee has already been refunded")
+	}
 	
-	if err := s.accountRepo.UpdateBalance(account.AccountNumber, newBalance); err != nil {
+	accounts := s.accountRepo.WithTx(tx)
+	locked, err := accounts.LockByIDs(reversal.FromAccountID, reversal.ToAccountID)
+	if err != nil {
 		return err
 	}
+	payee := locked[reversal.FromAccountID]
+	payer := locked[reversal.ToAccountID]
 	
-	// Update transaction status
-	return s.transactionRepo.UpdateStatus(transaction.TransactionID, models.TransactionStatusCompleted)
+	// A suspended or closed account can be neither debited nor credited
+	if !payee.IsActive() {
+		return fmt.Errorf("payee account is not active")
+	}
+	if !payer.IsActive() {
+		return fmt.Errorf("payer account is not active")
+	}
+	
+	if !payee.HasSufficientBalance(reversal.ConvertedAmount) {
+		return fmt.Errorf("insufficient balance to reverse the transaction")
+	}
+	
+	// The fee refund is paid back out of the bank's fee income
+	entry := ledger.NewJournalEntry(reversal.TransactionID, "Reversal of "+original.TransactionID).
+		DebitAccount(payee.ID, payee.Currency, reversal.ConvertedAmount).
+		CreditAccount(payer.ID, payer.Currency, reversal.Amount).
+		DebitGL(ledger.GLFeeIncome, payer.Currency, reversal.FeeRefund).
+		CreditAccount(payer.ID, payer.Currency, reversal.FeeRefund)
+	
+	// Unwind a conversion through the FX position at the original rate
+	if payee.Currency != payer.Currency {
+		entry.CreditGL(ledger.GLFXPosition, payee.Currency, reversal.ConvertedAmount).
+			DebitGL(ledger.GLFXPosition, payer.Currency, reversal.Amount)
+	}
+	
+	return s.ledger.WithTx(tx).Post(entry)
 }
 
-func (s *transactionService) processWithdrawal(transaction *models.Transaction) error {
-	// Get account
-	account, err := s.accountRepo.GetByID(transaction.FromAccountID)
+// resolveQuote returns the quote a cross-currency transfer converts at: the
+// locked quote referenced by the request or a fresh snapshot of the live rate
+func (s *transactionService) resolveQuote(req *models.TransferRequest, toCurrency string) (*models.FXQuote, error) {
+	if req.QuoteID == "" {
+		return s.fxService.Quote(req.FromAccountNumber, req.Currency, toCurrency, req.Amount)
+	}
+	
+	quote, err := s.fxService.GetQuote(req.QuoteID)
+	if err != nil {
+		return nil, fmt.Errorf("FX quote not found")
+	}
+	
+	if err := checkQuote(quote, req.FromAccountNumber, req.Currency, toCurrency); err != nil {
+		return nil, err
+	}
+	
+	if quote.Amount > 0 && quote.Amount != req.Amount {
+		return nil, fmt.Errorf("transfer amount does not match the quoted amount of %d", quote.Amount)
+	}
+	
+	return quote, nil
+}
+
+// consumeQuote locks the transfer's quote and marks it used so that a locked
+// rate is applied to at most one transfer
+func (s *transactionService) consumeQuote(tx *sql.Tx, transaction *models.Transaction, fromCurrency, toCurrency string) error {
+	quotes := s.fxQuoteRepo.WithTx(tx)
+	
+	quote, err := quotes.GetByQuoteIDForUpdate(transaction.FXQuoteID)
 	if err != nil {
 		return err
 	}
 	
-	// Update balance
-	totalAmount := transaction.Amount + transaction.Fee
-	newBalance := account.Balance - totalAmount
+	if err := checkQuote(quote, transaction.FromAccountNumber, fromCurrency, toCurrency); err != nil {
+		return err
+	}
 	
-	if err := s.accountRepo.UpdateBalance(account.AccountNumber, newBalance); err != nil {
+	return quotes.MarkUsed(quote.QuoteID, transaction.TransactionID)
+}
+
+func checkQuote(quote *models.FXQuote, accountNumber, fromCurrency, toCurrency string) error {
+	if quote.AccountNumber != accountNumber {
+		return fmt.Errorf("FX quote was issued for another account")
+	}
+	if quote.FromCurrency != fromCurrency || quote.ToCurrency != toCurrency {
+		return fmt.Errorf("FX quote is for %s/%s, not %s/%s", quote.FromCurrency, quote.ToCurrency, fromCurrency, toCurrency)
+	}
+	if quote.IsUsed() {
+		return fmt.Errorf("FX quote has already been used")
+	}
+	if quote.IsExpired() {
+		return fmt.Errorf("FX quote has expired")
+	}
+	return nil
+}
+
+// assessFee prices a transaction from the fee schedules under its source
+// account's lock, so that concurrent transactions cannot both use the last
+// free one of the month, and records the fee on it
+func (s *transactionService) assessFee(tx *sql.Tx, transaction *models.Transaction, account *models.Account) error {
+	quote, err := s.fees.withTx(tx).assess(account, transaction.TransactionType, transaction.Channel, transaction.Amount, transaction.TransactionID)
+	if err != nil {
 		return err
 	}
 	
-	// Update transaction status
-	return s.transactionRepo.UpdateStatus(transaction.TransactionID, models.TransactionStatusCompleted)
+	transaction.Fee = quote.Fee
+	return s.transactionRepo.WithTx(tx).UpdateFee(transaction.TransactionID, quote.Fee)
+}
+
+// bookFee books the fee charged on a transaction as a FEE transaction of its
+// own, debited from account into the bank's fee income
+func (s *transactionService) bookFee(tx *sql.Tx, transaction *models.Transaction, account *models.Account) error {
+	if transaction.Fee == 0 {
+		return nil
+	}
+	
+	now := time.Now().UTC()
+	fee := &models.Transaction{
+		TransactionID:       generateTransactionID(),
+		FromAccountID:       account.ID,
+		FromAccountNumber:   account.AccountNumber,
+		Amount:              transaction.Fee,
+		Currency:            account.Currency,
+		ExchangeRate:        1.0,
+		ConvertedAmount:     transaction.Fee,
+		TransactionType:     models.TransactionTypeFee,
+		Status:              models.TransactionStatusCompleted,
+		Description:         "Fee for " + transaction.TransactionID,
+		Reference:           transaction.Reference,
+		Channel:             transaction.Channel,
+		ParentTransactionID: transaction.TransactionID,
+		ProcessedAt:         &now,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+	}
+	
+	if err := s.transactionRepo.WithTx(tx).Create(fee); err != nil {
+		return fmt.Errorf("failed to create fee transaction: %w", err)
+	}
+	
+	entry := ledger.NewJournalEntry(fee.TransactionID, fee.Description).
+		DebitAccount(account.ID, account.Currency, fee.Amount).
+		CreditGL(ledger.GLFeeIncome, account.Currency, fee.Amount)
+	
+	return s.ledger.WithTx(tx).Post(entry)
 }
 
-func (s *transactionService) calculateTransferFee(amount int64) int64 {
-	// 0.1% of transfer amount, minimum 100 cents ($1)
-	fee := amount / 1000
-	if fee < 100 {
-		fee = 100
+// transactionChannel returns the channel of a request, ONLINE unless the
+// caller set one
+func transactionChannel(channel string) string {
+	if channel == "" {
+		return models.ChannelOnline
 	}
-	return fee
+	return channel
 }
 
-func (s *transactionService) calculateWithdrawalFee() int64 {
-	// Fixed $2 fee for withdrawals
-	return 200
+func generatePaymentID() string {
+	timestamp := time.Now().Unix()
+	randomBytes := make([]byte, 8)
+	rand.Read(randomBytes)
+	
+	return fmt.Sprintf("PAY%d%x", timestamp, randomBytes)
+}
+
+// transferTransactionID returns the transaction ID preassigned to a transfer,
+// or a new one
+func transferTransactionID(req *models.TransferRequest) string {
+	if req.TransactionID != "" {
+		return req.TransactionID
+	}
+	return generateTransactionID()
 }
 
-func (s *transactionService) generateTransactionID() string {
+func generateTransactionID() string {
 	timestamp := time.Now().Unix()
 	randomBytes := make([]byte, 8)
 	rand.Read(randomBytes)
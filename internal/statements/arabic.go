@@ -0,0 +1,213 @@
+package statements
+
+import "unicode"
+
+// arabicLetters lists the Arabic letters in the order of their presentation
+// forms (Unicode Arabic Presentation Forms-B, from U+FE80) with how many
+// forms each has: 4 for letters joining on both sides (isolated, final,
+// initial, medial), 2 for letters joining to the preceding letter only
+// (isolated, final) and 1 for the non-joining hamza.
+var arabicLetters = []struct {
+	letter rune
+	forms  int
+}{
+	{0x0621, 1}, {0x0622, 2}, {0x0623, 2}, {0x0624, 2}, {0x0625, 2}, {0x0626, 4},
+	{0x0627, 2}, {0x0628, 4}, {0x0629, 2}, {0x062A, 4}, {0x062B, 4}, {0x062C, 4},
+	{0x062D, 4}, {0x062E, 4}, {0x062F, 2}, {0x0630, 2}, {0x0631, 2}, {0x0632, 2},
+	{0x0633, 4}, {0x0634, 4}, {0x0635, 4}, {0x0636, 4}, {0x0637, 4}, {0x0638, 4},
+	{0x0639, 4}, {0x063A, 4}, {0x0641, 4}, {0x0642, 4}, {0x0643, 4}, {0x0644, 4},
+	{0x0645, 4}, {0x0646, 4}, {0x0647, 4}, {0x0648, 2}, {0x0649, 2}, {0x064A, 4},
+}
+
+type arabicForm struct {
+	isolated rune
+	forms    int
+}
+
+var arabicForms = func() map[rune]arabicForm {
+	forms := make(map[rune]arabicForm, len(arabicLetters))
+	next := rune(0xFE80)
+	for _, l := range arabicLetters {
+		forms[l.letter] = arabicForm{isolated: next, forms: l.forms}
+		next += rune(l.forms)
+	}
+	return forms
+}()
+
+// lamAlef maps the alef variants following a lam to the isolated form of
+// their mandatory lam-alef ligature; the final form follows it
+var lamAlef = map[rune]rune{
+	0x0622: 0xFEF5,
+	0x0623: 0xFEF7,
+	0x0625: 0xFEF9,
+	0x0627: 0xFEFB,
+}
+
+const (
+	arabicLam     = 0x0644
+	arabicTatweel = 0x0640
+)
+
+// shapeArabic replaces Arabic letters with the presentation form matching
+// their position in the word. Harakat are skipped when deciding how letters
+// join.
+func shapeArabic(text []rune) []rune {
+	shaped := make([]rune, 0, len(text))
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		form, ok := arabicForms[c]
+		if !ok {
+			shaped = append(shaped, c)
+			continue
+		}
+
+		joinsPrevious := joinsForward(neighbour(text, i, -1))
+		next, nextIndex := neighbourIndex(text, i, 1)
+
+		if c == arabicLam {
+			if ligature, ok := lamAlef[next]; ok {
+				if joinsPrevious {
+					ligature++
+				}
+				shaped = append(shaped, ligature)
+				i = nextIndex
+				continue
+			}
+		}
+
+		_, nextIsLetter := arabicForms[next]
+		joinsNext := form.forms == 4 && (nextIsLetter || next == arabicTatweel)
+
+		switch {
+		case form.forms == 1:
+			shaped = append(shaped, form.isolated)
+		case joinsPrevious && joinsNext:
+			shaped = append(shaped, form.isolated+3)
+		case joinsPrevious:
+			shaped = append(shaped, form.isolated+1)
+		case joinsNext:
+			shaped = append(shaped, form.isolated+2)
+		default:
+			shaped = append(shaped, form.isolated)
+		}
+	}
+	return shaped
+}
+
+// joinsForward checks if a letter connects to the letter after it
+func joinsForward(c rune) bool {
+	return arabicForms[c].forms == 4 || c == arabicTatweel
+}
+
+func neighbour(text []rune, i, step int) rune {
+	c, _ := neighbourIndex(text, i, step)
+	return c
+}
+
+// neighbourIndex returns the nearest rune before (step -1) or after (step 1)
+// position i that is not a haraka, and its index
+func neighbourIndex(text []rune, i, step int) (rune, int) {
+	for j := i + step; j >= 0 && j < len(text); j += step {
+		if !unicode.Is(unicode.Mn, text[j]) {
+			return text[j], j
+		}
+	}
+	return 0, -1
+}
+
+// isRTL checks if r is written right to left
+func isRTL(r rune) bool {
+	return (r >= 0x0590 && r <= 0x08FF) || (r >= 0xFB1D && r <= 0xFDFF) || (r >= 0xFE70 && r <= 0xFEFF)
+}
+
+// isLTR checks if r is a letter or digit written left to right. Digits keep
+// their order inside Arabic text.
+func isLTR(r rune) bool {
+	return !isRTL(r) && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
+
+var mirrored = map[rune]rune{'(': ')', ')': '(', '[': ']', ']': '[', '<': '>', '>': '<', '«': '»', '»': '«'}
+
+// visualOrder reorders text containing Arabic into the left-to-right order
+// glyphs are drawn in. It is a simplified bidi algorithm for a right-to-left
+// line: runs of left-to-right letters and digits keep their order, and
+// neutral characters take the direction of the surrounding text. Text
+// without right-to-left characters is returned as is.
+func visualOrder(text []rune) []rune {
+	hasRTL := false
+	for _, r := range text {
+		if isRTL(r) {
+			hasRTL = true
+			break
+		}
+	}
+	if !hasRTL {
+		return text
+	}
+
+	// A neutral character is left to right only between left-to-right characters
+	ltr := make([]bool, len(text))
+	for i, r := range text {
+		switch {
+		case isLTR(r):
+			ltr[i] = true
+		case !isRTL(r):
+			before, after := strongBefore(text, i), strongAfter(text, i)
+			ltr[i] = before == 1 && after == 1
+		}
+	}
+
+	visual := make([]rune, len(text))
+	for i, r := range text {
+		if !ltr[i] {
+			if m, ok := mirrored[r]; ok {
+				r = m
+			}
+		}
+		visual[len(text)-1-i] = r
+	}
+
+	// Reversing the whole line also reversed the left-to-right runs; restore them
+	for start := 0; start < len(visual); {
+		if !ltr[len(text)-1-start] {
+			start++
+			continue
+		}
+		end := start
+		for end < len(visual) && ltr[len(text)-1-end] {
+			end++
+		}
+		for i, j := start, end-1; i < j; i, j = i+1, j-1 {
+			visual[i], visual[j] = visual[j], visual[i]
+		}
+		start = end
+	}
+
+	return visual
+}
+
+// strongBefore returns 1 if the nearest strong character before i is left
+// to right, -1 if it is right to left and 0 if there is none
+func strongBefore(text []rune, i int) int {
+	for j := i - 1; j >= 0; j-- {
+		if isLTR(text[j]) {
+			return 1
+		}
+		if isRTL(text[j]) {
+			return -1
+		}
+	}
+	return 0
+}
+
+func strongAfter(text []rune, i int) int {
+	for j := i + 1; j < len(text); j++ {
+		if isLTR(text[j]) {
+			return 1
+		}
+		if isRTL(text[j]) {
+			return -1
+		}
+	}
+	return 0
+}
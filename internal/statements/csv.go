@@ -0,0 +1,41 @@
+package statements
+
+import (
+	"bytes"
+	"encoding/csv"
+
+	"github.com/bank-api/internal/models"
+)
+
+// RenderCSV renders a statement as CSV: a row per line between the opening
+// balance and the totals and closing balance. Amounts are decimals in the
+// account currency, e.g. 1234.567 for TND.
+func RenderCSV(stmt *models.Statement) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	amount := func(amount int64) string {
+		return models.FormatAmount(amount, stmt.Currency)
+	}
+
+	w.Write([]string{
+		labelDate.fr, "Transaction", "Type", labelDescription.fr, "Référence",
+		labelDebit.fr, labelCredit.fr, labelFee.fr, labelBalance.fr,
+	})
+	w.Write([]string{stmt.PeriodStart.Format("2006-01-02"), "", "", labelOpening.fr, "", "", "", "", amount(stmt.OpeningBalance)})
+
+	for _, line := range stmt.Lines {
+		w.Write([]string{
+			line.Date.Format("2006-01-02"), line.TransactionID, line.TransactionType, line.Description, line.Reference,
+			amount(line.Debit), amount(line.Credit), amount(line.Fee), amount(line.Balance),
+		})
+	}
+
+	w.Write([]string{"", "", "", labelTotals.fr, "", amount(stmt.TotalDebits), amount(stmt.TotalCredits), amount(stmt.TotalFees), ""})
+	w.Write([]string{stmt.PeriodEnd.Format("2006-01-02"), "", "", labelClosing.fr, "", "", "", "", amount(stmt.ClosingBalance)})
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
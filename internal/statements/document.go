@@ -0,0 +1,266 @@
+package statements
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A4 portrait, in points
+const (
+	pageWidth  = 595.28
+	pageHeight = 841.89
+)
+
+// courierWidth is the advance of every Courier glyph in thousandths of an em
+const courierWidth = 600
+
+// document is a minimal PDF 1.4 writer: pages of text and rules in a single
+// font, either the embedded TrueType font or the standard Courier font when
+// none is configured
+type document struct {
+	font    *Font
+	pages   []*page
+	objects [][]byte
+	used    map[uint16]rune // Embedded font glyphs drawn, for widths and text extraction
+}
+
+type page struct {
+	doc     *document
+	content bytes.Buffer
+}
+
+func newDocument(font *Font) *document {
+	return &document{font: font, used: make(map[uint16]rune)}
+}
+
+func (d *document) addPage() *page {
+	p := &page{doc: d}
+	d.pages = append(d.pages, p)
+	return p
+}
+
+// textWidth returns the width of text at size points
+func (d *document) textWidth(text string, size float64) float64 {
+	units := 0
+	if d.font == nil {
+		units = courierWidth * len([]rune(text))
+	} else {
+		for _, r := range visualOrder(shapeArabic([]rune(text))) {
+			units += d.font.width(d.font.glyph(r))
+		}
+	}
+	return float64(units) * size / 1000
+}
+
+// fit shortens text with an ellipsis until it is at most width wide
+func (d *document) fit(text string, size, width float64) string {
+	if d.textWidth(text, size) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && d.textWidth(string(runes)+"...", size) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+// encode returns text as a PDF string operand in the page font's encoding
+func (d *document) encode(text string) string {
+	if d.font == nil {
+		return "(" + winAnsi(text) + ")"
+	}
+
+	var hex strings.Builder
+	hex.WriteByte('<')
+	for _, r := range visualOrder(shapeArabic([]rune(text))) {
+		glyph := d.font.glyph(r)
+		if glyph != 0 {
+			d.used[glyph] = r
+		}
+		fmt.Fprintf(&hex, "%04X", glyph)
+	}
+	hex.WriteByte('>')
+	return hex.String()
+}
+
+// winAnsi escapes text for the standard fonts' WinAnsiEncoding. Characters
+// outside Latin-1 are replaced with '?'.
+func winAnsi(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r == '€':
+			b.WriteString(`\200`)
+		case r >= 0x20 && r < 0x7F:
+			b.WriteRune(r)
+		case r >= 0xA0 && r <= 0xFF:
+			fmt.Fprintf(&b, `\%03o`, r)
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
+
+// Text alignment relative to x
+const (
+	alignLeft = iota
+	alignRight
+	alignCenter
+)
+
+// text draws a line of text whose baseline is y points from the top
+func (p *page) text(x, y, size float64, align int, text string) {
+	if text == "" {
+		return
+	}
+	switch align {
+	case alignRight:
+		x -= p.doc.textWidth(text, size)
+	case alignCenter:
+		x -= p.doc.textWidth(text, size) / 2
+	}
+	fmt.Fprintf(&p.content, "BT /F1 %.2f Tf %.2f %.2f Td %s Tj ET\n", size, x, pageHeight-y, p.doc.encode(text))
+}
+
+// rule draws a horizontal line y points from the top
+func (p *page) rule(x1, x2, y, width float64) {
+	fmt.Fprintf(&p.content, "%.2f w %.2f %.2f m %.2f %.2f l S\n", width, x1, pageHeight-y, x2, pageHeight-y)
+}
+
+// band fills a light grey band between top and bottom, measured from the top
+func (p *page) band(x1, x2, top, bottom float64) {
+	fmt.Fprintf(&p.content, "q 0.92 g %.2f %.2f %.2f %.2f re f Q\n", x1, pageHeight-bottom, x2-x1, bottom-top)
+}
+
+// reserve allocates an object number to be filled in later
+func (d *document) reserve() int {
+	d.objects = append(d.objects, nil)
+	return len(d.objects)
+}
+
+func (d *document) set(id int, body string) {
+	d.objects[id-1] = []byte(body)
+}
+
+func (d *document) add(body string) int {
+	id := d.reserve()
+	d.set(id, body)
+	return id
+}
+
+// addStream adds a Flate-compressed stream object; extra holds additional
+// dictionary entries
+func (d *document) addStream(data []byte, extra string) int {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write(data)
+	zw.Close()
+
+	id := d.reserve()
+	d.objects[id-1] = []byte(fmt.Sprintf("<< /Length %d /Filter /FlateDecode%s >>\nstream\n%s\nendstream",
+		compressed.Len(), extra, compressed.Bytes()))
+	return id
+}
+
+// bytes serializes the document
+func (d *document) bytes() []byte {
+	fontID := d.addFont()
+
+	pagesID := d.reserve()
+	kids := make([]string, len(d.pages))
+	for i, p := range d.pages {
+		contentID := d.addStream(p.content.Bytes(), "")
+		kids[i] = fmt.Sprintf("%d 0 R", d.add(fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+			pagesID, pageWidth, pageHeight, fontID, contentID)))
+	}
+	d.set(pagesID, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(d.pages)))
+	catalogID := d.add(fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesID))
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(d.objects))
+	for i, body := range d.objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n", i+1)
+		out.Write(body)
+		out.WriteString("\nendobj\n")
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(d.objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(d.objects)+1, catalogID, xref)
+
+	return out.Bytes()
+}
+
+// addFont adds the page font: Courier, or the TrueType font embedded as a
+// CID font addressed by glyph id, with the widths and Unicode mapping of the
+// glyphs drawn
+func (d *document) addFont() int {
+	if d.font == nil {
+		return d.add("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	}
+
+	f := d.font
+	const name = "/StatementFont"
+	fileID := d.addStream(f.data, fmt.Sprintf(" /Length1 %d", len(f.data)))
+	descriptorID := d.add(fmt.Sprintf(
+		"<< /Type /FontDescriptor /FontName %s /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 /Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		name, f.scale(f.bbox[0]), f.scale(f.bbox[1]), f.scale(f.bbox[2]), f.scale(f.bbox[3]),
+		f.scale(f.ascent), f.scale(f.descent), f.scale(f.capHeight), fileID))
+
+	glyphs := make([]int, 0, len(d.used))
+	for glyph := range d.used {
+		glyphs = append(glyphs, int(glyph))
+	}
+	sort.Ints(glyphs)
+
+	var widths, unicode strings.Builder
+	for _, glyph := range glyphs {
+		fmt.Fprintf(&widths, "%d [%d] ", glyph, f.width(uint16(glyph)))
+	}
+
+	// ToUnicode lets viewers copy and search the text; bfchar blocks hold at
+	// most 100 entries
+	unicode.WriteString("/CIDInit /ProcSet findresource begin 12 dict begin begincmap\n")
+	unicode.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	unicode.WriteString("/CMapName /Adobe-Identity-UCS def /CMapType 2 def\n")
+	unicode.WriteString("1 begincodespacerange <0000> <FFFF> endcodespacerange\n")
+	for start := 0; start < len(glyphs); start += 100 {
+		end := min(start+100, len(glyphs))
+		fmt.Fprintf(&unicode, "%d beginbfchar\n", end-start)
+		for _, glyph := range glyphs[start:end] {
+			fmt.Fprintf(&unicode, "<%04X> <%s>\n", glyph, utf16Hex(d.used[uint16(glyph)]))
+		}
+		unicode.WriteString("endbfchar\n")
+	}
+	unicode.WriteString("endcmap CMapName currentdict /CMap defineresource pop end end\n")
+	toUnicodeID := d.addStream([]byte(unicode.String()), "")
+
+	cidFontID := d.add(fmt.Sprintf(
+		"<< /Type /Font /Subtype /CIDFontType2 /BaseFont %s /CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> /FontDescriptor %d 0 R /W [%s] /CIDToGIDMap /Identity >>",
+		name, descriptorID, widths.String()))
+	return d.add(fmt.Sprintf(
+		"<< /Type /Font /Subtype /Type0 /BaseFont %s /Encoding /Identity-H /DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		name, cidFontID, toUnicodeID))
+}
+
+// utf16Hex returns r in UTF-16BE as hex digits
+func utf16Hex(r rune) string {
+	if r < 0x10000 {
+		return fmt.Sprintf("%04X", r)
+	}
+	r -= 0x10000
+	return fmt.Sprintf("%04X%04X", 0xD800+(r>>10), 0xDC00+(r&0x3FF))
+}
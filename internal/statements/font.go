@@ -0,0 +1,203 @@
+package statements
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Font is a TrueType font embedded in PDF statements. Arabic text is drawn
+// with the Arabic Presentation Forms-B glyphs, so the font must map those
+// code points (DejaVu Sans does).
+type Font struct {
+	data       []byte
+	unitsPerEm int
+	ascent     int
+	descent    int
+	capHeight  int
+	bbox       [4]int
+	glyphs     map[rune]uint16
+	advances   []uint16
+}
+
+// LoadFont reads a TrueType (.ttf) font file
+func LoadFont(path string) (*Font, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read statement font: %w", err)
+	}
+	return ParseFont(data)
+}
+
+// ParseFont reads the metrics and character map of a TrueType font.
+// OpenType fonts with CFF outlines and font collections are not supported.
+func ParseFont(data []byte) (*Font, error) {
+	if len(data) < 12 {
+		return nil, errors.New("font file is too short")
+	}
+	if version := binary.BigEndian.Uint32(data); version != 0x00010000 && version != 0x74727565 {
+		return nil, errors.New("font is not a TrueType font")
+	}
+
+	tables := make(map[string][]byte)
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < numTables; i++ {
+		record := 12 + 16*i
+		if record+16 > len(data) {
+			return nil, errors.New("font table directory is truncated")
+		}
+		offset := int(binary.BigEndian.Uint32(data[record+8:]))
+		length := int(binary.BigEndian.Uint32(data[record+12:]))
+		if offset < 0 || length < 0 || offset+length > len(data) {
+			return nil, fmt.Errorf("font table %s is truncated", data[record:record+4])
+		}
+		tables[string(data[record:record+4])] = data[offset : offset+length]
+	}
+
+	for tag, minLength := range map[string]int{"head": 54, "hhea": 36, "maxp": 6, "hmtx": 0, "cmap": 4, "glyf": 0} {
+		if table, ok := tables[tag]; !ok || len(table) < minLength {
+			return nil, fmt.Errorf("font has no valid %s table", tag)
+		}
+	}
+
+	head, hhea := tables["head"], tables["hhea"]
+	f := &Font{
+		data:       data,
+		unitsPerEm: int(u16(head, 18)),
+		ascent:     int(i16(hhea, 4)),
+		descent:    int(i16(hhea, 6)),
+		bbox:       [4]int{int(i16(head, 36)), int(i16(head, 38)), int(i16(head, 40)), int(i16(head, 42))},
+	}
+	if f.unitsPerEm == 0 {
+		return nil, errors.New("font has no units per em")
+	}
+
+	f.capHeight = f.ascent
+	if os2 := tables["OS/2"]; len(os2) >= 90 && u16(os2, 0) >= 2 {
+		f.capHeight = int(i16(os2, 88))
+	}
+
+	// Glyphs past the last horizontal metric share its advance width
+	numGlyphs := int(u16(tables["maxp"], 4))
+	numMetrics := int(u16(hhea, 34))
+	hmtx := tables["hmtx"]
+	if numMetrics == 0 || len(hmtx) < 4*numMetrics {
+		return nil, errors.New("font hmtx table is truncated")
+	}
+	f.advances = make([]uint16, numGlyphs)
+	for gid := range f.advances {
+		if gid < numMetrics {
+			f.advances[gid] = u16(hmtx, 4*gid)
+		} else {
+			f.advances[gid] = f.advances[numMetrics-1]
+		}
+	}
+
+	glyphs, err := parseCmap(tables["cmap"])
+	if err != nil {
+		return nil, err
+	}
+	f.glyphs = glyphs
+
+	return f, nil
+}
+
+// parseCmap reads the font's Unicode character map, preferring the full
+// repertoire subtable (format 12) over the BMP one (format 4)
+func parseCmap(cmap []byte) (map[rune]uint16, error) {
+	var bmp, full []byte
+	numTables := int(u16(cmap, 2))
+	for i := 0; i < numTables; i++ {
+		record := 4 + 8*i
+		if record+8 > len(cmap) {
+			break
+		}
+		platform, encoding := u16(cmap, record), u16(cmap, record+2)
+		offset := int(u32(cmap, record+4))
+		if offset >= len(cmap) {
+			continue
+		}
+		subtable := cmap[offset:]
+		switch {
+		case u16(subtable, 0) == 12 && (platform == 0 || (platform == 3 && encoding == 10)):
+			full = subtable
+		case u16(subtable, 0) == 4 && (platform == 0 || (platform == 3 && encoding == 1)):
+			bmp = subtable
+		}
+	}
+
+	glyphs := make(map[rune]uint16)
+	switch {
+	case full != nil:
+		numGroups := int(u32(full, 12))
+		for i := 0; i < numGroups && 16+12*i+12 <= len(full); i++ {
+			group := 16 + 12*i
+			start, end, glyph := u32(full, group), u32(full, group+4), u32(full, group+8)
+			for c := start; c <= end && c <= 0x10FFFF; c++ {
+				glyphs[rune(c)] = uint16(glyph + c - start)
+			}
+		}
+	case bmp != nil:
+		segCount := int(u16(bmp, 6)) / 2
+		endCodes, startCodes := 14, 16+2*segCount
+		deltas, rangeOffsets := startCodes+2*segCount, startCodes+4*segCount
+		for i := 0; i < segCount; i++ {
+			start, end := int(u16(bmp, startCodes+2*i)), int(u16(bmp, endCodes+2*i))
+			delta, rangeOffset := int(u16(bmp, deltas+2*i)), int(u16(bmp, rangeOffsets+2*i))
+			for c := start; c <= end && c < 0xFFFF; c++ {
+				glyph := (c + delta) & 0xFFFF
+				if rangeOffset != 0 {
+					// idRangeOffset is relative to its own position in the table
+					glyph = int(u16(bmp, rangeOffsets+2*i+rangeOffset+2*(c-start)))
+					if glyph != 0 {
+						glyph = (glyph + delta) & 0xFFFF
+					}
+				}
+				if glyph != 0 {
+					glyphs[rune(c)] = uint16(glyph)
+				}
+			}
+		}
+	default:
+		return nil, errors.New("font has no Unicode character map")
+	}
+
+	return glyphs, nil
+}
+
+// glyph returns the glyph drawing r, or 0 (.notdef) if the font lacks it
+func (f *Font) glyph(r rune) uint16 {
+	return f.glyphs[r]
+}
+
+// width returns a glyph's advance in thousandths of an em
+func (f *Font) width(glyph uint16) int {
+	if int(glyph) >= len(f.advances) {
+		return 0
+	}
+	return int(f.advances[glyph]) * 1000 / f.unitsPerEm
+}
+
+// scale converts font units to thousandths of an em
+func (f *Font) scale(units int) int {
+	return units * 1000 / f.unitsPerEm
+}
+
+func u16(b []byte, offset int) uint16 {
+	if offset < 0 || offset+2 > len(b) {
+		return 0
+	}
+	return binary.BigEndian.Uint16(b[offset:])
+}
+
+func i16(b []byte, offset int) int16 {
+	return int16(u16(b, offset))
+}
+
+func u32(b []byte, offset int) uint32 {
+	if offset < 0 || offset+4 > len(b) {
+		return 0
+	}
+	return binary.BigEndian.Uint32(b[offset:])
+}
@@ -0,0 +1,207 @@
+package statements
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bank-api/internal/models"
+)
+
+// Layout, in points
+const (
+	marginLeft   = 36.0
+	marginRight  = pageWidth - 36.0
+	contentLimit = pageHeight - 70.0 // Lowest baseline of a table row
+	rowHeight    = 13.0
+	bodySize     = 8.0
+	infoSize     = 9.0
+)
+
+// column is a statement table column ending at right
+type column struct {
+	label label
+	left  float64
+	right float64
+	align int
+}
+
+var columns = []column{
+	{labelDate, marginLeft, 94, alignLeft},
+	{labelDescription, 94, 273, alignLeft},
+	{labelDebit, 273, 343, alignRight},
+	{labelCredit, 343, 413, alignRight},
+	{labelFee, 413, 468, alignRight},
+	{labelBalance, 468, marginRight, alignRight},
+}
+
+const cellPadding = 4.0
+
+// RenderPDF renders a statement as an A4 PDF with French and Arabic
+// captions. Without a font, Courier is used and the Arabic captions are
+// left out.
+func RenderPDF(stmt *models.Statement, bankName string, font *Font) ([]byte, error) {
+	doc := newDocument(font)
+	r := &pdfRenderer{doc: doc, stmt: stmt, bankName: bankName}
+
+	r.firstPage()
+	for _, line := range stmt.Lines {
+		description := line.Description
+		if description == "" {
+			description = line.TransactionType
+		}
+		r.row(
+			line.Date.Format("02/01/2006"), description,
+			r.amount(line.Debit), r.amount(line.Credit), r.amount(line.Fee),
+			r.signedAmount(line.Balance),
+		)
+	}
+
+	r.page.rule(marginLeft, marginRight, r.y+4, 0.5)
+	r.totalRow(labelTotals, r.amount(stmt.TotalDebits), r.amount(stmt.TotalCredits), r.amount(stmt.TotalFees), "")
+	r.totalRow(labelClosing, "", "", "", r.signedAmount(stmt.ClosingBalance))
+
+	generated := "Édité le " + stmt.GeneratedAt.Format("02/01/2006 à 15:04")
+	for i, p := range doc.pages {
+		p.rule(marginLeft, marginRight, pageHeight-40, 0.5)
+		p.text(marginLeft, pageHeight-28, 7, alignLeft, generated)
+		p.text(marginRight, pageHeight-28, 7, alignRight, fmt.Sprintf("Page %d / %d", i+1, len(doc.pages)))
+	}
+
+	return doc.bytes(), nil
+}
+
+type pdfRenderer struct {
+	doc      *document
+	stmt     *models.Statement
+	bankName string
+	page     *page
+	y        float64 // Baseline of the last row drawn, from the top
+}
+
+func (r *pdfRenderer) firstPage() {
+	r.page = r.doc.addPage()
+	p, stmt := r.page, r.stmt
+
+	p.text(marginLeft, 50, 12, alignLeft, r.bankName)
+	p.text(pageWidth/2, 82, 16, alignCenter, labelTitle.fr)
+	if r.arabic() {
+		p.text(pageWidth/2, 102, 14, alignCenter, labelTitle.ar)
+	}
+
+	period := fmt.Sprintf("Du %s au %s", stmt.PeriodStart.Format("02/01/2006"), stmt.PeriodEnd.Format("02/01/2006"))
+	info := []struct {
+		label label
+		value string
+	}{
+		{labelHolder, stmt.OwnerName},
+		{labelAccount, stmt.AccountNumber},
+		{labelIBAN, stmt.IBAN},
+		{labelBIC, stmt.BIC},
+		{labelCurrency, stmt.Currency},
+		{labelPeriod, period},
+		{labelOpening, r.signedAmount(stmt.OpeningBalance) + " " + stmt.Currency},
+	}
+
+	y := 130.0
+	for _, row := range info {
+		p.text(marginLeft, y, infoSize, alignLeft, row.label.fr)
+		p.text(150, y, infoSize, alignLeft, row.value)
+		if r.arabic() {
+			p.text(marginRight, y, infoSize, alignRight, row.label.ar)
+		}
+		y += 15
+	}
+
+	r.tableHeader(y + 10)
+}
+
+// tableHeader draws the column captions below top
+func (r *pdfRenderer) tableHeader(top float64) {
+	bottom := top + 16
+	if r.arabic() {
+		bottom += 11
+	}
+	r.page.band(marginLeft, marginRight, top, bottom)
+	for _, c := range columns {
+		x := c.left + cellPadding
+		if c.align == alignRight {
+			x = c.right - cellPadding
+		}
+		r.page.text(x, top+11, bodySize, c.align, c.label.fr)
+		if r.arabic() {
+			r.page.text(x, top+22, bodySize, c.align, c.label.ar)
+		}
+	}
+	r.y = bottom - 2
+}
+
+// nextRow moves to the next row, starting a new page when this one is full
+func (r *pdfRenderer) nextRow() {
+	r.y += rowHeight
+	if r.y <= contentLimit {
+		return
+	}
+
+	r.page = r.doc.addPage()
+	r.page.text(marginLeft, 50, infoSize, alignLeft, r.bankName)
+	r.page.text(marginRight, 50, infoSize, alignRight, r.stmt.AccountNumber)
+	r.tableHeader(62)
+	r.y += rowHeight
+}
+
+// row draws one statement line, one value per column
+func (r *pdfRenderer) row(values ...string) {
+	r.nextRow()
+	for i, c := range columns {
+		value := r.doc.fit(values[i], bodySize, c.right-c.left-2*cellPadding)
+		if c.align == alignRight {
+			r.page.text(c.right-cellPadding, r.y, bodySize, alignRight, value)
+		} else {
+			r.page.text(c.left+cellPadding, r.y, bodySize, alignLeft, value)
+		}
+	}
+}
+
+// totalRow draws a captioned row of amounts below the lines
+func (r *pdfRenderer) totalRow(caption label, debit, credit, fee, balance string) {
+	r.row("", "", debit, credit, fee, balance)
+	r.page.text(marginLeft+cellPadding, r.y, bodySize, alignLeft, caption.fr)
+	if r.arabic() {
+		r.page.text(columns[1].right-cellPadding, r.y, bodySize, alignRight, caption.ar)
+	}
+}
+
+// arabic checks if the Arabic captions can be drawn
+func (r *pdfRenderer) arabic() bool {
+	return r.doc.font != nil
+}
+
+// amount formats a non-negative amount, leaving zero blank
+func (r *pdfRenderer) amount(amount int64) string {
+	if amount == 0 {
+		return ""
+	}
+	return r.signedAmount(amount)
+}
+
+// signedAmount formats an amount the French way: "-1 234,567"
+func (r *pdfRenderer) signedAmount(amount int64) string {
+	formatted := models.FormatAmount(amount, r.stmt.Currency)
+	sign := ""
+	if strings.HasPrefix(formatted, "-") {
+		sign, formatted = "-", formatted[1:]
+	}
+	whole, fraction, hasFraction := strings.Cut(formatted, ".")
+
+	var grouped strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteByte(' ')
+		}
+		grouped.WriteRune(digit)
+	}
+	if hasFraction {
+		return sign + grouped.String() + "," + fraction
+	}
+	return sign + grouped.String()
+}
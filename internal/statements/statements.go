@@ -0,0 +1,29 @@
+// Package statements renders account statements as CSV and PDF documents.
+// The PDF renderer is self-contained: it writes the PDF objects itself and
+// embeds a TrueType font for the Arabic captions.
+package statements
+
+// label is a caption in French with its Arabic translation
+type label struct {
+	fr string
+	ar string
+}
+
+var (
+	labelTitle       = label{"Relevé de compte", "كشف حساب"}
+	labelHolder      = label{"Titulaire", "صاحب الحساب"}
+	labelAccount     = label{"RIB", "رقم الحساب"}
+	labelIBAN        = label{"IBAN", "رقم الحساب الدولي"}
+	labelBIC         = label{"BIC", "رمز البنك"}
+	labelCurrency    = label{"Devise", "العملة"}
+	labelPeriod      = label{"Période", "الفترة"}
+	labelOpening     = label{"Solde initial", "الرصيد الافتتاحي"}
+	labelDate        = label{"Date", "التاريخ"}
+	labelDescription = label{"Libellé", "البيان"}
+	labelDebit       = label{"Débit", "مدين"}
+	labelCredit      = label{"Crédit", "دائن"}
+	labelFee         = label{"Frais", "العمولات"}
+	labelBalance     = label{"Solde", "الرصيد"}
+	labelTotals      = label{"Totaux", "المجموع"}
+	labelClosing     = label{"Solde final", "الرصيد الختامي"}
+)
@@ -1,8 +1,9 @@
 package utils
 
 import (
-	"fmt"
-	"os"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
 	"time"
 
 	"github.com/bank-api/internal/models"
@@ -10,55 +11,110 @@ import (
 )
 
 type JWTClaims struct {
-	AccountNumber string `json:"account_number"`
-	CustomerID    string `json:"customer_id"`
+	CustomerID string `json:"customer_id,omitempty"`
+	StaffID    string `json:"staff_id,omitempty"`
+	ClientID   string `json:"client_id,omitempty"`
+	Role       string `json:"role"`
+	SessionID  string `json:"sid,omitempty"`
+	// How the session authenticated: amr lists the methods (RFC 8176), acr
+	// the level they reach and auth_time when it was last reached
+	AMR      []string         `json:"amr,omitempty"`
+	ACR      string           `json:"acr,omitempty"`
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
+	// Space-separated scopes granted to an API client's token (RFC 9068)
+	Scope string `json:"scope,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateJWT generates a JWT token for the given account
-func GenerateJWT(account *models.Account, secret string, expiresIn time.Duration) (string, error) {
+// EffectiveRole returns the token's role; tokens issued before roles existed
+// were always customer tokens
+func (c *JWTClaims) EffectiveRole() string {
+	if c.Role == "" {
+		return models.RoleCustomer
+	}
+	return c.Role
+}
+
+// Scopes returns the scopes of an API client's token
+func (c *JWTClaims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+// HasRecentMFA reports whether the token's session verified a second factor
+// within maxAge
+func (c *JWTClaims) HasRecentMFA(maxAge time.Duration) bool {
+	return c.ACR == models.ACRMultiFactor && c.AuthTime != nil && time.Since(c.AuthTime.Time) <= maxAge
+}
+
+// GenerateJWT generates an access token for the given customer within a login session
+func GenerateJWT(customer *models.Customer, session *models.AuthSession, keys *JWTKeySet, expiresIn time.Duration) (string, error) {
 	now := time.Now()
 	claims := &JWTClaims{
-		AccountNumber: account.AccountNumber,
-		CustomerID:    account.CustomerID,
+		CustomerID: customer.CustomerID,
+		Role:       models.RoleCustomer,
+		SessionID:  session.SessionID,
+		AMR:        session.AuthMethods,
+		ACR:        models.ACRForMethods(session.AuthMethods),
+		AuthTime:   jwt.NewNumericDate(session.AuthenticatedAt),
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        newTokenID(),
 			ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
 			IssuedAt:  jwt.NewNumericDate(now),
 			NotBefore: jwt.NewNumericDate(now),
-			Issuer:    "bank-api",
-			Subject:   account.CustomerID,
+			Subject:   customer.CustomerID,
 		},
 	}
 	
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	return keys.Sign(claims)
 }
 
-// VerifyJWT verifies and parses a JWT token
-func VerifyJWT(tokenString string, secret string) (*JWTClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(secret), nil
-	})
-	
-	if err != nil {
-		return nil, err
+// GenerateStaffJWT generates an access token for a back-office staff user
+// within a login session
+func GenerateStaffJWT(user *models.StaffUser, session *models.AuthSession, keys *JWTKeySet, expiresIn time.Duration) (string, error) {
+	now := time.Now()
+	claims := &JWTClaims{
+		StaffID:   user.StaffID,
+		Role:      user.Role,
+		SessionID: session.SessionID,
+		AMR:       session.AuthMethods,
+		ACR:       models.ACRForMethods(session.AuthMethods),
+		AuthTime:  jwt.NewNumericDate(session.AuthenticatedAt),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        newTokenID(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Subject:   user.StaffID,
+		},
 	}
 	
-	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
-		return claims, nil
+	return keys.Sign(claims)
+}
+
+// GenerateClientJWT generates an access token for a partner API client,
+// limited to scopes
+func GenerateClientJWT(client *models.APIClient, scopes []string, session *models.AuthSession, keys *JWTKeySet, expiresIn time.Duration) (string, error) {
+	now := time.Now()
+	claims := &JWTClaims{
+		ClientID:  client.ClientID,
+		Role:      models.RoleAPIClient,
+		SessionID: session.SessionID,
+		Scope:     strings.Join(scopes, " "),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        newTokenID(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(expiresIn)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Subject:   client.ClientID,
+		},
 	}
 	
-	return nil, fmt.Errorf("invalid token")
+	return keys.Sign(claims)
 }
 
-// GetJWTSecret gets the JWT secret from environment variable
-func GetJWTSecret() string {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "your-secret-key-change-in-production"
-	}
-	return secret
+// newTokenID returns a random jti so individual tokens can be revoked
+func newTokenID() string {
+	randomBytes := make([]byte, 16)
+	rand.Read(randomBytes)
+	return hex.EncodeToString(randomBytes)
 }
@@ -0,0 +1,289 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bank-api/internal/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSAKeyBits is the smallest RSA key accepted for signing tokens
+const minRSAKeyBits = 2048
+
+// JWTKey is one key access tokens are signed and verified with
+type JWTKey struct {
+	ID         string // Published as kid
+	Method     jwt.SigningMethod
+	ActiveFrom time.Time // When the key starts signing; it is published before then
+	signKey    interface{}
+	verifyKey  interface{}
+}
+
+// JWTKeySet signs access tokens with its current key and verifies them with
+// any key still in rotation. Each key takes over signing at its ActiveFrom;
+// the key it replaces keeps verifying for the overlap, so the tokens it
+// signed can run out.
+type JWTKeySet struct {
+	keys     []*JWTKey // Ordered by ActiveFrom
+	overlap  time.Duration
+	issuer   string
+	audience string
+}
+
+// NewHMACKeySet signs and verifies HS256 tokens with a shared secret. Nothing
+// is published in its JWKS.
+func NewHMACKeySet(secret, issuer, audience string) *JWTKeySet {
+	return &JWTKeySet{
+		keys: []*JWTKey{{
+			Method:    jwt.SigningMethodHS256,
+			signKey:   []byte(secret),
+			verifyKey: []byte(secret),
+		}},
+		issuer:   issuer,
+		audience: audience,
+	}
+}
+
+// NewJWTKeySet builds a key set from asymmetric keys. At least one of them
+// must already be active.
+func NewJWTKeySet(keys []*JWTKey, overlap time.Duration, issuer, audience string) (*JWTKeySet, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("at least one JWT signing key is required")
+	}
+
+	seen := map[string]bool{}
+	for _, key := range keys {
+		if key.ID == "" {
+			return nil, fmt.Errorf("JWT signing keys need a key ID")
+		}
+		if seen[key.ID] {
+			return nil, fmt.Errorf("duplicate JWT key ID %s", key.ID)
+		}
+		seen[key.ID] = true
+	}
+
+	ordered := append([]*JWTKey(nil), keys...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].ActiveFrom.Before(ordered[j].ActiveFrom)
+	})
+
+	set := &JWTKeySet{keys: ordered, overlap: overlap, issuer: issuer, audience: audience}
+	if set.signingKey(time.Now()) == nil {
+		return nil, fmt.Errorf("no JWT signing key is active yet")
+	}
+	return set, nil
+}
+
+// LoadJWTKeySet loads the keys listed in spec, a comma-separated list of
+// kid=path entries, each optionally followed by @activation (RFC 3339). Paths
+// name PEM-encoded RSA or P-256 ECDSA private keys; an entry without an
+// activation time is active from the start.
+func LoadJWTKeySet(spec string, overlap time.Duration, issuer, audience string) (*JWTKeySet, error) {
+	var keys []*JWTKey
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		id, path, found := strings.Cut(entry, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid JWT signing key %q: expected kid=path[@activation]", entry)
+		}
+
+		var activeFrom time.Time
+		if at := strings.LastIndex(path, "@"); at >= 0 {
+			parsed, err := time.Parse(time.RFC3339, path[at+1:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid activation time for JWT key %s: %w", id, err)
+			}
+			activeFrom, path = parsed, path[:at]
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWT key %s: %w", id, err)
+		}
+
+		key, err := ParseJWTKey(strings.TrimSpace(id), content, activeFrom)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return NewJWTKeySet(keys, overlap, issuer, audience)
+}
+
+// ParseJWTKey parses a PEM-encoded private key: RSA keys sign with RS256,
+// P-256 ECDSA keys with ES256
+func ParseJWTKey(id string, pemBytes []byte, activeFrom time.Time) (*JWTKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, fmt.Errorf("JWT key %s is not PEM encoded", id)
+	}
+
+	var privateKey interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		privateKey, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		privateKey, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("JWT key %s: unsupported PEM block %s", id, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JWT key %s: %w", id, err)
+	}
+
+	key := &JWTKey{ID: id, ActiveFrom: activeFrom, signKey: privateKey}
+	switch privateKey := privateKey.(type) {
+	case *rsa.PrivateKey:
+		if privateKey.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("JWT key %s: RSA keys must be at least %d bits", id, minRSAKeyBits)
+		}
+		key.Method = jwt.SigningMethodRS256
+		key.verifyKey = &privateKey.PublicKey
+	case *ecdsa.PrivateKey:
+		if privateKey.Curve != elliptic.P256() {
+			return nil, fmt.Errorf("JWT key %s: ECDSA keys must use the P-256 curve", id)
+		}
+		key.Method = jwt.SigningMethodES256
+		key.verifyKey = &privateKey.PublicKey
+	default:
+		return nil, fmt.Errorf("JWT key %s: only RSA and ECDSA keys are supported", id)
+	}
+
+	return key, nil
+}
+
+// signingKey returns the most recently activated key
+func (s *JWTKeySet) signingKey(now time.Time) *JWTKey {
+	var current *JWTKey
+	for _, key := range s.keys {
+		if key.ActiveFrom.After(now) {
+			break
+		}
+		current = key
+	}
+	return current
+}
+
+// verifies reports whether the i-th key still verifies tokens: keys not yet
+// active already do, replaced ones do until the overlap has passed
+func (s *JWTKeySet) verifies(i int, now time.Time) bool {
+	if i == len(s.keys)-1 {
+		return true
+	}
+	return now.Before(s.keys[i+1].ActiveFrom.Add(s.overlap))
+}
+
+// Sign stamps the key set's issuer and audience on claims and signs them with
+// the current key
+func (s *JWTKeySet) Sign(claims *JWTClaims) (string, error) {
+	key := s.signingKey(time.Now())
+	if key == nil {
+		return "", fmt.Errorf("no JWT signing key is active")
+	}
+
+	claims.Issuer = s.issuer
+	if s.audience != "" {
+		claims.Audience = jwt.ClaimStrings{s.audience}
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	if key.ID != "" {
+		token.Header["kid"] = key.ID
+	}
+	return token.SignedString(key.signKey)
+}
+
+// Verify checks a token's signature against the key named by its kid, and
+// its expiry, issuer and audience
+func (s *JWTKeySet) Verify(tokenString string) (*JWTClaims, error) {
+	now := time.Now()
+	methods := map[string]bool{}
+	for _, key := range s.keys {
+		methods[key.Method.Alg()] = true
+	}
+	validMethods := make([]string, 0, len(methods))
+	for method := range methods {
+		validMethods = append(validMethods, method)
+	}
+
+	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		for i, key := range s.keys {
+			if key.ID != kid {
+				continue
+			}
+			if !s.verifies(i, now) {
+				return nil, fmt.Errorf("key %s has been rotated out", kid)
+			}
+			if token.Method.Alg() != key.Method.Alg() {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			return key.verifyKey, nil
+		}
+		return nil, fmt.Errorf("unknown key %q", kid)
+	},
+		jwt.WithValidMethods(validMethods),
+		jwt.WithIssuer(s.issuer),
+		jwt.WithAudience(s.audience),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
+		return claims, nil
+	}
+
+	return nil, fmt.Errorf("invalid token")
+}
+
+// JWKS returns the public keys that currently verify tokens, including keys
+// not yet active so verifiers can fetch them ahead of the rotation
+func (s *JWTKeySet) JWKS() *models.JWKS {
+	now := time.Now()
+	jwks := &models.JWKS{Keys: []models.JWK{}}
+	for i, key := range s.keys {
+		if !s.verifies(i, now) {
+			continue
+		}
+
+		jwk := models.JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+		switch publicKey := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		case *ecdsa.PublicKey:
+			jwk.Kty = "EC"
+			jwk.Crv = "P-256"
+			jwk.X = base64.RawURLEncoding.EncodeToString(publicKey.X.FillBytes(make([]byte, 32)))
+			jwk.Y = base64.RawURLEncoding.EncodeToString(publicKey.Y.FillBytes(make([]byte, 32)))
+		default:
+			// Shared secrets are never published
+			continue
+		}
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	return jwks
+}
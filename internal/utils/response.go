@@ -2,6 +2,7 @@ package utils
 
 import (
 	"encoding/json"
+	"net"
 	"net/http"
 	"time"
 
@@ -24,6 +25,16 @@ func WriteError(w http.ResponseWriter, status int, message string) error {
 	return WriteJSON(w, status, errorResponse)
 }
 
+// WriteErrorCode writes an error response carrying a machine-readable code
+func WriteErrorCode(w http.ResponseWriter, status int, code, message string) error {
+	errorResponse := models.ErrorResponse{
+		Error:     message,
+		Code:      code,
+		Timestamp: time.Now().UTC(),
+	}
+	return WriteJSON(w, status, errorResponse)
+}
+
 // WriteSuccess writes a success response to the http.ResponseWriter
 func WriteSuccess(w http.ResponseWriter, status int, message string, data interface{}) error {
 	successResponse := models.SuccessResponse{
@@ -39,3 +50,11 @@ func ParseJSON(r *http.Request, v interface{}) error {
 	defer r.Body.Close()
 	return json.NewDecoder(r.Body).Decode(v)
 }
+
+// RemoteIP returns the IP address a request comes from, without the port
+func RemoteIP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
@@ -0,0 +1,92 @@
+package utils
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA-1 key of the RFC 6238 test vectors, base32
+// encoded as authenticator apps take it
+var rfc6238Secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 appendix B gives 8-digit codes; ours are their last 6 digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},          // 94287082
+		{unix: 1111111109, want: "081804"},  // 07081804
+		{unix: 1111111111, want: "050471"},  // 14050471
+		{unix: 1234567890, want: "005924"},  // 89005924
+		{unix: 2000000000, want: "279037"},  // 69279037
+		{unix: 20000000000, want: "353130"}, // 65353130
+	}
+
+	for _, tt := range tests {
+		t.Run(time.Unix(tt.unix, 0).UTC().Format(time.RFC3339), func(t *testing.T) {
+			got, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(tt.unix, 0)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("TOTPCode = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTOTPCodeSecretFormat(t *testing.T) {
+	want, err := TOTPCode(rfc6238Secret, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Secrets are often typed in lower case and in groups of four
+	got, err := TOTPCode("gezd gnbv gy3t qojq gezd gnbv gy3t qojq", 1)
+	if err != nil || got != want {
+		t.Errorf("TOTPCode of a grouped lower-case secret = %q, %v, want %q", got, err, want)
+	}
+
+	if _, err := TOTPCode("not base32!", 1); err == nil {
+		t.Error("TOTPCode accepted an invalid secret")
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	current := TOTPStep(now)
+
+	codeAt := func(step int64) string {
+		code, err := TOTPCode(rfc6238Secret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "current step", code: codeAt(current), wantStep: current, wantOK: true},
+		{name: "previous step", code: codeAt(current - 1), wantStep: current - 1, wantOK: true},
+		{name: "next step", code: codeAt(current + 1), wantStep: current + 1, wantOK: true},
+		{name: "two steps old", code: codeAt(current - 2)},
+		{name: "two steps ahead", code: codeAt(current + 2)},
+		{name: "wrong length", code: "50471"},
+		{name: "empty", code: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(rfc6238Secret, tt.code, now)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("ValidateTOTP = %d, %v, want %d, %v", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
@@ -2,19 +2,38 @@ package tests
 
 import (
 	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
 	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/bank-api/internal/api/handlers"
 	"github.com/bank-api/internal/api/routes"
 	"github.com/bank-api/internal/config"
+	"github.com/bank-api/internal/ledger"
 	"github.com/bank-api/internal/models"
 	"github.com/bank-api/internal/repository"
+	"github.com/bank-api/internal/services"
+	"github.com/bank-api/internal/utils"
 )
 
 const (
@@ -41,6 +60,16 @@ func TestMain(m *testing.M) {
 }
 
 func setup() {
+	// Notifications and the breached password list live in a scratch directory
+	scratchDir, err := os.MkdirTemp("", "bank-api-test")
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create scratch directory: %v", err))
+	}
+	breachedList := filepath.Join(scratchDir, "breached-passwords.txt")
+	if err := os.WriteFile(breachedList, []byte("# test list\nMotdepasse2024\n"), 0o600); err != nil {
+		panic(fmt.Sprintf("Failed to write breached password list: %v", err))
+	}
+	
 	// Load test configuration
 	testConfig = &config.Config{		Database: config.DatabaseConfig{
 			Host:     "localhost",
@@ -51,14 +80,56 @@ func setup() {
 			SSLMode:  "disable",
 		},
 		JWT: config.JWTConfig{
-			Secret:    "test-secret-key",
-			ExpiresIn: 24 * time.Hour,
-			Issuer:    "bank-api-test",
+			Secret:           "test-secret-key",
+			ExpiresIn:        15 * time.Minute,
+			RefreshExpiresIn: 24 * time.Hour,
+			SessionLifetime:  7 * 24 * time.Hour,
+			Issuer:           "bank-api-test",
+		},
+		FX: config.FXConfig{
+			Provider:      "static",
+			QuoteTTL:      time.Minute,
+			BuySpreadBps:  50,
+			SellSpreadBps: 75,
+		},
+		Holds: config.HoldConfig{
+			DefaultTTL: time.Hour,
+			MaxTTL:     24 * time.Hour,
+		},
+		Bank: config.BankConfig{
+			Code:       "10",
+			BranchCode: "001",
+		},
+		Clearing: config.ClearingConfig{
+			Gateway:        "mock",
+			CallbackSecret: "test-clearing-secret",
+		},
+		MFA: config.MFAConfig{
+			Issuer:          "Bank API Test",
+			ChallengeTTL:    5 * time.Minute,
+			StepUpThreshold: 500000,
+			StepUpMaxAge:    5 * time.Minute,
+		},
+		Login: config.LoginConfig{
+			MaxFailures:     3,
+			LockoutDuration: 15 * time.Minute,
+			IPMaxFailures:   5,
+			IPWindow:        15 * time.Minute,
+		},
+		Password: config.PasswordConfig{
+			MinLength:        8,
+			MinCharClasses:   2,
+			HistoryDepth:     3,
+			BreachedListFile: breachedList,
+			ResetTTL:         30 * time.Minute,
+		},
+		Notify: config.NotifyConfig{
+			Sender:    "file",
+			OutboxDir: filepath.Join(scratchDir, "outbox"),
 		},
 	}
 	
 	// Create test database connection
-	var err error
 	testDB, err = repository.NewPostgresDB(&testConfig.Database)
 	if err != nil {
 		panic(fmt.Sprintf("Failed to connect to test database: %v", err))
@@ -70,7 +141,10 @@ func setup() {
 	}
 	
 	// Create test router
-	testRouter = routes.NewRouter(testDB, testConfig)
+	testRouter, err = routes.NewRouter(testDB, testConfig)
+	if err != nil {
+		panic(fmt.Sprintf("Failed to create test router: %v", err))
+	}
 }
 
 func teardown() {
@@ -78,8 +152,10 @@ func teardown() {
 		// Clean up test data
 		testDB.Exec("TRUNCATE TABLE transactions CASCADE")
 		testDB.Exec("TRUNCATE TABLE accounts CASCADE")
+		testDB.Exec("TRUNCATE TABLE customers CASCADE")
 		testDB.Close()
 	}
+	os.RemoveAll(filepath.Dir(testConfig.Notify.OutboxDir))
 }
 
 func TestHealthCheck(t *testing.T) {
@@ -102,6 +178,32 @@ func TestHealthCheck(t *testing.T) {
 	}
 }
 
+func TestMigrationsApplied(t *testing.T) {
+	migrator, err := repository.NewMigrator(testDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	// Running again must be a no-op once the schema is current
+	applied, err := migrator.Up()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if applied != 0 {
+		t.Errorf("Expected no pending migrations after setup, %d were applied", applied)
+	}
+	
+	statuses, err := migrator.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		if !status.Applied {
+			t.Errorf("Migration %04d_%s is not applied", status.Version, status.Name)
+		}
+	}
+}
+
 func TestCreateAccount(t *testing.T) {
 	createAccountReq := models.CreateAccountRequest{
 		FirstName:   "Mohamed",
@@ -145,6 +247,19 @@ func TestCreateAccount(t *testing.T) {
 	if response.Message != "Account created successfully" {
 		t.Errorf("Unexpected response message: got %v", response.Message)
 	}
+	
+	accountData, _ := json.Marshal(response.Data)
+	var account models.Account
+	if err := json.Unmarshal(accountData, &account); err != nil {
+		t.Fatal("Failed to unmarshal account data:", err)
+	}
+	
+	if err := models.ValidateTunisianIBAN(account.IBAN); err != nil {
+		t.Errorf("Account IBAN %s is invalid: %v", account.IBAN, err)
+	}
+	if account.BIC != "STBKTNTT" {
+		t.Errorf("Unexpected BIC: got %v want STBKTNTT", account.BIC)
+	}
 }
 
 func TestLogin(t *testing.T) {
@@ -152,8 +267,8 @@ func TestLogin(t *testing.T) {
 	account := createTestAccount(t)
 		// Now test login
 	loginReq := models.LoginRequest{
-		AccountNumber: account.AccountNumber,
-		Password:      "motdepasse123",
+		CustomerID: account.CustomerID,
+		Password:   "motdepasse123",
 	}
 	
 	jsonData, _ := json.Marshal(loginReq)
@@ -187,9 +302,78 @@ func TestLogin(t *testing.T) {
 		t.Error("Login response should contain a token")
 	}
 	
-	if loginData["account_number"] != account.AccountNumber {
-		t.Errorf("Login response account number mismatch: got %v want %v", 
-			loginData["account_number"], account.AccountNumber)
+	if loginData["customer_id"] != account.CustomerID {
+		t.Errorf("Login response customer ID mismatch: got %v want %v", 
+			loginData["customer_id"], account.CustomerID)
+	}
+}
+
+func TestCustomerMultipleAccounts(t *testing.T) {
+	handler := testRouter.SetupRoutes()
+	
+	account := createTestAccount(t)
+	other := createTestAccount(t)
+	token := loginAndGetToken(t, account.AccountNumber)
+	otherToken := loginAndGetToken(t, other.AccountNumber)
+	
+	request := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	
+	accountsPath := "/api/v1/customers/" + account.CustomerID + "/accounts"
+	openBody := `{"account_type": "` + models.AccountTypeSavings + `", "currency": "TND"}`
+	
+	// Customers cannot open accounts for somebody else
+	if rr := request("POST", accountsPath, otherToken, openBody); rr.Code != http.StatusForbidden {
+		t.Errorf("Opening an account for another customer: got %v want %v", rr.Code, http.StatusForbidden)
+	}
+	
+	rr := request("POST", accountsPath, token, openBody)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Open account returned wrong status code: got %v want %v, body %s", rr.Code, http.StatusCreated, rr.Body.String())
+	}
+	
+	var opened struct {
+		Data models.Account `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &opened); err != nil {
+		t.Fatal("Failed to unmarshal open account response:", err)
+	}
+	if opened.Data.CustomerID != account.CustomerID || opened.Data.AccountType != models.AccountTypeSavings {
+		t.Errorf("Unexpected opened account: %+v", opened.Data)
+	}
+	
+	// The same login reaches both accounts
+	rr = request("GET", accountsPath, token, "")
+	var listed struct {
+		Data []models.Account `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &listed); err != nil {
+		t.Fatal("Failed to unmarshal accounts response:", err)
+	}
+	if len(listed.Data) != 2 {
+		t.Errorf("Expected 2 accounts for the customer, got %d", len(listed.Data))
+	}
+	
+	deposit(t, handler, token, opened.Data.AccountNumber, 10000)
+	if balance := getBalance(t, handler, token, opened.Data.AccountNumber); balance != 10000 {
+		t.Errorf("Unexpected balance on the new account: got %d want %d", balance, 10000)
+	}
+	
+	// With several accounts, history needs to name one
+	if rr := request("GET", "/api/v1/transactions/history", token, ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("History without account number: got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+	if rr := request("GET", "/api/v1/transactions/history?account_number="+opened.Data.AccountNumber, token, ""); rr.Code != http.StatusOK {
+		t.Errorf("History of own second account: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if rr := request("GET", "/api/v1/transactions/history?account_number="+opened.Data.AccountNumber, otherToken, ""); rr.Code != http.StatusForbidden {
+		t.Errorf("History of another customer's account: got %v want %v", rr.Code, http.StatusForbidden)
 	}
 }
 
@@ -250,47 +434,2527 @@ func TestTransfer(t *testing.T) {
 	}
 }
 
-func TestGetTransactionHistory(t *testing.T) {
-	// Create test account and login
+func TestTransferByIBAN(t *testing.T) {
+	fromAccount := createTestAccount(t)
+	toAccount := createTestAccount(t)
+	token := loginAndGetToken(t, fromAccount.AccountNumber)
+	handler := testRouter.SetupRoutes()
+	
+	deposit(t, handler, token, fromAccount.AccountNumber, 100000)
+	
+	// Swapping two digits of the account number breaks the IBAN checksum
+	iban := []byte(toAccount.IBAN)
+	iban[10], iban[11] = iban[11], iban[10]
+	if iban[10] == iban[11] {
+		iban[11] = '0' + (iban[11]-'0'+1)%10
+	}
+	
+	code := postTransfer(handler, token, models.TransferRequest{
+		FromAccountNumber: fromAccount.AccountNumber,
+		ToIBAN:            string(iban),
+		Amount:            10000,
+		Currency:          models.CurrencyTND,
+	})
+	if code != http.StatusBadRequest {
+		t.Errorf("Transfer to IBAN with bad checksum: got %v want %v", code, http.StatusBadRequest)
+	}
+	
+	code = postTransfer(handler, token, models.TransferRequest{
+		FromAccountNumber: fromAccount.AccountNumber,
+		ToIBAN:            toAccount.IBAN,
+		Amount:            10000,
+		Currency:          models.CurrencyTND,
+	})
+	if code != http.StatusCreated {
+		t.Fatalf("Transfer by IBAN: got %v want %v", code, http.StatusCreated)
+	}
+	
+	// One of our IBANs stays internal even with another bank's BIC
+	code = postTransfer(handler, token, models.TransferRequest{
+		FromAccountNumber: fromAccount.AccountNumber,
+		ToIBAN:            toAccount.IBAN,
+		ToBIC:             "BIATTNTT",
+		Amount:            10000,
+		Currency:          models.CurrencyTND,
+	})
+	if code != http.StatusCreated {
+		t.Fatalf("Transfer by IBAN with a foreign BIC: got %v want %v", code, http.StatusCreated)
+	}
+	
+	toToken := loginAndGetToken(t, toAccount.AccountNumber)
+	if balance := getBalance(t, handler, toToken, toAccount.AccountNumber); balance != 20000 {
+		t.Errorf("Destination balance after transfers by IBAN: got %d want 20000", balance)
+	}
+}
+
+func TestExternalTransfer(t *testing.T) {
 	account := createTestAccount(t)
 	token := loginAndGetToken(t, account.AccountNumber)
-		// Create some transactions first (deposit)
-	depositReq := models.DepositRequest{
-		AccountNumber: account.AccountNumber,
-		Amount:        100000, // 100 TND
-		Currency:      models.CurrencyTND,
-		Description:   "Dépôt de test",
-	}
+	handler := testRouter.SetupRoutes()
 	
-	jsonData, _ := json.Marshal(depositReq)
-	req, err := http.NewRequest("POST", "/api/v1/transactions/deposit", bytes.NewBuffer(jsonData))
+	deposit(t, handler, token, account.AccountNumber, 100000)
+	
+	// A BIAT beneficiary: the BIC is derived from the RIB's bank code
+	rib, err := models.NewRIB("08", "001", "0000012345678")
 	if err != nil {
 		t.Fatal(err)
 	}
+	beneficiaryIBAN, _ := rib.IBAN()
+	
+	jsonData, _ := json.Marshal(models.TransferRequest{
+		FromAccountNumber: account.AccountNumber,
+		ToIBAN:            beneficiaryIBAN,
+		BeneficiaryName:   "Sami Gharbi",
+		Amount:            10000,
+		Currency:          models.CurrencyTND,
+		Description:       "Loyer",
+	})
+	req, _ := http.NewRequest("POST", "/api/v1/transactions/transfer", bytes.NewBuffer(jsonData))
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+token)
 	
 	rr := httptest.NewRecorder()
-	handler := testRouter.SetupRoutes()
 	handler.ServeHTTP(rr, req)
 	
-	// Now get transaction history
-	req, err = http.NewRequest("GET", "/api/v1/transactions/history", nil)
+	if status := rr.Code; status != http.StatusCreated {
+		t.Fatalf("External transfer returned wrong status code: got %v want %v, body %s", status, http.StatusCreated, rr.Body.String())
+	}
+	
+	var created struct {
+		Data models.Transaction `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatal("Failed to unmarshal transfer response:", err)
+	}
+	
+	if created.Data.Status != models.TransactionStatusPending || created.Data.CounterpartyBIC != "BIATTNTT" {
+		t.Errorf("Unexpected external transfer: status %s, BIC %s", created.Data.Status, created.Data.CounterpartyBIC)
+	}
+	
+	// Amount and fee leave the account as soon as the payment is queued
+	if balance := getBalance(t, handler, token, account.AccountNumber); balance != 100000-10000-created.Data.Fee {
+		t.Errorf("Balance after external transfer: got %d want %d", balance, 100000-10000-created.Data.Fee)
+	}
+	
+	paymentRepo := repository.NewPostgresOutboundPaymentRepository(testDB)
+	clearingService := services.NewClearingService(
+		paymentRepo, repository.NewPostgresTransactionRepository(testDB),
+		repository.NewPostgresAccountRepository(testDB), ledger.NewPostgresLedger(testDB),
+		repository.NewPostgresTxRunner(testDB), services.NewMockGateway(),
+	)
+	if _, err := clearingService.DispatchQueued(50); err != nil {
+		t.Fatal("Failed to dispatch outbound payments:", err)
+	}
+	
+	payment, err := paymentRepo.GetByTransactionID(created.Data.TransactionID)
 	if err != nil {
 		t.Fatal(err)
 	}
+	if payment.Status != models.OutboundPaymentStatusSubmitted {
+		t.Errorf("Outbound payment status after dispatch: got %s want %s", payment.Status, models.OutboundPaymentStatusSubmitted)
+	}
+	
+	callback, _ := json.Marshal(models.ClearingCallback{
+		PaymentID: payment.PaymentID,
+		Status:    models.OutboundPaymentStatusRejected,
+		Reason:    "AC04 compte clôturé",
+	})
+	
+	if code := postClearingCallback(handler, callback, "wrong-secret"); code != http.StatusUnauthorized {
+		t.Errorf("Callback with a bad signature: got %v want %v", code, http.StatusUnauthorized)
+	}
+	if code := postClearingCallback(handler, callback, testConfig.Clearing.CallbackSecret); code != http.StatusOK {
+		t.Fatalf("Signed callback: got %v want %v", code, http.StatusOK)
+	}
+	
+	req, _ = http.NewRequest("GET", "/api/v1/transactions/"+created.Data.TransactionID, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	
+	var fetched struct {
+		Data models.Transaction `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &fetched); err != nil {
+		t.Fatal("Failed to unmarshal transaction response:", err)
+	}
+	
+	if fetched.Data.Status != models.TransactionStatusFailed || fetched.Data.FailureReason != "AC04 compte clôturé" {
+		t.Errorf("Rejected transfer: status %s, failure reason %q", fetched.Data.Status, fetched.Data.FailureReason)
+	}
+	
+	// The rejected amount and its fee are returned
+	if balance := getBalance(t, handler, token, account.AccountNumber); balance != 100000 {
+		t.Errorf("Balance after rejected transfer: got %d want 100000", balance)
+	}
+}
+
+func TestPaymentFileAndCamt053(t *testing.T) {
+	account := createTestAccount(t)
+	beneficiary := createTestAccount(t)
+	token := loginAndGetToken(t, account.AccountNumber)
+	handler := testRouter.SetupRoutes()
+	
+	deposit(t, handler, token, account.AccountNumber, 100000)
+	
+	// One transfer to a customer of the bank, one to a mistyped IBAN
+	painFile := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03">
+  <CstmrCdtTrfInitn>
+    <GrpHdr><MsgId>MSG-%d</MsgId><CreDtTm>2026-01-15T10:00:00</CreDtTm><NbOfTxs>2</NbOfTxs><CtrlSum>35.000</CtrlSum></GrpHdr>
+    <PmtInf>
+      <PmtInfId>SALAIRES</PmtInfId><PmtMtd>TRF</PmtMtd>
+      <DbtrAcct><Id><IBAN>%s</IBAN></Id></DbtrAcct>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>E2E-1</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="TND">25.000</InstdAmt></Amt>
+        <Cdtr><Nm>Amira Ben Salah</Nm></Cdtr>
+        <CdtrAcct><Id><IBAN>%s</IBAN></Id></CdtrAcct>
+        <RmtInf><Ustrd>Salaire janvier</Ustrd></RmtInf>
+      </CdtTrfTxInf>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>E2E-2</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="TND">10.000</InstdAmt></Amt>
+        <Cdtr><Nm>Karim Trabelsi</Nm></Cdtr>
+        <CdtrAcct><Id><IBAN>TN5910001000000000000000</IBAN></Id></CdtrAcct>
+      </CdtTrfTxInf>
+    </PmtInf>
+  </CstmrCdtTrfInitn>
+</Document>`, time.Now().UnixNano(), account.IBAN, beneficiary.IBAN)
+	
+	req, _ := http.NewRequest("POST", "/api/v1/accounts/"+account.AccountNumber+"/payment-files", bytes.NewBufferString(painFile))
+	req.Header.Set("Content-Type", "application/xml")
+	req.Header.Set("Authorization", "Bearer "+token)
+	
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("Payment file upload returned wrong status code: got %v want %v, body %s", status, http.StatusOK, rr.Body.String())
+	}
+	
+	var uploaded struct {
+		Data models.PaymentFileResponse `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &uploaded); err != nil {
+		t.Fatal("Failed to unmarshal payment file response:", err)
+	}
+	
+	if uploaded.Data.Total != 2 || uploaded.Data.Accepted != 1 || uploaded.Data.Rejected != 1 {
+		t.Fatalf("Payment file counts: total %d, accepted %d, rejected %d", uploaded.Data.Total, uploaded.Data.Accepted, uploaded.Data.Rejected)
+	}
+	if item := uploaded.Data.Items[0]; item.Status != models.TransactionStatusCompleted || item.TransactionID == "" {
+		t.Errorf("First payment file item: status %s, transaction %q", item.Status, item.TransactionID)
+	}
+	if item := uploaded.Data.Items[1]; item.Status != services.PaymentFileItemRejected || len(item.Errors) == 0 {
+		t.Errorf("Second payment file item: status %s, errors %v", item.Status, item.Errors)
+	}
+	
+	beneficiaryToken := loginAndGetToken(t, beneficiary.AccountNumber)
+	if balance := getBalance(t, handler, beneficiaryToken, beneficiary.AccountNumber); balance != 25000 {
+		t.Errorf("Beneficiary balance after payment file: got %d want 25000", balance)
+	}
+	
+	today := time.Now().UTC().Format("2006-01-02")
+	req, _ = http.NewRequest("GET", "/api/v1/accounts/"+account.AccountNumber+"/statements/camt053?from="+today+"&to="+today, nil)
 	req.Header.Set("Authorization", "Bearer "+token)
 	
 	rr = httptest.NewRecorder()
 	handler.ServeHTTP(rr, req)
 	
 	if status := rr.Code; status != http.StatusOK {
-		t.Errorf("Get transaction history returned wrong status code: got %v want %v", status, http.StatusOK)
-		t.Errorf("Response body: %s", rr.Body.String())
+		t.Fatalf("camt.053 download returned wrong status code: got %v want %v, body %s", status, http.StatusOK, rr.Body.String())
+	}
+	if contentType := rr.Header().Get("Content-Type"); contentType != "application/xml" {
+		t.Errorf("camt.053 content type: got %s want application/xml", contentType)
+	}
+	
+	statement := rr.Body.String()
+	for _, want := range []string{"camt.053.001.02", account.IBAN, "Salaire janvier", "<Cd>CLBD</Cd>"} {
+		if !strings.Contains(statement, want) {
+			t.Errorf("camt.053 statement does not contain %q", want)
+		}
+	}
+	
+	// The statement belongs to the account owner only
+	req, _ = http.NewRequest("GET", "/api/v1/accounts/"+account.AccountNumber+"/statements/camt053?from="+today+"&to="+today, nil)
+	req.Header.Set("Authorization", "Bearer "+beneficiaryToken)
+	
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	
+	if status := rr.Code; status != http.StatusForbidden {
+		t.Errorf("camt.053 download by another customer: got %v want %v", status, http.StatusForbidden)
 	}
 }
 
-// Helper functions
+func TestAccountStatement(t *testing.T) {
+	account := createTestAccount(t)
+	payee := createTestAccount(t)
+	token := loginAndGetToken(t, account.AccountNumber)
+	handler := testRouter.SetupRoutes()
+	
+	deposit(t, handler, token, account.AccountNumber, 100000)
+	if code := transfer(handler, token, account.AccountNumber, payee.AccountNumber, 30000); code != http.StatusCreated {
+		t.Fatalf("Transfer returned wrong status code: got %v want %v", code, http.StatusCreated)
+	}
+	
+	today := time.Now().UTC().Format("2006-01-02")
+	path := "/api/v1/accounts/" + account.AccountNumber + "/statements?from=" + today + "&to=" + today
+	
+	req, _ := http.NewRequest("GET", path, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("Statement returned wrong status code: got %v want %v, body %s", status, http.StatusOK, rr.Body.String())
+	}
+	
+	var response struct {
+		Data models.Statement `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatal("Failed to unmarshal statement response:", err)
+	}
+	
+	statement := response.Data
+	if statement.OpeningBalance != 0 || len(statement.Lines) != 3 {
+		t.Fatalf("Statement: opening balance %d, %d lines", statement.OpeningBalance, len(statement.Lines))
+	}
+	if line := statement.Lines[0]; line.Credit != 100000 || line.Balance != 100000 {
+		t.Errorf("Deposit line: credit %d, balance %d", line.Credit, line.Balance)
+	}
+	if line := statement.Lines[1]; line.Debit != 30000 || line.Fee != 0 || line.Balance != 70000 {
+		t.Errorf("Transfer line: debit %d, fee %d, balance %d", line.Debit, line.Fee, line.Balance)
+	}
+	if line := statement.Lines[2]; line.TransactionType != models.TransactionTypeFee || line.Fee <= 0 || line.Balance != 70000-line.Fee {
+		t.Errorf("Fee line: type %s, fee %d, balance %d", line.TransactionType, line.Fee, line.Balance)
+	}
+	if statement.TotalCredits != 100000 || statement.TotalDebits != 30000 ||
+		statement.ClosingBalance != statement.OpeningBalance+statement.TotalCredits-statement.TotalDebits-statement.TotalFees {
+		t.Errorf("Statement totals: credits %d, debits %d, fees %d, closing %d",
+			statement.TotalCredits, statement.TotalDebits, statement.TotalFees, statement.ClosingBalance)
+	}
+	if balance := getBalance(t, handler, token, account.AccountNumber); statement.ClosingBalance != balance {
+		t.Errorf("Statement closing balance: got %d want %d", statement.ClosingBalance, balance)
+	}
+	
+	documents := []struct {
+		format      string
+		contentType string
+	}{
+		{"csv", "text/csv; charset=utf-8"},
+		{"pdf", "application/pdf"},
+	}
+	for _, document := range documents {
+		format, contentType := document.format, document.contentType
+		req, _ = http.NewRequest("GET", path+"&format="+format, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("%s statement returned wrong status code: got %v want %v", format, status, http.StatusOK)
+		}
+		if got := rr.Header().Get("Content-Type"); got != contentType {
+			t.Errorf("%s statement content type: got %s want %s", format, got, contentType)
+		}
+	}
+	
+	// The last document requested was the PDF
+	if !bytes.HasPrefix(rr.Body.Bytes(), []byte("%PDF-")) {
+		t.Error("PDF statement does not start with a PDF header")
+	}
+}
+
+func TestFeeSchedule(t *testing.T) {
+	account := createTestAccount(t)
+	payee := createTestAccount(t)
+	token := loginAndGetToken(t, account.AccountNumber)
+	handler := testRouter.SetupRoutes()
+	
+	// Price savings transfers with a schedule of their own, so that other
+	// tests keep the default fees
+	if _, err := testDB.Exec("UPDATE accounts SET account_type = $1 WHERE account_number = $2", models.AccountTypeSavings, account.AccountNumber); err != nil {
+		t.Fatal(err)
+	}
+	feeService := services.NewFeeService(
+		repository.NewPostgresFeeScheduleRepository(testDB), repository.NewPostgresAccountRepository(testDB),
+		repository.NewPostgresTransactionRepository(testDB),
+	)
+	scheduleReq := &models.FeeScheduleRequest{
+		AccountType:     models.AccountTypeSavings,
+		TransactionType: models.TransactionTypeTransfer,
+		Tiers: []models.FeeTier{
+			{UpTo: 50000, FlatFee: 50},
+			{PercentageBps: 100},
+		},
+		MaxFee:       1000,
+		FreePerMonth: 1,
+	}
+	schedule, err := feeService.CreateSchedule(scheduleReq)
+	if err != nil {
+		t.Fatal("Failed to create fee schedule:", err)
+	}
+	defer feeService.DeactivateSchedule(schedule.ID)
+	
+	if _, err := feeService.CreateSchedule(scheduleReq); err == nil {
+		t.Error("Created a second active schedule with the same key")
+	}
+	
+	deposit(t, handler, token, account.AccountNumber, 100000)
+	
+	quote := func(amount int64) models.FeeQuote {
+		path := fmt.Sprintf("/api/v1/fees/quote?account_number=%s&transaction_type=TRANSFER&amount=%d", account.AccountNumber, amount)
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("Fee quote returned wrong status code: got %v want %v, body %s", status, http.StatusOK, rr.Body.String())
+		}
+		
+		var response struct {
+			Data models.FeeQuote `json:"data"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatal("Failed to unmarshal fee quote response:", err)
+		}
+		return response.Data
+	}
+	
+	// The first transfer of the month is free
+	if q := quote(30000); q.Fee != 0 || q.FreeRemaining != 1 || q.ScheduleID != schedule.ID {
+		t.Errorf("First quote: fee %d, free remaining %d, schedule %d", q.Fee, q.FreeRemaining, q.ScheduleID)
+	}
+	if code := transfer(handler, token, account.AccountNumber, payee.AccountNumber, 30000); code != http.StatusCreated {
+		t.Fatalf("Transfer returned wrong status code: got %v want %v", code, http.StatusCreated)
+	}
+	if balance := getBalance(t, handler, token, account.AccountNumber); balance != 70000 {
+		t.Errorf("Balance after free transfer: got %d want 70000", balance)
+	}
+	
+	// Then the tier of the amount applies, within the cap
+	if q := quote(30000); q.Fee != 50 || q.Total != 30050 {
+		t.Errorf("Quote in the first tier: fee %d, total %d", q.Fee, q.Total)
+	}
+	if q := quote(60000); q.Fee != 600 {
+		t.Errorf("Quote in the second tier: got fee %d want 600", q.Fee)
+	}
+	if q := quote(200000); q.Fee != 1000 {
+		t.Errorf("Quote over the cap: got fee %d want 1000", q.Fee)
+	}
+	
+	if code := transfer(handler, token, account.AccountNumber, payee.AccountNumber, 30000); code != http.StatusCreated {
+		t.Fatalf("Transfer returned wrong status code: got %v want %v", code, http.StatusCreated)
+	}
+	if balance := getBalance(t, handler, token, account.AccountNumber); balance != 39950 {
+		t.Errorf("Balance after charged transfer: got %d want 39950", balance)
+	}
+	
+	// The fee is a FEE transaction of its own, linked to the transfer
+	var feeAmount, parentFee int64
+	err = testDB.QueryRow(`
+		SELECT f.amount, p.fee
+		FROM transactions f JOIN transactions p ON p.transaction_id = f.parent_transaction_id
+		WHERE f.from_account_number = $1 AND f.transaction_type = $2`,
+		account.AccountNumber, models.TransactionTypeFee,
+	).Scan(&feeAmount, &parentFee)
+	if err != nil {
+		t.Fatal("Fee transaction not found:", err)
+	}
+	if feeAmount != 50 || parentFee != 50 {
+		t.Errorf("Fee transaction: amount %d, fee on the transfer %d", feeAmount, parentFee)
+	}
+}
+
+func TestTransactionLimits(t *testing.T) {
+	account := createTestAccount(t)
+	payee := createTestAccount(t)
+	token := loginAndGetToken(t, account.AccountNumber)
+	handler := testRouter.SetupRoutes()
+	
+	deposit(t, handler, token, account.AccountNumber, 500000)
+	
+	request := func(method string, body interface{}) *httptest.ResponseRecorder {
+		jsonData, _ := json.Marshal(body)
+		req, _ := http.NewRequest(method, "/api/v1/accounts/"+account.AccountNumber+"/limits", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	status := func(rr *httptest.ResponseRecorder) models.LimitStatus {
+		var response struct {
+			Data models.LimitStatus `json:"data"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatal("Failed to unmarshal limits response:", err)
+		}
+		return response.Data
+	}
+	
+	// The customer lowers their own daily limit
+	dailyMax := int64(100000)
+	rr := request("PUT", models.LimitsRequest{DailyMax: &dailyMax})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Lowering the daily limit returned wrong status code: got %v want %v, body %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if limits := status(rr); limits.Limits.DailyMax != dailyMax || !limits.Customized {
+		t.Errorf("Limits after lowering: %+v", limits.Limits)
+	}
+	
+	if code := transfer(handler, token, account.AccountNumber, payee.AccountNumber, 60000); code != http.StatusCreated {
+		t.Fatalf("Transfer within the limit returned wrong status code: got %v want %v", code, http.StatusCreated)
+	}
+	
+	// The next transfer would go over the daily total
+	jsonData, _ := json.Marshal(models.TransferRequest{
+		FromAccountNumber: account.AccountNumber,
+		ToAccountNumber:   payee.AccountNumber,
+		Amount:            50000,
+		Currency:          models.CurrencyTND,
+	})
+	req, _ := http.NewRequest("POST", "/api/v1/transactions/transfer", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("Transfer over the daily limit returned wrong status code: got %v want %v, body %s", rr.Code, http.StatusUnprocessableEntity, rr.Body.String())
+	}
+	var errorResponse models.ErrorResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &errorResponse); err != nil {
+		t.Fatal("Failed to unmarshal error response:", err)
+	}
+	if errorResponse.Code != models.ErrCodeLimitExceeded {
+		t.Errorf("Error code: got %q want %q", errorResponse.Code, models.ErrCodeLimitExceeded)
+	}
+	
+	// The headroom left is what remains of the daily limit
+	rr = request("GET", nil)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Get limits returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	limits := status(rr)
+	if limits.Used.Daily != 60000 || limits.Remaining.Daily == nil || *limits.Remaining.Daily != 40000 {
+		t.Errorf("Daily usage %d, remaining %v", limits.Used.Daily, limits.Remaining.Daily)
+	}
+	if limits.Remaining.Single == nil || *limits.Remaining.Single != 40000 {
+		t.Errorf("Largest transaction allowed: got %v want 40000", limits.Remaining.Single)
+	}
+	
+	// Raising the limit back requires an admin, as does resetting it
+	raised := int64(200000)
+	if rr := request("PUT", models.LimitsRequest{DailyMax: &raised}); rr.Code != http.StatusForbidden {
+		t.Errorf("Customer raising a limit: got %v want %v", rr.Code, http.StatusForbidden)
+	}
+	if rr := request("DELETE", nil); rr.Code != http.StatusForbidden {
+		t.Errorf("Customer resetting limits: got %v want %v", rr.Code, http.StatusForbidden)
+	}
+	
+	// Limits are kept per currency: the dinar limit set above does not touch
+	// the customer's euro accounts, which have defaults in cents
+	limitRepo := repository.NewPostgresLimitRepository(testDB)
+	own, err := limitRepo.GetCustomerLimits(account.CustomerID, models.AccountTypeChecking, models.CurrencyEUR)
+	if err != nil || own != nil {
+		t.Errorf("Customer limits on euro accounts: got %+v, %v want none", own, err)
+	}
+	euroDefaults, err := limitRepo.GetDefault(models.AccountTypeChecking, models.CurrencyEUR)
+	if err != nil {
+		t.Fatal("Failed to get euro defaults:", err)
+	}
+	if euroDefaults.DailyMax != 600000 {
+		t.Errorf("Euro daily limit of current accounts: got %d want 600000", euroDefaults.DailyMax)
+	}
+}
+
+func TestOverdraft(t *testing.T) {
+	account := createTestAccount(t)
+	payee := createTestAccount(t)
+	token := loginAndGetToken(t, account.AccountNumber)
+	handler := testRouter.SetupRoutes()
+	
+	deposit(t, handler, token, account.AccountNumber, 10000)
+	
+	// The customer asks for an overdraft; it does not apply until approved
+	jsonData, _ := json.Marshal(models.OverdraftRequest{Limit: 50000, Reason: "Fin de mois"})
+	req, _ := http.NewRequest("POST", "/api/v1/accounts/"+account.AccountNumber+"/overdraft", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	
+	if status := rr.Code; status != http.StatusCreated {
+		t.Fatalf("Overdraft request returned wrong status code: got %v want %v, body %s", status, http.StatusCreated, rr.Body.String())
+	}
+	var requested struct {
+		Data models.OverdraftFacility `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &requested); err != nil {
+		t.Fatal("Failed to unmarshal overdraft response:", err)
+	}
+	if requested.Data.Status != models.OverdraftStatusPending {
+		t.Errorf("Overdraft request status: got %s want %s", requested.Data.Status, models.OverdraftStatusPending)
+	}
+	
+	if code := transfer(handler, token, account.AccountNumber, payee.AccountNumber, 30000); code != http.StatusBadRequest {
+		t.Errorf("Transfer before approval: got %v want %v", code, http.StatusBadRequest)
+	}
+	
+	// An admin approves a lower limit at the default rate of 12% a year
+	overdraftService := services.NewOverdraftService(
+		repository.NewPostgresOverdraftRepository(testDB), repository.NewPostgresAccountRepository(testDB),
+		repository.NewPostgresTransactionRepository(testDB), ledger.NewPostgresLedger(testDB),
+		repository.NewPostgresTxRunner(testDB), 1200, 365,
+	)
+	facility, err := overdraftService.ApproveOverdraft(requested.Data.ID, &models.OverdraftDecisionRequest{Limit: 40000}, "STAFF-TEST")
+	if err != nil {
+		t.Fatal("Failed to approve overdraft:", err)
+	}
+	if facility.ApprovedLimit != 40000 || facility.RateBps != 1200 {
+		t.Errorf("Approved facility: limit %d, rate %d", facility.ApprovedLimit, facility.RateBps)
+	}
+	
+	// The transfer and its fee now overdraw the account
+	if code := transfer(handler, token, account.AccountNumber, payee.AccountNumber, 30000); code != http.StatusCreated {
+		t.Fatalf("Transfer within the overdraft returned wrong status code: got %v want %v", code, http.StatusCreated)
+	}
+	balance := getBalanceResponse(t, handler, token, account.AccountNumber)
+	if balance.Balance > -20000 || balance.OverdraftLimit != 40000 ||
+		balance.OverdraftUsed != -balance.Balance || balance.OverdraftRemaining != 40000+balance.Balance {
+		t.Errorf("Balance in overdraft: balance %d, limit %d, used %d, remaining %d",
+			balance.Balance, balance.OverdraftLimit, balance.OverdraftUsed, balance.OverdraftRemaining)
+	}
+	if code := transfer(handler, token, account.AccountNumber, payee.AccountNumber, balance.OverdraftRemaining+1); code != http.StatusBadRequest {
+		t.Errorf("Transfer beyond the overdraft: got %v want %v", code, http.StatusBadRequest)
+	}
+	
+	// Accrue the last day of the previous month as if the account had been
+	// overdrawn then, and charge it
+	monthStart := models.InterestPeriodStart(time.Now().UTC(), models.InterestCapitalizationMonthly)
+	businessDate := monthStart.AddDate(0, 0, -1)
+	opened := businessDate.Add(-time.Hour)
+	if _, err := testDB.Exec("UPDATE accounts SET created_at = $1 WHERE id = $2", opened, account.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testDB.Exec("UPDATE postings SET created_at = $1 WHERE account_id = $2", opened, account.ID); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		accrued, err := overdraftService.AccrueInterest(businessDate, 100)
+		if err != nil {
+			t.Fatal("Failed to accrue debit interest:", err)
+		}
+		if accrued < 100 {
+			break
+		}
+	}
+	if _, err := overdraftService.ChargeInterest(time.Now().UTC(), 100); err != nil {
+		t.Fatal("Failed to charge debit interest:", err)
+	}
+	
+	expected := models.RoundInterest(models.OverdraftInterest(balance.Balance, 1200, 365))
+	var charged int64
+	err = testDB.QueryRow(
+		`SELECT amount FROM transactions WHERE from_account_number = $1 AND transaction_type = $2`,
+		account.AccountNumber, models.TransactionTypeDebitInterest,
+	).Scan(&charged)
+	if err != nil {
+		t.Fatal("Debit interest transaction not found:", err)
+	}
+	if charged != expected || charged <= 0 {
+		t.Errorf("Debit interest: got %d want %d", charged, expected)
+	}
+	if got := getBalance(t, handler, token, account.AccountNumber); got != balance.Balance-charged {
+		t.Errorf("Balance after debit interest: got %d want %d", got, balance.Balance-charged)
+	}
+	
+	// Once revoked, the overdraft allows no new debits
+	if _, err := overdraftService.RevokeOverdraft(facility.ID, nil, "STAFF-TEST"); err != nil {
+		t.Fatal("Failed to revoke overdraft:", err)
+	}
+	if balance := getBalanceResponse(t, handler, token, account.AccountNumber); balance.OverdraftLimit != 0 || balance.OverdraftRemaining != 0 {
+		t.Errorf("Revoked overdraft: limit %d, remaining %d", balance.OverdraftLimit, balance.OverdraftRemaining)
+	}
+	if code := transfer(handler, token, account.AccountNumber, payee.AccountNumber, 1000); code != http.StatusBadRequest {
+		t.Errorf("Transfer after revocation: got %v want %v", code, http.StatusBadRequest)
+	}
+}
+
+func TestInterestAccrual(t *testing.T) {
+	account := createTestAccount(t)
+	token := loginAndGetToken(t, account.AccountNumber)
+	handler := testRouter.SetupRoutes()
+	
+	if _, err := testDB.Exec("UPDATE accounts SET account_type = $1 WHERE account_number = $2", models.AccountTypeSavings, account.AccountNumber); err != nil {
+		t.Fatal(err)
+	}
+	deposit(t, handler, token, account.AccountNumber, 1000000)
+	
+	// Accrue the last two days of the previous quarter, with the account and
+	// its deposit moved back before them
+	now := time.Now().UTC()
+	periodStart := models.InterestPeriodStart(now, models.InterestCapitalizationQuarterly)
+	first, last := periodStart.AddDate(0, 0, -2), periodStart.AddDate(0, 0, -1)
+	opened := first.Add(-time.Hour)
+	if _, err := testDB.Exec("UPDATE accounts SET created_at = $1 WHERE id = $2", opened, account.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := testDB.Exec("UPDATE postings SET created_at = $1 WHERE account_id = $2", opened, account.ID); err != nil {
+		t.Fatal(err)
+	}
+	
+	interestService := services.NewInterestService(
+		repository.NewPostgresInterestRepository(testDB), repository.NewPostgresAccountRepository(testDB),
+		repository.NewPostgresTransactionRepository(testDB), ledger.NewPostgresLedger(testDB),
+		repository.NewPostgresTxRunner(testDB), models.InterestCapitalizationQuarterly, 2000, 365,
+	)
+	for _, date := range []time.Time{first, last} {
+		if _, err := interestService.RunAccrual(date); err != nil {
+			t.Fatal("Failed to accrue interest:", err)
+		}
+		// Running a business date again accrues nothing
+		if accrued, err := interestService.RunAccrual(date); err != nil || accrued != 0 {
+			t.Errorf("Second accrual run for %s: accrued %d accounts, error %v", date.Format("2006-01-02"), accrued, err)
+		}
+	}
+	if _, err := interestService.RunAccrual(now); err == nil {
+		t.Error("Accrued interest for a business date that has not ended")
+	}
+	
+	report := func() models.InterestAccrualSummary {
+		path := fmt.Sprintf("/api/v1/interest/accruals?account_number=%s&from=%s&to=%s",
+			account.AccountNumber, first.Format("2006-01-02"), last.Format("2006-01-02"))
+		req, _ := http.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		
+		if status := rr.Code; status != http.StatusOK {
+			t.Fatalf("Accrual report returned wrong status code: got %v want %v, body %s", status, http.StatusOK, rr.Body.String())
+		}
+		
+		var response struct {
+			Data models.InterestAccrualReport `json:"data"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatal("Failed to unmarshal accrual report response:", err)
+		}
+		if len(response.Data.Accounts) != 1 {
+			t.Fatalf("Accrual report: got %d accounts want 1", len(response.Data.Accounts))
+		}
+		return *response.Data.Accounts[0]
+	}
+	
+	// 1000 TND at 5% a year: 136.986 millimes a day
+	if summary := report(); summary.Days != 2 || summary.AverageBalance != 1000000 || summary.Accrued != 274 || summary.Capitalized != 0 {
+		t.Errorf("Accrual report: %d days, average balance %d, accrued %d, capitalized %d",
+			summary.Days, summary.AverageBalance, summary.Accrued, summary.Capitalized)
+	}
+	
+	// The quarter has ended: 274 millimes are credited less 20% withheld
+	if _, err := interestService.CapitalizeInterest(now, 100); err != nil {
+		t.Fatal("Failed to capitalize interest:", err)
+	}
+	if balance := getBalance(t, handler, token, account.AccountNumber); balance != 1000219 {
+		t.Errorf("Balance after capitalization: got %d want 1000219", balance)
+	}
+	if summary := report(); summary.Capitalized != 274 {
+		t.Errorf("Capitalized interest: got %d want 274", summary.Capitalized)
+	}
+	
+	var interest, tax int64
+	err := testDB.QueryRow(`
+		SELECT p.amount, w.amount
+		FROM transactions w JOIN transactions p ON p.transaction_id = w.parent_transaction_id
+		WHERE w.from_account_number = $1 AND w.transaction_type = $2 AND p.transaction_type = $3`,
+		account.AccountNumber, models.TransactionTypeWithholding, models.TransactionTypeInterest,
+	).Scan(&interest, &tax)
+	if err != nil {
+		t.Fatal("Withholding tax transaction not found:", err)
+	}
+	if interest != 274 || tax != 55 {
+		t.Errorf("Interest transactions: interest %d, withholding tax %d", interest, tax)
+	}
+}
+
+func TestStandingOrder(t *testing.T) {
+	account := createTestAccount(t)
+	payee := createTestAccount(t)
+	token := loginAndGetToken(t, account.AccountNumber)
+	handler := testRouter.SetupRoutes()
+	
+	// A monthly order starting today is due at once
+	jsonData, _ := json.Marshal(models.CreateStandingOrderRequest{
+		FromAccountNumber: account.AccountNumber,
+		ToAccountNumber:   payee.AccountNumber,
+		Amount:            20000,
+		Currency:          models.CurrencyTND,
+		Description:       "Loyer",
+		Frequency:         models.StandingOrderMonthly,
+		StartDate:         time.Now().UTC().Format("2006-01-02"),
+		MaxOccurrences:    2,
+	})
+	req, _ := http.NewRequest("POST", "/api/v1/standing-orders", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	
+	if status := rr.Code; status != http.StatusCreated {
+		t.Fatalf("Create standing order returned wrong status code: got %v want %v, body %s", status, http.StatusCreated, rr.Body.String())
+	}
+	
+	var created struct {
+		Data models.StandingOrder `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatal("Failed to unmarshal standing order response:", err)
+	}
+	orderID := created.Data.OrderID
+	
+	orderRepo := repository.NewPostgresStandingOrderRepository(testDB)
+	standingOrderService := services.NewStandingOrderService(
+		orderRepo, repository.NewPostgresAccountRepository(testDB), repository.NewPostgresTransactionRepository(testDB),
+		newTransactionService(), repository.NewPostgresTxRunner(testDB), 3, time.Hour,
+	)
+	
+	// The account is empty: the occurrence is retried later
+	if _, err := standingOrderService.ExecuteDue(100); err != nil {
+		t.Fatal("Failed to execute standing orders:", err)
+	}
+	order, err := orderRepo.GetByOrderID(orderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.RetryCount != 1 || order.Occurrences != 0 || !order.NextAttemptAt.After(time.Now()) {
+		t.Errorf("Order after insufficient funds: retry count %d, occurrences %d, next attempt %v", order.RetryCount, order.Occurrences, order.NextAttemptAt)
+	}
+	
+	deposit(t, handler, token, account.AccountNumber, 100000)
+	if _, err := testDB.Exec("UPDATE standing_orders SET next_attempt_at = NOW() WHERE order_id = $1", orderID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := standingOrderService.ExecuteDue(100); err != nil {
+		t.Fatal("Failed to execute standing orders:", err)
+	}
+	
+	order, err = orderRepo.GetByOrderID(orderID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != models.StandingOrderStatusActive || order.Occurrences != 1 || order.RetryCount != 0 || !order.NextRunDate.After(order.StartDate) {
+		t.Errorf("Order after execution: status %s, occurrences %d, retry count %d, next run %v", order.Status, order.Occurrences, order.RetryCount, order.NextRunDate)
+	}
+	
+	// Running the job again does not execute the occurrence twice
+	if _, err := standingOrderService.ExecuteDue(100); err != nil {
+		t.Fatal("Failed to execute standing orders:", err)
+	}
+	payeeToken := loginAndGetToken(t, payee.AccountNumber)
+	if balance := getBalance(t, handler, payeeToken, payee.AccountNumber); balance != 20000 {
+		t.Errorf("Payee balance: got %d want 20000", balance)
+	}
+	
+	req, _ = http.NewRequest("GET", "/api/v1/standing-orders/"+orderID+"/executions", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	
+	var executions struct {
+		Data []models.StandingOrderExecution `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &executions); err != nil {
+		t.Fatal("Failed to unmarshal executions response:", err)
+	}
+	if len(executions.Data) != 2 {
+		t.Fatalf("Executions: got %d want 2", len(executions.Data))
+	}
+	if latest := executions.Data[0]; latest.Status != models.ExecutionStatusCompleted || latest.Attempt != 2 {
+		t.Errorf("Latest execution: status %s, attempt %d", latest.Status, latest.Attempt)
+	}
+	if first := executions.Data[1]; first.Status != models.ExecutionStatusFailed || first.Error == "" {
+		t.Errorf("First execution: status %s, error %q", first.Status, first.Error)
+	}
+	
+	// The payee cannot manage the payer's order
+	req, _ = http.NewRequest("POST", "/api/v1/standing-orders/"+orderID+"/cancel", nil)
+	req.Header.Set("Authorization", "Bearer "+payeeToken)
+	
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	
+	if status := rr.Code; status != http.StatusForbidden {
+		t.Errorf("Cancel by another customer returned wrong status code: got %v want %v", status, http.StatusForbidden)
+	}
+	
+	steps := []struct {
+		action string
+		code   int
+		status string
+	}{
+		{"pause", http.StatusOK, models.StandingOrderStatusPaused},
+		{"resume", http.StatusOK, models.StandingOrderStatusActive},
+		{"cancel", http.StatusOK, models.StandingOrderStatusCancelled},
+		{"resume", http.StatusBadRequest, ""},
+	}
+	for _, step := range steps {
+		req, _ = http.NewRequest("POST", "/api/v1/standing-orders/"+orderID+"/"+step.action, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		
+		rr = httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		
+		if rr.Code != step.code {
+			t.Fatalf("%s returned wrong status code: got %v want %v, body %s", step.action, rr.Code, step.code, rr.Body.String())
+		}
+		if step.status == "" {
+			continue
+		}
+		
+		var changed struct {
+			Data models.StandingOrder `json:"data"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &changed); err != nil {
+			t.Fatal("Failed to unmarshal standing order response:", err)
+		}
+		if changed.Data.Status != step.status {
+			t.Errorf("Status after %s: got %s want %s", step.action, changed.Data.Status, step.status)
+		}
+	}
+}
+
+func TestProcessPendingTransactions(t *testing.T) {
+	account := createTestAccount(t)
+	token := loginAndGetToken(t, account.AccountNumber)
+	handler := testRouter.SetupRoutes()
+	
+	deposit(t, handler, token, account.AccountNumber, 50000)
+	
+	transactionRepo := repository.NewPostgresTransactionRepository(testDB)
+	transactionService := newTransactionService()
+	policy := services.RetryPolicy{MaxAttempts: 2, Backoff: time.Hour}
+	
+	// A pending deposit is booked; a pending withdrawal over the balance is
+	// retried, then given up on
+	now := time.Now().UTC()
+	pendingDeposit := &models.Transaction{
+		TransactionID:   fmt.Sprintf("TXNPENDDEP%d", now.UnixNano()),
+		ToAccountID:     account.ID,
+		ToAccountNumber: account.AccountNumber,
+		Amount:          20000,
+		Currency:        models.CurrencyTND,
+		ExchangeRate:    1.0,
+		ConvertedAmount: 20000,
+		TransactionType: models.TransactionTypeDeposit,
+		Status:          models.TransactionStatusPending,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	pendingWithdrawal := &models.Transaction{
+		TransactionID:     fmt.Sprintf("TXNPENDWDR%d", now.UnixNano()),
+		FromAccountID:     account.ID,
+		FromAccountNumber: account.AccountNumber,
+		Amount:            500000,
+		Currency:          models.CurrencyTND,
+		ExchangeRate:      1.0,
+		ConvertedAmount:   500000,
+		TransactionType:   models.TransactionTypeWithdrawal,
+		Status:            models.TransactionStatusPending,
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+	for _, transaction := range []*models.Transaction{pendingDeposit, pendingWithdrawal} {
+		if err := transactionRepo.Create(transaction); err != nil {
+			t.Fatal("Failed to create pending transaction:", err)
+		}
+	}
+	
+	queue, err := transactionService.PendingQueue()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if queue.Depth < 2 {
+		t.Errorf("Pending queue depth: got %d want at least 2", queue.Depth)
+	}
+	
+	if _, err := transactionService.ProcessPendingTransactions(100, policy); err != nil {
+		t.Fatal("Failed to process pending transactions:", err)
+	}
+	
+	processed, err := transactionRepo.GetByTransactionID(pendingDeposit.TransactionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if processed.Status != models.TransactionStatusCompleted {
+		t.Errorf("Pending deposit status: got %s want %s", processed.Status, models.TransactionStatusCompleted)
+	}
+	if balance := getBalance(t, handler, token, account.AccountNumber); balance != 70000 {
+		t.Errorf("Balance after pending deposit: got %d want 70000", balance)
+	}
+	
+	retried, err := transactionRepo.GetByTransactionID(pendingWithdrawal.TransactionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retried.Status != models.TransactionStatusPending || retried.Attempts != 1 || retried.FailureReason == "" {
+		t.Errorf("Withdrawal after one attempt: status %s, attempts %d, failure reason %q", retried.Status, retried.Attempts, retried.FailureReason)
+	}
+	
+	if _, err := testDB.Exec("UPDATE transactions SET next_attempt_at = NOW() WHERE transaction_id = $1", pendingWithdrawal.TransactionID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := transactionService.ProcessPendingTransactions(100, policy); err != nil {
+		t.Fatal("Failed to process pending transactions:", err)
+	}
+	
+	failed, err := transactionRepo.GetByTransactionID(pendingWithdrawal.TransactionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failed.Status != models.TransactionStatusFailed || failed.Attempts != 2 || failed.FailureReason != "insufficient balance" {
+		t.Errorf("Withdrawal after its last attempt: status %s, attempts %d, failure reason %q", failed.Status, failed.Attempts, failed.FailureReason)
+	}
+	if balance := getBalance(t, handler, token, account.AccountNumber); balance != 70000 {
+		t.Errorf("Balance after failed withdrawal: got %d want 70000", balance)
+	}
+}
+
+func TestGetTransactionHistory(t *testing.T) {
+	// Create test account and login
+	account := createTestAccount(t)
+	token := loginAndGetToken(t, account.AccountNumber)
+		// Create some transactions first (deposit)
+	depositReq := models.DepositRequest{
+		AccountNumber: account.AccountNumber,
+		Amount:        100000, // 100 TND
+		Currency:      models.CurrencyTND,
+		Description:   "Dépôt de test",
+	}
+	
+	jsonData, _ := json.Marshal(depositReq)
+	req, err := http.NewRequest("POST", "/api/v1/transactions/deposit", bytes.NewBuffer(jsonData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	
+	rr := httptest.NewRecorder()
+	handler := testRouter.SetupRoutes()
+	handler.ServeHTTP(rr, req)
+	
+	// Now get transaction history
+	req, err = http.NewRequest("GET", "/api/v1/transactions/history", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	
+	if status := rr.Code; status != http.StatusOK {
+		t.Errorf("Get transaction history returned wrong status code: got %v want %v", status, http.StatusOK)
+		t.Errorf("Response body: %s", rr.Body.String())
+	}
+}
+
+func TestConcurrentTransfers(t *testing.T) {
+	accountA := createTestAccount(t)
+	accountB := createTestAccount(t)
+	tokenA := loginAndGetToken(t, accountA.AccountNumber)
+	tokenB := loginAndGetToken(t, accountB.AccountNumber)
+	handler := testRouter.SetupRoutes()
+	
+	deposit(t, handler, tokenA, accountA.AccountNumber, 100000)
+	deposit(t, handler, tokenB, accountB.AccountNumber, 100000)
+	
+	// Fire transfers in both directions at once; more are attempted than
+	// either account can afford, so some must be rejected
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			transfer(handler, tokenA, accountA.AccountNumber, accountB.AccountNumber, 15000)
+		}()
+		go func() {
+			defer wg.Done()
+			transfer(handler, tokenB, accountB.AccountNumber, accountA.AccountNumber, 15000)
+		}()
+	}
+	wg.Wait()
+	
+	balanceA := getBalance(t, handler, tokenA, accountA.AccountNumber)
+	balanceB := getBalance(t, handler, tokenB, accountB.AccountNumber)
+	
+	if balanceA < 0 || balanceB < 0 {
+		t.Fatalf("Balances went negative: %d, %d", balanceA, balanceB)
+	}
+	
+	// Every completed transfer moves money between the two accounts and
+	// charges the sender a fee, so no more than the initial total can remain
+	var fees int64
+	for _, accountNumber := range []string{accountA.AccountNumber, accountB.AccountNumber} {
+		var accountFees int64
+		err := testDB.QueryRow(
+			`SELECT COALESCE(SUM(fee), 0) FROM transactions WHERE from_account_number = $1 AND status = 'COMPLETED'`,
+			accountNumber,
+		).Scan(&accountFees)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fees += accountFees
+	}
+	
+	if balanceA+balanceB+fees != 200000 {
+		t.Errorf("Money was created or lost: balances %d + %d + fees %d != 200000", balanceA, balanceB, fees)
+	}
+	
+	// The cached balances must match the ledger postings they are derived from
+	for accountNumber, balance := range map[string]int64{accountA.AccountNumber: balanceA, accountB.AccountNumber: balanceB} {
+		var ledgerBalance int64
+		err := testDB.QueryRow(
+			`SELECT COALESCE(SUM(CASE WHEN p.direction = 'CREDIT' THEN p.amount ELSE -p.amount END), 0)
+			 FROM postings p JOIN accounts a ON a.id = p.account_id WHERE a.account_number = $1`,
+			accountNumber,
+		).Scan(&ledgerBalance)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ledgerBalance != balance {
+			t.Errorf("Ledger balance %d does not match cached balance %d for %s", ledgerBalance, balance, accountNumber)
+		}
+	}
+}
+
+func TestIdempotentDeposit(t *testing.T) {
+	account := createTestAccount(t)
+	token := loginAndGetToken(t, account.AccountNumber)
+	handler := testRouter.SetupRoutes()
+	idempotencyKey := fmt.Sprintf("test-deposit-%d", time.Now().UnixNano())
+	
+	send := func(amount int64) *httptest.ResponseRecorder {
+		jsonData, _ := json.Marshal(models.DepositRequest{
+			AccountNumber: account.AccountNumber,
+			Amount:        amount,
+			Currency:      models.CurrencyTND,
+		})
+		req, err := http.NewRequest("POST", "/api/v1/transactions/deposit", bytes.NewBuffer(jsonData))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+		
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	
+	first := send(50000)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("First deposit returned wrong status code: got %v want %v", first.Code, http.StatusCreated)
+	}
+	
+	retry := send(50000)
+	if retry.Code != http.StatusCreated {
+		t.Errorf("Retried deposit returned wrong status code: got %v want %v", retry.Code, http.StatusCreated)
+	}
+	if retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Error("Retried deposit should be replayed from the stored response")
+	}
+	if retry.Body.String() != first.Body.String() {
+		t.Errorf("Replayed body differs: got %s want %s", retry.Body.String(), first.Body.String())
+	}
+	
+	if balance := getBalance(t, handler, token, account.AccountNumber); balance != 50000 {
+		t.Errorf("Deposit was applied more than once: balance %d", balance)
+	}
+	
+	mismatch := send(60000)
+	if mismatch.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Reused key with different body returned wrong status code: got %v want %v", mismatch.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestReverseTransfer(t *testing.T) {
+	payer := createTestAccount(t)
+	payee := createTestAccount(t)
+	payerToken := loginAndGetToken(t, payer.AccountNumber)
+	payeeToken := loginAndGetToken(t, payee.AccountNumber)
+	handler := testRouter.SetupRoutes()
+	
+	deposit(t, handler, payerToken, payer.AccountNumber, 100000)
+	
+	jsonData, _ := json.Marshal(models.TransferRequest{
+		FromAccountNumber: payer.AccountNumber,
+		ToAccountNumber:   payee.AccountNumber,
+		Amount:            50000,
+		Currency:          models.CurrencyTND,
+	})
+	req, _ := http.NewRequest("POST", "/api/v1/transactions/transfer", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+payerToken)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Transfer failed: status %v, body %s", rr.Code, rr.Body.String())
+	}
+	
+	var response struct {
+		Data models.Transaction `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatal("Failed to unmarshal transfer response:", err)
+	}
+	original := response.Data
+	
+	reverse := func(token string, body string) int {
+		req, _ := http.NewRequest("POST", "/api/v1/transactions/"+original.TransactionID+"/reverse", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	
+	if status := reverse(payerToken, `{}`); status != http.StatusForbidden {
+		t.Errorf("Sender reversing returned wrong status code: got %v want %v", status, http.StatusForbidden)
+	}
+	
+	// A suspended payee account cannot be debited by a reversal
+	accountRepo := repository.NewPostgresAccountRepository(testDB)
+	if err := accountRepo.UpdateStatus(payee.ID, models.AccountStatusSuspended); err != nil {
+		t.Fatal("Failed to suspend payee account:", err)
+	}
+	if status := reverse(payeeToken, `{"amount": 20000}`); status != http.StatusBadRequest {
+		t.Errorf("Reversal from a suspended account returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+	if err := accountRepo.UpdateStatus(payee.ID, models.AccountStatusActive); err != nil {
+		t.Fatal("Failed to reactivate payee account:", err)
+	}
+	
+	// Only staff can hand the fee back
+	if status := reverse(payeeToken, `{"amount": 20000, "refund_fee": true}`); status != http.StatusForbidden {
+		t.Errorf("Customer refunding the fee returned wrong status code: got %v want %v", status, http.StatusForbidden)
+	}
+	if status := reverse(payeeToken, `{"amount": 20000}`); status != http.StatusCreated {
+		t.Fatalf("Partial reversal returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+	
+	username := fmt.Sprintf("compliance%d", time.Now().UnixNano())
+	staffService := services.NewStaffService(repository.NewPostgresStaffRepository(testDB))
+	if _, err := staffService.CreateStaffUser(&models.CreateStaffUserRequest{
+		Username: username,
+		Email:    username + "@bank.tn",
+		FullName: "Compliance Test",
+		Role:     models.RoleCompliance,
+		Password: "compliance-secret-123",
+	}); err != nil {
+		t.Fatal("Failed to create compliance officer:", err)
+	}
+	
+	jsonData, _ = json.Marshal(models.StaffLoginRequest{Username: username, Password: "compliance-secret-123"})
+	req, _ = http.NewRequest("POST", "/api/v1/auth/staff/login", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	var staffLogin struct {
+		Data models.StaffLoginResponse `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &staffLogin); err != nil {
+		t.Fatal("Failed to unmarshal staff login response:", err)
+	}
+	
+	// The remaining 30000 and the fee
+	if status := reverse(staffLogin.Data.Token, `{"refund_fee": true}`); status != http.StatusCreated {
+		t.Fatalf("Remaining reversal returned wrong status code: got %v want %v", status, http.StatusCreated)
+	}
+	
+	if status := reverse(payeeToken, `{"amount": 1}`); status != http.StatusBadRequest {
+		t.Errorf("Double reversal returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+	
+	// Principal and fee are both back with the payer
+	if balance := getBalance(t, handler, payerToken, payer.AccountNumber); balance != 100000 {
+		t.Errorf("Payer balance after full reversal: got %d want 100000", balance)
+	}
+	if balance := getBalance(t, handler, payeeToken, payee.AccountNumber); balance != 0 {
+		t.Errorf("Payee balance after full reversal: got %d want 0", balance)
+	}
+}
+
+func TestFXQuote(t *testing.T) {
+	account := createTestAccount(t)
+	token := loginAndGetToken(t, account.AccountNumber)
+	handler := testRouter.SetupRoutes()
+	
+	req, err := http.NewRequest("GET", "/api/v1/fx/quote?from=TND&to=EUR&amount=100000", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("FX quote returned wrong status code: got %v want %v, body %s", status, http.StatusOK, rr.Body.String())
+	}
+	
+	var response struct {
+		Data models.FXQuote `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatal("Failed to unmarshal quote response:", err)
+	}
+	
+	quote := response.Data
+	if quote.QuoteID == "" || !quote.ExpiresAt.After(quote.CreatedAt) {
+		t.Errorf("Expected a time-limited quote, got %+v", quote)
+	}
+	if quote.Rate >= quote.MidRate {
+		t.Errorf("Expected the customer rate %v to include a spread below mid %v", quote.Rate, quote.MidRate)
+	}
+	// 100 TND at roughly 0.29 EUR/TND, in euro cents
+	if quote.ConvertedAmount < 2500 || quote.ConvertedAmount > 3500 {
+		t.Errorf("Unexpected converted amount: %d", quote.ConvertedAmount)
+	}
+	
+	// A transfer must be made in the source account's currency
+	jsonData, _ := json.Marshal(models.TransferRequest{
+		FromAccountNumber: account.AccountNumber,
+		ToAccountNumber:   createTestAccount(t).AccountNumber,
+		Amount:            1000,
+		Currency:          models.CurrencyEUR,
+	})
+	req, _ = http.NewRequest("POST", "/api/v1/transactions/transfer", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("Currency mismatch returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+}
+
+func TestHoldLifecycle(t *testing.T) {
+	account := createTestAccount(t)
+	token := loginAndGetToken(t, account.AccountNumber)
+	handler := testRouter.SetupRoutes()
+	
+	deposit(t, handler, token, account.AccountNumber, 100000)
+	
+	jsonData, _ := json.Marshal(models.PlaceHoldRequest{
+		AccountNumber:     account.AccountNumber,
+		Amount:            30000,
+		Currency:          models.CurrencyTND,
+		MerchantReference: "MERCH-001",
+	})
+	req, _ := http.NewRequest("POST", "/api/v1/holds", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	
+	if status := rr.Code; status != http.StatusCreated {
+		t.Fatalf("Place hold returned wrong status code: got %v want %v, body %s", status, http.StatusCreated, rr.Body.String())
+	}
+	
+	var placed struct {
+		Data models.Hold `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &placed); err != nil {
+		t.Fatal("Failed to unmarshal hold response:", err)
+	}
+	
+	balance := getBalanceResponse(t, handler, token, account.AccountNumber)
+	if balance.Balance != 100000 || balance.HoldAmount != 30000 || balance.AvailableBalance != 70000 {
+		t.Errorf("Unexpected balance after hold: %+v", balance)
+	}
+	
+	// Funds reserved by the hold cannot be withdrawn
+	jsonData, _ = json.Marshal(models.WithdrawalRequest{
+		AccountNumber: account.AccountNumber,
+		Amount:        70000,
+		Currency:      models.CurrencyTND,
+	})
+	req, _ = http.NewRequest("POST", "/api/v1/transactions/withdraw", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("Withdrawal over the available balance returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+	
+	// Holds on a suspended account cannot be captured
+	accountRepo := repository.NewPostgresAccountRepository(testDB)
+	if err := accountRepo.UpdateStatus(account.ID, models.AccountStatusSuspended); err != nil {
+		t.Fatal("Failed to suspend account:", err)
+	}
+	req, _ = http.NewRequest("POST", "/api/v1/holds/"+placed.Data.HoldID+"/capture", bytes.NewBufferString(`{"amount": 20000}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	
+	if status := rr.Code; status != http.StatusBadRequest {
+		t.Errorf("Capture on a suspended account returned wrong status code: got %v want %v", status, http.StatusBadRequest)
+	}
+	if err := accountRepo.UpdateStatus(account.ID, models.AccountStatusActive); err != nil {
+		t.Fatal("Failed to reactivate account:", err)
+	}
+	
+	// A partial capture releases the rest of the hold
+	req, _ = http.NewRequest("POST", "/api/v1/holds/"+placed.Data.HoldID+"/capture", bytes.NewBufferString(`{"amount": 20000}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("Capture returned wrong status code: got %v want %v, body %s", status, http.StatusOK, rr.Body.String())
+	}
+	
+	balance = getBalanceResponse(t, handler, token, account.AccountNumber)
+	if balance.Balance != 80000 || balance.HoldAmount != 0 || balance.AvailableBalance != 80000 {
+		t.Errorf("Unexpected balance after capture: %+v", balance)
+	}
+}
+
+func TestRoleBasedAccess(t *testing.T) {
+	handler := testRouter.SetupRoutes()
+	
+	owner := createTestAccount(t)
+	other := createTestAccount(t)
+	ownerToken := loginAndGetToken(t, owner.AccountNumber)
+	
+	request := func(method, path, token, body string) int {
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	
+	// Customers cannot reach other customers' accounts or back-office actions
+	if status := request("GET", fmt.Sprintf("/api/v1/accounts/%d", other.ID), ownerToken, ""); status != http.StatusForbidden {
+		t.Errorf("Reading another customer's account: got %v want %v", status, http.StatusForbidden)
+	}
+	if status := request("GET", "/api/v1/accounts/"+other.AccountNumber+"/balance", ownerToken, ""); status != http.StatusForbidden {
+		t.Errorf("Reading another customer's balance: got %v want %v", status, http.StatusForbidden)
+	}
+	if status := request("PATCH", fmt.Sprintf("/api/v1/accounts/%d/status", owner.ID), ownerToken, `{"status": "SUSPENDED"}`); status != http.StatusForbidden {
+		t.Errorf("Customer changing account status: got %v want %v", status, http.StatusForbidden)
+	}
+	if status := request("DELETE", fmt.Sprintf("/api/v1/accounts/%d", other.ID), ownerToken, ""); status != http.StatusForbidden {
+		t.Errorf("Customer deleting an account: got %v want %v", status, http.StatusForbidden)
+	}
+	
+	// Listing accounts only returns the customer's own
+	req, _ := http.NewRequest("GET", "/api/v1/accounts", nil)
+	req.Header.Set("Authorization", "Bearer "+ownerToken)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	
+	var listed struct {
+		Data []models.Account `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &listed); err != nil {
+		t.Fatal("Failed to unmarshal accounts response:", err)
+	}
+	for _, account := range listed.Data {
+		if account.CustomerID != owner.CustomerID {
+			t.Errorf("Customer listing included account %s of customer %s", account.AccountNumber, account.CustomerID)
+		}
+	}
+	
+	// A teller can act on any account but cannot delete it
+	username := fmt.Sprintf("teller%d", time.Now().UnixNano())
+	staffService := services.NewStaffService(repository.NewPostgresStaffRepository(testDB))
+	if _, err := staffService.CreateStaffUser(&models.CreateStaffUserRequest{
+		Username: username,
+		Email:    username + "@bank.tn",
+		FullName: "Guichetier Test",
+		Role:     models.RoleTeller,
+		Password: "guichet-secret-123",
+	}); err != nil {
+		t.Fatal("Failed to create teller:", err)
+	}
+	
+	jsonData, _ := json.Marshal(models.StaffLoginRequest{Username: username, Password: "guichet-secret-123"})
+	req, _ = http.NewRequest("POST", "/api/v1/auth/staff/login", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("Staff login returned wrong status code: got %v want %v, body %s", status, http.StatusOK, rr.Body.String())
+	}
+	
+	var login struct {
+		Data models.StaffLoginResponse `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &login); err != nil {
+		t.Fatal("Failed to unmarshal staff login response:", err)
+	}
+	tellerToken := login.Data.Token
+	
+	if status := request("GET", "/api/v1/accounts/"+other.AccountNumber+"/balance", tellerToken, ""); status != http.StatusOK {
+		t.Errorf("Teller reading a balance: got %v want %v", status, http.StatusOK)
+	}
+	if status := request("PATCH", fmt.Sprintf("/api/v1/accounts/%d/status", other.ID), tellerToken, `{"status": "ACTIVE"}`); status != http.StatusOK {
+		t.Errorf("Teller changing account status: got %v want %v", status, http.StatusOK)
+	}
+	if status := request("DELETE", fmt.Sprintf("/api/v1/accounts/%d", other.ID), tellerToken, ""); status != http.StatusForbidden {
+		t.Errorf("Teller deleting an account: got %v want %v", status, http.StatusForbidden)
+	}
+	if status := request("GET", "/api/v1/staff", tellerToken, ""); status != http.StatusForbidden {
+		t.Errorf("Teller managing staff: got %v want %v", status, http.StatusForbidden)
+	}
+}
+
+func TestRefreshTokenRotation(t *testing.T) {
+	handler := testRouter.SetupRoutes()
+	account := createTestAccount(t)
+	
+	post := func(path, token, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	
+	login := func() models.LoginResponse {
+		jsonData, _ := json.Marshal(models.LoginRequest{AccountNumber: account.AccountNumber, Password: "motdepasse123"})
+		rr := post("/api/v1/auth/login", "", string(jsonData))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Login failed: status %v, body %s", rr.Code, rr.Body.String())
+		}
+		var response struct {
+			Data models.LoginResponse `json:"data"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatal("Failed to unmarshal login response:", err)
+		}
+		return response.Data
+	}
+	
+	refresh := func(refreshToken string) (int, models.LoginResponse) {
+		jsonData, _ := json.Marshal(models.RefreshTokenRequest{RefreshToken: refreshToken})
+		rr := post("/api/v1/auth/refresh", "", string(jsonData))
+		var response struct {
+			Data models.LoginResponse `json:"data"`
+		}
+		json.Unmarshal(rr.Body.Bytes(), &response)
+		return rr.Code, response.Data
+	}
+	
+	session := login()
+	if session.RefreshToken == "" {
+		t.Fatal("Login response should contain a refresh token")
+	}
+	
+	// Refreshing rotates the refresh token
+	status, rotated := refresh(session.RefreshToken)
+	if status != http.StatusOK {
+		t.Fatalf("Refresh returned wrong status code: got %v want %v", status, http.StatusOK)
+	}
+	if rotated.RefreshToken == "" || rotated.RefreshToken == session.RefreshToken {
+		t.Error("Refresh should issue a new refresh token")
+	}
+	getBalance(t, handler, rotated.Token, account.AccountNumber)
+	
+	// Replaying the used token revokes the whole family
+	if status, _ := refresh(session.RefreshToken); status != http.StatusUnauthorized {
+		t.Errorf("Reusing a refresh token: got %v want %v", status, http.StatusUnauthorized)
+	}
+	if status, _ := refresh(rotated.RefreshToken); status != http.StatusUnauthorized {
+		t.Errorf("Refreshing after reuse detection: got %v want %v", status, http.StatusUnauthorized)
+	}
+	if rr := post("/api/v1/auth/logout", rotated.Token, ""); rr.Code != http.StatusUnauthorized {
+		t.Errorf("Access token of a revoked session: got %v want %v", rr.Code, http.StatusUnauthorized)
+	}
+	
+	// Logout revokes the session and its access token
+	session = login()
+	if rr := post("/api/v1/auth/logout", session.Token, ""); rr.Code != http.StatusOK {
+		t.Fatalf("Logout returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if status, _ := refresh(session.RefreshToken); status != http.StatusUnauthorized {
+		t.Errorf("Refreshing after logout: got %v want %v", status, http.StatusUnauthorized)
+	}
+	
+	// Logging out of all devices revokes every other session too
+	first, second := login(), login()
+	if rr := post("/api/v1/auth/logout-all", first.Token, ""); rr.Code != http.StatusOK {
+		t.Fatalf("Logout-all returned wrong status code: got %v want %v", rr.Code, http.StatusOK)
+	}
+	if rr := post("/api/v1/auth/logout", second.Token, ""); rr.Code != http.StatusUnauthorized {
+		t.Errorf("Access token after logout-all: got %v want %v", rr.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestMFA(t *testing.T) {
+	handler := testRouter.SetupRoutes()
+	account := createTestAccount(t)
+	payee := createTestAccount(t)
+	token := loginAndGetToken(t, account.AccountNumber)
+	deposit(t, handler, token, account.AccountNumber, 100000)
+	
+	post := func(path, token string, body interface{}) *httptest.ResponseRecorder {
+		jsonData, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", path, bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	
+	// Enrolment only takes effect once a code from the app confirms it
+	rr := post("/api/v1/auth/mfa/enroll", token, nil)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Enrolment returned wrong status code: got %v want %v, body %s", rr.Code, http.StatusCreated, rr.Body.String())
+	}
+	var enrollment struct {
+		Data models.MFAEnrollment `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &enrollment); err != nil {
+		t.Fatal("Failed to unmarshal enrolment response:", err)
+	}
+	if enrollment.Data.Secret == "" || !strings.HasPrefix(enrollment.Data.OTPAuthURI, "otpauth://totp/") {
+		t.Fatalf("Enrolment: secret %q, URI %q", enrollment.Data.Secret, enrollment.Data.OTPAuthURI)
+	}
+	
+	step := utils.TOTPStep(time.Now())
+	totp := func(step int64) string {
+		code, err := utils.TOTPCode(enrollment.Data.Secret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+	
+	if rr := post("/api/v1/auth/mfa/enroll/verify", token, models.MFACodeRequest{Code: totp(step - 10)}); rr.Code != http.StatusUnauthorized {
+		t.Errorf("Confirming with a stale code: got %v want %v", rr.Code, http.StatusUnauthorized)
+	}
+	rr = post("/api/v1/auth/mfa/enroll/verify", token, models.MFACodeRequest{Code: totp(step)})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Confirming enrolment returned wrong status code: got %v want %v, body %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var confirmed struct {
+		Data models.RecoveryCodesResponse `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &confirmed); err != nil {
+		t.Fatal("Failed to unmarshal recovery codes:", err)
+	}
+	recoveryCodes := confirmed.Data.RecoveryCodes
+	if len(recoveryCodes) != models.MFARecoveryCodeCount {
+		t.Fatalf("Recovery codes: got %d want %d", len(recoveryCodes), models.MFARecoveryCodeCount)
+	}
+	
+	// A password-only token must step up to pay a new beneficiary
+	if code := transfer(handler, token, account.AccountNumber, payee.AccountNumber, 1000); code != http.StatusUnauthorized {
+		t.Errorf("Transfer to a new beneficiary without step-up: got %v want %v", code, http.StatusUnauthorized)
+	}
+	
+	// A transfer refused for step-up can be retried with the same Idempotency-Key
+	idempotencyKey := fmt.Sprintf("mfa-transfer-%d", time.Now().UnixNano())
+	idempotentTransfer := func(token string) *httptest.ResponseRecorder {
+		jsonData, _ := json.Marshal(models.TransferRequest{
+			FromAccountNumber: account.AccountNumber,
+			ToAccountNumber:   payee.AccountNumber,
+			Amount:            1000,
+			Currency:          models.CurrencyTND,
+		})
+		req, _ := http.NewRequest("POST", "/api/v1/transactions/transfer", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	if rr := idempotentTransfer(token); rr.Code != http.StatusUnauthorized {
+		t.Errorf("Idempotent transfer without step-up: got %v want %v", rr.Code, http.StatusUnauthorized)
+	}
+	
+	if rr := post("/api/v1/auth/mfa/step-up", token, models.MFACodeRequest{Code: totp(step)}); rr.Code != http.StatusUnauthorized {
+		t.Errorf("Step-up with a code already used: got %v want %v", rr.Code, http.StatusUnauthorized)
+	}
+	rr = post("/api/v1/auth/mfa/step-up", token, models.MFACodeRequest{Code: totp(step + 1)})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Step-up returned wrong status code: got %v want %v, body %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var steppedUp struct {
+		Data models.StepUpResponse `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &steppedUp); err != nil {
+		t.Fatal("Failed to unmarshal step-up response:", err)
+	}
+	if steppedUp.Data.ACR != models.ACRMultiFactor {
+		t.Errorf("Step-up acr: got %s want %s", steppedUp.Data.ACR, models.ACRMultiFactor)
+	}
+	if rr := idempotentTransfer(steppedUp.Data.Token); rr.Code != http.StatusCreated || rr.Header().Get("Idempotent-Replayed") != "" {
+		t.Errorf("Retrying the refused transfer after step-up: got %v want %v, body %s", rr.Code, http.StatusCreated, rr.Body.String())
+	}
+	if code := transfer(handler, steppedUp.Data.Token, account.AccountNumber, payee.AccountNumber, 1000); code != http.StatusCreated {
+		t.Errorf("Transfer after step-up: got %v want %v", code, http.StatusCreated)
+	}
+	
+	// A known beneficiary only needs it from the threshold
+	if code := transfer(handler, token, account.AccountNumber, payee.AccountNumber, 1000); code != http.StatusCreated {
+		t.Errorf("Transfer to a known beneficiary: got %v want %v", code, http.StatusCreated)
+	}
+	if code := transfer(handler, token, account.AccountNumber, payee.AccountNumber, 500000); code != http.StatusUnauthorized {
+		t.Errorf("Transfer above the threshold without step-up: got %v want %v", code, http.StatusUnauthorized)
+	}
+	
+	// Payment files and standing orders cannot pay a new beneficiary without it either
+	stranger := createTestAccount(t)
+	painFile := fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:pain.001.001.03">
+  <CstmrCdtTrfInitn>
+    <GrpHdr><MsgId>MSG-%d</MsgId><CreDtTm>2026-01-15T10:00:00</CreDtTm><NbOfTxs>1</NbOfTxs><CtrlSum>1.000</CtrlSum></GrpHdr>
+    <PmtInf>
+      <PmtInfId>FOURNISSEURS</PmtInfId><PmtMtd>TRF</PmtMtd>
+      <DbtrAcct><Id><IBAN>%s</IBAN></Id></DbtrAcct>
+      <CdtTrfTxInf>
+        <PmtId><EndToEndId>E2E-1</EndToEndId></PmtId>
+        <Amt><InstdAmt Ccy="TND">1.000</InstdAmt></Amt>
+        <Cdtr><Nm>Sami Gharbi</Nm></Cdtr>
+        <CdtrAcct><Id><IBAN>%s</IBAN></Id></CdtrAcct>
+      </CdtTrfTxInf>
+    </PmtInf>
+  </CstmrCdtTrfInitn>
+</Document>`, time.Now().UnixNano(), account.IBAN, stranger.IBAN)
+	uploadPaymentFile := func(token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/v1/accounts/"+account.AccountNumber+"/payment-files", bytes.NewBufferString(painFile))
+		req.Header.Set("Content-Type", "application/xml")
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	errorCode := func(rr *httptest.ResponseRecorder) string {
+		var refused models.ErrorResponse
+		json.Unmarshal(rr.Body.Bytes(), &refused)
+		return refused.Code
+	}
+	
+	if rr := uploadPaymentFile(token); rr.Code != http.StatusUnauthorized || errorCode(rr) != models.ErrCodeMFARequired {
+		t.Errorf("Payment file to a new beneficiary without step-up: got %v %q want %v %q", rr.Code, errorCode(rr), http.StatusUnauthorized, models.ErrCodeMFARequired)
+	}
+	if rr := uploadPaymentFile(steppedUp.Data.Token); rr.Code != http.StatusOK {
+		t.Errorf("Payment file after step-up: got %v want %v, body %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	
+	standingOrder := models.CreateStandingOrderRequest{
+		FromAccountNumber: account.AccountNumber,
+		ToAccountNumber:   createTestAccount(t).AccountNumber,
+		Amount:            1000,
+		Currency:          models.CurrencyTND,
+		Frequency:         models.StandingOrderMonthly,
+		StartDate:         time.Now().UTC().AddDate(0, 0, 1).Format("2006-01-02"),
+	}
+	if rr := post("/api/v1/standing-orders", token, standingOrder); rr.Code != http.StatusUnauthorized || errorCode(rr) != models.ErrCodeMFARequired {
+		t.Errorf("Standing order to a new beneficiary without step-up: got %v %q want %v %q", rr.Code, errorCode(rr), http.StatusUnauthorized, models.ErrCodeMFARequired)
+	}
+	if rr := post("/api/v1/standing-orders", steppedUp.Data.Token, standingOrder); rr.Code != http.StatusCreated {
+		t.Errorf("Standing order after step-up: got %v want %v, body %s", rr.Code, http.StatusCreated, rr.Body.String())
+	}
+	
+	// Logging in now takes a second step
+	login := func() models.MFAChallengeResponse {
+		rr := post("/api/v1/auth/login", "", models.LoginRequest{AccountNumber: account.AccountNumber, Password: "motdepasse123"})
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Login failed: status %v, body %s", rr.Code, rr.Body.String())
+		}
+		var response struct {
+			Data models.MFAChallengeResponse `json:"data"`
+		}
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatal("Failed to unmarshal login response:", err)
+		}
+		if !response.Data.MFARequired || response.Data.MFAToken == "" {
+			t.Fatalf("Login should return an MFA challenge, got %s", rr.Body.String())
+		}
+		return response.Data
+	}
+	
+	challenge := login()
+	if rr := post("/api/v1/auth/login/mfa", "", models.MFALoginRequest{MFAToken: challenge.MFAToken, Code: totp(step - 10)}); rr.Code != http.StatusUnauthorized {
+		t.Errorf("Answering a challenge with a wrong code: got %v want %v", rr.Code, http.StatusUnauthorized)
+	}
+	rr = post("/api/v1/auth/login/mfa", "", models.MFALoginRequest{MFAToken: challenge.MFAToken, Code: recoveryCodes[0]})
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Answering a challenge with a recovery code: got %v want %v, body %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var session struct {
+		Data models.LoginResponse `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &session); err != nil {
+		t.Fatal("Failed to unmarshal login response:", err)
+	}
+	if session.Data.Token == "" || session.Data.RefreshToken == "" {
+		t.Fatal("Completing the challenge should open a session")
+	}
+	
+	// Challenges and recovery codes are single use
+	if rr := post("/api/v1/auth/login/mfa", "", models.MFALoginRequest{MFAToken: challenge.MFAToken, Code: recoveryCodes[1]}); rr.Code != http.StatusUnauthorized {
+		t.Errorf("Reusing a challenge: got %v want %v", rr.Code, http.StatusUnauthorized)
+	}
+	challenge = login()
+	if rr := post("/api/v1/auth/login/mfa", "", models.MFALoginRequest{MFAToken: challenge.MFAToken, Code: recoveryCodes[0]}); rr.Code != http.StatusUnauthorized {
+		t.Errorf("Reusing a recovery code: got %v want %v", rr.Code, http.StatusUnauthorized)
+	}
+	
+	req, _ := http.NewRequest("GET", "/api/v1/auth/mfa", nil)
+	req.Header.Set("Authorization", "Bearer "+session.Data.Token)
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	var status struct {
+		Data models.MFAStatus `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &status); err != nil {
+		t.Fatal("Failed to unmarshal MFA status:", err)
+	}
+	if !status.Data.Enabled || status.Data.RecoveryCodesRemaining != models.MFARecoveryCodeCount-1 {
+		t.Errorf("MFA status: enabled %v, recovery codes remaining %d", status.Data.Enabled, status.Data.RecoveryCodesRemaining)
+	}
+	
+	// The MFA session's token passes step-up checks without stepping up again
+	if code := transfer(handler, session.Data.Token, account.AccountNumber, payee.AccountNumber, 500000); code == http.StatusUnauthorized {
+		t.Errorf("Transfer above the threshold right after an MFA login: got %v", code)
+	}
+	
+	// Disabling MFA brings back single-step logins
+	if rr := post("/api/v1/auth/mfa/disable", session.Data.Token, models.MFACodeRequest{Code: recoveryCodes[1]}); rr.Code != http.StatusOK {
+		t.Fatalf("Disabling MFA returned wrong status code: got %v want %v, body %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	loginAndGetToken(t, account.AccountNumber)
+}
+
+func TestLoginLockout(t *testing.T) {
+	handler := testRouter.SetupRoutes()
+	account := createTestAccount(t)
+	
+	login := func(loginReq models.LoginRequest, remoteAddr string) *httptest.ResponseRecorder {
+		jsonData, _ := json.Marshal(loginReq)
+		req, _ := http.NewRequest("POST", "/api/v1/auth/login", bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = remoteAddr
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	request := func(method, path, token string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	
+	// Three wrong passwords in a row lock the customer out, even with the right one
+	wrong := models.LoginRequest{CustomerID: account.CustomerID, Password: "mauvais-passe"}
+	for i := 1; i <= testConfig.Login.MaxFailures; i++ {
+		if rr := login(wrong, ""); rr.Code != http.StatusUnauthorized {
+			t.Fatalf("Wrong password %d: got %v want %v, body %s", i, rr.Code, http.StatusUnauthorized, rr.Body.String())
+		}
+	}
+	
+	rr := login(models.LoginRequest{AccountNumber: account.AccountNumber, Password: "motdepasse123"}, "")
+	if rr.Code != http.StatusLocked {
+		t.Fatalf("Login while locked out: got %v want %v, body %s", rr.Code, http.StatusLocked, rr.Body.String())
+	}
+	var refused models.ErrorResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &refused); err != nil {
+		t.Fatal("Failed to unmarshal lockout response:", err)
+	}
+	if refused.Code != models.ErrCodeAccountLocked || rr.Header().Get("Retry-After") == "" {
+		t.Errorf("Lockout response: code %q, Retry-After %q", refused.Code, rr.Header().Get("Retry-After"))
+	}
+	
+	// Only admins can lift the lockout early
+	username := fmt.Sprintf("admin%d", time.Now().UnixNano())
+	staffService := services.NewStaffService(repository.NewPostgresStaffRepository(testDB))
+	if _, err := staffService.CreateStaffUser(&models.CreateStaffUserRequest{
+		Username: username,
+		Email:    username + "@bank.tn",
+		FullName: "Admin Test",
+		Role:     models.RoleAdmin,
+		Password: "admin-secret-123",
+	}); err != nil {
+		t.Fatal("Failed to create admin:", err)
+	}
+	
+	jsonData, _ := json.Marshal(models.StaffLoginRequest{Username: username, Password: "admin-secret-123"})
+	req, _ := http.NewRequest("POST", "/api/v1/auth/staff/login", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	var staffLogin struct {
+		Data models.StaffLoginResponse `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &staffLogin); err != nil {
+		t.Fatal("Failed to unmarshal staff login response:", err)
+	}
+	
+	if rr := request("POST", "/api/v1/customers/"+account.CustomerID+"/unlock", staffLogin.Data.Token); rr.Code != http.StatusOK {
+		t.Fatalf("Admin unlocking a customer: got %v want %v, body %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	token := loginAndGetToken(t, account.AccountNumber)
+	
+	if rr := request("POST", "/api/v1/customers/"+account.CustomerID+"/unlock", token); rr.Code != http.StatusForbidden {
+		t.Errorf("Customer unlocking themselves: got %v want %v", rr.Code, http.StatusForbidden)
+	}
+	
+	// The customer sees their last login and failed login, and the whole history
+	rr = request("GET", "/api/v1/customers/"+account.CustomerID, token)
+	var customer struct {
+		Data models.Customer `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &customer); err != nil {
+		t.Fatal("Failed to unmarshal customer:", err)
+	}
+	if customer.Data.LastLoginAt == nil || customer.Data.LastFailedLoginAt == nil || customer.Data.FailedLoginAttempts != 0 || customer.Data.LockedUntil != nil {
+		t.Errorf("Customer after unlock and login: %+v", customer.Data)
+	}
+	
+	rr = request("GET", "/api/v1/customers/"+account.CustomerID+"/logins", token)
+	var history struct {
+		Data []models.LoginAttempt `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &history); err != nil {
+		t.Fatal("Failed to unmarshal login history:", err)
+	}
+	if len(history.Data) != testConfig.Login.MaxFailures+2 {
+		t.Fatalf("Login history: got %d attempts want %d", len(history.Data), testConfig.Login.MaxFailures+2)
+	}
+	if !history.Data[0].Success || history.Data[1].FailureReason != models.LoginFailureLocked || history.Data[2].FailureReason != models.LoginFailureInvalidPassword {
+		t.Errorf("Login history, newest first: %+v, %+v, %+v", history.Data[0], history.Data[1], history.Data[2])
+	}
+	
+	// Guessing from one IP is blocked across customers, unknown ones included
+	ip := fmt.Sprintf("198.51.100.%d", time.Now().UnixNano()%250+1)
+	for i := 1; i <= testConfig.Login.IPMaxFailures; i++ {
+		unknown := models.LoginRequest{CustomerID: fmt.Sprintf("CUSTUNKNOWN%d", i), Password: "motdepasse123"}
+		if rr := login(unknown, ip+":4000"); rr.Code != http.StatusUnauthorized {
+			t.Fatalf("Unknown customer %d: got %v want %v", i, rr.Code, http.StatusUnauthorized)
+		}
+	}
+	rr = login(models.LoginRequest{AccountNumber: account.AccountNumber, Password: "motdepasse123"}, ip+":4001")
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") == "" {
+		t.Errorf("Login from a blocked IP: got %v want %v, body %s", rr.Code, http.StatusTooManyRequests, rr.Body.String())
+	}
+	if rr := login(models.LoginRequest{AccountNumber: account.AccountNumber, Password: "motdepasse123"}, ""); rr.Code != http.StatusOK {
+		t.Errorf("Login from another client: got %v want %v", rr.Code, http.StatusOK)
+	}
+	
+	// Waits double after each failure up to the cap
+	policy := models.LoginPolicy{DelayBase: time.Second, MaxDelay: 30 * time.Second}
+	for failures, want := range map[int]time.Duration{0: 0, 1: time.Second, 3: 4 * time.Second, 10: 30 * time.Second} {
+		if got := policy.Delay(failures); got != want {
+			t.Errorf("Delay after %d failures: got %v want %v", failures, got, want)
+		}
+	}
+}
+
+func TestPasswordChangeAndReset(t *testing.T) {
+	handler := testRouter.SetupRoutes()
+	account := createTestAccount(t)
+	
+	post := func(path, token string, body interface{}) *httptest.ResponseRecorder {
+		jsonData, _ := json.Marshal(body)
+		req, _ := http.NewRequest("POST", path, bytes.NewBuffer(jsonData))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	getCustomer := func(token string) int {
+		req, _ := http.NewRequest("GET", "/api/v1/customers/"+account.CustomerID, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	login := func(password string) int {
+		return post("/api/v1/auth/login", "", models.LoginRequest{CustomerID: account.CustomerID, Password: password}).Code
+	}
+	
+	token := loginAndGetToken(t, account.AccountNumber)
+	otherToken := loginAndGetToken(t, account.AccountNumber)
+	
+	// The current password must check out and the new one meet the policy
+	change := func(current, next string) int {
+		return post("/api/v1/auth/password/change", token, models.ChangePasswordRequest{CurrentPassword: current, NewPassword: next}).Code
+	}
+	if code := change("mauvais-passe", "Nouveau-passe-2025"); code != http.StatusUnauthorized {
+		t.Errorf("Change with a wrong current password: got %v want %v", code, http.StatusUnauthorized)
+	}
+	for _, weak := range []string{"court1", "seulementdeslettres", "motdepasse2024", "motdepasse123"} {
+		if code := change("motdepasse123", weak); code != http.StatusBadRequest {
+			t.Errorf("Change to %q: got %v want %v", weak, code, http.StatusBadRequest)
+		}
+	}
+	
+	if code := change("motdepasse123", "Nouveau-passe-2025"); code != http.StatusOK {
+		t.Fatalf("Changing the password: got %v want %v", code, http.StatusOK)
+	}
+	if code := getCustomer(token); code != http.StatusOK {
+		t.Errorf("Session that changed the password: got %v want %v", code, http.StatusOK)
+	}
+	if code := getCustomer(otherToken); code != http.StatusUnauthorized {
+		t.Errorf("Other session after a password change: got %v want %v", code, http.StatusUnauthorized)
+	}
+	if code := login("motdepasse123"); code != http.StatusUnauthorized {
+		t.Errorf("Login with the old password: got %v want %v", code, http.StatusUnauthorized)
+	}
+	if code := login("Nouveau-passe-2025"); code != http.StatusOK {
+		t.Errorf("Login with the new password: got %v want %v", code, http.StatusOK)
+	}
+	
+	// Reset requests look the same whether or not the email is known
+	var customer struct {
+		Data models.Customer `json:"data"`
+	}
+	req, _ := http.NewRequest("GET", "/api/v1/customers/"+account.CustomerID, nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if err := json.Unmarshal(rr.Body.Bytes(), &customer); err != nil {
+		t.Fatal("Failed to unmarshal customer:", err)
+	}
+	
+	for _, email := range []string{"personne@example.tn", customer.Data.Email} {
+		if rr := post("/api/v1/auth/password/reset/request", "", models.PasswordResetRequest{Email: email}); rr.Code != http.StatusAccepted {
+			t.Fatalf("Reset request for %s: got %v want %v, body %s", email, rr.Code, http.StatusAccepted, rr.Body.String())
+		}
+	}
+	
+	// The token is only delivered through the notification sender
+	resetToken := ""
+	files, _ := filepath.Glob(filepath.Join(testConfig.Notify.OutboxDir, "*.json"))
+	for _, file := range files {
+		content, _ := os.ReadFile(file)
+		var msg struct {
+			To   string `json:"to"`
+			Body string `json:"body"`
+		}
+		if json.Unmarshal(content, &msg) == nil && msg.To == customer.Data.Email {
+			if match := regexp.MustCompile(`new password: (\S+)`).FindStringSubmatch(msg.Body); match != nil {
+				resetToken = match[1]
+			}
+		}
+	}
+	if resetToken == "" {
+		t.Fatal("No password reset token was sent to the customer")
+	}
+	
+	// A refused password leaves the token usable; a used token is refused
+	reset := func(next string) int {
+		return post("/api/v1/auth/password/reset", "", models.ResetPasswordRequest{Token: resetToken, NewPassword: next}).Code
+	}
+	if code := reset("motdepasse123"); code != http.StatusBadRequest {
+		t.Errorf("Reset to a recent password: got %v want %v", code, http.StatusBadRequest)
+	}
+	if code := reset("Encore-un-passe-9"); code != http.StatusOK {
+		t.Fatalf("Resetting the password: got %v want %v", code, http.StatusOK)
+	}
+	if code := reset("Encore-un-autre-9"); code != http.StatusBadRequest {
+		t.Errorf("Reusing a reset token: got %v want %v", code, http.StatusBadRequest)
+	}
+	
+	// A reset logs out every session
+	if code := getCustomer(token); code != http.StatusUnauthorized {
+		t.Errorf("Session after a password reset: got %v want %v", code, http.StatusUnauthorized)
+	}
+	if code := login("Encore-un-passe-9"); code != http.StatusOK {
+		t.Errorf("Login after a password reset: got %v want %v", code, http.StatusOK)
+	}
+}
+
+func TestJWTSigningKeys(t *testing.T) {
+	dir := t.TempDir()
+	writeKey := func(name string, key interface{}) string {
+		var block *pem.Block
+		if rsaKey, ok := key.(*rsa.PrivateKey); ok {
+			block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}
+		} else {
+			der, err := x509.MarshalPKCS8PrivateKey(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+		}
+		path := filepath.Join(dir, name+".pem")
+		if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	newECKey := func() *ecdsa.PrivateKey {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return key
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	
+	// "retired" was replaced two hours ago, past the one hour overlap;
+	// "previous" half an hour ago; "next" is published ahead of its rotation
+	now := time.Now().UTC()
+	at := func(offset time.Duration) string { return now.Add(offset).Format(time.RFC3339) }
+	paths := map[string]string{
+		"retired":  writeKey("retired", newECKey()),
+		"previous": writeKey("previous", rsaKey),
+		"current":  writeKey("current", newECKey()),
+		"next":     writeKey("next", newECKey()),
+	}
+	spec := fmt.Sprintf("retired=%s@%s,previous=%s@%s,current=%s@%s,next=%s@%s",
+		paths["retired"], at(-3*time.Hour), paths["previous"], at(-2*time.Hour),
+		paths["current"], at(-30*time.Minute), paths["next"], at(24*time.Hour))
+	
+	cfg := *testConfig
+	cfg.JWT.SigningKeys = spec
+	cfg.JWT.KeyOverlap = time.Hour
+	cfg.JWT.Audience = "bank-api-test"
+	router, err := routes.NewRouter(testDB, &cfg)
+	if err != nil {
+		t.Fatalf("Failed to create router with signing keys: %v", err)
+	}
+	handler := router.SetupRoutes()
+	
+	// The JWKS lists every key still verifying tokens
+	req, _ := http.NewRequest("GET", "/.well-known/jwks.json", nil)
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("JWKS: got %v want %v", rr.Code, http.StatusOK)
+	}
+	var jwks models.JWKS
+	if err := json.Unmarshal(rr.Body.Bytes(), &jwks); err != nil {
+		t.Fatal("Failed to unmarshal JWKS:", err)
+	}
+	var kids []string
+	for _, key := range jwks.Keys {
+		kids = append(kids, key.Kid+"/"+key.Alg)
+	}
+	if got := strings.Join(kids, ","); got != "previous/RS256,current/ES256,next/ES256" {
+		t.Errorf("JWKS keys: got %s", got)
+	}
+	
+	// Logins are signed with the current key
+	account := createTestAccount(t)
+	jsonData, _ := json.Marshal(models.LoginRequest{AccountNumber: account.AccountNumber, Password: "motdepasse123"})
+	req, _ = http.NewRequest("POST", "/api/v1/auth/login", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	var login struct {
+		Data models.LoginResponse `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &login); err != nil || rr.Code != http.StatusOK {
+		t.Fatalf("Login: status %v, body %s", rr.Code, rr.Body.String())
+	}
+	header, _ := base64.RawURLEncoding.DecodeString(strings.Split(login.Data.Token, ".")[0])
+	if !strings.Contains(string(header), `"kid":"current"`) || !strings.Contains(string(header), `"alg":"ES256"`) {
+		t.Errorf("Token header: got %s", header)
+	}
+	
+	keys, err := utils.LoadJWTKeySet(spec, time.Hour, cfg.JWT.Issuer, cfg.JWT.Audience)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := keys.Verify(login.Data.Token)
+	if err != nil {
+		t.Fatalf("Verifying the token: %v", err)
+	}
+	if claims.Issuer != cfg.JWT.Issuer || len(claims.Audience) != 1 || claims.Audience[0] != cfg.JWT.Audience {
+		t.Errorf("Token issuer and audience: got %s %v", claims.Issuer, claims.Audience)
+	}
+	
+	getAccounts := func(token string) int {
+		req, _ := http.NewRequest("GET", "/api/v1/accounts", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	resign := func(kid, issuer, audience string) string {
+		content, _ := os.ReadFile(paths[kid])
+		key, err := utils.ParseJWTKey(kid, content, time.Time{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		single, err := utils.NewJWTKeySet([]*utils.JWTKey{key}, time.Hour, issuer, audience)
+		if err != nil {
+			t.Fatal(err)
+		}
+		token, err := single.Sign(claims)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	
+	if code := getAccounts(login.Data.Token); code != http.StatusOK {
+		t.Errorf("Token of the current key: got %v want %v", code, http.StatusOK)
+	}
+	if code := getAccounts(resign("previous", cfg.JWT.Issuer, cfg.JWT.Audience)); code != http.StatusOK {
+		t.Errorf("Token of a key within its overlap: got %v want %v", code, http.StatusOK)
+	}
+	if code := getAccounts(resign("retired", cfg.JWT.Issuer, cfg.JWT.Audience)); code != http.StatusUnauthorized {
+		t.Errorf("Token of a rotated out key: got %v want %v", code, http.StatusUnauthorized)
+	}
+	if code := getAccounts(resign("current", "someone-else", cfg.JWT.Audience)); code != http.StatusUnauthorized {
+		t.Errorf("Token of another issuer: got %v want %v", code, http.StatusUnauthorized)
+	}
+	if code := getAccounts(resign("current", cfg.JWT.Issuer, "another-service")); code != http.StatusUnauthorized {
+		t.Errorf("Token for another audience: got %v want %v", code, http.StatusUnauthorized)
+	}
+	
+	// The shared secret no longer signs tokens once keys are configured
+	hmacToken, _ := utils.NewHMACKeySet(cfg.JWT.Secret, cfg.JWT.Issuer, cfg.JWT.Audience).Sign(claims)
+	if code := getAccounts(hmacToken); code != http.StatusUnauthorized {
+		t.Errorf("HS256 token: got %v want %v", code, http.StatusUnauthorized)
+	}
+	
+	// The built-in secret is only accepted in development
+	defaults := config.Config{JWT: config.JWTConfig{Secret: config.DefaultJWTSecret}}
+	if err := defaults.Validate(); err == nil {
+		t.Error("Expected the default JWT secret to be refused outside development")
+	}
+	defaults.Server.Environment = "development"
+	if err := defaults.Validate(); err != nil {
+		t.Errorf("Default JWT secret in development: %v", err)
+	}
+	
+	// So are the notification senders that expose reset tokens
+	for _, sender := range []string{"log", "file"} {
+		unsafe := config.Config{JWT: config.JWTConfig{Secret: "a-real-secret"}, Notify: config.NotifyConfig{Sender: sender}}
+		if err := unsafe.Validate(); err == nil {
+			t.Errorf("Expected NOTIFY_SENDER=%s to be refused outside development", sender)
+		}
+		unsafe.Server.Environment = "development"
+		if err := unsafe.Validate(); err != nil {
+			t.Errorf("NOTIFY_SENDER=%s in development: %v", sender, err)
+		}
+	}
+}
+
+func TestAPIClients(t *testing.T) {
+	handler := testRouter.SetupRoutes()
+	
+	allowed := createTestAccount(t)
+	other := createTestAccount(t)
+	
+	// Only admins register API clients
+	username := fmt.Sprintf("admin%d", time.Now().UnixNano())
+	staffService := services.NewStaffService(repository.NewPostgresStaffRepository(testDB))
+	if _, err := staffService.CreateStaffUser(&models.CreateStaffUserRequest{
+		Username: username,
+		Email:    username + "@bank.tn",
+		FullName: "Admin Test",
+		Role:     models.RoleAdmin,
+		Password: "admin-secret-123",
+	}); err != nil {
+		t.Fatal("Failed to create admin:", err)
+	}
+	
+	jsonData, _ := json.Marshal(models.StaffLoginRequest{Username: username, Password: "admin-secret-123"})
+	req, _ := http.NewRequest("POST", "/api/v1/auth/staff/login", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	var staffLogin struct {
+		Data models.StaffLoginResponse `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &staffLogin); err != nil {
+		t.Fatal("Failed to unmarshal staff login response:", err)
+	}
+	adminToken := staffLogin.Data.Token
+	
+	request := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	tokenRequest := func(clientID, secret, scope, remoteAddr string) *httptest.ResponseRecorder {
+		form := url.Values{"grant_type": {models.GrantTypeClientCredentials}}
+		if scope != "" {
+			form.Set("scope", scope)
+		}
+		req, _ := http.NewRequest("POST", "/api/v1/oauth/token", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(secret))
+		if remoteAddr != "" {
+			req.RemoteAddr = remoteAddr
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	
+	createBody := fmt.Sprintf(`{"name": "ERP Sfax", "scopes": ["%s", "%s", "%s"], "account_numbers": ["%s"]}`,
+		models.ScopeAccountsRead, models.ScopeTransactionsRead, models.ScopeDepositsWrite, allowed.AccountNumber)
+	if rr := request("POST", "/api/v1/api-clients", loginAndGetToken(t, allowed.AccountNumber), createBody); rr.Code != http.StatusForbidden {
+		t.Errorf("Customer registering an API client: got %v want %v", rr.Code, http.StatusForbidden)
+	}
+	
+	rr = request("POST", "/api/v1/api-clients", adminToken, createBody)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("Registering an API client: got %v want %v, body %s", rr.Code, http.StatusCreated, rr.Body.String())
+	}
+	var created struct {
+		Data models.APIClientCredentials `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &created); err != nil {
+		t.Fatal("Failed to unmarshal API client response:", err)
+	}
+	clientID, secret := created.Data.ClientID, created.Data.ClientSecret
+	if clientID == "" || secret == "" {
+		t.Fatalf("API client registration returned no credentials: %s", rr.Body.String())
+	}
+	
+	// Client credentials grant
+	if rr := tokenRequest(clientID, "wrong-secret", "", ""); rr.Code != http.StatusUnauthorized {
+		t.Errorf("Token with a wrong secret: got %v want %v", rr.Code, http.StatusUnauthorized)
+	}
+	if rr := tokenRequest(clientID, secret, models.ScopeTransfersWrite, ""); rr.Code != http.StatusBadRequest {
+		t.Errorf("Token for a scope not granted: got %v want %v", rr.Code, http.StatusBadRequest)
+	}
+	
+	rr = tokenRequest(clientID, secret, "", "")
+	if rr.Code != http.StatusOK {
+		t.Fatalf("Client credentials token: got %v want %v, body %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	var issued models.OAuthTokenResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &issued); err != nil {
+		t.Fatal("Failed to unmarshal token response:", err)
+	}
+	if issued.TokenType != "Bearer" || issued.ExpiresIn <= 0 || len(strings.Fields(issued.Scope)) != 3 {
+		t.Errorf("Token response: %+v", issued)
+	}
+	clientToken := issued.AccessToken
+	
+	// The client acts on its allowed account, within its scopes
+	deposit(t, handler, clientToken, allowed.AccountNumber, 25000)
+	if balance := getBalance(t, handler, clientToken, allowed.AccountNumber); balance != 25000 {
+		t.Errorf("Balance read by the API client: got %d want %d", balance, 25000)
+	}
+	
+	// Retries with the same Idempotency-Key replay the stored response
+	depositBody := fmt.Sprintf(`{"account_number": "%s", "amount": 5000, "currency": "TND"}`, allowed.AccountNumber)
+	idempotencyKey := fmt.Sprintf("erp-deposit-%d", time.Now().UnixNano())
+	sendDeposit := func() *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("POST", "/api/v1/transactions/deposit", bytes.NewBufferString(depositBody))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+clientToken)
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+	first := sendDeposit()
+	if first.Code != http.StatusCreated {
+		t.Fatalf("API client idempotent deposit: got %v want %v, body %s", first.Code, http.StatusCreated, first.Body.String())
+	}
+	retry := sendDeposit()
+	if retry.Code != http.StatusCreated || retry.Header().Get("Idempotent-Replayed") != "true" || retry.Body.String() != first.Body.String() {
+		t.Errorf("API client deposit retry was not replayed: status %v, body %s", retry.Code, retry.Body.String())
+	}
+	if balance := getBalance(t, handler, clientToken, allowed.AccountNumber); balance != 30000 {
+		t.Errorf("API client deposit was applied more than once: balance %d want %d", balance, 30000)
+	}
+	
+	if rr := request("GET", "/api/v1/transactions/history?account_number="+allowed.AccountNumber, clientToken, ""); rr.Code != http.StatusOK {
+		t.Errorf("API client reading history: got %v want %v, body %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if rr := request("GET", "/api/v1/accounts/"+other.AccountNumber+"/balance", clientToken, ""); rr.Code != http.StatusForbidden {
+		t.Errorf("API client reading an account not allowed: got %v want %v", rr.Code, http.StatusForbidden)
+	}
+	
+	rr = request("POST", "/api/v1/transactions/transfer", clientToken, fmt.Sprintf(`{"from_account_number": "%s", "to_account_number": "%s", "amount": 1000, "currency": "TND"}`, allowed.AccountNumber, other.AccountNumber))
+	var refused models.ErrorResponse
+	json.Unmarshal(rr.Body.Bytes(), &refused)
+	if rr.Code != http.StatusForbidden || refused.Code != models.ErrCodeInsufficientScope {
+		t.Errorf("API client transferring without the scope: got %v %q want %v %q", rr.Code, refused.Code, http.StatusForbidden, models.ErrCodeInsufficientScope)
+	}
+	if rr := request("GET", "/api/v1/customers/"+allowed.CustomerID, clientToken, ""); rr.Code != http.StatusForbidden {
+		t.Errorf("API client reading a customer: got %v want %v", rr.Code, http.StatusForbidden)
+	}
+	
+	// Narrowing the IP allowlist shuts out other addresses, for new and issued tokens alike
+	if rr := request("PATCH", "/api/v1/api-clients/"+clientID, adminToken, `{"allowed_ips": ["10.20.0.0/16"]}`); rr.Code != http.StatusOK {
+		t.Fatalf("Updating the IP allowlist: got %v want %v, body %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if rr := tokenRequest(clientID, secret, "", "192.0.2.10:4000"); rr.Code != http.StatusUnauthorized {
+		t.Errorf("Token from an address not allowed: got %v want %v", rr.Code, http.StatusUnauthorized)
+	}
+	if rr := request("GET", "/api/v1/accounts/"+allowed.AccountNumber+"/balance", clientToken, ""); rr.Code != http.StatusForbidden {
+		t.Errorf("Issued token from an address not allowed: got %v want %v", rr.Code, http.StatusForbidden)
+	}
+	if rr := tokenRequest(clientID, secret, "", "10.20.3.4:4000"); rr.Code != http.StatusOK {
+		t.Fatalf("Token from an allowed address: got %v want %v, body %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	
+	// Rotating the secret retires the old secret and its tokens
+	if rr := request("POST", "/api/v1/api-clients/"+clientID+"/secret", adminToken, ""); rr.Code != http.StatusOK {
+		t.Fatalf("Rotating the secret: got %v want %v, body %s", rr.Code, http.StatusOK, rr.Body.String())
+	}
+	if rr := tokenRequest(clientID, secret, "", "10.20.3.4:4000"); rr.Code != http.StatusUnauthorized {
+		t.Errorf("Token with the rotated secret: got %v want %v", rr.Code, http.StatusUnauthorized)
+	}
+	req, _ = http.NewRequest("GET", "/api/v1/accounts/"+allowed.AccountNumber+"/balance", nil)
+	req.Header.Set("Authorization", "Bearer "+clientToken)
+	req.RemoteAddr = "10.20.3.4:4000"
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Token issued before the rotation: got %v want %v", rr.Code, http.StatusUnauthorized)
+	}
+}
+
+// Helper functions
+
+func deposit(t *testing.T, handler http.Handler, token, accountNumber string, amount int64) {
+	jsonData, _ := json.Marshal(models.DepositRequest{
+		AccountNumber: accountNumber,
+		Amount:        amount,
+		Currency:      models.CurrencyTND,
+	})
+	req, err := http.NewRequest("POST", "/api/v1/transactions/deposit", bytes.NewBuffer(jsonData))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	
+	if status := rr.Code; status != http.StatusCreated {
+		t.Fatalf("Deposit failed: status %v, body %s", status, rr.Body.String())
+	}
+}
+
+func transfer(handler http.Handler, token, from, to string, amount int64) int {
+	return postTransfer(handler, token, models.TransferRequest{
+		FromAccountNumber: from,
+		ToAccountNumber:   to,
+		Amount:            amount,
+		Currency:          models.CurrencyTND,
+	})
+}
+
+func postTransfer(handler http.Handler, token string, transferReq models.TransferRequest) int {
+	jsonData, _ := json.Marshal(transferReq)
+	req, _ := http.NewRequest("POST", "/api/v1/transactions/transfer", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr.Code
+}
+
+func postClearingCallback(handler http.Handler, body []byte, secret string) int {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	
+	req, _ := http.NewRequest("POST", "/api/v1/clearing/callbacks", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(handlers.ClearingSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	return rr.Code
+}
+
+func getBalance(t *testing.T, handler http.Handler, token, accountNumber string) int64 {
+	return getBalanceResponse(t, handler, token, accountNumber).Balance
+}
+
+func getBalanceResponse(t *testing.T, handler http.Handler, token, accountNumber string) models.BalanceResponse {
+	req, err := http.NewRequest("GET", "/api/v1/accounts/"+accountNumber+"/balance", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	
+	if status := rr.Code; status != http.StatusOK {
+		t.Fatalf("Get balance failed: status %v, body %s", status, rr.Body.String())
+	}
+	
+	var response struct {
+		Data models.BalanceResponse `json:"data"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+		t.Fatal("Failed to unmarshal balance response:", err)
+	}
+	
+	return response.Data
+}
+
+
+// newTransactionService builds a transaction service for driving background
+// work directly
+func newTransactionService() services.TransactionService {
+	fxQuoteRepo := repository.NewPostgresFXQuoteRepository(testDB)
+	bank, _ := models.LookupBankByCode(testConfig.Bank.Code)
+	return services.NewTransactionService(
+		repository.NewPostgresTransactionRepository(testDB), repository.NewPostgresAccountRepository(testDB),
+		ledger.NewPostgresLedger(testDB), repository.NewPostgresTxRunner(testDB),
+		services.NewFXService(services.NewStaticRateProvider(nil), fxQuoteRepo, time.Minute, 0, 0),
+		fxQuoteRepo, repository.NewPostgresOutboundPaymentRepository(testDB),
+		repository.NewPostgresFeeScheduleRepository(testDB), repository.NewPostgresLimitRepository(testDB), bank,
+	)
+}
 
 func createTestAccount(t *testing.T) *models.Account {
 	createAccountReq := models.CreateAccountRequest{